@@ -0,0 +1,588 @@
+package builtins
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+)
+
+// AnsibleFilters holds filters of Ansible which are not part of Filters
+var AnsibleFilters = exec.NewFilterSet(map[string]exec.FilterFunction{
+	"b64decode":     filterB64decode,
+	"b64encode":     filterB64encode,
+	"from_json":     filterFromJSON,
+	"ipaddr":        filterIpaddr,
+	"ipv4":          filterIpv4,
+	"ipv6":          filterIpv6,
+	"regex_escape":  filterRegexEscape,
+	"regex_findall": filterRegexFindall,
+	"regex_replace": filterRegexReplace,
+	"regex_search":  filterRegexSearch,
+	"to_json":       filterToPythonJSON,
+	"to_nice_json":  filterToNiceJSON,
+	"type_debug":    filterAnsibleTypeDebug,
+})
+
+// AnsibleTests holds tests of Ansible which are not part of Tests
+var AnsibleTests = exec.NewTestSet(map[string]exec.TestFunction{
+	"subset":          testSubset,
+	"superset":        testSuperset,
+	"version":         testVersion,
+	"version_compare": testVersion,
+})
+
+// AnsibleEnvironment returns an environment made of the builtins along with the filters and tests of
+// AnsibleFilters and AnsibleTests, so that templates written for Ansible playbooks render the same.
+func AnsibleEnvironment() *exec.Environment {
+	builtins := &exec.Environment{
+		Context:           exec.EmptyContext().Update(GlobalFunctions).Update(GlobalVariables),
+		Filters:           Filters,
+		Tests:             Tests,
+		ControlStructures: ControlStructures,
+		Methods:           Methods,
+	}
+	ansible := builtins.Overlay()
+	ansible.Filters.Update(AnsibleFilters)
+	ansible.Tests.Update(AnsibleTests)
+	return ansible
+}
+
+// filterAnsibleTypeDebug returns the name python gives to the type of a value
+func filterAnsibleTypeDebug(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'type_debug': %w", p))
+	}
+	switch {
+	case in.IsNil():
+		return exec.AsValue("NoneType")
+	case in.IsBool():
+		return exec.AsValue("bool")
+	case in.IsInteger():
+		return exec.AsValue("int")
+	case in.IsFloat():
+		return exec.AsValue("float")
+	case in.IsString():
+		return exec.AsValue("str")
+	case in.IsList():
+		return exec.AsValue("list")
+	case in.IsDict():
+		return exec.AsValue("dict")
+	}
+	return exec.AsValue(reflect.TypeOf(in.Interface()).String())
+}
+
+func filterB64encode(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'b64encode': %w", p))
+	}
+	return exec.AsValue(base64.StdEncoding.EncodeToString([]byte(in.String())))
+}
+
+func filterB64decode(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'b64decode': %w", p))
+	}
+	decoded, err := base64.StdEncoding.DecodeString(in.String())
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not base64 encoded: %w", in.String(), err)))
+	}
+	return exec.AsValue(string(decoded))
+}
+
+func filterFromJSON(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'from_json': %w", p))
+	}
+	var decoded interface{}
+	decoder := json.NewDecoder(strings.NewReader(in.String()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not valid JSON: %w", in.String(), err)))
+	}
+	return exec.AsValue(fromJSONNumbers(decoded))
+}
+
+// fromJSONNumbers turns the numbers of decoded JSON documents into integers when they have no fractional part
+func fromJSONNumbers(decoded interface{}) interface{} {
+	switch v := decoded.(type) {
+	case json.Number:
+		if integer, err := v.Int64(); err == nil {
+			return int(integer)
+		}
+		float, _ := v.Float64()
+		return float
+	case []interface{}:
+		for index, item := range v {
+			v[index] = fromJSONNumbers(item)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = fromJSONNumbers(item)
+		}
+	}
+	return decoded
+}
+
+func filterToPythonJSON(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var indent int
+	if err := params.Take(
+		exec.KeywordArgument("indent", exec.AsValue(0), exec.IntArgument(&indent)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return toPythonJSON(in, indent)
+}
+
+func filterToNiceJSON(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var indent int
+	if err := params.Take(
+		exec.KeywordArgument("indent", exec.AsValue(4), exec.IntArgument(&indent)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return toPythonJSON(in, indent)
+}
+
+// toPythonJSON encodes a value as python's json.dumps does, with sorted keys
+func toPythonJSON(in *exec.Value, indent int) *exec.Value {
+	casted := in.ToGoSimpleType(false)
+	if err, ok := casted.(error); ok {
+		return exec.AsValue(err)
+	}
+	var out strings.Builder
+	if err := writePythonJSON(&out, casted, indent, 0); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return exec.AsValue(out.String())
+}
+
+func writePythonJSON(out *strings.Builder, value interface{}, indent, depth int) error {
+	// python separates items with ', ' unless indenting, in which case lines end with a bare ','
+	separator, newline := ", ", ""
+	if indent > 0 {
+		separator, newline = ",", "\n"+strings.Repeat(" ", indent*(depth+1))
+	}
+	closing := ""
+	if indent > 0 {
+		closing = "\n" + strings.Repeat(" ", indent*depth)
+	}
+	switch v := value.(type) {
+	case nil:
+		out.WriteString("null")
+	case bool:
+		out.WriteString(strconv.FormatBool(v))
+	case int:
+		out.WriteString(strconv.Itoa(v))
+	case float64:
+		out.WriteString(pythonFloat(v))
+	case string:
+		out.WriteString(pythonJSONString(v))
+	case []interface{}:
+		if len(v) == 0 {
+			out.WriteString("[]")
+			return nil
+		}
+		out.WriteByte('[')
+		for index, item := range v {
+			if index > 0 {
+				out.WriteString(separator)
+			}
+			out.WriteString(newline)
+			if err := writePythonJSON(out, item, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		out.WriteString(closing + "]")
+	case map[string]interface{}:
+		if len(v) == 0 {
+			out.WriteString("{}")
+			return nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		out.WriteByte('{')
+		for index, key := range keys {
+			if index > 0 {
+				out.WriteString(separator)
+			}
+			out.WriteString(newline + pythonJSONString(key) + ": ")
+			if err := writePythonJSON(out, v[key], indent, depth+1); err != nil {
+				return err
+			}
+		}
+		out.WriteString(closing + "}")
+	default:
+		return fmt.Errorf("unable to encode %v to JSON", value)
+	}
+	return nil
+}
+
+// pythonFloat formats a float as python's repr does for the usual magnitudes
+func pythonFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	formatted := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(formatted, ".") {
+		formatted += ".0"
+	}
+	return formatted
+}
+
+// pythonJSONString quotes a string escaping non ASCII characters, as python's json.dumps does by default
+func pythonJSONString(s string) string {
+	var out strings.Builder
+	out.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			out.WriteString(`\"`)
+		case r == '\\':
+			out.WriteString(`\\`)
+		case r == '\n':
+			out.WriteString(`\n`)
+		case r == '\r':
+			out.WriteString(`\r`)
+		case r == '\t':
+			out.WriteString(`\t`)
+		case r == '\b':
+			out.WriteString(`\b`)
+		case r == '\f':
+			out.WriteString(`\f`)
+		case r < 0x20 || r >= 0x7f && r <= 0xffff:
+			fmt.Fprintf(&out, `\u%04x`, r)
+		case r > 0xffff:
+			r -= 0x10000
+			fmt.Fprintf(&out, `\u%04x\u%04x`, 0xd800+(r>>10), 0xdc00+(r&0x3ff))
+		default:
+			out.WriteRune(r)
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
+// takeRegexFlags removes the ignorecase and multiline keyword arguments of Ansible's regex filters from
+// the arguments, so that the remaining ones can be taken separately
+func takeRegexFlags(params *exec.VarArgs) *exec.VarArgs {
+	flags := &exec.VarArgs{KwArgs: map[string]*exec.Value{}}
+	for _, name := range []string{"ignorecase", "multiline"} {
+		if value, ok := params.KwArgs[name]; ok {
+			flags.KwArgs[name] = value
+			delete(params.KwArgs, name)
+		}
+	}
+	return flags
+}
+
+// compileAnsibleRegex compiles a pattern along with the flags taken out of the arguments of a regex filter
+func compileAnsibleRegex(pattern string, flags *exec.VarArgs) (*regexp.Regexp, error) {
+	var ignoreCase, multiline bool
+	if err := flags.Take(
+		exec.KeywordArgument("ignorecase", exec.AsValue(false), exec.BoolArgument(&ignoreCase)),
+		exec.KeywordArgument("multiline", exec.AsValue(false), exec.BoolArgument(&multiline)),
+	); err != nil {
+		return nil, err
+	}
+	prefix := ""
+	if ignoreCase {
+		prefix += "i"
+	}
+	if multiline {
+		prefix += "m"
+	}
+	if prefix != "" {
+		pattern = "(?" + prefix + ")" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+var pythonGroupReference = regexp.MustCompile(`\\(\d+)|\\g<(\w+)>|\$`)
+
+// pythonReplacement converts the group references of a python replacement string, such as \1 or
+// \g<name>, into the ones of go regular expressions
+func pythonReplacement(replacement string) string {
+	return pythonGroupReference.ReplaceAllStringFunc(replacement, func(reference string) string {
+		if reference == "$" {
+			return "$$"
+		}
+		match := pythonGroupReference.FindStringSubmatch(reference)
+		if match[1] != "" {
+			return "${" + match[1] + "}"
+		}
+		return "${" + match[2] + "}"
+	})
+}
+
+func filterRegexReplace(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var (
+		pattern     string
+		replacement string
+		count       int
+	)
+	flags := takeRegexFlags(params)
+	if err := params.Take(
+		exec.PositionalArgument("pattern", nil, exec.StringArgument(&pattern)),
+		exec.PositionalArgument("replacement", exec.AsValue(""), exec.StringArgument(&replacement)),
+		exec.KeywordArgument("count", exec.AsValue(0), exec.IntArgument(&count)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	expression, err := compileAnsibleRegex(pattern, flags)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	source, template := in.String(), pythonReplacement(replacement)
+	var out []byte
+	last := 0
+	for index, match := range expression.FindAllStringSubmatchIndex(source, -1) {
+		if count > 0 && index >= count {
+			break
+		}
+		out = append(out, source[last:match[0]]...)
+		out = expression.ExpandString(out, template, source, match)
+		last = match[1]
+	}
+	return exec.AsValue(string(append(out, source[last:]...)))
+}
+
+func filterRegexSearch(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if len(params.Args) == 0 {
+		return exec.AsValue(exec.ErrInvalidCall(errors.New("missing required 1st positional argument 'pattern'")))
+	}
+	flags := takeRegexFlags(params)
+	if len(params.KwArgs) > 0 {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("received %d unexpected keyword arguments", len(params.KwArgs))))
+	}
+	expression, err := compileAnsibleRegex(params.Args[0].String(), flags)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	match := expression.FindStringSubmatch(in.String())
+	if match == nil {
+		return exec.AsValue(nil)
+	}
+	if len(params.Args) == 1 {
+		return exec.AsValue(match[0])
+	}
+	groups := make([]interface{}, 0, len(params.Args)-1)
+	for _, reference := range params.Args[1:] {
+		index, err := groupIndex(expression, reference.String())
+		if err != nil {
+			return exec.AsValue(exec.ErrInvalidCall(err))
+		}
+		groups = append(groups, match[index])
+	}
+	return exec.AsValue(groups)
+}
+
+// groupIndex returns the index of the group referenced as \1 or \g<name> by regex_search
+func groupIndex(expression *regexp.Regexp, reference string) (int, error) {
+	match := pythonGroupReference.FindStringSubmatch(reference)
+	if match == nil || match[0] != reference || reference == "$" {
+		return 0, fmt.Errorf("unknown group reference '%s'", reference)
+	}
+	index := expression.SubexpIndex(match[2])
+	if match[1] != "" {
+		index, _ = strconv.Atoi(match[1])
+	}
+	if index < 0 || index > expression.NumSubexp() {
+		return 0, fmt.Errorf("unknown group reference '%s'", reference)
+	}
+	return index, nil
+}
+
+func filterRegexFindall(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var pattern string
+	flags := takeRegexFlags(params)
+	if err := params.Take(
+		exec.PositionalArgument("pattern", nil, exec.StringArgument(&pattern)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	expression, err := compileAnsibleRegex(pattern, flags)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	matches := expression.FindAllStringSubmatch(in.String(), -1)
+	found := make([]interface{}, 0, len(matches))
+	for _, match := range matches {
+		switch len(match) {
+		case 1:
+			found = append(found, match[0])
+		case 2:
+			found = append(found, match[1])
+		default:
+			groups := make([]interface{}, 0, len(match)-1)
+			for _, group := range match[1:] {
+				groups = append(groups, group)
+			}
+			found = append(found, groups)
+		}
+	}
+	return exec.AsValue(found)
+}
+
+// pythonSpecialCharacters are the characters escaped by python's re.escape
+const pythonSpecialCharacters = "()[]{}?*+-|^$\\.&~# \t\n\r\v\f"
+
+func filterRegexEscape(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'regex_escape': %w", p))
+	}
+	var out strings.Builder
+	for _, r := range in.String() {
+		if r < utf8.RuneSelf && strings.ContainsRune(pythonSpecialCharacters, r) {
+			out.WriteByte('\\')
+		}
+		out.WriteRune(r)
+	}
+	return exec.AsValue(out.String())
+}
+
+func testSubset(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
+	var other interface{}
+	if err := params.Take(
+		exec.PositionalArgument("b", nil, exec.AnyArgument(&other)),
+	); err != nil {
+		return false, exec.ErrInvalidCall(err)
+	}
+	return isSubset(in, exec.AsValue(other))
+}
+
+func testSuperset(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
+	var other interface{}
+	if err := params.Take(
+		exec.PositionalArgument("b", nil, exec.AnyArgument(&other)),
+	); err != nil {
+		return false, exec.ErrInvalidCall(err)
+	}
+	return isSubset(exec.AsValue(other), in)
+}
+
+// isSubset tells whether every item of a list is also an item of another one
+func isSubset(subset, superset *exec.Value) (bool, error) {
+	for _, list := range []*exec.Value{subset, superset} {
+		if !list.IsList() {
+			return false, exec.ErrInvalidCall(fmt.Errorf("%s is not a list", list.String()))
+		}
+	}
+	contained := true
+	subset.Iterate(func(_, _ int, item, _ *exec.Value) bool {
+		contained = superset.Contains(item)
+		return contained
+	}, func() {})
+	return contained, nil
+}
+
+// versionOperators maps the operators accepted by the version test to the signs of the comparisons they accept
+var versionOperators = map[string][]int{
+	"==": {0}, "=": {0}, "eq": {0},
+	"!=": {-1, 1}, "<>": {-1, 1}, "ne": {-1, 1},
+	"<": {-1}, "lt": {-1},
+	"<=": {-1, 0}, "le": {-1, 0},
+	">": {1}, "gt": {1},
+	">=": {0, 1}, "ge": {0, 1},
+}
+
+func testVersion(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
+	var (
+		version  string
+		operator string
+	)
+	if err := params.Take(
+		exec.PositionalArgument("version", nil, exec.StringArgument(&version)),
+		exec.KeywordArgument("operator", exec.AsValue("eq"), exec.StringArgument(&operator)),
+	); err != nil {
+		return false, exec.ErrInvalidCall(err)
+	}
+	signs, ok := versionOperators[operator]
+	if !ok {
+		return false, exec.ErrInvalidCall(fmt.Errorf("unknown operator '%s'", operator))
+	}
+	comparison := compareVersions(in.String(), version)
+	for _, sign := range signs {
+		if comparison == sign {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var versionComponent = regexp.MustCompile(`\d+|[a-zA-Z]+`)
+
+// compareVersions compares loose versions such as 1.10.2 or 2.0rc1 component by component, numbers being
+// compared numerically and other components alphabetically
+func compareVersions(left, right string) int {
+	leftComponents := versionComponent.FindAllString(left, -1)
+	rightComponents := versionComponent.FindAllString(right, -1)
+	for index := 0; index < len(leftComponents) && index < len(rightComponents); index++ {
+		l, r := leftComponents[index], rightComponents[index]
+		leftNumber, leftErr := strconv.Atoi(l)
+		rightNumber, rightErr := strconv.Atoi(r)
+		switch {
+		case leftErr == nil && rightErr == nil && leftNumber != rightNumber:
+			if leftNumber < rightNumber {
+				return -1
+			}
+			return 1
+		case (leftErr != nil || rightErr != nil) && l != r:
+			return strings.Compare(l, r)
+		}
+	}
+	switch {
+	case len(leftComponents) < len(rightComponents):
+		return -1
+	case len(leftComponents) > len(rightComponents):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package builtins
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+)
+
+// getCollator returns the collator ordering strings for the given locale, or for the Collation option of
+// the configuration when it is empty. It returns nil when neither is set.
+func getCollator(e *exec.Evaluator, locale string, caseSensitive bool) (*collate.Collator, error) {
+	if locale == "" && e.Config != nil {
+		locale = e.Config.Collation
+	}
+	if locale == "" {
+		return nil, nil
+	}
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return nil, fmt.Errorf("unknown locale '%s'", locale)
+	}
+	if caseSensitive {
+		return collate.New(tag), nil
+	}
+	return collate.New(tag, collate.IgnoreCase), nil
+}
+
+// collatedLess orders strings with the collator and other values as the sort filter does
+func collatedLess(collator *collate.Collator, a, b *exec.Value) bool {
+	if a.IsString() && b.IsString() {
+		return collator.CompareString(a.String(), b.String()) < 0
+	}
+	return exec.ValuesList{a, b}.Less(0, 1)
+}
+
+// collateValues sorts the values with the collator, keeping the order of the values it considers equal
+func collateValues(collator *collate.Collator, values []*exec.Value, reverse bool) {
+	sort.SliceStable(values, func(i, j int) bool {
+		if reverse {
+			return collatedLess(collator, values[j], values[i])
+		}
+		return collatedLess(collator, values[i], values[j])
+	})
+}
+
+// collatePairs sorts the pairs by key or by value with the collator
+func collatePairs(collator *collate.Collator, pairs []*exec.Pair, byValue bool, reverse bool) {
+	sort.SliceStable(pairs, func(i, j int) bool {
+		a, b := pairs[i].Key, pairs[j].Key
+		if byValue {
+			a, b = pairs[i].Value, pairs[j].Value
+		}
+		if reverse {
+			return collatedLess(collator, b, a)
+		}
+		return collatedLess(collator, a, b)
+	})
+}
@@ -0,0 +1,83 @@
+package controlStructures
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/parser"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+)
+
+// AbortControlStructure stops the rendering with an exec.AbortError carrying a code, such as an HTTP status
+// code, and a message defaulting to the text of the HTTP status
+type AbortControlStructure struct {
+	location *tokens.Token
+	code     nodes.Expression
+	message  nodes.Expression
+}
+
+func (controlStructure *AbortControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+func (controlStructure *AbortControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("AbortControlStructure(Line=%d Col=%d)", t.Line, t.Col)
+}
+
+func (controlStructure *AbortControlStructure) Children() []nodes.Node {
+	children := []nodes.Node{controlStructure.code}
+	if controlStructure.message != nil {
+		children = append(children, controlStructure.message)
+	}
+	return children
+}
+
+func (controlStructure *AbortControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	code := r.Eval(controlStructure.code)
+	if code.IsError() {
+		return fmt.Errorf(`unable to evaluate code %s: %w`, controlStructure.code, code)
+	}
+	if !code.IsInteger() {
+		return fmt.Errorf(`abort code must be an integer, got %s`, code.String())
+	}
+	message := http.StatusText(code.Integer())
+	if controlStructure.message != nil {
+		value := r.Eval(controlStructure.message)
+		if value.IsError() {
+			return fmt.Errorf(`unable to evaluate message %s: %w`, controlStructure.message, value)
+		}
+		message = value.String()
+	}
+	return r.Abort(tag, code.Integer(), message)
+}
+
+func abortParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+	controlStructure := &AbortControlStructure{
+		location: p.Current(),
+	}
+
+	if args.End() {
+		return nil, args.Error("Tag 'abort' requires a code.", nil)
+	}
+	code, err := args.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	controlStructure.code = code
+
+	if args.Match(tokens.Comma) != nil {
+		message, err := args.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		controlStructure.message = message
+	}
+
+	if !args.End() {
+		return nil, args.Error("Malformed 'abort' tag args.", args.Current())
+	}
+
+	return controlStructure, nil
+}
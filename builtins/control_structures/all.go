@@ -1,22 +1,46 @@
 package controlStructures
 
 import (
+	"fmt"
+
 	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/parser"
 )
 
-var All = exec.NewControlStructureSet(map[string]parser.ControlStructureParser{
-	"autoescape": autoescapeParser,
-	"block":      blockParser,
-	"extends":    extendsParser,
-	"filter":     filterParser,
-	"for":        forParser,
-	"from":       fromParser,
-	"if":         ifParser,
-	"import":     importParser,
-	"include":    includeParser,
-	"macro":      macroParser,
-	"raw":        rawParser,
-	"set":        setParser,
-	"with":       withParser,
+var All = withAliases(exec.NewControlStructureSet(map[string]parser.ControlStructureParser{
+	"abort":          abortParser,
+	"assert":         assertParser,
+	"autoescape":     autoescapeParser,
+	"block":          blockParser,
+	"break":          breakParser,
+	"comment":        commentParser,
+	"continue":       continueParser,
+	"extends":        extendsParser,
+	"filter":         filterParser,
+	"for":            forParser,
+	"from":           fromParser,
+	"if":             ifParser,
+	"import":         importParser,
+	"include":        includeParser,
+	"include_static": includeStaticParser,
+	"macro":          macroParser,
+	"raw":            rawParser,
+	"set":            setParser,
+	"spaceless":      spacelessParser,
+	"with":           withParser,
+}), map[string]string{
+	// verbatim is the name of the raw statement in Django templates
+	"verbatim":    "raw",
+	"endverbatim": "endraw",
 })
+
+// withAliases registers the given aliases on the built-in control structures, which can only fail if they
+// are inconsistent with the statements of the set
+func withAliases(set *exec.ControlStructureSet, aliases map[string]string) *exec.ControlStructureSet {
+	for alias, name := range aliases {
+		if err := set.Alias(alias, name); err != nil {
+			panic(fmt.Sprintf("invalid built-in control structure alias: %s", err))
+		}
+	}
+	return set
+}
@@ -0,0 +1,77 @@
+package controlStructures
+
+import (
+	"fmt"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/parser"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+)
+
+type AssertControlStructure struct {
+	location  *tokens.Token
+	condition nodes.Expression
+	message   nodes.Expression
+}
+
+func (controlStructure *AssertControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+func (controlStructure *AssertControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("AssertControlStructure(Line=%d Col=%d)", t.Line, t.Col)
+}
+
+func (controlStructure *AssertControlStructure) Children() []nodes.Node {
+	children := []nodes.Node{controlStructure.condition}
+	if controlStructure.message != nil {
+		children = append(children, controlStructure.message)
+	}
+	return children
+}
+
+func (controlStructure *AssertControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	condition := r.Eval(controlStructure.condition)
+	if condition.IsError() {
+		return fmt.Errorf(`unable to evaluate condition %s: %w`, controlStructure.condition, condition)
+	}
+	if condition.Truthy(r.Config) {
+		return nil
+	}
+	message := fmt.Sprintf("assertion failed: %s", controlStructure.condition)
+	if controlStructure.message != nil {
+		value := r.Eval(controlStructure.message)
+		if value.IsError() {
+			return fmt.Errorf(`unable to evaluate message %s: %w`, controlStructure.message, value)
+		}
+		message = value.String()
+	}
+	return r.Fail(tag, message)
+}
+
+func assertParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+	controlStructure := &AssertControlStructure{
+		location: p.Current(),
+	}
+
+	condition, err := args.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	controlStructure.condition = condition
+
+	if args.Match(tokens.Comma) != nil {
+		message, err := args.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		controlStructure.message = message
+	}
+
+	if !args.End() {
+		return nil, args.Error("Malformed 'assert' tag args.", args.Current())
+	}
+
+	return controlStructure, nil
+}
@@ -22,6 +22,10 @@ func (controlStructure *AutoescapeControlStructure) String() string {
 	return fmt.Sprintf("AutoescapeControlStructure(Line=%d Col=%d)", t.Line, t.Col)
 }
 
+func (controlStructure *AutoescapeControlStructure) Children() []nodes.Node {
+	return []nodes.Node{controlStructure.Wrapper}
+}
+
 func (controlStructure *AutoescapeControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
 	sub := r.Inherit()
 	sub.Config.AutoEscape = controlStructure.Autoescape
@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/pkg/errors"
-
 	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/nodes"
 	"github.com/nikolalohinski/gonja/v2/parser"
@@ -15,6 +13,7 @@ import (
 type BlockControlStructure struct {
 	location *tokens.Token
 	name     string
+	wrapper  *nodes.Wrapper
 }
 
 func (controlStructure *BlockControlStructure) Position() *tokens.Token {
@@ -25,14 +24,28 @@ func (controlStructure *BlockControlStructure) String() string {
 	return fmt.Sprintf("BlockControlStructure(Line=%d Col=%d)", t.Line, t.Col)
 }
 
+func (controlStructure *BlockControlStructure) Children() []nodes.Node {
+	return []nodes.Node{controlStructure.wrapper}
+}
+
 func (controlStructure *BlockControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
 	blocks := r.RootNode.GetBlocks(controlStructure.name)
+
+	if override := r.BlockOverride(controlStructure.name); override != nil {
+		sub := r.Inherit()
+		infos := &BlockInfos{Block: controlStructure, Renderer: sub, Blocks: blocks}
+		sub.Environment.Context.Set("self", exec.Self(sub))
+		return override(sub, infos.super)
+	}
+
 	block, blocks := blocks[0], blocks[1:]
 
 	if block == nil {
-		return errors.Errorf(`Unable to find block "%s"`, controlStructure.name)
+		return fmt.Errorf(`Unable to find block "%s"`, controlStructure.name)
 	}
 
+	r.TraceBlock(controlStructure.name, block, controlStructure.wrapper)
+
 	sub := r.Inherit()
 	infos := &BlockInfos{Block: controlStructure, Renderer: sub, Blocks: blocks}
 
@@ -54,9 +67,9 @@ type BlockInfos struct {
 	Root     *nodes.Template
 }
 
-func (bi *BlockInfos) super() string {
+func (bi *BlockInfos) super() (string, error) {
 	if len(bi.Blocks) <= 0 {
-		return ""
+		return "", nil
 	}
 	r := bi.Renderer
 	block, blocks := bi.Blocks[0], bi.Blocks[1:]
@@ -70,8 +83,10 @@ func (bi *BlockInfos) super() string {
 	}
 	sub.Environment.Context.Set("self", exec.Self(sub))
 	sub.Environment.Context.Set("super", infos.super)
-	sub.ExecuteWrapper(block)
-	return out.String()
+	if err := sub.ExecuteWrapper(block); err != nil {
+		return "", err
+	}
+	return out.String(), nil
 }
 
 func blockParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
@@ -79,19 +94,22 @@ func blockParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure,
 		location: p.Current(),
 	}
 	if args.End() {
-		return nil, errors.New("Tag 'block' requires an identifier.")
+		return nil, args.Error("Tag 'block' requires an identifier.", nil)
 	}
 
 	name := args.Match(tokens.Name)
 	if name == nil {
-		return nil, errors.New("First argument for tag 'block' must be an identifier.")
+		return nil, args.Error("First argument for tag 'block' must be an identifier.", nil)
 	}
 
 	if !args.End() {
-		return nil, errors.New("Tag 'block' takes exactly 1 argument (an identifier).")
+		return nil, args.Error("Tag 'block' takes exactly 1 argument (an identifier).", nil)
 	}
 
+	// blocks can be rendered apart from the loops holding them, with self or by the templates extending them
+	restore := p.LeaveLoops()
 	wrapper, endargs, err := p.WrapUntil("endblock")
+	restore()
 	if err != nil {
 		return nil, err
 	}
@@ -99,13 +117,13 @@ func blockParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure,
 		endName := endargs.Match(tokens.Name)
 		if endName != nil {
 			if endName.Val != endName.Val {
-				return nil, errors.Errorf(`Name for 'endblock' must equal to 'block'-tag's name ('%s' != '%s').`,
-					name.Val, endName.Val)
+				return nil, endargs.Error(fmt.Sprintf(`Name for 'endblock' must equal to 'block'-tag's name ('%s' != '%s').`,
+					name.Val, endName.Val), nil)
 			}
 		}
 
 		if endName == nil || !endargs.End() {
-			return nil, errors.New("Either no or only one argument (identifier) allowed for 'endblock'.")
+			return nil, endargs.Error("Either no or only one argument (identifier) allowed for 'endblock'.", nil)
 		}
 	}
 
@@ -116,5 +134,6 @@ func blockParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure,
 	}
 
 	block.name = name.Val
+	block.wrapper = wrapper
 	return block, nil
 }
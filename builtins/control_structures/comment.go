@@ -0,0 +1,58 @@
+package controlStructures
+
+import (
+	"fmt"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/parser"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+)
+
+// CommentControlStructure is a block commented out, along with an optional note explaining why. Its body
+// is neither lexed nor parsed, so that it does not need to be valid template syntax.
+type CommentControlStructure struct {
+	location *tokens.Token
+	note     string
+}
+
+func (controlStructure *CommentControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+func (controlStructure *CommentControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("CommentControlStructure(Line=%d Col=%d)", t.Line, t.Col)
+}
+
+func (controlStructure *CommentControlStructure) Children() []nodes.Node {
+	return []nodes.Node{}
+}
+
+// Note returns the note given to the comment, if any
+func (controlStructure *CommentControlStructure) Note() string {
+	return controlStructure.note
+}
+
+func (controlStructure *CommentControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	return nil
+}
+
+func commentParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+	controlStructure := &CommentControlStructure{
+		location: p.Current(),
+	}
+
+	if _, _, err := p.WrapUntil("endcomment"); err != nil {
+		return nil, err
+	}
+
+	if note := args.Match(tokens.String); note != nil {
+		controlStructure.note = note.Val
+	}
+
+	if !args.End() {
+		return nil, args.Error("Tag 'comment' only accepts an optional note string.", args.Current())
+	}
+
+	return controlStructure, nil
+}
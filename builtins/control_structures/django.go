@@ -0,0 +1,271 @@
+package controlStructures
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/parser"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+	u "github.com/nikolalohinski/gonja/v2/utils"
+)
+
+// URLResolver builds the URL of a view for the url statement of Django templates, as Django's reverse does
+type URLResolver interface {
+	ResolveURL(name string, args []interface{}, kwargs map[string]interface{}) (string, error)
+}
+
+// StaticResolver builds the URL of a static file for the static statement of Django templates
+type StaticResolver interface {
+	ResolveStatic(path string) (string, error)
+}
+
+// Django returns the load, url and static statements of Django templates, which are not part of All.
+// The url and static statements render the URLs built by the given resolvers, or store them in a variable:
+//
+//	{% load static %}
+//	{% url 'article' article.id page=2 %}
+//	{% static 'css/main.css' as stylesheet %}
+func Django(urls URLResolver, static StaticResolver) *exec.ControlStructureSet {
+	return exec.NewControlStructureSet(map[string]parser.ControlStructureParser{
+		"load":   loadParser,
+		"static": staticParser(static),
+		"url":    urlParser(urls),
+	})
+}
+
+// LoadControlStructure is the load statement of Django templates, which does nothing since
+// the filters and statements of Django libraries are registered on the environment instead
+type LoadControlStructure struct {
+	location *tokens.Token
+}
+
+func (controlStructure *LoadControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+func (controlStructure *LoadControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("LoadControlStructure(Line=%d Col=%d)", t.Line, t.Col)
+}
+
+func (controlStructure *LoadControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	return nil
+}
+
+func loadParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+	controlStructure := &LoadControlStructure{
+		location: p.Current(),
+	}
+	for !args.End() {
+		if args.Match(tokens.Name) == nil {
+			return nil, args.Error("Expected the name of a library.", args.Current())
+		}
+	}
+	return controlStructure, nil
+}
+
+// URLControlStructure renders the URL of a view, or stores it in a variable
+type URLControlStructure struct {
+	location *tokens.Token
+	resolver URLResolver
+	name     nodes.Expression
+	args     []nodes.Expression
+	kwargs   map[string]nodes.Expression
+	as       string
+}
+
+func (controlStructure *URLControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+func (controlStructure *URLControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("URLControlStructure(Line=%d Col=%d)", t.Line, t.Col)
+}
+
+func (controlStructure *URLControlStructure) Children() []nodes.Node {
+	children := []nodes.Node{controlStructure.name}
+	for _, arg := range controlStructure.args {
+		children = append(children, arg)
+	}
+	names := make([]string, 0, len(controlStructure.kwargs))
+	for name := range controlStructure.kwargs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		children = append(children, controlStructure.kwargs[name])
+	}
+	return children
+}
+
+// DeclaredNames returns the name of the variable the URL is stored in, if any
+func (controlStructure *URLControlStructure) DeclaredNames() []string {
+	if controlStructure.as == "" {
+		return nil
+	}
+	return []string{controlStructure.as}
+}
+
+func (controlStructure *URLControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	name := r.Eval(controlStructure.name)
+	if name.IsError() {
+		return fmt.Errorf(`unable to evaluate view name %s: %w`, controlStructure.name, name)
+	}
+	args := make([]interface{}, 0, len(controlStructure.args))
+	for _, arg := range controlStructure.args {
+		value := r.Eval(arg)
+		if value.IsError() {
+			return fmt.Errorf(`unable to evaluate argument %s: %w`, arg, value)
+		}
+		args = append(args, value.Interface())
+	}
+	kwargs := make(map[string]interface{}, len(controlStructure.kwargs))
+	for key, kwarg := range controlStructure.kwargs {
+		value := r.Eval(kwarg)
+		if value.IsError() {
+			return fmt.Errorf(`unable to evaluate argument '%s': %w`, key, value)
+		}
+		kwargs[key] = value.Interface()
+	}
+	if controlStructure.resolver == nil {
+		return fmt.Errorf(`unable to resolve URL of view '%s': no URL resolver configured`, name.String())
+	}
+	url, err := controlStructure.resolver.ResolveURL(name.String(), args, kwargs)
+	if err != nil {
+		return fmt.Errorf(`unable to resolve URL of view '%s': %w`, name.String(), err)
+	}
+	return writeURL(r, url, controlStructure.as)
+}
+
+func urlParser(resolver URLResolver) parser.ControlStructureParser {
+	return func(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+		controlStructure := &URLControlStructure{
+			location: p.Current(),
+			resolver: resolver,
+			kwargs:   map[string]nodes.Expression{},
+		}
+		name, err := args.ParseExpression()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse view name: %w", err)
+		}
+		controlStructure.name = name
+		for !args.End() {
+			if as, ok := parseAs(args); ok {
+				controlStructure.as = as
+				break
+			}
+			if key := args.Current(tokens.Name); key != nil && args.Peek(tokens.Assign) != nil {
+				args.Consume()
+				args.Consume()
+				value, err := args.ParseExpression()
+				if err != nil {
+					return nil, fmt.Errorf(`unable to parse argument '%s': %w`, key.Val, err)
+				}
+				controlStructure.kwargs[key.Val] = value
+				continue
+			}
+			if len(controlStructure.kwargs) > 0 {
+				return nil, args.Error("Positional arguments cannot follow keyword arguments.", args.Current())
+			}
+			arg, err := args.ParseExpression()
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse argument: %w", err)
+			}
+			controlStructure.args = append(controlStructure.args, arg)
+		}
+		if !args.End() {
+			return nil, args.Error("Malformed 'url' tag args.", args.Current())
+		}
+		return controlStructure, nil
+	}
+}
+
+// StaticControlStructure renders the URL of a static file, or stores it in a variable
+type StaticControlStructure struct {
+	location *tokens.Token
+	resolver StaticResolver
+	path     nodes.Expression
+	as       string
+}
+
+func (controlStructure *StaticControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+func (controlStructure *StaticControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("StaticControlStructure(Line=%d Col=%d)", t.Line, t.Col)
+}
+
+func (controlStructure *StaticControlStructure) Children() []nodes.Node {
+	return []nodes.Node{controlStructure.path}
+}
+
+// DeclaredNames returns the name of the variable the URL is stored in, if any
+func (controlStructure *StaticControlStructure) DeclaredNames() []string {
+	if controlStructure.as == "" {
+		return nil
+	}
+	return []string{controlStructure.as}
+}
+
+func (controlStructure *StaticControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	path := r.Eval(controlStructure.path)
+	if path.IsError() {
+		return fmt.Errorf(`unable to evaluate path %s: %w`, controlStructure.path, path)
+	}
+	if controlStructure.resolver == nil {
+		return fmt.Errorf(`unable to resolve URL of static file '%s': no static resolver configured`, path.String())
+	}
+	url, err := controlStructure.resolver.ResolveStatic(path.String())
+	if err != nil {
+		return fmt.Errorf(`unable to resolve URL of static file '%s': %w`, path.String(), err)
+	}
+	return writeURL(r, url, controlStructure.as)
+}
+
+func staticParser(resolver StaticResolver) parser.ControlStructureParser {
+	return func(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+		controlStructure := &StaticControlStructure{
+			location: p.Current(),
+			resolver: resolver,
+		}
+		path, err := args.ParseExpression()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse path: %w", err)
+		}
+		controlStructure.path = path
+		if as, ok := parseAs(args); ok {
+			controlStructure.as = as
+		}
+		if !args.End() {
+			return nil, args.Error("Malformed 'static' tag args.", args.Current())
+		}
+		return controlStructure, nil
+	}
+}
+
+// parseAs parses the 'as name' suffix of statements storing their result in a variable
+func parseAs(args *parser.Parser) (string, bool) {
+	if args.CurrentName("as") == nil || args.Peek(tokens.Name) == nil {
+		return "", false
+	}
+	args.Consume()
+	return args.Match(tokens.Name).Val, true
+}
+
+// writeURL stores a URL in a variable when one is given, or writes it to the output otherwise
+func writeURL(r *exec.Renderer, url string, as string) error {
+	if as != "" {
+		if r.Environment.Context.IsReadOnly(as) {
+			return fmt.Errorf(`unable to set '%s': variable is read-only`, as)
+		}
+		r.Environment.Context.Set(as, url)
+		return nil
+	}
+	if r.Config.AutoEscape {
+		url = u.Escape(url)
+	}
+	_, err := r.Output.WriteString(url)
+	return err
+}
@@ -1,6 +1,7 @@
 package controlStructures
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/nikolalohinski/gonja/v2/exec"
@@ -43,7 +44,14 @@ func extendsParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructur
 
 		extended, err := p.Extend(controlStructure.filename)
 		if err != nil {
-			return nil, fmt.Errorf("unable to load template '%s': %s", filename, err)
+			err = fmt.Errorf("unable to load template '%s': %w", filename, err)
+			var syntax *parser.SyntaxError
+			var located *exec.Error
+			if errors.As(err, &syntax) || errors.As(err, &located) {
+				return nil, err
+			}
+			// failures to load the extended template, such as it missing, are located at the statement
+			return nil, &exec.Error{Identifier: p.Template.Identifier, Line: filename.Line, Col: filename.Col, Err: err}
 		}
 
 		p.Template.Parent = extended
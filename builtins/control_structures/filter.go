@@ -5,11 +5,8 @@ import (
 
 	// "github.com/nikolalohinski/gonja/v2/exec"
 	"fmt"
-	"io"
 	"strings"
 
-	"github.com/pkg/errors"
-
 	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/nodes"
 	"github.com/nikolalohinski/gonja/v2/parser"
@@ -30,6 +27,14 @@ func (controlStructure *FilterControlStructure) String() string {
 	return fmt.Sprintf("FilterControlStructure(Line=%d Col=%d)", t.Line, t.Col)
 }
 
+func (controlStructure *FilterControlStructure) Children() []nodes.Node {
+	children := []nodes.Node{controlStructure.bodyWrapper}
+	for _, call := range controlStructure.filterChain {
+		children = append(children, call)
+	}
+	return children
+}
+
 func (node *FilterControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
 	var out strings.Builder
 	sub := r.Inherit()
@@ -46,12 +51,11 @@ func (node *FilterControlStructure) Execute(r *exec.Renderer, tag *nodes.Control
 	for _, call := range node.filterChain {
 		value = r.Evaluator().ExecuteFilter(call, value)
 		if value.IsError() {
-			return errors.Wrapf(value, `Unable to apply filter %s (Line: %d Col: %d, near %s`,
-				call.Name, call.Token.Line, call.Token.Col, call.Token.Val)
+			return fmt.Errorf(`Unable to apply filter %s (Line: %d Col: %d, near %s: %w`, call.Name, call.Token.Line, call.Token.Col, call.Token.Val, value)
 		}
 	}
 
-	_, err = io.WriteString(r.Output, value.String())
+	_, err = r.Output.WriteString(value.String())
 
 	return err
 }
@@ -1,8 +1,10 @@
 package controlStructures
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"reflect"
 
 	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/nodes"
@@ -28,6 +30,27 @@ func (controlStructure *ForControlStructure) String() string {
 	return fmt.Sprintf("ForControlStructure(Line=%d Col=%d)", t.Line, t.Col)
 }
 
+// DeclaredNames returns the names of the loop variables, including the loop object itself
+func (controlStructure *ForControlStructure) DeclaredNames() []string {
+	names := []string{"loop", controlStructure.Key}
+	if controlStructure.Value != "" {
+		names = append(names, controlStructure.Value)
+	}
+	return names
+}
+
+func (controlStructure *ForControlStructure) Children() []nodes.Node {
+	children := []nodes.Node{controlStructure.ObjectEvaluator}
+	if controlStructure.IfCondition != nil {
+		children = append(children, controlStructure.IfCondition)
+	}
+	children = append(children, controlStructure.BodyWrapper)
+	if controlStructure.EmptyWrapper != nil {
+		children = append(children, controlStructure.EmptyWrapper)
+	}
+	return children
+}
+
 type LoopInfos struct {
 	index     int
 	index0    int
@@ -58,53 +81,54 @@ func (node *ForControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStr
 	if obj.IsError() {
 		return obj
 	}
-
 	// Create loop struct
 	items := exec.NewDict()
 
-	// First iteration: filter values to ensure proper LoopInfos
-	obj.Iterate(func(idx, count int, key, value *exec.Value) bool {
-		sub := r.Inherit()
-		ctx := sub.Environment.Context
-		pair := &exec.Pair{}
-
-		// There's something to iterate over (correct type and at least 1 item)
-		// Update loop infos and public context
-		if node.Value != "" && !key.IsString() && key.Len() == 2 {
-			key.Iterate(func(idx, count int, key, value *exec.Value) bool {
-				switch idx {
-				case 0:
-					ctx.Set(node.Key, key)
-					pair.Key = key
-				case 1:
-					ctx.Set(node.Value, key)
-					pair.Value = key
-				}
-				return true
-			}, func() {})
-		} else {
-			ctx.Set(node.Key, key)
-			pair.Key = key
-			if value != nil {
-				ctx.Set(node.Value, value)
-				pair.Value = value
+	if next, ok := obj.Iterator(r.Done()); ok {
+		pull := node.pull(r, next)
+		if reflect.Indirect(obj.Val).Kind() != reflect.Chan {
+			return node.stream(r, pull, lengthOf(obj, node.IfCondition))
+		}
+		// channels are drained before rendering, so that loops over them know their length and last item
+		for {
+			pair, err := pull()
+			if err != nil {
+				return err
+			}
+			if pair == nil {
+				break
 			}
+			items.Pairs = append(items.Pairs, pair)
 		}
-
-		if node.IfCondition != nil {
-			if !sub.Eval(node.IfCondition).IsTrue() {
-				return true
+	} else {
+		// First iteration: filter values to ensure proper LoopInfos
+		var iterationErr error
+		obj.Iterate(func(idx, count int, key, value *exec.Value) bool {
+			if iterationErr != nil {
+				return false
+			}
+			if iterationErr = r.Canceled(); iterationErr != nil {
+				return false
 			}
+			if iterationErr = r.SpendIteration(); iterationErr != nil {
+				return false
+			}
+			if pair, ok := node.accept(r, key, value); ok {
+				items.Pairs = append(items.Pairs, pair)
+			}
+			return true
+		}, func() {})
+		if iterationErr != nil {
+			return iterationErr
 		}
-		items.Pairs = append(items.Pairs, pair)
-		return true
-	}, func() {})
+	}
 
 	// 2nd pass: all values are defined, render
 	length := len(items.Pairs)
 	loop := &LoopInfos{
 		first:  true,
 		index0: -1,
+		length: length,
 	}
 	if len(items.Pairs) == 0 && node.EmptyWrapper != nil {
 		if err := r.Inherit().ExecuteWrapper(node.EmptyWrapper); err != nil {
@@ -112,15 +136,6 @@ func (node *ForControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStr
 		}
 	}
 	for idx, pair := range items.Pairs {
-		sub := r.Inherit()
-		ctx := sub.Environment.Context
-
-		ctx.Set(node.Key, pair.Key)
-		if pair.Value != nil {
-			ctx.Set(node.Value, pair.Value)
-		}
-
-		ctx.Set("loop", loop)
 		loop.index0 = idx
 		loop.index = loop.index0 + 1
 		if idx == 1 {
@@ -135,33 +150,170 @@ func (node *ForControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStr
 		if idx == 0 {
 			loop.PrevItem = exec.AsValue(nil)
 		} else {
-			pp := items.Pairs[idx-1]
-			if pp.Value != nil {
-				loop.PrevItem = exec.AsValue([2]*exec.Value{pp.Key, pp.Value})
-			} else {
-				loop.PrevItem = pp.Key
-			}
+			loop.PrevItem = itemOf(items.Pairs[idx-1])
 		}
 
 		if idx == length-1 {
 			loop.NextItem = exec.AsValue(nil)
 		} else {
-			np := items.Pairs[idx+1]
-			if np.Value != nil {
-				loop.NextItem = exec.AsValue([2]*exec.Value{np.Key, np.Value})
-			} else {
-				loop.NextItem = np.Key
+			loop.NextItem = itemOf(items.Pairs[idx+1])
+		}
+
+		err := node.render(r, loop, pair)
+		if errors.Is(err, exec.ErrBreak) {
+			break
+		} else if errors.Is(err, exec.ErrContinue) {
+			continue
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return forError
+}
+
+// pull returns a function pulling the next item accepted by the loop from a channel or an iterator, see
+// exec.Value.Iterator, and returning nil once there are no more items
+func (node *ForControlStructure) pull(r *exec.Renderer, next func() (*exec.Value, bool)) func() (*exec.Pair, error) {
+	return func() (*exec.Pair, error) {
+		for {
+			if err := r.Canceled(); err != nil {
+				return nil, err
+			}
+			item, ok := next()
+			if !ok {
+				// the channel stops waiting for items once the render is canceled
+				return nil, r.Canceled()
+			}
+			if err := r.SpendIteration(); err != nil {
+				return nil, err
+			}
+			if pair, ok := node.accept(r, item, nil); ok {
+				return pair, nil
 			}
 		}
+	}
+}
 
-		// Render elements with updated context
-		err := sub.ExecuteWrapper(node.BodyWrapper)
+// lengthOf returns the number of items of an iterator going through all of them, that is when it reports
+// its length with a `Len() int` method, as the ones of range do, or -1 otherwise
+func lengthOf(obj *exec.Value, condition nodes.Expression) int {
+	if sized, ok := obj.Interface().(interface{ Len() int }); ok && condition == nil {
+		return sized.Len()
+	}
+	return -1
+}
+
+// stream renders the body for each item pulled from an iterator as soon as the next accepted item is pulled,
+// which tells whether it is the last one. Unless the iterator reports its length, loop.length, loop.revindex
+// and loop.revindex0 are -1.
+func (node *ForControlStructure) stream(r *exec.Renderer, pull func() (*exec.Pair, error), length int) error {
+	current, err := pull()
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		if node.EmptyWrapper != nil {
+			return r.Inherit().ExecuteWrapper(node.EmptyWrapper)
+		}
+		return nil
+	}
+	loop := &LoopInfos{
+		length:    length,
+		revindex:  -1,
+		revindex0: -1,
+		PrevItem:  exec.AsValue(nil),
+	}
+	for idx := 0; current != nil; idx++ {
+		following, err := pull()
 		if err != nil {
 			return err
 		}
+		loop.index0 = idx
+		loop.index = idx + 1
+		loop.first = idx == 0
+		loop.last = following == nil
+		if length >= 0 {
+			loop.revindex = length - idx
+			loop.revindex0 = length - (idx + 1)
+		}
+		if following == nil {
+			loop.NextItem = exec.AsValue(nil)
+		} else {
+			loop.NextItem = itemOf(following)
+		}
+
+		err = node.render(r, loop, current)
+		if errors.Is(err, exec.ErrBreak) {
+			break
+		} else if err != nil && !errors.Is(err, exec.ErrContinue) {
+			return err
+		}
+		loop.PrevItem = itemOf(current)
+		current = following
 	}
+	return nil
+}
 
-	return forError
+// accept binds the loop variables to an item and evaluates the condition of the loop, returning the pair
+// of the item when it holds
+func (node *ForControlStructure) accept(r *exec.Renderer, key, value *exec.Value) (*exec.Pair, bool) {
+	sub := r.Inherit()
+	ctx := sub.Environment.Context
+	pair := &exec.Pair{}
+
+	// There's something to iterate over (correct type and at least 1 item)
+	// Update loop infos and public context
+	if node.Value != "" && !key.IsString() && key.Len() == 2 {
+		key.Iterate(func(idx, count int, key, value *exec.Value) bool {
+			switch idx {
+			case 0:
+				ctx.Set(node.Key, key)
+				pair.Key = key
+			case 1:
+				ctx.Set(node.Value, key)
+				pair.Value = key
+			}
+			return true
+		}, func() {})
+	} else {
+		ctx.Set(node.Key, key)
+		pair.Key = key
+		if value != nil {
+			ctx.Set(node.Value, value)
+			pair.Value = value
+		}
+	}
+
+	if node.IfCondition != nil {
+		if !sub.Eval(node.IfCondition).Truthy(sub.Config) {
+			return nil, false
+		}
+	}
+	return pair, true
+}
+
+// render renders the body of the loop for an item
+func (node *ForControlStructure) render(r *exec.Renderer, loop *LoopInfos, pair *exec.Pair) error {
+	sub := r.Inherit()
+	ctx := sub.Environment.Context
+
+	ctx.Set(node.Key, pair.Key)
+	if pair.Value != nil {
+		ctx.Set(node.Value, pair.Value)
+	}
+	ctx.Set("loop", loop)
+
+	// Render elements with updated context
+	return sub.ExecuteWrapper(node.BodyWrapper)
+}
+
+// itemOf returns the item of a pair as exposed by loop.PrevItem and loop.NextItem
+func itemOf(pair *exec.Pair) *exec.Value {
+	if pair.Value != nil {
+		return exec.AsValue([2]*exec.Value{pair.Key, pair.Value})
+	}
+	return pair.Key
 }
 
 func forParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
@@ -209,7 +361,9 @@ func forParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, e
 	}
 
 	// Body wrapping
+	leave := p.EnterLoop()
 	wrapper, endargs, err := p.WrapUntil("else", "endfor")
+	leave()
 	if err != nil {
 		return nil, err
 	}
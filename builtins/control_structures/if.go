@@ -25,6 +25,18 @@ func (controlStructure *IfControlStructure) String() string {
 	return fmt.Sprintf("IfControlStructure(Line=%d Col=%d)", t.Line, t.Col)
 }
 
+func (controlStructure *IfControlStructure) Children() []nodes.Node {
+	children := []nodes.Node{}
+	for index, wrapper := range controlStructure.Wrappers {
+		// the last wrapper has no condition when there is an else branch
+		if index < len(controlStructure.Conditions) {
+			children = append(children, controlStructure.Conditions[index])
+		}
+		children = append(children, wrapper)
+	}
+	return children
+}
+
 func (node *IfControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
 	for i, condition := range node.Conditions {
 		result := r.Eval(condition)
@@ -32,22 +44,27 @@ func (node *IfControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStru
 			return result
 		}
 
-		if result.IsTrue() {
+		if result.Truthy(r.Config) {
+			r.TraceBranch(tag, i, len(node.Wrappers))
 			return r.ExecuteIfWrapper(node.Wrappers[i])
 		}
 		// Last condition?
 		if len(node.Conditions) == i+1 && len(node.Wrappers) > i+1 {
+			r.TraceBranch(tag, i+1, len(node.Wrappers))
 			return r.ExecuteIfWrapper(node.Wrappers[i+1])
 		}
 	}
+	r.TraceBranch(tag, -1, len(node.Wrappers))
 	return nil
 }
 
 func ifParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
-	log.WithFields(log.Fields{
-		"arg":     args.Current(),
-		"current": p.Current(),
-	}).Trace("ParseIf")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"arg":     args.Current(),
+			"current": p.Current(),
+		}).Trace("ParseIf")
+	}
 	ifNode := &IfControlStructure{
 		location: args.Current(),
 	}
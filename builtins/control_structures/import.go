@@ -2,8 +2,7 @@ package controlStructures
 
 import (
 	"fmt"
-
-	"github.com/pkg/errors"
+	"sort"
 
 	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/nodes"
@@ -27,33 +26,47 @@ func (controlStructure *ImportControlStructure) String() string {
 	return fmt.Sprintf("ImportControlStructure(Line=%d Col=%d)", t.Line, t.Col)
 }
 
+func (controlStructure *ImportControlStructure) Children() []nodes.Node {
+	return []nodes.Node{controlStructure.filenameExpression}
+}
+
+// ImportedNames returns the name the imported template is bound to
+func (controlStructure *ImportControlStructure) ImportedNames() []string {
+	return []string{controlStructure.as}
+}
+
 func (controlStructure *ImportControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
 
 	filenameValue := r.Eval(controlStructure.filenameExpression)
 	if filenameValue.IsError() {
-		return errors.Wrap(filenameValue, `Unable to evaluate filename`)
+		return fmt.Errorf("Unable to evaluate filename: %w", filenameValue)
+	}
+
+	current, err := r.LoaderOf(tag)
+	if err != nil {
+		return err
 	}
 
-	filename, err := r.Loader.Resolve(filenameValue.String())
+	filename, err := current.Resolve(filenameValue.String())
 	if err != nil {
-		return errors.Errorf("failed to resolve filename: %s", err)
+		return fmt.Errorf("failed to resolve filename: %w", err)
 	}
 
-	loader, err := r.Loader.Inherit(filename)
+	loader, err := current.Inherit(filename)
 	if err != nil {
-		return fmt.Errorf("failed to inherit loader from '%s': %s", filename, r.Loader)
+		return fmt.Errorf("failed to inherit loader from '%s': %w", filename, err)
 	}
 
-	template, err := exec.NewTemplate(filename, r.Config, loader, r.Environment)
+	template, err := r.LoadTemplate(filename, loader)
 	if err != nil {
-		return fmt.Errorf("unable to load template '%s': %s", filename, err)
+		return fmt.Errorf("unable to load template '%s': %w", filename, err)
 	}
 
 	macros := map[string]exec.Macro{}
 	for name, macro := range template.Macros() {
 		fn, err := exec.MacroNodeToFunc(macro, r)
 		if err != nil {
-			return errors.Wrapf(err, `Unable to import macro '%s'`, name)
+			return fmt.Errorf(`Unable to import macro '%s': %w`, name, err)
 		}
 		macros[name] = fn
 	}
@@ -80,26 +93,45 @@ func (controlStructure *FromImportControlStructure) String() string {
 	return fmt.Sprintf("FromImportControlStructure(Line=%d Col=%d)", t.Line, t.Col)
 }
 
+func (controlStructure *FromImportControlStructure) Children() []nodes.Node {
+	return []nodes.Node{controlStructure.FilenameExpression}
+}
+
+// ImportedNames returns the sorted names the imported macros are bound to
+func (controlStructure *FromImportControlStructure) ImportedNames() []string {
+	names := make([]string, 0, len(controlStructure.As))
+	for alias := range controlStructure.As {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (controlStructure *FromImportControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
 
 	filenameValue := r.Eval(controlStructure.FilenameExpression)
 	if filenameValue.IsError() {
-		return errors.Wrap(filenameValue, `Unable to evaluate filename`)
+		return fmt.Errorf("Unable to evaluate filename: %w", filenameValue)
+	}
+
+	current, err := r.LoaderOf(tag)
+	if err != nil {
+		return err
 	}
 
-	filename, err := r.Loader.Resolve(filenameValue.String())
+	filename, err := current.Resolve(filenameValue.String())
 	if err != nil {
-		return errors.Errorf("failed to resolve filename: %s", err)
+		return fmt.Errorf("failed to resolve filename: %w", err)
 	}
 
-	loader, err := r.Loader.Inherit(filename)
+	loader, err := current.Inherit(filename)
 	if err != nil {
-		return fmt.Errorf("failed to inherit loader from '%s': %s", filename, r.Loader)
+		return fmt.Errorf("failed to inherit loader from '%s': %w", filename, err)
 	}
 
-	template, err := exec.NewTemplate(filename, r.Config, loader, r.Environment)
+	template, err := r.LoadTemplate(filename, loader)
 	if err != nil {
-		return fmt.Errorf("unable to load template '%s': %s", filename, err)
+		return fmt.Errorf("unable to load template '%s': %w", filename, err)
 	}
 
 	imported := template.Macros()
@@ -107,7 +139,7 @@ func (controlStructure *FromImportControlStructure) Execute(r *exec.Renderer, ta
 		node := imported[name]
 		fn, err := exec.MacroNodeToFunc(node, r)
 		if err != nil {
-			return errors.Wrapf(err, `Unable to import macro '%s'`, name)
+			return fmt.Errorf(`Unable to import macro '%s': %w`, name, err)
 		}
 		r.Environment.Context.Set(alias, fn)
 	}
@@ -129,6 +161,7 @@ func importParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure
 		return nil, err
 	}
 	controlStructure.filenameExpression = expression
+	registerReference(p, "import", expression, false)
 	if args.MatchName("as") == nil {
 		return nil, args.Error(`Expected "as" keyword`, args.Current())
 	}
@@ -164,6 +197,7 @@ func fromParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure,
 		return nil, err
 	}
 	controlStructure.FilenameExpression = filename
+	registerReference(p, "from", filename, false)
 
 	if args.MatchName("import") == nil {
 		return nil, args.Error("Expected import keyword", args.Current())
@@ -1,11 +1,12 @@
 package controlStructures
 
 import (
+	"errors"
 	"fmt"
-
-	"github.com/pkg/errors"
+	"sort"
 
 	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
 	"github.com/nikolalohinski/gonja/v2/nodes"
 	"github.com/nikolalohinski/gonja/v2/parser"
 	"github.com/nikolalohinski/gonja/v2/tokens"
@@ -29,6 +30,10 @@ func (controlStructure *IncludeControlStructure) String() string {
 	return fmt.Sprintf("IncludeControlStructure(Filename=%s Line=%d Col=%d)", controlStructure.filenameExpression, t.Line, t.Col)
 }
 
+func (controlStructure *IncludeControlStructure) Children() []nodes.Node {
+	return []nodes.Node{controlStructure.filenameExpression}
+}
+
 func (controlStructure *IncludeControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
 	if controlStructure.isEmpty {
 		return nil
@@ -36,36 +41,96 @@ func (controlStructure *IncludeControlStructure) Execute(r *exec.Renderer, tag *
 
 	filenameValue := r.Eval(controlStructure.filenameExpression)
 	if filenameValue.IsError() {
-		return errors.Wrap(filenameValue, `Unable to evaluate filename`)
+		return fmt.Errorf("Unable to evaluate filename: %w", filenameValue)
 	}
 
-	filename, err := r.Loader.Resolve(filenameValue.String())
+	current, err := r.LoaderOf(tag)
+	if err != nil {
+		return err
+	}
+
+	name := filenameValue.String()
+	if filenameValue.IsList() {
+		names, err := filenameValue.AsStringSlice()
+		if err != nil {
+			return fmt.Errorf("Unable to evaluate filename: %w", err)
+		}
+		name, err = loaders.FirstExisting(current, names...)
+		if err != nil {
+			if controlStructure.ignoreMissing && errors.Is(err, loaders.ErrTemplateNotFound) {
+				return nil
+			}
+			return fmt.Errorf("failed to select a template: %w", err)
+		}
+	}
+	if globLoader, ok := current.(loaders.GlobLoader); ok && loaders.IsPattern(name) {
+		filenames, err := globLoader.Glob(name)
+		if err != nil {
+			return fmt.Errorf("failed to list templates matching '%s': %w", name, err)
+		}
+		// names holding special characters can be actual template names, which are included as such when
+		// nothing matches them as patterns
+		if len(filenames) > 0 {
+			sort.Strings(filenames)
+			for _, filename := range filenames {
+				if err := controlStructure.include(r, tag, current, name, filename); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if exists, err := loaders.Exists(current, name); err != nil {
+			return fmt.Errorf("failed to look up template '%s': %w", name, err)
+		} else if !exists {
+			return nil
+		}
+	}
+
+	filename, err := current.Resolve(name)
 	if err != nil {
 		if controlStructure.ignoreMissing {
 			return nil
 		} else {
-			return errors.Errorf("failed to resolve filename: %s", err)
+			return fmt.Errorf("failed to resolve filename: %w", err)
 		}
 	}
 
-	loader, err := r.Loader.Inherit(filename)
+	return controlStructure.include(r, tag, current, name, filename)
+}
+
+// include renders the template with the given resolved filename
+func (controlStructure *IncludeControlStructure) include(r *exec.Renderer, tag *nodes.ControlStructureBlock, current loaders.Loader, name string, filename string) error {
+	loader, err := current.Inherit(filename)
 	if err != nil {
 		if controlStructure.ignoreMissing {
 			return nil
 		} else {
-			return errors.Errorf("failed to inherit loader: %s", err)
+			return fmt.Errorf("failed to inherit loader: %w", err)
 		}
 	}
 
-	included, err := exec.NewTemplate(filename, r.Config, loader, r.Environment)
+	// templates included through names computed during renders are not cached, since the names could vary
+	// without bounds
+	load := r.LoadTemplate
+	if _, static := controlStructure.filenameExpression.(*nodes.String); !static {
+		load = r.ParseTemplate
+	}
+	included, err := load(filename, loader)
 	if err != nil {
 		if controlStructure.ignoreMissing {
 			return nil
 		} else {
-			return fmt.Errorf("unable to load template '%s': %s", filename, err)
+			return fmt.Errorf("unable to load template '%s': %w", filename, err)
 		}
 	}
 
+	leave, err := r.EnterInclude(filename)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	r.TraceInclude(tag, name, filename)
+
 	return exec.NewRenderer(r.Environment, r.Output, r.Config.Inherit(), loader, included).Execute()
 }
 
@@ -87,6 +152,9 @@ func includeParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructur
 			args.Stream().Backup()
 		}
 	}
+	// patterns are referenced as optional templates, since they only name one when nothing matches them
+	name, ok := filenameExpression.(*nodes.String)
+	registerReference(p, "include", filenameExpression, controlStructure.ignoreMissing || ok && loaders.IsPattern(name.Val))
 
 	if tok := args.MatchName("with", "without"); tok != nil {
 		if args.MatchName("context") != nil {
@@ -102,3 +170,16 @@ func includeParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructur
 
 	return controlStructure, nil
 }
+
+// registerReference records a template referenced with a string literal on the template being parsed,
+// so that it can be precompiled or listed as a dependency
+func registerReference(p *parser.Parser, kind string, expression nodes.Expression, optional bool) {
+	if name, ok := expression.(*nodes.String); ok && p.Template != nil {
+		p.Template.References = append(p.Template.References, &nodes.Reference{
+			Location: name.Location,
+			Kind:     kind,
+			Name:     name.Val,
+			Optional: optional,
+		})
+	}
+}
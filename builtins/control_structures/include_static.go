@@ -0,0 +1,90 @@
+package controlStructures
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/parser"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+)
+
+// IncludeStaticControlStructure includes the content of a file as is, without parsing it as a template nor
+// escaping it, which suits large fragments such as SVG images or stylesheets. The content is read once per
+// root template and reused by later renders.
+type IncludeStaticControlStructure struct {
+	location           *tokens.Token
+	filenameExpression nodes.Expression
+	ignoreMissing      bool
+}
+
+func (controlStructure *IncludeStaticControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+
+func (controlStructure *IncludeStaticControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("IncludeStaticControlStructure(Filename=%s Line=%d Col=%d)", controlStructure.filenameExpression, t.Line, t.Col)
+}
+
+func (controlStructure *IncludeStaticControlStructure) Children() []nodes.Node {
+	return []nodes.Node{controlStructure.filenameExpression}
+}
+
+func (controlStructure *IncludeStaticControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	filenameValue := r.Eval(controlStructure.filenameExpression)
+	if filenameValue.IsError() {
+		return fmt.Errorf("Unable to evaluate filename: %w", filenameValue)
+	}
+
+	current, err := r.LoaderOf(tag)
+	if err != nil {
+		return err
+	}
+
+	filename, err := current.Resolve(filenameValue.String())
+	if err != nil {
+		if controlStructure.ignoreMissing && errors.Is(err, loaders.ErrTemplateNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to resolve filename: %w", err)
+	}
+
+	content, err := r.LoadStatic(filename, current)
+	if err != nil {
+		if controlStructure.ignoreMissing && errors.Is(err, loaders.ErrTemplateNotFound) {
+			return nil
+		}
+		return fmt.Errorf("unable to load file '%s': %w", filename, err)
+	}
+
+	return r.WriteStatic(content)
+}
+
+func includeStaticParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+	controlStructure := &IncludeStaticControlStructure{
+		location: p.Current(),
+	}
+
+	filenameExpression, err := args.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	controlStructure.filenameExpression = filenameExpression
+
+	if args.MatchName("ignore") != nil {
+		if args.MatchName("missing") != nil {
+			controlStructure.ignoreMissing = true
+		} else {
+			args.Stream().Backup()
+		}
+	}
+
+	if !args.End() {
+		return nil, args.Error("Malformed 'include_static'-tag args.", nil)
+	}
+
+	return controlStructure, nil
+}
@@ -0,0 +1,52 @@
+package controlStructures
+
+import (
+	"fmt"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/parser"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+)
+
+// LoopControlStructure stops the loop holding it with the break statement, or skips to its next iteration with
+// the continue statement, like the loopcontrols extension of Jinja does
+type LoopControlStructure struct {
+	location *tokens.Token
+	name     string
+	err      error
+}
+
+func (controlStructure *LoopControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+func (controlStructure *LoopControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("LoopControlStructure(Name=%s Line=%d Col=%d)", controlStructure.name, t.Line, t.Col)
+}
+
+func (controlStructure *LoopControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	return controlStructure.err
+}
+
+func breakParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+	return loopControlParser(p, args, "break", exec.ErrBreak)
+}
+
+func continueParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+	return loopControlParser(p, args, "continue", exec.ErrContinue)
+}
+
+func loopControlParser(p *parser.Parser, args *parser.Parser, name string, err error) (nodes.ControlStructure, error) {
+	if !args.End() {
+		return nil, args.Error(fmt.Sprintf("Tag '%s' takes no argument.", name), args.Current())
+	}
+	if !p.InLoop() {
+		return nil, p.Error(fmt.Sprintf("'%s' used outside of a loop", name), p.Current())
+	}
+	return &LoopControlStructure{
+		location: p.Current(),
+		name:     name,
+		err:      err,
+	}, nil
+}
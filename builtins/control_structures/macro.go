@@ -7,7 +7,6 @@ import (
 	"github.com/nikolalohinski/gonja/v2/nodes"
 	"github.com/nikolalohinski/gonja/v2/parser"
 	"github.com/nikolalohinski/gonja/v2/tokens"
-	"github.com/pkg/errors"
 )
 
 type MacroControlStructure struct {
@@ -19,10 +18,14 @@ func (controlStructure *MacroControlStructure) String() string {
 	return fmt.Sprintf("MacroControlStructure(Macro=%s Line=%d Col=%d)", controlStructure.Macro, t.Line, t.Col)
 }
 
+func (controlStructure *MacroControlStructure) Children() []nodes.Node {
+	return nodes.Children(controlStructure.Macro)
+}
+
 func (controlStructure *MacroControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
 	macro, err := exec.MacroNodeToFunc(controlStructure.Macro, r)
 	if err != nil {
-		return errors.Wrapf(err, `Unable to parse marco '%s'`, controlStructure.Name)
+		return fmt.Errorf(`Unable to parse marco '%s': %w`, controlStructure.Name, err)
 	}
 	r.Environment.Context.Set(controlStructure.Name, macro)
 	return nil
@@ -94,7 +97,10 @@ func macroParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure,
 		return nil, args.Error("Malformed macro-tag.", nil)
 	}
 
+	// macros are called from anywhere, so loops holding their definition do not hold their body
+	restore := p.LeaveLoops()
 	wrapper, endargs, err := p.WrapUntil("endmacro")
+	restore()
 	if err != nil {
 		return nil, err
 	}
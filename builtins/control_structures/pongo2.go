@@ -0,0 +1,350 @@
+package controlStructures
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/parser"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+)
+
+// Pongo2 returns the statements of pongo2 templates which are not part of All, so that templates written
+// for pongo2 can be rendered while they are being migrated:
+//
+//	{% ifequal user.role "admin" %}...{% else %}...{% endifequal %}
+//	{% firstof user.nickname user.name "anonymous" %}
+//	{% now "2006-01-02" %}
+//	{% templatetag openblock %}
+//	{% widthratio done total 100 as percent %}
+func Pongo2() *exec.ControlStructureSet {
+	return exec.NewControlStructureSet(map[string]parser.ControlStructureParser{
+		"firstof":     firstofParser,
+		"ifequal":     ifEqualParser("ifequal", false),
+		"ifnotequal":  ifEqualParser("ifnotequal", true),
+		"load":        loadParser,
+		"now":         nowParser,
+		"templatetag": templatetagParser,
+		"widthratio":  widthratioParser,
+	})
+}
+
+// IfEqualControlStructure is the ifequal and ifnotequal statements of pongo2, rendering their body
+// when both of their arguments are equal, or are not equal respectively
+type IfEqualControlStructure struct {
+	location *tokens.Token
+	negated  bool
+	left     nodes.Expression
+	right    nodes.Expression
+	body     *nodes.Wrapper
+	orElse   *nodes.Wrapper
+}
+
+func (controlStructure *IfEqualControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+func (controlStructure *IfEqualControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("IfEqualControlStructure(Line=%d Col=%d)", t.Line, t.Col)
+}
+
+func (controlStructure *IfEqualControlStructure) Children() []nodes.Node {
+	children := []nodes.Node{controlStructure.left, controlStructure.right, controlStructure.body}
+	if controlStructure.orElse != nil {
+		children = append(children, controlStructure.orElse)
+	}
+	return children
+}
+
+func (controlStructure *IfEqualControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	left := r.Eval(controlStructure.left)
+	if left.IsError() {
+		return fmt.Errorf(`unable to evaluate %s: %w`, controlStructure.left, left)
+	}
+	right := r.Eval(controlStructure.right)
+	if right.IsError() {
+		return fmt.Errorf(`unable to evaluate %s: %w`, controlStructure.right, right)
+	}
+	if left.EqualValueTo(right) != controlStructure.negated {
+		return r.ExecuteIfWrapper(controlStructure.body)
+	}
+	if controlStructure.orElse != nil {
+		return r.ExecuteIfWrapper(controlStructure.orElse)
+	}
+	return nil
+}
+
+func ifEqualParser(name string, negated bool) parser.ControlStructureParser {
+	return func(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+		controlStructure := &IfEqualControlStructure{
+			location: p.Current(),
+			negated:  negated,
+		}
+		malformed := fmt.Sprintf("'%s' expects exactly two arguments.", name)
+		left, err := args.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if args.End() {
+			return nil, args.Error(malformed, nil)
+		}
+		right, err := args.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if !args.End() {
+			return nil, args.Error(malformed, args.Current())
+		}
+		controlStructure.left, controlStructure.right = left, right
+
+		end := "end" + name
+		wrapper, tagArgs, err := p.WrapUntil("else", end)
+		if err != nil {
+			return nil, err
+		}
+		if !tagArgs.End() {
+			return nil, tagArgs.Error("Arguments not allowed here.", nil)
+		}
+		controlStructure.body = wrapper
+		if wrapper.EndTag == "else" {
+			wrapper, tagArgs, err = p.WrapUntil(end)
+			if err != nil {
+				return nil, err
+			}
+			if !tagArgs.End() {
+				return nil, tagArgs.Error("Arguments not allowed here.", nil)
+			}
+			controlStructure.orElse = wrapper
+		}
+		return controlStructure, nil
+	}
+}
+
+// FirstofControlStructure renders the first of its arguments which is true
+type FirstofControlStructure struct {
+	location *tokens.Token
+	values   []nodes.Expression
+}
+
+func (controlStructure *FirstofControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+func (controlStructure *FirstofControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("FirstofControlStructure(Line=%d Col=%d)", t.Line, t.Col)
+}
+
+func (controlStructure *FirstofControlStructure) Children() []nodes.Node {
+	children := make([]nodes.Node, 0, len(controlStructure.values))
+	for _, value := range controlStructure.values {
+		children = append(children, value)
+	}
+	return children
+}
+
+func (controlStructure *FirstofControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	for _, expression := range controlStructure.values {
+		value := r.Eval(expression)
+		if value.IsError() {
+			return fmt.Errorf(`unable to evaluate %s: %w`, expression, value)
+		}
+		if !value.Truthy(r.Config) {
+			continue
+		}
+		var err error
+		if r.Config.AutoEscape && value.IsString() && !value.Safe {
+			_, err = r.Output.WriteString(value.Escaped())
+		} else {
+			_, err = r.Output.WriteString(value.Printed(r.Config))
+		}
+		return err
+	}
+	return nil
+}
+
+func firstofParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+	controlStructure := &FirstofControlStructure{
+		location: p.Current(),
+	}
+	for !args.End() {
+		value, err := args.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		controlStructure.values = append(controlStructure.values, value)
+	}
+	if len(controlStructure.values) == 0 {
+		return nil, args.Error("'firstof' expects at least one argument.", nil)
+	}
+	return controlStructure, nil
+}
+
+// NowControlStructure renders the current time formatted with a go layout, as pongo2 does
+type NowControlStructure struct {
+	location *tokens.Token
+	layout   nodes.Expression
+}
+
+func (controlStructure *NowControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+func (controlStructure *NowControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("NowControlStructure(Line=%d Col=%d)", t.Line, t.Col)
+}
+
+func (controlStructure *NowControlStructure) Children() []nodes.Node {
+	return []nodes.Node{controlStructure.layout}
+}
+
+func (controlStructure *NowControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	layout := r.Eval(controlStructure.layout)
+	if layout.IsError() {
+		return fmt.Errorf(`unable to evaluate layout %s: %w`, controlStructure.layout, layout)
+	}
+	_, err := r.Output.WriteString(time.Now().Format(layout.String()))
+	return err
+}
+
+func nowParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+	controlStructure := &NowControlStructure{
+		location: p.Current(),
+	}
+	layout, err := args.ParseExpression()
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse layout: %w", err)
+	}
+	controlStructure.layout = layout
+	if !args.End() {
+		return nil, args.Error("Malformed 'now' tag args.", args.Current())
+	}
+	return controlStructure, nil
+}
+
+// TemplatetagControlStructure renders one of the delimiters of the template syntax
+type TemplatetagControlStructure struct {
+	location *tokens.Token
+	tag      string
+}
+
+func (controlStructure *TemplatetagControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+func (controlStructure *TemplatetagControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("TemplatetagControlStructure(Line=%d Col=%d)", t.Line, t.Col)
+}
+
+func (controlStructure *TemplatetagControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	delimiters := map[string]string{
+		"openblock":     r.Config.BlockStartString,
+		"closeblock":    r.Config.BlockEndString,
+		"openvariable":  r.Config.VariableStartString,
+		"closevariable": r.Config.VariableEndString,
+		"openbrace":     "{",
+		"closebrace":    "}",
+		"opencomment":   r.Config.CommentStartString,
+		"closecomment":  r.Config.CommentEndString,
+	}
+	_, err := r.Output.WriteString(delimiters[controlStructure.tag])
+	return err
+}
+
+func templatetagParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+	controlStructure := &TemplatetagControlStructure{
+		location: p.Current(),
+	}
+	tag := args.MatchName("openblock", "closeblock", "openvariable", "closevariable", "openbrace", "closebrace", "opencomment", "closecomment")
+	if tag == nil {
+		return nil, args.Error("Expected the name of a delimiter.", args.Current())
+	}
+	controlStructure.tag = tag.Val
+	if !args.End() {
+		return nil, args.Error("Malformed 'templatetag' tag args.", args.Current())
+	}
+	return controlStructure, nil
+}
+
+// WidthratioControlStructure renders the ratio of a value to a maximum scaled to a width, or stores it
+// in a variable, which is typically used to size bars in charts
+type WidthratioControlStructure struct {
+	location *tokens.Token
+	value    nodes.Expression
+	max      nodes.Expression
+	width    nodes.Expression
+	as       string
+}
+
+func (controlStructure *WidthratioControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+func (controlStructure *WidthratioControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("WidthratioControlStructure(Line=%d Col=%d)", t.Line, t.Col)
+}
+
+func (controlStructure *WidthratioControlStructure) Children() []nodes.Node {
+	return []nodes.Node{controlStructure.value, controlStructure.max, controlStructure.width}
+}
+
+// DeclaredNames returns the name of the variable the ratio is stored in, if any
+func (controlStructure *WidthratioControlStructure) DeclaredNames() []string {
+	if controlStructure.as == "" {
+		return nil
+	}
+	return []string{controlStructure.as}
+}
+
+func (controlStructure *WidthratioControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	operands := make([]float64, 0, 3)
+	for _, expression := range []nodes.Expression{controlStructure.value, controlStructure.max, controlStructure.width} {
+		value := r.Eval(expression)
+		if value.IsError() {
+			return fmt.Errorf(`unable to evaluate %s: %w`, expression, value)
+		}
+		if !value.IsNumber() {
+			return fmt.Errorf(`%s is not a number`, value.String())
+		}
+		operands = append(operands, value.Float())
+	}
+	ratio := 0
+	if operands[1] != 0 {
+		ratio = int(math.Round(operands[0] / operands[1] * operands[2]))
+	}
+	if controlStructure.as != "" {
+		if r.Environment.Context.IsReadOnly(controlStructure.as) {
+			return fmt.Errorf(`unable to set '%s': variable is read-only`, controlStructure.as)
+		}
+		r.Environment.Context.Set(controlStructure.as, ratio)
+		return nil
+	}
+	_, err := fmt.Fprint(r.Output, ratio)
+	return err
+}
+
+func widthratioParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+	controlStructure := &WidthratioControlStructure{
+		location: p.Current(),
+	}
+	operands := make([]nodes.Expression, 0, 3)
+	for len(operands) < 3 {
+		if args.End() {
+			return nil, args.Error("'widthratio' expects a value, a maximum and a width.", nil)
+		}
+		operand, err := args.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	controlStructure.value, controlStructure.max, controlStructure.width = operands[0], operands[1], operands[2]
+	if as, ok := parseAs(args); ok {
+		controlStructure.as = as
+	}
+	if !args.End() {
+		return nil, args.Error("Malformed 'widthratio' tag args.", args.Current())
+	}
+	return controlStructure, nil
+}
@@ -2,7 +2,6 @@ package controlStructures
 
 import (
 	"fmt"
-	"io"
 
 	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/nodes"
@@ -22,8 +21,12 @@ func (controlStructure *RawControlStructure) String() string {
 	return fmt.Sprintf("RawControlStructure(Line=%d Col=%d)", t.Line, t.Col)
 }
 
+func (controlStructure *RawControlStructure) Children() []nodes.Node {
+	return []nodes.Node{controlStructure.data}
+}
+
 func (controlStructure *RawControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
-	_, err := io.WriteString(r.Output, controlStructure.data.Data.Val)
+	_, err := r.Output.WriteString(controlStructure.data.Data.Val)
 	return err
 }
 
@@ -7,7 +7,6 @@ import (
 	"github.com/nikolalohinski/gonja/v2/nodes"
 	"github.com/nikolalohinski/gonja/v2/parser"
 	"github.com/nikolalohinski/gonja/v2/tokens"
-	"github.com/pkg/errors"
 )
 
 type SetControlStructure struct {
@@ -26,6 +25,25 @@ func (controlStructure *SetControlStructure) String() string {
 	return fmt.Sprintf("SetControlStructure(Line=%d Col=%d)", t.Line, t.Col)
 }
 
+func (controlStructure *SetControlStructure) Children() []nodes.Node {
+	children := []nodes.Node{controlStructure.target, controlStructure.expression}
+	if controlStructure.condition != nil {
+		children = append(children, controlStructure.condition)
+	}
+	if controlStructure.alternative != nil {
+		children = append(children, controlStructure.alternative)
+	}
+	return children
+}
+
+// DeclaredNames returns the name of the variable the control structure assigns, if any
+func (controlStructure *SetControlStructure) DeclaredNames() []string {
+	if name, ok := controlStructure.target.(*nodes.Name); ok {
+		return []string{name.Name.Val}
+	}
+	return nil
+}
+
 func (controlStructure *SetControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
 	var value *exec.Value
 	// Evaluate expression
@@ -43,42 +61,59 @@ func (controlStructure *SetControlStructure) Execute(r *exec.Renderer, tag *node
 		value = r.Eval(controlStructure.expression)
 	}
 	if value == nil {
-		return errors.Errorf(`Invalid value in 'set' tag: %s`, controlStructure.expression)
+		return fmt.Errorf(`Invalid value in 'set' tag: %s`, controlStructure.expression)
 	}
 	if value.IsError() {
 		return value
 	}
 
+	if name := targetName(controlStructure.target); name != "" && r.Environment.Context.IsReadOnly(name) {
+		return fmt.Errorf(`unable to set '%s': variable is read-only`, name)
+	}
+
 	switch n := controlStructure.target.(type) {
 	case *nodes.Name:
 		r.Environment.Context.Set(n.Name.Val, value.Interface())
 	case *nodes.GetAttribute:
 		target := r.Eval(n.Node)
 		if target.IsError() {
-			return errors.Wrapf(target, `Unable to evaluate target %s`, n)
+			return fmt.Errorf(`Unable to evaluate target %s: %w`, n, target)
 		}
 		if err := target.Set(exec.AsValue(n.Attribute), value.Interface()); err != nil {
-			return errors.Wrapf(err, `Unable to set value on "%s"`, n.Attribute)
+			return fmt.Errorf(`Unable to set value on "%s": %w`, n.Attribute, err)
 		}
 	case *nodes.GetItem:
 		target := r.Eval(n.Node)
 		if target.IsError() {
-			return errors.Wrapf(target, `Unable to evaluate target %s`, n)
+			return fmt.Errorf(`Unable to evaluate target %s: %w`, n, target)
 		}
 		arg := r.Eval(n.Arg)
 		if arg.IsError() {
-			return errors.Wrapf(target, `Unable to evaluate argument %s`, n.Arg)
+			return fmt.Errorf(`Unable to evaluate argument %s: %w`, n.Arg, target)
 		}
 		if err := target.Set(arg, value.Interface()); err != nil {
-			return errors.Wrapf(err, `Unable to set value on "%s"`, n.Arg)
+			return fmt.Errorf(`Unable to set value on "%s": %w`, n.Arg, err)
 		}
 	default:
-		return errors.Errorf(`Illegal set target node %s`, n)
+		return fmt.Errorf(`Illegal set target node %s`, n)
 	}
 
 	return nil
 }
 
+// targetName returns the name of the variable modified by a set target
+func targetName(target nodes.Expression) string {
+	switch n := target.(type) {
+	case *nodes.Name:
+		return n.Name.Val
+	case *nodes.GetAttribute:
+		return targetName(n.Node)
+	case *nodes.GetItem:
+		return targetName(n.Node)
+	}
+	return ""
+}
+
 func setParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
 	controlStructure := &SetControlStructure{
 		location: p.Current(),
@@ -87,13 +122,13 @@ func setParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, e
 	// Parse variable name
 	ident, err := args.ParseVariableOrLiteral()
 	if err != nil {
-		return nil, errors.Wrap(err, `unable to parse identifier`)
+		return nil, fmt.Errorf("unable to parse identifier: %w", err)
 	}
 	switch n := ident.(type) {
 	case *nodes.Name, *nodes.Call, *nodes.GetItem, *nodes.GetAttribute:
 		controlStructure.target = n
 	default:
-		return nil, errors.Errorf(`unexpected set target %s`, n)
+		return nil, fmt.Errorf(`unexpected set target %s`, n)
 	}
 
 	if args.Match(tokens.Assign) == nil {
@@ -0,0 +1,96 @@
+package controlStructures
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/parser"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+)
+
+type SpacelessControlStructure struct {
+	location *tokens.Token
+	wrapper  *nodes.Wrapper
+}
+
+func (controlStructure *SpacelessControlStructure) Position() *tokens.Token {
+	return controlStructure.location
+}
+func (controlStructure *SpacelessControlStructure) String() string {
+	t := controlStructure.Position()
+	return fmt.Sprintf("SpacelessControlStructure(Line=%d Col=%d)", t.Line, t.Col)
+}
+
+func (controlStructure *SpacelessControlStructure) Children() []nodes.Node {
+	return []nodes.Node{controlStructure.wrapper}
+}
+
+func (controlStructure *SpacelessControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	// the body is written as it renders rather than buffered, so that the nodes it holds keep their spans and
+	// that what is rendered before a break or a continue statement is kept
+	sub := r.Inherit()
+	sub.Output = r.OutputThrough(&spacelessOutput{output: r.Output})
+	return sub.ExecuteWrapper(controlStructure.wrapper)
+}
+
+// spacelessOutput removes the whitespace around the content written to it and between HTML tags. Whitespace
+// is held until the next content, and dropped if none follows.
+type spacelessOutput struct {
+	output exec.Output
+	// started is set once content other than whitespace is written, and afterTag when it ends with a tag
+	started  bool
+	afterTag bool
+	pending  string
+}
+
+func (o *spacelessOutput) Write(p []byte) (int, error) {
+	return o.WriteString(string(p))
+}
+
+func (o *spacelessOutput) WriteString(s string) (int, error) {
+	written := len(s)
+	for len(s) > 0 {
+		space := strings.TrimLeft(s, " \t\n\f\r")
+		if len(space) < len(s) {
+			if o.started {
+				o.pending += s[:len(s)-len(space)]
+			}
+			s = space
+			continue
+		}
+		end := strings.IndexAny(s, " \t\n\f\r")
+		if end < 0 {
+			end = len(s)
+		}
+		content := s[:end]
+		if o.pending != "" && !(o.afterTag && content[0] == '<') {
+			content = o.pending + content
+		}
+		if _, err := o.output.WriteString(content); err != nil {
+			return 0, err
+		}
+		o.started, o.afterTag, o.pending = true, content[len(content)-1] == '>', ""
+		s = s[end:]
+	}
+	return written, nil
+}
+
+func spacelessParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+	controlStructure := &SpacelessControlStructure{
+		location: p.Current(),
+	}
+
+	wrapper, _, err := p.WrapUntil("endspaceless")
+	if err != nil {
+		return nil, err
+	}
+	controlStructure.wrapper = wrapper
+
+	if !args.End() {
+		return nil, args.Error("Tag 'spaceless' does not take any argument.", args.Current())
+	}
+
+	return controlStructure, nil
+}
@@ -2,8 +2,7 @@ package controlStructures
 
 import (
 	"fmt"
-
-	"github.com/pkg/errors"
+	"sort"
 
 	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/nodes"
@@ -25,13 +24,36 @@ func (controlStructure *WithControlStructure) String() string {
 	return fmt.Sprintf("WithControlStructure(Line=%d Col=%d)", t.Line, t.Col)
 }
 
+func (controlStructure *WithControlStructure) Children() []nodes.Node {
+	names := make([]string, 0, len(controlStructure.pairs))
+	for name := range controlStructure.pairs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	children := []nodes.Node{}
+	for _, name := range names {
+		children = append(children, controlStructure.pairs[name])
+	}
+	return append(children, controlStructure.wrapper)
+}
+
+// DeclaredNames returns the names of the variables defined within the control structure
+func (controlStructure *WithControlStructure) DeclaredNames() []string {
+	names := make([]string, 0, len(controlStructure.pairs))
+	for name := range controlStructure.pairs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (controlStructure *WithControlStructure) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
 	sub := r.Inherit()
 
 	for key, value := range controlStructure.pairs {
 		val := r.Eval(value)
 		if val.IsError() {
-			return errors.Wrapf(val, `unable to evaluate parameter %s`, value)
+			return fmt.Errorf(`unable to evaluate parameter %s: %w`, value, val)
 		}
 		sub.Environment.Context.Set(key, val)
 	}
@@ -75,7 +97,7 @@ func withParser(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure,
 	}
 
 	if !args.End() {
-		return nil, errors.New("")
+		return nil, args.Error("", nil)
 	}
 
 	return controlStructure, nil
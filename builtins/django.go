@@ -0,0 +1,125 @@
+package builtins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	controlStructures "github.com/nikolalohinski/gonja/v2/builtins/control_structures"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/utils"
+)
+
+// DjangoFilters holds filters of Django templates which are not part of Filters. Their arguments are given
+// between parentheses, so that {{ value|date:"Y-m-d" }} is written {{ value|date("Y-m-d") }}.
+var DjangoFilters = exec.NewFilterSet(map[string]exec.FilterFunction{
+	"date":      filterDjangoDate,
+	"pluralize": filterPluralize,
+	"time":      filterDjangoTime,
+	"yesno":     filterYesno,
+})
+
+// Django returns an overlay of the environment adding the filters of DjangoFilters and the load, url and
+// static statements of Django templates, so that templates written for Django render with minimal edits.
+// The url and static statements build URLs with the given resolvers, which may be nil when unused.
+func Django(environment *exec.Environment, urls controlStructures.URLResolver, static controlStructures.StaticResolver) *exec.Environment {
+	django := environment.Overlay()
+	django.Filters.Update(DjangoFilters)
+	django.ControlStructures.Update(controlStructures.Django(urls, static))
+	return django
+}
+
+func filterDjangoDate(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	return formatDjangoTime(in, params, "N j, Y")
+}
+
+func filterDjangoTime(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	return formatDjangoTime(in, params, "P")
+}
+
+// formatDjangoTime formats dates as the date and time filters of Django do, rendering empty values as
+// an empty string
+func formatDjangoTime(in *exec.Value, params *exec.VarArgs, defaultFormat string) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var format string
+	if err := params.Take(
+		exec.PositionalArgument("format", exec.AsValue(defaultFormat), exec.StringArgument(&format)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if in.IsNil() || in.IsString() && in.String() == "" {
+		return exec.AsValue("")
+	}
+	t, err := asTime(in)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return exec.AsValue(utils.DateFormat(t, format))
+}
+
+func filterYesno(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var choices string
+	if err := params.Take(
+		exec.PositionalArgument("choices", exec.AsValue("yes,no,maybe"), exec.StringArgument(&choices)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	words := strings.Split(choices, ",")
+	if len(words) < 2 {
+		return in
+	}
+	if len(words) == 2 {
+		words = append(words, words[1])
+	}
+	switch {
+	case in.IsNil():
+		return exec.AsValue(words[2])
+	case in.Truthy(e.Config):
+		return exec.AsValue(words[0])
+	}
+	return exec.AsValue(words[1])
+}
+
+func filterPluralize(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var suffixes string
+	if err := params.Take(
+		exec.PositionalArgument("suffix", exec.AsValue("s"), exec.StringArgument(&suffixes)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	singular, plural := "", suffixes
+	if strings.Contains(suffixes, ",") {
+		bits := strings.Split(suffixes, ",")
+		if len(bits) > 2 {
+			return exec.AsValue("")
+		}
+		singular, plural = bits[0], bits[1]
+	}
+	var count float64
+	switch {
+	case in.IsNumber():
+		count = in.Float()
+	case in.IsString():
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(in.String()), 64)
+		if err != nil {
+			return exec.AsValue("")
+		}
+		count = parsed
+	case in.IsList() || in.IsDict():
+		count = float64(in.Len())
+	default:
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is neither a number nor a collection", in.String())))
+	}
+	if count == 1 {
+		return exec.AsValue(singular)
+	}
+	return exec.AsValue(plural)
+}
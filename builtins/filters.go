@@ -1,18 +1,21 @@
 package builtins
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
 	"net/url"
+	"reflect"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
 	json "github.com/json-iterator/go"
-	"github.com/pkg/errors"
 
 	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/utils"
@@ -22,14 +25,19 @@ import (
 var Filters = exec.NewFilterSet(map[string]exec.FilterFunction{
 	"abs":            filterAbs,
 	"attr":           filterAttr,
+	"basename":       filterBasename,
 	"batch":          filterBatch,
+	"bool":           filterBool,
 	"capitalize":     filterCapitalize,
 	"center":         filterCenter,
 	"default":        filterDefault,
 	"d":              filterDefault,
 	"dictsort":       filterDictSort,
+	"dirname":        filterDirname,
 	"e":              filterEscape,
 	"escape":         filterEscape,
+	"expanduser":     filterExpandUser,
+	"extract":        filterExtract,
 	"filesizeformat": filterFileSize,
 	"first":          filterFirst,
 	"float":          filterFloat,
@@ -38,18 +46,27 @@ var Filters = exec.NewFilterSet(map[string]exec.FilterFunction{
 	"groupby":        filterGroupBy,
 	"indent":         filterIndent,
 	"int":            filterInteger,
+	"intcomma":       filterIntComma,
 	"join":           filterJoin,
 	"last":           filterLast,
 	"length":         filterLength,
 	"list":           filterList,
 	"lower":          filterLower,
+	"mandatory":      filterMandatory,
 	"map":            filterMap,
 	"max":            filterMax,
 	"min":            filterMin,
+	"naturaldate":    filterNaturalDate,
+	"naturalsize":    filterNaturalSize,
+	"naturaltime":    filterNaturalTime,
+	"nl2br":          filterNl2br,
+	"ordinal":        filterOrdinal,
 	"pprint":         filterPPrint,
 	"random":         filterRandom,
+	"realpath":       filterRealPath,
 	"rejectattr":     filterRejectAttr,
 	"reject":         filterReject,
+	"relpath":        filterRelPath,
 	"replace":        filterReplace,
 	"reverse":        filterReverse,
 	"round":          filterRound,
@@ -58,17 +75,25 @@ var Filters = exec.NewFilterSet(map[string]exec.FilterFunction{
 	"select":         filterSelect,
 	"slice":          filterSlice,
 	"sort":           filterSort,
+	"splitext":       filterSplitExt,
+	"strftime":       filterStrftime,
 	"string":         filterString,
 	"striptags":      filterStriptags,
 	"sum":            filterSum,
+	"ternary":        filterTernary,
 	"title":          filterTitle,
+	"to_datetime":    filterToDatetime,
+	"to_duration":    filterToDuration,
 	"tojson":         filterToJSON,
 	"trim":           filterTrim,
 	"truncate":       filterTruncate,
+	"type_debug":     filterTypeDebug,
 	"unique":         filterUnique,
 	"upper":          filterUpper,
 	"urlencode":      filterUrlencode,
 	"urlize":         filterUrlize,
+	"win_basename":   filterWinBasename,
+	"win_dirname":    filterWinDirname,
 	"wordcount":      filterWordcount,
 	"wordwrap":       filterWordwrap,
 	"xmlattr":        filterXMLAttr,
@@ -79,7 +104,7 @@ func filterAbs(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Va
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'abs'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'abs': %w", p))
 	}
 	if in.IsInteger() {
 		asInt := in.Integer()
@@ -99,20 +124,33 @@ func filterAttr(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.V
 	}
 	p := params.ExpectArgs(1)
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'attr'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'attr': %w", p))
 	}
 	attr := p.First().String()
 	value, _ := in.GetAttribute(attr)
 	return value
 }
 
+func filterBasename(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if err := params.Take(); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if !in.IsString() {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not a string", in.String())))
+	}
+	return exec.AsValue(utils.PosixBasename(in.String()))
+}
+
 func filterBatch(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
 	if in.IsError() {
 		return in
 	}
 	p := params.Expect(1, []*exec.KwArg{{Name: "fill_with", Default: nil}})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'batch'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'batch': %w", p))
 	}
 	size := p.First().Integer()
 	out := make([]interface{}, 0)
@@ -139,12 +177,52 @@ func filterBatch(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.
 	return exec.AsValue(out)
 }
 
+// booleanStrings lists the strings the bool filter recognizes, in lower case
+var booleanStrings = map[string]bool{
+	"yes": true, "y": true, "on": true, "true": true, "1": true,
+	"no": false, "n": false, "off": false, "false": false, "0": false,
+}
+
+func filterBool(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var strict bool
+	if err := params.Take(
+		exec.KeywordArgument("strict", exec.AsValue(false), exec.BoolArgument(&strict)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	switch {
+	case in.IsBool():
+		return in
+	case in.IsInteger():
+		return exec.AsValue(in.Integer() != 0)
+	case in.IsFloat():
+		return exec.AsValue(in.Float() != 0)
+	case in.IsString():
+		text := strings.ToLower(strings.TrimSpace(in.String()))
+		if value, ok := booleanStrings[text]; ok {
+			return exec.AsValue(value)
+		}
+		if number, err := strconv.ParseFloat(text, 64); err == nil {
+			return exec.AsValue(number != 0)
+		}
+	}
+	if strict && in.IsNil() {
+		return exec.AsValue(exec.ErrInvalidCall(errors.New("None is not a boolean")))
+	} else if strict {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not a boolean", in.String())))
+	}
+	return exec.AsValue(false)
+}
+
 func filterCapitalize(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
 	if in.IsError() {
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'capitalize'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'capitalize': %w", p))
 	}
 
 	if !in.IsString() {
@@ -163,11 +241,12 @@ func filterCenter(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec
 	if in.IsError() {
 		return in
 	}
-	p := params.ExpectArgs(1)
-	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'center'"))
+	var width int
+	if err := params.Take(
+		exec.KeywordArgument("width", exec.AsValue(80), exec.IntArgument(&width)),
+	); err != nil {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'center': %w", err))
 	}
-	width := p.First().Integer()
 	slen := in.Len()
 	if width <= slen {
 		return in
@@ -227,14 +306,33 @@ func filterDictSort(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *ex
 		{Name: "case_sensitive", Default: false},
 		{Name: "by", Default: "key"},
 		{Name: "reverse", Default: false},
+		{Name: "locale", Default: ""},
 	})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'dictsort'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'dictsort': %w", p))
 	}
 
 	caseSensitive := p.KwArgs["case_sensitive"].Bool()
 	by := p.KwArgs["by"].String()
 	reverse := p.KwArgs["reverse"].Bool()
+	collator, err := getCollator(e, p.KwArgs["locale"].String(), caseSensitive)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+
+	if collator != nil && (by == "key" || by == "value") {
+		pairs := []*exec.Pair{}
+		in.Iterate(func(idx, count int, key, value *exec.Value) bool {
+			pairs = append(pairs, &exec.Pair{Key: key, Value: value})
+			return true
+		}, func() {})
+		collatePairs(collator, pairs, by == "value", reverse)
+		out := make([][2]interface{}, 0, len(pairs))
+		for _, pair := range pairs {
+			out = append(out, [2]interface{}{pair.Key.Interface(), pair.Value.Interface()})
+		}
+		return exec.AsValue(out)
+	}
 
 	switch by {
 	case "key":
@@ -246,12 +344,25 @@ func filterDictSort(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *ex
 	}
 }
 
+func filterDirname(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if err := params.Take(); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if !in.IsString() {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not a string", in.String())))
+	}
+	return exec.AsValue(utils.PosixDirname(in.String()))
+}
+
 func filterEscape(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
 	if in.IsError() {
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'escape'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'escape': %w", p))
 	}
 	if in.Safe {
 		return in
@@ -264,13 +375,69 @@ var (
 	binaryPrefixes = []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB", "ZiB", "YiB"}
 )
 
+func filterExpandUser(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if err := params.Take(); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if !in.IsString() {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not a string", in.String())))
+	}
+	return exec.AsValue(utils.ExpandUser(in.String()))
+}
+
+func filterExtract(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var container, morekeys *exec.Value
+	if err := params.Take(
+		exec.PositionalArgument("container", nil, func(v *exec.Value) error { container = v; return nil }),
+		exec.KeywordArgument("morekeys", exec.AsValue(nil), func(v *exec.Value) error { morekeys = v; return nil }),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	keys := []*exec.Value{in}
+	if morekeys.IsList() {
+		morekeys.Iterate(func(idx, count int, key, value *exec.Value) bool {
+			keys = append(keys, key)
+			return true
+		}, func() {})
+	} else if !morekeys.IsNil() {
+		keys = append(keys, morekeys)
+	}
+	value := container
+	for _, key := range keys {
+		var item *exec.Value
+		found := false
+		if key.IsInteger() {
+			item, found = value.GetItem(key.Integer())
+		} else if !key.IsNil() {
+			item, found = value.GetItem(key.String())
+			if !found {
+				item, found = value.GetAttribute(key.String())
+			}
+		}
+		if !found {
+			if e.Config.StrictUndefined {
+				return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("item '%s' not found", key.String())))
+			}
+			return exec.AsValue(nil)
+		}
+		value = item
+	}
+	return value
+}
+
 func filterFileSize(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
 	if in.IsError() {
 		return in
 	}
 	p := params.Expect(0, []*exec.KwArg{{Name: "binary", Default: false}})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'filesizeformat'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'filesizeformat': %w", p))
 	}
 	bytes := in.Float()
 	binary := p.KwArgs["binary"].Bool()
@@ -306,7 +473,7 @@ func filterFirst(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'first'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'first': %w", p))
 	}
 	if in.CanSlice() && in.Len() > 0 {
 		return in.Index(0)
@@ -319,7 +486,7 @@ func filterFloat(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'float'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'float': %w", p))
 	}
 	if in.IsNil() {
 		return exec.AsValue(0.0)
@@ -332,7 +499,7 @@ func filterForceEscape(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs)
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'forceescape'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'forceescape': %w", p))
 	}
 	return exec.AsSafeValue(in.Escaped())
 }
@@ -352,11 +519,15 @@ func filterGroupBy(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exe
 	if in.IsError() {
 		return in
 	}
-	p := params.ExpectArgs(1)
+	p := params.Expect(1, []*exec.KwArg{{Name: "locale", Default: ""}})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'groupby"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'groupby: %w", p))
 	}
 	field := p.First().String()
+	collator, err := getCollator(e, p.KwArgs["locale"].String(), false)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
 	groups := make(map[interface{}][]interface{})
 	groupers := []interface{}{}
 
@@ -375,6 +546,12 @@ func filterGroupBy(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exe
 		return true
 	}, func() {})
 
+	if collator != nil {
+		sort.SliceStable(groupers, func(i, j int) bool {
+			return collatedLess(collator, exec.AsValue(groupers[i]), exec.AsValue(groupers[j]))
+		})
+	}
+
 	out := make([]map[string]interface{}, 0)
 	for _, grouper := range groupers {
 		out = append(out, map[string]interface{}{
@@ -421,12 +598,35 @@ func filterIndent(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec
 	return exec.AsValue(out.String())
 }
 
+func filterIntComma(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var name string
+	if err := params.Take(
+		exec.KeywordArgument("locale", exec.AsValue("en"), exec.StringArgument(&name)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	locale, err := getHumanizeLocale(name)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	switch {
+	case in.IsInteger():
+		return exec.AsValue(formatNumber(strconv.Itoa(in.Integer()), locale))
+	case in.IsFloat():
+		return exec.AsValue(formatNumber(formatFloat(in.Float()), locale))
+	}
+	return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not a number", in.String())))
+}
+
 func filterInteger(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
 	if in.IsError() {
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'int'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'int': %w", p))
 	}
 	if in.IsNil() {
 		return exec.AsValue(0)
@@ -443,7 +643,7 @@ func filterJoin(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.V
 		{Name: "attribute", Default: nil},
 	})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'join'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'join': %w", p))
 	}
 	if !in.CanSlice() {
 		return in
@@ -461,7 +661,7 @@ func filterLast(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.V
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'last'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'last': %w", p))
 	}
 	if in.CanSlice() && in.Len() > 0 {
 		return in.Index(in.Len() - 1)
@@ -474,7 +674,7 @@ func filterLength(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'length'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'length': %w", p))
 	}
 	return exec.AsValue(in.Len())
 }
@@ -484,20 +684,30 @@ func filterList(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.V
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'list'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'list': %w", p))
 	}
 	if in.IsString() {
 		out := []string{}
 		for _, r := range in.String() {
+			if err := e.SpendIterations(1); err != nil {
+				return exec.AsValue(fmt.Errorf("Unable to build a list: %w", err))
+			}
 			out = append(out, string(r))
 		}
 		return exec.AsValue(out)
 	}
 	out := make([]interface{}, 0)
+	var err error
 	in.Iterate(func(idx, count int, key, value *exec.Value) bool {
+		if err = e.SpendIterations(1); err != nil {
+			return false
+		}
 		out = append(out, key.Interface())
 		return true
 	}, func() {})
+	if err != nil {
+		return exec.AsValue(fmt.Errorf("Unable to build a list: %w", err))
+	}
 	return exec.AsValue(out)
 }
 
@@ -506,22 +716,62 @@ func filterLower(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'lower'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'lower': %w", p))
 	}
 	return exec.AsValue(strings.ToLower(in.String()))
 }
 
+func filterMandatory(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var message string
+	if err := params.Take(
+		exec.KeywordArgument("msg", exec.AsValue("Mandatory variable not defined."), exec.StringArgument(&message)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if in.IsUndefined() {
+		return exec.AsValue(errors.New(message))
+	}
+	return in
+}
+
 func filterMap(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
 	if in.IsError() {
 		return in
 	}
+	// map("name", *args, **kwargs) applies the filter with the remaining arguments to each item
+	if len(params.Args) > 1 {
+		filter := params.Args[0].String()
+		arguments := &exec.VarArgs{Args: params.Args[1:], KwArgs: params.KwArgs}
+		out := make([]interface{}, 0)
+		var failure *exec.Value
+		in.Iterate(func(idx, count int, key, value *exec.Value) bool {
+			forwarded := &exec.VarArgs{Args: arguments.Args, KwArgs: make(map[string]*exec.Value, len(arguments.KwArgs))}
+			for name, argument := range arguments.KwArgs {
+				forwarded.KwArgs[name] = argument
+			}
+			val := e.ExecuteFilterByName(filter, key, forwarded)
+			if val.IsError() {
+				failure = val
+				return false
+			}
+			out = append(out, val.Interface())
+			return true
+		}, func() {})
+		if failure != nil {
+			return failure
+		}
+		return exec.AsValue(out)
+	}
 	p := params.Expect(0, []*exec.KwArg{
 		{Name: "filter", Default: ""},
 		{Name: "attribute", Default: nil},
 		{Name: "default", Default: nil},
 	})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'map'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'map': %w", p))
 	}
 	filter := p.KwArgs["filter"].String()
 	attribute := p.KwArgs["attribute"].String()
@@ -557,7 +807,7 @@ func filterMax(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Va
 		{Name: "attribute", Default: nil},
 	})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'max'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'max': %w", p))
 	}
 	caseSensitive := p.KwArgs["case_sensitive"].Bool()
 	attribute := p.KwArgs["attribute"].String()
@@ -592,7 +842,7 @@ func filterMax(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Va
 				max = val
 			}
 		default:
-			max = exec.AsValue(errors.Errorf(`%s and %s are not comparable`, max.Val.Type(), val.Val.Type()))
+			max = exec.AsValue(fmt.Errorf(`%s and %s are not comparable`, max.Val.Type(), val.Val.Type()))
 		}
 		return true
 	}, func() {})
@@ -612,7 +862,7 @@ func filterMin(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Va
 		{Name: "attribute", Default: nil},
 	})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'min'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'min': %w", p))
 	}
 	caseSensitive := p.KwArgs["case_sensitive"].Bool()
 	attribute := p.KwArgs["attribute"].String()
@@ -647,7 +897,7 @@ func filterMin(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Va
 				min = val
 			}
 		default:
-			min = exec.AsValue(errors.Errorf(`%s and %s are not comparable`, min.Val.Type(), val.Val.Type()))
+			min = exec.AsValue(fmt.Errorf(`%s and %s are not comparable`, min.Val.Type(), val.Val.Type()))
 		}
 		return true
 	}, func() {})
@@ -658,19 +908,143 @@ func filterMin(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Va
 	return min
 }
 
+func filterNaturalDate(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var name string
+	if err := params.Take(
+		exec.KeywordArgument("locale", exec.AsValue("en"), exec.StringArgument(&name)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	locale, err := getHumanizeLocale(name)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	t, err := asTime(in)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	now := time.Now()
+	if day, ok := naturalDay(t, now, locale); ok {
+		return exec.AsValue(day)
+	}
+	format := locale.DateFormat
+	if delta := t.Sub(now); delta > 5*30*24*time.Hour || delta < -5*30*24*time.Hour {
+		format = locale.DateFormatWithYear
+	}
+	formatted, err := utils.Strftime(t, format)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return exec.AsValue(formatted)
+}
+
+func filterNaturalSize(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var (
+		binary bool
+		name   string
+	)
+	if err := params.Take(
+		exec.KeywordArgument("binary", exec.AsValue(false), exec.BoolArgument(&binary)),
+		exec.KeywordArgument("locale", exec.AsValue("en"), exec.StringArgument(&name)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	locale, err := getHumanizeLocale(name)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if !in.IsNumber() || in.Float() < 0 {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not a positive number", in.String())))
+	}
+	return exec.AsValue(formatSize(uint64(in.Float()), binary, locale))
+}
+
+func filterNaturalTime(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var name string
+	if err := params.Take(
+		exec.KeywordArgument("locale", exec.AsValue("en"), exec.StringArgument(&name)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	locale, err := getHumanizeLocale(name)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	t, err := asTime(in)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return exec.AsValue(locale.RelativeTime(t, time.Now()))
+}
+
+var filterNl2brParagraphs = regexp.MustCompile(`\n{2,}`)
+
+// filterNl2br escapes the text, unless it is already safe, then wraps its paragraphs into <p> tags and
+// breaks its other lines with <br> tags
+func filterNl2br(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if err := params.Take(); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	text := in.String()
+	if !in.Safe {
+		text = in.Escaped()
+	}
+	text = strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n"))
+	if text == "" {
+		return exec.AsSafeValue("")
+	}
+	paragraphs := filterNl2brParagraphs.Split(text, -1)
+	for index, paragraph := range paragraphs {
+		paragraphs[index] = "<p>" + strings.ReplaceAll(paragraph, "\n", "<br>\n") + "</p>"
+	}
+	return exec.AsSafeValue(strings.Join(paragraphs, "\n\n"))
+}
+
+func filterOrdinal(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var name string
+	if err := params.Take(
+		exec.KeywordArgument("locale", exec.AsValue("en"), exec.StringArgument(&name)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	locale, err := getHumanizeLocale(name)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if !in.IsInteger() {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not an integer", in.String())))
+	}
+	return exec.AsValue(locale.Ordinal(in.Integer()))
+}
+
 func filterPPrint(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
 	if in.IsError() {
 		return in
 	}
 	p := params.Expect(0, []*exec.KwArg{{Name: "verbose", Default: false}})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'pprint'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'pprint': %w", p))
 	}
 	b, err := json.MarshalIndent(in.Interface(), "", "  ")
 	if err != nil {
-		return exec.AsValue(errors.Wrapf(err, `Unable to pretty print '%s'`, in.String()))
+		return exec.AsValue(fmt.Errorf(`Unable to pretty print '%s': %w`, in.String(), err))
 	}
-	return exec.AsSafeValue(string(b))
+	return exec.AsSafeValue(e.Environment.Context.Redact(string(b)))
 }
 
 func filterRandom(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
@@ -678,7 +1052,7 @@ func filterRandom(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'random'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'random': %w", p))
 	}
 	if !in.CanSlice() || in.Len() <= 0 {
 		return in
@@ -687,6 +1061,43 @@ func filterRandom(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec
 	return in.Index(i)
 }
 
+func filterRealPath(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if err := params.Take(); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if !in.IsString() {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not a string", in.String())))
+	}
+	path, err := utils.RealPath(in.String())
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return exec.AsValue(path)
+}
+
+func filterRelPath(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var start string
+	if err := params.Take(
+		exec.KeywordArgument("start", exec.AsValue("."), exec.StringArgument(&start)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if !in.IsString() {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not a string", in.String())))
+	}
+	path, err := utils.RelPath(in.String(), start)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return exec.AsValue(path)
+}
+
 func filterReject(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
 	if in.IsError() {
 		return in
@@ -695,7 +1106,7 @@ func filterReject(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec
 	if len(params.Args) == 0 {
 		// Reject truthy value
 		test = func(in *exec.Value) bool {
-			return in.IsTrue()
+			return in.Truthy(e.Config)
 		}
 	} else {
 		name := params.First().String()
@@ -705,7 +1116,7 @@ func filterReject(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec
 		}
 		test = func(in *exec.Value) bool {
 			out := e.ExecuteTestByName(name, in, testParams)
-			return out.IsTrue()
+			return out.Truthy(e.Config)
 		}
 	}
 
@@ -735,7 +1146,7 @@ func filterRejectAttr(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *
 		test = func(in *exec.Value) *exec.Value {
 			attr, found := in.Get(attribute)
 			if !found {
-				return exec.AsValue(errors.Errorf(`%s has no attribute '%s'`, in.String(), attribute))
+				return exec.AsValue(fmt.Errorf(`%s has no attribute '%s'`, in.String(), attribute))
 			}
 			return attr
 		}
@@ -748,7 +1159,7 @@ func filterRejectAttr(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *
 		test = func(in *exec.Value) *exec.Value {
 			attr, found := in.Get(attribute)
 			if !found {
-				return exec.AsValue(errors.Errorf(`%s has no attribute '%s'`, in.String(), attribute))
+				return exec.AsValue(fmt.Errorf(`%s has no attribute '%s'`, in.String(), attribute))
 			}
 			out := e.ExecuteTestByName(name, attr, testParams)
 			return out
@@ -764,7 +1175,7 @@ func filterRejectAttr(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *
 			err = result
 			return false
 		}
-		if !result.IsTrue() {
+		if !result.Truthy(e.Config) {
 			out = append(out, key.Interface())
 		}
 		return true
@@ -782,7 +1193,7 @@ func filterReplace(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exe
 	}
 	p := params.Expect(2, []*exec.KwArg{{Name: "count", Default: nil}})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'replace'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'replace': %w", p))
 	}
 	old := p.Args[0].String()
 	new := p.Args[1].String()
@@ -798,7 +1209,7 @@ func filterReverse(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exe
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'safe'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'safe': %w", p))
 	}
 	if in.IsString() {
 		var out strings.Builder
@@ -822,7 +1233,7 @@ func filterRound(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.
 	}
 	p := params.Expect(0, []*exec.KwArg{{Name: "precision", Default: 0}, {Name: "method", Default: "common"}})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'round'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'round': %w", p))
 	}
 	method := p.KwArgs["method"].String()
 	var op func(float64) float64
@@ -834,7 +1245,7 @@ func filterRound(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.
 	case "ceil":
 		op = math.Ceil
 	default:
-		return exec.AsValue(errors.Errorf(`Unknown method '%s', mush be one of 'common, 'floor', 'ceil`, method))
+		return exec.AsValue(fmt.Errorf(`Unknown method '%s', mush be one of 'common, 'floor', 'ceil`, method))
 	}
 	value := in.Float()
 	factor := float64(10 * p.KwArgs["precision"].Integer())
@@ -853,7 +1264,7 @@ func filterSafe(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.V
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'safe'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'safe': %w", p))
 	}
 	in.Safe = true
 	return in // nothing to do here, just to keep track of the safe application
@@ -867,7 +1278,7 @@ func filterSelect(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec
 	if len(params.Args) == 0 {
 		// Reject truthy value
 		test = func(in *exec.Value) bool {
-			return in.IsTrue()
+			return in.Truthy(e.Config)
 		}
 	} else {
 		name := params.First().String()
@@ -877,7 +1288,7 @@ func filterSelect(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec
 		}
 		test = func(in *exec.Value) bool {
 			out := e.ExecuteTestByName(name, in, testParams)
-			return out.IsTrue()
+			return out.Truthy(e.Config)
 		}
 	}
 
@@ -932,17 +1343,51 @@ func filterSlice(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.
 	return exec.AsValue(output)
 }
 
+func filterSplitExt(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if err := params.Take(); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if !in.IsString() {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not a string", in.String())))
+	}
+	root, extension := utils.SplitExt(in.String())
+	return exec.AsValue([]interface{}{root, extension})
+}
+
 func filterSort(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
 	if in.IsError() {
 		return in
 	}
-	p := params.Expect(0, []*exec.KwArg{{Name: "reverse", Default: false}, {Name: "case_sensitive", Default: false}})
+	p := params.Expect(0, []*exec.KwArg{
+		{Name: "reverse", Default: false},
+		{Name: "case_sensitive", Default: false},
+		{Name: "locale", Default: ""},
+	})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'sort'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'sort': %w", p))
 	}
 	reverse := p.KwArgs["reverse"].Bool()
 	caseSensitive := p.KwArgs["case_sensitive"].Bool()
+	collator, err := getCollator(e, p.KwArgs["locale"].String(), caseSensitive)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
 	out := make([]interface{}, 0)
+	if collator != nil && !in.IsString() {
+		values := []*exec.Value{}
+		in.Iterate(func(idx, count int, key, value *exec.Value) bool {
+			values = append(values, key)
+			return true
+		}, func() {})
+		collateValues(collator, values, reverse)
+		for _, value := range values {
+			out = append(out, value.Interface())
+		}
+		return exec.AsValue(out)
+	}
 	in.IterateOrder(func(idx, count int, key, value *exec.Value) bool {
 		out = append(out, key.Interface())
 		return true
@@ -950,12 +1395,70 @@ func filterSort(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.V
 	return exec.AsValue(out)
 }
 
+func filterStrftime(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var (
+		format   string
+		timezone string
+	)
+	if err := params.Take(
+		exec.PositionalArgument("format", nil, exec.StringArgument(&format)),
+		exec.KeywordArgument("tz", exec.AsValue(""), exec.StringArgument(&timezone)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	t, err := asTime(in)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("unknown timezone '%s'", timezone)))
+		}
+		t = t.In(location)
+	}
+	formatted, err := utils.Strftime(t, format)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return exec.AsValue(formatted)
+}
+
+// asTime converts time.Time values, RFC3339 strings and unix timestamps into a time.Time
+func asTime(in *exec.Value) (time.Time, error) {
+	switch {
+	case in.IsNil():
+		return time.Time{}, fmt.Errorf("None is not a date")
+	case in.IsString():
+		t, err := time.Parse(time.RFC3339Nano, in.String())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s is not a RFC3339 date", in.String())
+		}
+		return t, nil
+	case in.IsInteger():
+		return time.Unix(int64(in.Integer()), 0).UTC(), nil
+	case in.IsFloat():
+		seconds, fraction := math.Modf(in.Float())
+		return time.Unix(int64(seconds), int64(fraction*float64(time.Second))).UTC(), nil
+	}
+	switch t := in.Interface().(type) {
+	case time.Time:
+		return t, nil
+	case *time.Time:
+		return *t, nil
+	}
+	return time.Time{}, fmt.Errorf("%s is not a date", in.String())
+}
+
 func filterString(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
 	if in.IsError() {
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'string'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'string': %w", p))
 	}
 	return exec.AsValue(in.String())
 }
@@ -967,7 +1470,7 @@ func filterStriptags(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *e
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'striptags'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'striptags': %w", p))
 	}
 	s := in.String()
 
@@ -983,7 +1486,7 @@ func filterSum(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Va
 	}
 	p := params.Expect(0, []*exec.KwArg{{Name: "attribute", Default: nil}, {Name: "start", Default: 0}})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'sum'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'sum': %w", p))
 	}
 
 	attribute := p.KwArgs["attribute"]
@@ -997,7 +1500,7 @@ func filterSum(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Va
 			for _, attr := range strings.Split(attribute.String(), ".") {
 				val, found = val.Get(attr)
 				if !found {
-					err = errors.Errorf("'%s' has no attribute '%s'", key.String(), attribute.String())
+					err = fmt.Errorf("'%s' has no attribute '%s'", key.String(), attribute.String())
 					return false
 				}
 			}
@@ -1023,12 +1526,36 @@ func filterSum(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Va
 	return exec.AsValue(sum)
 }
 
+func filterTernary(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	// none values get the false value unless a value for them is given, even if it is none itself
+	_, hasNoneValue := params.KwArgs["none_val"]
+	hasNoneValue = hasNoneValue || len(params.Args) > 2
+	var trueValue, falseValue, noneValue interface{}
+	if err := params.Take(
+		exec.PositionalArgument("true_val", nil, exec.AnyArgument(&trueValue)),
+		exec.PositionalArgument("false_val", nil, exec.AnyArgument(&falseValue)),
+		exec.KeywordArgument("none_val", exec.AsValue(nil), exec.AnyArgument(&noneValue)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	switch {
+	case hasNoneValue && in.IsNil():
+		return exec.ToValue(noneValue)
+	case in.Truthy(e.Config):
+		return exec.ToValue(trueValue)
+	}
+	return exec.ToValue(falseValue)
+}
+
 func filterTitle(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
 	if in.IsError() {
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'title'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'title': %w", p))
 	}
 	if !in.IsString() {
 		return exec.AsValue("")
@@ -1043,12 +1570,61 @@ func filterTrim(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.V
 	}
 	p := params.ExpectKwArgs([]*exec.KwArg{&charsParam})
 	if p.IsError() || !in.IsString() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'trim'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'trim': %w", p))
 	}
 	chars := p.GetKeywordArgument(charsParam.Name, charsParam.Default).String()
 	return exec.AsValue(strings.Trim(in.String(), chars))
 }
 
+func filterToDatetime(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var format string
+	if err := params.Take(
+		exec.KeywordArgument("format", exec.AsValue("%Y-%m-%d"), exec.StringArgument(&format)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if in.IsTime() {
+		return in
+	}
+	if !in.IsString() {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not a string", in.String())))
+	}
+	layout, err := utils.StrftimeLayout(format)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	t, err := time.Parse(layout, in.String())
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("'%s' does not match format '%s'", in.String(), format)))
+	}
+	return exec.AsValue(t)
+}
+
+func filterToDuration(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if err := params.Take(); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	switch {
+	case in.IsDuration():
+		return in
+	case in.IsNumber():
+		return exec.AsValue(in.Duration())
+	case in.IsString():
+		duration, err := time.ParseDuration(in.String())
+		if err != nil {
+			return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("'%s' is not a valid duration", in.String())))
+		}
+		return exec.AsValue(duration)
+	}
+	return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not a string nor a number", in.String())))
+}
+
 func filterToJSON(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
 	// Done not mess around with trying to marshall error pipelines
 	if in.IsError() {
@@ -1070,7 +1646,7 @@ func filterToJSON(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec
 		{Name: "ensure_ascii", Default: exec.AsValue(true)}, // Accepted for compatibility, ignored (Go handles unicode)
 	})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'tojson'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'tojson': %w", p))
 	}
 
 	casted := in.ToGoSimpleType(true)
@@ -1083,17 +1659,17 @@ func filterToJSON(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec
 	if indent.IsNil() {
 		b, err := json.ConfigCompatibleWithStandardLibrary.Marshal(casted)
 		if err != nil {
-			return exec.AsValue(errors.Wrap(err, "Unable to marhsall to json"))
+			return exec.AsValue(fmt.Errorf("Unable to marhsall to json: %w", err))
 		}
 		out = string(b)
 	} else if indent.IsInteger() {
 		b, err := json.ConfigCompatibleWithStandardLibrary.MarshalIndent(casted, "", strings.Repeat(" ", indent.Integer()))
 		if err != nil {
-			return exec.AsValue(errors.Wrap(err, "Unable to marhsall to json"))
+			return exec.AsValue(fmt.Errorf("Unable to marhsall to json: %w", err))
 		}
 		out = string(b)
 	} else {
-		return exec.AsValue(errors.Errorf("Expected an integer for 'indent', got %s", indent.String()))
+		return exec.AsValue(fmt.Errorf("Expected an integer for 'indent', got %s", indent.String()))
 	}
 	return exec.AsSafeValue(out)
 }
@@ -1109,7 +1685,7 @@ func filterTruncate(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *ex
 		{Name: "leeway", Default: 0},
 	})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'truncate'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'truncate': %w", p))
 	}
 
 	source := in.String()
@@ -1122,7 +1698,7 @@ func filterTruncate(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *ex
 	runes := []rune(source)
 
 	if length < len(rEnd) {
-		return exec.AsValue(errors.Errorf(`expected length >= %d, got %d`, len(rEnd), length))
+		return exec.AsValue(fmt.Errorf(`expected length >= %d, got %d`, len(rEnd), length))
 	}
 
 	if len(runes) <= fullLength {
@@ -1139,13 +1715,79 @@ func filterTruncate(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *ex
 	return exec.AsValue(fmt.Sprintf("%s%s", atLength, end))
 }
 
+func filterTypeDebug(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'type_debug': %w", p))
+	}
+	if in.IsNil() {
+		return exec.AsValue("nil")
+	}
+	if dict, ok := in.Interface().(*exec.Dict); ok {
+		values := make([]interface{}, 0, len(dict.Pairs))
+		for _, pair := range dict.Pairs {
+			values = append(values, pair.Value.Interface())
+		}
+		return exec.AsValue(fmt.Sprintf("%T (values: %s)", dict, typeNames(values)))
+	}
+	value := reflect.ValueOf(in.Interface())
+	description := value.Type().String()
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Interface && value.Len() > 0 {
+			elements := make([]interface{}, 0, value.Len())
+			for i := 0; i < value.Len(); i++ {
+				elements = append(elements, value.Index(i).Interface())
+			}
+			description += fmt.Sprintf(" (elements: %s)", typeNames(elements))
+		}
+	case reflect.Map:
+		keys, values := []interface{}{}, []interface{}{}
+		for iterator := value.MapRange(); iterator.Next(); {
+			keys = append(keys, iterator.Key().Interface())
+			values = append(values, iterator.Value().Interface())
+		}
+		details := []string{}
+		if value.Type().Key().Kind() == reflect.Interface && len(keys) > 0 {
+			details = append(details, "keys: "+typeNames(keys))
+		}
+		if value.Type().Elem().Kind() == reflect.Interface && len(values) > 0 {
+			details = append(details, "values: "+typeNames(values))
+		}
+		if len(details) > 0 {
+			description += fmt.Sprintf(" (%s)", strings.Join(details, ", "))
+		}
+	}
+	return exec.AsValue(description)
+}
+
+// typeNames returns the sorted and distinct names of the dynamic types of the given values
+func typeNames(values []interface{}) string {
+	distinct := map[string]bool{}
+	for _, value := range values {
+		if value == nil {
+			distinct["nil"] = true
+		} else {
+			distinct[reflect.TypeOf(value).String()] = true
+		}
+	}
+	names := make([]string, 0, len(distinct))
+	for name := range distinct {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
 func filterUnique(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
 	if in.IsError() {
 		return in
 	}
 	p := params.Expect(0, []*exec.KwArg{{Name: "case_sensitive", Default: false}, {Name: "attribute", Default: nil}})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'unique'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'unique': %w", p))
 	}
 
 	caseSensitive := p.KwArgs["case_sensitive"].Bool()
@@ -1161,7 +1803,7 @@ func filterUnique(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec
 			attr := attribute.String()
 			nested, found := key.Get(attr)
 			if !found {
-				err = errors.Errorf(`%s has no attribute %s`, key.String(), attr)
+				err = fmt.Errorf(`%s has no attribute %s`, key.String(), attr)
 				return false
 			}
 			val = nested
@@ -1188,7 +1830,7 @@ func filterUpper(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'upper'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'upper': %w", p))
 	}
 	return exec.AsValue(strings.ToUpper(in.String()))
 }
@@ -1198,7 +1840,7 @@ func filterUrlencode(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *e
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'urlencode'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'urlencode': %w", p))
 	}
 	return exec.AsValue(url.QueryEscape(in.String()))
 }
@@ -1206,10 +1848,49 @@ func filterUrlencode(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *e
 // TODO: This regexp could do some work
 var filterUrlizeURLRegexp = regexp.MustCompile(`((((http|https)://)|www\.|((^|[ ])[0-9A-Za-z_\-]+(\.com|\.net|\.org|\.info|\.biz|\.de))))(?U:.*)([ ]+|$)`)
 var filterUrlizeEmailRegexp = regexp.MustCompile(`(\w+@\w+\.\w{2,4})`)
+var filterUrlizeSchemeRegexp = regexp.MustCompile(`^[\w.+-]{2,}:/{0,2}$`)
+
+// urlizeOptions holds the arguments of the urlize filter shared by the links it creates
+type urlizeOptions struct {
+	trunc   int
+	rel     string
+	target  string
+	schemes []string
+	escape  func(string) string
+}
 
-func filterUrlizeHelper(input string, trunc int, rel string, target string) (string, error) {
-	var soutErr error
-	sout := filterUrlizeURLRegexp.ReplaceAllStringFunc(input, func(raw_url string) string {
+// title returns the escaped, and possibly truncated, text of a link
+func (o urlizeOptions) title(raw string) string {
+	if o.trunc > 3 && len(raw) > o.trunc {
+		raw = fmt.Sprintf("%s...", raw[:o.trunc-3])
+	}
+	return utils.Escape(raw)
+}
+
+// attributes returns the rel and target attributes of the links other than email ones
+func (o urlizeOptions) attributes() string {
+	attrs := fmt.Sprintf(` rel="%s"`, o.rel)
+	if len(o.target) > 0 {
+		attrs += fmt.Sprintf(` target="%s"`, utils.Escape(o.target))
+	}
+	return attrs
+}
+
+// urlizeReplace links the matches of the expression in the input and passes the text between them to rest
+func urlizeReplace(input string, expression *regexp.Regexp, link func(string) string, rest func(string) string) string {
+	var out strings.Builder
+	last := 0
+	for _, match := range expression.FindAllStringIndex(input, -1) {
+		out.WriteString(rest(input[last:match[0]]))
+		out.WriteString(link(input[match[0]:match[1]]))
+		last = match[1]
+	}
+	out.WriteString(rest(input[last:]))
+	return out.String()
+}
+
+func filterUrlizeHelper(input string, options urlizeOptions) string {
+	linkURL := func(raw_url string) string {
 		var prefix string
 		var suffix string
 		if strings.HasPrefix(raw_url, " ") {
@@ -1227,43 +1908,31 @@ func filterUrlizeHelper(input string, trunc int, rel string, target string) (str
 			url = fmt.Sprintf("http://%s", url)
 		}
 
-		title := raw_url
-
-		if trunc > 3 && len(title) > trunc {
-			title = fmt.Sprintf("%s...", title[:trunc-3])
-		}
-
-		title = utils.Escape(title)
-
-		attrs := ""
-		if len(target) > 0 {
-			attrs = fmt.Sprintf(` target="%s"`, target)
-		}
-
-		rels := []string{}
-		cleanedRel := strings.Trim(strings.Replace(rel, "noopener", "", -1), " ")
-		if len(cleanedRel) > 0 {
-			rels = append(rels, cleanedRel)
-		}
-		rels = append(rels, "noopener")
-		rel = strings.Join(rels, " ")
-
-		return fmt.Sprintf(`%s<a href="%s" rel="%s"%s>%s</a>%s`, prefix, url, rel, attrs, title, suffix)
-	})
-	if soutErr != nil {
-		return "", soutErr
+		return fmt.Sprintf(`%s<a href="%s"%s>%s</a>%s`, prefix, url, options.attributes(), options.title(raw_url), suffix)
+	}
+	linkEmail := func(mail string) string {
+		return fmt.Sprintf(`<a href="mailto:%s">%s</a>`, mail, options.title(mail))
+	}
+	urlize := func(text string) string {
+		return urlizeReplace(text, filterUrlizeURLRegexp, linkURL, func(rest string) string {
+			return urlizeReplace(rest, filterUrlizeEmailRegexp, linkEmail, options.escape)
+		})
+	}
+	if len(options.schemes) == 0 {
+		return urlize(input)
 	}
 
-	sout = filterUrlizeEmailRegexp.ReplaceAllStringFunc(sout, func(mail string) string {
-		title := mail
-
-		if trunc > 3 && len(title) > trunc {
-			title = fmt.Sprintf("%s...", title[:trunc-3])
-		}
-
-		return fmt.Sprintf(`<a href="mailto:%s">%s</a>`, mail, title)
-	})
-	return sout, nil
+	// Words starting with one of the extra schemes are linked as they are, before looking for URLs
+	quoted := make([]string, len(options.schemes))
+	for index, scheme := range options.schemes {
+		quoted[index] = regexp.QuoteMeta(scheme)
+	}
+	schemes := regexp.MustCompile(fmt.Sprintf(`(^|\s)(%s)\S+`, strings.Join(quoted, "|")))
+	linkScheme := func(word string) string {
+		trimmed := strings.TrimLeftFunc(word, unicode.IsSpace)
+		return fmt.Sprintf(`%s<a href="%s"%s>%s</a>`, word[:len(word)-len(trimmed)], utils.Escape(trimmed), options.attributes(), options.title(trimmed))
+	}
+	return urlizeReplace(input, schemes, linkScheme, urlize)
 }
 
 func filterUrlize(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
@@ -1275,23 +1944,79 @@ func filterUrlize(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec
 		{Name: "nofollow", Default: false},
 		{Name: "target", Default: nil},
 		{Name: "rel", Default: nil},
+		{Name: "extra_schemes", Default: nil},
 	})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'urlize'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'urlize': %w", p))
 	}
-	truncate := -1
+	options := urlizeOptions{trunc: -1, escape: utils.Escape}
 	if param := p.KwArgs["trim_url_limit"]; param.IsInteger() {
-		truncate = param.Integer()
+		options.trunc = param.Integer()
+	}
+	if target := p.KwArgs["target"]; !target.IsNil() {
+		options.target = target.String()
 	}
-	rel := p.KwArgs["rel"]
-	target := p.KwArgs["target"]
 
-	s, err := filterUrlizeHelper(in.String(), truncate, rel.String(), target.String())
-	if err != nil {
-		return exec.AsValue(err)
+	rels := map[string]bool{"noopener": true}
+	if rel := p.KwArgs["rel"]; !rel.IsNil() {
+		for _, word := range strings.Fields(rel.String()) {
+			rels[word] = true
+		}
+	}
+	if p.KwArgs["nofollow"].IsTrue() {
+		rels["nofollow"] = true
+	}
+	words := make([]string, 0, len(rels))
+	for word := range rels {
+		words = append(words, word)
 	}
+	sort.Strings(words)
+	options.rel = strings.Join(words, " ")
 
-	return exec.AsValue(s)
+	if schemes := p.KwArgs["extra_schemes"]; !schemes.IsNil() {
+		if !schemes.IsList() {
+			return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("extra_schemes must be a list of URI scheme prefixes, got %s", schemes.String())))
+		}
+		for index := 0; index < schemes.Len(); index++ {
+			scheme := schemes.Index(index).String()
+			if !filterUrlizeSchemeRegexp.MatchString(scheme) {
+				return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("'%s' is not a valid URI scheme prefix", scheme)))
+			}
+			options.schemes = append(options.schemes, scheme)
+		}
+	}
+
+	// The text around the links is escaped as the links are, unless it was already marked safe
+	if in.Safe {
+		options.escape = func(text string) string { return text }
+	}
+	return exec.AsSafeValue(filterUrlizeHelper(in.String(), options))
+}
+
+func filterWinBasename(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if err := params.Take(); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if !in.IsString() {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not a string", in.String())))
+	}
+	return exec.AsValue(utils.WindowsBasename(in.String()))
+}
+
+func filterWinDirname(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if err := params.Take(); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if !in.IsString() {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("%s is not a string", in.String())))
+	}
+	return exec.AsValue(utils.WindowsDirname(in.String()))
 }
 
 func filterWordcount(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
@@ -1299,7 +2024,7 @@ func filterWordcount(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *e
 		return in
 	}
 	if p := params.ExpectNothing(); p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'wordcount'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'wordcount': %w", p))
 	}
 	return exec.AsValue(len(strings.Fields(in.String())))
 }
@@ -1333,7 +2058,7 @@ func filterXMLAttr(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exe
 	}
 	p := params.ExpectKwArgs([]*exec.KwArg{{Name: "autospace", Default: true}})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'xmlattr'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'xmlattr': %w", p))
 	}
 	autospace := p.KwArgs["autospace"].Bool()
 	kvs := []string{}
@@ -1358,12 +2083,12 @@ func filterDefault(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exe
 		Default: false,
 	}})
 	if p.IsError() || !p.GetKeywordArgument("boolean", false).IsBool() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'default'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'default': %w", p))
 	}
-	if in.IsError() || in.IsNil() {
+	if in.IsError() || in.IsNil() && (in.IsUndefined() || !e.Config.PythonNone) {
 		return p.First()
 	}
-	if p.GetKeywordArgument("boolean", false).Bool() && !in.IsTrue() {
+	if p.GetKeywordArgument("boolean", false).Bool() && !in.Truthy(e.Config) {
 		return p.First()
 	}
 	return in
@@ -1383,7 +2108,7 @@ func filterSelectAttr(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *
 		test = func(in *exec.Value) *exec.Value {
 			attr, found := in.Get(attribute)
 			if !found {
-				return exec.AsValue(errors.Errorf(`%s has no attribute '%s'`, in.String(), attribute))
+				return exec.AsValue(fmt.Errorf(`%s has no attribute '%s'`, in.String(), attribute))
 			}
 			return attr
 		}
@@ -1396,7 +2121,7 @@ func filterSelectAttr(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *
 		test = func(in *exec.Value) *exec.Value {
 			attr, found := in.Get(attribute)
 			if !found {
-				return exec.AsValue(errors.Errorf(`%s has no attribute '%s'`, in.String(), attribute))
+				return exec.AsValue(fmt.Errorf(`%s has no attribute '%s'`, in.String(), attribute))
 			}
 			out := e.ExecuteTestByName(name, attr, testParams)
 			return out
@@ -1412,7 +2137,7 @@ func filterSelectAttr(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *
 			err = result
 			return false
 		}
-		if result.IsTrue() {
+		if result.Truthy(e.Config) {
 			out = append(out, key.Interface())
 		}
 		return true
@@ -1,21 +1,30 @@
 package builtins
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/utils"
-	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 var GlobalFunctions = exec.NewContext(map[string]interface{}{
 	"cycler":    cyclerFunction,
 	"dict":      dictFunction,
+	"fail":      failFunction,
 	"joiner":    joinerFunction,
 	"lipsum":    lipSumFunction,
+	"log":       logFunction,
 	"namespace": namespaceFunction,
+	"now":       nowFunction,
 	"range":     rangeFunction,
+	"timedelta": timedeltaFunction,
+	"warn":      warnFunction,
 })
 
-func rangeFunction(_ *exec.Evaluator, params *exec.VarArgs) (<-chan int, error) {
+func rangeFunction(e *exec.Evaluator, params *exec.VarArgs) (*rangeIterator, error) {
 	var (
 		start = 0
 		stop  = -1
@@ -39,20 +48,103 @@ func rangeFunction(_ *exec.Evaluator, params *exec.VarArgs) (<-chan int, error)
 		return nil, exec.ErrInvalidCall(errors.New("step cannot be 0"))
 	}
 
-	channel := make(chan int)
-	go func() {
-		if step > 0 {
-			for i := start; i < stop; i += step {
-				channel <- i
-			}
+	count := 0
+	if step > 0 && stop > start {
+		count = (stop - start + step - 1) / step
+	} else if step < 0 && start > stop {
+		count = (start - stop - step - 1) / -step
+	}
+	// the items are only checked against the budget of the render here, since the loops and filters going
+	// through them account for them
+	if err := e.CheckIterations(count); err != nil {
+		return nil, err
+	}
+	return &rangeIterator{next: start, step: step, remaining: count}, nil
+}
+
+// rangeIterator pulls the items of a range one at a time, which spares allocating all of them up front, and
+// reports how many are left so that loops over ranges know their length
+type rangeIterator struct {
+	next      int
+	step      int
+	remaining int
+}
+
+func (it *rangeIterator) Next() (int, bool) {
+	if it.remaining == 0 {
+		return 0, false
+	}
+	item := it.next
+	it.next += it.step
+	it.remaining--
+	return item, true
+}
+
+func (it *rangeIterator) Len() int {
+	return it.remaining
+}
+
+func failFunction(_ *exec.Evaluator, params *exec.VarArgs) (*exec.Value, error) {
+	var message string
+	if err := params.Take(
+		exec.PositionalArgument("message", exec.AsValue("failed"), exec.StringArgument(&message)),
+	); err != nil {
+		return nil, exec.ErrInvalidCall(err)
+	}
+	return nil, &exec.FailError{Message: message}
+}
+
+func warnFunction(e *exec.Evaluator, params *exec.VarArgs) (string, error) {
+	if len(params.Args) != 1 {
+		return "", exec.ErrInvalidCall(errors.New("expected signature is message[, **fields]"))
+	}
+	ctx := e.Environment.Context
+	e.Environment.Log().WithFields(logFields(ctx, params.KwArgs)).Warn(ctx.Redact(params.Args[0].String()))
+	return "", nil
+}
+
+func logFunction(e *exec.Evaluator, params *exec.VarArgs) (string, error) {
+	if len(params.Args) != 1 {
+		return "", exec.ErrInvalidCall(errors.New("expected signature is message[, level][, **fields]"))
+	}
+	level := "info"
+	fields := map[string]*exec.Value{}
+	for key, value := range params.KwArgs {
+		if key == "level" {
+			level = value.String()
 		} else {
-			for i := start; i > stop; i += step {
-				channel <- i
-			}
+			fields[key] = value
 		}
-		close(channel)
-	}()
-	return channel, nil
+	}
+	ctx := e.Environment.Context
+	logger, message := e.Environment.Log().WithFields(logFields(ctx, fields)), ctx.Redact(params.Args[0].String())
+	switch level {
+	case "debug":
+		logger.Debug(message)
+	case "info":
+		logger.Info(message)
+	case "warning", "warn":
+		logger.Warn(message)
+	case "error":
+		logger.Error(message)
+	default:
+		return "", exec.ErrInvalidCall(fmt.Errorf("unknown level '%s', expected one of debug, info, warning or error", level))
+	}
+	return "", nil
+}
+
+// logFields returns the keyword arguments of a log function as the fields of the logged entry. Values
+// holding sensitive variables of the context are logged as redacted strings.
+func logFields(ctx *exec.Context, kwargs map[string]*exec.Value) logrus.Fields {
+	fields := make(logrus.Fields, len(kwargs))
+	for key, value := range kwargs {
+		if text := value.String(); ctx.Redact(text) != text {
+			fields[key] = ctx.Redact(text)
+		} else {
+			fields[key] = value.Interface()
+		}
+	}
+	return fields
 }
 
 func dictFunction(_ *exec.Evaluator, params *exec.VarArgs) *exec.Value {
@@ -151,3 +243,47 @@ func lipSumFunction(_ *exec.Evaluator, params *exec.VarArgs) *exec.Value {
 	}
 	return exec.AsSafeValue(utils.Lipsum(n, html, min, max))
 }
+
+func nowFunction(_ *exec.Evaluator, params *exec.VarArgs) *exec.Value {
+	var utc bool
+	if err := params.Take(
+		exec.KeywordArgument("utc", exec.AsValue(false), exec.BoolArgument(&utc)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if utc {
+		return exec.AsValue(time.Now().UTC())
+	}
+	return exec.AsValue(time.Now())
+}
+
+func timedeltaFunction(_ *exec.Evaluator, params *exec.VarArgs) *exec.Value {
+	var (
+		days         float64
+		seconds      float64
+		microseconds float64
+		milliseconds float64
+		minutes      float64
+		hours        float64
+		weeks        float64
+	)
+	if err := params.Take(
+		exec.KeywordArgument("days", exec.AsValue(0), exec.NumberArgument(&days)),
+		exec.KeywordArgument("seconds", exec.AsValue(0), exec.NumberArgument(&seconds)),
+		exec.KeywordArgument("microseconds", exec.AsValue(0), exec.NumberArgument(&microseconds)),
+		exec.KeywordArgument("milliseconds", exec.AsValue(0), exec.NumberArgument(&milliseconds)),
+		exec.KeywordArgument("minutes", exec.AsValue(0), exec.NumberArgument(&minutes)),
+		exec.KeywordArgument("hours", exec.AsValue(0), exec.NumberArgument(&hours)),
+		exec.KeywordArgument("weeks", exec.AsValue(0), exec.NumberArgument(&weeks)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	duration := weeks*float64(7*24*time.Hour) +
+		days*float64(24*time.Hour) +
+		hours*float64(time.Hour) +
+		minutes*float64(time.Minute) +
+		seconds*float64(time.Second) +
+		milliseconds*float64(time.Millisecond) +
+		microseconds*float64(time.Microsecond)
+	return exec.AsValue(time.Duration(duration))
+}
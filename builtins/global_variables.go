@@ -6,4 +6,5 @@ var GlobalVariables = exec.NewContext(map[string]interface{}{
 	"gonja": map[string]interface{}{
 		"version": "v0.0.0+trunk",
 	},
+	"omit": exec.Omit,
 })
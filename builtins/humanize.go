@@ -0,0 +1,111 @@
+package builtins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// HumanizeLocale holds the wording used by the humanize filters
+type HumanizeLocale struct {
+	// RelativeTime describes a date relatively to now, e.g. "3 hours ago"
+	RelativeTime func(then, now time.Time) string
+	// Today, Yesterday and Tomorrow name the dates close to the current day
+	Today     string
+	Yesterday string
+	Tomorrow  string
+	// DateFormat and DateFormatWithYear are the strftime formats used for other dates,
+	// the latter being used for dates further than five months from now
+	DateFormat         string
+	DateFormatWithYear string
+	// ThousandsSeparator and DecimalSeparator are used to format numbers
+	ThousandsSeparator string
+	DecimalSeparator   string
+	// Ordinal returns the ordinal form of an integer, e.g. "1st"
+	Ordinal func(n int) string
+}
+
+// HumanizeLocales lists the locales available to the humanize filters through their `locale` argument.
+// Additional locales can be registered before rendering templates.
+var HumanizeLocales = map[string]*HumanizeLocale{
+	"en": {
+		RelativeTime: func(then, now time.Time) string {
+			return humanize.RelTime(then, now, "ago", "from now")
+		},
+		Today:              "today",
+		Yesterday:          "yesterday",
+		Tomorrow:           "tomorrow",
+		DateFormat:         "%b %d",
+		DateFormatWithYear: "%b %d %Y",
+		ThousandsSeparator: ",",
+		DecimalSeparator:   ".",
+		Ordinal:            humanize.Ordinal,
+	},
+}
+
+func getHumanizeLocale(name string) (*HumanizeLocale, error) {
+	locale, ok := HumanizeLocales[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown locale '%s'", name)
+	}
+	return locale, nil
+}
+
+// formatNumber groups the digits of the integer part of a number by thousands
+func formatNumber(number string, locale *HumanizeLocale) string {
+	sign := ""
+	if strings.HasPrefix(number, "-") {
+		sign, number = "-", number[1:]
+	}
+	integer, decimals, hasDecimals := strings.Cut(number, ".")
+	var out strings.Builder
+	out.WriteString(sign)
+	for index, digit := range integer {
+		if index > 0 && (len(integer)-index)%3 == 0 {
+			out.WriteString(locale.ThousandsSeparator)
+		}
+		out.WriteRune(digit)
+	}
+	if hasDecimals {
+		out.WriteString(locale.DecimalSeparator)
+		out.WriteString(decimals)
+	}
+	return out.String()
+}
+
+// naturalDay names a date relatively to the current day if possible
+func naturalDay(then, now time.Time, locale *HumanizeLocale) (string, bool) {
+	then = then.In(now.Location())
+	thenYear, thenMonth, thenDay := then.Date()
+	day := time.Date(thenYear, thenMonth, thenDay, 0, 0, 0, 0, time.UTC)
+	nowYear, nowMonth, nowDay := now.Date()
+	today := time.Date(nowYear, nowMonth, nowDay, 0, 0, 0, 0, time.UTC)
+	switch day.Sub(today) {
+	case 0:
+		return locale.Today, true
+	case -24 * time.Hour:
+		return locale.Yesterday, true
+	case 24 * time.Hour:
+		return locale.Tomorrow, true
+	}
+	return "", false
+}
+
+// formatSize localizes the decimal separator of a human readable size
+func formatSize(size uint64, binary bool, locale *HumanizeLocale) string {
+	var formatted string
+	if binary {
+		formatted = humanize.IBytes(size)
+	} else {
+		formatted = humanize.Bytes(size)
+	}
+	return strings.Replace(formatted, ".", locale.DecimalSeparator, 1)
+}
+
+// formatFloat renders a float without exponent nor trailing zeros
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,133 @@
+package builtins
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+)
+
+// ipaddrQueries are the queries supported by the ipaddr, ipv4 and ipv6 filters, returning the result of
+// a query on an address along with the prefix it was given with, or false when the query does not apply
+var ipaddrQueries = map[string]func(address netip.Addr, prefix netip.Prefix, original string) interface{}{
+	"": func(_ netip.Addr, _ netip.Prefix, original string) interface{} {
+		return original
+	},
+	"address": func(address netip.Addr, _ netip.Prefix, _ string) interface{} {
+		return address.String()
+	},
+	"network": func(_ netip.Addr, prefix netip.Prefix, _ string) interface{} {
+		return prefix.Masked().Addr().String()
+	},
+	"prefix": func(_ netip.Addr, prefix netip.Prefix, _ string) interface{} {
+		return prefix.Bits()
+	},
+	"netmask": func(address netip.Addr, prefix netip.Prefix, _ string) interface{} {
+		mask := net.CIDRMask(prefix.Bits(), address.BitLen())
+		masked, _ := netip.AddrFromSlice(mask)
+		return masked.Unmap().String()
+	},
+	"broadcast": func(address netip.Addr, prefix netip.Prefix, _ string) interface{} {
+		if !address.Is4() || prefix.Bits() >= 31 {
+			return false
+		}
+		network := prefix.Masked().Addr().As4()
+		mask := net.CIDRMask(prefix.Bits(), 32)
+		for index := range network {
+			network[index] |= ^mask[index]
+		}
+		return netip.AddrFrom4(network).String()
+	},
+	"size": func(address netip.Addr, prefix netip.Prefix, _ string) interface{} {
+		size := new(big.Int).Lsh(big.NewInt(1), uint(address.BitLen()-prefix.Bits()))
+		if size.IsInt64() {
+			return int(size.Int64())
+		}
+		return size.String()
+	},
+	"version": func(address netip.Addr, _ netip.Prefix, _ string) interface{} {
+		if address.Is4() {
+			return 4
+		}
+		return 6
+	},
+	"private": func(address netip.Addr, _ netip.Prefix, original string) interface{} {
+		if address.IsPrivate() {
+			return original
+		}
+		return false
+	},
+	"public": func(address netip.Addr, _ netip.Prefix, original string) interface{} {
+		if address.IsGlobalUnicast() && !address.IsPrivate() {
+			return original
+		}
+		return false
+	},
+}
+
+func filterIpaddr(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	return ipaddr(in, params, "ipaddr", 0)
+}
+
+func filterIpv4(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	return ipaddr(in, params, "ipv4", 4)
+}
+
+func filterIpv6(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	return ipaddr(in, params, "ipv6", 6)
+}
+
+// ipaddr applies a query to an address, or to each address of a list in which case the ones the query
+// does not apply to are left out. Addresses of another version than the one given, if any, are rejected.
+func ipaddr(in *exec.Value, params *exec.VarArgs, name string, version int) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var query string
+	if err := params.Take(
+		exec.PositionalArgument("query", exec.AsValue(""), exec.StringArgument(&query)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	apply, ok := ipaddrQueries[query]
+	if !ok {
+		return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf("unknown query '%s' for filter '%s'", query, name)))
+	}
+	if !in.IsList() {
+		return exec.AsValue(queryAddress(in.String(), version, apply))
+	}
+	results := []interface{}{}
+	in.Iterate(func(_, _ int, item, _ *exec.Value) bool {
+		if result := queryAddress(item.String(), version, apply); result != false {
+			results = append(results, result)
+		}
+		return true
+	}, func() {})
+	return exec.AsValue(results)
+}
+
+func queryAddress(value string, version int, apply func(netip.Addr, netip.Prefix, string) interface{}) interface{} {
+	var (
+		address netip.Addr
+		prefix  netip.Prefix
+		err     error
+	)
+	if strings.Contains(value, "/") {
+		if prefix, err = netip.ParsePrefix(value); err != nil {
+			return false
+		}
+		address = prefix.Addr()
+	} else {
+		if address, err = netip.ParseAddr(value); err != nil {
+			return false
+		}
+		prefix = netip.PrefixFrom(address, address.BitLen())
+	}
+	if version == 4 && !address.Is4() || version == 6 && !address.Is6() {
+		return false
+	}
+	return apply(address, prefix, value)
+}
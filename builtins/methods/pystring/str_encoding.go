@@ -19,7 +19,7 @@ func Encode(str, encoding, errors string) ([]byte, error) {
 		encoder := charmap.ISO8859_1.NewEncoder()
 		result, err := encoder.Bytes([]byte(str))
 		if err != nil && errors == "strict" {
-			return nil, fmt.Errorf("failed to encode %s to ISO-8859-1: %s", str, err)
+			return nil, fmt.Errorf("failed to encode %s to ISO-8859-1: %w", str, err)
 		}
 		return result, nil
 	default:
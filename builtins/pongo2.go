@@ -0,0 +1,390 @@
+package builtins
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	controlStructures "github.com/nikolalohinski/gonja/v2/builtins/control_structures"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/utils"
+)
+
+// Pongo2Filters holds the filters of pongo2 which are not part of Filters, under their pongo2 names. As
+// for DjangoFilters, their arguments are given between parentheses, which lint.Pongo2Rewrite takes care of.
+var Pongo2Filters = exec.NewFilterSet(map[string]exec.FilterFunction{
+	"add":             filterAdd,
+	"addslashes":      filterAddslashes,
+	"capfirst":        filterCapfirst,
+	"cut":             filterCut,
+	"date":            filterGoDate,
+	"default_if_none": filterDefaultIfNone,
+	"escapejs":        filterEscapejs,
+	"floatformat":     filterFloatformat,
+	"integer":         filterInteger,
+	"iriencode":       filterIriencode,
+	"length_is":       filterLengthIs,
+	"linebreaks":      filterLinebreaks,
+	"linebreaksbr":    filterLinebreaksbr,
+	"ljust":           filterLjust,
+	"make_list":       filterMakeList,
+	"pluralize":       filterPluralize,
+	"removetags":      filterRemovetags,
+	"rjust":           filterRjust,
+	"slugify":         filterSlugify,
+	"split":           filterSplit,
+	"stringformat":    filterStringformat,
+	"time":            filterGoDate,
+	"truncatechars":   filterTruncatechars,
+	"truncatewords":   filterTruncatewords,
+	"yesno":           filterYesno,
+})
+
+// Pongo2 returns an overlay of the environment adding the filters of Pongo2Filters and the statements of
+// pongo2 which gonja lacks, so that pongo2 templates can be migrated one at a time. lint.Pongo2Report lists
+// what remains to be changed in a template, and lint.Pongo2Rewrite performs the mechanical changes.
+func Pongo2(environment *exec.Environment) *exec.Environment {
+	pongo2 := environment.Overlay()
+	pongo2.Filters.Update(Pongo2Filters)
+	pongo2.ControlStructures.Update(controlStructures.Pongo2())
+	return pongo2
+}
+
+func filterAdd(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var operand *exec.Value
+	if err := params.Take(
+		exec.PositionalArgument("value", nil, func(v *exec.Value) error { operand = v; return nil }),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	switch {
+	case in.IsInteger() && operand.IsInteger():
+		return exec.AsValue(in.Integer() + operand.Integer())
+	case in.IsNumber() && operand.IsNumber():
+		return exec.AsValue(in.Float() + operand.Float())
+	}
+	return exec.AsValue(in.String() + operand.String())
+}
+
+var addslashesReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `'`, `\'`)
+
+func filterAddslashes(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'addslashes': %w", p))
+	}
+	return exec.AsValue(addslashesReplacer.Replace(in.String()))
+}
+
+func filterCapfirst(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'capfirst': %w", p))
+	}
+	s := in.String()
+	first, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return exec.AsValue(s)
+	}
+	return exec.AsValue(string(unicode.ToUpper(first)) + s[size:])
+}
+
+func filterCut(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var cut string
+	if err := params.Take(
+		exec.PositionalArgument("value", nil, exec.StringArgument(&cut)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return exec.AsValue(strings.ReplaceAll(in.String(), cut, ""))
+}
+
+// filterGoDate formats dates with a go layout, as the date and time filters of pongo2 do
+func filterGoDate(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var layout string
+	if err := params.Take(
+		exec.PositionalArgument("layout", nil, exec.StringArgument(&layout)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	t, err := asTime(in)
+	if err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return exec.AsValue(t.Format(layout))
+}
+
+func filterDefaultIfNone(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var fallback *exec.Value
+	if err := params.Take(
+		exec.PositionalArgument("default", nil, func(v *exec.Value) error { fallback = v; return nil }),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if in.IsNil() {
+		return fallback
+	}
+	return in
+}
+
+var escapejsReplacer = strings.NewReplacer(
+	`\`, `\u005C`, `'`, `\u0027`, `"`, `\u0022`, `>`, `\u003E`, `<`, `\u003C`, `&`, `\u0026`,
+	`=`, `\u003D`, `-`, `\u002D`, `;`, `\u003B`, "\u2028", `\u2028`, "\u2029", `\u2029`,
+)
+
+func filterEscapejs(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'escapejs': %w", p))
+	}
+	var out strings.Builder
+	for _, r := range escapejsReplacer.Replace(in.String()) {
+		if r < 32 {
+			fmt.Fprintf(&out, `\u%04X`, r)
+			continue
+		}
+		out.WriteRune(r)
+	}
+	return exec.AsValue(out.String())
+}
+
+// filterFloatformat rounds numbers to the given number of decimals, which are only kept when the number
+// is not whole if the number of decimals is negative
+func filterFloatformat(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var decimals int
+	if err := params.Take(
+		exec.PositionalArgument("decimals", exec.AsValue(-1), exec.IntArgument(&decimals)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	value := in.Float()
+	if decimals < 0 {
+		decimals = -decimals
+		if value == math.Trunc(value) {
+			decimals = 0
+		}
+	}
+	return exec.AsValue(fmt.Sprintf("%.*f", decimals, value))
+}
+
+func filterIriencode(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'iriencode': %w", p))
+	}
+	return exec.AsValue(utils.IRIEncode(in.String()))
+}
+
+func filterLengthIs(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var length int
+	if err := params.Take(
+		exec.PositionalArgument("length", nil, exec.IntArgument(&length)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return exec.AsValue(in.Len() == length)
+}
+
+var reParagraphs = regexp.MustCompile(`\n{2,}`)
+
+func filterLinebreaks(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'linebreaks': %w", p))
+	}
+	text := strings.TrimSpace(strings.ReplaceAll(in.String(), "\r\n", "\n"))
+	if text == "" {
+		return exec.AsValue("")
+	}
+	paragraphs := reParagraphs.Split(text, -1)
+	for index, paragraph := range paragraphs {
+		paragraphs[index] = "<p>" + strings.ReplaceAll(paragraph, "\n", "<br />") + "</p>"
+	}
+	return exec.AsValue(strings.Join(paragraphs, "\n\n"))
+}
+
+func filterLinebreaksbr(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'linebreaksbr': %w", p))
+	}
+	return exec.AsValue(strings.ReplaceAll(strings.ReplaceAll(in.String(), "\r\n", "\n"), "\n", "<br />"))
+}
+
+func filterLjust(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	return justify(in, params, func(s, padding string) string { return s + padding })
+}
+
+func filterRjust(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	return justify(in, params, func(s, padding string) string { return padding + s })
+}
+
+// justify pads a string with spaces up to the given width, on the side chosen by pad
+func justify(in *exec.Value, params *exec.VarArgs, pad func(s, padding string) string) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var width int
+	if err := params.Take(
+		exec.PositionalArgument("width", nil, exec.IntArgument(&width)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	s := in.String()
+	if missing := width - utf8.RuneCountInString(s); missing > 0 {
+		return exec.AsValue(pad(s, strings.Repeat(" ", missing)))
+	}
+	return exec.AsValue(s)
+}
+
+func filterMakeList(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'make_list': %w", p))
+	}
+	if in.IsList() {
+		return in
+	}
+	characters := []string{}
+	for _, r := range in.String() {
+		characters = append(characters, string(r))
+	}
+	return exec.AsValue(characters)
+}
+
+func filterRemovetags(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var tags string
+	if err := params.Take(
+		exec.PositionalArgument("tags", nil, exec.StringArgument(&tags)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	names := []string{}
+	for _, name := range strings.Split(tags, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, regexp.QuoteMeta(name))
+		}
+	}
+	if len(names) == 0 {
+		return in
+	}
+	tagsPattern := regexp.MustCompile(fmt.Sprintf(`</?(?:%s)(?:\s[^>]*)?/?>`, strings.Join(names, "|")))
+	return exec.AsValue(tagsPattern.ReplaceAllString(in.String(), ""))
+}
+
+var (
+	reSlugifyInvalid   = regexp.MustCompile(`[^\w\s-]`)
+	reSlugifySeparator = regexp.MustCompile(`[-\s]+`)
+)
+
+func filterSlugify(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	if p := params.ExpectNothing(); p.IsError() {
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'slugify': %w", p))
+	}
+	slug := reSlugifyInvalid.ReplaceAllString(strings.ToLower(in.String()), "")
+	slug = reSlugifySeparator.ReplaceAllString(strings.TrimSpace(slug), "-")
+	return exec.AsValue(strings.Trim(slug, "-_"))
+}
+
+func filterSplit(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var separator string
+	if err := params.Take(
+		exec.PositionalArgument("separator", nil, exec.StringArgument(&separator)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return exec.AsValue(strings.Split(in.String(), separator))
+}
+
+func filterStringformat(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var format string
+	if err := params.Take(
+		exec.PositionalArgument("format", nil, exec.StringArgument(&format)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	return exec.AsValue(fmt.Sprintf(format, in.Interface()))
+}
+
+func filterTruncatechars(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var length int
+	if err := params.Take(
+		exec.PositionalArgument("length", nil, exec.IntArgument(&length)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	if length < 1 {
+		return exec.AsValue("")
+	}
+	return exec.AsValue(utils.Ellipsis(in.String(), length))
+}
+
+func filterTruncatewords(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+	if in.IsError() {
+		return in
+	}
+	var length int
+	if err := params.Take(
+		exec.PositionalArgument("length", nil, exec.IntArgument(&length)),
+	); err != nil {
+		return exec.AsValue(exec.ErrInvalidCall(err))
+	}
+	words := strings.Fields(in.String())
+	if len(words) <= length {
+		return exec.AsValue(strings.Join(words, " "))
+	}
+	if length < 0 {
+		length = 0
+	}
+	return exec.AsValue(strings.Join(append(words[:length:length], "…"), " "))
+}
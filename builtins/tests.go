@@ -1,8 +1,12 @@
 package builtins
 
 import (
+	"cmp"
 	"errors"
+	"fmt"
+	"math"
 	"reflect"
+	"regexp"
 	"strings"
 
 	"github.com/nikolalohinski/gonja/v2/exec"
@@ -11,6 +15,7 @@ import (
 var Tests = exec.NewTestSet(map[string]exec.TestFunction{
 	"boolean":     testBoolean,
 	"callable":    testCallable,
+	"contains":    testContains,
 	"defined":     testDefined,
 	"divisibleby": testDivisibleby,
 	"eq":          testEqual,
@@ -19,6 +24,7 @@ var Tests = exec.NewTestSet(map[string]exec.TestFunction{
 	"escaped":     testEscaped,
 	"even":        testEven,
 	"false":       testFalse,
+	"falsy":       testFalsy,
 	"filter":      testFilter,
 	"float":       testFloat,
 	"ge":          testGreaterEqual,
@@ -36,16 +42,20 @@ var Tests = exec.NewTestSet(map[string]exec.TestFunction{
 	"lessthan":    testLessThan,
 	"<":           testLessThan,
 	"mapping":     testMapping,
+	"match":       testMatch,
 	"ne":          testNotEqual,
 	"!=":          testNotEqual,
 	"none":        testNone,
 	"number":      testNumber,
 	"odd":         testOdd,
+	"regex":       testRegex,
 	"sameas":      testSameas,
+	"search":      testSearch,
 	"sequence":    testSequence,
 	"string":      testString,
 	"test":        testTest,
 	"true":        testTrue,
+	"truthy":      testTruthy,
 	"undefined":   testUndefined,
 	"upper":       testUpper,
 })
@@ -63,16 +73,34 @@ func testDefined(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, e
 }
 
 func testDivisibleby(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	param := params.First()
-	if param.Integer() == 0 {
+	var num interface{}
+	if err := params.Take(
+		exec.PositionalArgument("num", nil, exec.AnyArgument(&num)),
+	); err != nil {
+		return false, exec.ErrInvalidCall(err)
+	}
+	// numbers given as strings are accepted for backward compatibility
+	divisor := exec.AsValue(num)
+	if divisor.Float() == 0 {
+		return false, exec.ErrInvalidCall(fmt.Errorf("%s is not a non-zero number", divisor.String()))
+	}
+	if !in.IsNumber() {
 		return false, nil
 	}
-	return in.Integer()%param.Integer() == 0, nil
+	if in.IsInteger() && divisor.Float() == float64(divisor.Integer()) {
+		return in.Integer()%divisor.Integer() == 0, nil
+	}
+	return math.Mod(in.Float(), divisor.Float()) == 0, nil
 }
 
 func testEqual(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	param := params.First()
-	return in.EqualValueTo(param), nil
+	var other interface{}
+	if err := params.Take(
+		exec.PositionalArgument("other", nil, exec.AnyArgument(&other)),
+	); err != nil {
+		return false, exec.ErrInvalidCall(err)
+	}
+	return in.EqualValueTo(exec.AsValue(other)), nil
 }
 
 func testEven(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
@@ -83,7 +111,11 @@ func testEven(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, erro
 }
 
 func testFalse(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	return !in.Bool(), nil
+	return in.IsBool() && !in.Bool(), nil
+}
+
+func testFalsy(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) (bool, error) {
+	return !in.Truthy(e.Config), nil
 }
 
 func testFloat(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
@@ -91,35 +123,55 @@ func testFloat(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, err
 }
 
 func testGreaterEqual(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	param := params.Args[0]
-	if !in.IsNumber() || !param.IsNumber() {
-		return false, nil
-	}
-	return in.Float() >= param.Float(), nil
+	comparison, comparable, err := compareTo(in, params)
+	return comparable && comparison >= 0, err
 }
 
 func testGreaterThan(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	var to float64
+	comparison, comparable, err := compareTo(in, params)
+	return comparable && comparison > 0, err
+}
+
+func testIn(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
+	var seq interface{}
 	if err := params.Take(
-		exec.PositionalArgument("to", nil, exec.NumberArgument(&to)),
+		exec.PositionalArgument("seq", nil, exec.AnyArgument(&seq)),
 	); err != nil {
 		return false, exec.ErrInvalidCall(err)
 	}
+	container := exec.AsValue(seq)
+	if !isContainer(container) {
+		return false, exec.ErrInvalidCall(fmt.Errorf("%s is not iterable", container.String()))
+	}
+	return container.Contains(in), nil
+}
 
-	return in.Float() > to, nil
+func testContains(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
+	var value interface{}
+	if err := params.Take(
+		exec.PositionalArgument("value", nil, exec.AnyArgument(&value)),
+	); err != nil {
+		return false, exec.ErrInvalidCall(err)
+	}
+	if !isContainer(in) {
+		return false, exec.ErrInvalidCall(fmt.Errorf("%s is not iterable", in.String()))
+	}
+	return in.Contains(exec.AsValue(value)), nil
 }
 
-func testIn(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	seq := params.First()
-	return seq.Contains(in), nil
+// isContainer tells whether the value supports membership checks, structs being checked against their field names
+func isContainer(v *exec.Value) bool {
+	return v.IsIterable() || reflect.Indirect(v.Val).Kind() == reflect.Struct
 }
 
 func testInteger(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	return in.IsInteger(), nil
+	return in.IsInteger() && !in.IsDuration(), nil
 }
 
 func testIterable(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	return in.IsDict() || in.IsList() || in.IsString(), nil
+	// channels and iterators can be iterated over in for loops, e.g. the output of range
+	_, iterator := in.Iterator(nil)
+	return in.IsIterable() || iterator, nil
 }
 
 func testSequence(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
@@ -127,11 +179,8 @@ func testSequence(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool,
 }
 
 func testLessEqual(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	param := params.Args[0]
-	if !in.IsNumber() || !param.IsNumber() {
-		return false, nil
-	}
-	return in.Float() <= param.Float(), nil
+	comparison, comparable, err := compareTo(in, params)
+	return comparable && comparison <= 0, err
 }
 
 func testLower(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
@@ -142,20 +191,21 @@ func testLower(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, err
 }
 
 func testLessThan(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	param := params.Args[0]
-	if !in.IsNumber() || !param.IsNumber() {
-		return false, nil
-	}
-	return in.Float() < param.Float(), nil
+	comparison, comparable, err := compareTo(in, params)
+	return comparable && comparison < 0, err
 }
 
 func testMapping(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
 	return in.IsDict(), nil
 }
 
-func testNotEqual(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	param := params.Args[0]
-	return in.Interface() != param.Interface(), nil
+func testMatch(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
+	return regexTest(in, params, "match")
+}
+
+func testNotEqual(ctx *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
+	equal, err := testEqual(ctx, in, params)
+	return !equal, err
 }
 
 func testNone(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
@@ -163,7 +213,7 @@ func testNone(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, erro
 }
 
 func testNumber(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	return in.IsNumber(), nil
+	return in.IsNumber() && !in.IsDuration(), nil
 }
 
 func testOdd(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
@@ -173,14 +223,40 @@ func testOdd(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error
 	return in.Integer()%2 == 1, nil
 }
 
+func testRegex(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
+	return regexTest(in, params, "")
+}
+
 func testSameas(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	param := params.Args[0]
-	if in.IsNil() && param.IsNil() {
-		return true, nil
-	} else if param.Val.CanAddr() && in.Val.CanAddr() {
-		return param.Val.Addr() == in.Val.Addr(), nil
+	var other interface{}
+	if err := params.Take(
+		exec.PositionalArgument("other", nil, exec.AnyArgument(&other)),
+	); err != nil {
+		return false, exec.ErrInvalidCall(err)
+	}
+	param := exec.AsValue(other)
+	if in.IsNil() || param.IsNil() {
+		return in.IsNil() && param.IsNil(), nil
 	}
-	return reflect.Indirect(param.Val) == reflect.Indirect(in.Val), nil
+	left, right := reflect.ValueOf(in.Interface()), reflect.ValueOf(param.Interface())
+	if left.Type() != right.Type() {
+		return false, nil
+	}
+	switch left.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return left.Pointer() == right.Pointer(), nil
+	case reflect.Slice:
+		return left.Pointer() == right.Pointer() && left.Len() == right.Len(), nil
+	case reflect.Struct, reflect.Array, reflect.Interface:
+		// values without any reference semantics are copied around and can not share an identity
+		return false, nil
+	}
+	// scalars behave like python's interned values
+	return left.Interface() == right.Interface(), nil
+}
+
+func testSearch(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
+	return regexTest(in, params, "search")
 }
 
 func testString(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
@@ -188,7 +264,11 @@ func testString(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, er
 }
 
 func testTrue(_ *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
-	return in.Bool(), nil
+	return in.IsBool() && in.Bool(), nil
+}
+
+func testTruthy(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) (bool, error) {
+	return in.Truthy(e.Config), nil
 }
 
 func testUndefined(ctx *exec.Context, in *exec.Value, params *exec.VarArgs) (bool, error) {
@@ -207,18 +287,17 @@ func testEscaped(_ *exec.Evaluator, in *exec.Value, params *exec.VarArgs) (bool,
 	if in.IsError() {
 		return false, errors.New(in.Error())
 	}
-	if err := params.ExpectNothing(); err != nil {
+	if err := params.Take(); err != nil {
 		return false, exec.ErrInvalidCall(err)
 	}
-	escaped := in.Escaped()
-	return escaped == in.String(), nil
+	return in.Safe, nil
 }
 
 func testTest(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) (bool, error) {
 	if in.IsError() {
 		return false, errors.New(in.Error())
 	}
-	if err := params.ExpectNothing(); err != nil {
+	if err := params.Take(); err != nil {
 		return false, exec.ErrInvalidCall(err)
 	}
 	return e.Environment.Tests.Exists(in.String()), nil
@@ -228,8 +307,103 @@ func testFilter(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) (bool,
 	if in.IsError() {
 		return false, errors.New(in.Error())
 	}
-	if err := params.ExpectNothing(); err != nil {
+	if err := params.Take(); err != nil {
 		return false, exec.ErrInvalidCall(err)
 	}
 	return e.Environment.Filters.Exists(in.String()), nil
 }
+
+// compareTo compares the tested value with the single argument of a comparison test.
+// Values of different kinds are reported as not comparable rather than failing.
+func compareTo(in *exec.Value, params *exec.VarArgs) (int, bool, error) {
+	var other interface{}
+	if err := params.Take(
+		exec.PositionalArgument("other", nil, exec.AnyArgument(&other)),
+	); err != nil {
+		return 0, false, exec.ErrInvalidCall(err)
+	}
+	argument := exec.AsValue(other)
+	if comparisonKind(argument) == "" {
+		expected := comparisonKind(in)
+		if expected == "" {
+			expected = "number"
+		}
+		return 0, false, exec.ErrInvalidCall(fmt.Errorf("%s is not a %s", argument.String(), expected))
+	}
+	if comparisonKind(in) != comparisonKind(argument) {
+		return 0, false, nil
+	}
+	switch {
+	case in.IsTime():
+		return in.Time().Compare(argument.Time()), true, nil
+	case in.IsNumber():
+		return cmp.Compare(in.Float(), argument.Float()), true, nil
+	default:
+		return strings.Compare(in.String(), argument.String()), true, nil
+	}
+}
+
+// comparisonKind returns the kind of orderable value, or an empty string if the value can not be ordered
+func comparisonKind(v *exec.Value) string {
+	switch {
+	case v.IsTime():
+		return "date"
+	case v.IsNumber():
+		return "number"
+	case v.IsString():
+		return "string"
+	}
+	return ""
+}
+
+// regexTest matches the input against a regular expression, either from its start with the
+// "match" type or anywhere with the "search" type. An empty match type is read from the arguments.
+func regexTest(in *exec.Value, params *exec.VarArgs, matchType string) (bool, error) {
+	var (
+		pattern    string
+		ignoreCase bool
+		multiline  bool
+	)
+	var err error
+	if matchType == "" {
+		err = params.Take(
+			exec.PositionalArgument("pattern", nil, exec.StringArgument(&pattern)),
+			exec.KeywordArgument("ignorecase", exec.AsValue(false), exec.BoolArgument(&ignoreCase)),
+			exec.KeywordArgument("multiline", exec.AsValue(false), exec.BoolArgument(&multiline)),
+			exec.KeywordArgument("match_type", exec.AsValue("search"), exec.StringEnumArgument(&matchType, []string{"match", "search", "fullmatch"})),
+		)
+	} else {
+		err = params.Take(
+			exec.PositionalArgument("pattern", nil, exec.StringArgument(&pattern)),
+			exec.KeywordArgument("ignorecase", exec.AsValue(false), exec.BoolArgument(&ignoreCase)),
+			exec.KeywordArgument("multiline", exec.AsValue(false), exec.BoolArgument(&multiline)),
+		)
+	}
+	if err != nil {
+		return false, exec.ErrInvalidCall(err)
+	}
+	if !in.IsString() {
+		return false, nil
+	}
+	flags := ""
+	if ignoreCase {
+		flags += "i"
+	}
+	if multiline {
+		flags += "m"
+	}
+	if flags != "" {
+		flags = "(?" + flags + ")"
+	}
+	switch matchType {
+	case "match":
+		pattern = `\A(?:` + pattern + `)`
+	case "fullmatch":
+		pattern = `\A(?:` + pattern + `)\z`
+	}
+	expression, err := regexp.Compile(flags + pattern)
+	if err != nil {
+		return false, exec.ErrInvalidCall(err)
+	}
+	return expression.MatchString(in.String()), nil
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/lint"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/schema"
+)
+
+// defaultExtensions are the extensions of the templates checked when walking directories
+var defaultExtensions = []string{".j2", ".jinja", ".jinja2"}
+
+// problem is an issue found in a template
+type problem struct {
+	path    string
+	line    int
+	col     int
+	message string
+	warning bool
+}
+
+func (p problem) String() string {
+	severity := "error"
+	if p.warning {
+		severity = "warning"
+	}
+	if p.line == 0 {
+		return fmt.Sprintf("%s: %s: %s", p.path, severity, p.message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", p.path, p.line, p.col, severity, p.message)
+}
+
+func check(args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	var (
+		extensions stringList
+		searchPath string
+		schemaPath string
+		strict     bool
+	)
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Var(&extensions, "ext", fmt.Sprintf("`extension` of the templates checked in directories, can be repeated (default %s)", strings.Join(defaultExtensions, ", ")))
+	flags.StringVar(&searchPath, "search-path", "", "`directory` referenced templates are loaded from, defaults to the checked directory or to the directory of the checked file")
+	flags.StringVar(&schemaPath, "schema", "", "JSON schema `file` describing the data templates are rendered with, to report the variables and attributes it does not define")
+	flags.BoolVar(&strict, "strict", false, "fail on warnings, such as filters and tests which are not defined by the default environment")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: gonja check <path>... [--ext extension]... [--search-path directory] [--schema file] [--strict]")
+		fmt.Fprintln(stderr, "\nParses templates and the templates they reference, and reports the problems found.\n\nFlags:")
+		flags.PrintDefaults()
+	}
+
+	paths, err := parseInterspersed(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		flags.Usage()
+		return errors.New("expected at least one path")
+	}
+	if len(extensions) == 0 {
+		extensions = defaultExtensions
+	}
+	var described *schema.Schema
+	if schemaPath != "" {
+		content, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return err
+		}
+		if described, err = schema.FromJSONSchema(content); err != nil {
+			return fmt.Errorf("failed to load schema '%s': %w", schemaPath, err)
+		}
+	}
+
+	problems := []problem{}
+	checked := 0
+	for _, path := range paths {
+		files, root, err := listTemplates(path, extensions)
+		if err != nil {
+			return err
+		}
+		if searchPath != "" {
+			root = searchPath
+		}
+		loader, err := loaders.NewFileSystemLoader(root)
+		if err != nil {
+			return fmt.Errorf("failed to create loader: %w", err)
+		}
+		for _, file := range files {
+			problems = append(problems, checkTemplate(file, loader, described)...)
+			checked++
+		}
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, problem := range problems {
+		fmt.Fprintln(stdout, problem)
+		if problem.warning {
+			warningCount++
+		} else {
+			errorCount++
+		}
+	}
+	if errorCount > 0 || (strict && warningCount > 0) {
+		return fmt.Errorf("checked %d templates: found %d errors and %d warnings", checked, errorCount, warningCount)
+	}
+	return nil
+}
+
+// listTemplates returns the templates to check for a path, along with the directory their references are
+// loaded from. Files are always checked, while only the ones with the given extensions are checked in directories.
+func listTemplates(path string, extensions []string) ([]string, string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if !info.IsDir() {
+		return []string{path}, filepath.Dir(path), nil
+	}
+	files := []string{}
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		for _, extension := range extensions {
+			if strings.HasSuffix(file, "."+strings.TrimPrefix(extension, ".")) {
+				files = append(files, file)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	sort.Strings(files)
+	return files, path, nil
+}
+
+func checkTemplate(path string, loader loaders.Loader, described *schema.Schema) []problem {
+	identifier, err := filepath.Abs(path)
+	if err != nil {
+		return []problem{{path: path, message: err.Error()}}
+	}
+	template, err := exec.NewTemplate(identifier, gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+	if err != nil {
+		return []problem{{path: path, message: err.Error()}}
+	}
+
+	problems := []problem{}
+	graph, err := template.Dependencies()
+	if err != nil {
+		problems = append(problems, problem{path: path, message: err.Error()})
+	} else if err := graph.Validate(); err != nil {
+		problems = append(problems, problem{path: path, message: err.Error()})
+	}
+
+	for _, call := range collectCalls(template.Root()) {
+		exists := gonja.DefaultEnvironment.Filters.Exists(call.name)
+		if call.kind == "test" {
+			exists = gonja.DefaultEnvironment.Tests.Exists(call.name)
+		}
+		if !exists {
+			problems = append(problems, problem{
+				path:    path,
+				line:    call.line,
+				col:     call.col,
+				message: fmt.Sprintf("%s '%s' is not defined", call.kind, call.name),
+				warning: true,
+			})
+		}
+	}
+
+	if described != nil {
+		for _, diagnostic := range schema.Validate(template, described) {
+			problems = append(problems, problem{
+				path:    path,
+				line:    diagnostic.Line,
+				col:     diagnostic.Col,
+				message: diagnostic.Message,
+				warning: diagnostic.Severity != lint.SeverityError,
+			})
+		}
+	}
+	return problems
+}
+
+// call is a filter or a test applied in a template
+type call struct {
+	kind      string
+	name      string
+	line, col int
+}
+
+// collectCalls returns the filters and tests applied in a template, including the ones used within control structures
+func collectCalls(root *nodes.Template) []call {
+	calls := []call{}
+	nodes.Traverse(root, func(node nodes.Node, _ []nodes.Node) bool {
+		found := call{}
+		switch n := node.(type) {
+		case *nodes.FilterCall:
+			found = call{kind: "filter", name: n.Name}
+		case *nodes.TestCall:
+			found = call{kind: "test", name: n.Name}
+		default:
+			return true
+		}
+		if token := node.Position(); token != nil {
+			found.line, found.col = token.Line, token.Col
+		}
+		calls = append(calls, found)
+		return true
+	})
+	sort.SliceStable(calls, func(i, j int) bool {
+		if calls[i].line != calls[j].line {
+			return calls[i].line < calls[j].line
+		}
+		return calls[i].col < calls[j].col
+	})
+	return calls
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("check", func() {
+	var (
+		directory = new(string)
+		args      = new([]string)
+
+		returnedCode   = new(int)
+		returnedStdout = new(string)
+		returnedStderr = new(string)
+	)
+	write := func(name, content string) string {
+		path := filepath.Join(*directory, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+		return path
+	}
+	BeforeEach(func() {
+		*directory = GinkgoT().TempDir()
+		write("partials/header.j2", "{{ title | upper }}")
+		write("page.j2", "{% include 'partials/header.j2' %}{% if items is iterable %}{{ items | join(', ') }}{% endif %}")
+		write("notes.txt", "{{ not a template")
+		*args = []string{*directory}
+	})
+	JustBeforeEach(func() {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		*returnedCode = run(append([]string{"check"}, *args...), strings.NewReader(""), stdout, stderr)
+		*returnedStdout, *returnedStderr = stdout.String(), stderr.String()
+	})
+	Context("when every template is valid", func() {
+		It("should succeed without reporting anything", func() {
+			Expect(*returnedStderr).To(BeEmpty())
+			Expect(*returnedStdout).To(BeEmpty())
+			Expect(*returnedCode).To(Equal(0))
+		})
+	})
+	Context("when a template cannot be parsed", func() {
+		var path = new(string)
+		BeforeEach(func() {
+			*path = write("broken.j2", "{{ value ")
+		})
+		It("should report the position of the error", func() {
+			Expect(*returnedCode).To(Equal(1))
+			Expect(*returnedStdout).To(HavePrefix(*path + ": error: failed to parse template"))
+			Expect(*returnedStdout).To(ContainSubstring("(Line: 1 Col: 10"))
+			Expect(*returnedStderr).To(Equal("gonja check: checked 3 templates: found 1 errors and 0 warnings\n"))
+		})
+	})
+	Context("when a referenced template does not exist", func() {
+		BeforeEach(func() {
+			write("layout.j2", "{% extends 'missing.j2' %}")
+		})
+		It("should report it", func() {
+			Expect(*returnedCode).To(Equal(1))
+			Expect(*returnedStdout).To(ContainSubstring("layout.j2: error: "))
+			Expect(*returnedStdout).To(ContainSubstring("missing.j2"))
+		})
+	})
+	Context("when templates include each other", func() {
+		BeforeEach(func() {
+			write("a.j2", "{% if false %}{% include 'b.j2' %}{% endif %}")
+			write("b.j2", "{% include 'a.j2' %}")
+		})
+		It("should report the cycle", func() {
+			Expect(*returnedCode).To(Equal(1))
+			Expect(*returnedStdout).To(ContainSubstring("a.j2: error: template dependency cycle detected: "))
+		})
+	})
+	Context("when filters and tests are not defined", func() {
+		var path = new(string)
+		BeforeEach(func() {
+			*path = write("custom.j2", "{{ value | shout }}\n{% if value is fancy %}{% filter whisper %}x{% endfilter %}{% endif %}")
+		})
+		It("should report warnings without failing", func() {
+			Expect(*returnedCode).To(Equal(0))
+			Expect(*returnedStdout).To(Equal(strings.Join([]string{
+				*path + ":1:12: warning: filter 'shout' is not defined",
+				*path + ":2:16: warning: test 'fancy' is not defined",
+				*path + ":2:34: warning: filter 'whisper' is not defined",
+				"",
+			}, "\n")))
+		})
+		Context("when strict mode is enabled", func() {
+			BeforeEach(func() {
+				*args = append(*args, "--strict")
+			})
+			It("should fail", func() {
+				Expect(*returnedCode).To(Equal(1))
+				Expect(*returnedStderr).To(Equal("gonja check: checked 3 templates: found 0 errors and 3 warnings\n"))
+			})
+		})
+	})
+	Context("when checking templates against a schema", func() {
+		var path = new(string)
+		BeforeEach(func() {
+			*path = filepath.Join(*directory, "page.j2")
+			schema := write("schema/payload.json", `{"properties": {"title": {"type": "string"}, "items": {"type": "array"}}}`)
+			*args = append(*args, "--schema", schema)
+		})
+		It("should succeed when the schema defines every referenced variable", func() {
+			Expect(*returnedStdout).To(BeEmpty())
+			Expect(*returnedCode).To(Equal(0))
+		})
+		Context("when the schema does not define a referenced variable", func() {
+			BeforeEach(func() {
+				write("schema/payload.json", `{"properties": {"title": {"type": "string"}}}`)
+			})
+			It("should report it", func() {
+				Expect(*returnedCode).To(Equal(1))
+				Expect(*returnedStdout).To(Equal(strings.Join([]string{
+					*path + ":1:41: error: 'items' is not defined by the schema",
+					*path + ":1:64: error: 'items' is not defined by the schema",
+					"",
+				}, "\n")))
+			})
+		})
+	})
+	Context("when checking other extensions", func() {
+		BeforeEach(func() {
+			*args = append(*args, "--ext", "txt")
+		})
+		It("should check the matching files only", func() {
+			Expect(*returnedCode).To(Equal(1))
+			Expect(*returnedStdout).To(ContainSubstring("notes.txt: error: failed to parse template"))
+			Expect(*returnedStderr).To(ContainSubstring("checked 1 templates"))
+		})
+	})
+	Context("when checking a single file", func() {
+		BeforeEach(func() {
+			*args = []string{write("notes.txt", "{{ title }}")}
+		})
+		It("should check it regardless of its extension", func() {
+			Expect(*returnedCode).To(Equal(0))
+		})
+	})
+	Context("when no path is given", func() {
+		BeforeEach(func() {
+			*args = []string{}
+		})
+		It("should fail with the usage", func() {
+			Expect(*returnedCode).To(Equal(1))
+			Expect(*returnedStderr).To(ContainSubstring("Usage: gonja check"))
+		})
+	})
+})
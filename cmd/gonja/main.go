@@ -0,0 +1,101 @@
+// Command gonja renders Jinja templates from the command line.
+//
+// Usage:
+//
+//	gonja <command> [arguments]
+//
+// Run `gonja <command> -h` for the arguments of each command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// command runs a sub command of the CLI with its own arguments
+type command struct {
+	description string
+	run         func(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+var commands = map[string]command{
+	"check":  {"check templates for problems", check},
+	"render": {"render a template", render},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	switch args[0] {
+	case "-h", "-help", "--help", "help":
+		usage(stdout)
+		return 0
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "gonja: unknown command '%s'\n\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	if err := command.run(args[1:], stdin, stdout, stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(stderr, "gonja %s: %s\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func usage(out io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{"Usage: gonja <command> [arguments]", "", "Commands:"}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("  %-10s %s", name, commands[name].description))
+	}
+	fmt.Fprintln(out, strings.Join(lines, "\n"))
+}
+
+// parseInterspersed parses flags placed before, between or after positional arguments,
+// and returns the positional arguments
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// stringList is a flag which can be repeated
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
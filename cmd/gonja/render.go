@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+)
+
+// stdinIdentifier identifies templates read from the standard input
+const stdinIdentifier = "<stdin>"
+
+func render(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var (
+		data            dataOptions
+		output          string
+		searchPath      string
+		sourceMap       string
+		strictUndefined bool
+	)
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	data.register(flags)
+	flags.StringVar(&output, "o", "", "`file` to write the rendered content to instead of the standard output")
+	flags.StringVar(&output, "output", "", "same as -o")
+	flags.StringVar(&searchPath, "search-path", "", "`directory` other templates are loaded from, defaults to the directory of the template")
+	flags.StringVar(&sourceMap, "source-map", "", "`file` to write the JSON map of the lines of the rendered content to the template lines they come from")
+	flags.BoolVar(&strictUndefined, "strict-undefined", false, "fail when rendering undefined variables")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: gonja render <template> [--data file]... [--set key=value]... [--env-prefix prefix] [-o file] [--search-path directory] [--source-map file] [--strict-undefined]")
+		fmt.Fprintln(stderr, "\nRenders a template, read from the standard input when given as '-'.\n\nFlags:")
+		flags.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		flags.Usage()
+		return errors.New("expected exactly one template")
+	}
+
+	if positional[0] == "-" && data.readsStdin() {
+		return errStdinConflict
+	}
+	values, err := data.load(stdin)
+	if err != nil {
+		return err
+	}
+
+	config := gonja.DefaultConfig.Inherit()
+	config.StrictUndefined = strictUndefined
+	template, err := loadTemplate(positional[0], searchPath, config, stdin)
+	if err != nil {
+		return err
+	}
+
+	// the whole content is rendered before writing it, so that failures leave existing outputs untouched
+	rendered := new(bytes.Buffer)
+	if sourceMap == "" {
+		if err := template.ExecuteToBuffer(rendered, values); err != nil {
+			return err
+		}
+	} else {
+		mapping, err := template.ExecuteWithSourceMap(rendered, values)
+		if err != nil {
+			return err
+		}
+		encoded, err := json.MarshalIndent(mapping, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(sourceMap, append(encoded, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write source map: %w", err)
+		}
+	}
+	if output == "" {
+		_, err = stdout.Write(rendered.Bytes())
+		return err
+	}
+	if err := os.WriteFile(output, rendered.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// loadTemplate loads a template from a file, or from the standard input when the path is '-'. Other
+// templates are loaded relatively to the search path if any, or to the directory of the template.
+func loadTemplate(path, searchPath string, config *config.Config, stdin io.Reader) (*exec.Template, error) {
+	root := searchPath
+	if root == "" && path != "-" {
+		root = filepath.Dir(path)
+	}
+	loader, err := loaders.NewFileSystemLoader(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create loader: %w", err)
+	}
+
+	identifier := path
+	if path == "-" {
+		identifier = stdinIdentifier
+		if loader, err = loaders.NewShiftedLoader(identifier, stdin, loader); err != nil {
+			return nil, err
+		}
+	} else if identifier, err = filepath.Abs(path); err != nil {
+		return nil, err
+	}
+	return exec.NewTemplate(identifier, config, loader, gonja.DefaultEnvironment)
+}
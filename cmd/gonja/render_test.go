@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("render", func() {
+	var (
+		directory = new(string)
+		args      = new([]string)
+		stdin     = new(string)
+
+		returnedCode   = new(int)
+		returnedStdout = new(string)
+		returnedStderr = new(string)
+	)
+	write := func(name, content string) string {
+		path := filepath.Join(*directory, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+		return path
+	}
+	BeforeEach(func() {
+		*directory = GinkgoT().TempDir()
+		*stdin = ""
+	})
+	JustBeforeEach(func() {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		*returnedCode = run(append([]string{"render"}, *args...), strings.NewReader(*stdin), stdout, stderr)
+		*returnedStdout, *returnedStderr = stdout.String(), stderr.String()
+	})
+	Context("when rendering a template with data files and overrides", func() {
+		BeforeEach(func() {
+			template := write("template.j2", "{{ app.name }}:{{ app.port + 1 }} {{ env }} {{ app.debug is boolean }}")
+			*args = []string{
+				template,
+				"--data", write("base.yaml", "app:\n  name: base\n  port: 80\nenv: dev\n"),
+				"--data", write("prod.json", `{"env": "prod"}`),
+				"--set", "app.port=8080",
+				"--set", "app.debug=true",
+			}
+		})
+		It("should deep merge the values in order", func() {
+			Expect(*returnedStderr).To(BeEmpty())
+			Expect(*returnedCode).To(Equal(0))
+			Expect(*returnedStdout).To(Equal("base:8081 prod True"))
+		})
+	})
+	Context("when reading data from several sources", func() {
+		BeforeEach(func() {
+			GinkgoT().Setenv("APP_DB__HOST", "env-host")
+			GinkgoT().Setenv("APP_DB__PORT", "5432")
+			GinkgoT().Setenv("APP_NAME", "env-name")
+			GinkgoT().Setenv("OTHER_NAME", "ignored")
+			*stdin = `{"db": {"user": "stdin-user"}}`
+			*args = []string{
+				write("template.j2", "{{ name }} {{ db.host }}:{{ db.port }} {{ db.user }}@{{ db.name }} {{ other_name is defined }}"),
+				"--env-prefix", "APP_",
+				"--data", write("values.toml", "name = \"toml-name\"\n[db]\nname = \"toml-db\"\nport = 3306\n"),
+				"--data", "-",
+				"--stdin-format", "json",
+				"--set", "db.host=set-host",
+			}
+		})
+		It("should merge the environment, the data files and the overrides in order", func() {
+			Expect(*returnedStderr).To(BeEmpty())
+			Expect(*returnedStdout).To(Equal("toml-name set-host:3306 stdin-user@toml-db False"))
+		})
+		Context("when changing the precedence", func() {
+			BeforeEach(func() {
+				*args = append(*args, "--precedence", "data,set,env")
+			})
+			It("should let the environment take over", func() {
+				Expect(*returnedStderr).To(BeEmpty())
+				Expect(*returnedStdout).To(Equal("env-name env-host:5432 stdin-user@toml-db False"))
+			})
+		})
+		Context("when the precedence is missing a source", func() {
+			BeforeEach(func() {
+				*args = append(*args, "--precedence", "data,set")
+			})
+			It("should fail", func() {
+				Expect(*returnedCode).To(Equal(1))
+				Expect(*returnedStderr).To(Equal("gonja render: invalid value 'data,set' for --precedence: expected every source of env, data, set\n"))
+			})
+		})
+	})
+	Context("when reading numbers from JSON data", func() {
+		BeforeEach(func() {
+			*args = []string{
+				write("template.j2", "{{ port }} {{ port + 1 }} {{ ratio }} {{ ports | first }} {{ limits.max + 1 }}"),
+				"--data", write("values.json", `{"port": 80, "ratio": 0.5, "ports": [8080]}`),
+				"--data", "-",
+				"--stdin-format", "json",
+			}
+			*stdin = `{"limits": {"max": 9}}`
+		})
+		It("should render them as integers when they are integral", func() {
+			Expect(*returnedStderr).To(BeEmpty())
+			Expect(*returnedStdout).To(Equal("80 81 0.5 8080 10"))
+		})
+	})
+	Context("when reading both the template and data from the standard input", func() {
+		BeforeEach(func() {
+			*args = []string{"-", "--data", "-"}
+		})
+		It("should fail", func() {
+			Expect(*returnedCode).To(Equal(1))
+			Expect(*returnedStderr).To(Equal("gonja render: the standard input cannot be read for both the template and data\n"))
+		})
+	})
+	Context("when a data file is invalid", func() {
+		BeforeEach(func() {
+			*args = []string{write("template.j2", ""), "--data", write("values.json", "{")}
+		})
+		It("should fail", func() {
+			Expect(*returnedCode).To(Equal(1))
+			Expect(*returnedStderr).To(HavePrefix("gonja render: failed to parse data file '"))
+		})
+	})
+	Context("when writing to an output file", func() {
+		var output = new(string)
+		BeforeEach(func() {
+			*output = filepath.Join(*directory, "out.txt")
+			*args = []string{"-o", *output, write("template.j2", "{{ name }}"), "--set", "name=world"}
+		})
+		It("should write the rendered content to the file", func() {
+			Expect(*returnedCode).To(Equal(0))
+			Expect(*returnedStdout).To(BeEmpty())
+			Expect(os.ReadFile(*output)).To(Equal([]byte("world")))
+		})
+	})
+	Context("when writing a source map", func() {
+		var (
+			template  = new(string)
+			sourceMap = new(string)
+		)
+		BeforeEach(func() {
+			*template = write("template.j2", "a: 1\n{% if true %}\nb: {{ name }}\n{% endif %}")
+			*sourceMap = filepath.Join(*directory, "out.map.json")
+			*args = []string{*template, "--set", "name=world", "--source-map", *sourceMap}
+		})
+		It("should map the rendered lines to the template lines", func() {
+			Expect(*returnedStderr).To(BeEmpty())
+			Expect(*returnedStdout).To(Equal("a: 1\n\nb: world\n"))
+			Expect(os.ReadFile(*sourceMap)).To(MatchJSON(`{"segments": [
+				{"start": 0, "end": 5, "identifier": "` + *template + `", "line": 1},
+				{"start": 5, "end": 6, "identifier": "` + *template + `", "line": 2},
+				{"start": 6, "end": 15, "identifier": "` + *template + `", "line": 3}
+			]}`))
+		})
+	})
+	Context("when the template includes other templates", func() {
+		BeforeEach(func() {
+			write("templates/partials/header.j2", "header")
+			write("templates/page.j2", "{% include 'partials/header.j2' %} page")
+			*args = []string{filepath.Join(*directory, "templates", "page.j2")}
+		})
+		It("should load them relatively to the template directory", func() {
+			Expect(*returnedStderr).To(BeEmpty())
+			Expect(*returnedStdout).To(Equal("header page"))
+		})
+		Context("when a search path is given", func() {
+			BeforeEach(func() {
+				write("shared/partials/header.j2", "shared header")
+				*args = append(*args, "--search-path", filepath.Join(*directory, "shared"))
+			})
+			It("should load them from the search path", func() {
+				Expect(*returnedStderr).To(BeEmpty())
+				Expect(*returnedStdout).To(Equal("shared header page"))
+			})
+		})
+	})
+	Context("when reading the template from the standard input", func() {
+		BeforeEach(func() {
+			write("partial.j2", "partial")
+			*stdin = "{{ greeting }} {% include 'partial.j2' %}"
+			*args = []string{"-", "--set", "greeting=hello", "--search-path", *directory}
+		})
+		It("should render it", func() {
+			Expect(*returnedStderr).To(BeEmpty())
+			Expect(*returnedStdout).To(Equal("hello partial"))
+		})
+	})
+	Context("when a variable is undefined", func() {
+		BeforeEach(func() {
+			*args = []string{write("template.j2", "[{{ missing }}]")}
+		})
+		It("should render it as empty", func() {
+			Expect(*returnedCode).To(Equal(0))
+			Expect(*returnedStdout).To(Equal("[]"))
+		})
+		Context("when strict undefined is enabled", func() {
+			BeforeEach(func() {
+				*args = append(*args, "--strict-undefined")
+			})
+			It("should fail", func() {
+				Expect(*returnedCode).To(Equal(1))
+				Expect(*returnedStdout).To(BeEmpty())
+				Expect(*returnedStderr).To(ContainSubstring("missing"))
+			})
+		})
+	})
+	Context("when an override is malformed", func() {
+		BeforeEach(func() {
+			*args = []string{write("template.j2", ""), "--set", "name"}
+		})
+		It("should fail", func() {
+			Expect(*returnedCode).To(Equal(1))
+			Expect(*returnedStderr).To(Equal("gonja render: invalid value 'name' for --set: expected key=value\n"))
+		})
+	})
+	Context("when no template is given", func() {
+		BeforeEach(func() {
+			*args = []string{}
+		})
+		It("should fail with the usage", func() {
+			Expect(*returnedCode).To(Equal(1))
+			Expect(*returnedStderr).To(ContainSubstring("Usage: gonja render"))
+			Expect(*returnedStderr).To(HaveSuffix("gonja render: expected exactly one template\n"))
+		})
+	})
+})
+
+var _ = Context("cli", func() {
+	It("should fail on unknown commands", func() {
+		stderr := new(bytes.Buffer)
+		Expect(run([]string{"unknown"}, strings.NewReader(""), new(bytes.Buffer), stderr)).To(Equal(2))
+		Expect(stderr.String()).To(HavePrefix("gonja: unknown command 'unknown'"))
+	})
+	It("should list the commands", func() {
+		stdout := new(bytes.Buffer)
+		Expect(run([]string{"help"}, strings.NewReader(""), stdout, new(bytes.Buffer))).To(Equal(0))
+		Expect(stdout.String()).To(ContainSubstring("render"))
+	})
+})
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+)
+
+// data sources, which can be ordered with the --precedence flag
+const (
+	sourceEnv  = "env"
+	sourceData = "data"
+	sourceSet  = "set"
+)
+
+// defaultPrecedence lists the data sources from the lowest to the highest precedence
+var defaultPrecedence = []string{sourceEnv, sourceData, sourceSet}
+
+// errStdinConflict is returned when both the template and data are to be read from the standard input
+var errStdinConflict = errors.New("the standard input cannot be read for both the template and data")
+
+// dataOptions holds the flags defining the values templates are rendered with
+type dataOptions struct {
+	files       stringList
+	pairs       stringList
+	envPrefix   string
+	stdinFormat string
+	precedence  string
+}
+
+func (o *dataOptions) register(flags *flag.FlagSet) {
+	flags.Var(&o.files, "data", "JSON, YAML or TOML `file` to read values from, detected from its extension, or '-' for the standard input, can be repeated to deep merge several files")
+	flags.Var(&o.pairs, "set", "`key=value` pair overriding values, where keys can be dotted paths and values are parsed as YAML, can be repeated")
+	flags.StringVar(&o.envPrefix, "env-prefix", "", "read values from the environment variables starting with `prefix`, which is removed from their lowercased names, '__' separating nested keys")
+	flags.StringVar(&o.stdinFormat, "stdin-format", "yaml", "`format` of the data read from the standard input, one of json, yaml or toml")
+	flags.StringVar(&o.precedence, "precedence", strings.Join(defaultPrecedence, ","), "comma separated `sources` from the lowest to the highest precedence")
+}
+
+// readsStdin tells whether values are read from the standard input
+func (o *dataOptions) readsStdin() bool {
+	for _, file := range o.files {
+		if file == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// load deep merges the values of every source in order of precedence
+func (o *dataOptions) load(stdin io.Reader) (*exec.Context, error) {
+	precedence, err := parsePrecedence(o.precedence)
+	if err != nil {
+		return nil, err
+	}
+	values := exec.EmptyContext()
+	for _, source := range precedence {
+		var layer *exec.Context
+		switch source {
+		case sourceEnv:
+			layer = o.loadEnv()
+		case sourceData:
+			layer, err = o.loadFiles(stdin)
+		case sourceSet:
+			layer, err = o.loadPairs()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := values.Merge(layer, exec.MergeDeep); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func parsePrecedence(raw string) ([]string, error) {
+	precedence := strings.Split(raw, ",")
+	seen := map[string]bool{}
+	for index, source := range precedence {
+		source = strings.TrimSpace(source)
+		switch source {
+		case sourceEnv, sourceData, sourceSet:
+		default:
+			return nil, fmt.Errorf("invalid value '%s' for --precedence: unknown source '%s'", raw, source)
+		}
+		if seen[source] {
+			return nil, fmt.Errorf("invalid value '%s' for --precedence: duplicated source '%s'", raw, source)
+		}
+		seen[source] = true
+		precedence[index] = source
+	}
+	if len(seen) != len(defaultPrecedence) {
+		return nil, fmt.Errorf("invalid value '%s' for --precedence: expected every source of %s", raw, strings.Join(defaultPrecedence, ", "))
+	}
+	return precedence, nil
+}
+
+func (o *dataOptions) loadEnv() *exec.Context {
+	values := exec.EmptyContext()
+	if o.envPrefix == "" {
+		return values
+	}
+	for _, variable := range os.Environ() {
+		name, value, _ := strings.Cut(variable, "=")
+		if !strings.HasPrefix(name, o.envPrefix) || name == o.envPrefix {
+			continue
+		}
+		setValue(values, strings.Split(strings.ToLower(strings.TrimPrefix(name, o.envPrefix)), "__"), value)
+	}
+	return values
+}
+
+func (o *dataOptions) loadFiles(stdin io.Reader) (*exec.Context, error) {
+	values := exec.EmptyContext()
+	for _, file := range o.files {
+		var (
+			content []byte
+			err     error
+			format  = o.stdinFormat
+		)
+		if file == "-" {
+			content, err = io.ReadAll(stdin)
+		} else {
+			format = strings.TrimPrefix(filepath.Ext(file), ".")
+			content, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read data file: %w", err)
+		}
+		data, err := decode(content, format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse data file '%s': %w", file, err)
+		}
+		if err := values.Merge(exec.NewContext(data), exec.MergeDeep); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// decode parses data according to its format, falling back to YAML which JSON is a subset of
+func decode(content []byte, format string) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+	switch strings.ToLower(format) {
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&data); err != nil {
+			return nil, err
+		}
+		fromJSONNumbers(data)
+	case "toml":
+		if err := toml.Unmarshal(content, &data); err != nil {
+			return nil, err
+		}
+	default:
+		if err := yaml.Unmarshal(content, &data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// fromJSONNumbers replaces the numbers decoded as json.Number by integers when they are integral, and by
+// floats otherwise, so that JSON data is typed like YAML and TOML data instead of holding float64 only
+func fromJSONNumbers(decoded interface{}) interface{} {
+	switch v := decoded.(type) {
+	case json.Number:
+		if integer, err := v.Int64(); err == nil {
+			return int(integer)
+		}
+		float, _ := v.Float64()
+		return float
+	case []interface{}:
+		for index, item := range v {
+			v[index] = fromJSONNumbers(item)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = fromJSONNumbers(item)
+		}
+	}
+	return decoded
+}
+
+func (o *dataOptions) loadPairs() (*exec.Context, error) {
+	values := exec.EmptyContext()
+	for _, pair := range o.pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid value '%s' for --set: expected key=value", pair)
+		}
+		setValue(values, strings.Split(key, "."), parseValue(value))
+	}
+	return values, nil
+}
+
+// parseValue parses a value given on the command line as YAML, so that numbers, booleans and
+// lists are typed, falling back to the raw string
+func parseValue(raw string) interface{} {
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil || value == nil {
+		return raw
+	}
+	return value
+}
+
+func setValue(values *exec.Context, path []string, value interface{}) {
+	if len(path) == 1 {
+		values.Set(path[0], value)
+		return
+	}
+	existing, _ := values.Get(path[0])
+	parent, ok := existing.(map[string]interface{})
+	if !ok {
+		parent = map[string]interface{}{}
+		values.Set(path[0], parent)
+	}
+	for _, key := range path[1 : len(path)-1] {
+		child, ok := parent[key].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			parent[key] = child
+		}
+		parent = child
+	}
+	parent[path[len(path)-1]] = value
+}
@@ -26,6 +26,40 @@ type Config struct {
 	TrimBlocks bool
 	// If is set to true, the leading spaces and tabes are stripped from the start of a line to a block
 	LeftStripBlocks bool
+	// If set to true, statement tags trim the whitespace around them as if they were written with '-' on both
+	// sides, like {%- if x -%}. A '+' marker, as in {%+ if x +%}, keeps the whitespace on its side.
+	TrimStatements bool
+	// If set to true, output tags trim the whitespace around them as if they were written like {{- x -}}.
+	// A '+' marker before the closing delimiter, as in {{ x +}}, keeps the whitespace after the tag.
+	TrimOutputs bool
+	// If set to true, comments trim the whitespace around them as if they were written like {#- x -#}.
+	// A '+' marker, as in {#+ x +#}, keeps the whitespace on its side.
+	TrimComments bool
+	// If set to true, a value is falsy only when it is the zero value of its go type, meaning
+	// empty but allocated slices and maps are truthy. Otherwise python-like truthiness applies
+	// and empty strings, lists and dictionaries are falsy
+	ZeroValueTruthiness bool
+	// If set to true, a single trailing newline is removed from templates when they are loaded,
+	// as the python implementation does unless keep_trailing_newline is set
+	TrimTrailingNewline bool
+	// If set to true, none values are told apart from undefined ones like python does: they are printed
+	// and concatenated as 'None' instead of an empty string, and the default filter does not replace them.
+	// The lowercase none literal is only recognized then, and is a regular variable name otherwise.
+	PythonNone bool
+	// If set to true, a YAML document between '---' lines at the very beginning of templates is read as
+	// their front-matter instead of being rendered, see FrontMatter. Comment headers such as
+	// `{# gonja: autoescape=false #}` are read regardless.
+	FrontMatter bool
+	// If set to true, the templates referenced by include, import, from and extends statements are looked
+	// up from the root of the loader like python does, unless their identifier starts with './' or '../'
+	// in which case they are looked up relatively to the referencing template. Otherwise, identifiers are
+	// looked up from the root of the loader by the root template and relatively to themselves by the
+	// templates it loads.
+	RelativePaths bool
+	// The locale used by the sort, dictsort and groupby filters to order strings, as a BCP 47 tag such as
+	// 'fr' or 'sv-SE', unless they are given their own with their locale argument. Strings are ordered
+	// byte-wise when it is empty.
+	Collation string
 }
 
 func New() *Config {
@@ -40,9 +74,33 @@ func New() *Config {
 		StrictUndefined:     false,
 		TrimBlocks:          false,
 		LeftStripBlocks:     false,
+		TrimStatements:      false,
+		TrimOutputs:         false,
+		TrimComments:        false,
+		ZeroValueTruthiness: false,
+		TrimTrailingNewline: false,
+		PythonNone:          false,
+		FrontMatter:         false,
+		RelativePaths:       false,
+		Collation:           "",
 	}
 }
 
+// Jinja2 returns a configuration matching the defaults of the python implementation as closely as possible,
+// so that templates shared with python programs render the same output. It uses python-like truthiness and
+// lenient undefined values, removes the trailing newline of templates and tells none values apart from
+// undefined ones.
+//
+// Some differences remain: the defined test considers none values as undefined, tuples are printed like lists, floats are printed with at most 11 decimals
+// and dictionaries given as go maps are iterated in the order of their sorted keys rather than in
+// insertion order, which go maps do not keep. Dictionaries defined by templates keep their order.
+func Jinja2() *Config {
+	c := New()
+	c.TrimTrailingNewline = true
+	c.PythonNone = true
+	return c
+}
+
 func (c *Config) Inherit() *Config {
 	return &Config{
 		BlockStartString:    c.BlockStartString,
@@ -55,5 +113,14 @@ func (c *Config) Inherit() *Config {
 		StrictUndefined:     c.StrictUndefined,
 		TrimBlocks:          c.TrimBlocks,
 		LeftStripBlocks:     c.LeftStripBlocks,
+		TrimStatements:      c.TrimStatements,
+		TrimOutputs:         c.TrimOutputs,
+		TrimComments:        c.TrimComments,
+		ZeroValueTruthiness: c.ZeroValueTruthiness,
+		TrimTrailingNewline: c.TrimTrailingNewline,
+		PythonNone:          c.PythonNone,
+		FrontMatter:         c.FrontMatter,
+		RelativePaths:       c.RelativePaths,
+		Collation:           c.Collation,
 	}
 }
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatterKey is the key of the front-matter holding configuration overrides
+const FrontMatterKey = "gonja"
+
+// FrontMatter is the optional header of a template source, either a comment starting with the front-matter
+// key such as `{# gonja: autoescape=false, trim_blocks=true #}`, or, when enabled by Config.FrontMatter,
+// a YAML document between `---` lines where the configuration overrides are held by the front-matter key:
+//
+//	---
+//	title: Welcome
+//	gonja:
+//	  autoescape: true
+//	---
+type FrontMatter struct {
+	// Values holds the keys of a YAML front-matter, and is empty for a comment header
+	Values map[string]interface{}
+	// Config is the configuration with the overrides of the header applied
+	Config *Config
+	// Source is the template source where the header is replaced by a comment spanning as many lines,
+	// so that the lines of the template are left untouched
+	Source string
+	// Header is the header as written at the beginning of the template, empty when there is none
+	Header string
+	// HeaderSize is the size in bytes of the comment replacing the header at the beginning of Source
+	HeaderSize int
+
+	overrides map[string]interface{}
+}
+
+// frontMatterOptions maps the names of the options which front-matters can override to their fields
+var frontMatterOptions = map[string]func(c *Config) interface{}{
+	"block_start_string":    func(c *Config) interface{} { return &c.BlockStartString },
+	"block_end_string":      func(c *Config) interface{} { return &c.BlockEndString },
+	"variable_start_string": func(c *Config) interface{} { return &c.VariableStartString },
+	"variable_end_string":   func(c *Config) interface{} { return &c.VariableEndString },
+	"comment_start_string":  func(c *Config) interface{} { return &c.CommentStartString },
+	"comment_end_string":    func(c *Config) interface{} { return &c.CommentEndString },
+	"autoescape":            func(c *Config) interface{} { return &c.AutoEscape },
+	"strict_undefined":      func(c *Config) interface{} { return &c.StrictUndefined },
+	"trim_blocks":           func(c *Config) interface{} { return &c.TrimBlocks },
+	"lstrip_blocks":         func(c *Config) interface{} { return &c.LeftStripBlocks },
+	"trim_statements":       func(c *Config) interface{} { return &c.TrimStatements },
+	"trim_outputs":          func(c *Config) interface{} { return &c.TrimOutputs },
+	"trim_comments":         func(c *Config) interface{} { return &c.TrimComments },
+	"zero_value_truthiness": func(c *Config) interface{} { return &c.ZeroValueTruthiness },
+	"trim_trailing_newline": func(c *Config) interface{} { return &c.TrimTrailingNewline },
+	"python_none":           func(c *Config) interface{} { return &c.PythonNone },
+	"collation":             func(c *Config) interface{} { return &c.Collation },
+}
+
+// ParseFrontMatter reads the front-matter of a template source, if any, and returns it along with a copy
+// of this configuration where the options it sets are overridden. Sources without front-matter are returned
+// as they are with an inherited configuration.
+func (c *Config) ParseFrontMatter(source string) (*FrontMatter, error) {
+	frontMatter := &FrontMatter{
+		Values: map[string]interface{}{},
+		Config: c.Inherit(),
+		Source: source,
+	}
+	var (
+		header    string
+		overrides map[string]interface{}
+		err       error
+	)
+	if c.FrontMatter && (strings.HasPrefix(source, "---\n") || strings.HasPrefix(source, "---\r\n")) {
+		header, overrides, err = parseYAMLFrontMatter(source, frontMatter.Values)
+	} else if strings.HasPrefix(source, c.CommentStartString) {
+		header, overrides, err = c.parseCommentFrontMatter(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if header == "" {
+		return frontMatter, nil
+	}
+
+	frontMatter.overrides = overrides
+	if frontMatter.Config, err = frontMatter.apply(c); err != nil {
+		return nil, err
+	}
+	replacement := frontMatter.Config.CommentStartString +
+		strings.Repeat("\n", strings.Count(header, "\n")) +
+		frontMatter.Config.CommentEndString
+	frontMatter.Source = replacement + source[len(header):]
+	frontMatter.Header = header
+	frontMatter.HeaderSize = len(replacement)
+	return frontMatter, nil
+}
+
+// Apply returns a copy of the configuration with the overrides of the front-matter applied, for
+// example to render the template with the configuration of the template including it
+func (f *FrontMatter) Apply(c *Config) *Config {
+	config, _ := f.apply(c)
+	return config
+}
+
+func (f *FrontMatter) apply(c *Config) (*Config, error) {
+	config := c.Inherit()
+	names := make([]string, 0, len(f.overrides))
+	for name := range f.overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := config.set(name, f.overrides[name]); err != nil {
+			return nil, fmt.Errorf("invalid front-matter: %w", err)
+		}
+	}
+	return config, nil
+}
+
+// parseYAMLFrontMatter returns the YAML header of the source, up to and including its closing line, and the
+// overrides it holds
+func parseYAMLFrontMatter(source string, values map[string]interface{}) (string, map[string]interface{}, error) {
+	start := strings.Index(source, "\n") + 1
+	end := -1
+	for offset := start; offset < len(source); {
+		next := strings.Index(source[offset:], "\n")
+		line := source[offset:]
+		if next >= 0 {
+			line = source[offset : offset+next]
+		}
+		if strings.TrimRight(line, "\r") == "---" {
+			end = offset
+			if next >= 0 {
+				offset += next + 1
+			} else {
+				offset = len(source)
+			}
+			if err := yaml.Unmarshal([]byte(source[start:end]), &values); err != nil {
+				return "", nil, fmt.Errorf("invalid front-matter: %w", err)
+			}
+			overrides := map[string]interface{}{}
+			if options, ok := values[FrontMatterKey]; ok {
+				if overrides, ok = options.(map[string]interface{}); !ok {
+					return "", nil, fmt.Errorf("invalid front-matter: '%s' must be a mapping of options", FrontMatterKey)
+				}
+			}
+			return source[:offset], overrides, nil
+		}
+		if next < 0 {
+			break
+		}
+		offset += next + 1
+	}
+	return "", nil, fmt.Errorf("invalid front-matter: missing closing '---' line")
+}
+
+// parseCommentFrontMatter returns the comment header of the source and the overrides it holds, or an empty
+// header if the first comment of the source is not a front-matter
+func (c *Config) parseCommentFrontMatter(source string) (string, map[string]interface{}, error) {
+	end := strings.Index(source, c.CommentEndString)
+	if end < 0 {
+		return "", nil, nil
+	}
+	content := strings.TrimSpace(source[len(c.CommentStartString):end])
+	if !strings.HasPrefix(content, FrontMatterKey+":") {
+		return "", nil, nil
+	}
+	overrides := map[string]interface{}{}
+	for _, option := range strings.Split(strings.TrimPrefix(content, FrontMatterKey+":"), ",") {
+		if strings.TrimSpace(option) == "" {
+			continue
+		}
+		name, value, found := strings.Cut(option, "=")
+		if !found {
+			return "", nil, fmt.Errorf("invalid front-matter: expected name=value but got '%s'", strings.TrimSpace(option))
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			overrides[strings.TrimSpace(name)] = unquoted
+		} else if boolean, err := strconv.ParseBool(value); err == nil {
+			overrides[strings.TrimSpace(name)] = boolean
+		} else {
+			overrides[strings.TrimSpace(name)] = value
+		}
+	}
+	return source[:end+len(c.CommentEndString)], overrides, nil
+}
+
+// set overrides the option with the given front-matter name
+func (c *Config) set(name string, value interface{}) error {
+	field, ok := frontMatterOptions[name]
+	if !ok {
+		return fmt.Errorf("unknown option '%s'", name)
+	}
+	switch pointer := field(c).(type) {
+	case *bool:
+		boolean, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("option '%s' expects a boolean but got '%v'", name, value)
+		}
+		*pointer = boolean
+	case *string:
+		text, ok := value.(string)
+		if !ok || text == "" {
+			return fmt.Errorf("option '%s' expects a non empty string but got '%v'", name, value)
+		}
+		*pointer = text
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package gonja
+
+import (
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/parser"
+)
+
+// Error locates the errors of templates failing to parse or to render, see exec.Error
+type Error = exec.Error
+
+var (
+	// ErrTemplateNotFound is matched by the errors of templates which cannot be found, see loaders.ErrTemplateNotFound
+	ErrTemplateNotFound = loaders.ErrTemplateNotFound
+	// ErrSyntax is matched by the errors of templates with an invalid syntax, see parser.SyntaxError
+	ErrSyntax = parser.ErrSyntax
+	// ErrUndefined is matched by the errors of strict lookups of undefined variables, see exec.ErrUndefined
+	ErrUndefined = exec.ErrUndefined
+)
@@ -10,7 +10,6 @@ import (
 	"github.com/nikolalohinski/gonja/v2/config"
 	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/loaders"
-	"github.com/pkg/errors"
 )
 
 // Define a custom config. The StartString and EndString are different from default
@@ -34,7 +33,7 @@ var filterFuncB64Encode exec.FilterFunction = func(e *exec.Evaluator, in *exec.V
 	}
 	p := params.Expect(0, []*exec.KwArg{{Name: "wrap", Default: nil}})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'to_yaml'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'to_yaml': %w", p))
 	}
 	// wrap is unsupported in golang, try to implement it later on
 	o := b64.StdEncoding.EncodeToString([]byte(in.String()))
@@ -47,7 +46,7 @@ var filterFuncB64Decode exec.FilterFunction = func(e *exec.Evaluator, in *exec.V
 	}
 	p := params.Expect(0, []*exec.KwArg{{Name: "wrap", Default: nil}})
 	if p.IsError() {
-		return exec.AsValue(errors.Wrap(p, "Wrong signature for 'to_yaml'"))
+		return exec.AsValue(fmt.Errorf("Wrong signature for 'to_yaml': %w", p))
 	}
 	// wrap is unsupported in golang b64, try to implement it later on
 	o, err := b64.StdEncoding.DecodeString(in.String())
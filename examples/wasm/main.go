@@ -0,0 +1,55 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+)
+
+func main() {
+	// Expose a function rendering templates held in a JavaScript object, for example:
+	// renderTemplate({"/page": "{% include 'header' %}", "/header": "Hello {{ name }}"}, "/page", '{"name": "you"}')
+	js.Global().Set("renderTemplate", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) != 3 {
+			return map[string]interface{}{"error": "expected templates, identifier and data"}
+		}
+		output, err := render(args[0], args[1].String(), args[2].String())
+		if err != nil {
+			return map[string]interface{}{"error": err.Error()}
+		}
+		return map[string]interface{}{"output": output}
+	}))
+
+	// Keep the module running so that the function remains callable
+	select {}
+}
+
+func render(templates js.Value, identifier string, data string) (string, error) {
+	// Templates are loaded from memory since browsers have no file system
+	content := map[string]string{}
+	keys := js.Global().Get("Object").Call("keys", templates)
+	for i := 0; i < keys.Length(); i++ {
+		key := keys.Index(i).String()
+		content[key] = templates.Get(key).String()
+	}
+	loader, err := loaders.NewMemoryLoader(content)
+	if err != nil {
+		return "", err
+	}
+
+	values := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(data), &values); err != nil {
+		return "", err
+	}
+
+	template, err := exec.NewTemplate(identifier, gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+	if err != nil {
+		return "", err
+	}
+	return template.ExecuteToString(exec.NewContext(values))
+}
@@ -0,0 +1,135 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrBudgetExceeded is the cause of the errors of renders exceeding their budget, see Context.SetBudget
+var ErrBudgetExceeded = errors.New("render budget exceeded")
+
+// Budget bounds the resources a single render may use. Accounting is approximate: it is meant to stop
+// pathological templates early rather than to measure memory precisely. Zero values mean no limit.
+type Budget struct {
+	// MaxOutputSize is the number of bytes a render may produce, including the content captured by
+	// statements such as set blocks, macros and filter blocks before they use it. Strings repeated by the
+	// multiplication operator may not be larger either.
+	MaxOutputSize int
+	// MaxIterations is the number of items all the loops of a render and the lists built by the list
+	// filter may go through, which bounds the values they create. A range may not have more items than
+	// the ones left either.
+	MaxIterations int
+	// MaxIncludeDepth is the number of templates which may be included into one another
+	MaxIncludeDepth int
+}
+
+// renderBudget accounts for the resources spent by a render against its budget
+type renderBudget struct {
+	Budget
+	output     int
+	iterations int
+	depth      int
+}
+
+// SetBudget sets the budget of the renders executed with this context, or with contexts inheriting from
+// it. Each render accounts for its own resources: a render exceeding the budget fails with an error
+// wrapping ErrBudgetExceeded, which protects services rendering templates written by untrusted users.
+func (ctx *Context) SetBudget(budget Budget) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	ctx.budget = &renderBudget{Budget: budget}
+}
+
+// renderBudget returns the budget set on this context or the closest of its parents, if any
+func (ctx *Context) renderBudget() *renderBudget {
+	for ; ctx != nil; ctx = ctx.parent {
+		ctx.lock.Lock()
+		budget := ctx.budget
+		ctx.lock.Unlock()
+		if budget != nil {
+			return budget
+		}
+	}
+	return nil
+}
+
+// spendOutput accounts for content produced by the render
+func (r *Renderer) spendOutput(size int) error {
+	budget := r.budget
+	if budget == nil || budget.MaxOutputSize <= 0 {
+		return nil
+	}
+	budget.output += size
+	if budget.output > budget.MaxOutputSize {
+		return fmt.Errorf("%w: output larger than %d bytes", ErrBudgetExceeded, budget.MaxOutputSize)
+	}
+	return nil
+}
+
+// SpendIteration accounts for an item a loop goes through. It is meant to be called by the control
+// structures iterating over values, and returns an error once the budget of the render is exceeded.
+func (r *Renderer) SpendIteration() error {
+	return r.budget.spendIterations(1)
+}
+
+// SpendIterations accounts for the items a filter or a function goes through to build a value, such as
+// the list filter. It returns an error once the budget of the render is exceeded, and does nothing for
+// evaluators which are not given the budget of a render.
+func (e *Evaluator) SpendIterations(count int) error {
+	return e.budget.spendIterations(count)
+}
+
+// CheckIterations returns an error when going through count more items would exceed the budget of the
+// render, without accounting for them. It is meant for functions returning lazy values, such as range,
+// whose items are accounted for by the loops and filters going through them.
+func (e *Evaluator) CheckIterations(count int) error {
+	budget := e.budget
+	if budget == nil || budget.MaxIterations <= 0 {
+		return nil
+	}
+	if budget.iterations+count > budget.MaxIterations {
+		return fmt.Errorf("%w: more than %d loop iterations", ErrBudgetExceeded, budget.MaxIterations)
+	}
+	return nil
+}
+
+// checkSize returns an error when a value made of count times size bytes is larger than the output the
+// render may produce, before it is built
+func (e *Evaluator) checkSize(size int, count int) error {
+	budget := e.budget
+	if budget == nil || budget.MaxOutputSize <= 0 || count <= 0 {
+		return nil
+	}
+	// dividing rather than multiplying, which could overflow
+	if size > budget.MaxOutputSize/count {
+		return fmt.Errorf("%w: value larger than %d bytes", ErrBudgetExceeded, budget.MaxOutputSize)
+	}
+	return nil
+}
+
+// spendIterations accounts for count items against the budget, if any
+func (budget *renderBudget) spendIterations(count int) error {
+	if budget == nil || budget.MaxIterations <= 0 {
+		return nil
+	}
+	budget.iterations += count
+	if budget.iterations > budget.MaxIterations {
+		return fmt.Errorf("%w: more than %d loop iterations", ErrBudgetExceeded, budget.MaxIterations)
+	}
+	return nil
+}
+
+// EnterInclude accounts for the inclusion of a template, returning a function to call once the included
+// template is rendered. It is meant to be called by the control structures rendering other templates, and
+// returns an error once the budget of the render is exceeded.
+func (r *Renderer) EnterInclude(identifier string) (func(), error) {
+	budget := r.budget
+	if budget == nil || budget.MaxIncludeDepth <= 0 {
+		return func() {}, nil
+	}
+	if budget.depth >= budget.MaxIncludeDepth {
+		return nil, fmt.Errorf("%w: including '%s' nests more than %d templates", ErrBudgetExceeded, identifier, budget.MaxIncludeDepth)
+	}
+	budget.depth++
+	return func() { budget.depth-- }, nil
+}
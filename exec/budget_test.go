@@ -0,0 +1,157 @@
+package exec_test
+
+import (
+	"errors"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("render budget", func() {
+	var (
+		templates = new(map[string]string)
+		budget    = new(exec.Budget)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*templates = map[string]string{}
+		*budget = exec.Budget{MaxOutputSize: 64, MaxIterations: 10, MaxIncludeDepth: 3}
+	})
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(*templates)
+		t, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+		Expect(err).To(BeNil())
+		data := exec.NewContext(map[string]interface{}{"name": "ada"})
+		data.SetBudget(*budget)
+		*returnedResult, *returnedErr = t.ExecuteToString(data)
+	})
+	Context("when the render stays within the budget", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% for i in range(10) %}{{ i }}{% endfor %} {% include "/partial" %}`
+			(*templates)["/partial"] = "{{ name }}"
+		})
+		It("should render the template", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("0123456789 ada"))
+		})
+	})
+	Context("when the output is too large", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% for i in range(9) %}{{ name ~ name ~ name }}{% endfor %}`
+		})
+		It("should abort the render", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("render budget exceeded: output larger than 64 bytes")))
+		})
+	})
+	Context("when content captured by a statement is too large", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% filter length %}{% for i in range(9) %}{{ name ~ name ~ name }}{% endfor %}{% endfilter %}`
+		})
+		It("should abort the render as well", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("render budget exceeded: output larger than 64 bytes")))
+		})
+	})
+	Context("when loops go through too many items", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% for i in range(4) %}{% for j in range(5) %}{% endfor %}{% endfor %}`
+		})
+		It("should abort the render", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("render budget exceeded: more than 10 loop iterations")))
+		})
+	})
+	Context("when a string is repeated beyond the output size", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{{ ("abcd" * 5000000) | length }}`
+		})
+		It("should abort the render before repeating it", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("render budget exceeded: value larger than 64 bytes")))
+		})
+	})
+	Context("when a range has more items than the ones left", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% for i in range(5) %}{% endfor %}{{ range(6) | length }}`
+		})
+		It("should abort the render", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("render budget exceeded: more than 10 loop iterations")))
+		})
+	})
+	Context("when a list is built out of too many items", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{{ range(8) | list | length }}{{ "abcdefgh" | list | length }}`
+		})
+		It("should abort the render", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("render budget exceeded: more than 10 loop iterations")))
+		})
+	})
+	Context("when templates are included too deeply", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% include "/test" %}`
+		})
+		It("should abort the render", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("render budget exceeded: including '/test' nests more than 3 templates")))
+		})
+	})
+	Context("when the budget is unlimited", func() {
+		BeforeEach(func() {
+			*budget = exec.Budget{}
+			(*templates)["/test"] = `{% for i in range(100) %}{{ name }}{% endfor %}`
+		})
+		It("should render the template", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(HaveLen(300))
+		})
+	})
+	Context("when executing a template several times", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% for i in range(6) %}{% endfor %}`
+		})
+		It("should account for each render separately", func() {
+			Expect(*returnedErr).To(BeNil())
+			loader := loaders.MustNewMemoryLoader(*templates)
+			t, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+			Expect(err).To(BeNil())
+			data := exec.EmptyContext()
+			data.SetBudget(*budget)
+			for i := 0; i < 3; i++ {
+				_, err := t.ExecuteToString(data)
+				Expect(err).To(BeNil())
+			}
+		})
+	})
+	Context("when the budget is set on the globals of the environment", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% for i in range(6) %}{% endfor %}`
+		})
+		It("should account for each render separately, including concurrent ones", func() {
+			environment, err := exec.NewEnvironmentBuilder(gonja.DefaultEnvironment).Build()
+			Expect(err).To(BeNil())
+			environment.Context.SetBudget(*budget)
+			t, err := exec.NewTemplate("/test", gonja.DefaultConfig, loaders.MustNewMemoryLoader(*templates), environment)
+			Expect(err).To(BeNil())
+			errs := make(chan error, 8)
+			for i := 0; i < cap(errs); i++ {
+				go func() {
+					_, err := t.ExecuteToString(nil)
+					errs <- err
+				}()
+			}
+			for i := 0; i < cap(errs); i++ {
+				Expect(<-errs).To(BeNil())
+			}
+		})
+	})
+	Context("when the budget is exceeded", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% for i in range(20) %}{% endfor %}`
+		})
+		It("should return an error wrapping ErrBudgetExceeded", func() {
+			Expect(errors.Is(*returnedErr, exec.ErrBudgetExceeded)).To(BeTrue())
+		})
+	})
+})
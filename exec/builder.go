@@ -0,0 +1,228 @@
+package exec
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nikolalohinski/gonja/v2/parser"
+)
+
+// EnvironmentBuilder assembles an immutable Environment.
+//
+// Every registry of the base environment is copied when the builder is created, so neither
+// the base environment nor the built one are affected by later registrations. The built
+// environment is frozen: calls to Register, Replace or Update on its filters, tests and
+// control structures return errors and leave the sets untouched, and lookups happen without
+// locking. It can therefore be shared by any number of concurrent renders, each of them
+// getting its own inherited Context.
+type EnvironmentBuilder struct {
+	filters           map[string]FilterFunction
+	tests             map[string]TestFunction
+	controlStructures map[string]parser.ControlStructureParser
+	aliases           map[string]controlStructureAlias
+	globals           map[string]interface{}
+	methods           Methods
+	logger            logrus.FieldLogger
+	autoReload        bool
+	autoReloadEvery   time.Duration
+	notCallable       NotCallablePolicy
+	notCallableHook   NotCallableFunc
+	errs              []error
+}
+
+// NewEnvironmentBuilder creates a builder starting from a copy of the given environment,
+// which may be nil to start from an empty one
+func NewEnvironmentBuilder(base *Environment) *EnvironmentBuilder {
+	b := &EnvironmentBuilder{
+		filters:           map[string]FilterFunction{},
+		tests:             map[string]TestFunction{},
+		controlStructures: map[string]parser.ControlStructureParser{},
+		aliases:           map[string]controlStructureAlias{},
+		globals:           map[string]interface{}{},
+	}
+	if base == nil {
+		return b
+	}
+	if base.Filters != nil {
+		b.filters = base.Filters.all()
+	}
+	if base.Tests != nil {
+		b.tests = base.Tests.all()
+	}
+	if base.ControlStructures != nil {
+		b.controlStructures = base.ControlStructures.all()
+		b.aliases = base.ControlStructures.allAliases()
+	}
+	for ctx := base.Context; ctx != nil; ctx = ctx.parent {
+		ctx.lock.Lock()
+		for name, value := range ctx.data {
+			if _, shadowed := b.globals[name]; !shadowed {
+				b.globals[name] = value
+			}
+		}
+		ctx.lock.Unlock()
+	}
+	b.methods = Methods{
+		Bool:  base.Methods.Bool.clone(),
+		Int:   base.Methods.Int.clone(),
+		Float: base.Methods.Float.clone(),
+		Str:   base.Methods.Str.clone(),
+		Dict:  base.Methods.Dict.clone(),
+		List:  base.Methods.List.clone(),
+	}
+	b.logger = base.Logger
+	b.autoReload = base.AutoReload
+	b.autoReloadEvery = base.AutoReloadInterval
+	b.notCallable = base.NotCallable
+	b.notCallableHook = base.NotCallableHook
+	return b
+}
+
+// WithFilter registers a filter, replacing any existing one with the same name
+func (b *EnvironmentBuilder) WithFilter(name string, fn FilterFunction) *EnvironmentBuilder {
+	b.filters[name] = fn
+	return b
+}
+
+// WithoutFilter removes a filter if it is registered
+func (b *EnvironmentBuilder) WithoutFilter(name string) *EnvironmentBuilder {
+	delete(b.filters, name)
+	return b
+}
+
+// WithTest registers a test, replacing any existing one with the same name.
+// Tests are validated when building the environment.
+func (b *EnvironmentBuilder) WithTest(name string, fn TestFunction) *EnvironmentBuilder {
+	b.tests[name] = fn
+	return b
+}
+
+// WithoutTest removes a test if it is registered
+func (b *EnvironmentBuilder) WithoutTest(name string) *EnvironmentBuilder {
+	delete(b.tests, name)
+	return b
+}
+
+// WithControlStructure registers a control structure, replacing any existing one with the same name
+func (b *EnvironmentBuilder) WithControlStructure(name string, controlStructure parser.ControlStructureParser) *EnvironmentBuilder {
+	b.controlStructures[name] = controlStructure
+	return b
+}
+
+// WithoutControlStructure removes a control structure if it is registered
+func (b *EnvironmentBuilder) WithoutControlStructure(name string) *EnvironmentBuilder {
+	delete(b.controlStructures, name)
+	return b
+}
+
+// WithAlias registers a legacy name for a control structure or one of its intermediate tags, replacing any
+// existing alias with the same name, see ControlStructureSet.Alias
+func (b *EnvironmentBuilder) WithAlias(alias string, name string) *EnvironmentBuilder {
+	b.aliases[alias] = controlStructureAlias{name: name}
+	return b
+}
+
+// WithDeprecatedAlias registers an alias logging a warning on each use, see ControlStructureSet.DeprecatedAlias
+func (b *EnvironmentBuilder) WithDeprecatedAlias(alias string, name string) *EnvironmentBuilder {
+	b.aliases[alias] = controlStructureAlias{name: name, deprecated: true}
+	return b
+}
+
+// WithGlobal defines a global variable or function available to all templates
+func (b *EnvironmentBuilder) WithGlobal(name string, value interface{}) *EnvironmentBuilder {
+	b.globals[name] = value
+	return b
+}
+
+// WithoutGlobal removes a global variable or function if it is defined
+func (b *EnvironmentBuilder) WithoutGlobal(name string) *EnvironmentBuilder {
+	delete(b.globals, name)
+	return b
+}
+
+// WithLogger sets the logger receiving the messages logged by templates
+func (b *EnvironmentBuilder) WithLogger(logger logrus.FieldLogger) *EnvironmentBuilder {
+	b.logger = logger
+	return b
+}
+
+// WithAutoReload enables or disables the reloading of the templates changed since they were parsed, see
+// Environment.AutoReload
+func (b *EnvironmentBuilder) WithAutoReload(enabled bool) *EnvironmentBuilder {
+	b.autoReload = enabled
+	return b
+}
+
+// WithAutoReloadInterval sets how often the templates check whether they changed, see
+// Environment.AutoReloadInterval
+func (b *EnvironmentBuilder) WithAutoReloadInterval(interval time.Duration) *EnvironmentBuilder {
+	b.autoReloadEvery = interval
+	return b
+}
+
+// WithNotCallablePolicy sets what happens when a template calls something which is not a function, see
+// Environment.NotCallable
+func (b *EnvironmentBuilder) WithNotCallablePolicy(policy NotCallablePolicy) *EnvironmentBuilder {
+	b.notCallable = policy
+	return b
+}
+
+// WithNotCallableHook sets the function called in place of the functions and methods which can not be
+// called, and selects the NotCallableCallsHook policy
+func (b *EnvironmentBuilder) WithNotCallableHook(hook NotCallableFunc) *EnvironmentBuilder {
+	b.notCallable = NotCallableCallsHook
+	b.notCallableHook = hook
+	return b
+}
+
+// Build validates the registered tests and functions and returns a new frozen environment.
+// The builder can be reused afterwards without affecting the returned environment.
+func (b *EnvironmentBuilder) Build() (*Environment, error) {
+	if len(b.errs) > 0 {
+		return nil, fmt.Errorf("failed to build environment: %w", b.errs[0])
+	}
+	tests := &TestSet{tests: make(map[string]TestFunction, len(b.tests)), frozen: true}
+	for name, test := range b.tests {
+		if err := tests.validate(name, test); err != nil {
+			return nil, fmt.Errorf("failed to build environment: %w", err)
+		}
+		tests.tests[name] = test
+	}
+	filters := &FilterSet{filters: make(map[string]FilterFunction, len(b.filters)), frozen: true}
+	for name, filter := range b.filters {
+		filters.filters[name] = filter
+	}
+	controlStructures := &ControlStructureSet{statements: make(map[string]parser.ControlStructureParser, len(b.controlStructures)), frozen: true}
+	for name, controlStructure := range b.controlStructures {
+		controlStructures.statements[name] = controlStructure
+	}
+	controlStructures.aliases = make(map[string]controlStructureAlias, len(b.aliases))
+	for alias, target := range b.aliases {
+		controlStructures.aliases[alias] = target
+	}
+	globals := make(map[string]interface{}, len(b.globals))
+	for name, value := range b.globals {
+		globals[name] = value
+	}
+	return &Environment{
+		Filters:           filters,
+		Tests:             tests,
+		ControlStructures: controlStructures,
+		Context:           NewContext(globals),
+		Methods: Methods{
+			Bool:  b.methods.Bool.clone(),
+			Int:   b.methods.Int.clone(),
+			Float: b.methods.Float.clone(),
+			Str:   b.methods.Str.clone(),
+			Dict:  b.methods.Dict.clone(),
+			List:  b.methods.List.clone(),
+		},
+		Logger:             b.logger,
+		AutoReload:         b.autoReload,
+		AutoReloadInterval: b.autoReloadEvery,
+		NotCallable:        b.notCallable,
+		NotCallableHook:    b.notCallableHook,
+	}, nil
+}
@@ -0,0 +1,129 @@
+package exec_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("environment builder", func() {
+	var (
+		base    = new(*exec.Environment)
+		builder = new(*exec.EnvironmentBuilder)
+
+		returnedEnvironment = new(*exec.Environment)
+		returnedErr         = new(error)
+	)
+	upper := func(_ *exec.Evaluator, in *exec.Value, _ *exec.VarArgs) *exec.Value {
+		return exec.AsValue(strings.ToUpper(in.String()))
+	}
+	nameOf := func(environment *exec.Environment) interface{} {
+		value, _ := environment.Context.Get("name")
+		return value
+	}
+	BeforeEach(func() {
+		*base = &exec.Environment{
+			Filters:           exec.NewFilterSet(map[string]exec.FilterFunction{"upper": upper}),
+			Tests:             exec.NewTestSet(map[string]exec.TestFunction{}),
+			ControlStructures: exec.NewControlStructureSet(nil),
+			Context:           exec.NewContext(map[string]interface{}{"name": "base"}),
+		}
+		*builder = exec.NewEnvironmentBuilder(*base)
+	})
+	JustBeforeEach(func() {
+		*returnedEnvironment, *returnedErr = (*builder).Build()
+	})
+	Context("default", func() {
+		It("should copy the base environment", func() {
+			By("not returning an error")
+			Expect(*returnedErr).To(BeNil())
+			By("keeping the base filters")
+			Expect((*returnedEnvironment).Filters.Exists("upper")).To(BeTrue())
+			By("keeping the base globals")
+			Expect(nameOf(*returnedEnvironment)).To(Equal("base"))
+		})
+		It("should be frozen", func() {
+			By("refusing to register filters")
+			Expect((*returnedEnvironment).Filters.Register("lower", upper)).To(MatchError("unable to register filter 'lower' on a frozen environment"))
+			By("refusing to replace filters")
+			Expect((*returnedEnvironment).Filters.Replace("upper", upper)).To(MatchError("unable to replace filter 'upper' on a frozen environment"))
+			By("refusing to update filters")
+			Expect((*returnedEnvironment).Filters.Update(exec.NewFilterSet(map[string]exec.FilterFunction{"lower": upper}))).To(MatchError("unable to update filters on a frozen environment"))
+			Expect((*returnedEnvironment).Filters.Exists("lower")).To(BeFalse())
+			By("refusing to update tests")
+			Expect((*returnedEnvironment).Tests.Update(exec.NewTestSet(map[string]exec.TestFunction{}))).To(MatchError("unable to update tests on a frozen environment"))
+			By("refusing to update control structures")
+			Expect((*returnedEnvironment).ControlStructures.Update(exec.NewControlStructureSet(nil))).To(MatchError("unable to update control structures on a frozen environment"))
+		})
+	})
+	Context("when registering new entries", func() {
+		BeforeEach(func() {
+			(*builder).
+				WithFilter("shout", upper).
+				WithoutFilter("upper").
+				WithTest("always", func(_ *exec.Context, _ *exec.Value, _ *exec.VarArgs) (bool, error) { return true, nil }).
+				WithGlobal("name", "built")
+		})
+		It("should only affect the built environment", func() {
+			By("not returning an error")
+			Expect(*returnedErr).To(BeNil())
+			By("exposing the new entries")
+			Expect((*returnedEnvironment).Filters.Exists("shout")).To(BeTrue())
+			Expect((*returnedEnvironment).Filters.Exists("upper")).To(BeFalse())
+			Expect((*returnedEnvironment).Tests.Exists("always")).To(BeTrue())
+			Expect(nameOf(*returnedEnvironment)).To(Equal("built"))
+			By("leaving the base environment untouched")
+			Expect((*base).Filters.Exists("shout")).To(BeFalse())
+			Expect((*base).Filters.Exists("upper")).To(BeTrue())
+			Expect((*base).Tests.Exists("always")).To(BeFalse())
+			Expect(nameOf(*base)).To(Equal("base"))
+		})
+		It("should not be affected by later changes of the builder", func() {
+			(*builder).WithFilter("later", upper)
+			Expect((*returnedEnvironment).Filters.Exists("later")).To(BeFalse())
+		})
+	})
+	Context("when registering an invalid test", func() {
+		BeforeEach(func() {
+			(*builder).WithTest("invalid", func() {})
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError("failed to build environment: test 'invalid' is not a function with 3 arguments and 2 returns"))
+		})
+	})
+	Context("when rendering concurrently", func() {
+		BeforeEach(func() {
+			(*builder).WithFilter("shout", upper)
+		})
+		It("should render every template independently", func() {
+			loader := loaders.MustNewMemoryLoader(map[string]string{"/test": "{{ name | shout }}"})
+			template, err := exec.NewTemplate("/test", config.New(), loader, *returnedEnvironment)
+			Expect(err).To(BeNil())
+
+			var wg sync.WaitGroup
+			results := make([]string, 50)
+			errs := make([]error, 50)
+			for index := range results {
+				wg.Add(1)
+				go func(index int) {
+					defer wg.Done()
+					results[index], errs[index] = template.ExecuteToString(exec.NewContext(map[string]interface{}{
+						"name": fmt.Sprintf("render-%d", index),
+					}))
+				}(index)
+			}
+			wg.Wait()
+			for index := range results {
+				Expect(errs[index]).To(BeNil())
+				Expect(results[index]).To(Equal(fmt.Sprintf("RENDER-%d", index)))
+			}
+		})
+	})
+})
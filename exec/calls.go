@@ -6,7 +6,6 @@ import (
 	"runtime"
 
 	"github.com/nikolalohinski/gonja/v2/nodes"
-	"github.com/pkg/errors"
 )
 
 func (e *Evaluator) evalCall(node *nodes.Call) *Value {
@@ -14,12 +13,20 @@ func (e *Evaluator) evalCall(node *nodes.Call) *Value {
 	if !fn.IsCallable() {
 		getAttributeNode, ok := node.Func.(*nodes.GetAttribute)
 		if node.Parent == nil || !ok {
-			return AsValue(errors.Errorf(`%s is not callable`, node.Func))
+			if fn.IsError() {
+				return AsValue(fmt.Errorf(`unable to evaluate function '%s': %w`, node.Func, fn))
+			}
+			params, err := e.evalVarArgs(node)
+			if err != nil {
+				return AsValue(fmt.Errorf(`unable to evaluate parameters: %w`, err))
+			}
+			name := fmt.Sprint(node.Func)
+			return e.notCallable(node.Func, name, params[0].Interface().(*VarArgs), fmt.Errorf(`%s is not callable`, name))
 		}
-		return e.evalMethod(node.Parent, getAttributeNode.Attribute, node.Args, node.Kwargs)
+		return e.evalMethod(getAttributeNode, node.Parent, getAttributeNode.Attribute, node.Args, node.Kwargs)
 	}
 	if fn.IsError() {
-		return AsValue(errors.Wrapf(fn, `unable to evaluate function '%s'`, node.Func))
+		return AsValue(fmt.Errorf(`unable to evaluate function '%s': %w`, node.Func, fn))
 	}
 
 	var current reflect.Value
@@ -38,7 +45,7 @@ func (e *Evaluator) evalCall(node *nodes.Call) *Value {
 		params, err = e.evalParams(node, fn)
 	}
 	if err != nil {
-		return AsValue(errors.Wrapf(err, `unable to evaluate parameters`))
+		return AsValue(fmt.Errorf(`unable to evaluate parameters: %w`, err))
 	}
 	functionName := runtime.FuncForPC(fn.Val.Pointer()).Name()
 	if nameNode, ok := node.Func.(*nodes.Name); ok {
@@ -55,8 +62,10 @@ func (e *Evaluator) evalCall(node *nodes.Call) *Value {
 			if !ok {
 				return AsValue(fmt.Errorf("second return value of function '%s' is not an error", functionName))
 			}
-			if err, ok := err.(ErrInvalidCall); ok && err != nil {
-				return AsValue(fmt.Errorf("invalid call to function '%s': %s", functionName, err.Error()))
+			if failure := locate(err, node.Func); failure != nil {
+				return AsValue(failure)
+			} else if err, ok := err.(ErrInvalidCall); ok && err != nil {
+				return AsValue(fmt.Errorf("invalid call to function '%s': %w", functionName, err))
 			} else if err != nil {
 				return AsValue(err)
 			}
@@ -78,22 +87,22 @@ func (e *Evaluator) evalCall(node *nodes.Call) *Value {
 	value := &Value{Val: current, Safe: isSafe}
 	if value.IsError() {
 		if err, ok := value.Interface().(ErrInvalidCall); ok {
-			return AsValue(fmt.Errorf("invalid call to function '%s': %s", functionName, err.Error()))
+			return AsValue(fmt.Errorf("invalid call to function '%s': %w", functionName, err))
 		}
 	}
 	return value
 }
 
-func (e *Evaluator) evalMethod(parentNode nodes.Node, method string, args []nodes.Expression, kwargs map[string]nodes.Expression) *Value {
+func (e *Evaluator) evalMethod(callee nodes.Node, parentNode nodes.Node, method string, args []nodes.Expression, kwargs map[string]nodes.Expression) *Value {
 	parent := e.Eval(parentNode)
 	if parent.IsError() {
-		return AsValue(errors.Wrapf(parent, "unable to evaluate '%s'", parentNode))
+		return AsValue(fmt.Errorf("unable to evaluate '%s': %w", parentNode, parent))
 	}
 	parameters := NewVarArgs()
 	for _, param := range args {
 		value := e.Eval(param)
 		if value.IsError() {
-			return AsValue(errors.Wrapf(value, "unable to evaluate parameter %s", param))
+			return AsValue(fmt.Errorf("unable to evaluate parameter %s: %w", param, value))
 		}
 		parameters.Args = append(parameters.Args, value)
 	}
@@ -101,12 +110,16 @@ func (e *Evaluator) evalMethod(parentNode nodes.Node, method string, args []node
 	for key, param := range kwargs {
 		value := e.Eval(param)
 		if value.IsError() {
-			return AsValue(errors.Wrapf(value, "unable to evaluate parameter %s=%s", key, param))
+			return AsValue(fmt.Errorf("unable to evaluate parameter %s=%s: %w", key, param, value))
+		}
+		if value.IsOmitted() {
+			continue
 		}
 		parameters.KwArgs[key] = value
 	}
 	var result interface{}
-	err := fmt.Errorf("unknown method '%s' for '%s'", method, parent.String())
+	unknown := fmt.Errorf("unknown method '%s' for '%s'", method, parent.String())
+	err := unknown
 	switch {
 	case parent.IsString():
 		if method, ok := e.Environment.Methods.Str.Get(method); ok {
@@ -128,11 +141,11 @@ func (e *Evaluator) evalMethod(parentNode nodes.Node, method string, args []node
 		if method, ok := e.Environment.Methods.Dict.Get(method); ok {
 			dict := parent.ToGoSimpleType(false)
 			if err, ok := dict.(error); err != nil && ok {
-				return AsValue(fmt.Errorf("failed to cast '%s' to a Go type: %s", parent.String(), err))
+				return AsValue(fmt.Errorf("failed to cast '%s' to a Go type: %w", parent.String(), err))
 			}
 			goMap, ok := dict.(map[string]interface{})
 			if !ok {
-				return AsValue(fmt.Errorf("failed to cast '%s' to map[string]interface{}: %s", parent.String(), err))
+				return AsValue(fmt.Errorf("failed to cast '%s' to map[string]interface{}: %w", parent.String(), err))
 			}
 			result, err = method(goMap, parent, parameters)
 		}
@@ -140,20 +153,24 @@ func (e *Evaluator) evalMethod(parentNode nodes.Node, method string, args []node
 		if method, ok := e.Environment.Methods.List.Get(method); ok {
 			list := parent.ToGoSimpleType(false)
 			if err, ok := list.(error); err != nil && ok {
-				return AsValue(fmt.Errorf("failed to cast '%s' to a Go type: %s", parent.String(), err))
+				return AsValue(fmt.Errorf("failed to cast '%s' to a Go type: %w", parent.String(), err))
 			}
 			goList, ok := list.([]interface{})
 			if !ok {
-				return AsValue(fmt.Errorf("failed to cast '%s' to []interface{}: %s", parent.String(), err))
+				return AsValue(fmt.Errorf("failed to cast '%s' to []interface{}: %w", parent.String(), err))
 			}
 			result, err = method(goList, parent, parameters)
 		}
 	default:
-		err = AsValue(errors.Errorf(`'%s' is not callable on %s`, method, parent))
+		unknown = fmt.Errorf(`'%s' is not callable on %s`, method, parent)
+		err = unknown
+	}
+	if err == unknown {
+		return e.notCallable(callee, fmt.Sprintf("%s.%s", parentNode, method), parameters, err)
 	}
 	if err != nil {
 		if callErr, ok := err.(ErrInvalidCall); ok {
-			return AsValue(fmt.Errorf("invalid call to method '%s' of %s: %s", method, parent.String(), callErr.Error()))
+			return AsValue(fmt.Errorf("invalid call to method '%s' of %s: %w", method, parent.String(), callErr))
 		}
 		return AsValue(err)
 	}
@@ -0,0 +1,76 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// setCancellation sets the Go context cancelling the renders executed with this context, or with contexts
+// inheriting from it
+func (ctx *Context) setCancellation(cancellation context.Context) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	ctx.cancellation = cancellation
+}
+
+// renderCancellation returns the Go context set on this context or the closest of its parents, if any
+func (ctx *Context) renderCancellation() context.Context {
+	for ; ctx != nil; ctx = ctx.parent {
+		ctx.lock.Lock()
+		cancellation := ctx.cancellation
+		ctx.lock.Unlock()
+		if cancellation != nil {
+			return cancellation
+		}
+	}
+	return nil
+}
+
+// Canceled returns an error wrapping the error of the Go context of the render once it is done, and nil
+// otherwise or when the render was not given any, see ExecuteWithContext. It is called between the nodes
+// of templates and meant to be called by the control structures iterating over values as well.
+func (r *Renderer) Canceled() error {
+	cancellation := r.cancellation
+	if cancellation == nil {
+		return nil
+	}
+	select {
+	case <-cancellation.Done():
+		return fmt.Errorf("render canceled: %w", context.Cause(cancellation))
+	default:
+		return nil
+	}
+}
+
+// Done returns a channel closed once the Go context of the render is done, or nil when the render was not
+// given any, see ExecuteWithContext. It is meant to be called by the control structures waiting for values,
+// such as the items of channels.
+func (r *Renderer) Done() <-chan struct{} {
+	if r.cancellation == nil {
+		return nil
+	}
+	return r.cancellation.Done()
+}
+
+// ExecuteWithContext renders the template like Execute, stopping as soon as the Go context is done, see
+// Canceled. The Go context only applies to this call, and not to later executions of the renderer.
+func (r *Renderer) ExecuteWithContext(ctx context.Context) error {
+	environment := *r.Environment
+	environment.Context = r.Environment.Context.Inherit()
+	environment.Context.setCancellation(ctx)
+	sub := *r
+	sub.Environment = &environment
+	sub.evaluator = nil
+	sub.cancellation = ctx
+	return sub.Execute()
+}
+
+// ExecuteWithContext executes the template like Execute, stopping with an error wrapping the error of the Go
+// context as soon as it is done, for example when the client of an HTTP request goes away. The content
+// rendered until then has already been written.
+func (t *Template) ExecuteWithContext(ctx context.Context, wr io.Writer, data *Context) error {
+	scope := t.scopeOf(data)
+	scope.setCancellation(ctx)
+	return t.execute(wr, scope)
+}
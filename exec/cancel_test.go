@@ -0,0 +1,113 @@
+package exec_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("render cancellation", func() {
+	var (
+		templates = new(map[string]string)
+		ctx       = new(context.Context)
+		cancel    = new(context.CancelCauseFunc)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*templates = map[string]string{}
+		*ctx, *cancel = context.WithCancelCause(context.Background())
+	})
+	AfterEach(func() {
+		(*cancel)(nil)
+	})
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(*templates)
+		t, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+		Expect(err).To(BeNil())
+		data := exec.NewContext(map[string]interface{}{
+			"name": "ada",
+			"stop": func() string {
+				(*cancel)(errors.New("client went away"))
+				return "!"
+			},
+		})
+		out := new(bytes.Buffer)
+		*returnedErr = t.ExecuteWithContext(*ctx, out, data)
+		*returnedResult = out.String()
+	})
+	Context("when the context is not done", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% for i in range(3) %}{{ i }}{% endfor %} {% include "/partial" %}`
+			(*templates)["/partial"] = "{{ name }}"
+		})
+		It("should render the template", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("012 ada"))
+		})
+	})
+	Context("when the context is done before the render", func() {
+		BeforeEach(func() {
+			(*cancel)(nil)
+			(*templates)["/test"] = `Hello {{ name }}`
+		})
+		It("should not render anything", func() {
+			Expect(errors.Is(*returnedErr, context.Canceled)).To(BeTrue())
+			Expect(*returnedErr).To(MatchError(ContainSubstring("render canceled: context canceled")))
+			Expect(*returnedResult).To(BeEmpty())
+		})
+	})
+	Context("when the context is done during a loop", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% for i in range(1000) %}{{ i }}{% if i == 3 %}{{ stop() }}{% endif %}{% endfor %}`
+		})
+		It("should stop the render with the cause of the cancellation", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("render canceled: client went away")))
+			Expect(*returnedResult).To(Equal("0123!"))
+		})
+	})
+	Context("when the context is done during an included template", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% include "/partial" %} after`
+			(*templates)["/partial"] = `{{ name }}{{ stop() }} {{ name }}`
+		})
+		It("should stop the whole render", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("render canceled: client went away")))
+			Expect(*returnedResult).To(Equal("ada!"))
+		})
+	})
+	Context("when the context is done while a loop filters its items", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% for i in range(100000) if i == 3 and stop() %}{% endfor %}`
+		})
+		It("should stop the loop", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("render canceled: client went away")))
+		})
+	})
+	Context("when executing a renderer with a context", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `Hello {{ name }}`
+		})
+		It("should only apply the context to that execution", func() {
+			loader := loaders.MustNewMemoryLoader(*templates)
+			t, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+			Expect(err).To(BeNil())
+			environment := gonja.DefaultEnvironment.Overlay()
+			environment.Context.Set("name", "bob")
+			out := new(bytes.Buffer)
+			r := exec.NewRenderer(environment, out, gonja.DefaultConfig, loader, t)
+			(*cancel)(nil)
+			Expect(errors.Is(r.ExecuteWithContext(*ctx), context.Canceled)).To(BeTrue())
+			Expect(r.Execute()).To(Succeed())
+			Expect(out.String()).To(Equal("Hello bob"))
+		})
+	})
+})
@@ -0,0 +1,62 @@
+package exec_test
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/nikolalohinski/gonja/v2/builtins"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("concurrent executions", func() {
+	var (
+		template = new(*exec.Template)
+	)
+	BeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(map[string]string{
+			"/root": `{% extends "/base" %}{% block content %}{% import "/macros" as m %}` +
+				`{% set ns = namespace(total=0) %}` +
+				`{% for item in items %}{% set ns.total = ns.total + item %}{{ m.tag(item) }}{% if not loop.last %},{% endif %}{% endfor %}` +
+				`{% filter upper %}{{ name }}{% endfilter %}{% autoescape true %}{{ "<" ~ name ~ ">" }}{% endautoescape %}` +
+				`{% with doubled = ns.total * 2 %} {{ doubled }}{% endwith %}{% include "/footer" %}{% endblock %}`,
+			"/base":   `[{% block content %}{% endblock %}]`,
+			"/macros": `{% macro tag(value) %}<{{ value }}>{% endmacro %}`,
+			"/footer": ` by {{ name | default("nobody") }}`,
+		})
+		environment := &exec.Environment{
+			Filters:           builtins.Filters,
+			Tests:             builtins.Tests,
+			ControlStructures: builtins.ControlStructures,
+			Methods:           builtins.Methods,
+			Context:           exec.NewContext(map[string]interface{}{"namespace": builtins.GlobalFunctions.Export()["namespace"]}),
+		}
+		var err error
+		*template, err = exec.NewTemplate("/root", config.New(), loader, environment)
+		Expect(err).To(BeNil())
+	})
+	It("should render every execution independently", func() {
+		var wg sync.WaitGroup
+		results := make([]string, 64)
+		errs := make([]error, 64)
+		for index := range results {
+			wg.Add(1)
+			go func(index int) {
+				defer wg.Done()
+				results[index], errs[index] = (*template).ExecuteToString(exec.NewContext(map[string]interface{}{
+					"name":  fmt.Sprintf("r%d", index),
+					"items": []int{index, 1},
+				}))
+			}(index)
+		}
+		wg.Wait()
+		for index := range results {
+			Expect(errs[index]).To(BeNil())
+			Expect(results[index]).To(Equal(fmt.Sprintf("[<%d>,<1>R%d&lt;r%d&gt; %d by r%d]", index, index, index, (index+1)*2, index)))
+		}
+	})
+})
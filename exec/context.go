@@ -1,11 +1,24 @@
 package exec
 
-import "sync"
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
 
 type Context struct {
-	data   map[string]interface{}
-	parent *Context
-	lock   sync.Mutex
+	data         map[string]interface{}
+	readOnly     map[string]bool
+	sensitive    map[string]bool
+	budget       *renderBudget
+	trace        *Trace
+	cancellation context.Context
+	overrides    map[string]BlockOverride
+	parent       *Context
+	lock         sync.Mutex
 }
 
 func NewContext(data map[string]interface{}) *Context {
@@ -41,29 +54,284 @@ func (ctx *Context) Get(name string) (interface{}, bool) {
 
 func (ctx *Context) Set(name string, value interface{}) {
 	ctx.lock.Lock()
+	if ctx.data == nil {
+		ctx.data = map[string]interface{}{}
+	}
 	ctx.data[name] = value
 	ctx.lock.Unlock()
 }
 
 func (ctx *Context) Inherit() *Context {
 	ctx.lock.Lock()
+	// the data map is only allocated once a value is set
 	inherited := &Context{
-		data:   map[string]interface{}{},
 		parent: ctx,
 	}
 	ctx.lock.Unlock()
 	return inherited
 }
 
-// Update updates this context with the key/value pairs from a map.
+// SetReadOnly marks names as read-only for templates, in this context and all the contexts inheriting
+// from it, so that the `set` control structure fails instead of overwriting or shadowing them.
+// Values can still be changed from go code.
+func (ctx *Context) SetReadOnly(names ...string) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	if ctx.readOnly == nil {
+		ctx.readOnly = map[string]bool{}
+	}
+	for _, name := range names {
+		ctx.readOnly[name] = true
+	}
+}
+
+// IsReadOnly tells whether the name was marked as read-only in this context or one of its parents
+func (ctx *Context) IsReadOnly(name string) bool {
+	ctx.lock.Lock()
+	readOnly := ctx.readOnly[name]
+	ctx.lock.Unlock()
+	if !readOnly && ctx.parent != nil {
+		return ctx.parent.IsReadOnly(name)
+	}
+	return readOnly
+}
+
+// readOnlyNames returns all the names marked as read-only in this context and its parents
+func (ctx *Context) readOnlyNames() []string {
+	names := []string{}
+	if ctx.parent != nil {
+		names = ctx.parent.readOnlyNames()
+	}
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	for name := range ctx.readOnly {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Keys returns the sorted names of all the values visible from this context, including the ones of its parents
+func (ctx *Context) Keys() []string {
+	data := ctx.Export()
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Delete removes a value from this context. Parent contexts are left untouched, so a value
+// defined by a parent remains visible after being deleted from the child context.
+func (ctx *Context) Delete(name string) {
+	ctx.lock.Lock()
+	delete(ctx.data, name)
+	ctx.lock.Unlock()
+}
+
+// Export returns a copy of all the key/value pairs visible from this context,
+// values of the context taking precedence over the ones of its parents
+func (ctx *Context) Export() map[string]interface{} {
+	data := map[string]interface{}{}
+	if ctx.parent != nil {
+		data = ctx.parent.Export()
+	}
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	for key, value := range ctx.data {
+		data[key] = value
+	}
+	return data
+}
+
+// MergeMode selects how conflicting keys are handled when merging contexts
+type MergeMode int
+
+const (
+	// MergeOverwrite replaces existing values with the merged ones, which is how Update behaves
+	MergeOverwrite MergeMode = iota
+	// MergeKeepExisting only adds the keys that are not defined yet
+	MergeKeepExisting
+	// MergeErrorOnConflict fails without modifying the context if any merged key is already defined
+	MergeErrorOnConflict
+	// MergeDeep recursively merges dictionaries defined on both sides, other values being overwritten
+	MergeDeep
+)
+
+// Merge sets the key/value pairs visible from the other context, including the ones of its parents, into this
+// context according to the given mode. Values visible from this context through its parents are considered
+// as already defined, but parents are never modified.
+func (ctx *Context) Merge(other *Context, mode MergeMode) error {
+	if other == nil {
+		return nil
+	}
+	data := other.Export()
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if mode == MergeErrorOnConflict {
+		conflicts := []string{}
+		for _, key := range keys {
+			if ctx.Has(key) {
+				conflicts = append(conflicts, key)
+			}
+		}
+		if len(conflicts) > 0 {
+			return fmt.Errorf("unable to merge contexts: conflicting keys '%s'", strings.Join(conflicts, "', '"))
+		}
+	}
+
+	for _, key := range keys {
+		value := data[key]
+		existing, exists := ctx.Get(key)
+		switch mode {
+		case MergeKeepExisting:
+			if exists {
+				continue
+			}
+		case MergeDeep:
+			if exists {
+				value = deepMerge(existing, value)
+			}
+		case MergeOverwrite, MergeErrorOnConflict:
+		default:
+			return fmt.Errorf("unknown merge mode %d", mode)
+		}
+		ctx.Set(key, value)
+	}
+	return nil
+}
+
+// deepMerge merges two dictionaries recursively without modifying them. If either value
+// is not a dictionary, the new value is returned.
+func deepMerge(existing, value interface{}) interface{} {
+	existingMap, ok := existing.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	valueMap, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	merged := make(map[string]interface{}, len(existingMap)+len(valueMap))
+	for key, item := range existingMap {
+		merged[key] = item
+	}
+	for key, item := range valueMap {
+		if previous, ok := merged[key]; ok {
+			item = deepMerge(previous, item)
+		}
+		merged[key] = item
+	}
+	return merged
+}
+
+// Update updates this context with the key/value pairs from a map, overwriting existing values.
+// See Merge for other ways of handling conflicts.
 func (ctx *Context) Update(other *Context) *Context {
 	if other == nil {
 		return ctx
 	}
 	ctx.lock.Lock()
+	if ctx.data == nil && len(other.data) > 0 {
+		ctx.data = make(map[string]interface{}, len(other.data))
+	}
 	for k, v := range other.data {
 		ctx.data[k] = v
 	}
 	ctx.lock.Unlock()
 	return ctx
 }
+
+// Clone returns a deep copy of the context and of its parents. Maps, slices and arrays are
+// copied recursively so the clone can be modified without affecting this context, while
+// other values such as structs, pointers and functions are shared.
+func (ctx *Context) Clone() *Context {
+	ctx.lock.Lock()
+	clone := &Context{data: make(map[string]interface{}, len(ctx.data))}
+	for key, value := range ctx.data {
+		clone.data[key] = deepCopy(value)
+	}
+	if ctx.readOnly != nil {
+		clone.readOnly = make(map[string]bool, len(ctx.readOnly))
+		for name := range ctx.readOnly {
+			clone.readOnly[name] = true
+		}
+	}
+	if ctx.sensitive != nil {
+		clone.sensitive = make(map[string]bool, len(ctx.sensitive))
+		for name := range ctx.sensitive {
+			clone.sensitive[name] = true
+		}
+	}
+	if ctx.budget != nil {
+		clone.budget = &renderBudget{Budget: ctx.budget.Budget}
+	}
+	clone.trace = ctx.trace
+	clone.cancellation = ctx.cancellation
+	if ctx.overrides != nil {
+		clone.overrides = make(map[string]BlockOverride, len(ctx.overrides))
+		for name, override := range ctx.overrides {
+			clone.overrides[name] = override
+		}
+	}
+	ctx.lock.Unlock()
+	if ctx.parent != nil {
+		clone.parent = ctx.parent.Clone()
+	}
+	return clone
+}
+
+func deepCopy(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return deepCopyValue(reflect.ValueOf(value)).Interface()
+}
+
+func deepCopyValue(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+		clone := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iterator := value.MapRange()
+		for iterator.Next() {
+			clone.SetMapIndex(iterator.Key(), deepCopyElement(iterator.Value(), value.Type().Elem()))
+		}
+		return clone
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+		clone := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for index := 0; index < value.Len(); index++ {
+			clone.Index(index).Set(deepCopyElement(value.Index(index), value.Type().Elem()))
+		}
+		return clone
+	case reflect.Array:
+		clone := reflect.New(value.Type()).Elem()
+		for index := 0; index < value.Len(); index++ {
+			clone.Index(index).Set(deepCopyElement(value.Index(index), value.Type().Elem()))
+		}
+		return clone
+	default:
+		return value
+	}
+}
+
+// deepCopyElement copies an element of a container, unwrapping interfaces so that
+// containers stored as interface{} values are copied as well
+func deepCopyElement(element reflect.Value, elementType reflect.Type) reflect.Value {
+	if element.Kind() == reflect.Interface {
+		if element.IsNil() {
+			return reflect.Zero(elementType)
+		}
+		return deepCopyValue(element.Elem())
+	}
+	return deepCopyValue(element)
+}
@@ -0,0 +1,214 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ContextFromStruct builds a context out of the exported fields of a struct or of a pointer to a struct.
+//
+// Field names can be changed with a `gonja:"name"` tag, falling back to the name of a `json` tag, and
+// fields tagged with `gonja:"-"` are skipped. Fields of embedded structs are promoted to the top level
+// unless the embedded struct is given a name through a tag. Nested structs, including the ones held by
+// pointers, slices, arrays and maps, are converted to map[string]interface{} following the same rules,
+// while times and other values are kept as is. Values referencing themselves, such as a struct pointing to
+// its own parent, return an error rather than being converted endlessly.
+func ContextFromStruct(v interface{}) (*Context, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, errors.New("unable to build a context from a nil pointer")
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unable to build a context from %s: not a struct", value.Kind())
+	}
+	data, err := (&structConverter{visiting: map[structVisit]bool{}}).structToMap(value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build a context from %s: %w", value.Type(), err)
+	}
+	return NewContext(data), nil
+}
+
+// structConverter converts structs to maps, keeping track of the pointers, maps and slices being converted
+// to detect the cycles
+type structConverter struct {
+	visiting map[structVisit]bool
+}
+
+type structVisit struct {
+	pointer uintptr
+	length  int
+	kind    reflect.Type
+}
+
+// enter marks the value as being converted, and fails if it already is, which means it references itself
+func (c *structConverter) enter(value reflect.Value) (func(), error) {
+	visit := structVisit{pointer: value.Pointer(), kind: value.Type()}
+	if value.Kind() == reflect.Slice {
+		visit.length = value.Len()
+	}
+	if c.visiting[visit] {
+		return nil, fmt.Errorf("cycle detected through %s", value.Type())
+	}
+	c.visiting[visit] = true
+	return func() { delete(c.visiting, visit) }, nil
+}
+
+func (c *structConverter) structToMap(value reflect.Value) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+	structType := value.Type()
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		name, skip := structFieldName(field)
+		if skip {
+			continue
+		}
+		fieldValue := value.Field(index)
+		if field.Anonymous && name == "" {
+			embedded := fieldValue
+			for embedded.Kind() == reflect.Ptr && !embedded.IsNil() {
+				leave, err := c.enter(embedded)
+				if err != nil {
+					return nil, err
+				}
+				defer leave()
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				promoted, err := c.structToMap(embedded)
+				if err != nil {
+					return nil, err
+				}
+				for key, value := range promoted {
+					if _, shadowed := data[key]; !shadowed {
+						data[key] = value
+					}
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		// fields declared by the struct itself take precedence over promoted ones
+		converted, err := c.fieldValue(fieldValue)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s': %w", field.Name, err)
+		}
+		data[name] = converted
+	}
+	return data, nil
+}
+
+// structFieldName returns the name given to a field through its tags, and whether it should be skipped
+func structFieldName(field reflect.StructField) (string, bool) {
+	for _, tag := range []string{"gonja", "json"} {
+		value, ok := field.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(value, ",")
+		if name == "-" {
+			return "", true
+		}
+		if name != "" {
+			return name, false
+		}
+	}
+	return "", false
+}
+
+func (c *structConverter) fieldValue(value reflect.Value) (interface{}, error) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil, nil
+		}
+		if value.Elem().Kind() == reflect.Struct && value.Elem().Type() != typeOfTime {
+			if value.Kind() == reflect.Ptr {
+				leave, err := c.enter(value)
+				if err != nil {
+					return nil, err
+				}
+				defer leave()
+			}
+			return c.structToMap(value.Elem())
+		}
+		if value.Kind() == reflect.Interface {
+			return c.fieldValue(value.Elem())
+		}
+	case reflect.Struct:
+		if value.Type() != typeOfTime {
+			return c.structToMap(value)
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil, nil
+		}
+		if !containsStructs(value.Type().Elem()) {
+			break
+		}
+		if value.Kind() == reflect.Slice {
+			leave, err := c.enter(value)
+			if err != nil {
+				return nil, err
+			}
+			defer leave()
+		}
+		items := make([]interface{}, value.Len())
+		for index := range items {
+			item, err := c.fieldValue(value.Index(index))
+			if err != nil {
+				return nil, err
+			}
+			items[index] = item
+		}
+		return items, nil
+	case reflect.Map:
+		if value.IsNil() || value.Type().Key().Kind() != reflect.String || !containsStructs(value.Type().Elem()) {
+			break
+		}
+		leave, err := c.enter(value)
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+		items := make(map[string]interface{}, value.Len())
+		iterator := value.MapRange()
+		for iterator.Next() {
+			item, err := c.fieldValue(iterator.Value())
+			if err != nil {
+				return nil, err
+			}
+			items[iterator.Key().String()] = item
+		}
+		return items, nil
+	}
+	if !value.CanInterface() {
+		return nil, nil
+	}
+	return value.Interface(), nil
+}
+
+// containsStructs tells whether values of the type may hold structs that need to be converted
+func containsStructs(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return t != typeOfTime
+	case reflect.Interface:
+		return true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return containsStructs(t.Elem())
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package exec_test
+
+import (
+	"time"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type Audit struct {
+	CreatedBy string
+	UpdatedBy string `json:"updated_by"`
+}
+
+type Address struct {
+	City    string `gonja:"city"`
+	Country string `json:"country,omitempty"`
+}
+
+type User struct {
+	Audit
+	Name      string `gonja:"name" json:"full_name"`
+	Password  string `gonja:"-"`
+	Address   Address
+	Previous  *Address           `gonja:"previous"`
+	Missing   *Address           `gonja:"missing"`
+	Others    []Address          `gonja:"others"`
+	ByLabel   map[string]Address `gonja:"by_label"`
+	Tags      []string           `gonja:"tags"`
+	CreatedAt time.Time          `gonja:"created_at"`
+	CreatedBy string             `gonja:"created_by"`
+	secret    string
+}
+
+type Node struct {
+	Name     string                 `gonja:"name"`
+	Parent   *Node                  `gonja:"parent"`
+	Children []*Node                `gonja:"children"`
+	Extra    map[string]interface{} `gonja:"extra"`
+}
+
+var _ = Context("context from struct", func() {
+	var (
+		input = new(interface{})
+
+		returnedContext = new(*exec.Context)
+		returnedErr     = new(error)
+	)
+	JustBeforeEach(func() {
+		*returnedContext, *returnedErr = exec.ContextFromStruct(*input)
+	})
+	Context("when given a struct", func() {
+		createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		BeforeEach(func() {
+			*input = &User{
+				Audit:     Audit{CreatedBy: "admin", UpdatedBy: "robot"},
+				Name:      "Bob",
+				Password:  "hunter2",
+				Address:   Address{City: "Paris", Country: "France"},
+				Previous:  &Address{City: "Lyon"},
+				Others:    []Address{{City: "Nice"}},
+				ByLabel:   map[string]Address{"work": {City: "Lille"}},
+				Tags:      []string{"a", "b"},
+				CreatedAt: createdAt,
+				CreatedBy: "owner",
+				secret:    "hidden",
+			}
+		})
+		It("should flatten it into the context", func() {
+			By("not returning an error")
+			Expect(*returnedErr).To(BeNil())
+			By("returning the expected data")
+			Expect((*returnedContext).Export()).To(Equal(map[string]interface{}{
+				"updated_by": "robot",
+				"name":       "Bob",
+				"Address":    map[string]interface{}{"city": "Paris", "country": "France"},
+				"previous":   map[string]interface{}{"city": "Lyon", "country": ""},
+				"missing":    nil,
+				"others":     []interface{}{map[string]interface{}{"city": "Nice", "country": ""}},
+				"by_label":   map[string]interface{}{"work": map[string]interface{}{"city": "Lille", "country": ""}},
+				"tags":       []string{"a", "b"},
+				"created_at": createdAt,
+				"created_by": "owner",
+				"CreatedBy":  "admin",
+			}))
+		})
+	})
+	Context("when given a struct referencing itself", func() {
+		BeforeEach(func() {
+			root := &Node{Name: "root"}
+			root.Children = []*Node{{Name: "child", Parent: root}}
+			*input = root
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError("unable to build a context from exec_test.Node: field 'Children': field 'Parent': field 'Children': cycle detected through []*exec_test.Node"))
+		})
+	})
+	Context("when given a struct holding a map referencing itself", func() {
+		BeforeEach(func() {
+			extra := map[string]interface{}{}
+			extra["self"] = extra
+			*input = Node{Name: "root", Extra: extra}
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("field 'Extra': cycle detected through map[string]interface {}")))
+		})
+	})
+	Context("when given a struct referencing the same value several times", func() {
+		BeforeEach(func() {
+			shared := &Node{Name: "shared"}
+			*input = Node{Name: "root", Children: []*Node{shared, shared}}
+		})
+		It("should convert each of them", func() {
+			Expect(*returnedErr).To(BeNil())
+			shared := map[string]interface{}{"name": "shared", "parent": nil, "children": nil, "extra": map[string]interface{}(nil)}
+			Expect((*returnedContext).Export()).To(Equal(map[string]interface{}{
+				"name":     "root",
+				"parent":   nil,
+				"children": []interface{}{shared, shared},
+				"extra":    map[string]interface{}(nil),
+			}))
+		})
+	})
+	Context("when given something else than a struct", func() {
+		BeforeEach(func() {
+			*input = map[string]interface{}{}
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError("unable to build a context from map: not a struct"))
+		})
+	})
+	Context("when given a nil pointer", func() {
+		BeforeEach(func() {
+			*input = (*User)(nil)
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError("unable to build a context from a nil pointer"))
+		})
+	})
+})
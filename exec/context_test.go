@@ -149,3 +149,202 @@ var _ = Context("context", func() {
 		})
 	})
 })
+
+var _ = Context("context clone", func() {
+	var (
+		ctx   = new(*exec.Context)
+		clone = new(*exec.Context)
+	)
+	valueOf := func(ctx *exec.Context, name string) interface{} {
+		value, _ := ctx.Get(name)
+		return value
+	}
+	BeforeEach(func() {
+		parent := exec.NewContext(map[string]interface{}{
+			"inherited": []string{"a", "b"},
+		})
+		*ctx = parent.Inherit()
+		(*ctx).Set("nested", map[string]interface{}{
+			"list": []interface{}{1, map[string]interface{}{"key": "value"}},
+		})
+		(*ctx).Set("array", [2]int{1, 2})
+	})
+	JustBeforeEach(func() {
+		*clone = (*ctx).Clone()
+	})
+	It("should copy all the values", func() {
+		Expect(valueOf((*clone), "nested")).To(Equal(map[string]interface{}{
+			"list": []interface{}{1, map[string]interface{}{"key": "value"}},
+		}))
+		Expect(valueOf((*clone), "inherited")).To(Equal([]string{"a", "b"}))
+		Expect(valueOf((*clone), "array")).To(Equal([2]int{1, 2}))
+	})
+	It("should not share mutable data with the original context", func() {
+		By("setting new keys on the clone")
+		(*clone).Set("added", true)
+		Expect((*ctx).Has("added")).To(BeFalse())
+
+		By("modifying nested values of the clone")
+		nested, _ := (*clone).Get("nested")
+		list := nested.(map[string]interface{})["list"].([]interface{})
+		list[0] = 2
+		list[1].(map[string]interface{})["key"] = "changed"
+		original, _ := (*ctx).Get("nested")
+		Expect(original).To(Equal(map[string]interface{}{
+			"list": []interface{}{1, map[string]interface{}{"key": "value"}},
+		}))
+
+		By("modifying inherited values of the clone")
+		inherited, _ := (*clone).Get("inherited")
+		inherited.([]string)[0] = "changed"
+		Expect(valueOf((*ctx), "inherited")).To(Equal([]string{"a", "b"}))
+	})
+})
+
+var _ = Context("context inspection", func() {
+	var (
+		parent = new(*exec.Context)
+		ctx    = new(*exec.Context)
+	)
+	BeforeEach(func() {
+		*parent = exec.NewContext(map[string]interface{}{"shared": "parent", "inherited": 1})
+		*ctx = (*parent).Inherit()
+		(*ctx).Set("shared", "child")
+		(*ctx).Set("own", true)
+	})
+	It("should list all the visible keys", func() {
+		Expect((*ctx).Keys()).To(Equal([]string{"inherited", "own", "shared"}))
+	})
+	It("should export all the visible values", func() {
+		By("flattening the parent chain")
+		exported := (*ctx).Export()
+		Expect(exported).To(Equal(map[string]interface{}{"shared": "child", "inherited": 1, "own": true}))
+		By("returning a copy")
+		exported["added"] = true
+		Expect((*ctx).Has("added")).To(BeFalse())
+	})
+	It("should delete values from the context only", func() {
+		(*ctx).Delete("own")
+		(*ctx).Delete("shared")
+		(*ctx).Delete("inherited")
+		Expect((*ctx).Has("own")).To(BeFalse())
+		Expect((*ctx).Export()).To(Equal(map[string]interface{}{"shared": "parent", "inherited": 1}))
+		Expect((*parent).Keys()).To(Equal([]string{"inherited", "shared"}))
+	})
+})
+
+var _ = Context("context merge", func() {
+	var (
+		ctx   = new(*exec.Context)
+		other = new(*exec.Context)
+		mode  = new(exec.MergeMode)
+
+		returnedErr = new(error)
+	)
+	BeforeEach(func() {
+		defaults := exec.NewContext(map[string]interface{}{"inherited": "default"})
+		*ctx = defaults.Inherit()
+		(*ctx).Set("name", "default")
+		(*ctx).Set("settings", map[string]interface{}{
+			"color":  "blue",
+			"nested": map[string]interface{}{"a": 1, "b": 2},
+		})
+		*other = exec.NewContext(map[string]interface{}{
+			"name":      "user",
+			"inherited": "user",
+			"added":     true,
+			"settings": map[string]interface{}{
+				"size":   "large",
+				"nested": map[string]interface{}{"b": 3},
+			},
+		})
+	})
+	JustBeforeEach(func() {
+		*returnedErr = (*ctx).Merge(*other, *mode)
+	})
+	Context("when overwriting", func() {
+		BeforeEach(func() {
+			*mode = exec.MergeOverwrite
+		})
+		It("should replace existing values", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect((*ctx).Export()).To(Equal(map[string]interface{}{
+				"name":      "user",
+				"inherited": "user",
+				"added":     true,
+				"settings": map[string]interface{}{
+					"size":   "large",
+					"nested": map[string]interface{}{"b": 3},
+				},
+			}))
+		})
+	})
+	Context("when keeping existing values", func() {
+		BeforeEach(func() {
+			*mode = exec.MergeKeepExisting
+		})
+		It("should only add new keys", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect((*ctx).Export()).To(Equal(map[string]interface{}{
+				"name":      "default",
+				"inherited": "default",
+				"added":     true,
+				"settings": map[string]interface{}{
+					"color":  "blue",
+					"nested": map[string]interface{}{"a": 1, "b": 2},
+				},
+			}))
+		})
+	})
+	Context("when failing on conflicts", func() {
+		BeforeEach(func() {
+			*mode = exec.MergeErrorOnConflict
+		})
+		It("should return an error without modifying the context", func() {
+			Expect(*returnedErr).To(MatchError("unable to merge contexts: conflicting keys 'inherited', 'name', 'settings'"))
+			Expect((*ctx).Has("added")).To(BeFalse())
+		})
+		Context("without conflicts", func() {
+			BeforeEach(func() {
+				*other = exec.NewContext(map[string]interface{}{"added": true})
+			})
+			It("should merge the values", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect((*ctx).Keys()).To(Equal([]string{"added", "inherited", "name", "settings"}))
+			})
+		})
+	})
+	Context("when merging deeply", func() {
+		BeforeEach(func() {
+			*mode = exec.MergeDeep
+		})
+		It("should merge nested dictionaries", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect((*ctx).Export()).To(Equal(map[string]interface{}{
+				"name":      "user",
+				"inherited": "user",
+				"added":     true,
+				"settings": map[string]interface{}{
+					"color":  "blue",
+					"size":   "large",
+					"nested": map[string]interface{}{"a": 1, "b": 3},
+				},
+			}))
+		})
+		It("should not modify the merged dictionaries", func() {
+			settings, _ := (*other).Get("settings")
+			Expect(settings).To(Equal(map[string]interface{}{
+				"size":   "large",
+				"nested": map[string]interface{}{"b": 3},
+			}))
+		})
+	})
+	Context("when using an unknown mode", func() {
+		BeforeEach(func() {
+			*mode = exec.MergeMode(42)
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError("unknown merge mode 42"))
+		})
+	})
+})
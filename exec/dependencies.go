@@ -0,0 +1,156 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+)
+
+// Dependency is a template another template depends on
+type Dependency struct {
+	Kind       string // name of the statement referencing the template, such as "extends" or "include"
+	Identifier string // resolved identifier of the template
+	Optional   bool   // whether the statement ignores the template when it is missing, see include ... ignore missing
+}
+
+// DependencyGraph holds the templates a template depends on, directly or not
+type DependencyGraph struct {
+	Root string
+	// Dependencies holds the direct dependencies of every template of the graph, keyed by identifier
+	Dependencies map[string][]Dependency
+
+	templates map[string]*nodes.Template
+}
+
+// Dependencies returns the graph of the templates the template depends on through extends statements,
+// as well as include, import and from statements using string literals. Referenced templates are loaded
+// to build the graph and cached as done by Precompile, while the missing templates of include statements
+// ignoring them are left out. The graph may contain cycles, see Validate.
+func (t *Template) Dependencies() (*DependencyGraph, error) {
+	t = t.current()
+	root := t.root.Identifier
+	if identifier, err := t.loader.Resolve(root); err == nil {
+		root = identifier
+	}
+	graph := &DependencyGraph{
+		Root:         root,
+		Dependencies: map[string][]Dependency{},
+		templates:    map[string]*nodes.Template{},
+	}
+	if err := graph.add(root, t.root, t); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+func (g *DependencyGraph) add(identifier string, root *nodes.Template, template *Template) error {
+	if _, visited := g.Dependencies[identifier]; visited {
+		return nil
+	}
+	dependencies := []Dependency{}
+	g.Dependencies[identifier] = dependencies
+	g.templates[identifier] = root
+
+	if root.Parent != nil {
+		dependencies = append(dependencies, Dependency{Kind: "extends", Identifier: root.Parent.Identifier})
+	}
+	referenced := []*Template{}
+	for _, reference := range root.References {
+		dependency, _, err := template.precompileReference(root, reference.Name)
+		if reference.Optional && errors.Is(err, loaders.ErrTemplateNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("unable to load the template referenced by the %s statement at line %d of '%s': %w", reference.Kind, reference.Location.Line, identifier, err)
+		}
+		edge := Dependency{Kind: reference.Kind, Identifier: dependency.root.Identifier, Optional: reference.Optional}
+		if !containsDependency(dependencies, edge) {
+			dependencies = append(dependencies, edge)
+		}
+		referenced = append(referenced, dependency)
+	}
+	g.Dependencies[identifier] = dependencies
+
+	if root.Parent != nil {
+		// parents are rendered along with the template, which loads their references relatively to them
+		if err := g.add(root.Parent.Identifier, root.Parent, template); err != nil {
+			return err
+		}
+	}
+	for _, dependency := range referenced {
+		if err := g.add(dependency.root.Identifier, dependency.root, dependency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsDependency(dependencies []Dependency, dependency Dependency) bool {
+	for _, existing := range dependencies {
+		if existing == dependency {
+			return true
+		}
+	}
+	return false
+}
+
+// Cycle returns the identifiers of the templates forming a cycle in the graph, starting and ending with
+// the same template, or nil if the graph has no cycle. Cycles reachable from the root are looked up first.
+func (g *DependencyGraph) Cycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := map[string]int{}
+	path := []string{}
+	var visit func(identifier string) []string
+	visit = func(identifier string) []string {
+		switch states[identifier] {
+		case visiting:
+			for index, step := range path {
+				if step == identifier {
+					return append(append([]string{}, path[index:]...), identifier)
+				}
+			}
+		case visited:
+			return nil
+		}
+		states[identifier] = visiting
+		path = append(path, identifier)
+		for _, dependency := range g.Dependencies[identifier] {
+			if cycle := visit(dependency.Identifier); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		states[identifier] = visited
+		return nil
+	}
+
+	identifiers := make([]string, 0, len(g.Dependencies))
+	for identifier := range g.Dependencies {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+	for _, identifier := range append([]string{g.Root}, identifiers...) {
+		if cycle := visit(identifier); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// Validate returns an error naming the templates involved if the graph contains a cycle. Note that the graph
+// is built statically: a cycle guarded by a condition, such as a template including itself recursively until
+// a variable is empty, is reported as well.
+func (g *DependencyGraph) Validate() error {
+	if cycle := g.Cycle(); cycle != nil {
+		return fmt.Errorf("template dependency cycle detected: %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package exec_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2/builtins"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("template dependencies", func() {
+	var (
+		sources = new(map[string]string)
+
+		returnedGraph = new(*exec.DependencyGraph)
+		returnedErr   = new(error)
+	)
+	BeforeEach(func() {
+		*sources = map[string]string{
+			"/root":   `{% extends "/base" %}{% block content %}{% include "/a" %}{% include "/a" %}{% endblock %}`,
+			"/base":   `{% from "/macros" import greet %}{% block content %}{% endblock %}`,
+			"/a":      `{% import "/macros" as macros %}{% include name %}`,
+			"/macros": `{% macro greet() %}Hello{% endmacro %}`,
+		}
+	})
+	JustBeforeEach(func() {
+		environment := &exec.Environment{
+			Filters:           builtins.Filters,
+			Tests:             builtins.Tests,
+			ControlStructures: builtins.ControlStructures,
+			Methods:           builtins.Methods,
+			Context:           exec.EmptyContext(),
+		}
+		template, err := exec.NewTemplate("/root", config.New(), loaders.MustNewMemoryLoader(*sources), environment)
+		Expect(err).To(BeNil())
+		*returnedGraph, *returnedErr = template.Dependencies()
+	})
+	Context("default", func() {
+		It("should return the dependency graph", func() {
+			By("not returning an error")
+			Expect(*returnedErr).To(BeNil())
+			By("listing the dependencies of every template")
+			Expect((*returnedGraph).Root).To(Equal("/root"))
+			Expect((*returnedGraph).Dependencies).To(Equal(map[string][]exec.Dependency{
+				"/root":   {{Kind: "extends", Identifier: "/base"}, {Kind: "include", Identifier: "/a"}},
+				"/base":   {{Kind: "from", Identifier: "/macros"}},
+				"/a":      {{Kind: "import", Identifier: "/macros"}},
+				"/macros": {},
+			}))
+			By("not finding any cycle")
+			Expect((*returnedGraph).Cycle()).To(BeNil())
+			Expect((*returnedGraph).Validate()).To(Succeed())
+		})
+	})
+	Context("when templates include each other", func() {
+		BeforeEach(func() {
+			(*sources)["/macros"] = `{% if false %}{% include "/a" %}{% endif %}`
+		})
+		It("should report the cycle", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect((*returnedGraph).Cycle()).To(Equal([]string{"/macros", "/a", "/macros"}))
+			Expect((*returnedGraph).Validate()).To(MatchError("template dependency cycle detected: /macros -> /a -> /macros"))
+		})
+	})
+	Context("when an include statement ignores missing templates", func() {
+		BeforeEach(func() {
+			(*sources)["/a"] = `{% include "/optional" ignore missing %}{% include "/macros" ignore missing %}`
+		})
+		It("should record the existing ones as optional and leave the missing ones out", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect((*returnedGraph).Dependencies["/a"]).To(Equal([]exec.Dependency{
+				{Kind: "include", Identifier: "/macros", Optional: true},
+			}))
+		})
+	})
+	Context("when a referenced template is missing", func() {
+		BeforeEach(func() {
+			delete(*sources, "/macros")
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("unable to load the template referenced by the from statement at line 1 of '/base'")))
+		})
+	})
+})
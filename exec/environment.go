@@ -1,25 +1,127 @@
 package exec
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/nikolalohinski/gonja/v2/parser"
-	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
+// Environment holds the filters, tests, control structures, methods and global context available to templates.
+// Environments created through an EnvironmentBuilder are frozen and safe to share between concurrent renders.
 type Environment struct {
 	Filters           *FilterSet
 	ControlStructures *ControlStructureSet
 	Tests             *TestSet
 	Context           *Context
 	Methods           Methods
+	// Logger receives the messages logged by templates, such as the ones of the warn and log global
+	// functions. The standard logger of logrus is used when it is nil.
+	Logger logrus.FieldLogger
+	// AutoReload makes the templates check whether they or the templates they extend changed when executed,
+	// and parse them again if so, which lets developers see their edits without restarting their server. It
+	// requires a loader implementing loaders.StatLoader, such as the file system loader, and is meant for
+	// development. Changes are polled rather than watched with file system notifications such as fsnotify:
+	// the versions of the templates are asked to the loader at most once per AutoReloadInterval, by one of
+	// the renders, so edits show up within an interval rather than immediately. Accessors such as Root,
+	// Metadata or Precompile use the reloaded template as well. Templates whose version can not be retrieved
+	// are considered unchanged.
+	AutoReload bool
+	// AutoReloadInterval is how often templates check whether they changed under AutoReload, which defaults
+	// to DefaultAutoReloadInterval when zero
+	AutoReloadInterval time.Duration
+	// NotCallable controls what happens when a template calls something which is not a function, and
+	// NotCallableHook is called in place of it under the NotCallableCallsHook policy, see NotCallablePolicy
+	NotCallable     NotCallablePolicy
+	NotCallableHook NotCallableFunc
+
+	// restriction is set on environments returned by Restrict
+	restriction *restriction
+}
+
+// Overlay returns a child environment falling back to this one for every filter, test, control structure
+// and global it does not define itself. Registering or replacing entries on the child, as well as setting
+// globals on its context, does not affect this environment, and changes made to this environment remain
+// visible from the child. Methods are shared with this environment. Overlays are cheap to create since no
+// registry is copied, which makes them suitable for per-request or per-tenant customizations.
+func (e *Environment) Overlay() *Environment {
+	overlay := &Environment{
+		Filters:            &FilterSet{filters: map[string]FilterFunction{}, parent: e.Filters},
+		Tests:              &TestSet{tests: map[string]TestFunction{}, parent: e.Tests},
+		ControlStructures:  &ControlStructureSet{statements: map[string]parser.ControlStructureParser{}, parent: e.ControlStructures},
+		Context:            EmptyContext(),
+		Methods:            e.Methods,
+		Logger:             e.Logger,
+		AutoReload:         e.AutoReload,
+		AutoReloadInterval: e.AutoReloadInterval,
+		NotCallable:        e.NotCallable,
+		NotCallableHook:    e.NotCallableHook,
+		restriction:        e.restriction,
+	}
+	if e.Context != nil {
+		overlay.Context = e.Context.Inherit()
+	}
+	return overlay
+}
+
+// Log returns the logger receiving the messages logged by templates, see Logger
+func (e *Environment) Log() logrus.FieldLogger {
+	if e.Logger == nil {
+		return logrus.StandardLogger()
+	}
+	return e.Logger
+}
+
+// Clone returns a deep copy of the environment. Its filters, tests and control structures are copied
+// into new sets, including the entries inherited from parent environments, and its context is cloned,
+// so the copy can be specialized without affecting this environment and the other way around.
+// A clone of a frozen environment can be modified.
+func (e *Environment) Clone() *Environment {
+	clone := &Environment{
+		Filters:           &FilterSet{filters: map[string]FilterFunction{}},
+		Tests:             &TestSet{tests: map[string]TestFunction{}},
+		ControlStructures: &ControlStructureSet{statements: map[string]parser.ControlStructureParser{}},
+		Context:           EmptyContext(),
+		Methods: Methods{
+			Bool:  e.Methods.Bool.clone(),
+			Int:   e.Methods.Int.clone(),
+			Float: e.Methods.Float.clone(),
+			Str:   e.Methods.Str.clone(),
+			Dict:  e.Methods.Dict.clone(),
+			List:  e.Methods.List.clone(),
+		},
+		Logger:             e.Logger,
+		AutoReload:         e.AutoReload,
+		AutoReloadInterval: e.AutoReloadInterval,
+		NotCallable:        e.NotCallable,
+		NotCallableHook:    e.NotCallableHook,
+		restriction:        e.restriction,
+	}
+	if e.Filters != nil {
+		clone.Filters.filters = e.Filters.all()
+	}
+	if e.Tests != nil {
+		clone.Tests.tests = e.Tests.all()
+	}
+	if e.ControlStructures != nil {
+		clone.ControlStructures.statements = e.ControlStructures.all()
+		clone.ControlStructures.aliases = e.ControlStructures.allAliases()
+	}
+	if e.Context != nil {
+		clone.Context = e.Context.Clone()
+	}
+	return clone
 }
 
 type FilterSet struct {
 	filters map[string]FilterFunction
+	parent  *FilterSet
 	lock    sync.Mutex
+	frozen  bool
 }
 
 func NewFilterSet(filters map[string]FilterFunction) *FilterSet {
@@ -30,18 +132,41 @@ func NewFilterSet(filters map[string]FilterFunction) *FilterSet {
 
 // Exists returns true if the given filter is already registered
 func (f *FilterSet) Exists(name string) bool {
-	f.lock.Lock()
-	defer f.lock.Unlock()
-	_, existing := f.filters[name]
+	_, existing := f.Get(name)
 	return existing
 }
 
 // Get returns true and the named filter if it is already registered
 func (f *FilterSet) Get(name string) (FilterFunction, bool) {
+	var (
+		filter FilterFunction
+		ok     bool
+	)
+	if f.frozen {
+		filter, ok = f.filters[name]
+	} else {
+		f.lock.Lock()
+		filter, ok = f.filters[name]
+		f.lock.Unlock()
+	}
+	if !ok && f.parent != nil {
+		return f.parent.Get(name)
+	}
+	return filter, ok
+}
+
+// all returns a copy of every filter available in the set, including the ones of its parents
+func (f *FilterSet) all() map[string]FilterFunction {
+	filters := map[string]FilterFunction{}
+	if f.parent != nil {
+		filters = f.parent.all()
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
-	filter, ok := f.filters[name]
-	return filter, ok
+	for name, filter := range f.filters {
+		filters[name] = filter
+	}
+	return filters
 }
 
 // Register registers a new filter. If there's already a filter with the same
@@ -49,8 +174,11 @@ func (f *FilterSet) Get(name string) (FilterFunction, bool) {
 // function in the filter's init() function:
 // http://golang.org/doc/effective_go.html#init
 func (f *FilterSet) Register(name string, fn FilterFunction) error {
+	if f.frozen {
+		return fmt.Errorf("unable to register filter '%s' on a frozen environment", name)
+	}
 	if f.Exists(name) {
-		return errors.Errorf("filter with name '%s' is already registered", name)
+		return fmt.Errorf("filter with name '%s' is already registered", name)
 	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
@@ -61,8 +189,11 @@ func (f *FilterSet) Register(name string, fn FilterFunction) error {
 // Replace replaces an already registered filter with a new implementation. Use this
 // function with caution since it allows you to change existing filter behaviour.
 func (f *FilterSet) Replace(name string, fn FilterFunction) error {
+	if f.frozen {
+		return fmt.Errorf("unable to replace filter '%s' on a frozen environment", name)
+	}
 	if !f.Exists(name) {
-		return errors.Errorf("filter with name '%s' does not exist (therefore cannot be overridden)", name)
+		return fmt.Errorf("filter with name '%s' does not exist (therefore cannot be overridden)", name)
 	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
@@ -70,23 +201,36 @@ func (f *FilterSet) Replace(name string, fn FilterFunction) error {
 	return nil
 }
 
-func (f *FilterSet) Update(other *FilterSet) *FilterSet {
+// Update copies all filters of the other set into this one. Frozen sets are left untouched and return an
+// error instead.
+func (f *FilterSet) Update(other *FilterSet) error {
+	if f.frozen {
+		return errors.New("unable to update filters on a frozen environment")
+	}
 	if other == nil {
-		return f
+		return nil
 	}
+	filters := other.all()
 	f.lock.Lock()
-	other.lock.Lock()
 	defer f.lock.Unlock()
-	defer other.lock.Unlock()
-	for name, filter := range other.filters {
+	for name, filter := range filters {
 		f.filters[name] = filter
 	}
-	return f
+	return nil
 }
 
 type ControlStructureSet struct {
 	statements map[string]parser.ControlStructureParser
+	aliases    map[string]controlStructureAlias
+	parent     *ControlStructureSet
 	lock       sync.Mutex
+	frozen     bool
+}
+
+// controlStructureAlias is the name a legacy tag name is renamed to while parsing
+type controlStructureAlias struct {
+	name       string
+	deprecated bool
 }
 
 func NewControlStructureSet(statements map[string]parser.ControlStructureParser) *ControlStructureSet {
@@ -97,25 +241,51 @@ func NewControlStructureSet(statements map[string]parser.ControlStructureParser)
 
 // Exists returns true if the given test is already registered
 func (c *ControlStructureSet) Exists(name string) bool {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	_, existing := c.statements[name]
+	_, existing := c.Get(name)
 	return existing
 }
 
 func (c *ControlStructureSet) Get(name string) (parser.ControlStructureParser, bool) {
+	var (
+		controlStructure parser.ControlStructureParser
+		existing         bool
+	)
+	if c.frozen {
+		controlStructure, existing = c.statements[name]
+	} else {
+		c.lock.Lock()
+		controlStructure, existing = c.statements[name]
+		c.lock.Unlock()
+	}
+	if !existing && c.parent != nil {
+		return c.parent.Get(name)
+	}
+	return controlStructure, existing
+}
+
+// all returns a copy of every control structure available in the set, including the ones of its parents
+func (c *ControlStructureSet) all() map[string]parser.ControlStructureParser {
+	statements := map[string]parser.ControlStructureParser{}
+	if c.parent != nil {
+		statements = c.parent.all()
+	}
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	parser, existing := c.statements[name]
-	return parser, existing
+	for name, controlStructure := range c.statements {
+		statements[name] = controlStructure
+	}
+	return statements
 }
 
 // Registers a new tag. You usually want to call this
 // function in the tag's init() function:
 // http://golang.org/doc/effective_go.html#init
 func (c *ControlStructureSet) Register(name string, parser parser.ControlStructureParser) error {
+	if c.frozen {
+		return fmt.Errorf("unable to register ControlStructure '%s' on a frozen environment", name)
+	}
 	if c.Exists(name) {
-		return errors.Errorf("ControlStructure '%s' is already registered", name)
+		return fmt.Errorf("ControlStructure '%s' is already registered", name)
 	}
 	c.lock.Lock()
 	defer c.lock.Unlock()
@@ -126,8 +296,11 @@ func (c *ControlStructureSet) Register(name string, parser parser.ControlStructu
 // Replaces an already registered tag with a new implementation. Use this
 // function with caution since it allows you to change existing tag behaviour.
 func (c *ControlStructureSet) Replace(name string, parser parser.ControlStructureParser) error {
+	if c.frozen {
+		return fmt.Errorf("unable to replace ControlStructure '%s' on a frozen environment", name)
+	}
 	if !c.Exists(name) {
-		return errors.Errorf("ControlStructure '%s' does not exist (therefore cannot be overridden)", name)
+		return fmt.Errorf("ControlStructure '%s' does not exist (therefore cannot be overridden)", name)
 	}
 	c.lock.Lock()
 	defer c.lock.Unlock()
@@ -135,21 +308,116 @@ func (c *ControlStructureSet) Replace(name string, parser parser.ControlStructur
 	return nil
 }
 
-func (c *ControlStructureSet) Update(other *ControlStructureSet) *ControlStructureSet {
+// Update copies all control structures and aliases of the other set into this one. Frozen sets are left
+// untouched and return an error instead.
+func (c *ControlStructureSet) Update(other *ControlStructureSet) error {
+	if c.frozen {
+		return errors.New("unable to update control structures on a frozen environment")
+	}
+	if other == nil {
+		return nil
+	}
+	statements := other.all()
+	aliases := other.allAliases()
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	other.lock.Lock()
-	defer other.lock.Unlock()
-	for name, parser := range other.statements {
+	for name, parser := range statements {
 		c.statements[name] = parser
 	}
-	return c
+	if len(aliases) > 0 && c.aliases == nil {
+		c.aliases = map[string]controlStructureAlias{}
+	}
+	for alias, target := range aliases {
+		c.aliases[alias] = target
+	}
+	return nil
+}
+
+// Alias registers a legacy name for a control structure or for one of its intermediate tags, such as
+// Alias("elsif", "elif") or Alias("endunless", "endif"), so that templates using the legacy name keep
+// parsing while they are being migrated. Aliases are renamed while parsing, before looking the
+// control structure up.
+func (c *ControlStructureSet) Alias(alias string, name string) error {
+	return c.alias(alias, controlStructureAlias{name: name})
+}
+
+// DeprecatedAlias registers an alias like Alias does, and logs a warning with the location of each use
+// of the alias in the templates being parsed
+func (c *ControlStructureSet) DeprecatedAlias(alias string, name string) error {
+	return c.alias(alias, controlStructureAlias{name: name, deprecated: true})
+}
+
+func (c *ControlStructureSet) alias(alias string, target controlStructureAlias) error {
+	if c.frozen {
+		return fmt.Errorf("unable to register alias '%s' on a frozen environment", alias)
+	}
+	if alias == target.name {
+		return fmt.Errorf("unable to register alias '%s' of itself", alias)
+	}
+	if c.Exists(alias) {
+		return fmt.Errorf("ControlStructure '%s' is already registered", alias)
+	}
+	if _, _, existing := c.Rename(alias); existing {
+		return fmt.Errorf("alias '%s' is already registered", alias)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.aliases == nil {
+		c.aliases = map[string]controlStructureAlias{}
+	}
+	c.aliases[alias] = target
+	return nil
+}
+
+// Rename returns the name an alias stands for and whether it is deprecated, see Alias
+func (c *ControlStructureSet) Rename(alias string) (string, bool, bool) {
+	var (
+		target   controlStructureAlias
+		existing bool
+	)
+	if c.frozen {
+		target, existing = c.aliases[alias]
+	} else {
+		c.lock.Lock()
+		target, existing = c.aliases[alias]
+		c.lock.Unlock()
+	}
+	if !existing && c.parent != nil {
+		return c.parent.Rename(alias)
+	}
+	return target.name, target.deprecated, existing
+}
+
+// Aliases returns the rename map of the set, from each alias to the name it stands for, including the
+// aliases of its parents
+func (c *ControlStructureSet) Aliases() map[string]string {
+	aliases := map[string]string{}
+	for alias, target := range c.allAliases() {
+		aliases[alias] = target.name
+	}
+	return aliases
+}
+
+// allAliases returns a copy of every alias available in the set, including the ones of its parents
+func (c *ControlStructureSet) allAliases() map[string]controlStructureAlias {
+	aliases := map[string]controlStructureAlias{}
+	if c.parent != nil {
+		aliases = c.parent.allAliases()
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for alias, target := range c.aliases {
+		aliases[alias] = target
+	}
+	return aliases
 }
 
 // TestSet maps test names to their TestFunction handler
 type TestSet struct {
-	tests map[string]TestFunction
-	lock  sync.Mutex
+	tests  map[string]TestFunction
+	parent *TestSet
+	lock   sync.Mutex
+	frozen bool
 }
 
 func NewTestSet(tests map[string]TestFunction) *TestSet {
@@ -191,24 +459,50 @@ func (t *TestSet) validate(name string, fn TestFunction) error {
 
 // Exists returns true if the given test is already registered
 func (t *TestSet) Exists(name string) bool {
-	t.lock.Lock()
-	defer t.lock.Unlock()
-	_, existing := t.tests[name]
+	_, existing := t.Get(name)
 	return existing
 }
 
 func (t *TestSet) Get(name string) (TestFunction, bool) {
+	var (
+		fn       TestFunction
+		existing bool
+	)
+	if t.frozen {
+		fn, existing = t.tests[name]
+	} else {
+		t.lock.Lock()
+		fn, existing = t.tests[name]
+		t.lock.Unlock()
+	}
+	if !existing && t.parent != nil {
+		return t.parent.Get(name)
+	}
+	return fn, existing
+}
+
+// all returns a copy of every test available in the set, including the ones of its parents
+func (t *TestSet) all() map[string]TestFunction {
+	tests := map[string]TestFunction{}
+	if t.parent != nil {
+		tests = t.parent.all()
+	}
 	t.lock.Lock()
 	defer t.lock.Unlock()
-	fn, existing := t.tests[name]
-	return fn, existing
+	for name, fn := range t.tests {
+		tests[name] = fn
+	}
+	return tests
 }
 
 // Register registers a new test. If there's already a test with the same
 // name, RegisterTest will error out.
 func (t *TestSet) Register(name string, fn TestFunction) error {
+	if t.frozen {
+		return fmt.Errorf("unable to register test '%s' on a frozen environment", name)
+	}
 	if t.Exists(name) {
-		return errors.Errorf("test with name '%s' is already registered", name)
+		return fmt.Errorf("test with name '%s' is already registered", name)
 	}
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -222,8 +516,11 @@ func (t *TestSet) Register(name string, fn TestFunction) error {
 // Replace replaces an already registered test with a new implementation. Use this
 // function with caution since it allows you to change existing test behaviour.
 func (t *TestSet) Replace(name string, fn TestFunction) error {
+	if t.frozen {
+		return fmt.Errorf("unable to replace test '%s' on a frozen environment", name)
+	}
 	if !t.Exists(name) {
-		return errors.Errorf("test with name '%s' does not exist (therefore cannot be overridden)", name)
+		return fmt.Errorf("test with name '%s' does not exist (therefore cannot be overridden)", name)
 	}
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -234,18 +531,25 @@ func (t *TestSet) Replace(name string, fn TestFunction) error {
 	return nil
 }
 
-func (t *TestSet) Update(other *TestSet) *TestSet {
+// Update copies all valid tests of the other set into this one. Frozen sets are left untouched and return an
+// error instead.
+func (t *TestSet) Update(other *TestSet) error {
+	if t.frozen {
+		return errors.New("unable to update tests on a frozen environment")
+	}
+	if other == nil {
+		return nil
+	}
+	tests := other.all()
 	t.lock.Lock()
 	defer t.lock.Unlock()
-	other.lock.Lock()
-	defer other.lock.Unlock()
-	for name, test := range other.tests {
+	for name, test := range tests {
 		if err := t.validate(name, test); err != nil {
 			continue
 		}
 		t.tests[name] = test
 	}
-	return t
+	return nil
 }
 
 type Method[I interface{}] func(self I, selfValue *Value, arguments *VarArgs) (interface{}, error)
@@ -262,6 +566,7 @@ type Methods struct {
 type MethodSet[I interface{}] struct {
 	methods map[string]Method[I]
 	lock    sync.Mutex
+	frozen  bool
 }
 
 func NewMethodSet[I interface{}](methods map[string]Method[I]) *MethodSet[I] {
@@ -271,6 +576,10 @@ func NewMethodSet[I interface{}](methods map[string]Method[I]) *MethodSet[I] {
 }
 
 func (m *MethodSet[I]) Get(name string) (Method[I], bool) {
+	if m.frozen {
+		method, existing := m.methods[name]
+		return method, existing
+	}
 	m.lock.Lock()
 	defer m.lock.Unlock()
 	method, existing := m.methods[name]
@@ -278,8 +587,25 @@ func (m *MethodSet[I]) Get(name string) (Method[I], bool) {
 }
 
 func (m *MethodSet[I]) Exists(name string) bool {
+	if m.frozen {
+		_, existing := m.methods[name]
+		return existing
+	}
 	m.lock.Lock()
 	defer m.lock.Unlock()
 	_, existing := m.methods[name]
 	return existing
 }
+
+// clone returns a frozen copy of the method set
+func (m *MethodSet[I]) clone() *MethodSet[I] {
+	methods := map[string]Method[I]{}
+	if m != nil {
+		m.lock.Lock()
+		for name, method := range m.methods {
+			methods[name] = method
+		}
+		m.lock.Unlock()
+	}
+	return &MethodSet[I]{methods: methods, frozen: true}
+}
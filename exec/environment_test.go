@@ -0,0 +1,243 @@
+package exec_test
+
+import (
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/parser"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("environment overlay", func() {
+	var (
+		parent  = new(*exec.Environment)
+		overlay = new(*exec.Environment)
+	)
+	upper := func(_ *exec.Evaluator, in *exec.Value, _ *exec.VarArgs) *exec.Value {
+		return exec.AsValue(strings.ToUpper(in.String()))
+	}
+	lower := func(_ *exec.Evaluator, in *exec.Value, _ *exec.VarArgs) *exec.Value {
+		return exec.AsValue(strings.ToLower(in.String()))
+	}
+	render := func(environment *exec.Environment, source string) string {
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/test": source})
+		template, err := exec.NewTemplate("/test", config.New(), loader, environment)
+		Expect(err).To(BeNil())
+		out, err := template.ExecuteToString(nil)
+		Expect(err).To(BeNil())
+		return out
+	}
+	BeforeEach(func() {
+		*parent = &exec.Environment{
+			Filters:           exec.NewFilterSet(map[string]exec.FilterFunction{"transform": upper}),
+			Tests:             exec.NewTestSet(map[string]exec.TestFunction{}),
+			ControlStructures: exec.NewControlStructureSet(map[string]parser.ControlStructureParser{}),
+			Context:           exec.NewContext(map[string]interface{}{"name": "Parent"}),
+		}
+		*overlay = (*parent).Overlay()
+	})
+	It("should fall back to the parent environment", func() {
+		Expect(render(*overlay, "{{ name | transform }}")).To(Equal("PARENT"))
+	})
+	Context("when overriding entries in the overlay", func() {
+		BeforeEach(func() {
+			Expect((*overlay).Filters.Replace("transform", lower)).To(Succeed())
+			Expect((*overlay).Filters.Register("shout", upper)).To(Succeed())
+			(*overlay).Context.Set("name", "Overlay")
+		})
+		It("should use the overlay entries", func() {
+			Expect(render(*overlay, "{{ name | transform }} {{ name | shout }}")).To(Equal("overlay OVERLAY"))
+		})
+		It("should leave the parent environment untouched", func() {
+			Expect((*parent).Filters.Exists("shout")).To(BeFalse())
+			Expect(render(*parent, "{{ name | transform }}")).To(Equal("PARENT"))
+		})
+	})
+	Context("when registering entries on the parent afterwards", func() {
+		BeforeEach(func() {
+			Expect((*parent).Filters.Register("shout", upper)).To(Succeed())
+		})
+		It("should expose them through the overlay", func() {
+			Expect(render(*overlay, "{{ name | shout }}")).To(Equal("PARENT"))
+		})
+	})
+	Context("when registering an entry already defined by the parent", func() {
+		It("should return an error", func() {
+			Expect((*overlay).Filters.Register("transform", lower)).To(MatchError("filter with name 'transform' is already registered"))
+		})
+	})
+	Context("when the parent is frozen", func() {
+		BeforeEach(func() {
+			frozen, err := exec.NewEnvironmentBuilder(*parent).Build()
+			Expect(err).To(BeNil())
+			*overlay = frozen.Overlay()
+		})
+		It("should allow customizing the overlay", func() {
+			Expect((*overlay).Filters.Replace("transform", lower)).To(Succeed())
+			Expect(render(*overlay, "{{ name | transform }}")).To(Equal("parent"))
+		})
+		It("should be usable as a builder base", func() {
+			Expect((*overlay).Filters.Register("shout", upper)).To(Succeed())
+			built, err := exec.NewEnvironmentBuilder(*overlay).Build()
+			Expect(err).To(BeNil())
+			Expect(built.Filters.Exists("shout")).To(BeTrue())
+			Expect(built.Filters.Exists("transform")).To(BeTrue())
+		})
+	})
+})
+
+var _ = Context("environment clone", func() {
+	var (
+		original = new(*exec.Environment)
+		clone    = new(*exec.Environment)
+	)
+	valueOf := func(ctx *exec.Context, name string) interface{} {
+		value, _ := ctx.Get(name)
+		return value
+	}
+	upper := func(_ *exec.Evaluator, in *exec.Value, _ *exec.VarArgs) *exec.Value {
+		return exec.AsValue(strings.ToUpper(in.String()))
+	}
+	BeforeEach(func() {
+		*original = &exec.Environment{
+			Filters:           exec.NewFilterSet(map[string]exec.FilterFunction{"upper": upper}),
+			Tests:             exec.NewTestSet(map[string]exec.TestFunction{}),
+			ControlStructures: exec.NewControlStructureSet(map[string]parser.ControlStructureParser{}),
+			Context:           exec.NewContext(map[string]interface{}{"names": []interface{}{"a"}}),
+		}
+	})
+	JustBeforeEach(func() {
+		*clone = (*original).Clone()
+	})
+	It("should not share any registry with the original environment", func() {
+		Expect((*clone).Filters.Exists("upper")).To(BeTrue())
+		Expect((*clone).Filters.Register("shout", upper)).To(Succeed())
+		Expect((*original).Filters.Register("whisper", upper)).To(Succeed())
+		Expect((*original).Filters.Exists("shout")).To(BeFalse())
+		Expect((*clone).Filters.Exists("whisper")).To(BeFalse())
+	})
+	It("should deep copy the context", func() {
+		names, _ := (*clone).Context.Get("names")
+		names.([]interface{})[0] = "b"
+		Expect(valueOf((*original).Context, "names")).To(Equal([]interface{}{"a"}))
+	})
+	Context("when the original environment is frozen", func() {
+		BeforeEach(func() {
+			frozen, err := exec.NewEnvironmentBuilder(*original).Build()
+			Expect(err).To(BeNil())
+			*original = frozen
+		})
+		It("should return a modifiable environment", func() {
+			Expect((*clone).Filters.Register("shout", upper)).To(Succeed())
+			Expect((*original).Filters.Exists("shout")).To(BeFalse())
+		})
+	})
+})
+
+var _ = Context("control structure aliases", func() {
+	var (
+		environment = new(*exec.Environment)
+		source      = new(string)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*environment = gonja.DefaultEnvironment.Clone()
+		Expect((*environment).ControlStructures.Alias("elsif", "elif")).To(Succeed())
+		Expect((*environment).ControlStructures.Alias("foreach", "for")).To(Succeed())
+		*source = `{% if first %}first{% elsif second %}second{% endif %} {% foreach i in range(3) %}{{ i }}{% endfor %}`
+	})
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/template": *source})
+		template, err := exec.NewTemplate("/template", config.New(), loader, *environment)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		*returnedResult, *returnedErr = template.ExecuteToString(exec.NewContext(map[string]interface{}{"second": true}))
+	})
+	It("should parse the aliases of control structures and intermediate tags", func() {
+		Expect(*returnedErr).To(BeNil())
+		Expect(*returnedResult).To(Equal("second 012"))
+	})
+	It("should expose the rename map", func() {
+		Expect((*environment).ControlStructures.Aliases()).To(Equal(map[string]string{"elsif": "elif", "foreach": "for", "verbatim": "raw", "endverbatim": "endraw"}))
+	})
+	It("should reject aliases shadowing a control structure or another alias", func() {
+		Expect((*environment).ControlStructures.Alias("if", "for")).To(MatchError("ControlStructure 'if' is already registered"))
+		Expect((*environment).ControlStructures.Alias("elsif", "else")).To(MatchError("alias 'elsif' is already registered"))
+	})
+	Context("when the alias is deprecated", func() {
+		var (
+			logs = new(strings.Builder)
+		)
+		BeforeEach(func() {
+			*environment = gonja.DefaultEnvironment.Clone()
+			Expect((*environment).ControlStructures.DeprecatedAlias("elsif", "elif")).To(Succeed())
+			*source = "{% if first %}first\n{% elsif second %}second{% endif %}"
+			logs.Reset()
+			log.SetOutput(logs)
+			DeferCleanup(log.SetOutput, os.Stderr)
+		})
+		It("should log a warning with the location of each use", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("second"))
+			Expect(logs.String()).To(ContainSubstring(`level=warning msg="'elsif' is deprecated, use 'elif' instead" col=4 line=2 template=/template`))
+			Expect(strings.Count(logs.String(), "deprecated")).To(Equal(1))
+		})
+	})
+	Context("when aliasing statements whose body is not lexed", func() {
+		BeforeEach(func() {
+			Expect((*environment).ControlStructures.Alias("literal", "raw")).To(Succeed())
+			Expect((*environment).ControlStructures.Alias("endliteral", "endraw")).To(Succeed())
+			*source = `{% verbatim %}{{ x }}{% endverbatim %} {% literal %}{% if %}{{ y }}{% endliteral %} {% raw %}{{ z }}{% endraw %}`
+		})
+		It("should lex their body as raw content", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("{{ x }} {% if %}{{ y }} {{ z }}"))
+		})
+	})
+	Context("when an extended template uses the aliases of raw statements", func() {
+		JustBeforeEach(func() {
+			loader := loaders.MustNewMemoryLoader(map[string]string{
+				"/base":  `{% verbatim %}{{ x }}{% endverbatim %}`,
+				"/child": `{% extends "/base" %}`,
+			})
+			template, err := exec.NewTemplate("/child", config.New(), loader, *environment)
+			if *returnedErr = err; err != nil {
+				return
+			}
+			*returnedResult, *returnedErr = template.ExecuteToString(nil)
+		})
+		It("should lex their body as raw content as well", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("{{ x }}"))
+		})
+	})
+	Context("when the environment is built", func() {
+		BeforeEach(func() {
+			built, err := exec.NewEnvironmentBuilder(gonja.DefaultEnvironment).WithAlias("elsif", "elif").WithAlias("foreach", "for").Build()
+			Expect(err).To(BeNil())
+			*environment = built
+		})
+		It("should keep the aliases registered on the builder", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("second 012"))
+		})
+		It("should not accept new aliases", func() {
+			Expect((*environment).ControlStructures.Alias("endforeach", "endfor")).To(MatchError("unable to register alias 'endforeach' on a frozen environment"))
+		})
+		It("should hand them down to overlays and clones", func() {
+			Expect((*environment).Overlay().ControlStructures.Aliases()).To(HaveKey("elsif"))
+			Expect((*environment).Clone().ControlStructures.Aliases()).To(HaveKey("foreach"))
+		})
+	})
+})
@@ -0,0 +1,82 @@
+package exec
+
+import (
+	"errors"
+
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/parser"
+)
+
+// ErrUndefined is matched with errors.Is by the errors of the lookups of undefined variables, attributes and
+// items, which fail the render when the StrictUndefined option of the configuration is set
+var ErrUndefined = errors.New("undefined")
+
+// ErrBreak and ErrContinue are returned by the break and continue statements to make the loop holding them
+// stop, or skip to its next iteration. Loops catch them with errors.Is, and templates using the statements
+// outside of a loop fail to parse, so their messages only show up for nodes built by hand.
+var (
+	ErrBreak    = errors.New("'break' used outside of a loop")
+	ErrContinue = errors.New("'continue' used outside of a loop")
+)
+
+// undefinedError reports an undefined lookup with the message of the evaluator, and matches ErrUndefined
+type undefinedError struct {
+	cause error
+}
+
+func (e *undefinedError) Error() string        { return e.cause.Error() }
+func (e *undefinedError) Unwrap() error        { return e.cause }
+func (e *undefinedError) Is(target error) bool { return target == ErrUndefined }
+
+// undefined marks the error as reporting an undefined lookup
+func undefined(err error) error {
+	return &undefinedError{cause: err}
+}
+
+// Error locates the errors of templates failing to parse, as returned by NewTemplate, or failing to render,
+// as returned by the Execute methods, and can be retrieved with errors.As. When a template fails because of
+// a template it includes, extends or imports, the error is located in the latter. Its message is the one of
+// the error it wraps.
+type Error struct {
+	// Identifier is the template holding the error
+	Identifier string
+	// Line and Col locate the error in the template, and are zero when it is unknown
+	Line int
+	Col  int
+	// Err is the error itself
+	Err error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+func (e *Error) Unwrap() error { return e.Err }
+
+// templateError locates the error of the given template, unless it is already located. Syntax errors are
+// located at their faulty token, in the template holding it.
+func templateError(identifier string, err error) error {
+	var located *Error
+	if errors.As(err, &located) {
+		return err
+	}
+	located = &Error{Identifier: identifier, Err: err}
+	var syntax *parser.SyntaxError
+	if errors.As(err, &syntax) {
+		if syntax.Identifier != "" {
+			located.Identifier = syntax.Identifier
+		}
+		located.Line, located.Col = syntax.Line, syntax.Col
+	}
+	return located
+}
+
+// errorAt locates the error at the given node, unless it is already located
+func (r *Renderer) errorAt(node nodes.Node, err error) error {
+	var located *Error
+	if errors.As(err, &located) {
+		return err
+	}
+	located = &Error{Identifier: r.identifierOf(node), Err: err}
+	if position := node.Position(); position != nil {
+		located.Line, located.Col = position.Line, position.Col
+	}
+	return located
+}
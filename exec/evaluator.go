@@ -1,11 +1,12 @@
 package exec
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"reflect"
 	"strings"
-
-	"github.com/pkg/errors"
+	"time"
 
 	"github.com/nikolalohinski/gonja/v2/config"
 	"github.com/nikolalohinski/gonja/v2/loaders"
@@ -16,6 +17,8 @@ import (
 var (
 	typeOfValuePtr   = reflect.TypeOf(new(Value))
 	typeOfExecCtxPtr = reflect.TypeOf(new(Context))
+	typeOfTime       = reflect.TypeOf(time.Time{})
+	typeOfDuration   = reflect.TypeOf(time.Duration(0))
 )
 
 type ErrInvalidCall error
@@ -24,6 +27,8 @@ type Evaluator struct {
 	Config      *config.Config
 	Environment *Environment
 	Loader      loaders.Loader
+
+	budget *renderBudget
 }
 
 func (e *Evaluator) Eval(node nodes.Expression) *Value {
@@ -63,9 +68,15 @@ func (e *Evaluator) Eval(node nodes.Expression) *Value {
 		if result.IsError() {
 			return result
 		}
+		if e.Config.ZeroValueTruthiness {
+			return AsValue(!result.Truthy(e.Config))
+		}
 		return result.Negate()
 	case *nodes.BinaryExpression:
-		return e.evalBinaryExpression(n)
+		result := e.evalBinaryExpression(n)
+		// the result is handed over to the caller and must not be recycled anymore
+		result.pooled = false
+		return result
 	case *nodes.UnaryExpression:
 		return e.evalUnaryExpression(n)
 	case *nodes.FilteredExpression:
@@ -73,35 +84,76 @@ func (e *Evaluator) Eval(node nodes.Expression) *Value {
 	case *nodes.TestExpression:
 		return e.EvalTest(n)
 	default:
-		return AsValue(errors.Errorf(`Unknown expression type "%T"`, n))
+		return AsValue(fmt.Errorf(`Unknown expression type "%T"`, n))
 	}
 }
 
+// evalBinaryExpression evaluates a binary expression. The returned value may come from the value pool
+// and must be passed through Eval or releaseValue once it is not used anymore by the caller.
 func (e *Evaluator) evalBinaryExpression(node *nodes.BinaryExpression) *Value {
-	var (
-		left  *Value
-		right *Value
-	)
-	left = e.Eval(node.Left)
+	left := e.evalOperand(node.Left)
 	if left.IsError() {
-		return AsValue(errors.Wrapf(left, `Unable to evaluate left parameter %s`, node.Left))
+		return AsValue(fmt.Errorf(`Unable to evaluate left parameter %s: %w`, node.Left, left))
 	}
-
+	var right *Value
 	switch node.Operator.Token.Val {
 	// These operators allow lazy right expression evluation
 	case "and", "or":
 	default:
-		right = e.Eval(node.Right)
+		right = e.evalOperand(node.Right)
 		if right.IsError() {
-			return AsValue(errors.Wrapf(right, `Unable to evaluate right parameter %s`, node.Right))
+			return AsValue(fmt.Errorf(`Unable to evaluate right parameter %s: %w`, node.Right, right))
 		}
 	}
 
+	result := e.applyBinaryOperator(node, left, right)
+	// operands are wrapped in errors, so they can only be recycled on success
+	if !result.IsError() {
+		if left != result {
+			releaseValue(left)
+		}
+		if right != result {
+			releaseValue(right)
+		}
+	}
+	return result
+}
+
+// evalOperand evaluates an operand of a binary expression, taking literals and the results
+// of nested binary expressions from the value pool
+func (e *Evaluator) evalOperand(node nodes.Expression) *Value {
+	switch n := node.(type) {
+	case *nodes.String:
+		return newValue(n.Val)
+	case *nodes.Integer:
+		return newValue(n.Val)
+	case *nodes.Float:
+		return newValue(n.Val)
+	case *nodes.Bool:
+		return newValue(n.Val)
+	case *nodes.BinaryExpression:
+		// the result only serves as an operand, and is recycled by the caller along with the literals
+		return e.evalBinaryExpression(n)
+	default:
+		return e.Eval(node)
+	}
+}
+
+func (e *Evaluator) applyBinaryOperator(node *nodes.BinaryExpression, left, right *Value) *Value {
 	switch node.Operator.Token.Type {
 	case tokens.Addition:
+		if left.IsTime() && right.IsDuration() {
+			return newValue(left.Time().Add(right.Duration()))
+		}
+		if left.IsDuration() && right.IsTime() {
+			return newValue(right.Time().Add(left.Duration()))
+		}
+		if left.IsDuration() && right.IsDuration() {
+			return newValue(left.Duration() + right.Duration())
+		}
 		if left.IsList() {
 			if !right.IsList() {
-				return AsValue(errors.Wrapf(right, `Unable to concatenate list to %s`, node.Right))
+				return AsValue(fmt.Errorf(`Unable to concatenate list to %s: %w`, node.Right, right))
 			}
 
 			v := &Value{Val: reflect.ValueOf([]interface{}{})}
@@ -118,112 +170,147 @@ func (e *Evaluator) evalBinaryExpression(node *nodes.BinaryExpression) *Value {
 		}
 		if left.IsFloat() || right.IsFloat() {
 			// Result will be a float
-			return AsValue(left.Float() + right.Float())
+			return newValue(left.Float() + right.Float())
 		}
 
 		if left.IsString() || right.IsString() {
-			return AsValue(left.String() + right.String())
+			return newValue(left.String() + right.String())
 		}
 
 		// Result will be an integer
-		return AsValue(left.Integer() + right.Integer())
+		return newValue(left.Integer() + right.Integer())
 	case tokens.Subtraction:
+		if left.IsTime() && right.IsTime() {
+			return newValue(left.Time().Sub(right.Time()))
+		}
+		if left.IsTime() && right.IsDuration() {
+			return newValue(left.Time().Add(-right.Duration()))
+		}
+		if left.IsDuration() && right.IsDuration() {
+			return newValue(left.Duration() - right.Duration())
+		}
 		if left.IsFloat() || right.IsFloat() {
 			// Result will be a float
-			return AsValue(left.Float() - right.Float())
+			return newValue(left.Float() - right.Float())
 		}
 		// Result will be an integer
-		return AsValue(left.Integer() - right.Integer())
+		return newValue(left.Integer() - right.Integer())
 	case tokens.Multiply:
+		if left.IsDuration() && right.IsNumber() && !right.IsDuration() {
+			return newValue(time.Duration(float64(left.Duration()) * right.Float()))
+		}
+		if left.IsNumber() && !left.IsDuration() && right.IsDuration() {
+			return newValue(time.Duration(left.Float() * float64(right.Duration())))
+		}
 		if left.IsFloat() || right.IsFloat() {
 			// Result will be float
-			return AsValue(left.Float() * right.Float())
+			return newValue(left.Float() * right.Float())
 		}
 		if left.IsString() {
-			return AsValue(strings.Repeat(left.String(), right.Integer()))
+			// the size is checked before repeating the string, which could exhaust the memory otherwise
+			if err := e.checkSize(len(left.String()), right.Integer()); err != nil {
+				return AsValue(err)
+			}
+			return newValue(strings.Repeat(left.String(), right.Integer()))
 		}
 		// Result will be int
-		return AsValue(left.Integer() * right.Integer())
+		return newValue(left.Integer() * right.Integer())
 	case tokens.Division:
 		// Float division
-		return AsValue(left.Float() / right.Float())
+		return newValue(left.Float() / right.Float())
 	case tokens.FloorDivision:
 		// Int division
-		return AsValue(int(left.Float() / right.Float()))
+		return newValue(int(left.Float() / right.Float()))
 	case tokens.Modulo:
 		// Result will be int
-		return AsValue(left.Integer() % right.Integer())
+		return newValue(left.Integer() % right.Integer())
 	case tokens.Power:
-		return AsValue(math.Pow(left.Float(), right.Float()))
+		return newValue(math.Pow(left.Float(), right.Float()))
 	case tokens.Tilde:
-		return AsValue(strings.Join([]string{left.String(), right.String()}, ""))
+		return newValue(strings.Join([]string{left.Printed(e.Config), right.Printed(e.Config)}, ""))
 	case tokens.And:
-		if !left.IsTrue() {
-			return AsValue(false)
+		if !left.Truthy(e.Config) {
+			return newValue(false)
 		}
-		right = e.Eval(node.Right)
+		right = e.evalOperand(node.Right)
 		if right.IsError() {
-			return AsValue(errors.Wrapf(right, `Unable to evaluate right parameter %s`, node.Right))
+			return AsValue(fmt.Errorf(`Unable to evaluate right parameter %s: %w`, node.Right, right))
 		}
-		return AsValue(right.IsTrue())
+		result := right.Truthy(e.Config)
+		releaseValue(right)
+		return newValue(result)
 	case tokens.Or:
-		if left.IsTrue() {
-			return AsValue(true)
+		if left.Truthy(e.Config) {
+			return newValue(true)
 		}
-		right = e.Eval(node.Right)
+		right = e.evalOperand(node.Right)
 		if right.IsError() {
-			return AsValue(errors.Wrapf(right, `Unable to evaluate right parameter %s`, node.Right))
+			return AsValue(fmt.Errorf(`Unable to evaluate right parameter %s: %w`, node.Right, right))
 		}
-		return AsValue(right.IsTrue())
+		result := right.Truthy(e.Config)
+		releaseValue(right)
+		return newValue(result)
 	case tokens.LowerThanOrEqual:
+		if left.IsTime() && right.IsTime() {
+			return newValue(!left.Time().After(right.Time()))
+		}
 		if left.IsFloat() || right.IsFloat() {
-			return AsValue(left.Float() <= right.Float())
+			return newValue(left.Float() <= right.Float())
 		}
 		if left.IsString() || right.IsString() {
-			return AsValue(left.String() <= right.String())
+			return newValue(left.String() <= right.String())
 		}
-		return AsValue(left.Integer() <= right.Integer())
+		return newValue(left.Integer() <= right.Integer())
 	case tokens.GreaterThanOrEqual:
+		if left.IsTime() && right.IsTime() {
+			return newValue(!left.Time().Before(right.Time()))
+		}
 		if left.IsFloat() || right.IsFloat() {
-			return AsValue(left.Float() >= right.Float())
+			return newValue(left.Float() >= right.Float())
 		}
 		if left.IsString() || right.IsString() {
-			return AsValue(left.String() >= right.String())
+			return newValue(left.String() >= right.String())
 		}
-		return AsValue(left.Integer() >= right.Integer())
+		return newValue(left.Integer() >= right.Integer())
 	case tokens.Equals:
-		return AsValue(left.EqualValueTo(right))
+		return newValue(left.EqualValueTo(right))
 	case tokens.GreaterThan:
+		if left.IsTime() && right.IsTime() {
+			return newValue(left.Time().After(right.Time()))
+		}
 		if left.IsFloat() || right.IsFloat() {
-			return AsValue(left.Float() > right.Float())
+			return newValue(left.Float() > right.Float())
 		}
 		if left.IsString() || right.IsString() {
-			return AsValue(left.String() > right.String())
+			return newValue(left.String() > right.String())
 		}
 
-		return AsValue(left.Integer() > right.Integer())
+		return newValue(left.Integer() > right.Integer())
 	case tokens.LowerThan:
+		if left.IsTime() && right.IsTime() {
+			return newValue(left.Time().Before(right.Time()))
+		}
 		if left.IsFloat() || right.IsFloat() {
-			return AsValue(left.Float() < right.Float())
+			return newValue(left.Float() < right.Float())
 		}
 		if left.IsString() || right.IsString() {
-			return AsValue(left.String() < right.String())
+			return newValue(left.String() < right.String())
 		}
 
-		return AsValue(left.Integer() < right.Integer())
+		return newValue(left.Integer() < right.Integer())
 	case tokens.Ne:
-		return AsValue(!left.EqualValueTo(right))
+		return newValue(!left.EqualValueTo(right))
 	case tokens.In:
-		return AsValue(right.Contains(left))
+		return newValue(right.Contains(left))
 	default:
-		return AsValue(errors.Errorf(`Unknown operator "%s"`, node.Operator.Token))
+		return AsValue(fmt.Errorf(`Unknown operator "%s"`, node.Operator.Token))
 	}
 }
 
 func (e *Evaluator) evalUnaryExpression(expr *nodes.UnaryExpression) *Value {
 	result := e.Eval(expr.Term)
 	if result.IsError() {
-		return AsValue(errors.Wrapf(result, `Unable to evaluate term %s`, expr.Term))
+		return AsValue(fmt.Errorf(`Unable to evaluate term %s: %w`, expr.Term, result))
 	}
 	if expr.Negative {
 		if result.IsNumber() {
@@ -236,7 +323,7 @@ func (e *Evaluator) evalUnaryExpression(expr *nodes.UnaryExpression) *Value {
 				return AsValue(errors.New("Operation between a number and a non-(float/integer) is not possible"))
 			}
 		} else {
-			return AsValue(errors.Errorf("Negative sign on a non-number expression %s", expr.Position()))
+			return AsValue(fmt.Errorf("Negative sign on a non-number expression %s", expr.Position()))
 		}
 	}
 	return result
@@ -246,6 +333,9 @@ func (e *Evaluator) evalList(node *nodes.List) *Value {
 	values := ValuesList{}
 	for _, val := range node.Val {
 		value := e.Eval(val)
+		if value.IsOmitted() {
+			continue
+		}
 		values = append(values, value)
 	}
 	return AsValue(values)
@@ -255,6 +345,9 @@ func (e *Evaluator) evalTuple(node *nodes.Tuple) *Value {
 	values := ValuesList{}
 	for _, val := range node.Val {
 		value := e.Eval(val)
+		if value.IsOmitted() {
+			continue
+		}
 		values = append(values, value)
 	}
 	return AsValue(values)
@@ -265,9 +358,11 @@ func (e *Evaluator) evalDict(node *nodes.Dict) *Value {
 	for _, pair := range node.Pairs {
 		p := e.evalPair(pair)
 		if p.IsError() {
-			return AsValue(errors.Wrapf(p, `Unable to evaluate pair "%s"`, pair))
+			return AsValue(fmt.Errorf(`Unable to evaluate pair "%s": %w`, pair, p))
+		}
+		if pair := p.Interface().(*Pair); !pair.Value.IsOmitted() {
+			pairs = append(pairs, pair)
 		}
-		pairs = append(pairs, p.Interface().(*Pair))
 	}
 	return AsValue(&Dict{pairs})
 }
@@ -275,11 +370,11 @@ func (e *Evaluator) evalDict(node *nodes.Dict) *Value {
 func (e *Evaluator) evalPair(node *nodes.Pair) *Value {
 	key := e.Eval(node.Key)
 	if key.IsError() {
-		return AsValue(errors.Wrapf(key, `Unable to evaluate key "%s"`, node.Key))
+		return AsValue(fmt.Errorf(`Unable to evaluate key "%s": %w`, node.Key, key))
 	}
 	value := e.Eval(node.Value)
 	if value.IsError() {
-		return AsValue(errors.Wrapf(value, `Unable to evaluate value "%s"`, node.Value))
+		return AsValue(fmt.Errorf(`Unable to evaluate value "%s": %w`, node.Value, value))
 	}
 	return AsValue(&Pair{key, value})
 }
@@ -287,7 +382,10 @@ func (e *Evaluator) evalPair(node *nodes.Pair) *Value {
 func (e *Evaluator) evalName(node *nodes.Name) *Value {
 	val, ok := e.Environment.Context.Get(node.Name.Val)
 	if !ok && e.Config.StrictUndefined {
-		return AsValue(errors.Errorf(`Unable to evaluate name "%s"`, node.Name.Val))
+		return AsValue(undefined(fmt.Errorf(`Unable to evaluate name "%s"`, node.Name.Val)))
+	}
+	if !ok {
+		return undefinedValue()
 	}
 	return ToValue(val)
 }
@@ -295,11 +393,11 @@ func (e *Evaluator) evalName(node *nodes.Name) *Value {
 func (e *Evaluator) evalGetItem(node *nodes.GetItem) *Value {
 	value := e.Eval(node.Node)
 	if value.IsError() {
-		return AsValue(errors.Wrapf(value, `unable to evaluate target %s`, node.Node))
+		return AsValue(fmt.Errorf(`unable to evaluate target %s: %w`, node.Node, value))
 	}
 	if node.Arg == nil {
 		if e.Config.StrictUndefined {
-			return AsValue(errors.Wrapf(value, `argument is undefined to access: %s`, node.Node))
+			return AsValue(undefined(fmt.Errorf(`argument is undefined to access: %s: %w`, node.Node, value)))
 		} else {
 			return AsValue(nil)
 		}
@@ -313,9 +411,9 @@ func (e *Evaluator) evalGetItem(node *nodes.GetItem) *Value {
 	case argument != nil && argument.IsInteger():
 		key = argument.Integer()
 	case argument.IsNil() && e.Config.StrictUndefined:
-		return AsValue(errors.Wrapf(value, `argument is undefined to access: %s`, node.Node))
+		return AsValue(undefined(fmt.Errorf(`argument is undefined to access: %s: %w`, node.Node, value)))
 	default:
-		return AsValue(errors.Wrapf(value, `argument %s does not evaluate to string or integer in: %s`, node.Arg, node.Node))
+		return AsValue(fmt.Errorf(`argument %s does not evaluate to string or integer in: %s: %w`, node.Arg, node.Node, value))
 	}
 
 	item, found := value.GetItem(key)
@@ -324,12 +422,12 @@ func (e *Evaluator) evalGetItem(node *nodes.GetItem) *Value {
 	}
 	if !found {
 		if item.IsError() {
-			return AsValue(errors.Wrapf(item, `unable to evaluate %s`, node))
+			return AsValue(fmt.Errorf(`unable to evaluate %s: %w`, node, item))
 		}
 		if e.Config.StrictUndefined {
-			return AsValue(errors.Errorf(`unable to evaluate %s: item '%s' not found`, node, node.Arg))
+			return AsValue(undefined(fmt.Errorf(`unable to evaluate %s: item '%s' not found`, node, node.Arg)))
 		}
-		return AsValue(nil)
+		return undefinedValue()
 	}
 	return item
 }
@@ -337,20 +435,20 @@ func (e *Evaluator) evalGetItem(node *nodes.GetItem) *Value {
 func (e *Evaluator) evalGetSlice(node *nodes.GetSlice) *Value {
 	value := e.Eval(node.Node)
 	if value.IsError() {
-		return AsValue(errors.Wrapf(value, `unable to evaluate target %s`, node.Node))
+		return AsValue(fmt.Errorf(`unable to evaluate target %s: %w`, node.Node, value))
 	}
 	if !value.CanSlice() {
-		return AsValue(errors.Wrapf(value, `can not slice %s`, node.Node))
+		return AsValue(fmt.Errorf(`can not slice %s: %w`, node.Node, value))
 	}
 	start := 0
 	end := value.Len()
 	if node.Start != nil {
 		startValue := e.Eval(node.Start)
 		if startValue.IsError() {
-			return AsValue(errors.Wrapf(value, `unable to slice starting index %s`, node.Start))
+			return AsValue(fmt.Errorf(`unable to slice starting index %s: %w`, node.Start, value))
 		}
 		if !startValue.IsInteger() {
-			return AsValue(errors.Wrapf(value, `slice starting index is not an integer: %s`, startValue))
+			return AsValue(fmt.Errorf(`slice starting index is not an integer: %s: %w`, startValue, value))
 		}
 		start = startValue.Integer()
 		if start < 0 {
@@ -360,10 +458,10 @@ func (e *Evaluator) evalGetSlice(node *nodes.GetSlice) *Value {
 	if node.End != nil {
 		endValue := e.Eval(node.End)
 		if endValue.IsError() {
-			return AsValue(errors.Wrapf(value, `unable to slice starting index %s`, node.Start))
+			return AsValue(fmt.Errorf(`unable to slice starting index %s: %w`, node.Start, value))
 		}
 		if !endValue.IsInteger() {
-			return AsValue(errors.Wrapf(value, `slice starting index is not an integer: %s`, endValue))
+			return AsValue(fmt.Errorf(`slice starting index is not an integer: %s: %w`, endValue, value))
 		}
 		end = endValue.Integer()
 		if end < 0 {
@@ -377,7 +475,7 @@ func (e *Evaluator) evalGetSlice(node *nodes.GetSlice) *Value {
 func (e *Evaluator) evalGetAttribute(node *nodes.GetAttribute) *Value {
 	value := e.Eval(node.Node)
 	if value.IsError() {
-		return AsValue(errors.Wrapf(value, `Unable to evaluate target %s`, node.Node))
+		return AsValue(fmt.Errorf(`Unable to evaluate target %s: %w`, node.Node, value))
 	}
 
 	if node.Attribute != "" {
@@ -387,24 +485,24 @@ func (e *Evaluator) evalGetAttribute(node *nodes.GetAttribute) *Value {
 		}
 		if !found {
 			if attr.IsError() {
-				return AsValue(errors.Wrapf(attr, `Unable to evaluate %s`, node))
+				return AsValue(fmt.Errorf(`Unable to evaluate %s: %w`, node, attr))
 			}
 			if e.Config.StrictUndefined {
-				return AsValue(errors.Errorf(`Unable to evaluate %s: attribute '%s' not found`, node, node.Attribute))
+				return AsValue(undefined(fmt.Errorf(`Unable to evaluate %s: attribute '%s' not found`, node, node.Attribute)))
 			}
-			return AsValue(nil)
+			return undefinedValue()
 		}
 		return attr
 	} else {
 		item, found := value.GetItem(node.Index)
 		if !found {
 			if item.IsError() {
-				return AsValue(errors.Wrapf(item, `Unable to evaluate %s`, node))
+				return AsValue(fmt.Errorf(`Unable to evaluate %s: %w`, node, item))
 			}
 			if e.Config.StrictUndefined {
-				return AsValue(errors.Errorf(`Unable to evaluate %s: item %d not found`, node, node.Index))
+				return AsValue(undefined(fmt.Errorf(`Unable to evaluate %s: item %d not found`, node, node.Index)))
 			}
-			return AsValue(nil)
+			return undefinedValue()
 		}
 		return item
 	}
@@ -418,7 +516,7 @@ func (e *Evaluator) evalVariable(node *nodes.Variable) (*Value, error) {
 		if idx == 0 {
 			val, ok := e.Environment.Context.Get(node.Parts[0].S)
 			if !ok && e.Config.StrictUndefined {
-				return nil, errors.Errorf(`Unable to evaluate name "%s"`, node.Parts[0].S)
+				return nil, undefined(fmt.Errorf(`Unable to evaluate name "%s"`, node.Parts[0].S))
 			}
 			current = reflect.ValueOf(val) // Get the initial value
 		} else {
@@ -459,7 +557,7 @@ func (e *Evaluator) evalVariable(node *nodes.Variable) (*Value, error) {
 							return AsValue(nil), nil
 						}
 					default:
-						return nil, errors.Errorf("Can't access an index on type %s (variable %s)",
+						return nil, fmt.Errorf("Can't access an index on type %s (variable %s)",
 							current.Kind().String(), node.String())
 					}
 				case nodes.VarTypeIdent:
@@ -470,7 +568,7 @@ func (e *Evaluator) evalVariable(node *nodes.Variable) (*Value, error) {
 					case reflect.Map:
 						current = current.MapIndex(reflect.ValueOf(part.S))
 					default:
-						return nil, errors.Errorf("Can't access a field by name on type %s (variable %s)",
+						return nil, fmt.Errorf("Can't access a field by name on type %s (variable %s)",
 							current.Kind().String(), node.String())
 					}
 				default:
@@ -522,7 +620,7 @@ func (e *Evaluator) evalVariable(node *nodes.Variable) (*Value, error) {
 				if e != nil {
 					err, ok := e.(error)
 					if !ok {
-						return nil, errors.Errorf("The second return value is not an error")
+						return nil, fmt.Errorf("The second return value is not an error")
 					}
 					if err != nil {
 						return nil, err
@@ -566,6 +664,9 @@ func (e *Evaluator) evalVarArgs(node *nodes.Call) ([]reflect.Value, error) {
 		if value.IsError() {
 			return nil, value
 		}
+		if value.IsOmitted() {
+			continue
+		}
 		params.KwArgs[key] = value
 	}
 	return []reflect.Value{reflect.ValueOf(params)}, nil
@@ -576,9 +677,23 @@ func (e *Evaluator) evalParams(node *nodes.Call, fn *Value) ([]reflect.Value, er
 
 	args := node.Args
 	t := fn.Val.Type()
-
-	if len(args) != t.NumIn() && !(len(args) >= t.NumIn()-1 && t.IsVariadic()) {
-		return nil, errors.Errorf(
+	// the keyword parameter is given its zero value when a call omits every keyword argument
+	keywords := len(node.Kwargs) > 0 || (keywordParameter(t) && len(args) == t.NumIn()-1)
+
+	if keywords {
+		if !keywordParameter(t) {
+			return nil, fmt.Errorf("function '%s' does not accept keyword arguments", node.Func)
+		}
+		if len(args) != t.NumIn()-1 {
+			return nil, fmt.Errorf(
+				"function input argument count (%d) of '%s' must be equal to the calling argument count (%d) followed by keyword arguments",
+				t.NumIn()-1,
+				node.String(),
+				len(args),
+			)
+		}
+	} else if len(args) != t.NumIn() && !(len(args) >= t.NumIn()-1 && t.IsVariadic()) {
+		return nil, fmt.Errorf(
 			"function input argument count (%d) of '%s' must be equal to the calling argument count (%d)",
 			t.NumIn(),
 			node.String(),
@@ -589,7 +704,7 @@ func (e *Evaluator) evalParams(node *nodes.Call, fn *Value) ([]reflect.Value, er
 	// Output arguments
 	if t.NumOut() != 1 && t.NumOut() != 2 {
 		msg := "'%s' must have exactly 1 or 2 output arguments, the second argument must be of type error"
-		return nil, errors.Errorf(msg, node.String())
+		return nil, fmt.Errorf(msg, node.String())
 	}
 
 	// Evaluate all parameters
@@ -618,7 +733,7 @@ func (e *Evaluator) evalParams(node *nodes.Call, fn *Value) ([]reflect.Value, er
 		if functionArgument != typeOfValuePtr {
 			// Function's argument is not a *gonja.Value, then we have to check whether input argument is of the same type as the function's argument
 			if !isVariadic && functionArgument != reflect.TypeOf(evaluatedArgument.Interface()) && functionArgument.Kind() != reflect.Interface {
-				return nil, errors.Errorf(
+				return nil, fmt.Errorf(
 					"function input argument %d of '%s' must be of type %s or *gonja.Value (not %T)",
 					index,
 					node.String(),
@@ -626,7 +741,7 @@ func (e *Evaluator) evalParams(node *nodes.Call, fn *Value) ([]reflect.Value, er
 					evaluatedArgument.Interface(),
 				)
 			} else if functionArgument != reflect.TypeOf(evaluatedArgument.Interface()) && functionArgument.Kind() != reflect.Interface {
-				return nil, errors.Errorf(
+				return nil, fmt.Errorf(
 					"function variadic input argument of '%s' must be of type %s or *gonja.Value (not %T)",
 					node.String(),
 					functionArgument.String(),
@@ -639,10 +754,29 @@ func (e *Evaluator) evalParams(node *nodes.Call, fn *Value) ([]reflect.Value, er
 		}
 	}
 
+	if keywords {
+		kwargs := map[string]*Value{}
+		for key, param := range node.Kwargs {
+			value := e.Eval(param)
+			if value.IsError() {
+				return nil, value
+			}
+			if value.IsOmitted() {
+				continue
+			}
+			kwargs[key] = value
+		}
+		parameter, err := decodeKeywordArguments(kwargs, t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid keyword arguments for '%s': %w", node.Func, err)
+		}
+		parameters = append(parameters, parameter)
+	}
+
 	// Check if any of the values are invalid
 	for _, p := range parameters {
 		if p.Kind() == reflect.Invalid {
-			return nil, errors.Errorf("calling a function using an invalid parameter")
+			return nil, fmt.Errorf("calling a function using an invalid parameter")
 		}
 	}
 
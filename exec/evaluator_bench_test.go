@@ -0,0 +1,29 @@
+package exec_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+)
+
+func BenchmarkEvaluateExpressions(b *testing.B) {
+	template, err := gonja.FromString(`{% for item in items %}{% if item.value > 10 and item.name != "x" %}{{ loop.index }}:{{ item.name | upper }}-{{ item.value * 2 + 1 }}{% endif %}{% endfor %}`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	items := make([]map[string]interface{}, 200)
+	for index := range items {
+		items[index] = map[string]interface{}{"name": "abc", "value": index}
+	}
+	data := exec.NewContext(map[string]interface{}{"items": items})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := template.Execute(io.Discard, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
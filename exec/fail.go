@@ -0,0 +1,82 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nikolalohinski/gonja/v2/nodes"
+)
+
+// FailError is the error of the renders stopped by a template itself, through the assert statement or the
+// fail global function. It is returned wrapped into the errors of the statements and expressions holding
+// it, and can be retrieved with errors.As.
+type FailError struct {
+	// Message is the message given by the template
+	Message string
+	// Identifier, Line and Col locate the statement or call which stopped the render
+	Identifier string
+	Line       int
+	Col        int
+}
+
+func (e *FailError) Error() string {
+	if e.Identifier == "" {
+		return fmt.Sprintf("%s (line %d, col %d)", e.Message, e.Line, e.Col)
+	}
+	return fmt.Sprintf("%s (%s, line %d, col %d)", e.Message, e.Identifier, e.Line, e.Col)
+}
+
+// Fail returns the error stopping the render with the given message at the given node, see FailError
+func (r *Renderer) Fail(node nodes.Node, message string) error {
+	failure := &FailError{Message: message}
+	r.locate(failure, node)
+	return failure
+}
+
+// AbortError is the error of the renders aborted by a template with the abort statement. It carries a code,
+// such as an HTTP status code, so that web frameworks embedding templates can turn it into the matching
+// response. Like FailError, it is returned wrapped into the errors of the statements holding it, and can be
+// retrieved with errors.As.
+type AbortError struct {
+	// Code and Message are the ones given by the template
+	Code    int
+	Message string
+	// Identifier, Line and Col locate the statement which aborted the render
+	Identifier string
+	Line       int
+	Col        int
+}
+
+func (e *AbortError) Error() string {
+	return fmt.Sprintf("aborted with code %d: %s (%s, line %d, col %d)", e.Code, e.Message, e.Identifier, e.Line, e.Col)
+}
+
+// Abort returns the error aborting the render with the given code and message at the given node, see AbortError
+func (r *Renderer) Abort(node nodes.Node, code int, message string) error {
+	abort := &AbortError{Code: code, Message: message, Identifier: r.identifierOf(node)}
+	if position := node.Position(); position != nil {
+		abort.Line, abort.Col = position.Line, position.Col
+	}
+	return abort
+}
+
+// locate completes the location of a FailError held by the given error with the one of the given node,
+// and returns it if any
+func locate(err error, node nodes.Node) *FailError {
+	var failure *FailError
+	if !errors.As(err, &failure) {
+		return nil
+	}
+	if failure.Line == 0 && node.Position() != nil {
+		failure.Line, failure.Col = node.Position().Line, node.Position().Col
+	}
+	return failure
+}
+
+// locate completes the location of a FailError held by the given error with the one of the given node,
+// including the identifier of its template
+func (r *Renderer) locate(err error, node nodes.Node) {
+	if failure := locate(err, node); failure != nil && failure.Identifier == "" {
+		failure.Identifier = r.identifierOf(node)
+	}
+}
@@ -0,0 +1,44 @@
+package exec
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+)
+
+// Fingerprint returns a SHA-256 hash, hex encoded, of the template source, of its configuration and of the
+// identifiers and sources of all the templates it statically depends on, see Dependencies. It changes whenever
+// one of them changes, and can be used to build cache keys for rendered content or to tell whether generated
+// files need to be generated again. Templates referenced through variables are not taken into account.
+func (t *Template) Fingerprint() (string, error) {
+	t = t.current()
+	graph, err := t.Dependencies()
+	if err != nil {
+		return "", err
+	}
+	identifiers := make([]string, 0, len(graph.templates))
+	for identifier := range graph.templates {
+		if identifier != graph.Root {
+			identifiers = append(identifiers, identifier)
+		}
+	}
+	sort.Strings(identifiers)
+
+	digest := sha256.New()
+	writeFingerprintField(digest, fmt.Sprintf("%+v", *t.config))
+	writeFingerprintField(digest, t.source)
+	for _, identifier := range identifiers {
+		writeFingerprintField(digest, identifier)
+		writeFingerprintField(digest, graph.templates[identifier].Source)
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// writeFingerprintField writes a length prefixed value, so that consecutive values cannot be confused
+func writeFingerprintField(digest hash.Hash, value string) {
+	binary.Write(digest, binary.BigEndian, uint64(len(value)))
+	digest.Write([]byte(value))
+}
@@ -0,0 +1,76 @@
+package exec_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2/builtins"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("template fingerprint", func() {
+	var (
+		sources = new(map[string]string)
+		cfg     = new(*config.Config)
+	)
+	fingerprint := func() string {
+		environment := &exec.Environment{
+			Filters:           builtins.Filters,
+			Tests:             builtins.Tests,
+			ControlStructures: builtins.ControlStructures,
+			Methods:           builtins.Methods,
+			Context:           exec.EmptyContext(),
+		}
+		template, err := exec.NewTemplate("/root", *cfg, loaders.MustNewMemoryLoader(*sources), environment)
+		Expect(err).To(BeNil())
+		fingerprint, err := template.Fingerprint()
+		Expect(err).To(BeNil())
+		return fingerprint
+	}
+	BeforeEach(func() {
+		*sources = map[string]string{
+			"/root":      `{% extends "/base" %}{% block content %}{% include "/partial" %}{% endblock %}`,
+			"/base":      `<main>{% block content %}{% endblock %}</main>`,
+			"/partial":   `partial`,
+			"/unrelated": `unrelated`,
+		}
+		*cfg = config.New()
+	})
+	It("should be stable", func() {
+		Expect(fingerprint()).To(HaveLen(64))
+		Expect(fingerprint()).To(Equal(fingerprint()))
+	})
+	It("should ignore templates the template does not depend on", func() {
+		before := fingerprint()
+		(*sources)["/unrelated"] = "changed"
+		Expect(fingerprint()).To(Equal(before))
+	})
+	It("should change with the template", func() {
+		before := fingerprint()
+		(*sources)["/root"] = `{% extends "/base" %}{% block content %}{% include "/partial" %}!{% endblock %}`
+		Expect(fingerprint()).ToNot(Equal(before))
+	})
+	It("should change with the parent templates", func() {
+		before := fingerprint()
+		(*sources)["/base"] = `<div>{% block content %}{% endblock %}</div>`
+		Expect(fingerprint()).ToNot(Equal(before))
+	})
+	It("should change with the referenced templates", func() {
+		before := fingerprint()
+		(*sources)["/partial"] = "changed"
+		Expect(fingerprint()).ToNot(Equal(before))
+	})
+	It("should change once a missing template ignored by an include statement is created", func() {
+		(*sources)["/partial"] = `{% include "/optional" ignore missing %}`
+		before := fingerprint()
+		(*sources)["/optional"] = "optional"
+		Expect(fingerprint()).NotTo(Equal(before))
+	})
+	It("should change with the configuration", func() {
+		before := fingerprint()
+		(*cfg).AutoEscape = true
+		Expect(fingerprint()).ToNot(Equal(before))
+	})
+})
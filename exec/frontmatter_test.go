@@ -0,0 +1,174 @@
+package exec_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("front-matter", func() {
+	var (
+		templates     = new(map[string]string)
+		configuration = new(*config.Config)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*templates = map[string]string{}
+		*configuration = config.New()
+		(*configuration).FrontMatter = true
+	})
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(*templates)
+		t, err := exec.NewTemplate("/test", *configuration, loader, gonja.DefaultEnvironment)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(exec.NewContext(map[string]interface{}{"html": "<b>"}))
+	})
+	Context("when the template starts with a comment header", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = "{# gonja: autoescape=true, block_start_string=\"<%\", block_end_string=\"%>\" #}<% if true %>{{ html }}<% endif %>"
+		})
+		It("should override the configuration of the template", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("&lt;b&gt;"))
+		})
+	})
+	Context("when the template starts with a YAML front-matter", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = "---\ntitle: Welcome\ngonja:\n  trim_blocks: true\n  strict_undefined: true\n---\n{% if true %}\n{{ html }}{% endif %}\n{{ missing }}"
+		})
+		It("should override the configuration and keep the lines of the template", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("Unable to render expression at line 9")))
+		})
+		Context("when front-matters are disabled", func() {
+			BeforeEach(func() {
+				(*configuration).FrontMatter = false
+				(*templates)["/test"] = "---\nkind: Service\n---\n{{ html }}"
+			})
+			It("should render it as any other content", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect(*returnedResult).To(Equal("---\nkind: Service\n---\n<b>"))
+			})
+		})
+	})
+	Context("when the template includes another one", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = "{# gonja: autoescape=true #}{{ html }}{% include '/partial' %}"
+			(*templates)["/partial"] = "{# gonja: autoescape=false #}{{ html }}"
+		})
+		It("should apply the front-matter of each template to its own content", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("&lt;b&gt;<b>"))
+		})
+	})
+	Context("when the template extends a layout with a YAML front-matter", func() {
+		BeforeEach(func() {
+			(*templates)["/layout"] = "---\ntitle: Layout\n---\n[{% block body %}{% endblock %}]"
+			(*templates)["/test"] = "{% extends '/layout' %}{% block body %}{{ html }}{% endblock %}"
+		})
+		It("should not render the front-matter of the layout", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("[<b>]"))
+		})
+	})
+	Context("when the first comment is not a front-matter", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = "{# autoescape=true #}{{ html }}"
+		})
+		It("should leave the configuration untouched", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("<b>"))
+		})
+	})
+	Context("when the front-matter sets an unknown option", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = "{# gonja: unknown=true #}"
+		})
+		It("should fail", func() {
+			Expect(*returnedErr).To(MatchError("failed to parse template '/test': invalid front-matter: unknown option 'unknown'"))
+		})
+	})
+	Context("when the front-matter sets an option with the wrong type", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = "---\ngonja:\n  autoescape: yes please\n---\n"
+		})
+		It("should fail", func() {
+			Expect(*returnedErr).To(MatchError("failed to parse template '/test': invalid front-matter: option 'autoescape' expects a boolean but got 'yes please'"))
+		})
+	})
+	Context("when the YAML front-matter is not closed", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = "---\ntitle: Welcome\n"
+		})
+		It("should fail", func() {
+			Expect(*returnedErr).To(MatchError("failed to parse template '/test': invalid front-matter: missing closing '---' line"))
+		})
+	})
+})
+
+var _ = Context("metadata", func() {
+	var (
+		source        = new(string)
+		configuration = new(*config.Config)
+
+		returnedMetadata = new(*exec.Metadata)
+		returnedErr      = new(error)
+	)
+	BeforeEach(func() {
+		*configuration = config.New()
+		(*configuration).FrontMatter = true
+	})
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/test": *source})
+		t, err := exec.NewTemplate("/test", *configuration, loader, gonja.DefaultEnvironment)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		*returnedMetadata = t.Metadata()
+	})
+	Context("when the template has a YAML front-matter", func() {
+		BeforeEach(func() {
+			*source = "---\ntitle: Welcome\ndescription: Landing page\nrequired: [user, site]\nlayout: wide\ngonja:\n  autoescape: true\n---\nHello {{ user }}"
+		})
+		It("should expose it", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect((*returnedMetadata).Title).To(Equal("Welcome"))
+			Expect((*returnedMetadata).Description).To(Equal("Landing page"))
+			Expect((*returnedMetadata).Required).To(Equal([]string{"user", "site"}))
+			Expect((*returnedMetadata).Values).To(HaveKeyWithValue("layout", "wide"))
+			Expect((*returnedMetadata).Values).To(HaveKey("gonja"))
+		})
+	})
+	Context("when the template has no front-matter", func() {
+		BeforeEach(func() {
+			*source = "{# gonja: autoescape=true #}Hello"
+		})
+		It("should expose empty metadata", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(**returnedMetadata).To(Equal(exec.Metadata{Required: []string{}, Values: map[string]interface{}{}}))
+		})
+	})
+	Context("when the required variables are not a list of names", func() {
+		BeforeEach(func() {
+			*source = "---\nrequired: user\n---\n"
+		})
+		It("should fail", func() {
+			Expect(*returnedErr).To(MatchError("failed to parse template '/test': invalid front-matter: 'required' must be a list of variable names"))
+		})
+	})
+	Context("when the title is not a string", func() {
+		BeforeEach(func() {
+			*source = "---\ntitle: [a]\n---\n"
+		})
+		It("should fail", func() {
+			Expect(*returnedErr).To(MatchError("failed to parse template '/test': invalid front-matter: 'title' must be a string"))
+		})
+	})
+})
@@ -0,0 +1,209 @@
+package exec
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+var typeOfError = reflect.TypeOf((*error)(nil)).Elem()
+
+// FilterFromFunc turns a function written for text/template or html/template into a filter. As in the
+// pipelines of these engines, the filtered value is given as the last argument of the function, so that
+// {{ name | replace("a", "b") }} calls replace("a", "b", name).
+//
+// Arguments are converted to the types of the parameters of the function when they are numbers, strings or
+// lists, and a non-nil error returned as second value fails the render, as it does with text/template.
+func FilterFromFunc(name string, fn interface{}) (FilterFunction, error) {
+	function, err := validateFunc(name, fn)
+	if err != nil {
+		return nil, err
+	}
+	return func(e *Evaluator, in *Value, params *VarArgs) *Value {
+		if in.IsError() {
+			return in
+		}
+		if len(params.KwArgs) > 0 {
+			return AsValue(ErrInvalidCall(fmt.Errorf(`filter '%s' does not accept keyword arguments`, name)))
+		}
+		return callFunc(name, function, append(append([]*Value{}, params.Args...), in))
+	}, nil
+}
+
+// GlobalFromFunc turns a function written for text/template or html/template into a function which can
+// be defined as a global, converting its arguments and handling its error as FilterFromFunc does. Functions
+// whose last parameter is a struct, a pointer to a struct or a map keyed by strings can also be called with
+// keyword arguments, which are decoded into that parameter as Value.Decode does, or without any to give it
+// its zero value.
+func GlobalFromFunc(name string, fn interface{}) (func(*VarArgs) *Value, error) {
+	function, err := validateFunc(name, fn)
+	if err != nil {
+		return nil, err
+	}
+	t := function.Type()
+	return func(params *VarArgs) *Value {
+		// the keyword parameter is given its zero value when a call omits every keyword argument
+		if len(params.KwArgs) > 0 || (keywordParameter(t) && len(params.Args) == t.NumIn()-1) {
+			if !keywordParameter(t) {
+				return AsValue(ErrInvalidCall(fmt.Errorf(`function '%s' does not accept keyword arguments`, name)))
+			}
+			if len(params.Args) != t.NumIn()-1 {
+				return AsValue(ErrInvalidCall(fmt.Errorf(`function '%s' expects %d arguments before its keyword arguments, got %d`, name, t.NumIn()-1, len(params.Args))))
+			}
+			parameter, err := decodeKeywordArguments(params.KwArgs, t)
+			if err != nil {
+				return AsValue(ErrInvalidCall(fmt.Errorf(`keyword arguments of function '%s': %w`, name, err)))
+			}
+			return callFunc(name, function, append(append([]*Value{}, params.Args...), AsValue(parameter.Interface())))
+		}
+		return callFunc(name, function, params.Args)
+	}, nil
+}
+
+// FiltersFromFuncMap turns the functions of a template.FuncMap into a filter set, see FilterFromFunc
+func FiltersFromFuncMap(funcs map[string]interface{}) (*FilterSet, error) {
+	filters := make(map[string]FilterFunction, len(funcs))
+	for _, name := range sortedFuncNames(funcs) {
+		filter, err := FilterFromFunc(name, funcs[name])
+		if err != nil {
+			return nil, err
+		}
+		filters[name] = filter
+	}
+	return NewFilterSet(filters), nil
+}
+
+// WithFuncMap registers the functions of a template.FuncMap both as filters and as globals, replacing any
+// existing ones with the same names, so that helper libraries written for text/template can be reused.
+// Functions which cannot be called from templates make Build fail.
+func (b *EnvironmentBuilder) WithFuncMap(funcs map[string]interface{}) *EnvironmentBuilder {
+	for _, name := range sortedFuncNames(funcs) {
+		filter, err := FilterFromFunc(name, funcs[name])
+		if err != nil {
+			b.errs = append(b.errs, err)
+			continue
+		}
+		global, _ := GlobalFromFunc(name, funcs[name])
+		b.filters[name] = filter
+		b.globals[name] = global
+	}
+	return b
+}
+
+func sortedFuncNames(funcs map[string]interface{}) []string {
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateFunc checks that a function returns a single value, or a value and an error, as text/template requires
+func validateFunc(name string, fn interface{}) (reflect.Value, error) {
+	function := reflect.ValueOf(fn)
+	if function.Kind() != reflect.Func || function.IsNil() {
+		return reflect.Value{}, fmt.Errorf(`'%s' is not a function`, name)
+	}
+	t := function.Type()
+	switch {
+	case t.NumOut() == 1:
+	case t.NumOut() == 2 && t.Out(1) == typeOfError:
+	default:
+		return reflect.Value{}, fmt.Errorf(`function '%s' must return a single value, or a value and an error`, name)
+	}
+	return function, nil
+}
+
+// callFunc calls a function with converted arguments, turning its error or panic into an error value
+func callFunc(name string, function reflect.Value, args []*Value) (result *Value) {
+	t := function.Type()
+	if len(args) != t.NumIn() && !(t.IsVariadic() && len(args) >= t.NumIn()-1) {
+		expected := fmt.Sprintf("%d", t.NumIn())
+		if t.IsVariadic() {
+			expected = fmt.Sprintf("at least %d", t.NumIn()-1)
+		}
+		return AsValue(ErrInvalidCall(fmt.Errorf(`function '%s' expects %s arguments, got %d`, name, expected, len(args))))
+	}
+	params := make([]reflect.Value, 0, len(args))
+	for index, arg := range args {
+		if arg.IsError() {
+			return arg
+		}
+		parameterType := func() reflect.Type {
+			if t.IsVariadic() && index >= t.NumIn()-1 {
+				return t.In(t.NumIn() - 1).Elem()
+			}
+			return t.In(index)
+		}()
+		param, err := convertArgument(arg, parameterType)
+		if err != nil {
+			return AsValue(ErrInvalidCall(fmt.Errorf(`argument %d of function '%s': %w`, index+1, name, err)))
+		}
+		params = append(params, param)
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = AsValue(fmt.Errorf(`function '%s' panicked: %v`, name, recovered))
+		}
+	}()
+	values := function.Call(params)
+	if len(values) == 2 && !values[1].IsNil() {
+		return AsValue(fmt.Errorf(`function '%s' failed: %w`, name, values[1].Interface().(error)))
+	}
+	if values[0].Type() == typeOfValuePtr {
+		return values[0].Interface().(*Value)
+	}
+	return AsValue(values[0].Interface())
+}
+
+// convertArgument converts a value to the type of a parameter, following the conversions of text/template
+// for numbers and strings and converting lists element by element
+func convertArgument(value *Value, t reflect.Type) (reflect.Value, error) {
+	if t == typeOfValuePtr {
+		return reflect.ValueOf(value), nil
+	}
+	if inner, ok := value.Interface().(*Value); ok {
+		// elements of list literals are values themselves
+		return convertArgument(inner, t)
+	}
+	switch value.Interface().(type) {
+	case ValuesList, *Dict:
+		// lists and dicts built by templates are given as plain go slices and maps
+		if simple := value.ToGoSimpleType(false); simple != nil {
+			if _, failed := simple.(error); !failed {
+				value = AsValue(simple)
+			}
+		}
+	}
+	if !value.Val.IsValid() || value.IsNil() {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf(`None cannot be used as %s`, t)
+	}
+	v := reflect.ValueOf(value.Interface())
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+	switch {
+	case isNumberKind(v.Kind()) && isNumberKind(t.Kind()), v.Kind() == reflect.String && t.Kind() == reflect.String:
+		return v.Convert(t), nil
+	case t.Kind() == reflect.Slice && value.IsList():
+		slice := reflect.MakeSlice(t, 0, value.Len())
+		for index := 0; index < value.Len(); index++ {
+			element, err := convertArgument(value.Index(index), t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			slice = reflect.Append(slice, element)
+		}
+		return slice, nil
+	}
+	return reflect.Value{}, fmt.Errorf(`%s cannot be used as %s`, value.String(), t)
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
@@ -0,0 +1,109 @@
+package exec_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("func map", func() {
+	var (
+		funcs  = new(template.FuncMap)
+		source = new(string)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*funcs = template.FuncMap{
+			"repeat": func(count int, s string) string { return strings.Repeat(s, count) },
+			"join":   func(separator string, items []string) string { return strings.Join(items, separator) },
+			"printf": fmt.Sprintf,
+			"half": func(n float64) (float64, error) {
+				if n < 0 {
+					return 0, errors.New("negative number")
+				}
+				return n / 2, nil
+			},
+			"boom": func(string) string { panic("boom") },
+		}
+	})
+	JustBeforeEach(func() {
+		environment, err := exec.NewEnvironmentBuilder(gonja.DefaultEnvironment).WithFuncMap(*funcs).Build()
+		if *returnedErr = err; err != nil {
+			return
+		}
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/test": *source})
+		t, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, environment)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(nil)
+	})
+	Context("when using the functions as filters", func() {
+		BeforeEach(func() {
+			*source = `{{ "ab" | repeat(3) }}|{{ ["a", "b"] | join("-") }}|{{ 3 | half }}|{{ 7 | printf("%s=%03d", "n") }}`
+		})
+		It("should give the filtered value as last argument", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("ababab|a-b|1.5|n=007"))
+		})
+	})
+	Context("when using the functions as globals", func() {
+		BeforeEach(func() {
+			*source = `{{ repeat(2, "x") }}|{{ join(",", ["a", "b"]) }}|{{ printf("%d%%", 50) }}`
+		})
+		It("should call them with the given arguments", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("xx|a,b|50%"))
+		})
+	})
+	Context("when a function returns an error", func() {
+		BeforeEach(func() {
+			*source = `{{ -1 | half }}`
+		})
+		It("should fail the render", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("function 'half' failed: negative number")))
+		})
+	})
+	Context("when a function panics", func() {
+		BeforeEach(func() {
+			*source = `{{ boom("x") }}`
+		})
+		It("should fail the render", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("function 'boom' panicked: boom")))
+		})
+	})
+	Context("when arguments cannot be converted", func() {
+		BeforeEach(func() {
+			*source = `{{ "x" | repeat("twice") }}`
+		})
+		It("should fail the render", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("argument 1 of function 'repeat': twice cannot be used as int")))
+		})
+	})
+	Context("when the number of arguments is wrong", func() {
+		BeforeEach(func() {
+			*source = `{{ repeat(1) }}`
+		})
+		It("should fail the render", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("function 'repeat' expects 2 arguments, got 1")))
+		})
+	})
+	Context("when a function cannot be called from templates", func() {
+		BeforeEach(func() {
+			(*funcs)["pair"] = func() (int, int) { return 1, 2 }
+		})
+		It("should fail to build the environment", func() {
+			Expect(*returnedErr).To(MatchError("failed to build environment: function 'pair' must return a single value, or a value and an error"))
+		})
+	})
+})
@@ -0,0 +1,41 @@
+package exec
+
+// Block describes a block of the inheritance chain of a template, see Template.Blocks
+type Block struct {
+	Name string
+	// Provider is the identifier of the template whose definition of the block is rendered, which is the
+	// lowest template of the chain defining it
+	Provider string
+	// Definitions lists the identifiers of the templates defining the block, from the provider to the root
+	// layout. Calling super() within a definition renders the next one.
+	Definitions []string
+}
+
+// Parents returns the resolved identifiers of the templates the template extends, from the one it extends
+// directly to the root layout. It is empty when the template does not extend any other template.
+func (t *Template) Parents() []string {
+	t = t.current()
+	parents := []string{}
+	for parent := t.root.Parent; parent != nil; parent = parent.Parent {
+		parents = append(parents, parent.Identifier)
+	}
+	return parents
+}
+
+// Blocks returns the blocks defined by the template and the templates it extends, keyed by name, telling
+// which template of the inheritance chain provides each of them
+func (t *Template) Blocks() map[string]*Block {
+	t = t.current()
+	blocks := map[string]*Block{}
+	for root := t.root; root != nil; root = root.Parent {
+		for name := range root.Blocks {
+			block, ok := blocks[name]
+			if !ok {
+				block = &Block{Name: name, Provider: root.Identifier}
+				blocks[name] = block
+			}
+			block.Definitions = append(block.Definitions, root.Identifier)
+		}
+	}
+	return blocks
+}
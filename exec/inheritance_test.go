@@ -0,0 +1,66 @@
+package exec_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("template inheritance", func() {
+	var (
+		identifier = new(string)
+		sources    = new(map[string]string)
+
+		template = new(*exec.Template)
+	)
+	BeforeEach(func() {
+		*identifier = "/theme/page"
+		*sources = map[string]string{
+			"/theme/page":   `{% extends "/theme/layout" %}{% block title %}Page{% endblock %}{% block content %}{{ super() }}!{% endblock %}`,
+			"/theme/layout": `{% extends "/base/layout" %}{% block content %}Themed{% endblock %}`,
+			"/base/layout":  `{% block title %}{% endblock %}{% block content %}{% endblock %}{% block footer %}{% endblock %}`,
+		}
+	})
+	JustBeforeEach(func() {
+		var err error
+		*template, err = exec.NewTemplate(*identifier, gonja.DefaultConfig, loaders.MustNewMemoryLoader(*sources), gonja.DefaultEnvironment)
+		Expect(err).To(BeNil())
+	})
+	Context("Parents", func() {
+		It("should return the inheritance chain", func() {
+			Expect((*template).Parents()).To(Equal([]string{"/theme/layout", "/base/layout"}))
+		})
+		Context("when the template does not extend any template", func() {
+			BeforeEach(func() {
+				*identifier = "/base/layout"
+			})
+			It("should return an empty chain", func() {
+				Expect((*template).Parents()).To(BeEmpty())
+			})
+		})
+	})
+	Context("Blocks", func() {
+		It("should tell which template provides each block", func() {
+			Expect((*template).Blocks()).To(Equal(map[string]*exec.Block{
+				"title": {
+					Name:        "title",
+					Provider:    "/theme/page",
+					Definitions: []string{"/theme/page", "/base/layout"},
+				},
+				"content": {
+					Name:        "content",
+					Provider:    "/theme/page",
+					Definitions: []string{"/theme/page", "/theme/layout", "/base/layout"},
+				},
+				"footer": {
+					Name:        "footer",
+					Provider:    "/base/layout",
+					Definitions: []string{"/base/layout"},
+				},
+			}))
+		})
+	})
+})
@@ -0,0 +1,59 @@
+package exec
+
+import (
+	"reflect"
+)
+
+// Iterator returns a function pulling the items of the underlying value one at a time, along with true, when
+// the value is a channel or implements an iterator interface, that is a `Next() (T, bool)` method returning
+// the next item and whether there was one, like the cursors of database drivers often do. The pulling function
+// returns false once the channel is closed or the iterator is exhausted. Maps, slices, arrays and strings are
+// not considered iterators even when they implement such a method, and return false.
+//
+// Loops consume the items of iterators as they are pulled, which lets templates go through streams of data
+// without holding all of their items at once, while they drain channels first. Waiting for the item of a channel stops as soon as done is closed, the pulling
+// function then returning false, while a nil done waits until the channel is closed, see Renderer.Done.
+// Iterators are called as is and can not be interrupted that way.
+func (v *Value) Iterator(done <-chan struct{}) (func() (*Value, bool), bool) {
+	if !v.Val.IsValid() {
+		return nil, false
+	}
+	resolved := v.getResolvedValue()
+	switch resolved.Kind() {
+	case reflect.Chan:
+		if resolved.Type().ChanDir()&reflect.RecvDir == 0 {
+			return nil, false
+		}
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: resolved},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)},
+		}
+		return func() (*Value, bool) {
+			chosen, item, ok := reflect.Select(cases)
+			if chosen != 0 || !ok {
+				return nil, false
+			}
+			return ToValue(item), true
+		}, true
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.String:
+		return nil, false
+	}
+	next := v.Val.MethodByName("Next")
+	if !next.IsValid() && resolved.CanAddr() {
+		next = resolved.Addr().MethodByName("Next")
+	}
+	if !next.IsValid() {
+		return nil, false
+	}
+	t := next.Type()
+	if t.NumIn() != 0 || t.NumOut() != 2 || t.Out(1).Kind() != reflect.Bool {
+		return nil, false
+	}
+	return func() (*Value, bool) {
+		values := next.Call(nil)
+		if !values[1].Bool() {
+			return nil, false
+		}
+		return ToValue(values[0]), true
+	}, true
+}
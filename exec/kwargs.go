@@ -0,0 +1,63 @@
+package exec
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// keywordParameter tells whether a function takes keyword arguments, that is whether its last parameter is
+// a struct, a pointer to a struct or a map keyed by strings. Keyword arguments are then decoded into that
+// parameter as Value.Decode does, so that {{ make_url(path="/x", secure=true) }} can call
+// func(options URLOptions) string.
+func keywordParameter(t reflect.Type) bool {
+	if t.Kind() != reflect.Func || t.NumIn() == 0 || t.IsVariadic() {
+		return false
+	}
+	last := t.In(t.NumIn() - 1)
+	if last.Kind() == reflect.Ptr {
+		last = last.Elem()
+	}
+	switch {
+	case last == typeOfValuePtr.Elem(), last == typeOfTime:
+		return false
+	case last.Kind() == reflect.Struct:
+		return true
+	case last.Kind() == reflect.Map:
+		return last.Key().Kind() == reflect.String
+	}
+	return false
+}
+
+// decodeKeywordArguments decodes the keyword arguments of a call into the last parameter of a function, see
+// keywordParameter. Keyword arguments matching no field of a struct are rejected.
+func decodeKeywordArguments(kwargs map[string]*Value, t reflect.Type) (reflect.Value, error) {
+	parameter := reflect.New(t.In(t.NumIn() - 1)).Elem()
+	out := parameter
+	if out.Kind() == reflect.Ptr {
+		out.Set(reflect.New(out.Type().Elem()))
+		out = out.Elem()
+	}
+	if out.Kind() == reflect.Map {
+		items := make(map[string]interface{}, len(kwargs))
+		for key, value := range kwargs {
+			items[key] = value
+		}
+		return parameter, AsValue(items).decode(out, "")
+	}
+	used := map[string]bool{}
+	if err := decodeStruct(kwargs, out, "", used); err != nil {
+		return reflect.Value{}, err
+	}
+	unexpected := []string{}
+	for key := range kwargs {
+		if !used[key] {
+			unexpected = append(unexpected, key)
+		}
+	}
+	if len(unexpected) > 0 {
+		sort.Strings(unexpected)
+		return reflect.Value{}, fmt.Errorf("unexpected keyword argument '%s'", unexpected[0])
+	}
+	return parameter, nil
+}
@@ -0,0 +1,140 @@
+package exec_test
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type urlOptions struct {
+	Path   string `json:"path"`
+	Secure bool
+	Query  map[string]string
+}
+
+func makeURL(host string, options urlOptions) string {
+	scheme := "http"
+	if options.Secure {
+		scheme = "https"
+	}
+	url := fmt.Sprintf("%s://%s%s", scheme, host, options.Path)
+	if value, ok := options.Query["q"]; ok {
+		url += "?q=" + value
+	}
+	return url
+}
+
+var _ = Context("keyword arguments of go functions", func() {
+	var (
+		source = new(string)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	JustBeforeEach(func() {
+		environment, err := exec.NewEnvironmentBuilder(gonja.DefaultEnvironment).
+			WithGlobal("make_url", makeURL).
+			WithGlobal("tags", func(attributes map[string]interface{}) string {
+				return fmt.Sprintf("%d", len(attributes))
+			}).
+			WithGlobal("upper", strings.ToUpper).
+			WithGlobal("mk", func(options urlOptions) string { return makeURL("example.com", options) }).
+			WithFuncMap(template.FuncMap{
+				"link": func(options *urlOptions) string { return makeURL("example.com", *options) },
+				"g":    func(attributes map[string]interface{}) int { return len(attributes) },
+			}).
+			Build()
+		Expect(err).To(BeNil())
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/test": *source})
+		t, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, environment)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(nil)
+	})
+	Context("when the function takes a struct", func() {
+		BeforeEach(func() {
+			*source = `{{ make_url("example.com", path="/x", secure=true, query={"q": "go"}) }}`
+		})
+		It("should decode the keyword arguments into it", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("https://example.com/x?q=go"))
+		})
+	})
+	Context("when a keyword argument is omitted", func() {
+		BeforeEach(func() {
+			*source = `{{ make_url("example.com", path="/x") }}`
+		})
+		It("should leave its field to its zero value", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("http://example.com/x"))
+		})
+	})
+	Context("when the function takes a map", func() {
+		BeforeEach(func() {
+			*source = `{{ tags(id="main", hidden=true) }}`
+		})
+		It("should give it every keyword argument", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("2"))
+		})
+	})
+	Context("when the function comes from a func map", func() {
+		BeforeEach(func() {
+			*source = `{{ link(path="/y", secure=true) }}`
+		})
+		It("should decode the keyword arguments as well", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("https://example.com/y"))
+		})
+	})
+	Context("when every keyword argument is omitted", func() {
+		BeforeEach(func() {
+			*source = `{{ mk() }} {{ make_url("example.com") }} {{ tags() }}`
+		})
+		It("should give the zero value to the keyword parameter", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("http://example.com http://example.com 0"))
+		})
+	})
+	Context("when every keyword argument of a func map function is omitted", func() {
+		BeforeEach(func() {
+			*source = `{{ link() }} {{ g() }}`
+		})
+		It("should give the zero value to the keyword parameter as well", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("http://example.com 0"))
+		})
+	})
+	Context("when a keyword argument matches no field", func() {
+		BeforeEach(func() {
+			*source = `{{ make_url("example.com", pth="/x") }}`
+		})
+		It("should fail", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("unexpected keyword argument 'pth'")))
+		})
+	})
+	Context("when a keyword argument has the wrong type", func() {
+		BeforeEach(func() {
+			*source = `{{ make_url("example.com", secure="yes") }}`
+		})
+		It("should fail with its name", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("unable to decode 'secure'")))
+		})
+	})
+	Context("when the function does not take keyword arguments", func() {
+		BeforeEach(func() {
+			*source = `{{ upper("a", strict=true) }}`
+		})
+		It("should fail", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("function 'upper' does not accept keyword arguments")))
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"fmt"
+)
+
+// Metadata holds the YAML front-matter of a template, see config.FrontMatter
+type Metadata struct {
+	// Title is the value of the title key
+	Title string
+	// Description is the value of the description key
+	Description string
+	// Required lists the variables named by the required key, which the template expects to be given
+	Required []string
+	// Values holds all the keys of the front-matter, including the ones above and the configuration
+	// overrides. It is shared by all the callers of Template.Metadata and must not be modified.
+	Values map[string]interface{}
+}
+
+func newMetadata(values map[string]interface{}) (*Metadata, error) {
+	metadata := &Metadata{Values: values, Required: []string{}}
+	for key, field := range map[string]*string{"title": &metadata.Title, "description": &metadata.Description} {
+		if value, ok := values[key]; ok {
+			text, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid front-matter: '%s' must be a string", key)
+			}
+			*field = text
+		}
+	}
+	if value, ok := values["required"]; ok {
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid front-matter: 'required' must be a list of variable names")
+		}
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid front-matter: 'required' must be a list of variable names")
+			}
+			metadata.Required = append(metadata.Required, name)
+		}
+	}
+	return metadata, nil
+}
+
+// Metadata returns the metadata held by the YAML front-matter of the template, which is empty when the
+// template has none or when front-matters are disabled by the configuration
+func (t *Template) Metadata() *Metadata {
+	t = t.current()
+	return t.metadata
+}
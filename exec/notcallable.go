@@ -0,0 +1,65 @@
+package exec
+
+import (
+	"fmt"
+
+	"github.com/nikolalohinski/gonja/v2/nodes"
+)
+
+// NotCallablePolicy controls what happens when a template calls something which is not a function, such as
+// an undefined variable in `{{ not_a_func() }}` or an unknown method of a value
+type NotCallablePolicy int
+
+const (
+	// NotCallableFails fails the render with a NotCallableError, which is the default
+	NotCallableFails NotCallablePolicy = iota
+	// NotCallableRendersEmpty evaluates the call to an undefined value, rendered as an empty string
+	NotCallableRendersEmpty
+	// NotCallableCallsHook evaluates the call with the NotCallableHook of the environment, and fails like
+	// NotCallableFails when the environment has none
+	NotCallableCallsHook
+)
+
+// NotCallableFunc is called in place of the functions and methods which can not be called under the
+// NotCallableCallsHook policy. It receives the name of the call, such as "not_a_func" or "user.greet",
+// and its evaluated arguments, and returns the result of the call.
+type NotCallableFunc func(name string, args *VarArgs) (interface{}, error)
+
+// NotCallableError is the error of the calls to something which is not a function under the
+// NotCallableFails policy. It can be retrieved with errors.As.
+type NotCallableError struct {
+	// Name is the name of the call, such as "not_a_func" or "user.greet"
+	Name string
+	// Line and Col locate the call site
+	Line int
+	Col  int
+	// Err tells why the call failed
+	Err error
+}
+
+func (e *NotCallableError) Error() string {
+	return fmt.Sprintf("%s (line %d, col %d)", e.Err, e.Line, e.Col)
+}
+
+func (e *NotCallableError) Unwrap() error { return e.Err }
+
+// notCallable evaluates the call of the given name at the given node according to the policy of the environment
+func (e *Evaluator) notCallable(node nodes.Node, name string, args *VarArgs, cause error) *Value {
+	switch e.Environment.NotCallable {
+	case NotCallableRendersEmpty:
+		return undefinedValue()
+	case NotCallableCallsHook:
+		if e.Environment.NotCallableHook != nil {
+			result, err := e.Environment.NotCallableHook(name, args)
+			if err != nil {
+				return AsValue(fmt.Errorf("unable to call '%s': %w", name, err))
+			}
+			return AsValue(result)
+		}
+	}
+	failure := &NotCallableError{Name: name, Err: cause}
+	if position := node.Position(); position != nil {
+		failure.Line, failure.Col = position.Line, position.Col
+	}
+	return AsValue(failure)
+}
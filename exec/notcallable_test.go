@@ -0,0 +1,103 @@
+package exec_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("calls to something which is not a function", func() {
+	var (
+		source  = new(string)
+		builder = new(*exec.EnvironmentBuilder)
+
+		returnedErr    = new(error)
+		returnedResult = new(string)
+	)
+	BeforeEach(func() {
+		*builder = exec.NewEnvironmentBuilder(gonja.DefaultEnvironment)
+	})
+	JustBeforeEach(func() {
+		environment, err := (*builder).Build()
+		Expect(err).To(BeNil())
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/test": *source})
+		t, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, environment)
+		Expect(err).To(BeNil())
+		*returnedResult, *returnedErr = t.ExecuteToString(exec.NewContext(map[string]interface{}{"name": "ada"}))
+	})
+	Context("when calling a missing function", func() {
+		BeforeEach(func() {
+			*source = "a\n{{ not_a_func(1) }}b"
+		})
+		Context("with the default policy", func() {
+			It("should fail at the call site", func() {
+				var notCallable *exec.NotCallableError
+				Expect(errors.As(*returnedErr, &notCallable)).To(BeTrue())
+				Expect(notCallable.Name).To(Equal("not_a_func"))
+				Expect(notCallable.Line).To(Equal(2))
+				Expect(notCallable.Col).To(Equal(4))
+				Expect(*returnedErr).To(MatchError(ContainSubstring("not_a_func is not callable (line 2, col 4)")))
+			})
+		})
+		Context("with the policy rendering empty", func() {
+			BeforeEach(func() {
+				(*builder).WithNotCallablePolicy(exec.NotCallableRendersEmpty)
+			})
+			It("should render nothing", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect(*returnedResult).To(Equal("a\nb"))
+			})
+		})
+		Context("with a hook", func() {
+			BeforeEach(func() {
+				(*builder).WithNotCallableHook(func(name string, args *exec.VarArgs) (interface{}, error) {
+					return fmt.Sprintf("<%s(%d)>", name, args.First().Integer()), nil
+				})
+			})
+			It("should render the result of the hook", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect(*returnedResult).To(Equal("a\n<not_a_func(1)>b"))
+			})
+		})
+		Context("with a hook returning an error", func() {
+			BeforeEach(func() {
+				(*builder).WithNotCallableHook(func(name string, args *exec.VarArgs) (interface{}, error) {
+					return nil, errors.New("no such function")
+				})
+			})
+			It("should fail with it", func() {
+				Expect(*returnedErr).To(MatchError(ContainSubstring("unable to call 'not_a_func': no such function")))
+			})
+		})
+	})
+	Context("when calling an unknown method", func() {
+		BeforeEach(func() {
+			*source = "{{ name.shout() }}"
+		})
+		Context("with the default policy", func() {
+			It("should fail at the call site", func() {
+				var notCallable *exec.NotCallableError
+				Expect(errors.As(*returnedErr, &notCallable)).To(BeTrue())
+				Expect(notCallable.Name).To(Equal("name.shout"))
+				Expect(*returnedErr).To(MatchError(ContainSubstring("unknown method 'shout' for 'ada'")))
+			})
+		})
+		Context("with a hook", func() {
+			BeforeEach(func() {
+				(*builder).WithNotCallableHook(func(name string, args *exec.VarArgs) (interface{}, error) {
+					return name, nil
+				})
+			})
+			It("should render the result of the hook", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect(*returnedResult).To(Equal("name.shout"))
+			})
+		})
+	})
+})
@@ -0,0 +1,24 @@
+package exec
+
+// omitted is the type of Omit
+type omitted struct{}
+
+// String renders the placeholder as nothing
+func (omitted) String() string {
+	return ""
+}
+
+// Omit is the placeholder which templates use, as the omit global variable, to drop an entry entirely:
+// list items, dictionary pairs and keyword arguments evaluating to it are left out, so that
+// {{ {"port": port | default(omit)} | tojson }} renders {} when port is undefined. It renders as an
+// empty string everywhere else.
+var Omit = omitted{}
+
+// IsOmitted tells whether the value is the Omit placeholder
+func (v *Value) IsOmitted() bool {
+	if v == nil || !v.Val.IsValid() || !v.Val.CanInterface() {
+		return false
+	}
+	_, ok := v.Val.Interface().(omitted)
+	return ok
+}
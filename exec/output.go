@@ -0,0 +1,65 @@
+package exec
+
+import (
+	"io"
+
+	"github.com/nikolalohinski/gonja/v2/nodes"
+)
+
+// Output is the sink the renderer writes content to. Control structures capturing the rendered
+// content of their body, such as `filter` blocks or macros, replace the output of a sub renderer
+// with their own sink, usually a *strings.Builder.
+type Output interface {
+	io.Writer
+	io.StringWriter
+}
+
+// Flusher can be implemented by outputs buffering content, in which case Flush is called
+// once a template has been fully executed
+type Flusher interface {
+	Flush() error
+}
+
+// NewOutput returns the writer as an Output, wrapping it if it does not support writing strings directly
+func NewOutput(wr io.Writer) Output {
+	if output, ok := wr.(Output); ok {
+		return output
+	}
+	return &writerOutput{Writer: wr}
+}
+
+type writerOutput struct {
+	io.Writer
+}
+
+func (w *writerOutput) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush flushes the wrapped writer if it supports it
+func (w *writerOutput) Flush() error {
+	if flusher, ok := w.Writer.(Flusher); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// OutputThrough returns the given output, typically rewriting the content written to it before writing it to
+// the output of the renderer, so that it keeps attributing the content to the nodes producing it when the
+// renderer records a source map or segments, see ExecuteWithSourceMap and ExecuteToSegments
+func (r *Renderer) OutputThrough(output Output) Output {
+	if marker := r.marker(); marker != nil {
+		return &markedOutput{Output: output, marker: marker}
+	}
+	return output
+}
+
+// markedOutput forwards the attributions of the content written to it to the output it eventually writes to
+type markedOutput struct {
+	Output
+	marker markingOutput
+}
+
+func (o *markedOutput) mark(template *nodes.Template, node nodes.Node, line int, multiline bool) {
+	o.marker.mark(template, node, line, multiline)
+}
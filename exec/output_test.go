@@ -0,0 +1,101 @@
+package exec_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/parser"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type writerOnly struct {
+	buffer bytes.Buffer
+}
+
+func (w *writerOnly) Write(p []byte) (int, error) {
+	return w.buffer.Write(p)
+}
+
+type failingFlusher struct {
+	bytes.Buffer
+}
+
+func (f *failingFlusher) Flush() error {
+	return errors.New("broken pipe")
+}
+
+// chunkRecorder records the content written between its flushes, as an http.Flusher would send it
+type chunkRecorder struct {
+	pending bytes.Buffer
+	chunks  []string
+}
+
+func (c *chunkRecorder) Write(p []byte) (int, error) {
+	return c.pending.Write(p)
+}
+
+func (c *chunkRecorder) Flush() {
+	c.chunks = append(c.chunks, c.pending.String())
+	c.pending.Reset()
+}
+
+var _ = Context("output", func() {
+	var (
+		template = new(*exec.Template)
+	)
+	BeforeEach(func() {
+		environment := &exec.Environment{
+			Filters:           exec.NewFilterSet(map[string]exec.FilterFunction{}),
+			Tests:             exec.NewTestSet(map[string]exec.TestFunction{}),
+			ControlStructures: exec.NewControlStructureSet(map[string]parser.ControlStructureParser{}),
+			Context:           exec.EmptyContext(),
+		}
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/test": "Hello {{ name }}!"})
+		var err error
+		*template, err = exec.NewTemplate("/test", config.New(), loader, environment)
+		Expect(err).To(BeNil())
+	})
+	It("should keep writers supporting strings as is", func() {
+		buffer := new(bytes.Buffer)
+		Expect(exec.NewOutput(buffer)).To(BeIdenticalTo(buffer))
+	})
+	It("should support writers that do not write strings directly", func() {
+		out := new(writerOnly)
+		Expect((*template).Render(out, map[string]interface{}{"name": "world"})).To(Succeed())
+		Expect(out.buffer.String()).To(Equal("Hello world!"))
+	})
+	It("should flush buffered writers once the template is executed", func() {
+		out := new(bytes.Buffer)
+		buffered := bufio.NewWriter(out)
+		Expect((*template).Render(buffered, map[string]interface{}{"name": "world"})).To(Succeed())
+		Expect(out.String()).To(Equal("Hello world!"))
+	})
+	It("should return flushing errors", func() {
+		Expect((*template).Render(new(failingFlusher), nil)).To(MatchError("unable to flush template output: broken pipe"))
+	})
+	Context("when streaming", func() {
+		It("should flush the writer after every chunk", func() {
+			out := new(chunkRecorder)
+			data := exec.NewContext(map[string]interface{}{"name": strings.Repeat("a", 20)})
+			Expect((*template).Stream(out, data, 8)).To(Succeed())
+			Expect(strings.Join(out.chunks, "")).To(Equal("Hello " + strings.Repeat("a", 20) + "!"))
+			Expect(out.chunks).To(HaveExactElements("Hello aa", "aaaaaaaa", "aaaaaaaa", "aa!"))
+			Expect(out.pending.Len()).To(BeZero())
+		})
+		It("should buffer small writes until a chunk is full", func() {
+			out := new(chunkRecorder)
+			Expect((*template).Stream(out, exec.NewContext(map[string]interface{}{"name": "world"}), 0)).To(Succeed())
+			Expect(out.chunks).To(HaveExactElements("Hello world!"))
+		})
+		It("should return flushing errors", func() {
+			Expect((*template).Stream(new(failingFlusher), nil, 4)).To(MatchError(ContainSubstring("broken pipe")))
+		})
+	})
+})
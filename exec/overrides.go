@@ -0,0 +1,72 @@
+package exec
+
+// BlockOverride renders the content injected in place of a block, see Context.OverrideBlock. The renderer
+// writes to the output of the block and holds its context, and super renders the content given to the block
+// by the templates, returning the error stopping the render of that content if any.
+type BlockOverride func(r *Renderer, super func() (string, error)) error
+
+// BlockFunc returns a block override writing the content returned by the function as is, without escaping
+// it. The function can call super to wrap or extend the content given to the block by the templates, and
+// should return the errors of super to stop the render with them.
+func BlockFunc(fn func(super func() (string, error)) (string, error)) BlockOverride {
+	return func(r *Renderer, super func() (string, error)) error {
+		content, err := fn(super)
+		if err != nil {
+			return err
+		}
+		return r.WriteStatic(content)
+	}
+}
+
+// BlockTemplate returns a block override rendering the template with the context of the block, where
+// super() renders the content given to the block by the templates
+func BlockTemplate(template *Template) BlockOverride {
+	return func(r *Renderer, super func() (string, error)) error {
+		sub := r.Inherit()
+		sub.Environment.Context.Set("super", super)
+		return NewRenderer(sub.Environment, sub.Output, sub.Config, template.loader, template).Execute()
+	}
+}
+
+// OverrideBlock injects content in place of the block with the given name in the templates executed with
+// this context, or with contexts inheriting from it, whatever the templates defining the block. This lets
+// the host application inject content such as a maintenance banner without editing template files.
+func (ctx *Context) OverrideBlock(name string, override BlockOverride) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	if ctx.overrides == nil {
+		ctx.overrides = map[string]BlockOverride{}
+	}
+	ctx.overrides[name] = override
+}
+
+// blockOverrides returns the block overrides set on this context and its parents, the closest taking precedence
+func (ctx *Context) blockOverrides() map[string]BlockOverride {
+	var overrides map[string]BlockOverride
+	if ctx.parent != nil {
+		overrides = ctx.parent.blockOverrides()
+	}
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	for name, override := range ctx.overrides {
+		if overrides == nil {
+			overrides = map[string]BlockOverride{}
+		}
+		overrides[name] = override
+	}
+	return overrides
+}
+
+// BlockOverride returns the override of the block with the given name set on the context of the render, if
+// any. It is meant to be called by the control structures rendering blocks.
+func (r *Renderer) BlockOverride(name string) BlockOverride {
+	for ctx := r.Environment.Context; ctx != nil; ctx = ctx.parent {
+		ctx.lock.Lock()
+		override := ctx.overrides[name]
+		ctx.lock.Unlock()
+		if override != nil {
+			return override
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package exec_test
+
+import (
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("block overrides", func() {
+	var (
+		loader = new(loaders.Loader)
+		data   = new(*exec.Context)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*loader = loaders.MustNewMemoryLoader(map[string]string{
+			"/layout": `<body>{% block banner %}{% endblock %}{% block content %}layout{% endblock %}</body>`,
+			"/page":   `{% extends "/layout" %}{% block content %}{{ name }}{% endblock %}`,
+			"/banner": `<p>{{ name }}: {{ super() }}</p>`,
+		})
+		*data = exec.NewContext(map[string]interface{}{"name": "ada"})
+	})
+	JustBeforeEach(func() {
+		t, err := exec.NewTemplate("/page", gonja.DefaultConfig, *loader, gonja.DefaultEnvironment)
+		Expect(err).To(BeNil())
+		*returnedResult, *returnedErr = t.ExecuteToString(*data)
+	})
+	Context("when no block is overridden", func() {
+		It("should render the blocks of the templates", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("<body>ada</body>"))
+		})
+	})
+	Context("when a block is overridden by a function", func() {
+		BeforeEach(func() {
+			(*data).OverrideBlock("banner", exec.BlockFunc(func(super func() (string, error)) (string, error) {
+				return `<div class="maintenance">Down at 6pm</div>`, nil
+			}))
+			(*data).OverrideBlock("content", exec.BlockFunc(func(super func() (string, error)) (string, error) {
+				content, err := super()
+				return strings.ToUpper(content), err
+			}))
+		})
+		It("should inject the content returned by the function", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal(`<body><div class="maintenance">Down at 6pm</div>ADA</body>`))
+		})
+	})
+	Context("when a block is overridden by a template", func() {
+		BeforeEach(func() {
+			banner, err := exec.NewTemplate("/banner", gonja.DefaultConfig, *loader, gonja.DefaultEnvironment)
+			Expect(err).To(BeNil())
+			(*data).OverrideBlock("content", exec.BlockTemplate(banner))
+		})
+		It("should render the template with the context of the block", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("<body><p>ada: ada</p></body>"))
+		})
+	})
+	Context("when the content given to the block fails to render", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				"/layout": `<body>{% block content %}{% endblock %}</body>`,
+				"/page":   `{% extends "/layout" %}{% block content %}{{ fail("broken content") }}{% endblock %}`,
+				"/banner": `<p>{{ super() }}</p>`,
+			})
+		})
+		Context("with an override written in Go", func() {
+			BeforeEach(func() {
+				(*data).OverrideBlock("content", exec.BlockFunc(func(super func() (string, error)) (string, error) {
+					content, err := super()
+					return strings.ToUpper(content), err
+				}))
+			})
+			It("should fail with the error of the content", func() {
+				Expect(*returnedErr).To(MatchError(ContainSubstring("broken content")))
+			})
+		})
+		Context("with an override written as a template", func() {
+			BeforeEach(func() {
+				banner, err := exec.NewTemplate("/banner", gonja.DefaultConfig, *loader, gonja.DefaultEnvironment)
+				Expect(err).To(BeNil())
+				(*data).OverrideBlock("content", exec.BlockTemplate(banner))
+			})
+			It("should fail with the error of the content", func() {
+				Expect(*returnedErr).To(MatchError(ContainSubstring("broken content")))
+			})
+		})
+	})
+	Context("when the override is set on a parent context", func() {
+		BeforeEach(func() {
+			parent := exec.EmptyContext()
+			parent.OverrideBlock("banner", exec.BlockFunc(func(super func() (string, error)) (string, error) {
+				return "!", nil
+			}))
+			*data = parent.Inherit()
+			(*data).Set("name", "bob")
+		})
+		It("should apply it as well", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("<body>!bob</body>"))
+		})
+	})
+})
@@ -0,0 +1,36 @@
+package exec
+
+import (
+	"reflect"
+	"sync"
+)
+
+// valuePool recycles the intermediate values of binary expressions: their literal operands and the
+// results of the binary expressions nested in them, which never leave the evaluator. Other values,
+// such as variables, attributes, filter results and the values returned by Eval, are still allocated.
+// On BenchmarkEvaluateExpressions, it saves about 10% of the allocations without a measurable speedup.
+var valuePool = sync.Pool{
+	New: func() interface{} {
+		return new(Value)
+	},
+}
+
+// newValue works like AsValue but takes the value from the pool
+func newValue(i interface{}) *Value {
+	v := valuePool.Get().(*Value)
+	v.Val = reflect.ValueOf(i)
+	v.Safe = false
+	v.pooled = true
+	return v
+}
+
+// releaseValue gives a value back to the pool if it was taken from it
+func releaseValue(v *Value) {
+	if v == nil || !v.pooled {
+		return
+	}
+	v.Val = reflect.Value{}
+	v.Safe = false
+	v.pooled = false
+	valuePool.Put(v)
+}
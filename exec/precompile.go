@@ -0,0 +1,175 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+)
+
+// templateCache holds the templates referenced by a root template, keyed by resolved identifier, as well as
+// the content of the files included as is, see Renderer.LoadStatic
+type templateCache struct {
+	entries sync.Map
+	statics sync.Map
+}
+
+type templateCacheEntry struct {
+	once     sync.Once
+	template *Template
+	content  string
+	err      error
+
+	// versions holds the versions of the template and of the templates it extends, keyed by identifier,
+	// and stale tells whether one of them could not be determined
+	lock     sync.Mutex
+	versions map[string]string
+	stale    bool
+}
+
+// load returns the cached template, calling the load function if it is not cached yet. Concurrent loads of
+// the same identifier wait for a single call to complete, and the returned boolean tells whether the template
+// was loaded by this call. Failed loads are not cached, so they are attempted again next time.
+//
+// When the loader implements loaders.StatLoader, the versions of the cached template and of the templates it
+// extends are compared to the current ones, and the template is loaded again if one of them changed or if
+// it cannot be determined.
+func (c *templateCache) load(identifier string, loader loaders.Loader, load func() (*Template, error)) (*Template, bool, error) {
+	entry := c.entry(&c.entries, identifier, loader)
+	loaded := false
+	entry.once.Do(func() {
+		entry.template, entry.err = load()
+		if entry.template != nil {
+			entry.template.cache = c
+			if statLoader, ok := loader.(loaders.StatLoader); ok {
+				entry.track(statLoader, entry.template.Parents()...)
+			}
+		}
+		loaded = true
+	})
+	if entry.err != nil {
+		c.entries.CompareAndDelete(identifier, entry)
+		return nil, loaded, entry.err
+	}
+	return entry.template, loaded, nil
+}
+
+// entry returns the entry of the identifier, replaced by a fresh one when one of the versions reported by the
+// loader changed or cannot be determined
+func (c *templateCache) entry(entries *sync.Map, identifier string, loader loaders.Loader) *templateCacheEntry {
+	fresh := &templateCacheEntry{}
+	statLoader, ok := loader.(loaders.StatLoader)
+	if ok {
+		fresh.track(statLoader, identifier)
+	}
+	value, cached := entries.LoadOrStore(identifier, fresh)
+	entry := value.(*templateCacheEntry)
+	if cached && ok && !entry.current(statLoader) {
+		entries.Swap(identifier, fresh)
+		entry = fresh
+	}
+	return entry
+}
+
+// track records the current versions of the given templates, which are considered changed when they cannot
+// be determined
+func (e *templateCacheEntry) track(loader loaders.StatLoader, identifiers ...string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.versions == nil {
+		e.versions = map[string]string{}
+	}
+	for _, identifier := range identifiers {
+		version, err := loader.Stat(identifier)
+		e.stale = e.stale || err != nil
+		e.versions[identifier] = version
+	}
+}
+
+// current tells whether none of the versions recorded by the entry changed
+func (e *templateCacheEntry) current(loader loaders.StatLoader) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.stale {
+		return false
+	}
+	for identifier, version := range e.versions {
+		if current, err := loader.Stat(identifier); err != nil || current != version {
+			return false
+		}
+	}
+	return true
+}
+
+// Precompile parses the templates statically referenced by the template and by its parents, such as the
+// ones included or imported with a string literal, as well as the templates they reference themselves.
+// Templates are parsed concurrently and cached, so that renders do not have to parse them anymore.
+// Templates referenced through variables are still parsed during the renders using them, and the missing
+// templates of include statements ignoring them are skipped.
+func (t *Template) Precompile() error {
+	t = t.current()
+	var (
+		wg       sync.WaitGroup
+		lock     sync.Mutex
+		failures []string
+	)
+	var visit func(template *Template)
+	visit = func(template *Template) {
+		for root := template.root; root != nil; root = root.Parent {
+			for _, reference := range root.References {
+				wg.Add(1)
+				go func(root *nodes.Template, reference *nodes.Reference) {
+					defer wg.Done()
+					referenced, loaded, err := template.precompileReference(root, reference.Name)
+					if reference.Optional && errors.Is(err, loaders.ErrTemplateNotFound) {
+						return
+					}
+					if err != nil {
+						lock.Lock()
+						failures = append(failures, err.Error())
+						lock.Unlock()
+						return
+					}
+					if loaded {
+						visit(referenced)
+					}
+				}(root, reference)
+			}
+		}
+	}
+	visit(t)
+	wg.Wait()
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("unable to precompile templates referenced by '%s': %s", t.root.Identifier, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// precompileReference loads a template referenced by the root node of the template or by one of its parents,
+// which resolve the templates they reference relatively to themselves as they do when rendered
+func (t *Template) precompileReference(root *nodes.Template, name string) (*Template, bool, error) {
+	current := t.loader
+	if root != t.root {
+		var err error
+		if current, err = t.loader.Inherit(root.Identifier); err != nil {
+			return nil, false, fmt.Errorf("failed to inherit loader for '%s': %w", root.Identifier, err)
+		}
+	}
+	identifier, err := current.Resolve(name)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to resolve '%s': %w", name, err)
+	}
+	loader, err := current.Inherit(identifier)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to inherit loader for '%s': %w", identifier, err)
+	}
+	return t.cache.load(identifier, loader, func() (*Template, error) {
+		return newTemplate(identifier, t.config, loader, t.environment)
+	})
+}
@@ -0,0 +1,146 @@
+package exec_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2/builtins"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// staticLoader hides the Stat method of the loader it wraps
+type staticLoader struct {
+	loaders.Loader
+}
+
+func (s staticLoader) Inherit(from string) (loaders.Loader, error) {
+	loader, err := s.Loader.Inherit(from)
+	if err != nil {
+		return nil, err
+	}
+	return staticLoader{loader}, nil
+}
+
+var _ = Context("template precompilation", func() {
+	var (
+		sources  = new(map[string]string)
+		loader   = new(loaders.Loader)
+		template = new(*exec.Template)
+
+		returnedErr = new(error)
+	)
+	BeforeEach(func() {
+		*sources = map[string]string{
+			"/root":       `{% extends "/base" %}{% block content %}{% include "/a" %}{% endblock %}`,
+			"/base":       `{% from "/macros" import greet %}{{ greet() }} {% block content %}{% endblock %}`,
+			"/a":          `A{% include "/b" %}{% include name %}`,
+			"/b":          `B{% if false %}{% include "/a" %}{% endif %}`,
+			"/macros":     `{% macro greet() %}Hello{% endmacro %}`,
+			"/c":          `C`,
+			"/unrelated":  `unrelated`,
+			"/not-loaded": `{{ broken`,
+		}
+		*loader = staticLoader{loaders.MustNewMemoryLoader(*sources)}
+	})
+	JustBeforeEach(func() {
+		environment := &exec.Environment{
+			Filters:           builtins.Filters,
+			Tests:             builtins.Tests,
+			ControlStructures: builtins.ControlStructures,
+			Methods:           builtins.Methods,
+			Context:           exec.EmptyContext(),
+		}
+		var err error
+		*template, err = exec.NewTemplate("/root", config.New(), *loader, environment)
+		Expect(err).To(BeNil())
+		*returnedErr = (*template).Precompile()
+	})
+	Context("default", func() {
+		It("should parse the referenced templates ahead of time", func() {
+			By("not returning an error")
+			Expect(*returnedErr).To(BeNil())
+			By("reusing the parsed templates when rendering")
+			(*sources)["/b"] = "changed"
+			(*sources)["/macros"] = "{{ broken"
+			out, err := (*template).RenderToString(map[string]interface{}{"name": "/c"})
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Hello ABC"))
+		})
+	})
+	Context("when the loader tells the version of templates", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(*sources)
+		})
+		It("should parse again the templates that changed", func() {
+			Expect(*returnedErr).To(BeNil())
+			out, err := (*template).RenderToString(map[string]interface{}{"name": "/c"})
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Hello ABC"))
+
+			(*sources)["/b"] = "changed"
+			out, err = (*template).RenderToString(map[string]interface{}{"name": "/c"})
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Hello AchangedC"))
+		})
+	})
+	Context("when a referenced template extends another one", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(*sources)
+			(*sources)["/b"] = `{% extends "/layout" %}{% block content %}B{% endblock %}`
+			(*sources)["/layout"] = `[{% block content %}{% endblock %}]`
+		})
+		It("should parse it again when the template it extends changed", func() {
+			Expect(*returnedErr).To(BeNil())
+			out, err := (*template).RenderToString(map[string]interface{}{"name": "/c"})
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Hello A[B]C"))
+
+			(*sources)["/layout"] = `({% block content %}{% endblock %})`
+			out, err = (*template).RenderToString(map[string]interface{}{"name": "/c"})
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Hello A(B)C"))
+		})
+	})
+	Context("when a template is included through a variable", func() {
+		It("should parse it again on each render", func() {
+			out, err := (*template).RenderToString(map[string]interface{}{"name": "/c"})
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Hello ABC"))
+
+			(*sources)["/c"] = "changed"
+			out, err = (*template).RenderToString(map[string]interface{}{"name": "/c"})
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Hello ABchanged"))
+		})
+	})
+	Context("when an include statement ignores a missing template", func() {
+		BeforeEach(func() {
+			(*sources)["/a"] = `A{% include "/optional" ignore missing %}{% include "/b" %}{% include name %}`
+		})
+		It("should skip it", func() {
+			Expect(*returnedErr).To(BeNil())
+			out, err := (*template).RenderToString(map[string]interface{}{"name": "/c"})
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Hello ABC"))
+		})
+		Context("and the template exists but is invalid", func() {
+			BeforeEach(func() {
+				(*sources)["/optional"] = "{{ broken"
+			})
+			It("should return an error", func() {
+				Expect(*returnedErr).To(MatchError(ContainSubstring("'}}' expected here")))
+			})
+		})
+	})
+	Context("when a referenced template is invalid", func() {
+		BeforeEach(func() {
+			(*sources)["/b"] = "{{ broken"
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("unable to precompile templates referenced by '/root': ")))
+			Expect(*returnedErr).To(MatchError(ContainSubstring("'}}' expected here")))
+		})
+	})
+})
@@ -0,0 +1,209 @@
+package exec
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Redacted replaces the values of sensitive variables, see Context.SetSensitive
+const Redacted = "[REDACTED]"
+
+// minSecretLength is the length under which strings are not redacted, as they could not be told apart
+// from the rest of the messages
+const minSecretLength = 4
+
+// SetSensitive marks names as sensitive, in this context and all the contexts inheriting from it, so that
+// their values, or the strings they hold for lists, dictionaries and structs, are replaced by [REDACTED]
+// in the messages of render errors and in the output of debugging filters such as pprint. Templates can
+// still output them as usual. Strings shorter than four bytes are left as is.
+func (ctx *Context) SetSensitive(names ...string) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	if ctx.sensitive == nil {
+		ctx.sensitive = map[string]bool{}
+	}
+	for _, name := range names {
+		ctx.sensitive[name] = true
+	}
+}
+
+// IsSensitive tells whether the name was marked as sensitive in this context or one of its parents
+func (ctx *Context) IsSensitive(name string) bool {
+	ctx.lock.Lock()
+	sensitive := ctx.sensitive[name]
+	ctx.lock.Unlock()
+	if !sensitive && ctx.parent != nil {
+		return ctx.parent.IsSensitive(name)
+	}
+	return sensitive
+}
+
+// sensitiveNames returns all the names marked as sensitive in this context and its parents
+func (ctx *Context) sensitiveNames() []string {
+	names := []string{}
+	if ctx.parent != nil {
+		names = ctx.parent.sensitiveNames()
+	}
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	for name := range ctx.sensitive {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Redact replaces the values of the sensitive variables visible from this context within a text
+func (ctx *Context) Redact(text string) string {
+	return redact(text, ctx.secrets())
+}
+
+// secrets returns the strings held by sensitive variables, longest first so that a secret containing
+// another one is redacted as a whole
+func (ctx *Context) secrets() []string {
+	var secrets []string
+	for _, name := range ctx.sensitiveNames() {
+		if value, ok := ctx.Get(name); ok {
+			collectSecrets(reflect.ValueOf(value), &secrets, 0)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	return secrets
+}
+
+func collectSecrets(value reflect.Value, secrets *[]string, depth int) {
+	if !value.IsValid() || depth > 32 {
+		return
+	}
+	switch value.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if !value.IsNil() {
+			if v, ok := value.Interface().(*Value); ok {
+				collectSecrets(v.Val, secrets, depth+1)
+				return
+			}
+			collectSecrets(value.Elem(), secrets, depth+1)
+		}
+	case reflect.String:
+		if value.Len() >= minSecretLength {
+			*secrets = append(*secrets, value.String())
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 {
+			if value.Len() >= minSecretLength {
+				*secrets = append(*secrets, string(value.Bytes()))
+			}
+			return
+		}
+		for index := 0; index < value.Len(); index++ {
+			collectSecrets(value.Index(index), secrets, depth+1)
+		}
+	case reflect.Map:
+		iterator := value.MapRange()
+		for iterator.Next() {
+			collectSecrets(iterator.Value(), secrets, depth+1)
+		}
+	case reflect.Struct:
+		for index := 0; index < value.NumField(); index++ {
+			if value.Type().Field(index).IsExported() {
+				collectSecrets(value.Field(index), secrets, depth+1)
+			}
+		}
+	}
+}
+
+func redact(text string, secrets []string) string {
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, Redacted)
+	}
+	return text
+}
+
+// redactedError replaces an error of the chain of a redacted error: its message is redacted and it wraps
+// the redacted copy of the errors it wrapped. It matches the same targets with errors.Is as the original
+// error, without handing it out, since its fields could hold the secrets removed from its message.
+type redactedError struct {
+	original error
+	message  string
+	cause    error
+}
+
+func (e *redactedError) Error() string        { return e.message }
+func (e *redactedError) Unwrap() error        { return e.cause }
+func (e *redactedError) Is(target error) bool { return isError(e.original, target) }
+
+// redactedJoinedErrors is the redactedError of errors wrapping several errors, such as the ones of errors.Join
+type redactedJoinedErrors struct {
+	original error
+	message  string
+	causes   []error
+}
+
+func (e *redactedJoinedErrors) Error() string        { return e.message }
+func (e *redactedJoinedErrors) Unwrap() []error      { return e.causes }
+func (e *redactedJoinedErrors) Is(target error) bool { return isError(e.original, target) }
+
+// isError tells whether the error matches the target itself, without looking at the errors it wraps
+func isError(err error, target error) bool {
+	if reflect.TypeOf(target).Comparable() && err == target {
+		return true
+	}
+	if matcher, ok := err.(interface{ Is(error) bool }); ok {
+		return matcher.Is(target)
+	}
+	return false
+}
+
+// redactError returns a copy of the chain of the error with the values of the sensitive variables of the
+// context redacted from the messages and the fields of its errors, or the error itself if it holds none.
+// The errors of this package keep their types in the copy, so that errors.As still retrieves them.
+func redactError(err error, ctx *Context) error {
+	secrets := ctx.secrets()
+	if len(secrets) == 0 {
+		return err
+	}
+	message := err.Error()
+	if redact(message, secrets) == message {
+		return err
+	}
+	return redactChain(err, secrets)
+}
+
+func redactChain(err error, secrets []string) error {
+	if err == nil {
+		return nil
+	}
+	switch e := err.(type) {
+	case *Error:
+		redacted := *e
+		redacted.Err = redactChain(e.Err, secrets)
+		return &redacted
+	case *FailError:
+		redacted := *e
+		redacted.Message = redact(e.Message, secrets)
+		return &redacted
+	case *AbortError:
+		redacted := *e
+		redacted.Message = redact(e.Message, secrets)
+		return &redacted
+	case *NotCallableError:
+		redacted := *e
+		redacted.Name = redact(e.Name, secrets)
+		redacted.Err = redactChain(e.Err, secrets)
+		return &redacted
+	case interface{ Unwrap() []error }:
+		causes := []error{}
+		for _, cause := range e.Unwrap() {
+			causes = append(causes, redactChain(cause, secrets))
+		}
+		return &redactedJoinedErrors{original: err, message: redact(err.Error(), secrets), causes: causes}
+	}
+	cause := errors.Unwrap(err)
+	message := err.Error()
+	if redacted := redact(message, secrets); cause == nil && redacted == message {
+		// errors holding no secret and wrapping none, such as sentinel errors, are kept as is
+		return err
+	}
+	return &redactedError{original: err, message: redact(message, secrets), cause: redactChain(cause, secrets)}
+}
@@ -0,0 +1,152 @@
+package exec_test
+
+import (
+	"errors"
+	"fmt"
+	"text/template"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var errDenied = errors.New("access denied")
+
+// loginError holds the password it failed with
+type loginError struct {
+	password string
+}
+
+func (e *loginError) Error() string { return "unable to log in with " + e.password }
+func (e *loginError) Unwrap() error { return errDenied }
+
+var _ = Context("sensitive variables", func() {
+	var (
+		environment = new(*exec.Environment)
+		source      = new(string)
+		sensitive   = new([]string)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*sensitive = []string{"password", "db"}
+		*environment = gonja.DefaultEnvironment
+	})
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/test": *source})
+		t, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, *environment)
+		Expect(err).To(BeNil())
+		data := exec.NewContext(map[string]interface{}{
+			"password": "hunter22",
+			"pin":      "123",
+			"db":       map[string]interface{}{"user": "admin", "port": 5432, "tokens": []string{"s3cr3t-token"}},
+		})
+		data.SetSensitive(*sensitive...)
+		*returnedResult, *returnedErr = t.ExecuteToString(data)
+	})
+	Context("when a render fails on a sensitive value", func() {
+		BeforeEach(func() {
+			*source = `{{ range(password) }}`
+		})
+		It("should redact it from the error message", func() {
+			Expect(*returnedErr).To(HaveOccurred())
+			Expect((*returnedErr).Error()).To(ContainSubstring("invalid call to function 'range'"))
+			Expect((*returnedErr).Error()).NotTo(ContainSubstring("hunter22"))
+		})
+	})
+	Context("when a function fails with a sensitive value", func() {
+		BeforeEach(func() {
+			var err error
+			*environment, err = exec.NewEnvironmentBuilder(gonja.DefaultEnvironment).WithFuncMap(template.FuncMap{
+				"login": func(password string) (string, error) { return "", fmt.Errorf("%w for %s", errDenied, password) },
+			}).Build()
+			Expect(err).To(BeNil())
+			*source = `{{ login(password) }}`
+		})
+		It("should redact the message of the error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("function 'login' failed: access denied for [REDACTED]")))
+		})
+		It("should redact the errors it wraps as well", func() {
+			Expect(errors.Is(*returnedErr, errDenied)).To(BeTrue())
+			for err := *returnedErr; err != nil; err = errors.Unwrap(err) {
+				Expect(err.Error()).NotTo(ContainSubstring("hunter22"))
+			}
+		})
+		Context("and the error holds it in its fields", func() {
+			BeforeEach(func() {
+				var err error
+				*environment, err = exec.NewEnvironmentBuilder(gonja.DefaultEnvironment).WithFuncMap(template.FuncMap{
+					"login": func(password string) (string, error) { return "", &loginError{password: password} },
+				}).Build()
+				Expect(err).To(BeNil())
+			})
+			It("should not hand the error out", func() {
+				Expect(*returnedErr).To(MatchError(ContainSubstring("unable to log in with [REDACTED]")))
+				Expect(errors.Is(*returnedErr, errDenied)).To(BeTrue())
+				var login *loginError
+				Expect(errors.As(*returnedErr, &login)).To(BeFalse())
+			})
+		})
+	})
+	Context("when a template fails with a sensitive value", func() {
+		BeforeEach(func() {
+			*source = `{{ fail("wrong password " ~ password) }}`
+		})
+		It("should redact the message of the error it can still be retrieved as", func() {
+			var failure *exec.FailError
+			Expect(errors.As(*returnedErr, &failure)).To(BeTrue())
+			Expect(failure.Message).To(Equal("wrong password [REDACTED]"))
+			var located *exec.Error
+			Expect(errors.As(*returnedErr, &located)).To(BeTrue())
+			Expect(located.Error()).NotTo(ContainSubstring("hunter22"))
+		})
+	})
+	Context("when a sensitive value is shorter than four bytes", func() {
+		BeforeEach(func() {
+			*sensitive = []string{"pin"}
+			*source = `{{ pin.missing() }}`
+		})
+		It("should leave it as is, as it could not be told apart from the rest of the message", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("unknown method 'missing' for '123'")))
+		})
+	})
+	Context("when a sensitive value ends up in an error message through another variable", func() {
+		BeforeEach(func() {
+			*source = `{% set copy = db.tokens[0] %}{{ copy.missing() }}`
+		})
+		It("should redact it as well", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("unknown method 'missing' for '[REDACTED]'")))
+		})
+	})
+	Context("when pretty printing sensitive values", func() {
+		BeforeEach(func() {
+			*source = `{{ db | pprint }}`
+		})
+		It("should redact their strings", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(MatchJSON(`{"user": "[REDACTED]", "port": 5432, "tokens": ["[REDACTED]"]}`))
+		})
+	})
+	Context("when outputting sensitive values", func() {
+		BeforeEach(func() {
+			*source = `{{ password }}`
+		})
+		It("should render them as usual", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("hunter22"))
+		})
+	})
+	Context("when no variable is sensitive", func() {
+		BeforeEach(func() {
+			*sensitive = nil
+			*source = `{{ password.missing() }}`
+		})
+		It("should leave error messages untouched", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("unknown method 'missing' for 'hunter22'")))
+		})
+	})
+})
@@ -0,0 +1,130 @@
+package exec
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+)
+
+// DefaultAutoReloadInterval is how often templates check whether their sources changed when the
+// AutoReloadInterval of their environment is not set
+const DefaultAutoReloadInterval = time.Second
+
+// templateReload tracks the sources of a template created in an environment enabling AutoReload
+type templateReload struct {
+	// lock is held by the render checking the sources, while the other renders use the latest template
+	lock sync.Mutex
+	// identifier, config, loader and environment are the arguments the template was created with
+	identifier  string
+	config      *config.Config
+	loader      loaders.StatLoader
+	environment *Environment
+	interval    time.Duration
+	// versions holds the versions of the template and of the templates it extends, keyed by identifier
+	versions map[string]string
+	// checked holds the time of the last check, in nanoseconds since the Unix epoch
+	checked atomic.Int64
+	latest  atomic.Pointer[reloadedTemplate]
+}
+
+// reloadedTemplate is the outcome of the last reload, whose error is returned until the sources are fixed
+type reloadedTemplate struct {
+	template *Template
+	err      error
+}
+
+// newTemplateReload tracks the sources of the template, which is not reloaded when the loader cannot tell
+// whether templates changed
+func newTemplateReload(identifier string, config *config.Config, loader loaders.Loader, environment *Environment, template *Template) *templateReload {
+	statLoader, ok := loader.(loaders.StatLoader)
+	if !ok {
+		return nil
+	}
+	reload := &templateReload{
+		identifier:  identifier,
+		config:      config,
+		loader:      statLoader,
+		environment: environment,
+		interval:    environment.AutoReloadInterval,
+	}
+	if reload.interval <= 0 {
+		reload.interval = DefaultAutoReloadInterval
+	}
+	reload.versions = reload.versionsOf(template)
+	reload.checked.Store(time.Now().UnixNano())
+	reload.latest.Store(&reloadedTemplate{template: template})
+	return reload
+}
+
+// versionsOf returns the current versions of the template and of the templates it extends
+func (r *templateReload) versionsOf(template *Template) map[string]string {
+	versions := map[string]string{}
+	for root := template.root; root != nil; root = root.Parent {
+		versions[root.Identifier], _ = r.loader.Stat(root.Identifier)
+	}
+	return versions
+}
+
+// check returns the latest version of the template, parsed again if any of its sources changed since. The
+// sources are checked at most once per interval, by a single render which does not block the others.
+func (r *templateReload) check() (*Template, error) {
+	now := time.Now().UnixNano()
+	if now-r.checked.Load() < int64(r.interval) || !r.lock.TryLock() {
+		return r.current()
+	}
+	defer r.lock.Unlock()
+	r.checked.Store(now)
+
+	// templates failing to reload are parsed again until fixed, even when reverted to their last version
+	changed := r.latest.Load().err != nil
+	for identifier, version := range r.versions {
+		// sources which can not be checked are considered unchanged, rather than parsed again at every check
+		if current, err := r.loader.Stat(identifier); err == nil && current != version {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return r.current()
+	}
+	template, err := NewTemplate(r.identifier, r.config, r.loader, r.environment)
+	if err != nil {
+		// the previous template is kept for accessors, and the sources are checked again at the next interval
+		r.latest.Store(&reloadedTemplate{
+			template: r.latest.Load().template,
+			err:      fmt.Errorf("unable to reload template '%s': %w", r.identifier, err),
+		})
+		return r.current()
+	}
+	// the reloaded template keeps the templates it references, which are revalidated when loaded
+	template.reload = nil
+	template.cache = r.latest.Load().template.cache
+	r.versions = r.versionsOf(template)
+	r.latest.Store(&reloadedTemplate{template: template})
+	return template, nil
+}
+
+// current returns the latest template and the error of its last reload, if any
+func (r *templateReload) current() (*Template, error) {
+	latest := r.latest.Load()
+	if latest.err != nil {
+		return nil, latest.err
+	}
+	return latest.template, nil
+}
+
+// current returns the latest version of the template, which is the template itself unless it is reloaded.
+// The last template parsed successfully is returned while an edit fails to parse.
+func (t *Template) current() *Template {
+	if t.reload == nil {
+		return t
+	}
+	if latest, err := t.reload.check(); err == nil {
+		return latest
+	}
+	return t.reload.latest.Load().template
+}
@@ -0,0 +1,132 @@
+package exec_test
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// unstatableLoader fails to tell the version of one of its templates, and counts the reads and the stats of
+// the others
+type unstatableLoader struct {
+	loaders.Loader
+	unstatable string
+	reads      map[string]int
+	stats      int
+}
+
+func (l *unstatableLoader) Read(identifier string) (io.Reader, error) {
+	l.reads[identifier]++
+	return l.Loader.Read(identifier)
+}
+
+func (l *unstatableLoader) Stat(identifier string) (string, error) {
+	l.stats++
+	if identifier == l.unstatable {
+		return "", errors.New("unavailable")
+	}
+	return l.Loader.(loaders.StatLoader).Stat(identifier)
+}
+
+var _ = Context("template auto-reload", func() {
+	var (
+		sources    = new(map[string]string)
+		autoReload = new(bool)
+		interval   = new(time.Duration)
+
+		template = new(*exec.Template)
+	)
+	BeforeEach(func() {
+		*sources = map[string]string{
+			"/page":    `{% extends "/layout" %}{% block content %}{% include "/partial" %}{% endblock %}`,
+			"/layout":  `<main>{% block content %}{% endblock %}</main>`,
+			"/partial": `partial`,
+		}
+		*autoReload = true
+		*interval = time.Nanosecond
+	})
+	JustBeforeEach(func() {
+		environment := gonja.DefaultEnvironment.Overlay()
+		environment.AutoReload = *autoReload
+		environment.AutoReloadInterval = *interval
+		var err error
+		*template, err = exec.NewTemplate("/page", gonja.DefaultConfig, loaders.MustNewMemoryLoader(*sources), environment)
+		Expect(err).To(BeNil())
+		Expect((*template).ExecuteToString(nil)).To(Equal("<main>partial</main>"))
+	})
+	It("should render the edits of the template", func() {
+		(*sources)["/page"] = `{% extends "/layout" %}{% block content %}edited {% include "/partial" %}{% endblock %}`
+		Expect((*template).ExecuteToString(nil)).To(Equal("<main>edited partial</main>"))
+	})
+	It("should render the edits of the templates it extends", func() {
+		(*sources)["/layout"] = `<article>{% block content %}{% endblock %}</article>`
+		Expect((*template).ExecuteToString(nil)).To(Equal("<article>partial</article>"))
+	})
+	It("should render the edits of the templates it includes", func() {
+		(*sources)["/partial"] = `edited`
+		Expect((*template).ExecuteToString(nil)).To(Equal("<main>edited</main>"))
+	})
+	It("should return an error until an invalid edit is fixed", func() {
+		(*sources)["/page"] = `{% extends "/layout" %}{% block content %}`
+		_, err := (*template).ExecuteToString(nil)
+		Expect(err).To(MatchError(ContainSubstring("unable to reload template '/page'")))
+
+		(*sources)["/page"] = `{% extends "/layout" %}{% block content %}fixed{% endblock %}`
+		Expect((*template).ExecuteToString(nil)).To(Equal("<main>fixed</main>"))
+	})
+	It("should use the reloaded template in its accessors", func() {
+		Expect((*template).Parents()).To(Equal([]string{"/layout"}))
+		(*sources)["/page"] = `edited`
+		Expect((*template).Parents()).To(BeEmpty())
+		Expect((*template).Root().Source).To(Equal("edited"))
+	})
+	Context("when the interval has not elapsed since the last check", func() {
+		It("should neither check nor reload the template", func() {
+			environment := gonja.DefaultEnvironment.Overlay()
+			environment.AutoReload = true
+			environment.AutoReloadInterval = time.Hour
+			loader := &unstatableLoader{Loader: loaders.MustNewMemoryLoader(*sources), reads: map[string]int{}}
+			t, err := exec.NewTemplate("/page", gonja.DefaultConfig, loader, environment)
+			Expect(err).To(BeNil())
+			stats := loader.stats
+
+			(*sources)["/page"] = `edited`
+			for i := 0; i < 3; i++ {
+				Expect(t.ExecuteToString(nil)).To(Equal("<main>partial</main>"))
+			}
+			Expect(loader.stats).To(Equal(stats))
+			Expect(loader.reads["/page"]).To(Equal(1))
+		})
+	})
+	Context("when the version of a template can not be retrieved", func() {
+		It("should consider it unchanged", func() {
+			environment := gonja.DefaultEnvironment.Overlay()
+			environment.AutoReload = true
+			environment.AutoReloadInterval = time.Nanosecond
+			loader := &unstatableLoader{Loader: loaders.MustNewMemoryLoader(*sources), unstatable: "/layout", reads: map[string]int{}}
+			t, err := exec.NewTemplate("/page", gonja.DefaultConfig, loader, environment)
+			Expect(err).To(BeNil())
+			for i := 0; i < 3; i++ {
+				Expect(t.ExecuteToString(nil)).To(Equal("<main>partial</main>"))
+			}
+			Expect(loader.reads["/page"]).To(Equal(1))
+		})
+	})
+	Context("when auto-reload is disabled", func() {
+		BeforeEach(func() {
+			*autoReload = false
+		})
+		It("should keep rendering the template as parsed", func() {
+			(*sources)["/page"] = `edited`
+			(*sources)["/layout"] = `edited`
+			Expect((*template).ExecuteToString(nil)).To(Equal("<main>partial</main>"))
+		})
+	})
+})
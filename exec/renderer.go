@@ -1,11 +1,11 @@
 package exec
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"strings"
 
-	"github.com/pkg/errors"
-
 	"github.com/nikolalohinski/gonja/v2/config"
 	"github.com/nikolalohinski/gonja/v2/loaders"
 	"github.com/nikolalohinski/gonja/v2/nodes"
@@ -18,18 +18,34 @@ type Renderer struct {
 	Loader      loaders.Loader
 	Template    *Template
 	RootNode    *nodes.Template
-	Output      io.Writer
+	Output      Output
+
+	evaluator *Evaluator
+
+	// the budget, trace and cancellation of the render are looked up once, see NewRenderer
+	budget       *renderBudget
+	trace        *Trace
+	cancellation context.Context
 }
 
 // NewRenderer initializes a new renderer
 func NewRenderer(environment *Environment, wr io.Writer, config *config.Config, loader loaders.Loader, template *Template) *Renderer {
+	// the front-matter of the template takes precedence over the configuration it is rendered with,
+	// such as the one of the template including it
+	if template.frontMatter != nil {
+		config = template.frontMatter.Apply(config)
+	}
 	r := &Renderer{
 		Config:      config.Inherit(),
 		Environment: environment,
 		Template:    template,
 		RootNode:    template.root,
-		Output:      wr,
+		Output:      NewOutput(wr),
 		Loader:      loader,
+
+		budget:       environment.Context.renderBudget(),
+		trace:        environment.Context.renderTrace(),
+		cancellation: environment.Context.renderCancellation(),
 	}
 	r.Environment.Context.Set("self", Self(r))
 	return r
@@ -45,17 +61,28 @@ func (r *Renderer) Inherit() *Renderer {
 			Filters:           r.Environment.Filters,
 			ControlStructures: r.Environment.ControlStructures,
 			Methods:           r.Environment.Methods,
+			Logger:            r.Environment.Logger,
+			NotCallable:       r.Environment.NotCallable,
+			NotCallableHook:   r.Environment.NotCallableHook,
+			restriction:       r.Environment.restriction,
 		},
 		Template: r.Template,
 		RootNode: r.RootNode,
 		Output:   r.Output,
 		Loader:   r.Loader,
+
+		budget:       r.budget,
+		trace:        r.trace,
+		cancellation: r.cancellation,
 	}
 	return sub
 }
 
 // Visit implements the nodes.Visitor interface
 func (r *Renderer) Visit(node nodes.Node) (nodes.Visitor, error) {
+	if err := r.Canceled(); err != nil {
+		return nil, err
+	}
 	switch n := node.(type) {
 	case *nodes.Comment:
 		return nil, nil
@@ -65,8 +92,11 @@ func (r *Renderer) Visit(node nodes.Node) (nodes.Visitor, error) {
 			output = strings.TrimSuffix(output, "\n")
 			output = strings.TrimSuffix(output, "\r\n")
 		}
+		line := n.Data.Line
 		if n.Trim.Left {
-			output = strings.TrimLeft(output, " \r\n\t")
+			trimmed := strings.TrimLeft(output, " \r\n\t")
+			line += strings.Count(output[:len(output)-len(trimmed)], "\n")
+			output = trimmed
 		}
 		if n.Trim.Right {
 			output = strings.TrimRight(output, " \r\n\t")
@@ -76,44 +106,62 @@ func (r *Renderer) Visit(node nodes.Node) (nodes.Visitor, error) {
 			lines = append(lines[0:len(lines)-1], strings.TrimRight(lines[len(lines)-1], " \n\t\r"))
 			output = strings.Join(lines, "\n")
 		}
-		_, err := io.WriteString(r.Output, output)
+		if err := r.spendOutput(len(output)); err != nil {
+			return nil, err
+		}
+		if marker := r.marker(); marker != nil {
+			marker.mark(r.templateOf(n), n, line, true)
+		}
+		_, err := r.Output.WriteString(output)
 		return nil, err
 	case *nodes.Output:
 		var value *Value
 		if n.Condition != nil {
 			condition := r.Eval(n.Condition)
 			if condition.IsError() {
-				return nil, errors.Wrapf(condition, `Unable to render condition at line %d: %s`, n.Condition.Position().Line, n.Condition)
+				return nil, r.errorAt(n.Condition, fmt.Errorf(`Unable to render condition at line %d: %s: %w`, n.Condition.Position().Line, n.Condition, condition))
 			}
-			if !condition.IsNil() && condition.IsTrue() {
+			if !condition.IsNil() && condition.Truthy(r.Config) {
 				value = r.Eval(n.Expression)
-			} else if condition.IsNil() || !condition.IsTrue() {
+			} else if condition.IsNil() || !condition.Truthy(r.Config) {
 				if n.Alternative != nil {
 					value = r.Eval(n.Alternative)
 				} else {
 					return nil, nil
 				}
 			} else {
-				return nil, errors.Wrapf(condition, `Unable to evaluation condition as boolean at line %d: %s`, n.Condition.Position().Line, n.Condition)
+				return nil, r.errorAt(n.Condition, fmt.Errorf(`Unable to evaluation condition as boolean at line %d: %s: %w`, n.Condition.Position().Line, n.Condition, condition))
 			}
 		} else {
 			value = r.Eval(n.Expression)
 		}
 		if value.IsError() {
-			return nil, errors.Wrapf(value, `Unable to render expression at line %d: %s`, n.Expression.Position().Line, n.Expression)
+			r.locate(value, n.Expression)
+			return nil, r.errorAt(n.Expression, fmt.Errorf(`Unable to render expression at line %d: %s: %w`, n.Expression.Position().Line, n.Expression, value))
 		}
-		var err error
+		if marker := r.marker(); marker != nil {
+			marker.mark(r.templateOf(n), n, n.Position().Line, false)
+		}
+		var output string
 		if r.Config.AutoEscape && value.IsString() && !value.Safe {
-			_, err = io.WriteString(r.Output, value.Escaped())
+			output = value.Escaped()
 		} else {
-			_, err = io.WriteString(r.Output, value.String())
+			output = value.Printed(r.Config)
+		}
+		if err := r.spendOutput(len(output)); err != nil {
+			return nil, err
 		}
+		_, err := r.Output.WriteString(output)
 		return nil, err
 	case *nodes.ControlStructureBlock:
 		controlStructure, ok := n.ControlStructure.(ControlStructure)
 		if ok {
+			if marker := r.marker(); marker != nil {
+				marker.mark(r.templateOf(n), n, n.Position().Line, false)
+			}
 			if err := controlStructure.Execute(r, n); err != nil {
-				return nil, errors.Wrapf(err, `Unable to execute controlStructure at line %d: %s`, n.ControlStructure.Position().Line, n.ControlStructure)
+				r.locate(err, n)
+				return nil, r.errorAt(n, fmt.Errorf(`Unable to execute controlStructure at line %d: %s: %w`, n.ControlStructure.Position().Line, n.ControlStructure, err))
 			}
 		}
 		return nil, nil
@@ -140,19 +188,65 @@ func (r *Renderer) ExecuteIfWrapper(wrapper *nodes.Wrapper) error {
 func (r *Renderer) Execute() error {
 	// Determine the parent to be executed (for template inheritance)
 	root := r.RootNode
+	chain := []string{root.Identifier}
 	for root.Parent != nil {
 		root = root.Parent
+		for index, identifier := range chain {
+			if identifier == root.Identifier {
+				return fmt.Errorf("extends cycle detected: %s -> %s", strings.Join(chain[index:], " -> "), root.Identifier)
+			}
+		}
+		chain = append(chain, root.Identifier)
 	}
 
 	return nodes.Walk(r, root)
 }
 
 func (r *Renderer) Evaluator() *Evaluator {
-	return &Evaluator{
-		Environment: r.Environment,
-		Config:      r.Config,
-		Loader:      r.Template.parser.Loader,
+	// the evaluator is reused as long as the renderer is not pointed to another environment or config
+	if r.evaluator == nil || r.evaluator.Environment != r.Environment || r.evaluator.Config != r.Config {
+		r.evaluator = &Evaluator{
+			Environment: r.Environment,
+			Config:      r.Config,
+			Loader:      r.Template.parser.Loader,
+
+			budget: r.budget,
+		}
+	}
+	return r.evaluator
+}
+
+// LoadTemplate returns the template with the given resolved identifier, read through the loader.
+// Templates are parsed once per root template: later loads, including the ones of later renders,
+// reuse the template parsed the first time or ahead of time by Template.Precompile, unless the
+// loader implements loaders.StatLoader and reports that the template changed since.
+func (r *Renderer) LoadTemplate(identifier string, loader loaders.Loader) (*Template, error) {
+	template, _, err := r.Template.cache.load(identifier, loader, func() (*Template, error) {
+		return newTemplate(identifier, r.Config, loader, r.Environment)
+	})
+	return template, err
+}
+
+// ParseTemplate parses the template with the given resolved identifier, read through the loader, without
+// caching it. It suits the templates referenced by names computed during renders, which would otherwise
+// fill the cache of the root template with every name they take.
+func (r *Renderer) ParseTemplate(identifier string, loader loaders.Loader) (*Template, error) {
+	return newTemplate(identifier, r.Config, loader, r.Environment)
+}
+
+// LoaderOf returns the loader resolving the templates referenced by a node. Nodes of the layouts extended by
+// the rendered template resolve them relatively to their layout, which may come from another loader, see
+// loaders.NewNamespacedLoader.
+func (r *Renderer) LoaderOf(node nodes.Node) (loaders.Loader, error) {
+	identifier := r.identifierOf(node)
+	if identifier == r.RootNode.Identifier {
+		return r.Loader, nil
+	}
+	loader, err := r.Loader.Inherit(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inherit loader from '%s': %w", identifier, err)
 	}
+	return loader, nil
 }
 
 func (r *Renderer) Eval(node nodes.Expression) *Value {
@@ -0,0 +1,170 @@
+package exec
+
+import (
+	"fmt"
+
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/parser"
+)
+
+// Allowlist lists the filters, tests, control structures and globals kept by Restrict
+type Allowlist struct {
+	Filters           []string
+	Tests             []string
+	ControlStructures []string
+	Globals           []string
+}
+
+// restriction holds what a restricted environment rejects when parsing templates
+type restriction struct {
+	// globals are the names of the globals of the base environment which are not allowed
+	globals map[string]bool
+}
+
+// Restrict returns a frozen copy of the environment where only the allowlisted filters, tests, control
+// structures and globals are available. Templates using anything else are rejected by NewTemplate with
+// an error naming what is not allowed, and so are the templates they include, import or extend, which
+// makes restricted environments suitable for evaluating templates written by untrusted users. Names of
+// the allowlist missing from the environment are reported as errors. Methods are kept as is.
+func Restrict(environment *Environment, allowlist Allowlist) (*Environment, error) {
+	builder := NewEnvironmentBuilder(environment)
+	denied := &restriction{globals: map[string]bool{}}
+
+	allowed, err := allowedNames("filter", allowlist.Filters, builder.filters)
+	if err != nil {
+		return nil, err
+	}
+	for name := range builder.filters {
+		if !allowed[name] {
+			builder.WithoutFilter(name)
+		}
+	}
+	if allowed, err = allowedNames("test", allowlist.Tests, builder.tests); err != nil {
+		return nil, err
+	}
+	for name := range builder.tests {
+		if !allowed[name] {
+			builder.WithoutTest(name)
+		}
+	}
+	if allowed, err = allowedNames("control structure", allowlist.ControlStructures, builder.controlStructures); err != nil {
+		return nil, err
+	}
+	for name := range builder.controlStructures {
+		if !allowed[name] {
+			builder.WithControlStructure(name, deniedControlStructure(name))
+		}
+	}
+	if allowed, err = allowedNames("global", allowlist.Globals, builder.globals); err != nil {
+		return nil, err
+	}
+	for name := range builder.globals {
+		if !allowed[name] {
+			builder.WithoutGlobal(name)
+			denied.globals[name] = true
+		}
+	}
+
+	restricted, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	restricted.restriction = denied
+	if environment != nil && environment.restriction != nil {
+		for name := range environment.restriction.globals {
+			denied.globals[name] = true
+		}
+	}
+	return restricted, nil
+}
+
+func allowedNames[T any](kind string, names []string, registered map[string]T) (map[string]bool, error) {
+	allowed := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := registered[name]; !ok {
+			return nil, fmt.Errorf("unable to allow %s '%s': not found in the environment", kind, name)
+		}
+		allowed[name] = true
+	}
+	return allowed, nil
+}
+
+// deniedControlStructure replaces the control structures left out of a restricted environment, so that
+// using one fails with a clearer error than an unknown control structure
+func deniedControlStructure(name string) parser.ControlStructureParser {
+	return func(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+		return nil, fmt.Errorf("control structure '%s' is not allowed", name)
+	}
+}
+
+// check returns an error for the first filter, test or global of the template, or of the templates it
+// extends, which is not allowed. Names bound by the templates themselves, such as loop variables, set
+// targets, imports and macro parameters, refer to their own values rather than to globals and are not
+// reported.
+func (r *restriction) check(root *nodes.Template, environment *Environment) error {
+	bound := boundNames(root)
+	var err error
+	for template := root; template != nil && err == nil; template = template.Parent {
+		nodes.Traverse(template, func(node nodes.Node, _ []nodes.Node) bool {
+			if err != nil {
+				return false
+			}
+			switch n := node.(type) {
+			case *nodes.FilterCall:
+				if !environment.Filters.Exists(n.Name) {
+					err = notAllowed("filter", n.Name, n)
+				}
+			case *nodes.TestCall:
+				if !environment.Tests.Exists(n.Name) {
+					err = notAllowed("test", n.Name, n)
+				}
+			case *nodes.Name:
+				if r.globals[n.Name.Val] && !bound[n.Name.Val] {
+					err = notAllowed("global", n.Name.Val, n)
+				}
+			}
+			return err == nil
+		})
+	}
+	return err
+}
+
+// boundNames returns the names bound by the template and the templates it extends. Since denied globals are
+// left out of the environment anyway, a name bound anywhere is allowed everywhere rather than per scope.
+func boundNames(root *nodes.Template) map[string]bool {
+	bound := map[string]bool{}
+	for template := root; template != nil; template = template.Parent {
+		for name, macro := range template.Macros {
+			bound[name] = true
+			for _, pair := range macro.Kwargs {
+				if argument, ok := pair.Key.(*nodes.String); ok {
+					bound[argument.Val] = true
+				}
+			}
+		}
+		nodes.Traverse(template, func(node nodes.Node, _ []nodes.Node) bool {
+			if block, ok := node.(*nodes.ControlStructureBlock); ok {
+				node = block.ControlStructure
+			}
+			if declarer, ok := node.(interface{ DeclaredNames() []string }); ok {
+				for _, name := range declarer.DeclaredNames() {
+					bound[name] = true
+				}
+			}
+			if importer, ok := node.(interface{ ImportedNames() []string }); ok {
+				for _, name := range importer.ImportedNames() {
+					bound[name] = true
+				}
+			}
+			return true
+		})
+	}
+	return bound
+}
+
+func notAllowed(kind string, name string, node nodes.Node) error {
+	if position := node.Position(); position != nil {
+		return fmt.Errorf(`%s '%s' is not allowed (Line: %d Col: %d, near "%s")`, kind, name, position.Line, position.Col, position.Val)
+	}
+	return fmt.Errorf("%s '%s' is not allowed", kind, name)
+}
@@ -0,0 +1,127 @@
+package exec_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("restricted environment", func() {
+	var (
+		allowlist = new(exec.Allowlist)
+		templates = new(map[string]string)
+
+		returnedRestrictErr = new(error)
+		returnedParseErr    = new(error)
+		returnedResult      = new(string)
+	)
+	BeforeEach(func() {
+		*allowlist = exec.Allowlist{
+			Filters:           []string{"upper", "default"},
+			Tests:             []string{"defined"},
+			ControlStructures: []string{"if", "for", "include"},
+			Globals:           []string{"range"},
+		}
+		*templates = map[string]string{
+			"/partial": "{{ name | upper }}",
+		}
+	})
+	JustBeforeEach(func() {
+		var environment *exec.Environment
+		environment, *returnedRestrictErr = exec.Restrict(gonja.DefaultEnvironment, *allowlist)
+		if *returnedRestrictErr != nil {
+			return
+		}
+		loader := loaders.MustNewMemoryLoader(*templates)
+		t, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, environment)
+		if *returnedParseErr = err; err != nil {
+			return
+		}
+		*returnedResult, *returnedParseErr = t.ExecuteToString(exec.NewContext(map[string]interface{}{"name": "ada"}))
+	})
+	Context("when the template only uses allowed operations", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = `{% for i in range(2) %}{% if name is defined %}{{ name | upper }}{% endif %}{% endfor %} {{ missing | default("-") }} {% include "/partial" %}`
+		})
+		It("should render it", func() {
+			Expect(*returnedRestrictErr).To(BeNil())
+			Expect(*returnedParseErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("ADAADA - ADA"))
+		})
+	})
+	Context("when the template uses a filter which is not allowed", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = "{{ name }}\n{{ name | lower }}"
+		})
+		It("should reject it at parse time", func() {
+			Expect(*returnedParseErr).To(MatchError(`failed to parse template '/test': filter 'lower' is not allowed (Line: 2 Col: 11, near "lower")`))
+		})
+	})
+	Context("when the template uses a test which is not allowed", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = "{% if name is string %}{% endif %}"
+		})
+		It("should reject it at parse time", func() {
+			Expect(*returnedParseErr).To(MatchError(ContainSubstring("test 'string' is not allowed")))
+		})
+	})
+	Context("when the template uses a control structure which is not allowed", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = "{% set name = 'x' %}"
+		})
+		It("should reject it at parse time", func() {
+			Expect(*returnedParseErr).To(MatchError(ContainSubstring("control structure 'set' is not allowed")))
+		})
+	})
+	Context("when the template uses a global which is not allowed", func() {
+		BeforeEach(func() {
+			(*templates)["/test"] = "{{ lipsum() }}"
+		})
+		It("should reject it at parse time", func() {
+			Expect(*returnedParseErr).To(MatchError(ContainSubstring("global 'lipsum' is not allowed")))
+		})
+	})
+	Context("when the template binds names of globals which are not allowed", func() {
+		BeforeEach(func() {
+			(*allowlist).ControlStructures = append((*allowlist).ControlStructures, "set", "macro")
+			(*templates)["/test"] = `{% for x in range(2) %}{{ x }}{% endfor %}|` +
+				`{% for dict, log in {"a": 1} %}{{ dict }}{{ log }}{% endfor %}|` +
+				`{% set lipsum = "set" %}{{ lipsum }}|` +
+				`{% macro greet(joiner) %}{{ joiner }}{% endmacro %}{{ greet("param") }}`
+		})
+		It("should render their own values", func() {
+			Expect(*returnedParseErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("01|a1|set|param"))
+		})
+	})
+	Context("when an included template uses an operation which is not allowed", func() {
+		BeforeEach(func() {
+			(*templates)["/partial"] = "{{ name | lower }}"
+			(*templates)["/test"] = `{% include "/partial" %}`
+		})
+		It("should reject it as well", func() {
+			Expect(*returnedParseErr).To(MatchError(ContainSubstring("filter 'lower' is not allowed")))
+		})
+	})
+	Context("when an extended template uses an operation which is not allowed", func() {
+		BeforeEach(func() {
+			(*allowlist).ControlStructures = append((*allowlist).ControlStructures, "extends", "block")
+			(*templates)["/layout"] = "{% block body %}{% endblock %}{{ name | title }}"
+			(*templates)["/test"] = `{% extends "/layout" %}{% block body %}{{ name }}{% endblock %}`
+		})
+		It("should reject it as well", func() {
+			Expect(*returnedParseErr).To(MatchError(ContainSubstring("filter 'title' is not allowed")))
+		})
+	})
+	Context("when the allowlist holds an unknown name", func() {
+		BeforeEach(func() {
+			(*allowlist).Filters = append((*allowlist).Filters, "unknown")
+		})
+		It("should fail", func() {
+			Expect(*returnedRestrictErr).To(MatchError("unable to allow filter 'unknown': not found in the environment"))
+		})
+	})
+})
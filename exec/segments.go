@@ -0,0 +1,81 @@
+package exec
+
+import (
+	"io"
+
+	"github.com/nikolalohinski/gonja/v2/nodes"
+)
+
+// RenderSegment is the output produced by a node of a template: some text of the template, the value of an
+// expression or the content written by a statement which does not render other nodes, such as firstof.
+// Outputs are attributed to the innermost rendered node, so the body of a for-loop is made of the segments
+// of the nodes it holds, and the templates included or extended contribute the segments of their own nodes.
+type RenderSegment struct {
+	// Identifier is the identifier of the template holding the node
+	Identifier string `json:"identifier"`
+	// Line and Col locate the node in its template
+	Line int `json:"line"`
+	Col  int `json:"col"`
+	// Start and End are the byte offsets of the node within the source of its template, see nodes.Span
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Output is the content the node produced
+	Output string `json:"output"`
+	// Node is the node which produced the output
+	Node nodes.Node `json:"-"`
+}
+
+// segmentsOutput records the content written to it as segments, attributing writes to the node the
+// renderer marked last
+type segmentsOutput struct {
+	Output
+	segments []RenderSegment
+	template *nodes.Template
+	node     nodes.Node
+	// fresh is true until something is written after the last mark
+	fresh bool
+}
+
+func (o *segmentsOutput) mark(template *nodes.Template, node nodes.Node, _ int, _ bool) {
+	o.template, o.node, o.fresh = template, node, true
+}
+
+func (o *segmentsOutput) Write(p []byte) (int, error) {
+	return o.WriteString(string(p))
+}
+
+func (o *segmentsOutput) WriteString(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	last := len(o.segments) - 1
+	if !o.fresh && last >= 0 {
+		o.segments[last].Output += s
+		return len(s), nil
+	}
+	o.fresh = false
+	segment := RenderSegment{Output: s, Node: o.node}
+	if o.template != nil {
+		segment.Identifier = o.template.Identifier
+		if span, ok := o.template.Span(o.node); ok {
+			segment.Start, segment.End = span.Offsets()
+		}
+	}
+	if o.node != nil && o.node.Position() != nil {
+		segment.Line, segment.Col = o.node.Position().Line, o.node.Position().Col
+	}
+	o.segments = append(o.segments, segment)
+	return len(s), nil
+}
+
+// ExecuteToSegments executes the template as Execute does, and returns the output as the list of segments
+// produced by each node instead of a single string, for example to highlight the parts of a template which
+// produced some output in a live preview, or to only re-render what changed. Concatenating the outputs of
+// the segments gives the output of Execute.
+func (t *Template) ExecuteToSegments(data *Context) ([]RenderSegment, error) {
+	output := &segmentsOutput{Output: NewOutput(io.Discard), segments: []RenderSegment{}}
+	if err := t.Execute(output, data); err != nil {
+		return nil, err
+	}
+	return output.segments, nil
+}
@@ -0,0 +1,95 @@
+package exec_test
+
+import (
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("render segments", func() {
+	var (
+		templates = new(map[string]string)
+
+		returnedSegments = new([]exec.RenderSegment)
+		returnedErr      = new(error)
+	)
+	BeforeEach(func() {
+		*templates = map[string]string{
+			"/layout": "<h1>{{ title }}</h1>{% block body %}{% endblock %}",
+			"/page":   `{% extends "/layout" %}{% block body %}{% for item in items %}<li>{{ item | upper }}</li>{% endfor %}{% include "/footer" %}{% endblock %}`,
+			"/footer": "<footer>{{ 1 + 1 }}</footer>",
+		}
+	})
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(*templates)
+		t, err := exec.NewTemplate("/page", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		*returnedSegments, *returnedErr = t.ExecuteToSegments(exec.NewContext(map[string]interface{}{
+			"title": "Home",
+			"items": []string{"a", "b"},
+		}))
+	})
+	It("should return the output produced by each node along with its location", func() {
+		Expect(*returnedErr).To(BeNil())
+		type segment struct {
+			Identifier string
+			Col        int
+			Raw        string
+			Output     string
+		}
+		segments := []segment{}
+		for _, s := range *returnedSegments {
+			segments = append(segments, segment{s.Identifier, s.Col, (*templates)[s.Identifier][s.Start:s.End], s.Output})
+		}
+		Expect(segments).To(Equal([]segment{
+			{"/layout", 1, "<h1>", "<h1>"},
+			{"/layout", 5, "{{ title }}", "Home"},
+			{"/layout", 16, "</h1>", "</h1>"},
+			{"/page", 63, "<li>", "<li>"},
+			{"/page", 67, "{{ item | upper }}", "A"},
+			{"/page", 85, "</li>", "</li>"},
+			{"/page", 63, "<li>", "<li>"},
+			{"/page", 67, "{{ item | upper }}", "B"},
+			{"/page", 85, "</li>", "</li>"},
+			{"/footer", 1, "<footer>", "<footer>"},
+			{"/footer", 9, "{{ 1 + 1 }}", "2"},
+			{"/footer", 20, "</footer>", "</footer>"},
+		}))
+	})
+	Context("when nodes are rendered within a spaceless statement", func() {
+		BeforeEach(func() {
+			(*templates)["/page"] = "{% spaceless %}\n<p>{{ title }}</p>\n<p>x</p>\n{% endspaceless %}"
+		})
+		It("should keep attributing their output to them", func() {
+			Expect(*returnedErr).To(BeNil())
+			type segment struct {
+				Raw    string
+				Output string
+			}
+			segments := []segment{}
+			for _, s := range *returnedSegments {
+				segments = append(segments, segment{(*templates)[s.Identifier][s.Start:s.End], s.Output})
+			}
+			Expect(segments).To(Equal([]segment{
+				{"\n<p>", "<p>"},
+				{"{{ title }}", "Home"},
+				{"</p>\n<p>x</p>\n", "</p><p>x</p>"},
+			}))
+		})
+	})
+	It("should produce the output of Execute once concatenated", func() {
+		Expect(*returnedErr).To(BeNil())
+		outputs := []string{}
+		for _, segment := range *returnedSegments {
+			outputs = append(outputs, segment.Output)
+		}
+		Expect(strings.Join(outputs, "")).To(Equal("<h1>Home</h1><li>A</li><li>B</li><footer>2</footer>"))
+	})
+})
@@ -0,0 +1,160 @@
+package exec
+
+import (
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2/nodes"
+)
+
+// SourceMap maps the byte ranges of a rendered output to the templates and lines they were rendered from,
+// so that a problem reported at some line of a generated file can be traced back to its template
+type SourceMap struct {
+	// Segments holds the consecutive byte ranges of the output, in order
+	Segments []SourceSegment `json:"segments"`
+	// lineStarts holds the offsets at which the lines of the output start
+	lineStarts []int
+	size       int
+}
+
+// SourceSegment is a byte range of a rendered output, and the template line it was rendered from. Text of
+// the template is mapped line by line, while the output of an expression or a statement is mapped to the
+// line it starts at, as a whole.
+type SourceSegment struct {
+	Start      int    `json:"start"`
+	End        int    `json:"end"`
+	Identifier string `json:"identifier"`
+	Line       int    `json:"line"`
+}
+
+// Lookup returns the segment holding the byte at the given offset of the output
+func (m *SourceMap) Lookup(offset int) (SourceSegment, bool) {
+	index := sort.Search(len(m.Segments), func(i int) bool { return m.Segments[i].End > offset })
+	if index == len(m.Segments) || m.Segments[index].Start > offset {
+		return SourceSegment{}, false
+	}
+	return m.Segments[index], true
+}
+
+// LookupLine returns the segments overlapping a line of the output, counting lines from 1
+func (m *SourceMap) LookupLine(line int) []SourceSegment {
+	if line < 1 || line > len(m.lineStarts) {
+		return nil
+	}
+	start, end := m.lineStarts[line-1], m.size
+	if line < len(m.lineStarts) {
+		end = m.lineStarts[line]
+	}
+	var segments []SourceSegment
+	for index := sort.Search(len(m.Segments), func(i int) bool { return m.Segments[i].End > start }); index < len(m.Segments) && m.Segments[index].Start < end; index++ {
+		segments = append(segments, m.Segments[index])
+	}
+	return segments
+}
+
+// sourceMapOutput records the source map of the content written to an output, attributing writes to the
+// node the renderer marked last
+type sourceMapOutput struct {
+	Output
+	sourceMap  *SourceMap
+	identifier string
+	line       int
+	multiline  bool
+}
+
+func newSourceMapOutput(output Output) *sourceMapOutput {
+	return &sourceMapOutput{
+		Output:    output,
+		sourceMap: &SourceMap{lineStarts: []int{0}},
+	}
+}
+
+// mark attributes the next writes to a line of a template. Newlines written advance the line when the
+// content is text of the template.
+func (o *sourceMapOutput) mark(template *nodes.Template, _ nodes.Node, line int, multiline bool) {
+	o.identifier, o.line, o.multiline = template.Identifier, line, multiline
+}
+
+func (o *sourceMapOutput) Write(p []byte) (int, error) {
+	n, err := o.Output.Write(p)
+	o.record(string(p[:n]))
+	return n, err
+}
+
+func (o *sourceMapOutput) WriteString(s string) (int, error) {
+	n, err := o.Output.WriteString(s)
+	o.record(s[:n])
+	return n, err
+}
+
+// Flush flushes the wrapped output if it supports it
+func (o *sourceMapOutput) Flush() error {
+	if flusher, ok := o.Output.(Flusher); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+func (o *sourceMapOutput) record(s string) {
+	m := o.sourceMap
+	for len(s) > 0 {
+		length := len(s)
+		newline := strings.IndexByte(s, '\n')
+		if newline >= 0 {
+			length = newline + 1
+		}
+		last := len(m.Segments) - 1
+		if last >= 0 && m.Segments[last].End == m.size && m.Segments[last].Identifier == o.identifier && m.Segments[last].Line == o.line {
+			m.Segments[last].End += length
+		} else {
+			m.Segments = append(m.Segments, SourceSegment{Start: m.size, End: m.size + length, Identifier: o.identifier, Line: o.line})
+		}
+		m.size += length
+		if newline >= 0 {
+			m.lineStarts = append(m.lineStarts, m.size)
+			if o.multiline {
+				o.line++
+			}
+		}
+		s = s[length:]
+	}
+}
+
+// markingOutput is implemented by the outputs attributing the content written to the nodes producing it
+type markingOutput interface {
+	// mark attributes the next writes to a node of a template, starting at the given line
+	mark(template *nodes.Template, node nodes.Node, line int, multiline bool)
+}
+
+// marker returns the output attributing the content written to nodes, if any
+func (r *Renderer) marker() markingOutput {
+	output, _ := r.Output.(markingOutput)
+	return output
+}
+
+// templateOf returns the template a node belongs to, which is a parent of the rendered template for the
+// nodes of the layouts it extends
+func (r *Renderer) templateOf(node nodes.Node) *nodes.Template {
+	for root := r.RootNode; root != nil; root = root.Parent {
+		if _, ok := root.Span(node); ok {
+			return root
+		}
+	}
+	return r.RootNode
+}
+
+// identifierOf returns the identifier of the template a node belongs to, see templateOf
+func (r *Renderer) identifierOf(node nodes.Node) string {
+	return r.templateOf(node).Identifier
+}
+
+// ExecuteWithSourceMap executes the template as Execute does, and returns the source map of the content
+// written to the writer
+func (t *Template) ExecuteWithSourceMap(wr io.Writer, data *Context) (*SourceMap, error) {
+	output := newSourceMapOutput(NewOutput(wr))
+	if err := t.Execute(output, data); err != nil {
+		return nil, err
+	}
+	return output.sourceMap, nil
+}
@@ -0,0 +1,78 @@
+package exec_test
+
+import (
+	"bytes"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("source map", func() {
+	var (
+		templates = new(map[string]string)
+
+		returnedOutput    = new(string)
+		returnedSourceMap = new(*exec.SourceMap)
+		returnedErr       = new(error)
+	)
+	BeforeEach(func() {
+		*templates = map[string]string{
+			"/layout": "kind: {{ kind }}\n{% block spec %}{% endblock %}\n",
+			"/child": `{% extends "/layout" %}{% block spec %}spec:
+  replicas: {{ replicas }}
+{% for port in ports %}  - {{ port }}
+{% endfor %}{% include "/labels" %}{% endblock %}`,
+			"/labels": "labels:\n  app: {{ name | upper }}\n",
+		}
+	})
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(*templates)
+		t, err := exec.NewTemplate("/child", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		out := new(bytes.Buffer)
+		*returnedSourceMap, *returnedErr = t.ExecuteWithSourceMap(out, exec.NewContext(map[string]interface{}{
+			"kind":     "Deployment",
+			"replicas": 2,
+			"ports":    []int{80, 443},
+			"name":     "web",
+		}))
+		*returnedOutput = out.String()
+	})
+	It("should render the template", func() {
+		Expect(*returnedErr).To(BeNil())
+		Expect(*returnedOutput).To(Equal("kind: Deployment\nspec:\n  replicas: 2\n  - 80\n  - 443\nlabels:\n  app: WEB\n\n"))
+	})
+	It("should map the lines of the output to the templates and lines they come from", func() {
+		Expect(*returnedErr).To(BeNil())
+		lines := [][2]interface{}{}
+		for line := 1; line <= 8; line++ {
+			for _, segment := range (*returnedSourceMap).LookupLine(line) {
+				lines = append(lines, [2]interface{}{segment.Identifier, segment.Line})
+			}
+		}
+		Expect(lines).To(Equal([][2]interface{}{
+			{"/layout", 1},
+			{"/child", 1},
+			{"/child", 2},
+			{"/child", 3},
+			{"/child", 3},
+			{"/labels", 1},
+			{"/labels", 2},
+			{"/layout", 2},
+		}))
+	})
+	It("should find the segment holding an offset", func() {
+		Expect(*returnedErr).To(BeNil())
+		segment, ok := (*returnedSourceMap).Lookup(len("kind: Deployment\nspec:\n  replicas: "))
+		Expect(ok).To(BeTrue())
+		Expect(segment).To(Equal(exec.SourceSegment{Start: 23, End: 37, Identifier: "/child", Line: 2}))
+		_, ok = (*returnedSourceMap).Lookup(len(*returnedOutput))
+		Expect(ok).To(BeFalse())
+	})
+})
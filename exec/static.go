@@ -0,0 +1,42 @@
+package exec
+
+import (
+	"io"
+
+	"github.com/nikolalohinski/gonja/v2/loaders"
+)
+
+// LoadStatic returns the content of the file with the given resolved identifier, read through the loader
+// without being parsed as a template. Like templates, files are read once per root template: later loads,
+// including the ones of later renders, reuse the content read the first time. When the loader implements
+// loaders.StatLoader, the version of the file is checked on each load as it is for cached templates, and the
+// file is read again if it changed or if its version cannot be determined. Loaders wrapping another one
+// must implement Stat as well for their files to be revalidated.
+func (r *Renderer) LoadStatic(identifier string, loader loaders.Loader) (string, error) {
+	cache := r.Template.cache
+	entry := cache.entry(&cache.statics, identifier, loader)
+	entry.once.Do(func() {
+		var reader io.Reader
+		if reader, entry.err = loader.Read(identifier); entry.err != nil {
+			return
+		}
+		var content []byte
+		content, entry.err = io.ReadAll(reader)
+		entry.content = string(content)
+	})
+	if entry.err != nil {
+		cache.statics.CompareAndDelete(identifier, entry)
+		return "", entry.err
+	}
+	return entry.content, nil
+}
+
+// WriteStatic writes content to the output as is, without escaping it, accounting for it in the output budget
+// of the render
+func (r *Renderer) WriteStatic(content string) error {
+	if err := r.spendOutput(len(content)); err != nil {
+		return err
+	}
+	_, err := r.Output.WriteString(content)
+	return err
+}
@@ -0,0 +1,76 @@
+package exec
+
+import (
+	"bufio"
+	"io"
+)
+
+// DefaultStreamChunkSize is the number of bytes a Stream buffers when no chunk size is given
+const DefaultStreamChunkSize = 32 * 1024
+
+// Stream is an Output sending the rendered content to a writer by chunks while a template is executed,
+// rather than once it is fully rendered. The writer is flushed after every chunk if it supports it, either
+// with a Flush method returning an error like Flusher, or with one returning nothing like http.Flusher, so
+// that large templates reach HTTP clients or files without holding the whole content in memory.
+type Stream struct {
+	buffer *bufio.Writer
+	writer *flushingWriter
+}
+
+// NewStream returns a Stream writing to the writer by chunks of the given size in bytes, or of
+// DefaultStreamChunkSize when the size is not positive
+func NewStream(wr io.Writer, chunkSize int) *Stream {
+	if chunkSize <= 0 {
+		chunkSize = DefaultStreamChunkSize
+	}
+	writer := &flushingWriter{Writer: wr}
+	return &Stream{
+		buffer: bufio.NewWriterSize(writer, chunkSize),
+		writer: writer,
+	}
+}
+
+func (s *Stream) Write(p []byte) (int, error) {
+	return s.buffer.Write(p)
+}
+
+func (s *Stream) WriteString(str string) (int, error) {
+	return s.buffer.WriteString(str)
+}
+
+// Flush sends the content buffered so far to the writer and flushes it
+func (s *Stream) Flush() error {
+	if s.buffer.Buffered() == 0 {
+		return s.writer.flush()
+	}
+	return s.buffer.Flush()
+}
+
+// flushingWriter flushes the wrapped writer after every write
+type flushingWriter struct {
+	io.Writer
+}
+
+func (w *flushingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, w.flush()
+}
+
+func (w *flushingWriter) flush() error {
+	switch flusher := w.Writer.(type) {
+	case Flusher:
+		return flusher.Flush()
+	case interface{ Flush() }:
+		flusher.Flush()
+	}
+	return nil
+}
+
+// Stream executes the template while sending the rendered content to the writer by chunks of the given
+// size, see NewStream. On error, the chunks already sent are not taken back.
+func (t *Template) Stream(wr io.Writer, data *Context, chunkSize int) error {
+	return t.Execute(NewStream(wr, chunkSize), data)
+}
@@ -5,8 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
-
-	"github.com/pkg/errors"
+	"sync/atomic"
 
 	"github.com/nikolalohinski/gonja/v2/config"
 	"github.com/nikolalohinski/gonja/v2/loaders"
@@ -15,64 +14,225 @@ import (
 	"github.com/nikolalohinski/gonja/v2/tokens"
 )
 
+// Template is a parsed template.
+//
+// A template is never modified by its executions: each of them gets its own renderer, configuration, output
+// and context layered on top of the environment globals. A single template can therefore be executed by any
+// number of goroutines at once without locking, provided that its environment is not modified meanwhile,
+// see EnvironmentBuilder for environments that cannot be.
 type Template struct {
 	source      string
 	config      *config.Config
+	frontMatter *config.FrontMatter
+	metadata    *Metadata
 	environment *Environment
 	loader      loaders.Loader
 	tokens      *tokens.Stream
 	parser      *parser.Parser
 	root        *nodes.Template
+
+	sizeHint atomic.Int64
+	lastSize atomic.Int64
+	cache    *templateCache
+	// reload is set when the environment enables AutoReload
+	reload *templateReload
 }
 
-// NewTemplate creates a gonja template instance that can be executed with a given context later on
+// NewTemplate creates a gonja template instance that can be executed with a given context later on.
+// Errors are located with an *Error, including the ones of templates which cannot be read.
 func NewTemplate(identifier string, config *config.Config, loader loaders.Loader, environment *Environment) (*Template, error) {
+	t, err := newTemplate(identifier, config, loader, environment)
+	if err != nil {
+		return nil, templateError(identifier, err)
+	}
+	return t, nil
+}
+
+// newTemplate works like NewTemplate, but leaves the errors of templates which cannot be read for the
+// caller to locate, such as the statement including the template
+func newTemplate(identifier string, config *config.Config, loader loaders.Loader, environment *Environment) (*Template, error) {
+	// origin is the loader as given, before the front-matter of the template possibly roots it
+	origin := loader
 	input, err := loader.Read(identifier)
 	if err != nil {
-		return nil, fmt.Errorf("failed to reader template '%s': %s", identifier, err)
+		return nil, fmt.Errorf("failed to reader template '%s': %w", identifier, err)
 	}
 
 	source := new(strings.Builder)
 	if _, err := io.Copy(source, input); err != nil {
-		return nil, fmt.Errorf("failed to copy '%s' to string buffer: %s", source, err)
+		return nil, templateError(identifier, fmt.Errorf("failed to copy '%s' to string buffer: %w", source, err))
+	}
+
+	// the front-matter of the template overrides the configuration for this template only
+	frontMatter, err := config.ParseFrontMatter(source.String())
+	if err != nil {
+		return nil, templateError(identifier, fmt.Errorf("failed to parse template '%s': %w", identifier, err))
+	}
+	metadata, err := newMetadata(frontMatter.Values)
+	if err != nil {
+		return nil, templateError(identifier, fmt.Errorf("failed to parse template '%s': %w", identifier, err))
+	}
+	if frontMatter.Config.RelativePaths {
+		if loader, err = loaders.NewRootedLoader(loader, identifier); err != nil {
+			return nil, templateError(identifier, fmt.Errorf("failed to parse template '%s': %w", identifier, err))
+		}
 	}
 
 	t := &Template{
-		source:      source.String(),
-		config:      config,
+		source:      frontMatter.Source,
+		config:      frontMatter.Config,
+		frontMatter: frontMatter,
+		metadata:    metadata,
 		loader:      loader,
-		tokens:      tokens.Lex(source.String(), config),
+		tokens:      tokens.LexWithAliases(frontMatter.Source, frontMatter.Config, environment.ControlStructures.Aliases()),
 		environment: environment,
+		cache:       new(templateCache),
 	}
 
-	t.parser = parser.NewParser(identifier, t.tokens, config, loader, environment.ControlStructures)
+	t.parser = parser.NewParser(identifier, t.tokens, t.config, loader, environment.ControlStructures)
 
 	root, err := t.parser.Parse()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse template '%s': %s", source, err)
+		return nil, templateError(identifier, fmt.Errorf("failed to parse template '%s': %w", identifier, err))
 	}
+	if environment.restriction != nil {
+		if err := environment.restriction.check(root, environment); err != nil {
+			return nil, templateError(identifier, fmt.Errorf("failed to parse template '%s': %w", identifier, err))
+		}
+	}
+	root.Source = t.source
+	root.Header, root.HeaderSize = frontMatter.Header, frontMatter.HeaderSize
 	t.root = root
 
+	if environment.AutoReload {
+		t.reload = newTemplateReload(identifier, config, origin, environment, t)
+	}
+
 	return t, nil
 }
 
-// Execute executes the template and returns the rendered content in the provided writer
+// Execute executes the template and returns the rendered content in the provided writer.
+//
+// The data, including the values inherited from its parent contexts, is layered on top of the
+// environment globals in a context dedicated to this execution. Neither the data nor the environment
+// are modified by the execution, so a template can be executed concurrently with different data.
+// Writers implementing Flusher, such as a *bufio.Writer, are flushed once the template is executed.
 func (t *Template) Execute(wr io.Writer, data *Context) error {
-	if data == nil {
-		data = EmptyContext()
+	return t.execute(wr, t.scopeOf(data))
+}
+
+// scopeOf returns the context dedicated to an execution with the given data, see Execute
+func (t *Template) scopeOf(data *Context) *Context {
+	scope := EmptyContext()
+	if data != nil {
+		scope = NewContext(data.Export())
+		scope.SetReadOnly(data.readOnlyNames()...)
+		scope.SetSensitive(data.sensitiveNames()...)
+		if budget := data.renderBudget(); budget != nil {
+			scope.SetBudget(budget.Budget)
+		}
+		if trace := data.renderTrace(); trace != nil {
+			scope.SetTrace(trace)
+		}
+		if cancellation := data.renderCancellation(); cancellation != nil {
+			scope.setCancellation(cancellation)
+		}
+		scope.overrides = data.blockOverrides()
+	}
+	return scope
+}
+
+// Render executes the template with the given data, see Execute for details
+func (t *Template) Render(wr io.Writer, data map[string]interface{}) error {
+	scope := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		scope[key] = value
+	}
+	return t.execute(wr, NewContext(scope))
+}
+
+// RenderToString executes the template with the given data and returns the rendered content as a string
+func (t *Template) RenderToString(data map[string]interface{}) (string, error) {
+	output := new(bytes.Buffer)
+
+	if err := t.RenderToBuffer(output, data); err != nil {
+		return "", err
+	}
+
+	return output.String(), nil
+}
+
+// RenderToBuffer executes the template with the given data and appends the rendered content to the buffer,
+// see ExecuteToBuffer for details
+func (t *Template) RenderToBuffer(buffer *bytes.Buffer, data map[string]interface{}) error {
+	return t.toBuffer(buffer, func() error { return t.Render(buffer, data) })
+}
+
+// SetSizeHint sets the number of bytes reserved ahead of rendering to a string, to bytes or to a buffer.
+// Without a hint, or with a hint of 0, the size of the last content rendered that way is reserved instead.
+func (t *Template) SetSizeHint(size int) {
+	t.sizeHint.Store(int64(size))
+}
+
+func (t *Template) outputSizeHint() int {
+	if hint := t.sizeHint.Load(); hint > 0 {
+		return int(hint)
+	}
+	return int(t.lastSize.Load())
+}
+
+func (t *Template) toBuffer(buffer *bytes.Buffer, render func() error) error {
+	start := buffer.Len()
+	buffer.Grow(t.outputSizeHint())
+	if err := render(); err != nil {
+		buffer.Truncate(start)
+		return err
+	}
+	t.lastSize.Store(int64(buffer.Len() - start))
+	return nil
+}
+
+func (t *Template) execute(wr io.Writer, scope *Context) error {
+	if t.reload != nil {
+		latest, err := t.reload.check()
+		if err != nil {
+			return err
+		}
+		if latest != t {
+			return latest.execute(wr, scope)
+		}
+	}
+
+	globals := t.environment.Context
+	if globals == nil {
+		globals = EmptyContext()
+	}
+	scope.parent = globals
+	// each render accounts for its own resources, whichever context the budget is set on
+	if budget := scope.renderBudget(); budget != nil {
+		scope.budget = &renderBudget{Budget: budget.Budget}
 	}
 
 	renderer := NewRenderer(&Environment{
 		Tests:             t.environment.Tests,
 		Filters:           t.environment.Filters,
 		ControlStructures: t.environment.ControlStructures,
-		Context:           t.environment.Context.Inherit().Update(data),
+		Context:           scope,
 		Methods:           t.environment.Methods,
+		Logger:            t.environment.Logger,
+		NotCallable:       t.environment.NotCallable,
+		NotCallableHook:   t.environment.NotCallableHook,
+		restriction:       t.environment.restriction,
 	}, wr, t.config, t.loader, t)
 
 	err := renderer.Execute()
 	if err != nil {
-		return errors.Wrap(err, "unable to execute template")
+		return fmt.Errorf("unable to execute template: %w", redactError(err, scope))
+	}
+	if flusher, ok := renderer.Output.(Flusher); ok {
+		if err := flusher.Flush(); err != nil {
+			return fmt.Errorf("unable to flush template output: %w", err)
+		}
 	}
 
 	return nil
@@ -80,9 +240,9 @@ func (t *Template) Execute(wr io.Writer, data *Context) error {
 
 // ExecuteToString executes the template and returns the rendered content as a string
 func (t *Template) ExecuteToString(data *Context) (string, error) {
-	output := bytes.NewBufferString("")
+	output := new(bytes.Buffer)
 
-	if err := t.Execute(output, data); err != nil {
+	if err := t.ExecuteToBuffer(output, data); err != nil {
 		return "", err
 	}
 
@@ -91,21 +251,41 @@ func (t *Template) ExecuteToString(data *Context) (string, error) {
 
 // ExecuteToBytes executes the template and returns the rendered content as bytes
 func (t *Template) ExecuteToBytes(data *Context) ([]byte, error) {
-	output := bytes.NewBuffer(nil)
+	output := new(bytes.Buffer)
 
-	if err := t.Execute(output, data); err != nil {
+	if err := t.ExecuteToBuffer(output, data); err != nil {
 		return nil, err
 	}
 
 	return output.Bytes(), nil
 }
 
+// ExecuteToBuffer executes the template and appends the rendered content to the buffer, which can be
+// reset and reused across renders to avoid growing a new one every time. The buffer is grown ahead of
+// rendering according to the size hint of the template, see SetSizeHint. On error, the buffer is
+// truncated back to its original length.
+func (t *Template) ExecuteToBuffer(buffer *bytes.Buffer, data *Context) error {
+	return t.toBuffer(buffer, func() error { return t.Execute(buffer, data) })
+}
+
 // Macros returns all macros available to the template
 func (t *Template) Macros() map[string]*nodes.Macro {
+	t = t.current()
 	return t.root.Macros
 }
 
 // Root returns the root node of the template
 func (t *Template) Root() *nodes.Template {
+	t = t.current()
 	return t.root
 }
+
+// Config returns the configuration the template was parsed with
+func (t *Template) Config() *config.Config {
+	return t.config
+}
+
+// Environment returns the environment the template is rendered in
+func (t *Template) Environment() *Environment {
+	return t.environment
+}
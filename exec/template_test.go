@@ -0,0 +1,121 @@
+package exec_test
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/parser"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("template", func() {
+	var (
+		environment = new(*exec.Environment)
+		template    = new(*exec.Template)
+	)
+	BeforeEach(func() {
+		*environment = &exec.Environment{
+			Filters:           exec.NewFilterSet(map[string]exec.FilterFunction{}),
+			Tests:             exec.NewTestSet(map[string]exec.TestFunction{}),
+			ControlStructures: exec.NewControlStructureSet(map[string]parser.ControlStructureParser{}),
+			Context:           exec.NewContext(map[string]interface{}{"greeting": "Hello", "name": "nobody"}),
+		}
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/test": "{{ greeting }} {{ name }}"})
+		var err error
+		*template, err = exec.NewTemplate("/test", config.New(), loader, *environment)
+		Expect(err).To(BeNil())
+	})
+	Context("when rendering with data", func() {
+		It("should layer the data on top of the environment globals", func() {
+			out, err := (*template).RenderToString(map[string]interface{}{"name": "world"})
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Hello world"))
+		})
+		It("should fall back to the environment globals without data", func() {
+			out, err := (*template).RenderToString(nil)
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Hello nobody"))
+		})
+		It("should not modify the environment globals", func() {
+			_, err := (*template).RenderToString(map[string]interface{}{"name": "world"})
+			Expect(err).To(BeNil())
+			name, _ := (*environment).Context.Get("name")
+			Expect(name).To(Equal("nobody"))
+		})
+	})
+	Context("when executing with an inherited context", func() {
+		It("should include the values of the parent contexts", func() {
+			data := exec.NewContext(map[string]interface{}{"greeting": "Hi", "name": "parent"}).Inherit()
+			data.Set("name", "child")
+			out, err := (*template).ExecuteToString(data)
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Hi child"))
+		})
+	})
+	Context("when rendering to a buffer", func() {
+		It("should append the rendered content", func() {
+			buffer := bytes.NewBufferString("> ")
+			Expect((*template).RenderToBuffer(buffer, map[string]interface{}{"name": "world"})).To(Succeed())
+			Expect(buffer.String()).To(Equal("> Hello world"))
+		})
+		It("should be reusable once reset", func() {
+			buffer := new(bytes.Buffer)
+			Expect((*template).ExecuteToBuffer(buffer, exec.NewContext(map[string]interface{}{"name": "first"}))).To(Succeed())
+			buffer.Reset()
+			Expect((*template).ExecuteToBuffer(buffer, exec.NewContext(map[string]interface{}{"name": "second"}))).To(Succeed())
+			Expect(buffer.String()).To(Equal("Hello second"))
+		})
+		It("should reserve the size of the last render", func() {
+			Expect((*template).RenderToBuffer(new(bytes.Buffer), map[string]interface{}{"name": "world"})).To(Succeed())
+			buffer := new(bytes.Buffer)
+			Expect((*template).RenderToBuffer(buffer, map[string]interface{}{"name": "world"})).To(Succeed())
+			Expect(buffer.Cap()).To(BeNumerically(">=", len("Hello world")))
+		})
+		It("should reserve the size given as hint", func() {
+			(*template).SetSizeHint(4096)
+			buffer := new(bytes.Buffer)
+			Expect((*template).RenderToBuffer(buffer, nil)).To(Succeed())
+			Expect(buffer.Cap()).To(BeNumerically(">=", 4096))
+		})
+		Context("when the execution fails", func() {
+			BeforeEach(func() {
+				loader := loaders.MustNewMemoryLoader(map[string]string{"/test": "{{ greeting }} {{ missing() }}"})
+				var err error
+				*template, err = exec.NewTemplate("/test", config.New(), loader, *environment)
+				Expect(err).To(BeNil())
+			})
+			It("should truncate the buffer back to its original length", func() {
+				buffer := bytes.NewBufferString("kept")
+				Expect((*template).RenderToBuffer(buffer, nil)).ToNot(Succeed())
+				Expect(buffer.String()).To(Equal("kept"))
+			})
+		})
+	})
+	Context("when rendering concurrently with different data", func() {
+		It("should keep every render isolated", func() {
+			var wg sync.WaitGroup
+			results := make([]string, 50)
+			errs := make([]error, 50)
+			for index := range results {
+				wg.Add(1)
+				go func(index int) {
+					defer wg.Done()
+					results[index], errs[index] = (*template).RenderToString(map[string]interface{}{
+						"name": fmt.Sprintf("render-%d", index),
+					})
+				}(index)
+			}
+			wg.Wait()
+			for index := range results {
+				Expect(errs[index]).To(BeNil())
+				Expect(results[index]).To(Equal(fmt.Sprintf("Hello render-%d", index)))
+			}
+		})
+	})
+})
@@ -0,0 +1,152 @@
+package exec
+
+import (
+	"sync"
+
+	"github.com/nikolalohinski/gonja/v2/nodes"
+)
+
+// Trace collects what happened during the renders it is set on, see Context.SetTrace, which helps finding
+// out which parts of templates a test suite exercises
+type Trace struct {
+	lock     sync.Mutex
+	blocks   []TracedBlock
+	includes []TracedInclude
+	branches []TracedBranch
+}
+
+// TracedBlock is a block rendered by a template
+type TracedBlock struct {
+	Name string `json:"name"`
+	// Identifier is the identifier of the template defining the rendered content of the block
+	Identifier string `json:"identifier"`
+	// Overridden is true when the rendered content overrides the one of the layout declaring the block
+	Overridden bool `json:"overridden"`
+}
+
+// TracedInclude is a template included by another one
+type TracedInclude struct {
+	// Identifier and Line locate the include statement
+	Identifier string `json:"identifier"`
+	Line       int    `json:"line"`
+	// Name is the name of the included template as given to the statement
+	Name string `json:"name"`
+	// Resolved is the resolved identifier of the included template
+	Resolved string `json:"resolved"`
+}
+
+// TracedBranch is the branch taken by an if statement
+type TracedBranch struct {
+	// Identifier and Line locate the if statement
+	Identifier string `json:"identifier"`
+	Line       int    `json:"line"`
+	// Branch is the index of the branch taken, starting from 0 for the if branch and followed by the elif
+	// and else branches, or -1 when no branch was taken
+	Branch int `json:"branch"`
+	// Branches is the number of branches of the statement, including the else branch if any
+	Branches int `json:"branches"`
+}
+
+// NewTrace creates an empty trace
+func NewTrace() *Trace {
+	return &Trace{
+		blocks:   []TracedBlock{},
+		includes: []TracedInclude{},
+		branches: []TracedBranch{},
+	}
+}
+
+// Blocks returns the blocks rendered so far, in order
+func (t *Trace) Blocks() []TracedBlock {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return append([]TracedBlock{}, t.blocks...)
+}
+
+// Includes returns the templates included so far, in order
+func (t *Trace) Includes() []TracedInclude {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return append([]TracedInclude{}, t.includes...)
+}
+
+// Branches returns the branches taken so far, in order
+func (t *Trace) Branches() []TracedBranch {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return append([]TracedBranch{}, t.branches...)
+}
+
+// SetTrace sets the trace collecting what happens during the renders executed with this context, or with
+// contexts inheriting from it. The same trace can be set on the contexts of several renders to collect
+// what happens in all of them, for example for all the test cases of a template.
+func (ctx *Context) SetTrace(trace *Trace) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	ctx.trace = trace
+}
+
+// renderTrace returns the trace set on this context or the closest of its parents, if any
+func (ctx *Context) renderTrace() *Trace {
+	for ; ctx != nil; ctx = ctx.parent {
+		ctx.lock.Lock()
+		trace := ctx.trace
+		ctx.lock.Unlock()
+		if trace != nil {
+			return trace
+		}
+	}
+	return nil
+}
+
+// TraceBlock records the rendering of a block with the given content, which overrides the one of the
+// block statement unless they are the same. It is meant to be called by the control structures rendering
+// blocks, and does nothing when the render is not traced.
+func (r *Renderer) TraceBlock(name string, content *nodes.Wrapper, declared *nodes.Wrapper) {
+	trace := r.trace
+	if trace == nil {
+		return
+	}
+	trace.lock.Lock()
+	defer trace.lock.Unlock()
+	trace.blocks = append(trace.blocks, TracedBlock{
+		Name:       name,
+		Identifier: r.identifierOf(content),
+		Overridden: content != declared,
+	})
+}
+
+// TraceInclude records the inclusion of a template by a statement. It is meant to be called by the control
+// structures rendering other templates, and does nothing when the render is not traced.
+func (r *Renderer) TraceInclude(tag *nodes.ControlStructureBlock, name string, resolved string) {
+	trace := r.trace
+	if trace == nil {
+		return
+	}
+	trace.lock.Lock()
+	defer trace.lock.Unlock()
+	trace.includes = append(trace.includes, TracedInclude{
+		Identifier: r.identifierOf(tag),
+		Line:       tag.Position().Line,
+		Name:       name,
+		Resolved:   resolved,
+	})
+}
+
+// TraceBranch records the branch taken by a conditional statement, -1 meaning none. It is meant to be
+// called by the control structures rendering one of their branches, and does nothing when the render is
+// not traced.
+func (r *Renderer) TraceBranch(tag *nodes.ControlStructureBlock, branch int, branches int) {
+	trace := r.trace
+	if trace == nil {
+		return
+	}
+	trace.lock.Lock()
+	defer trace.lock.Unlock()
+	trace.branches = append(trace.branches, TracedBranch{
+		Identifier: r.identifierOf(tag),
+		Line:       tag.Position().Line,
+		Branch:     branch,
+		Branches:   branches,
+	})
+}
@@ -0,0 +1,68 @@
+package exec_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("render trace", func() {
+	var (
+		templates = new(map[string]string)
+		data      = new(map[string]interface{})
+		trace     = new(*exec.Trace)
+
+		returnedErr = new(error)
+	)
+	BeforeEach(func() {
+		*templates = map[string]string{
+			"/layout": "{% block header %}header{% endblock %}{% block body %}{% endblock %}",
+			"/page":   "{% extends \"/layout\" %}{% block body %}\n{% if n == 1 %}one{% elif n == 2 %}two{% else %}many{% endif %}\n{% if n < 0 %}negative{% endif %}{% include \"footer\" %}{% endblock %}",
+			"/footer": "footer",
+		}
+		*data = map[string]interface{}{"n": 2}
+		*trace = exec.NewTrace()
+	})
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(*templates)
+		t, err := exec.NewTemplate("/page", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		ctx := exec.NewContext(*data)
+		ctx.SetTrace(*trace)
+		_, *returnedErr = t.ExecuteToString(ctx)
+	})
+	It("should record the rendered blocks", func() {
+		Expect(*returnedErr).To(BeNil())
+		Expect((*trace).Blocks()).To(Equal([]exec.TracedBlock{
+			{Name: "header", Identifier: "/layout", Overridden: false},
+			{Name: "body", Identifier: "/page", Overridden: true},
+		}))
+	})
+	It("should record the included templates", func() {
+		Expect(*returnedErr).To(BeNil())
+		Expect((*trace).Includes()).To(Equal([]exec.TracedInclude{
+			{Identifier: "/page", Line: 3, Name: "footer", Resolved: "/footer"},
+		}))
+	})
+	It("should record the branches taken", func() {
+		Expect(*returnedErr).To(BeNil())
+		Expect((*trace).Branches()).To(Equal([]exec.TracedBranch{
+			{Identifier: "/page", Line: 2, Branch: 1, Branches: 3},
+			{Identifier: "/page", Line: 3, Branch: -1, Branches: 1},
+		}))
+	})
+	Context("when the else branch is taken", func() {
+		BeforeEach(func() {
+			(*data)["n"] = 5
+		})
+		It("should record its index", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect((*trace).Branches()[0]).To(Equal(exec.TracedBranch{Identifier: "/page", Line: 2, Branch: 2, Branches: 3}))
+		})
+	})
+})
@@ -1,22 +1,28 @@
 package exec
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
-
-	"github.com/pkg/errors"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/nikolalohinski/gonja/v2/config"
 	u "github.com/nikolalohinski/gonja/v2/utils"
 )
 
 type Value struct {
 	Val  reflect.Value
 	Safe bool // used to indicate whether a Value needs explicit escaping in the template
+	// pooled is set on values taken from the value pool, see releaseValue
+	pooled bool
+	// undefined is set on the values of missing variables, attributes and items, which are printed
+	// as an empty string even when none values are not
+	undefined bool
 }
 
 // AsValue converts any given Value to a gonja.Value.
@@ -40,6 +46,10 @@ func AsSafeValue(i interface{}) *Value {
 	}
 }
 
+func undefinedValue() *Value {
+	return &Value{Val: reflect.ValueOf(nil), undefined: true}
+}
+
 func ValueError(err error) *Value {
 	return &Value{Val: reflect.ValueOf(err)}
 }
@@ -82,6 +92,18 @@ func (v *Value) IsNumber() bool {
 	return v.IsInteger() || v.IsFloat()
 }
 
+// IsTime checks whether the underlying value is a time.Time
+func (v *Value) IsTime() bool {
+	resolved := v.getResolvedValue()
+	return resolved.IsValid() && resolved.Type() == typeOfTime
+}
+
+// IsDuration checks whether the underlying value is a time.Duration
+func (v *Value) IsDuration() bool {
+	resolved := v.getResolvedValue()
+	return resolved.IsValid() && resolved.Type() == typeOfDuration
+}
+
 func (v *Value) IsCallable() bool {
 	return v.getResolvedValue().Kind() == reflect.Func
 }
@@ -120,6 +142,14 @@ func (v *Value) Error() string {
 	return ""
 }
 
+// Unwrap returns the error held by the value, if any, so that errors.Is and errors.As see through values
+func (v *Value) Unwrap() error {
+	if v.IsError() {
+		return v.Interface().(error)
+	}
+	return nil
+}
+
 func (v *Value) ToGoSimpleType(allowInterfaceKeys bool) interface{} {
 	switch {
 	case v.IsError():
@@ -206,7 +236,8 @@ func (v *Value) ToGoSimpleType(allowInterfaceKeys bool) interface{} {
 //  3. float (any precision)
 //  4. bool
 //  5. time.Time
-//  6. String() will be called on the underlying value if provided
+//  6. time.Duration
+//  7. String() will be called on the underlying value if provided
 //
 // nil values will lead to an empty string. For unsupported types, String will
 // return to the type's name.
@@ -215,6 +246,9 @@ func (v *Value) String() string {
 		return ""
 	}
 	resolved := v.getResolvedValue()
+	if resolved.Type() == typeOfDuration {
+		return time.Duration(resolved.Int()).String()
+	}
 
 	switch resolved.Kind() {
 	case reflect.String:
@@ -352,6 +386,30 @@ func (v *Value) Float() float64 {
 	}
 }
 
+// Time returns the underlying value as a time.Time. If the value is not a time.Time,
+// the zero time will be returned.
+func (v *Value) Time() time.Time {
+	if !v.IsTime() {
+		log.Errorf("Value.Time() not available for type: %s\n", v.getResolvedValue().Kind().String())
+		return time.Time{}
+	}
+	return v.getResolvedValue().Interface().(time.Time)
+}
+
+// Duration returns the underlying value as a time.Duration. Numbers are
+// considered as a number of seconds, and any other type leads to a zero duration.
+func (v *Value) Duration() time.Duration {
+	switch {
+	case v.IsDuration():
+		return time.Duration(v.getResolvedValue().Int())
+	case v.IsNumber():
+		return time.Duration(v.Float() * float64(time.Second))
+	default:
+		log.Errorf("Value.Duration() not available for type: %s\n", v.getResolvedValue().Kind().String())
+		return 0
+	}
+}
+
 // Bool returns the underlying value as a bool. If the value is not a bool, false
 // will always be returned. If you're looking for true/false-evaluation of the
 // underlying value, have a look at the IsTrue() function.
@@ -399,6 +457,32 @@ func (v *Value) IsTrue() bool {
 	}
 }
 
+// Truthy tells whether the value is considered true according to the truthiness
+// selected by the configuration, falling back to IsTrue when none is given
+func (v *Value) Truthy(cfg *config.Config) bool {
+	if cfg == nil || !cfg.ZeroValueTruthiness {
+		return v.IsTrue()
+	}
+	if v.IsNil() || v.IsError() {
+		return false
+	}
+	return !v.getResolvedValue().IsZero()
+}
+
+// IsUndefined tells whether the value is the one of a missing variable, attribute or item
+func (v *Value) IsUndefined() bool {
+	return v.undefined
+}
+
+// Printed returns the text the value is printed as according to the configuration, which is the one
+// returned by String unless none values are printed as 'None'
+func (v *Value) Printed(cfg *config.Config) string {
+	if cfg != nil && cfg.PythonNone && v.IsNil() && !v.undefined {
+		return "None"
+	}
+	return v.String()
+}
+
 // Negate tries to negate the underlying value. It's mainly used for
 // the NOT-operator and in conjunction with a call to
 // return_value.IsTrue() afterwards.
@@ -507,18 +591,17 @@ func (v *Value) Contains(other *Value) bool {
 		fieldValue := resolved.FieldByName(other.String())
 		return fieldValue.IsValid()
 	case reflect.Map:
-		var mapValue reflect.Value
-		switch other.Interface().(type) {
-		case int:
-			mapValue = resolved.MapIndex(other.getResolvedValue())
-		case string:
-			mapValue = resolved.MapIndex(other.getResolvedValue())
-		default:
-			log.Errorf("Value.Contains() does not support lookup type '%s'\n", other.getResolvedValue().Kind().String())
-			return false
+		key := other.getResolvedValue()
+		if key.IsValid() && key.Type().AssignableTo(resolved.Type().Key()) {
+			return resolved.MapIndex(key).IsValid()
 		}
-
-		return mapValue.IsValid()
+		// Fallback on comparing values to support keys of a different type, e.g. uint with int
+		for _, mapKey := range resolved.MapKeys() {
+			if ToValue(mapKey).EqualValueTo(other) {
+				return true
+			}
+		}
+		return false
 	case reflect.String:
 		return strings.Contains(resolved.String(), other.String())
 
@@ -527,8 +610,7 @@ func (v *Value) Contains(other *Value) bool {
 			return vl.Contains(other)
 		}
 		for i := 0; i < resolved.Len(); i++ {
-			item := resolved.Index(i)
-			if other.Interface() == item.Interface() {
+			if ToValue(resolved.Index(i)).EqualValueTo(other) {
 				return true
 			}
 		}
@@ -550,7 +632,7 @@ func (v *Value) CanSlice() bool {
 	return false
 }
 
-// Iterate iterates over a map, array, slice or a string. It calls the
+// Iterate iterates over a map, array, slice, string, channel or iterator, see Iterator. It calls the
 // function's first argument for every value with the following arguments:
 //
 //	idx      current 0-index
@@ -568,6 +650,15 @@ func (v *Value) Iterate(fn func(idx, count int, key, value *Value) bool, empty f
 // not affect the iteration through a map because maps don't have any particular order.
 // However, you can force an order using the `sorted` keyword (and even use `reversed sorted`).
 func (v *Value) IterateOrder(fn func(idx, count int, key, value *Value) bool, empty func(), reverse bool, sorted bool, caseSensitive bool) {
+	if next, ok := v.Iterator(nil); ok {
+		// channels and iterators are drained first, so that they can be counted and sorted like lists
+		items := ValuesList{}
+		for item, ok := next(); ok; item, ok = next() {
+			items = append(items, item)
+		}
+		AsValue(items).IterateOrder(fn, empty, reverse, sorted, caseSensitive)
+		return
+	}
 	resolved := v.getResolvedValue()
 	switch resolved.Kind() {
 	case reflect.Map:
@@ -675,24 +766,6 @@ func (v *Value) IterateOrder(fn func(idx, count int, key, value *Value) bool, em
 			empty()
 		}
 		return // done
-	case reflect.Chan:
-		items := []reflect.Value{}
-		for {
-			value, ok := resolved.Recv()
-			if !ok {
-				break
-			}
-			items = append(items, value)
-		}
-		count := len(items)
-		if count > 0 {
-			for idx, value := range items {
-				fn(idx, count, &Value{Val: value}, nil)
-			}
-		} else {
-			empty()
-		}
-		return
 	case reflect.Struct:
 		if resolved.Type() != TypeDict {
 			log.Errorf("Value.Iterate() not available for type: %s\n", resolved.Kind().String())
@@ -749,7 +822,16 @@ func (v *Value) EqualValueTo(other *Value) bool {
 	if v.IsNumber() && other.IsNumber() {
 		return v.Float() == other.Float()
 	}
-	return v.Interface() == other.Interface()
+	// times in different locations can represent the same instant
+	if v.IsTime() && other.IsTime() {
+		return v.Time().Equal(other.Time())
+	}
+	left, right := v.Interface(), other.Interface()
+	// comparing uncomparable types such as slices or maps with == panics
+	if left != nil && right != nil && (!reflect.TypeOf(left).Comparable() || !reflect.TypeOf(right).Comparable()) {
+		return reflect.DeepEqual(left, right)
+	}
+	return left == right
 }
 
 func (v *Value) Keys() ValuesList {
@@ -926,7 +1008,7 @@ func (v *Value) GetItem(key interface{}) (*Value, bool) {
 				}
 			}
 		default:
-			return AsValue(errors.Errorf("Can't access an index on type %s (variable %s)", val.Kind().String(), v)), false
+			return AsValue(fmt.Errorf("Can't access an index on type %s (variable %s)", val.Kind().String(), v)), false
 		}
 	default:
 		return AsValue(nil), false
@@ -952,25 +1034,25 @@ func (v *Value) Set(key *Value, value interface{}) error {
 		val = val.Elem()
 		if !val.IsValid() {
 			// Value is not valid (anymore)
-			return errors.Errorf(`Invalid value "%s"`, val)
+			return fmt.Errorf(`Invalid value "%s"`, val)
 		}
 	}
 
 	switch val.Kind() {
 	case reflect.Struct:
 		if !key.IsString() {
-			return errors.Errorf(`Can't write non-string field "%s" to struct: %s`, key.String(), value)
+			return fmt.Errorf(`Can't write non-string field "%s" to struct: %s`, key.String(), value)
 		}
 		field := val.FieldByName(key.String())
 		if field.IsValid() && field.CanSet() {
 			field.Set(reflect.ValueOf(value))
 		} else {
-			return errors.Errorf(`Can't write field "%s"`, key.String())
+			return fmt.Errorf(`Can't write field "%s"`, key.String())
 		}
 	case reflect.Map:
 		val.SetMapIndex(key.Val, reflect.ValueOf(value))
 	default:
-		return errors.Errorf(`Unknown type "%s", can't set value on "%s"`, val.Kind(), key.String())
+		return fmt.Errorf(`Unknown type "%s", can't set value on "%s"`, val.Kind(), key.String())
 	}
 
 	return nil
@@ -0,0 +1,367 @@
+package exec
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the layouts of the strings converted by Value.AsTime, tried in order
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// AsInt64 returns the underlying value as an int64. Integers are returned as is, floats when they have
+// no fractional part and strings when they hold an integer. Any other value returns an error.
+func (v *Value) AsInt64() (int64, error) {
+	resolved := v.getResolvedValue()
+	switch resolved.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return resolved.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if resolved.Uint() > math.MaxInt64 {
+			return 0, v.conversionError("int64")
+		}
+		return int64(resolved.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := resolved.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, v.conversionError("int64")
+		}
+		return int64(f), nil
+	case reflect.String:
+		i, err := strconv.ParseInt(strings.TrimSpace(resolved.String()), 10, 64)
+		if err != nil {
+			return 0, v.conversionError("int64")
+		}
+		return i, nil
+	}
+	return 0, v.conversionError("int64")
+}
+
+// AsFloat returns the underlying value as a float64. Numbers are converted and strings are parsed,
+// any other value returns an error.
+func (v *Value) AsFloat() (float64, error) {
+	resolved := v.getResolvedValue()
+	switch resolved.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(resolved.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(resolved.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return resolved.Float(), nil
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(resolved.String()), 64)
+		if err != nil {
+			return 0, v.conversionError("float64")
+		}
+		return f, nil
+	}
+	return 0, v.conversionError("float64")
+}
+
+// AsTime returns the underlying value as a time.Time. Strings are parsed as RFC 3339 timestamps, falling
+// back to dates and times without any time zone, which are read as UTC, and numbers are read as Unix
+// timestamps in seconds. Any other value returns an error.
+func (v *Value) AsTime() (time.Time, error) {
+	if v.IsTime() {
+		return v.getResolvedValue().Interface().(time.Time), nil
+	}
+	switch {
+	case v.IsString():
+		s := strings.TrimSpace(v.String())
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+	case v.IsInteger():
+		if seconds, err := v.AsInt64(); err == nil {
+			return time.Unix(seconds, 0).UTC(), nil
+		}
+	case v.IsFloat():
+		seconds, fraction := math.Modf(v.Float())
+		return time.Unix(int64(seconds), int64(fraction*float64(time.Second))).UTC(), nil
+	}
+	return time.Time{}, v.conversionError("time.Time")
+}
+
+// AsStringSlice returns the items of the underlying list as strings. Strings, numbers and booleans are
+// converted with Value.String, any other item returns an error, as does any value which is not a list.
+func (v *Value) AsStringSlice() ([]string, error) {
+	if !v.IsList() {
+		return nil, v.conversionError("[]string")
+	}
+	out := make([]string, 0, v.Len())
+	for index := 0; index < v.Len(); index++ {
+		item := v.item(index)
+		if !item.IsString() && !item.IsNumber() && !item.IsBool() {
+			return nil, fmt.Errorf("item %d: %w", index, item.conversionError("string"))
+		}
+		out = append(out, item.String())
+	}
+	return out, nil
+}
+
+// item returns the item of the underlying list at the given index, unwrapping the values held by the lists
+// evaluated from templates
+func (v *Value) item(index int) *Value {
+	return ToValue(v.Index(index).Interface())
+}
+
+// AsMap returns the underlying dict as a map keyed by strings, whose values are kept as is. Keys which
+// are not strings are converted with Value.String, and any value which is not a dict returns an error.
+func (v *Value) AsMap() (map[string]interface{}, error) {
+	if !v.IsDict() {
+		return nil, v.conversionError("map[string]interface {}")
+	}
+	out := map[string]interface{}{}
+	for _, pair := range v.pairs() {
+		out[pair.Key.String()] = pair.Value.Interface()
+	}
+	return out, nil
+}
+
+// Decode stores the underlying value in the value pointed to by target, the way mapstructure decodes
+// maps into structs. Dicts are decoded into structs and maps, lists into slices and arrays, and scalars
+// are converted with the other As* methods, so that the string "3" can be decoded into an int for
+// instance. Struct fields are matched against the keys of dicts by the name given in their `gonja` or
+// `json` tag, or else by their name regardless of case, and fields of embedded structs are decoded from
+// the same dict. Fields without any matching key are left untouched, as are keys without any matching field.
+//
+// Errors mention the path of the value that could not be decoded, such as 'servers[1].port'.
+func (v *Value) Decode(target interface{}) error {
+	out := reflect.ValueOf(target)
+	if out.Kind() != reflect.Ptr || out.IsNil() {
+		return fmt.Errorf("unable to decode into %T: not a non-nil pointer", target)
+	}
+	return v.decode(out.Elem(), "")
+}
+
+func (v *Value) decode(out reflect.Value, path string) error {
+	resolved := v.getResolvedValue()
+	for _, candidate := range []reflect.Value{v.Val, resolved} {
+		if candidate.IsValid() && candidate.CanInterface() && candidate.Type().AssignableTo(out.Type()) {
+			out.Set(candidate)
+			return nil
+		}
+	}
+	if v.IsNil() {
+		switch out.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			out.Set(reflect.Zero(out.Type()))
+			return nil
+		}
+		return v.decodeError(path, out.Type())
+	}
+
+	switch out.Type() {
+	case typeOfTime:
+		t, err := v.AsTime()
+		if err != nil {
+			return v.decodeError(path, out.Type())
+		}
+		out.Set(reflect.ValueOf(t))
+		return nil
+	case typeOfDuration:
+		if v.IsString() {
+			d, err := time.ParseDuration(strings.TrimSpace(v.String()))
+			if err != nil {
+				return v.decodeError(path, out.Type())
+			}
+			out.SetInt(int64(d))
+			return nil
+		}
+	}
+
+	switch out.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(out.Type().Elem())
+		if err := v.decode(elem.Elem(), path); err != nil {
+			return err
+		}
+		out.Set(elem)
+	case reflect.Bool:
+		if !v.IsBool() {
+			return v.decodeError(path, out.Type())
+		}
+		out.SetBool(resolved.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := v.AsInt64()
+		if err != nil || out.OverflowInt(i) {
+			return v.decodeError(path, out.Type())
+		}
+		out.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, err := v.AsInt64()
+		if err != nil || i < 0 || out.OverflowUint(uint64(i)) {
+			return v.decodeError(path, out.Type())
+		}
+		out.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, err := v.AsFloat()
+		if err != nil || out.OverflowFloat(f) {
+			return v.decodeError(path, out.Type())
+		}
+		out.SetFloat(f)
+	case reflect.String:
+		if !v.IsString() && !v.IsNumber() && !v.IsBool() {
+			return v.decodeError(path, out.Type())
+		}
+		out.SetString(v.String())
+	case reflect.Slice:
+		if !v.IsList() {
+			return v.decodeError(path, out.Type())
+		}
+		slice := reflect.MakeSlice(out.Type(), v.Len(), v.Len())
+		for index := 0; index < v.Len(); index++ {
+			if err := v.item(index).decode(slice.Index(index), fmt.Sprintf("%s[%d]", path, index)); err != nil {
+				return err
+			}
+		}
+		out.Set(slice)
+	case reflect.Array:
+		if !v.IsList() || v.Len() > out.Len() {
+			return v.decodeError(path, out.Type())
+		}
+		for index := 0; index < v.Len(); index++ {
+			if err := v.item(index).decode(out.Index(index), fmt.Sprintf("%s[%d]", path, index)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if !v.IsDict() {
+			return v.decodeError(path, out.Type())
+		}
+		m := reflect.MakeMap(out.Type())
+		for _, pair := range v.pairs() {
+			key := reflect.New(out.Type().Key()).Elem()
+			if err := pair.Key.decode(key, path); err != nil {
+				return err
+			}
+			value := reflect.New(out.Type().Elem()).Elem()
+			if err := pair.Value.decode(value, joinDecodePath(path, pair.Key.String())); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, value)
+		}
+		out.Set(m)
+	case reflect.Struct:
+		if !v.IsDict() {
+			return v.decodeError(path, out.Type())
+		}
+		items := map[string]*Value{}
+		for _, pair := range v.pairs() {
+			items[pair.Key.String()] = pair.Value
+		}
+		return decodeStruct(items, out, path, nil)
+	default:
+		return v.decodeError(path, out.Type())
+	}
+	return nil
+}
+
+// decodeStruct decodes the items of a dict into the fields of a struct, see Value.Decode. The keys of the
+// decoded items are recorded into used when it is not nil.
+func decodeStruct(items map[string]*Value, out reflect.Value, path string, used map[string]bool) error {
+	structType := out.Type()
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		name, skip := structFieldName(field)
+		if skip {
+			continue
+		}
+		fieldValue := out.Field(index)
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && embedded != typeOfTime && fieldValue.CanSet() {
+				if fieldValue.Kind() == reflect.Ptr {
+					if fieldValue.IsNil() {
+						fieldValue.Set(reflect.New(embedded))
+					}
+					fieldValue = fieldValue.Elem()
+				}
+				if err := decodeStruct(items, fieldValue, path, used); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		key, found := lookupDecodeKey(items, name, field.Name)
+		if !found {
+			continue
+		}
+		if err := items[key].decode(fieldValue, joinDecodePath(path, key)); err != nil {
+			return err
+		}
+		if used != nil {
+			used[key] = true
+		}
+	}
+	return nil
+}
+
+// lookupDecodeKey returns the key named by the tag of a field, or else the one matching its name
+// regardless of case
+func lookupDecodeKey(items map[string]*Value, tagged, name string) (string, bool) {
+	if tagged != "" {
+		_, found := items[tagged]
+		return tagged, found
+	}
+	if _, found := items[name]; found {
+		return name, true
+	}
+	for key := range items {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func joinDecodePath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// pairs returns the key/value pairs of a map or of a Dict
+func (v *Value) pairs() []*Pair {
+	resolved := v.getResolvedValue()
+	if resolved.Kind() == reflect.Struct && resolved.Type() == TypeDict {
+		return resolved.Interface().(Dict).Pairs
+	}
+	pairs := v.Items()
+	for _, pair := range pairs {
+		// items of maps of interfaces are held by interface values
+		pair.Key, pair.Value = ToValue(pair.Key.Val), ToValue(pair.Value.Val)
+	}
+	return pairs
+}
+
+// kind describes the type of the underlying value in errors
+func (v *Value) kind() string {
+	if v.IsNil() {
+		return "none"
+	}
+	return v.getResolvedValue().Type().String()
+}
+
+func (v *Value) conversionError(target string) error {
+	return fmt.Errorf("unable to convert %s value '%s' to %s", v.kind(), v.String(), target)
+}
+
+func (v *Value) decodeError(path string, target reflect.Type) error {
+	if path == "" {
+		return fmt.Errorf("unable to decode %s value '%s' into %s", v.kind(), v.String(), target)
+	}
+	return fmt.Errorf("unable to decode '%s': %s value '%s' can not be decoded into %s", path, v.kind(), v.String(), target)
+}
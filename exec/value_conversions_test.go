@@ -0,0 +1,172 @@
+package exec_test
+
+import (
+	"time"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("value conversions", func() {
+	Context("AsInt64", func() {
+		It("should convert integers, integral floats and numeric strings", func() {
+			Expect(exec.AsValue(uint8(42)).AsInt64()).To(Equal(int64(42)))
+			Expect(exec.AsValue(42.0).AsInt64()).To(Equal(int64(42)))
+			Expect(exec.AsValue(" -7 ").AsInt64()).To(Equal(int64(-7)))
+		})
+		It("should return an error for any other value", func() {
+			_, err := exec.AsValue(4.2).AsInt64()
+			Expect(err).To(MatchError("unable to convert float64 value '4.2' to int64"))
+			_, err = exec.AsValue(uint64(1 << 63)).AsInt64()
+			Expect(err).To(MatchError(ContainSubstring("to int64")))
+			_, err = exec.AsValue("forty-two").AsInt64()
+			Expect(err).To(MatchError("unable to convert string value 'forty-two' to int64"))
+			_, err = exec.AsValue(nil).AsInt64()
+			Expect(err).To(MatchError("unable to convert none value '' to int64"))
+		})
+	})
+	Context("AsFloat", func() {
+		It("should convert numbers and numeric strings", func() {
+			Expect(exec.AsValue(3).AsFloat()).To(Equal(3.0))
+			Expect(exec.AsValue("2.5").AsFloat()).To(Equal(2.5))
+		})
+		It("should return an error for any other value", func() {
+			_, err := exec.AsValue([]int{1}).AsFloat()
+			Expect(err).To(MatchError(ContainSubstring("to float64")))
+		})
+	})
+	Context("AsTime", func() {
+		It("should convert times, timestamps, dates and Unix timestamps", func() {
+			date := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+			Expect(exec.AsValue(date).AsTime()).To(Equal(date))
+			Expect(exec.AsValue("2024-03-01T12:30:00Z").AsTime()).To(BeTemporally("==", date))
+			Expect(exec.AsValue("2024-03-01").AsTime()).To(Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+			Expect(exec.AsValue(date.Unix()).AsTime()).To(Equal(date))
+			Expect(exec.AsValue(float64(date.Unix()) + 0.5).AsTime()).To(Equal(date.Add(500 * time.Millisecond)))
+		})
+		It("should return an error for any other value", func() {
+			_, err := exec.AsValue("yesterday").AsTime()
+			Expect(err).To(MatchError("unable to convert string value 'yesterday' to time.Time"))
+		})
+	})
+	Context("AsStringSlice", func() {
+		It("should convert the scalar items of lists", func() {
+			Expect(exec.AsValue([]interface{}{"a", 1, true}).AsStringSlice()).To(Equal([]string{"a", "1", "True"}))
+		})
+		It("should convert the lists written in templates", func() {
+			Expect(exec.AsValue(exec.ValuesList{exec.AsValue("1"), exec.AsValue("2")}).AsStringSlice()).To(Equal([]string{"1", "2"}))
+		})
+		It("should return an error for other items and values", func() {
+			_, err := exec.AsValue([]interface{}{"a", []int{1}}).AsStringSlice()
+			Expect(err).To(MatchError(ContainSubstring("item 1: unable to convert []int value")))
+			_, err = exec.AsValue("a").AsStringSlice()
+			Expect(err).To(MatchError("unable to convert string value 'a' to []string"))
+		})
+	})
+	Context("AsMap", func() {
+		It("should convert maps and dicts", func() {
+			Expect(exec.AsValue(map[string]int{"a": 1}).AsMap()).To(Equal(map[string]interface{}{"a": 1}))
+			dict := &exec.Dict{Pairs: []*exec.Pair{{Key: exec.AsValue("b"), Value: exec.AsValue(2)}}}
+			Expect(exec.AsValue(dict).AsMap()).To(Equal(map[string]interface{}{"b": 2}))
+		})
+		It("should return an error for any other value", func() {
+			_, err := exec.AsValue([]int{}).AsMap()
+			Expect(err).To(MatchError(ContainSubstring("unable to convert []int value")))
+		})
+	})
+	Context("Decode", func() {
+		type Base struct {
+			Name string
+		}
+		type Server struct {
+			Host string
+			Port uint16
+		}
+		type Config struct {
+			Base
+			Enabled   bool              `json:"enabled"`
+			Ratio     float32           `gonja:"ratio"`
+			Timeout   time.Duration     `json:"timeout"`
+			Start     time.Time         `json:"start"`
+			Tags      []string          `json:"tags"`
+			Servers   []*Server         `json:"servers"`
+			Labels    map[string]string `json:"labels"`
+			Ignored   string            `gonja:"-"`
+			Untouched string
+		}
+		var (
+			input = new(interface{})
+
+			config      = new(Config)
+			returnedErr = new(error)
+		)
+		BeforeEach(func() {
+			*config = Config{Ignored: "kept", Untouched: "kept"}
+			*input = map[string]interface{}{
+				"name":    "api",
+				"enabled": true,
+				"ratio":   "0.5",
+				"timeout": "1m30s",
+				"start":   "2024-03-01",
+				"tags":    []interface{}{"a", 2},
+				"servers": []interface{}{
+					map[string]interface{}{"host": "a.example.com", "port": 80},
+					&exec.Dict{Pairs: []*exec.Pair{
+						{Key: exec.AsValue("Host"), Value: exec.AsValue("b.example.com")},
+						{Key: exec.AsValue("port"), Value: exec.AsValue("8080")},
+					}},
+				},
+				"labels":  map[string]interface{}{"team": "core", "tier": 1},
+				"Ignored": "overwritten",
+				"unknown": "skipped",
+			}
+		})
+		JustBeforeEach(func() {
+			*returnedErr = exec.AsValue(*input).Decode(config)
+		})
+		It("should decode the value into the target", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*config).To(Equal(Config{
+				Base:      Base{Name: "api"},
+				Enabled:   true,
+				Ratio:     0.5,
+				Timeout:   90 * time.Second,
+				Start:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				Tags:      []string{"a", "2"},
+				Servers:   []*Server{{Host: "a.example.com", Port: 80}, {Host: "b.example.com", Port: 8080}},
+				Labels:    map[string]string{"team": "core", "tier": "1"},
+				Ignored:   "kept",
+				Untouched: "kept",
+			}))
+		})
+		Context("when a value can not be decoded", func() {
+			BeforeEach(func() {
+				(*input).(map[string]interface{})["servers"] = []interface{}{map[string]interface{}{"port": 70000}}
+			})
+			It("should return an error mentioning its path", func() {
+				Expect(*returnedErr).To(MatchError("unable to decode 'servers[0].port': int value '70000' can not be decoded into uint16"))
+			})
+		})
+		Context("when the target is not a pointer", func() {
+			It("should return an error", func() {
+				Expect(exec.AsValue(1).Decode(1)).To(MatchError("unable to decode into int: not a non-nil pointer"))
+			})
+		})
+		Context("when decoding into an interface", func() {
+			It("should keep the value as is", func() {
+				var out interface{}
+				Expect(exec.AsValue([]int{1, 2}).Decode(&out)).To(Succeed())
+				Expect(out).To(Equal([]int{1, 2}))
+			})
+		})
+		Context("when decoding a list written in a template", func() {
+			It("should decode its items", func() {
+				var out []int
+				Expect(exec.AsValue(exec.ValuesList{exec.AsValue("1"), exec.AsValue(2)}).Decode(&out)).To(Succeed())
+				Expect(out).To(Equal([]int{1, 2}))
+			})
+		})
+	})
+})
@@ -2,6 +2,7 @@ package exec_test
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/nikolalohinski/gonja/v2/exec"
 
@@ -172,6 +173,16 @@ var _ = Context("value", func() {
 					func() { Expect((*returnedValue).IsTrue()).To(BeTrue(), ".IsTrue()") },
 				},
 			},
+			{
+				time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+				"a time",
+				[]func(){
+					func() { Expect((*returnedValue).String()).To(Equal("2024-03-01 00:00:00 +0000 UTC"), ".String()") },
+					func() { Expect((*returnedValue).IsTime()).To(BeTrue(), ".IsTime()") },
+					func() { Expect((*returnedValue).Time().Year()).To(Equal(2024), ".Time()") },
+					func() { Expect((*returnedValue).IsTrue()).To(BeTrue(), ".IsTrue()") },
+				},
+			},
 			{
 				func() {},
 				"a function",
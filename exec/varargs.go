@@ -1,12 +1,12 @@
 package exec
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 
 	humanize "github.com/dustin/go-humanize"
-	"github.com/pkg/errors"
 )
 
 // VarArgs represents pythonic variadic args/kwargs
@@ -56,9 +56,9 @@ func (v *VarArgs) Expect(arguments int, keywordArguments []*KwArg) *ReducedVarAr
 	if len(v.Args) < arguments {
 		// Priority on missing arguments
 		if arguments > 1 {
-			result.error = errors.Errorf(`expected %d arguments, got %d`, arguments, len(v.Args))
+			result.error = fmt.Errorf(`expected %d arguments, got %d`, arguments, len(v.Args))
 		} else {
-			result.error = errors.Errorf(`expected an argument, got %d`, len(v.Args))
+			result.error = fmt.Errorf(`expected an argument, got %d`, len(v.Args))
 		}
 		return result
 	} else if len(v.Args) > arguments {
@@ -82,7 +82,7 @@ Loop:
 					copiedVariableArguments.KwArgs[key] = value
 					continue Loop
 				} else {
-					result.error = errors.Errorf(`keyword '%s' has been submitted twice`, key)
+					result.error = fmt.Errorf(`keyword '%s' has been submitted twice`, key)
 					break Loop
 				}
 			}
@@ -99,15 +99,15 @@ Loop:
 	switch {
 	case len(unexpectedArgs) == 0 && len(unexpectedKwArgs) == 0:
 	case len(unexpectedArgs) == 1 && len(unexpectedKwArgs) == 0:
-		result.error = errors.Errorf(`unexpected argument '%s'`, unexpectedArgs[0])
+		result.error = fmt.Errorf(`unexpected argument '%s'`, unexpectedArgs[0])
 	case len(unexpectedArgs) > 1 && len(unexpectedKwArgs) == 0:
-		result.error = errors.Errorf(`unexpected arguments '%s'`, strings.Join(unexpectedArgs, ", "))
+		result.error = fmt.Errorf(`unexpected arguments '%s'`, strings.Join(unexpectedArgs, ", "))
 	case len(unexpectedArgs) == 0 && len(unexpectedKwArgs) == 1:
-		result.error = errors.Errorf(`unexpected keyword argument '%s'`, unexpectedKwArgs[0])
+		result.error = fmt.Errorf(`unexpected keyword argument '%s'`, unexpectedKwArgs[0])
 	case len(unexpectedArgs) == 0 && len(unexpectedKwArgs) > 0:
-		result.error = errors.Errorf(`unexpected keyword arguments '%s'`, strings.Join(unexpectedKwArgs, ", "))
+		result.error = fmt.Errorf(`unexpected keyword arguments '%s'`, strings.Join(unexpectedKwArgs, ", "))
 	default:
-		result.error = errors.Errorf(`unexpected arguments '%s, %s'`,
+		result.error = fmt.Errorf(`unexpected arguments '%s, %s'`,
 			strings.Join(unexpectedArgs, ", "),
 			strings.Join(unexpectedKwArgs, ", "),
 		)
@@ -345,7 +345,7 @@ func (v *VarArgs) Take(arguments ...*argument) error {
 		}
 		for _, transmute := range argument.transmuters {
 			if err := transmute(value); err != nil {
-				return errors.Errorf("failed to validate argument '%s': %s", argument.name, err.Error())
+				return fmt.Errorf("failed to validate argument '%s': %w", argument.name, err)
 			}
 		}
 	}
@@ -0,0 +1,35 @@
+//go:build !js
+
+package gonja
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+)
+
+// DefaultLoader loads templates from the file system, relatively to the current working directory
+var DefaultLoader = loaders.MustNewFileSystemLoader("")
+
+// newBaseLoader returns the loader resolving the templates included by templates created from strings or bytes
+func newBaseLoader() (loaders.Loader, error) {
+	return loaders.NewFileSystemLoader("")
+}
+
+// FromFile creates a template out of a file, resolving the templates it references relatively to its directory.
+// Errors are located with an *Error, and match ErrTemplateNotFound when the file or its directory do not exist.
+func FromFile(filepath string) (*exec.Template, error) {
+	loader, err := loaders.NewFileSystemLoader(path.Dir(filepath))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = fmt.Errorf("%w: %w", ErrTemplateNotFound, err)
+		}
+		return nil, &Error{Identifier: filepath, Err: err}
+	}
+
+	return exec.NewTemplate(path.Base(filepath), DefaultConfig, loader, DefaultEnvironment)
+}
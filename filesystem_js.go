@@ -0,0 +1,24 @@
+//go:build js
+
+package gonja
+
+import (
+	"errors"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+)
+
+// DefaultLoader holds no template, since browsers have no file system to load them from. Templates
+// including others can be loaded with loaders.NewMemoryLoader and exec.NewTemplate instead.
+var DefaultLoader = loaders.MustNewMemoryLoader(map[string]string{})
+
+// newBaseLoader returns the loader resolving the templates included by templates created from strings or bytes
+func newBaseLoader() (loaders.Loader, error) {
+	return loaders.NewMemoryLoader(map[string]string{})
+}
+
+// FromFile is not supported on js/wasm, where templates have to be given as strings or bytes
+func FromFile(filepath string) (*exec.Template, error) {
+	return nil, errors.New("loading templates from files is not supported on js/wasm")
+}
@@ -5,7 +5,6 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
-	"path"
 
 	"github.com/nikolalohinski/gonja/v2/builtins"
 	"github.com/nikolalohinski/gonja/v2/config"
@@ -15,7 +14,6 @@ import (
 )
 
 var (
-	DefaultLoader      = loaders.MustNewFileSystemLoader("")
 	DefaultConfig      = config.New()
 	DefaultContext     = exec.EmptyContext().Update(builtins.GlobalFunctions).Update(builtins.GlobalVariables)
 	DefaultEnvironment = &exec.Environment{
@@ -42,7 +40,7 @@ func FromString(source string) (*exec.Template, error) {
 func FromBytes(source []byte) (*exec.Template, error) {
 	rootID := fmt.Sprintf("root-%s", string(sha256.New().Sum(source)))
 
-	loader, err := loaders.NewFileSystemLoader("")
+	loader, err := newBaseLoader()
 	if err != nil {
 		return nil, err
 	}
@@ -53,12 +51,3 @@ func FromBytes(source []byte) (*exec.Template, error) {
 
 	return exec.NewTemplate(rootID, DefaultConfig, shiftedLoader, DefaultEnvironment)
 }
-
-func FromFile(filepath string) (*exec.Template, error) {
-	loader, err := loaders.NewFileSystemLoader(path.Dir(filepath))
-	if err != nil {
-		return nil, err
-	}
-
-	return exec.NewTemplate(path.Base(filepath), DefaultConfig, loader, DefaultEnvironment)
-}
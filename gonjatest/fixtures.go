@@ -0,0 +1,101 @@
+package gonjatest
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture is a snippet along with the data it is rendered with and the expected result
+type Fixture struct {
+	Name     string
+	Template string
+	Data     map[string]interface{}
+	// Expected is the expected output, when Error is empty
+	Expected string
+	// Error is a part of the message of the error expected when rendering the snippet
+	Error string
+}
+
+// RunFixtures renders each fixture in its own subtest, failing the ones whose result is not the expected one
+func RunFixtures(t *testing.T, fixtures []Fixture, options ...Option) {
+	t.Helper()
+	for _, fixture := range fixtures {
+		fixture := fixture
+		t.Run(fixture.Name, func(t *testing.T) {
+			t.Helper()
+			if fixture.Error == "" {
+				ExpectRender(t, fixture.Template, fixture.Data, fixture.Expected, options...)
+				return
+			}
+			err := RenderError(t, fixture.Template, fixture.Data, options...)
+			if err != nil && !strings.Contains(err.Error(), fixture.Error) {
+				t.Errorf("expected error %q to contain %q", err, fixture.Error)
+			}
+		})
+	}
+}
+
+// LoadFixtures reads fixtures from a directory, failing the test if it cannot be read.
+//
+// Each fixture is made of a template named after it with the .j2 extension, and of a file with the same
+// name holding either the expected output with the .out extension or a part of the expected error with
+// the .err extension. Data can be given in a file with the same name and the .json or .yaml extension.
+// Trailing line breaks of templates, expected outputs and errors are ignored.
+func LoadFixtures(t testing.TB, directory string) []Fixture {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(directory, "*.j2"))
+	if err != nil {
+		t.Fatalf("failed to list fixtures: %s", err)
+		return nil
+	}
+	sort.Strings(paths)
+	fixtures := make([]Fixture, 0, len(paths))
+	for _, path := range paths {
+		base := strings.TrimSuffix(path, ".j2")
+		fixture := Fixture{Name: filepath.Base(base)}
+		template, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read fixture '%s': %s", fixture.Name, err)
+			return nil
+		}
+		fixture.Template = strings.TrimRight(string(template), "\n")
+
+		if expected, err := os.ReadFile(base + ".out"); err == nil {
+			fixture.Expected = strings.TrimRight(string(expected), "\n")
+		} else if expected, err := os.ReadFile(base + ".err"); err == nil {
+			fixture.Error = strings.TrimRight(string(expected), "\n")
+		} else {
+			t.Fatalf("fixture '%s' has neither an .out nor an .err file", fixture.Name)
+			return nil
+		}
+
+		if fixture.Data, err = readData(base); err != nil {
+			t.Fatalf("failed to decode data of fixture '%s': %s", fixture.Name, err)
+			return nil
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	return fixtures
+}
+
+// readData decodes the data found next to a template in a file with the .json or .yaml extension, if any.
+// Data is decoded as YAML, which JSON is a subset of, so that integers are not turned into floats.
+func readData(base string) (map[string]interface{}, error) {
+	for _, extension := range []string{".json", ".yaml"} {
+		content, err := os.ReadFile(base + extension)
+		if err != nil {
+			continue
+		}
+		data := map[string]interface{}{}
+		if err := yaml.Unmarshal(content, &data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	return nil, nil
+}
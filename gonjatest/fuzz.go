@@ -0,0 +1,87 @@
+package gonjatest
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// Inputs returns values of every kind templates commonly handle, including edge cases such as empty
+// collections, nil values and special floats, to check that filters cope with unexpected inputs
+func Inputs() []interface{} {
+	return []interface{}{
+		nil,
+		"",
+		"text",
+		"  Mixed Case ünicode ✓  ",
+		"<b>markup</b> & \"quotes\"",
+		"line\nbreaks\r\n",
+		0,
+		-42,
+		math.MaxInt64,
+		3.14,
+		-0.5,
+		math.Inf(1),
+		math.NaN(),
+		true,
+		false,
+		[]interface{}{},
+		[]interface{}{1, "two", 3.0},
+		[]string{"b", "a"},
+		map[string]interface{}{},
+		map[string]interface{}{"key": "value", "nested": map[string]interface{}{"list": []interface{}{1}}},
+	}
+}
+
+// CheckFilter applies a filter to each of the values returned by Inputs, and fails the test if it panics.
+// The filter is given as it would be written in a template, with its arguments if any, such as "truncate(5)",
+// and errors returned for unsupported inputs are not considered as failures.
+func CheckFilter(t testing.TB, filter string, options ...Option) {
+	t.Helper()
+	template, err := NewTemplate("{{ value | "+filter+" }}", options...)
+	if err != nil {
+		t.Fatalf("failed to parse filter %q: %s", filter, err)
+		return
+	}
+	for _, input := range Inputs() {
+		applyFilter(t, filter, func() { _, _ = template.RenderToString(map[string]interface{}{"value": input}) }, input)
+	}
+}
+
+// FuzzFilter fuzzes a filter with strings, integers, floats, booleans and lists made of them, failing when
+// it panics. The filter is given as with CheckFilter. It is meant to be called from fuzz tests:
+//
+//	func FuzzShout(f *testing.F) {
+//		gonjatest.FuzzFilter(f, "shout", gonjatest.WithFilter("shout", shout))
+//	}
+func FuzzFilter(f *testing.F, filter string, options ...Option) {
+	f.Helper()
+	template, err := NewTemplate("{{ value | "+filter+" }}", options...)
+	if err != nil {
+		f.Fatalf("failed to parse filter %q: %s", filter, err)
+		return
+	}
+	f.Add("", int64(0), 0.0, false)
+	f.Add("text", int64(-1), 3.14, true)
+	f.Add("  Mixed Case ünicode ✓  ", int64(math.MaxInt64), math.Inf(-1), false)
+	f.Add("<b>markup</b>\n", int64(math.MinInt64), math.NaN(), true)
+	f.Fuzz(func(t *testing.T, text string, integer int64, float float64, boolean bool) {
+		for _, input := range []interface{}{text, int(integer), float, boolean, []interface{}{text, int(integer), float, boolean}} {
+			applyFilter(t, filter, func() { _, _ = template.RenderToString(map[string]interface{}{"value": input}) }, input)
+		}
+	})
+}
+
+func applyFilter(t testing.TB, filter string, apply func(), input interface{}) {
+	t.Helper()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			t.Errorf("filter %q panicked with input %s: %v", filter, describe(input), recovered)
+		}
+	}()
+	apply()
+}
+
+func describe(input interface{}) string {
+	return fmt.Sprintf("%#v (%T)", input, input)
+}
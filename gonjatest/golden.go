@@ -0,0 +1,131 @@
+package gonjatest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+)
+
+// UpdateEnv is the environment variable updating golden files when set to a true value, as in
+// GONJATEST_UPDATE=1 go test ./...
+const UpdateEnv = "GONJATEST_UPDATE"
+
+// updateFlag is the name of the command line flag updating golden files when the tests define it
+const updateFlag = "update"
+
+// updating tells whether golden files are written instead of being compared, see Golden
+func updating(s *settings) bool {
+	if s.update {
+		return true
+	}
+	if update, err := strconv.ParseBool(os.Getenv(UpdateEnv)); err == nil && update {
+		return true
+	}
+	// the flag is left to the tests to define, as registering it here would clash with their own definition
+	f := flag.Lookup(updateFlag)
+	return f != nil && f.Value.String() == "true"
+}
+
+// Golden renders each template of a directory with the .j2 extension in its own subtest, and compares its
+// output with the content of the golden file with the same name and the .golden extension.
+//
+// Templates are rendered with the data found in the file with the same name and the .json or .yaml extension
+// if any, and they can reference any template of the directory, including the ones of its subdirectories which
+// are not rendered on their own. Golden files are written instead of being compared when the UpdateEnv
+// environment variable is true, as in GONJATEST_UPDATE=1 go test ./..., with the WithUpdate option, or when
+// the tests define an -update flag themselves and are run with it. Templates given with WithTemplates are ignored.
+func Golden(t *testing.T, directory string, options ...Option) {
+	t.Helper()
+	s := newSettings(options)
+	environment, err := s.builder.Build()
+	if err != nil {
+		t.Fatalf("failed to build environment: %s", err)
+		return
+	}
+	root, err := filepath.Abs(directory)
+	if err != nil {
+		t.Fatalf("failed to resolve directory: %s", err)
+		return
+	}
+	loader, err := loaders.NewFileSystemLoader(root)
+	if err != nil {
+		t.Fatalf("failed to create loader: %s", err)
+		return
+	}
+	paths, err := filepath.Glob(filepath.Join(root, "*.j2"))
+	if err != nil {
+		t.Fatalf("failed to list templates: %s", err)
+		return
+	}
+	if len(paths) == 0 {
+		t.Fatalf("no template found in '%s'", directory)
+		return
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		path := path
+		base := strings.TrimSuffix(path, ".j2")
+		t.Run(filepath.Base(base), func(t *testing.T) {
+			t.Helper()
+			data, err := readData(base)
+			if err != nil {
+				t.Fatalf("failed to decode data: %s", err)
+				return
+			}
+			template, err := exec.NewTemplate(path, s.config, loader, environment)
+			if err != nil {
+				t.Fatalf("%s", err)
+				return
+			}
+			out, err := template.RenderToString(data)
+			if err != nil {
+				t.Fatalf("failed to render: %s", err)
+				return
+			}
+			compareGolden(t, base+".golden", out, updating(s))
+		})
+	}
+}
+
+func compareGolden(t *testing.T, path, out string, update bool) {
+	t.Helper()
+	if update {
+		if err := os.WriteFile(path, []byte(out), 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %s", err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden file '%s' does not exist, run the tests with %s=1 to create it", path, UpdateEnv)
+		return
+	}
+	if err != nil {
+		t.Fatalf("failed to read golden file: %s", err)
+		return
+	}
+	if string(expected) == out {
+		return
+	}
+	expectedLines, outLines := strings.Split(string(expected), "\n"), strings.Split(out, "\n")
+	line := 0
+	for line < len(expectedLines) && line < len(outLines) && expectedLines[line] == outLines[line] {
+		line++
+	}
+	expectedLine, outLine := "<end of output>", "<end of output>"
+	if line < len(expectedLines) {
+		expectedLine = expectedLines[line]
+	}
+	if line < len(outLines) {
+		outLine = outLines[line]
+	}
+	t.Errorf("output differs from golden file '%s' at line %d, run the tests with %s=1 to update it:\n  expected: %q\n       got: %q",
+		path, line+1, UpdateEnv, expectedLine, outLine)
+}
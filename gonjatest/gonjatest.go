@@ -0,0 +1,178 @@
+// Package gonjatest provides helpers to test filters, tests, control structures and globals written for gonja,
+// by rendering snippets of templates in an environment extending the default one.
+//
+// The helpers work with any test framework able to provide a testing.TB, such as the standard testing package.
+package gonjatest
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/parser"
+)
+
+// SnippetIdentifier is the identifier of the rendered snippets, as found in error messages
+const SnippetIdentifier = "/snippet"
+
+// Option customizes the environment snippets are rendered in
+type Option func(*settings)
+
+type settings struct {
+	builder   *exec.EnvironmentBuilder
+	config    *config.Config
+	templates map[string]string
+	update    bool
+}
+
+// WithFilter registers a filter, replacing any built-in one with the same name
+func WithFilter(name string, filter exec.FilterFunction) Option {
+	return func(s *settings) { s.builder.WithFilter(name, filter) }
+}
+
+// WithTest registers a test, replacing any built-in one with the same name
+func WithTest(name string, test exec.TestFunction) Option {
+	return func(s *settings) { s.builder.WithTest(name, test) }
+}
+
+// WithControlStructure registers a control structure, replacing any built-in one with the same name
+func WithControlStructure(name string, controlStructure parser.ControlStructureParser) Option {
+	return func(s *settings) { s.builder.WithControlStructure(name, controlStructure) }
+}
+
+// WithGlobal defines a global variable or function
+func WithGlobal(name string, value interface{}) Option {
+	return func(s *settings) { s.builder.WithGlobal(name, value) }
+}
+
+// WithTemplates makes templates available to the include, import, from and extends statements of snippets.
+// Their identifiers must start with a slash, such as /macros.j2, and snippets can reference them as macros.j2.
+func WithTemplates(templates map[string]string) Option {
+	return func(s *settings) {
+		for identifier, source := range templates {
+			s.templates[identifier] = source
+		}
+	}
+}
+
+// WithConfig renders snippets with the given configuration instead of the default one
+func WithConfig(cfg *config.Config) Option {
+	return func(s *settings) { s.config = cfg }
+}
+
+// WithUpdate makes Golden write the golden files instead of comparing them when update is true, see Golden
+func WithUpdate(update bool) Option {
+	return func(s *settings) { s.update = update }
+}
+
+func newSettings(options []Option) *settings {
+	s := &settings{
+		builder:   exec.NewEnvironmentBuilder(gonja.DefaultEnvironment),
+		config:    gonja.DefaultConfig,
+		templates: map[string]string{},
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// NewTemplate parses a snippet in the default environment extended with the given options
+func NewTemplate(source string, options ...Option) (*exec.Template, error) {
+	s := newSettings(options)
+	environment, err := s.builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	s.templates[SnippetIdentifier] = source
+	loader, err := loaders.NewMemoryLoader(s.templates)
+	if err != nil {
+		return nil, err
+	}
+	return exec.NewTemplate(SnippetIdentifier, s.config, loader, environment)
+}
+
+// RenderString parses and renders a snippet with the given data
+func RenderString(source string, data map[string]interface{}, options ...Option) (string, error) {
+	template, err := NewTemplate(source, options...)
+	if err != nil {
+		return "", err
+	}
+	return template.RenderToString(data)
+}
+
+// Render renders a snippet with the given data, and fails the test if it cannot be parsed or rendered
+func Render(t testing.TB, source string, data map[string]interface{}, options ...Option) string {
+	t.Helper()
+	out, err := RenderString(source, data, options...)
+	if err != nil {
+		t.Fatalf("failed to render %q: %s", source, err)
+	}
+	return out
+}
+
+// ExpectRender fails the test unless the snippet renders to the expected output
+func ExpectRender(t testing.TB, source string, data map[string]interface{}, expected string, options ...Option) {
+	t.Helper()
+	out, err := RenderString(source, data, options...)
+	if err != nil {
+		t.Fatalf("failed to render %q: %s", source, err)
+		return
+	}
+	if out != expected {
+		t.Errorf("unexpected output when rendering %q:\n  expected: %q\n       got: %q", source, expected, out)
+	}
+}
+
+// RenderError renders a snippet which is expected to fail, failing the test when it does not,
+// and returns the error
+func RenderError(t testing.TB, source string, data map[string]interface{}, options ...Option) error {
+	t.Helper()
+	out, err := RenderString(source, data, options...)
+	if err == nil {
+		t.Fatalf("expected rendering %q to fail, got %q", source, out)
+	}
+	return err
+}
+
+var positionPattern = regexp.MustCompile(`\(Line: (\d+) Col: (\d+)`)
+
+// ErrorPosition returns the position reported by an error, such as the ones returned when a template
+// cannot be parsed, and whether the error reports any
+func ErrorPosition(err error) (int, int, bool) {
+	if err == nil {
+		return 0, 0, false
+	}
+	match := positionPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0, 0, false
+	}
+	line, _ := strconv.Atoi(match[1])
+	col, _ := strconv.Atoi(match[2])
+	return line, col, true
+}
+
+// ExpectErrorAt fails the test unless the error contains the given message and reports the given position
+func ExpectErrorAt(t testing.TB, err error, message string, line, col int) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected an error at line %d col %d, got none", line, col)
+		return
+	}
+	if !strings.Contains(err.Error(), message) {
+		t.Errorf("expected error %q to contain %q", err, message)
+	}
+	actualLine, actualCol, ok := ErrorPosition(err)
+	if !ok {
+		t.Errorf("expected error %q to report a position", err)
+		return
+	}
+	if actualLine != line || actualCol != col {
+		t.Errorf("expected error %q to be reported at line %d col %d, got line %d col %d", err, line, col, actualLine, actualCol)
+	}
+}
@@ -0,0 +1,154 @@
+package gonjatest_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/gonjatest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// recorder collects the failures reported by the helpers instead of failing the running spec
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func shout(_ *exec.Evaluator, in *exec.Value, _ *exec.VarArgs) *exec.Value {
+	return exec.AsValue(strings.ToUpper(in.String()) + "!")
+}
+
+func fragile(_ *exec.Evaluator, in *exec.Value, _ *exec.VarArgs) *exec.Value {
+	if in.IsNil() {
+		panic("nil input")
+	}
+	return in
+}
+
+var _ = Context("gonjatest", func() {
+	var t = new(*recorder)
+	BeforeEach(func() {
+		*t = &recorder{}
+	})
+	Context("when rendering snippets", func() {
+		It("should render them with the given extensions and templates", func() {
+			out := gonjatest.Render(GinkgoTB(), "{% include 'name.j2' %} {{ greeting | shout }}", map[string]interface{}{"greeting": "hi"},
+				gonjatest.WithFilter("shout", shout),
+				gonjatest.WithGlobal("name", "bob"),
+				gonjatest.WithTemplates(map[string]string{"/name.j2": "{{ name | capitalize }}"}),
+			)
+			Expect(out).To(Equal("Bob HI!"))
+		})
+		It("should report unexpected outputs", func() {
+			gonjatest.ExpectRender(*t, "{{ 1 + 1 }}", nil, "3")
+			Expect((*t).failures).To(Equal([]string{"unexpected output when rendering \"{{ 1 + 1 }}\":\n  expected: \"3\"\n       got: \"2\""}))
+		})
+		It("should report snippets failing to render", func() {
+			gonjatest.Render(*t, "{{ value | shout }}", nil)
+			Expect((*t).failures).To(HaveLen(1))
+			Expect((*t).failures[0]).To(HavePrefix(`failed to render "{{ value | shout }}": `))
+		})
+	})
+	Context("when asserting on errors", func() {
+		It("should check the message and the position", func() {
+			err := gonjatest.RenderError(GinkgoTB(), "Hello\n{{ name ", nil)
+			gonjatest.ExpectErrorAt(GinkgoTB(), err, "failed to parse template", 2, 9)
+			gonjatest.ExpectErrorAt(*t, err, "failed to parse template", 1, 1)
+			Expect((*t).failures).To(HaveLen(1))
+			Expect((*t).failures[0]).To(HaveSuffix("to be reported at line 1 col 1, got line 2 col 9"))
+		})
+		It("should report snippets rendering successfully", func() {
+			Expect(gonjatest.RenderError(*t, "{{ 42 }}", nil)).To(BeNil())
+			Expect((*t).failures).To(Equal([]string{`expected rendering "{{ 42 }}" to fail, got "42"`}))
+		})
+		It("should tell when errors do not report positions", func() {
+			_, _, ok := gonjatest.ErrorPosition(fmt.Errorf("no position"))
+			Expect(ok).To(BeFalse())
+		})
+	})
+	Context("when checking filters", func() {
+		It("should accept filters coping with every input", func() {
+			gonjatest.CheckFilter(*t, "truncate(5)")
+			gonjatest.CheckFilter(*t, "shout", gonjatest.WithFilter("shout", shout))
+			Expect((*t).failures).To(BeEmpty())
+		})
+		It("should report the inputs making filters panic", func() {
+			gonjatest.CheckFilter(*t, "fragile", gonjatest.WithFilter("fragile", fragile))
+			Expect((*t).failures).To(Equal([]string{`filter "fragile" panicked with input <nil> (<nil>): nil input`}))
+		})
+	})
+	Context("when loading fixtures", func() {
+		It("should read templates along with their data and expected results", func() {
+			fixtures := gonjatest.LoadFixtures(GinkgoTB(), "testdata/fixtures")
+			Expect(fixtures).To(Equal([]gonjatest.Fixture{
+				{Name: "broken", Template: "{{ missing( }}", Error: "failed to parse template"},
+				{Name: "greeting", Template: "{{ name | shout }}", Data: map[string]interface{}{"name": "bob"}, Expected: "BOB!"},
+				{Name: "joined", Template: "{{ items | join(sep) }}", Data: map[string]interface{}{"items": []interface{}{1, 2, 3}, "sep": "-"}, Expected: "1-2-3"},
+			}))
+		})
+	})
+})
+
+func TestFixtures(t *testing.T) {
+	gonjatest.RunFixtures(t, gonjatest.LoadFixtures(t, "testdata/fixtures"), gonjatest.WithFilter("shout", shout))
+}
+
+func FuzzShout(f *testing.F) {
+	gonjatest.FuzzFilter(f, "shout", gonjatest.WithFilter("shout", shout))
+}
+
+func TestGolden(t *testing.T) {
+	gonjatest.Golden(t, "testdata/golden", gonjatest.WithFilter("shout", shout))
+}
+
+// update is defined like consumers of the package do, which it must not clash with
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGoldenUpdate(t *testing.T) {
+	for name, enable := range map[string]func(t *testing.T) []gonjatest.Option{
+		"option": func(t *testing.T) []gonjatest.Option {
+			return []gonjatest.Option{gonjatest.WithUpdate(true)}
+		},
+		"environment": func(t *testing.T) []gonjatest.Option {
+			t.Setenv(gonjatest.UpdateEnv, "1")
+			return nil
+		},
+		"flag": func(t *testing.T) []gonjatest.Option {
+			*update = true
+			t.Cleanup(func() { *update = false })
+			return nil
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			directory := t.TempDir()
+			if err := os.WriteFile(filepath.Join(directory, "page.j2"), []byte("{{ 6 * 7 }}"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			gonjatest.Golden(t, directory, enable(t)...)
+
+			golden, err := os.ReadFile(filepath.Join(directory, "page.golden"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(golden) != "42" {
+				t.Errorf("unexpected golden file content %q", golden)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package gonjatest_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestGonjatest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "gonjatest")
+}
@@ -0,0 +1,265 @@
+package i18n
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// contextSeparator separates the context of a message from its identifier in catalogs, as gettext does
+const contextSeparator = "\x04"
+
+// Catalog holds the translations of a gettext catalog for a single locale. It implements Translator and
+// is safe for concurrent use once loaded.
+type Catalog struct {
+	// Headers holds the headers of the catalog, such as Language or Plural-Forms
+	Headers      map[string]string
+	plural       PluralForm
+	translations map[string][]string
+}
+
+// NewCatalog creates an empty catalog, which returns messages untranslated
+func NewCatalog() *Catalog {
+	return &Catalog{
+		Headers:      map[string]string{},
+		plural:       germanic,
+		translations: map[string][]string{},
+	}
+}
+
+// Gettext returns the translation of a message, or the message itself if it is not translated
+func (c *Catalog) Gettext(message string) string {
+	return c.PGettext("", message)
+}
+
+// NGettext returns the translation of a message in the plural form matching a count
+func (c *Catalog) NGettext(singular, plural string, n int) string {
+	return c.NPGettext("", singular, plural, n)
+}
+
+// PGettext returns the translation of a message in a context, which tells apart identical messages
+func (c *Catalog) PGettext(context, message string) string {
+	if translations, ok := c.translations[key(context, message)]; ok && translations[0] != "" {
+		return translations[0]
+	}
+	return message
+}
+
+// NPGettext returns the translation of a message in a context and in the plural form matching a count
+func (c *Catalog) NPGettext(context, singular, plural string, n int) string {
+	translations, ok := c.translations[key(context, singular)]
+	if index := c.plural(n); ok && index >= 0 && index < len(translations) && translations[index] != "" {
+		return translations[index]
+	}
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
+// Len returns the number of translated messages
+func (c *Catalog) Len() int {
+	return len(c.translations)
+}
+
+func key(context, message string) string {
+	if context == "" {
+		return message
+	}
+	return context + contextSeparator + message
+}
+
+// add stores the translations of a message, reading the headers from the entry of the empty message
+func (c *Catalog) add(context, message string, translations []string) error {
+	if context == "" && message == "" {
+		return c.parseHeaders(translations[0])
+	}
+	for _, translation := range translations {
+		if translation != "" {
+			c.translations[key(context, message)] = translations
+			return nil
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) parseHeaders(headers string) error {
+	for _, line := range strings.Split(headers, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if ok {
+			c.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	if header, ok := c.Headers["Plural-Forms"]; ok {
+		_, plural, err := ParsePluralForms(header)
+		if err != nil {
+			return err
+		}
+		c.plural = plural
+	}
+	return nil
+}
+
+// ParsePO parses a catalog in the textual .po format. Fuzzy and obsolete entries are ignored, as msgfmt
+// does by default.
+func ParsePO(r io.Reader) (*Catalog, error) {
+	catalog := NewCatalog()
+	var (
+		entry   poEntry
+		field   *string
+		line    int
+		scanner = bufio.NewScanner(r)
+	)
+	// flush stores the current entry once it is complete, which is known when the next one starts
+	flush := func() error {
+		complete := entry
+		entry, field = poEntry{}, nil
+		if len(complete.msgstr) == 0 || complete.fuzzy {
+			return nil
+		}
+		if err := catalog.add(complete.msgctxt, complete.msgid, complete.msgstr); err != nil {
+			return fmt.Errorf(`line %d: %w`, line, err)
+		}
+		return nil
+	}
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "#") {
+			if len(entry.msgstr) > 0 {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+			if strings.HasPrefix(text, "#,") && strings.Contains(text, "fuzzy") {
+				entry.fuzzy = true
+			}
+			continue
+		}
+		if strings.HasPrefix(text, `"`) {
+			if field == nil {
+				return nil, fmt.Errorf(`line %d: unexpected string`, line)
+			}
+			value, err := strconv.Unquote(text)
+			if err != nil {
+				return nil, fmt.Errorf(`line %d: invalid string %s`, line, text)
+			}
+			*field += value
+			continue
+		}
+		keyword, rest, _ := strings.Cut(text, " ")
+		value, err := strconv.Unquote(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf(`line %d: invalid string %s`, line, strings.TrimSpace(rest))
+		}
+		switch {
+		case keyword == "msgctxt" || keyword == "msgid":
+			if len(entry.msgstr) > 0 {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+			if keyword == "msgctxt" {
+				entry.msgctxt, field = value, &entry.msgctxt
+			} else {
+				entry.msgid, field = value, &entry.msgid
+			}
+		case keyword == "msgid_plural":
+			entry.msgidPlural, field = value, &entry.msgidPlural
+		case keyword == "msgstr" || strings.HasPrefix(keyword, "msgstr["):
+			index := 0
+			if keyword != "msgstr" {
+				index, err = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(keyword, "msgstr["), "]"))
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf(`line %d: invalid keyword %s`, line, keyword)
+				}
+			}
+			for len(entry.msgstr) <= index {
+				entry.msgstr = append(entry.msgstr, "")
+			}
+			entry.msgstr[index] = value
+			field = &entry.msgstr[index]
+		default:
+			return nil, fmt.Errorf(`line %d: unknown keyword %s`, line, keyword)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+type poEntry struct {
+	fuzzy       bool
+	msgctxt     string
+	msgid       string
+	msgidPlural string
+	msgstr      []string
+}
+
+// moMagic is the magic number of .mo files, whose byte order tells the endianness of the file
+const moMagic = 0x950412de
+
+// ParseMO parses a catalog in the binary .mo format produced by msgfmt
+func ParseMO(r io.Reader) (*Catalog, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 28 {
+		return nil, errors.New(`invalid .mo file: too short`)
+	}
+	var order binary.ByteOrder
+	switch {
+	case binary.LittleEndian.Uint32(data) == moMagic:
+		order = binary.LittleEndian
+	case binary.BigEndian.Uint32(data) == moMagic:
+		order = binary.BigEndian
+	default:
+		return nil, errors.New(`invalid .mo file: bad magic number`)
+	}
+	count := int(order.Uint32(data[8:]))
+	originals, translated := int(order.Uint32(data[12:])), int(order.Uint32(data[16:]))
+	read := func(table, index int) (string, error) {
+		offset := table + index*8
+		if offset < 0 || offset+8 > len(data) {
+			return "", fmt.Errorf(`invalid .mo file: string %d is out of bounds`, index)
+		}
+		length, start := int(order.Uint32(data[offset:])), int(order.Uint32(data[offset+4:]))
+		if start < 0 || length < 0 || start+length > len(data) {
+			return "", fmt.Errorf(`invalid .mo file: string %d is out of bounds`, index)
+		}
+		return string(data[start : start+length]), nil
+	}
+	catalog := NewCatalog()
+	for index := 0; index < count; index++ {
+		original, err := read(originals, index)
+		if err != nil {
+			return nil, err
+		}
+		translation, err := read(translated, index)
+		if err != nil {
+			return nil, err
+		}
+		context := ""
+		if before, after, ok := strings.Cut(original, contextSeparator); ok {
+			context, original = before, after
+		}
+		message, _, _ := strings.Cut(original, "\x00")
+		if err := catalog.add(context, message, strings.Split(translation, "\x00")); err != nil {
+			return nil, err
+		}
+	}
+	return catalog, nil
+}
@@ -0,0 +1,207 @@
+// Package i18n localizes templates with gettext catalogs. It exposes the globals of the i18n extension of
+// Jinja, where keyword arguments are substituted to the %(name)s placeholders of the translated messages,
+// and ngettext substitutes the count to %(num)s:
+//
+//	{{ _("Hello %(name)s!", name=user.name) }}
+//	{{ ngettext("%(num)d apple", "%(num)d apples", apples | length) }}
+//	{{ pgettext("month", "May") }}
+//
+// Catalogs are read from .po or .mo files, without depending on gettext.
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+)
+
+// Translator translates messages for a single locale
+type Translator interface {
+	Gettext(message string) string
+	NGettext(singular, plural string, n int) string
+	PGettext(context, message string) string
+	NPGettext(context, singular, plural string, n int) string
+}
+
+// Catalogs holds the catalogs of a domain by locale, such as fr or pt_BR
+type Catalogs map[string]*Catalog
+
+// LoadCatalogs loads the catalogs of a domain laid out as gettext does, under
+// <locale>/LC_MESSAGES/<domain>.mo, preferring .mo files to .po ones when both exist
+func LoadCatalogs(fsys fs.FS, domain string) (Catalogs, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list locales: %w", err)
+	}
+	catalogs := Catalogs{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		locale := entry.Name()
+		for _, extension := range []string{".mo", ".po"} {
+			name := path.Join(locale, "LC_MESSAGES", domain+extension)
+			file, err := fsys.Open(name)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf(`unable to open '%s': %w`, name, err)
+			}
+			parse := ParsePO
+			if extension == ".mo" {
+				parse = ParseMO
+			}
+			catalog, err := parse(file)
+			file.Close()
+			if err != nil {
+				return nil, fmt.Errorf(`unable to parse '%s': %w`, name, err)
+			}
+			catalogs[locale] = catalog
+			break
+		}
+	}
+	return catalogs, nil
+}
+
+// Locales returns the sorted locales of the catalogs
+func (c Catalogs) Locales() []string {
+	locales := make([]string, 0, len(c))
+	for locale := range c {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Translator returns the catalog of a locale, falling back to the catalog of its language, so that fr_CA
+// and fr-CA use the fr catalog when there is no other one, and to an empty catalog otherwise
+func (c Catalogs) Translator(locale string) Translator {
+	locale = strings.ReplaceAll(locale, "-", "_")
+	if catalog, ok := c[locale]; ok {
+		return catalog
+	}
+	language, _, _ := strings.Cut(locale, "_")
+	if catalog, ok := c[language]; ok {
+		return catalog
+	}
+	return NewCatalog()
+}
+
+// Environment returns a frozen copy of base defining the gettext, ngettext, pgettext and npgettext globals,
+// as well as _ as an alias of gettext, translating messages with the given translator
+func Environment(base *exec.Environment, translator Translator) (*exec.Environment, error) {
+	builder := exec.NewEnvironmentBuilder(base)
+	for name, global := range Globals(translator) {
+		builder.WithGlobal(name, global)
+	}
+	return builder.Build()
+}
+
+// Globals returns the translation functions defined by Environment, so that they can be set on the
+// context of a single render when the locale changes from one render to another
+func Globals(translator Translator) map[string]interface{} {
+	gettext := translation("gettext", 1, false, func(args []string, _ int) string {
+		return translator.Gettext(args[0])
+	})
+	return map[string]interface{}{
+		"_":       gettext,
+		"gettext": gettext,
+		"ngettext": translation("ngettext", 2, true, func(args []string, n int) string {
+			return translator.NGettext(args[0], args[1], n)
+		}),
+		"pgettext": translation("pgettext", 2, false, func(args []string, _ int) string {
+			return translator.PGettext(args[0], args[1])
+		}),
+		"npgettext": translation("npgettext", 3, true, func(args []string, n int) string {
+			return translator.NPGettext(args[0], args[1], args[2], n)
+		}),
+	}
+}
+
+// translation builds a global taking the given number of messages, followed by a count if it is plural,
+// and substituting its keyword arguments to the placeholders of the translated message
+func translation(name string, messages int, plural bool, translate func(args []string, n int) string) func(*exec.VarArgs) *exec.Value {
+	return func(params *exec.VarArgs) *exec.Value {
+		expected := messages
+		if plural {
+			expected++
+		}
+		if len(params.Args) != expected {
+			return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf(`%s expects %d arguments, got %d`, name, expected, len(params.Args))))
+		}
+		args := make([]string, 0, messages)
+		for _, arg := range params.Args[:messages] {
+			if !arg.IsString() {
+				return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf(`%s expects messages to be strings, got %s`, name, arg.String())))
+			}
+			args = append(args, arg.String())
+		}
+		variables := make(map[string]*exec.Value, len(params.KwArgs)+1)
+		for key, value := range params.KwArgs {
+			variables[key] = value
+		}
+		n := 0
+		if plural {
+			count := params.Args[messages]
+			if !count.IsNumber() {
+				return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf(`%s expects a number as count, got %s`, name, count.String())))
+			}
+			n = count.Integer()
+			if _, ok := variables["num"]; !ok {
+				variables["num"] = count
+			}
+		}
+		out, err := interpolate(translate(args, n), variables)
+		if err != nil {
+			return exec.AsValue(exec.ErrInvalidCall(fmt.Errorf(`%s failed: %w`, name, err)))
+		}
+		return exec.AsValue(out)
+	}
+}
+
+// interpolate substitutes variables to the %(name)s and %(name)d placeholders of a message, and %% to %.
+// Messages are left untouched when there are no variables, as Jinja does.
+func interpolate(message string, variables map[string]*exec.Value) (string, error) {
+	if len(variables) == 0 {
+		return message, nil
+	}
+	var out strings.Builder
+	for {
+		index := strings.IndexByte(message, '%')
+		if index < 0 || index == len(message)-1 {
+			out.WriteString(message)
+			return out.String(), nil
+		}
+		out.WriteString(message[:index])
+		message = message[index+1:]
+		if message[0] == '%' {
+			out.WriteByte('%')
+			message = message[1:]
+			continue
+		}
+		end := strings.IndexByte(message, ')')
+		if message[0] != '(' || end < 0 || end == len(message)-1 {
+			return "", fmt.Errorf(`invalid placeholder in '%%%s'`, message)
+		}
+		name := message[1:end]
+		value, ok := variables[name]
+		if !ok {
+			return "", fmt.Errorf(`missing variable '%s'`, name)
+		}
+		switch message[end+1] {
+		case 's':
+			out.WriteString(value.String())
+		case 'd':
+			out.WriteString(strconv.Itoa(value.Integer()))
+		default:
+			return "", fmt.Errorf(`unsupported conversion '%c' of variable '%s'`, message[end+1], name)
+		}
+		message = message[end+2:]
+	}
+}
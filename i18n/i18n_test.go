@@ -0,0 +1,172 @@
+package i18n_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"testing/fstest"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/i18n"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const frenchPO = `# French translations
+msgid ""
+msgstr ""
+"Language: fr\n"
+"Plural-Forms: nplurals=2; plural=(n > 1);\n"
+
+msgid "Hello %(name)s!"
+msgstr "Bonjour %(name)s !"
+
+#, fuzzy
+msgid "Goodbye"
+msgstr "Au revoir"
+
+msgctxt "month"
+msgid "May"
+msgstr "Mai"
+
+msgid "%(num)d apple"
+msgid_plural "%(num)d apples"
+msgstr[0] "%(num)d pomme"
+msgstr[1] "%(num)d "
+"pommes"
+`
+
+// compileMO encodes messages to the .mo format, keys holding contexts and plural identifiers as msgfmt does
+func compileMO(messages map[string]string) []byte {
+	keys := make([]string, 0, len(messages))
+	for key := range messages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var (
+		header = make([]byte, 28)
+		tables = make([]byte, 16*len(keys))
+		data   bytes.Buffer
+		offset = len(header) + len(tables)
+	)
+	binary.LittleEndian.PutUint32(header, 0x950412de)
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(keys)))
+	binary.LittleEndian.PutUint32(header[12:], 28)
+	binary.LittleEndian.PutUint32(header[16:], uint32(28+8*len(keys)))
+	write := func(entry []byte, s string) {
+		binary.LittleEndian.PutUint32(entry, uint32(len(s)))
+		binary.LittleEndian.PutUint32(entry[4:], uint32(offset+data.Len()))
+		data.WriteString(s)
+		data.WriteByte(0)
+	}
+	for index, key := range keys {
+		write(tables[8*index:], key)
+	}
+	for index, key := range keys {
+		write(tables[8*(len(keys)+index):], messages[key])
+	}
+	return append(append(header, tables...), data.Bytes()...)
+}
+
+var _ = Context("i18n", func() {
+	var (
+		fsys   = new(fstest.MapFS)
+		locale = new(string)
+		source = new(string)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*fsys = fstest.MapFS{
+			"fr/LC_MESSAGES/messages.po": {Data: []byte(frenchPO)},
+			"pl/LC_MESSAGES/messages.mo": {Data: compileMO(map[string]string{
+				"":                                "Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n",
+				"Hello %(name)s!":                 "Witaj %(name)s!",
+				"month\x04May":                    "Maj",
+				"%(num)d apple\x00%(num)d apples": "%(num)d jabłko\x00%(num)d jabłka\x00%(num)d jabłek",
+			})},
+			"README.md": {Data: []byte("not a locale")},
+		}
+		*locale = "fr_FR"
+		*source = `{{ _("Hello %(name)s!", name="Ada") }}|{{ gettext("Goodbye") }}|{{ pgettext("month", "May") }}|{{ ngettext("%(num)d apple", "%(num)d apples", 1) }}|{{ ngettext("%(num)d apple", "%(num)d apples", 2) }}|{{ ngettext("%(num)d apple", "%(num)d apples", 5) }}`
+	})
+	JustBeforeEach(func() {
+		catalogs, err := i18n.LoadCatalogs(*fsys, "messages")
+		if *returnedErr = err; err != nil {
+			return
+		}
+		environment, err := i18n.Environment(gonja.DefaultEnvironment, catalogs.Translator(*locale))
+		if *returnedErr = err; err != nil {
+			return
+		}
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/test": *source})
+		t, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, environment)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(nil)
+	})
+	Context("when rendering with a .po catalog", func() {
+		It("should translate messages, ignoring fuzzy ones", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("Bonjour Ada !|Goodbye|Mai|1 pomme|2 pommes|5 pommes"))
+		})
+	})
+	Context("when rendering with a .mo catalog", func() {
+		BeforeEach(func() {
+			*locale = "pl-PL"
+		})
+		It("should select plural forms with the expression of the catalog", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("Witaj Ada!|Goodbye|Maj|1 jabłko|2 jabłka|5 jabłek"))
+		})
+	})
+	Context("when there is no catalog for the locale", func() {
+		BeforeEach(func() {
+			*locale = "de"
+		})
+		It("should render messages untranslated", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("Hello Ada!|Goodbye|May|1 apple|2 apples|5 apples"))
+		})
+	})
+	Context("when a variable of a message is missing", func() {
+		BeforeEach(func() {
+			*source = `{{ _("Hello %(name)s!", other="Ada") }}`
+		})
+		It("should fail", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("gettext failed: missing variable 'name'")))
+		})
+	})
+	Context("when a catalog is malformed", func() {
+		BeforeEach(func() {
+			(*fsys)["es/LC_MESSAGES/messages.po"] = &fstest.MapFile{Data: []byte("msgid \"a\"\nmsgstr \"b\"\nunknown \"c\"\n")}
+		})
+		It("should fail to load it", func() {
+			Expect(*returnedErr).To(MatchError("unable to parse 'es/LC_MESSAGES/messages.po': line 3: unknown keyword unknown"))
+		})
+	})
+})
+
+var _ = Context("plural forms", func() {
+	It("should evaluate C expressions", func() {
+		nplurals, plural, err := i18n.ParsePluralForms("nplurals=4; plural=(n%100==1 ? 0 : n%100==2 ? 1 : n%100==3 || n%100==4 ? 2 : 3);")
+		Expect(err).To(BeNil())
+		Expect(nplurals).To(Equal(4))
+		forms := []int{}
+		for _, n := range []int{1, 2, 3, 4, 5, 101, 102} {
+			forms = append(forms, plural(n))
+		}
+		Expect(forms).To(Equal([]int{0, 1, 2, 2, 3, 0, 1}))
+	})
+	It("should reject invalid expressions", func() {
+		_, _, err := i18n.ParsePluralForms("nplurals=2; plural=n >> 1;")
+		Expect(err).To(MatchError("unable to parse plural expression 'n >> 1': unexpected '> 1'"))
+		_, _, err = i18n.ParsePluralForms("plural=n != 1;")
+		Expect(err).To(MatchError("plural forms 'plural=n != 1;' must define nplurals and plural"))
+	})
+})
@@ -0,0 +1,244 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PluralForm returns the index of the plural form to use for a count
+type PluralForm func(n int) int
+
+// germanic is the plural form of catalogs which do not declare any, as in English
+func germanic(n int) int {
+	if n != 1 {
+		return 1
+	}
+	return 0
+}
+
+// ParsePluralForms parses the Plural-Forms header of a catalog, such as
+// "nplurals=2; plural=(n > 1);", returning the number of plural forms and the function selecting them
+func ParsePluralForms(header string) (int, PluralForm, error) {
+	var (
+		nplurals   int
+		expression string
+		found      bool
+	)
+	for _, field := range strings.Split(header, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "nplurals":
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 1 {
+				return 0, nil, fmt.Errorf(`invalid number of plural forms '%s'`, strings.TrimSpace(value))
+			}
+			nplurals = n
+		case "plural":
+			expression, found = strings.TrimSpace(value), true
+		}
+	}
+	if nplurals == 0 || !found {
+		return 0, nil, fmt.Errorf(`plural forms '%s' must define nplurals and plural`, header)
+	}
+	p := &pluralParser{input: expression}
+	form, err := p.parseTernary()
+	if err == nil && p.skipSpaces() < len(p.input) {
+		err = fmt.Errorf(`unexpected '%s'`, p.input[p.position:])
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf(`unable to parse plural expression '%s': %w`, expression, err)
+	}
+	return nplurals, PluralForm(form), nil
+}
+
+// pluralParser parses the C expressions of plural forms by recursive descent, following the precedence of C
+type pluralParser struct {
+	input    string
+	position int
+}
+
+type pluralExpression func(n int) int
+
+func (p *pluralParser) skipSpaces() int {
+	for p.position < len(p.input) && strings.ContainsRune(" \t\r\n", rune(p.input[p.position])) {
+		p.position++
+	}
+	return p.position
+}
+
+// match consumes one of the given operators, preferring the longest ones
+func (p *pluralParser) match(operators ...string) string {
+	p.skipSpaces()
+	matched := ""
+	for _, operator := range operators {
+		if strings.HasPrefix(p.input[p.position:], operator) && len(operator) > len(matched) {
+			matched = operator
+		}
+	}
+	p.position += len(matched)
+	return matched
+}
+
+func (p *pluralParser) parseTernary() (pluralExpression, error) {
+	condition, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.match("?") == "" {
+		return condition, nil
+	}
+	then, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if p.match(":") == "" {
+		return nil, errors.New(`expected ':'`)
+	}
+	otherwise, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return func(n int) int {
+		if condition(n) != 0 {
+			return then(n)
+		}
+		return otherwise(n)
+	}, nil
+}
+
+// binaryOperators lists the binary operators by increasing precedence
+var binaryOperators = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", ">", "<=", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *pluralParser) parseBinary(level int) (pluralExpression, error) {
+	if level == len(binaryOperators) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator := p.match(binaryOperators[level]...)
+		if operator == "" {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryOperation(operator, left, right)
+	}
+}
+
+func binaryOperation(operator string, left, right pluralExpression) pluralExpression {
+	boolean := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	return func(n int) int {
+		l, r := left(n), right(n)
+		switch operator {
+		case "||":
+			return boolean(l != 0 || r != 0)
+		case "&&":
+			return boolean(l != 0 && r != 0)
+		case "==":
+			return boolean(l == r)
+		case "!=":
+			return boolean(l != r)
+		case "<":
+			return boolean(l < r)
+		case ">":
+			return boolean(l > r)
+		case "<=":
+			return boolean(l <= r)
+		case ">=":
+			return boolean(l >= r)
+		case "+":
+			return l + r
+		case "-":
+			return l - r
+		case "*":
+			return l * r
+		case "/":
+			if r == 0 {
+				return 0
+			}
+			return l / r
+		default:
+			if r == 0 {
+				return 0
+			}
+			return l % r
+		}
+	}
+}
+
+func (p *pluralParser) parseUnary() (pluralExpression, error) {
+	start := p.position
+	if p.match("!") != "" {
+		if p.match("=") != "" {
+			p.position = start
+			return nil, errors.New(`unexpected '!='`)
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int) int {
+			if operand(n) == 0 {
+				return 1
+			}
+			return 0
+		}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *pluralParser) parsePrimary() (pluralExpression, error) {
+	p.skipSpaces()
+	if p.position == len(p.input) {
+		return nil, errors.New(`unexpected end of expression`)
+	}
+	switch c := p.input[p.position]; {
+	case c == 'n':
+		p.position++
+		return func(n int) int { return n }, nil
+	case c >= '0' && c <= '9':
+		start := p.position
+		for p.position < len(p.input) && p.input[p.position] >= '0' && p.input[p.position] <= '9' {
+			p.position++
+		}
+		value, err := strconv.Atoi(p.input[start:p.position])
+		if err != nil {
+			return nil, err
+		}
+		return func(int) int { return value }, nil
+	case c == '(':
+		p.position++
+		expression, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		if p.match(")") == "" {
+			return nil, errors.New(`expected ')'`)
+		}
+		return expression, nil
+	default:
+		return nil, fmt.Errorf(`unexpected '%s'`, p.input[p.position:])
+	}
+}
@@ -0,0 +1,29 @@
+package httprender
+
+import (
+	"io"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Echo returns the renderer as the Renderer of an Echo server, so that templates are rendered by c.Render:
+//
+//	server.Renderer = renderer.Echo()
+//	server.GET("/", func(c echo.Context) error { return c.Render(http.StatusOK, "index", echo.Map{"title": "Home"}) })
+func (r *Renderer) Echo() echo.Renderer {
+	return echoRenderer{renderer: r}
+}
+
+type echoRenderer struct {
+	renderer *Renderer
+}
+
+// Render renders a template to the buffer Echo writes to the response, and sets the content type of the
+// response unless it was set already, which Echo then keeps
+func (e echoRenderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
+	if err := e.renderer.Execute(w, name, data); err != nil {
+		return err
+	}
+	e.renderer.WriteContentType(c.Response())
+	return nil
+}
@@ -0,0 +1,45 @@
+package httprender
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin/render"
+)
+
+// Gin returns the renderer as the HTMLRender of a Gin engine, so that templates are rendered by c.HTML:
+//
+//	engine.HTMLRender = renderer.Gin()
+//	engine.GET("/", func(c *gin.Context) { c.HTML(http.StatusOK, "index", gin.H{"title": "Home"}) })
+func (r *Renderer) Gin() render.HTMLRender {
+	return ginRender{renderer: r}
+}
+
+type ginRender struct {
+	renderer *Renderer
+}
+
+func (g ginRender) Instance(name string, data any) render.Render {
+	return &ginInstance{renderer: g.renderer, name: name, data: data}
+}
+
+// ginInstance renders a template for a single call to c.HTML
+type ginInstance struct {
+	renderer *Renderer
+	name     string
+	data     any
+}
+
+func (i *ginInstance) Render(w http.ResponseWriter) error {
+	var buffer bytes.Buffer
+	if err := i.renderer.Execute(&buffer, i.name, i.data); err != nil {
+		return err
+	}
+	i.WriteContentType(w)
+	_, err := buffer.WriteTo(w)
+	return err
+}
+
+func (i *ginInstance) WriteContentType(w http.ResponseWriter) {
+	i.renderer.WriteContentType(w)
+}
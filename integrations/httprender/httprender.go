@@ -0,0 +1,230 @@
+// Package httprender renders gonja templates as HTTP responses, either from net/http handlers or through
+// the HTML renderers of web frameworks such as Gin and Echo.
+//
+// Templates are rendered to a buffer before anything is written to the response, so that a failing render
+// results in a clean error response rather than in a truncated page.
+package httprender
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"sync"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+)
+
+const (
+	// DefaultContentType is the content type of rendered responses unless WithContentType is used
+	DefaultContentType = "text/html; charset=utf-8"
+	// LayoutVariable is the variable of the data selecting the layout of a render, which replaces the one
+	// given with WithLayout, or disables it when empty
+	LayoutVariable = "layout"
+	// ContentVariable is the variable holding the output of the rendered template within its layout
+	ContentVariable = "content"
+)
+
+// ErrorHandler writes the response of a request whose template failed to render
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Option customizes a renderer
+type Option func(*Renderer)
+
+// WithConfig parses templates with the given configuration instead of the default one
+func WithConfig(cfg *config.Config) Option {
+	return func(r *Renderer) { r.config = cfg }
+}
+
+// WithEnvironment renders templates in the given environment instead of the default one
+func WithEnvironment(environment *exec.Environment) Option {
+	return func(r *Renderer) { r.environment = environment }
+}
+
+// WithExtension appends an extension, such as .html, to the names of templates which have none, so that
+// handlers can refer to the users/show.html template as users/show
+func WithExtension(extension string) Option {
+	return func(r *Renderer) { r.extension = extension }
+}
+
+// WithLayout renders the output of templates within the given layout, a template which outputs it with
+// {{ content }} and is rendered with the same data. The layout variable of the data selects another layout
+// for a single render, or none when empty.
+func WithLayout(layout string) Option {
+	return func(r *Renderer) { r.layout = layout }
+}
+
+// WithContentType replaces the content type of responses, which should specify their charset
+func WithContentType(contentType string) Option {
+	return func(r *Renderer) { r.contentType = contentType }
+}
+
+// WithErrorHandler replaces the handler writing the response of requests whose template failed to render,
+// which by default responds with a plain text internal server error
+func WithErrorHandler(handler ErrorHandler) Option {
+	return func(r *Renderer) { r.errorHandler = handler }
+}
+
+// WithReload parses templates on every render instead of once, so that changes made to them during
+// development are rendered without restarting the server
+func WithReload(reload bool) Option {
+	return func(r *Renderer) { r.reload = reload }
+}
+
+// Renderer renders the templates of a loader as HTTP responses. It is safe for concurrent use.
+type Renderer struct {
+	loader       loaders.Loader
+	config       *config.Config
+	environment  *exec.Environment
+	extension    string
+	layout       string
+	contentType  string
+	errorHandler ErrorHandler
+	reload       bool
+
+	templates sync.Map
+}
+
+// New creates a renderer for the templates of the given loader
+func New(loader loaders.Loader, options ...Option) *Renderer {
+	r := &Renderer{
+		loader:       loader,
+		config:       gonja.DefaultConfig,
+		environment:  gonja.DefaultEnvironment,
+		contentType:  DefaultContentType,
+		errorHandler: defaultErrorHandler,
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+func defaultErrorHandler(w http.ResponseWriter, _ *http.Request, _ error) {
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// Template returns the parsed template of the given name, which is parsed once and cached unless
+// WithReload is used
+func (r *Renderer) Template(name string) (*exec.Template, error) {
+	if r.extension != "" && path.Ext(name) == "" {
+		name = name + r.extension
+	}
+	if !r.reload {
+		if template, ok := r.templates.Load(name); ok {
+			return template.(*exec.Template), nil
+		}
+	}
+	template, err := exec.NewTemplate(name, r.config, r.loader, r.environment)
+	if err != nil {
+		return nil, err
+	}
+	if !r.reload {
+		r.templates.Store(name, template)
+	}
+	return template, nil
+}
+
+// Execute renders a template within its layout, if any, to a writer which is left untouched if the render
+// fails. The data can be nil, a context, a map with string keys such as gin.H, or a struct as accepted by
+// exec.ContextFromStruct.
+func (r *Renderer) Execute(w io.Writer, name string, data interface{}) error {
+	ctx, err := context(data)
+	if err != nil {
+		return fmt.Errorf(`unable to render template '%s': %w`, name, err)
+	}
+	var buffer bytes.Buffer
+	if err := r.execute(&buffer, name, ctx); err != nil {
+		return err
+	}
+	layout := r.layout
+	if value, ok := ctx.Get(LayoutVariable); ok {
+		if layout, ok = value.(string); !ok {
+			return fmt.Errorf(`unable to render template '%s': the %s variable is not a string`, name, LayoutVariable)
+		}
+	}
+	if layout != "" {
+		ctx = ctx.Inherit()
+		ctx.Set(ContentVariable, exec.AsSafeValue(buffer.String()))
+		buffer.Reset()
+		if err := r.execute(&buffer, layout, ctx); err != nil {
+			return err
+		}
+	}
+	_, err = buffer.WriteTo(w)
+	return err
+}
+
+func (r *Renderer) execute(buffer *bytes.Buffer, name string, ctx *exec.Context) error {
+	template, err := r.Template(name)
+	if err != nil {
+		return err
+	}
+	return template.ExecuteToBuffer(buffer, ctx)
+}
+
+// Render renders a template as a response with the given status code. Nothing is written to the response
+// if the render fails, leaving the caller free to respond with an error instead.
+func (r *Renderer) Render(w http.ResponseWriter, status int, name string, data interface{}) error {
+	var buffer bytes.Buffer
+	if err := r.Execute(&buffer, name, data); err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+	w.WriteHeader(status)
+	_, err := buffer.WriteTo(w)
+	return err
+}
+
+// WriteContentType sets the content type of a response, unless it was set already
+func (r *Renderer) WriteContentType(w http.ResponseWriter) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", r.contentType)
+	}
+}
+
+// Handler returns a handler rendering a template with the data returned by the given function, which may
+// be nil when the template needs none. Failures of the function or of the render are passed to the error
+// handler.
+func (r *Renderer) Handler(name string, data func(*http.Request) (interface{}, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var values interface{}
+		if data != nil {
+			var err error
+			if values, err = data(req); err != nil {
+				r.errorHandler(w, req, err)
+				return
+			}
+		}
+		if err := r.Render(w, http.StatusOK, name, values); err != nil {
+			r.errorHandler(w, req, err)
+		}
+	})
+}
+
+// context builds the context of a render out of its data
+func context(data interface{}) (*exec.Context, error) {
+	switch d := data.(type) {
+	case nil:
+		return exec.EmptyContext(), nil
+	case *exec.Context:
+		return d, nil
+	case map[string]interface{}:
+		return exec.NewContext(d), nil
+	}
+	value := reflect.ValueOf(data)
+	if value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String {
+		ctx := exec.EmptyContext()
+		iterator := value.MapRange()
+		for iterator.Next() {
+			ctx.Set(iterator.Key().String(), iterator.Value().Interface())
+		}
+		return ctx, nil
+	}
+	return exec.ContextFromStruct(data)
+}
@@ -0,0 +1,169 @@
+package httprender_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labstack/echo/v4"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/integrations/httprender"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type page struct {
+	Title string `json:"title"`
+}
+
+var _ = Context("httprender", func() {
+	var (
+		options  = new([]httprender.Option)
+		renderer = new(*httprender.Renderer)
+		recorder = new(*httptest.ResponseRecorder)
+	)
+	BeforeEach(func() {
+		*options = nil
+		*recorder = httptest.NewRecorder()
+	})
+	JustBeforeEach(func() {
+		*renderer = httprender.New(loaders.MustNewMemoryLoader(map[string]string{
+			"/base.html":   "<title>{{ title }}</title>{{ content }}",
+			"/plain.html":  "<p>{{ content }}</p>",
+			"/index.html":  "Hello {{ name | default('world') }}",
+			"/broken.html": "before{{ missing.attribute.access }}after",
+		}), *options...)
+	})
+	Context("when rendering a template", func() {
+		var returnedErr = new(error)
+		JustBeforeEach(func() {
+			*returnedErr = (*renderer).Render(*recorder, http.StatusCreated, "/index.html", map[string]interface{}{"layout": "/base.html", "title": "Home"})
+		})
+		It("should write the rendered template with its status code and content type", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect((*recorder).Code).To(Equal(http.StatusCreated))
+			Expect((*recorder).Header().Get("Content-Type")).To(Equal(httprender.DefaultContentType))
+			Expect((*recorder).Body.String()).To(Equal("<title>Home</title>Hello world"))
+		})
+		Context("when the content type is customized", func() {
+			BeforeEach(func() {
+				*options = []httprender.Option{httprender.WithContentType("application/xhtml+xml; charset=utf-8")}
+			})
+			It("should use it", func() {
+				Expect((*recorder).Header().Get("Content-Type")).To(Equal("application/xhtml+xml; charset=utf-8"))
+			})
+		})
+	})
+	Context("when a layout and an extension are configured", func() {
+		var returnedErr = new(error)
+		BeforeEach(func() {
+			*options = []httprender.Option{httprender.WithLayout("/plain.html"), httprender.WithExtension(".html")}
+		})
+		It("should look templates up without their extension and extend the layout", func() {
+			*returnedErr = (*renderer).Render(*recorder, http.StatusOK, "/index", page{Title: "ignored"})
+			Expect(*returnedErr).To(BeNil())
+			Expect((*recorder).Body.String()).To(Equal("<p>Hello world</p>"))
+		})
+		It("should let the data override the layout", func() {
+			*returnedErr = (*renderer).Render(*recorder, http.StatusOK, "/index", exec.NewContext(map[string]interface{}{"layout": "/base.html", "title": "Home"}))
+			Expect(*returnedErr).To(BeNil())
+			Expect((*recorder).Body.String()).To(Equal("<title>Home</title>Hello world"))
+		})
+		It("should let the data disable the layout", func() {
+			*returnedErr = (*renderer).Render(*recorder, http.StatusOK, "/index", map[string]interface{}{"layout": ""})
+			Expect(*returnedErr).To(BeNil())
+			Expect((*recorder).Body.String()).To(Equal("Hello world"))
+		})
+		Context("when autoescaping is enabled", func() {
+			BeforeEach(func() {
+				cfg := gonja.DefaultConfig.Inherit()
+				cfg.AutoEscape = true
+				*options = append(*options, httprender.WithConfig(cfg))
+			})
+			It("should not escape the content twice", func() {
+				*returnedErr = (*renderer).Render(*recorder, http.StatusOK, "/index", map[string]interface{}{"name": "<i>"})
+				Expect(*returnedErr).To(BeNil())
+				Expect((*recorder).Body.String()).To(Equal("<p>Hello &lt;i&gt;</p>"))
+			})
+		})
+	})
+	Context("when the render fails", func() {
+		It("should not write anything to the response", func() {
+			err := (*renderer).Render(*recorder, http.StatusOK, "/broken.html", nil)
+			Expect(err).ToNot(BeNil())
+			Expect((*recorder).Body.String()).To(BeEmpty())
+			Expect((*recorder).Header().Get("Content-Type")).To(BeEmpty())
+		})
+	})
+	Context("when serving a handler", func() {
+		var data = new(func(*http.Request) (interface{}, error))
+		BeforeEach(func() {
+			*data = func(r *http.Request) (interface{}, error) {
+				return map[string]string{"layout": "/base.html", "name": r.URL.Query().Get("name")}, nil
+			}
+		})
+		JustBeforeEach(func() {
+			(*renderer).Handler("/index.html", *data).ServeHTTP(*recorder, httptest.NewRequest(http.MethodGet, "/?name=gonja", nil))
+		})
+		It("should render the template with the data of the request", func() {
+			Expect((*recorder).Code).To(Equal(http.StatusOK))
+			Expect((*recorder).Body.String()).To(Equal("<title></title>Hello gonja"))
+		})
+		Context("when the data cannot be retrieved", func() {
+			BeforeEach(func() {
+				*data = func(*http.Request) (interface{}, error) { return nil, errors.New("not found") }
+			})
+			It("should respond with an internal server error", func() {
+				Expect((*recorder).Code).To(Equal(http.StatusInternalServerError))
+				Expect((*recorder).Body.String()).To(Equal("Internal Server Error\n"))
+			})
+			Context("when an error handler is given", func() {
+				BeforeEach(func() {
+					*options = []httprender.Option{httprender.WithErrorHandler(func(w http.ResponseWriter, _ *http.Request, err error) {
+						http.Error(w, err.Error(), http.StatusNotFound)
+					})}
+				})
+				It("should let it respond", func() {
+					Expect((*recorder).Code).To(Equal(http.StatusNotFound))
+					Expect((*recorder).Body.String()).To(Equal("not found\n"))
+				})
+			})
+		})
+	})
+	Context("when used by Gin", func() {
+		JustBeforeEach(func() {
+			gin.SetMode(gin.TestMode)
+			engine := gin.New()
+			engine.HTMLRender = (*renderer).Gin()
+			engine.GET("/", func(c *gin.Context) {
+				c.HTML(http.StatusAccepted, "/index.html", gin.H{"layout": "/base.html", "title": "Gin"})
+			})
+			engine.ServeHTTP(*recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+		It("should render templates with c.HTML", func() {
+			Expect((*recorder).Code).To(Equal(http.StatusAccepted))
+			Expect((*recorder).Header().Get("Content-Type")).To(Equal(httprender.DefaultContentType))
+			Expect((*recorder).Body.String()).To(Equal("<title>Gin</title>Hello world"))
+		})
+	})
+	Context("when used by Echo", func() {
+		JustBeforeEach(func() {
+			server := echo.New()
+			server.Renderer = (*renderer).Echo()
+			server.GET("/", func(c echo.Context) error {
+				return c.Render(http.StatusAccepted, "/index.html", echo.Map{"layout": "/base.html", "title": "Echo"})
+			})
+			server.ServeHTTP(*recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+		It("should render templates with c.Render", func() {
+			Expect((*recorder).Code).To(Equal(http.StatusAccepted))
+			Expect((*recorder).Header().Get("Content-Type")).To(Equal(httprender.DefaultContentType))
+			Expect((*recorder).Body.String()).To(Equal("<title>Echo</title>Hello world"))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package httprender_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestHTTPRender(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "httprender")
+}
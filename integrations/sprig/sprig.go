@@ -0,0 +1,138 @@
+// Package sprig registers the functions of the sprig library, which Helm charts rely on, as gonja filters
+// and globals, so that users used to {{ .Values.name | default "app" | trimSuffix "-" }} find the same
+// functions under the same names:
+//
+//	{{ name | default("app") | trimSuffix("-") }}
+//	{{ toJson(values) }}
+//
+// As in the pipelines of text/template, the filtered value is given as the last argument of the functions.
+package sprig
+
+import (
+	"fmt"
+	"sort"
+	"text/template"
+
+	upstream "github.com/Masterminds/sprig/v3"
+
+	"github.com/nikolalohinski/gonja/v2/exec"
+)
+
+// Policy decides what happens to a sprig function named like a filter or a global of the base environment
+type Policy int
+
+const (
+	// KeepExisting leaves the filters and globals of the base environment in place, so that sprig functions
+	// named like them, such as default or dict, are only registered where they do not replace anything
+	KeepExisting Policy = iota
+	// ReplaceExisting registers the sprig functions in place of the filters and globals named like them
+	ReplaceExisting
+	// FailOnCollision makes Environment fail if any sprig function is named like a filter or a global
+	FailOnCollision
+)
+
+// Option customizes the registration of the sprig functions
+type Option func(*options)
+
+type options struct {
+	policy   Policy
+	prefix   string
+	hermetic bool
+	excluded map[string]bool
+}
+
+// WithPolicy decides what happens to sprig functions named like existing filters or globals, which are
+// kept by default
+func WithPolicy(policy Policy) Option {
+	return func(o *options) { o.policy = policy }
+}
+
+// WithPrefix registers the sprig functions under prefixed names, such as sprig_default, which avoids most
+// collisions with the filters and globals of the base environment
+func WithPrefix(prefix string) Option {
+	return func(o *options) { o.prefix = prefix }
+}
+
+// Hermetic only registers the functions whose output solely depends on their arguments, leaving out the
+// ones reading the environment variables, the clock or random sources
+func Hermetic() Option {
+	return func(o *options) { o.hermetic = true }
+}
+
+// WithoutFunctions leaves out the sprig functions of the given names, before any prefix is applied
+func WithoutFunctions(names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.excluded[name] = true
+		}
+	}
+}
+
+// FuncMap returns the sprig functions selected by the given options, under the names they are registered
+func FuncMap(options ...Option) template.FuncMap {
+	o := newOptions(options)
+	source := upstream.TxtFuncMap()
+	if o.hermetic {
+		source = upstream.HermeticTxtFuncMap()
+	}
+	funcs := make(template.FuncMap, len(source))
+	for name, fn := range source {
+		if !o.excluded[name] {
+			funcs[o.prefix+name] = fn
+		}
+	}
+	return funcs
+}
+
+// Register adds the sprig functions to a builder as both filters and globals, following the given policy
+// for the names already used by the filters and globals of base, which should be the environment the
+// builder was created from
+func Register(builder *exec.EnvironmentBuilder, base *exec.Environment, options ...Option) (*exec.EnvironmentBuilder, error) {
+	o := newOptions(options)
+	funcs := FuncMap(options...)
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		existingFilter := base != nil && base.Filters != nil && base.Filters.Exists(name)
+		existingGlobal := base != nil && base.Context != nil && base.Context.Has(name)
+		if (existingFilter || existingGlobal) && o.policy == FailOnCollision {
+			return nil, fmt.Errorf(`sprig function '%s' collides with an existing filter or global`, name)
+		}
+		if !existingFilter || o.policy == ReplaceExisting {
+			filter, err := exec.FilterFromFunc(name, funcs[name])
+			if err != nil {
+				return nil, fmt.Errorf(`unable to register sprig function '%s': %w`, name, err)
+			}
+			builder.WithFilter(name, filter)
+		}
+		if !existingGlobal || o.policy == ReplaceExisting {
+			global, err := exec.GlobalFromFunc(name, funcs[name])
+			if err != nil {
+				return nil, fmt.Errorf(`unable to register sprig function '%s': %w`, name, err)
+			}
+			builder.WithGlobal(name, global)
+		}
+	}
+	return builder, nil
+}
+
+// Environment returns a frozen copy of base in which the sprig functions are registered as both filters
+// and globals
+func Environment(base *exec.Environment, options ...Option) (*exec.Environment, error) {
+	builder, err := Register(exec.NewEnvironmentBuilder(base), base, options...)
+	if err != nil {
+		return nil, err
+	}
+	return builder.Build()
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{excluded: map[string]bool{}}
+	for _, option := range opts {
+		option(o)
+	}
+	return o
+}
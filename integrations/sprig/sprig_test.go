@@ -0,0 +1,106 @@
+package sprig_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/integrations/sprig"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("sprig", func() {
+	var (
+		options = new([]sprig.Option)
+		source  = new(string)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*options = nil
+	})
+	JustBeforeEach(func() {
+		environment, err := sprig.Environment(gonja.DefaultEnvironment, *options...)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/test": *source})
+		t, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, environment)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(exec.NewContext(map[string]interface{}{
+			"values": map[string]interface{}{"name": "app-", "replicas": 3},
+		}))
+	})
+	Context("when using sprig functions as filters", func() {
+		BeforeEach(func() {
+			*source = `{{ values.name | trimSuffix("-") | upper }}|{{ "a,b" | splitList(",") | join("+") }}|{{ values.replicas | add1 }}`
+		})
+		It("should give the filtered value as last argument", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("APP|a+b|4"))
+		})
+	})
+	Context("when using sprig functions as globals", func() {
+		BeforeEach(func() {
+			*source = `{{ toJson(values) }}|{{ ternary("yes", "no", true) }}|{{ toJson([1, "a"]) }}`
+		})
+		It("should call them with the given arguments", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal(`{"name":"app-","replicas":3}|yes|[1,"a"]`))
+		})
+	})
+	Context("when a sprig function is named like a builtin", func() {
+		BeforeEach(func() {
+			*source = `{{ missing | default("none") }}|{{ dict(a=1) | tojson }}|{{ default("fallback", "") }}`
+		})
+		It("should keep the builtin and register the sprig function where it replaces nothing", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal(`none|{"a":1}|fallback`))
+		})
+		Context("and the sprig functions replace existing ones", func() {
+			BeforeEach(func() {
+				*options = []sprig.Option{sprig.WithPolicy(sprig.ReplaceExisting)}
+				*source = `{{ dict("a", 1) | toJson }}`
+			})
+			It("should use the sprig function", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect(*returnedResult).To(Equal(`{"a":1}`))
+			})
+		})
+		Context("and collisions are not allowed", func() {
+			BeforeEach(func() {
+				*options = []sprig.Option{sprig.WithPolicy(sprig.FailOnCollision)}
+			})
+			It("should fail", func() {
+				Expect(*returnedErr).To(MatchError("sprig function 'default' collides with an existing filter or global"))
+			})
+			Context("but the functions are prefixed", func() {
+				BeforeEach(func() {
+					*options = append(*options, sprig.WithPrefix("sprig_"))
+					*source = `{{ "" | sprig_default("none") }}`
+				})
+				It("should register them under their prefixed names", func() {
+					Expect(*returnedErr).To(BeNil())
+					Expect(*returnedResult).To(Equal("none"))
+				})
+			})
+		})
+	})
+	Context("when only hermetic functions are registered", func() {
+		BeforeEach(func() {
+			*options = []sprig.Option{sprig.Hermetic(), sprig.WithoutFunctions("upper")}
+			*source = `{{ env("HOME") }}`
+		})
+		It("should leave out the other ones", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("env")))
+		})
+		It("should not register excluded functions", func() {
+			Expect(sprig.FuncMap(*options...)).NotTo(HaveKey("upper"))
+			Expect(sprig.FuncMap(*options...)).To(HaveKey("trimSuffix"))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package sprig_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSprig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "sprig")
+}
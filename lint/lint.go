@@ -0,0 +1,126 @@
+// Package lint reports problems found in templates by inspecting their syntax tree with pluggable rules.
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+)
+
+// Severity tells how serious a diagnostic is
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+var severityNames = map[Severity]string{
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// MarshalText encodes the severity by name, so that diagnostics can be exported as JSON
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity from its name
+func (s *Severity) UnmarshalText(text []byte) error {
+	for severity, name := range severityNames {
+		if name == string(text) {
+			*s = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity '%s'", text)
+}
+
+// Diagnostic is a problem reported by a rule
+type Diagnostic struct {
+	Rule       string   `json:"rule"`
+	Severity   Severity `json:"severity"`
+	Message    string   `json:"message"`
+	Identifier string   `json:"identifier"`
+	Line       int      `json:"line"`
+	Col        int      `json:"col"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s (%s)", d.Identifier, d.Line, d.Col, d.Severity, d.Message, d.Rule)
+}
+
+// Rule inspects the syntax tree of templates and reports the problems found
+type Rule interface {
+	// Name identifies the rule in the diagnostics it reports
+	Name() string
+	Check(pass *Pass)
+}
+
+// Pass gives a rule access to the template being linted and collects its diagnostics
+type Pass struct {
+	Template *nodes.Template
+	Config   *config.Config
+
+	rule        Rule
+	diagnostics []Diagnostic
+}
+
+// Report records a diagnostic positioned at the given node
+func (p *Pass) Report(node nodes.Node, severity Severity, format string, args ...interface{}) {
+	diagnostic := Diagnostic{
+		Rule:       p.rule.Name(),
+		Severity:   severity,
+		Message:    fmt.Sprintf(format, args...),
+		Identifier: p.Template.Identifier,
+	}
+	if node != nil {
+		if token := node.Position(); token != nil {
+			diagnostic.Line = token.Line
+			diagnostic.Col = token.Col
+		}
+	}
+	p.diagnostics = append(p.diagnostics, diagnostic)
+}
+
+// Linter runs a set of rules against templates
+type Linter struct {
+	rules []Rule
+}
+
+// New creates a linter running the given rules, or the default ones if none is given
+func New(rules ...Rule) *Linter {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+	return &Linter{rules: rules}
+}
+
+// Lint runs every rule against a parsed template, as returned by exec.Template.Root, and returns
+// the diagnostics sorted by position
+func (l *Linter) Lint(template *nodes.Template, config *config.Config) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, rule := range l.rules {
+		pass := &Pass{Template: template, Config: config, rule: rule}
+		rule.Check(pass)
+		diagnostics = append(diagnostics, pass.diagnostics...)
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Line != diagnostics[j].Line {
+			return diagnostics[i].Line < diagnostics[j].Line
+		}
+		return diagnostics[i].Col < diagnostics[j].Col
+	})
+	return diagnostics
+}
@@ -0,0 +1,143 @@
+package lint_test
+
+import (
+	"encoding/json"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/lint"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("lint", func() {
+	var (
+		source    = new(string)
+		templates = new(map[string]string)
+		cfg       = new(*config.Config)
+		rules     = new([]lint.Rule)
+
+		returnedDiagnostics = new([]lint.Diagnostic)
+	)
+	BeforeEach(func() {
+		*templates = map[string]string{"/macros": "{% macro a() %}a{% endmacro %}{% macro b() %}b{% endmacro %}"}
+		*cfg = gonja.DefaultConfig.Inherit()
+		*rules = nil
+	})
+	JustBeforeEach(func() {
+		(*templates)["/test"] = *source
+		loader := loaders.MustNewMemoryLoader(*templates)
+		template, err := exec.NewTemplate("/test", *cfg, loader, gonja.DefaultEnvironment)
+		Expect(err).To(BeNil())
+		*returnedDiagnostics = lint.New(*rules...).Lint(template.Root(), *cfg)
+	})
+	Context("when the template has no problem", func() {
+		BeforeEach(func() {
+			*source = "{% import '/macros' as macros %}{% for item in items %}{{ macros.a() }}{{ request.id | e }}{% endfor %}"
+		})
+		It("should not report anything", func() {
+			Expect(*returnedDiagnostics).To(BeEmpty())
+		})
+	})
+	Context("when imported names are not used", func() {
+		BeforeEach(func() {
+			*source = "{% import '/macros' as macros %}\n{% from '/macros' import a, b as renamed %}{{ a() }}"
+		})
+		It("should report each of them", func() {
+			Expect(*returnedDiagnostics).To(Equal([]lint.Diagnostic{
+				{Rule: "unused-import", Severity: lint.SeverityWarning, Message: "'macros' is imported but never used", Identifier: "/test", Line: 1, Col: 1},
+				{Rule: "unused-import", Severity: lint.SeverityWarning, Message: "'renamed' is imported but never used", Identifier: "/test", Line: 2, Col: 1},
+			}))
+		})
+	})
+	Context("when imported names are used within macros and control structures", func() {
+		BeforeEach(func() {
+			*source = "{% import '/macros' as m %}{% from '/macros' import b %}{% macro c() %}{% if true %}{{ m.a() }}{% endif %}{% endmacro %}{% set x = b %}"
+		})
+		It("should not report them", func() {
+			Expect(*returnedDiagnostics).To(BeEmpty())
+		})
+	})
+	Context("when using deprecated filters", func() {
+		BeforeEach(func() {
+			*rules = []lint.Rule{lint.DeprecatedFilters(map[string]string{"old": "new", "gone": ""})}
+			*source = "{{ value | upper | old }}\n{% filter gone %}{{ value | lower }}{% endfilter %}"
+			(*cfg).StrictUndefined = false
+		})
+		It("should report their usages", func() {
+			Expect(*returnedDiagnostics).To(Equal([]lint.Diagnostic{
+				{Rule: "deprecated-filter", Severity: lint.SeverityWarning, Message: "filter 'old' is deprecated, use 'new' instead", Identifier: "/test", Line: 1, Col: 20},
+				{Rule: "deprecated-filter", Severity: lint.SeverityWarning, Message: "filter 'gone' is deprecated", Identifier: "/test", Line: 2, Col: 11},
+			}))
+		})
+	})
+	Context("when rendering request data", func() {
+		BeforeEach(func() {
+			*source = "{{ request.args.q }}{{ request.path | e }}{{ request['x'] | safe }}{{ other }}"
+		})
+		It("should report the unescaped outputs", func() {
+			Expect(*returnedDiagnostics).To(HaveLen(2))
+			Expect((*returnedDiagnostics)[0].Message).To(Equal("'request' is rendered without being escaped"))
+			Expect((*returnedDiagnostics)[0].Col).To(Equal(1))
+			Expect((*returnedDiagnostics)[1].Message).To(Equal("'request' is marked as safe and rendered without being escaped"))
+		})
+		Context("when request data is part of a larger expression", func() {
+			BeforeEach(func() {
+				*source = `{{ "<b>" ~ request.path }}{{ "x" | replace("x", request.path) }}{{ "<b>" ~ (request.path | e) }}{{ ("<b>" ~ request.path) | e }}{{ ("<b>" ~ request.path) | safe }}`
+			})
+			It("should report the unescaped outputs", func() {
+				Expect(*returnedDiagnostics).To(HaveLen(3))
+				Expect((*returnedDiagnostics)[0].Message).To(Equal("'request' is rendered without being escaped"))
+				Expect((*returnedDiagnostics)[0].Col).To(Equal(1))
+				Expect((*returnedDiagnostics)[1].Message).To(Equal("'request' is rendered without being escaped"))
+				Expect((*returnedDiagnostics)[2].Message).To(Equal("'request' is marked as safe and rendered without being escaped"))
+			})
+		})
+		Context("when autoescaping is enabled", func() {
+			BeforeEach(func() {
+				(*cfg).AutoEscape = true
+			})
+			It("should only report the outputs marked as safe", func() {
+				Expect(*returnedDiagnostics).To(HaveLen(1))
+				Expect((*returnedDiagnostics)[0].Message).To(Equal("'request' is marked as safe and rendered without being escaped"))
+			})
+			Context("when disabled by an autoescape statement", func() {
+				BeforeEach(func() {
+					*source = "{{ request.path }}{% autoescape false %}{{ request.path }}{% endautoescape %}"
+				})
+				It("should report the outputs within the statement", func() {
+					Expect(*returnedDiagnostics).To(HaveLen(1))
+					Expect((*returnedDiagnostics)[0].Col).To(Equal(41))
+				})
+			})
+		})
+	})
+	Context("when statements are nested too deeply", func() {
+		BeforeEach(func() {
+			*rules = []lint.Rule{lint.MaxNesting(2)}
+			*source = "{% for a in b %}{% if a %}{% with c = a %}{% if c %}{% endif %}{% endwith %}{% endif %}{% endfor %}{% if a %}{% set x = 1 %}{% endif %}"
+		})
+		It("should report the outermost statement exceeding the depth", func() {
+			Expect(*returnedDiagnostics).To(Equal([]lint.Diagnostic{
+				{Rule: "max-nesting", Severity: lint.SeverityWarning, Message: "'with' statement is nested 3 levels deep, more than the 2 allowed", Identifier: "/test", Line: 1, Col: 27},
+			}))
+		})
+	})
+	Context("when exporting diagnostics", func() {
+		BeforeEach(func() {
+			*source = "{{ request.path }}"
+		})
+		It("should name severities", func() {
+			encoded, err := json.Marshal(*returnedDiagnostics)
+			Expect(err).To(BeNil())
+			Expect(string(encoded)).To(Equal(`[{"rule":"unescaped-output","severity":"warning","message":"'request' is rendered without being escaped","identifier":"/test","line":1,"col":1}]`))
+			decoded := []lint.Diagnostic{}
+			Expect(json.Unmarshal(encoded, &decoded)).To(Succeed())
+			Expect(decoded).To(Equal(*returnedDiagnostics))
+			Expect(decoded[0].String()).To(Equal("/test:1:1: warning: 'request' is rendered without being escaped (unescaped-output)"))
+		})
+	})
+})
@@ -0,0 +1,272 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+)
+
+// pongo2LoopAttributes maps the attributes of the forloop variable of pongo2 to those of loop
+var pongo2LoopAttributes = map[string]string{
+	"Counter":     "index",
+	"Counter0":    "index0",
+	"First":       "first",
+	"Last":        "last",
+	"Revcounter":  "revindex",
+	"Revcounter0": "revindex0",
+}
+
+// pongo2StatementHints tells how to replace the statements of pongo2 which have a counterpart in gonja
+var pongo2StatementHints = map[string]string{
+	"cycle":     "use loop.cycle instead",
+	"empty":     "use else instead",
+	"ifchanged": "use loop.changed instead",
+	"ssi":       "use include instead",
+}
+
+// pongo2Edit replaces the source between two offsets
+type pongo2Edit struct {
+	start int
+	end   int
+	text  string
+}
+
+// pongo2Finding is a construct of pongo2 found in a template, along with the edits rewriting it when
+// it can be rewritten mechanically
+type pongo2Finding struct {
+	diagnostic Diagnostic
+	edits      []pongo2Edit
+}
+
+// Pongo2Report lists the constructs of a pongo2 template which prevent it from being rendered by the
+// environment, typically one returned by builtins.Pongo2. Constructs which Pongo2Rewrite takes care of are
+// reported as warnings, and the ones which must be migrated by hand as errors. Since pongo2 templates may
+// not parse, the report is built from the tokens of the template rather than from its syntax tree.
+func Pongo2Report(identifier, source string, environment *exec.Environment, cfg *config.Config) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, finding := range scanPongo2(source, environment, cfg) {
+		finding.diagnostic.Identifier = identifier
+		diagnostics = append(diagnostics, finding.diagnostic)
+	}
+	return diagnostics
+}
+
+// Pongo2Rewrite rewrites the constructs of a pongo2 template which have a direct counterpart in gonja:
+// filter arguments given after a colon are put between parentheses, the attributes of forloop become
+// those of loop and the reversed and sorted modifiers of for-loops become the reverse and sort filters.
+// Everything else is left untouched.
+func Pongo2Rewrite(source string, cfg *config.Config) string {
+	edits := []pongo2Edit{}
+	for _, finding := range scanPongo2(source, nil, cfg) {
+		edits = append(edits, finding.edits...)
+	}
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var out strings.Builder
+	offset := 0
+	for _, edit := range edits {
+		out.WriteString(source[offset:edit.start])
+		out.WriteString(edit.text)
+		offset = edit.end
+	}
+	out.WriteString(source[offset:])
+	return out.String()
+}
+
+// scanPongo2 finds the constructs of pongo2 in a template. Filters and statements are only checked
+// against the environment when one is given.
+func scanPongo2(source string, environment *exec.Environment, cfg *config.Config) []pongo2Finding {
+	findings := []pongo2Finding{}
+	report := func(token *tokens.Token, rule string, severity Severity, message string, edits ...pongo2Edit) {
+		findings = append(findings, pongo2Finding{
+			diagnostic: Diagnostic{
+				Rule:     rule,
+				Severity: severity,
+				Message:  message,
+				Line:     token.Line,
+				Col:      token.Col,
+			},
+			edits: edits,
+		})
+	}
+
+	stream := []*tokens.Token{}
+	for _, token := range tokens.Tokenize(source, cfg) {
+		if token.Type == tokens.Whitespace {
+			if offset := strings.Index(source[token.Pos:token.End], "&&"); offset >= 0 {
+				start := token.Pos + offset
+				report(token, "pongo2-operator", SeverityWarning, "'&&' is not an operator, use and instead",
+					pongo2Edit{start: start, end: start + 2, text: "and"})
+			}
+			continue
+		}
+		stream = append(stream, token)
+	}
+	at := func(index int, types ...tokens.Type) *tokens.Token {
+		if index >= len(stream) {
+			return nil
+		}
+		for _, t := range types {
+			if stream[index].Type == t {
+				return stream[index]
+			}
+		}
+		return nil
+	}
+
+	for index := 0; index < len(stream); index++ {
+		token := stream[index]
+		switch token.Type {
+		case tokens.Error:
+			message := token.Val
+			if strings.Contains(token.Val, `"!"`) {
+				message = "'!' is not an operator, use not instead"
+			}
+			report(token, "pongo2-syntax", SeverityError, message)
+
+		case tokens.BlockBegin:
+			name := at(index+1, tokens.Name)
+			if name == nil {
+				continue
+			}
+			if environment != nil && !pongo2StatementExists(environment, name.Val) {
+				message := fmt.Sprintf("statement '%s' is not supported", name.Val)
+				if hint, ok := pongo2StatementHints[name.Val]; ok {
+					message = message + ", " + hint
+				}
+				report(name, "pongo2-statement", SeverityError, message)
+			}
+			if name.Val == "for" {
+				findings = append(findings, scanPongo2LoopModifiers(stream, index+2)...)
+			}
+
+		case tokens.Pipe:
+			if at(index+1, tokens.Pipe) != nil {
+				report(token, "pongo2-operator", SeverityWarning, "'||' is not an operator, use or instead",
+					pongo2Edit{start: token.Pos, end: stream[index+1].End, text: "or"})
+				index++
+				continue
+			}
+			name := at(index+1, tokens.Name)
+			if name == nil {
+				continue
+			}
+			if environment != nil && !environment.Filters.Exists(name.Val) {
+				report(name, "pongo2-filter", SeverityError, fmt.Sprintf("filter '%s' is not supported", name.Val))
+			}
+			colon := at(index+2, tokens.Colon)
+			if colon == nil {
+				continue
+			}
+			last := pongo2FilterArgumentEnd(stream, index+3)
+			if last < 0 {
+				continue
+			}
+			report(colon, "pongo2-filter-arguments", SeverityWarning,
+				fmt.Sprintf("arguments of filter '%s' must be given between parentheses", name.Val),
+				pongo2Edit{start: colon.Pos, end: colon.End, text: "("},
+				pongo2Edit{start: stream[last].End, end: stream[last].End, text: ")"},
+			)
+			index = last
+
+		case tokens.Name:
+			if token.Val != "forloop" || index > 0 && stream[index-1].Type == tokens.Dot || at(index+1, tokens.Dot) == nil {
+				continue
+			}
+			attribute := at(index+2, tokens.Name)
+			if attribute == nil {
+				continue
+			}
+			if replacement, ok := pongo2LoopAttributes[attribute.Val]; ok {
+				report(token, "pongo2-forloop", SeverityWarning,
+					fmt.Sprintf("forloop.%s is loop.%s", attribute.Val, replacement),
+					pongo2Edit{start: token.Pos, end: attribute.End, text: "loop." + replacement},
+				)
+			} else {
+				report(token, "pongo2-forloop", SeverityError, fmt.Sprintf("forloop.%s has no counterpart in loop", attribute.Val))
+			}
+			index += 2
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].diagnostic.Line != findings[j].diagnostic.Line {
+			return findings[i].diagnostic.Line < findings[j].diagnostic.Line
+		}
+		return findings[i].diagnostic.Col < findings[j].diagnostic.Col
+	})
+	return findings
+}
+
+// pongo2StatementExists tells whether a statement, or the statement it ends, is known to the environment
+func pongo2StatementExists(environment *exec.Environment, name string) bool {
+	switch {
+	case name == "else" || name == "elif":
+		return true
+	case environment.ControlStructures.Exists(name):
+		return true
+	}
+	return strings.HasPrefix(name, "end") && environment.ControlStructures.Exists(strings.TrimPrefix(name, "end"))
+}
+
+// pongo2FilterArgumentEnd returns the index of the last token of the argument of a filter starting at the
+// given index, which is a literal or a variable with its attributes, or -1 if there is none
+func pongo2FilterArgumentEnd(stream []*tokens.Token, index int) int {
+	if index >= len(stream) {
+		return -1
+	}
+	switch stream[index].Type {
+	case tokens.String, tokens.Integer, tokens.Float:
+		return index
+	case tokens.Subtraction:
+		if index+1 < len(stream) && (stream[index+1].Type == tokens.Integer || stream[index+1].Type == tokens.Float) {
+			return index + 1
+		}
+		return -1
+	case tokens.Name:
+		for index+2 < len(stream) && stream[index+1].Type == tokens.Dot &&
+			(stream[index+2].Type == tokens.Name || stream[index+2].Type == tokens.Integer) {
+			index += 2
+		}
+		return index
+	}
+	return -1
+}
+
+// scanPongo2LoopModifiers rewrites the reversed and sorted modifiers ending the arguments of a for-loop
+// starting at the given index into the reverse and sort filters
+func scanPongo2LoopModifiers(stream []*tokens.Token, index int) []pongo2Finding {
+	end := index
+	for end < len(stream) && stream[end].Type != tokens.BlockEnd {
+		end++
+	}
+	first, modifiers := end, map[string]bool{}
+	for first-1 > index && stream[first-1].Type == tokens.Name &&
+		(stream[first-1].Val == "reversed" || stream[first-1].Val == "sorted") && stream[first-2].Type != tokens.Dot {
+		first--
+		modifiers[stream[first].Val] = true
+	}
+	if len(modifiers) == 0 {
+		return nil
+	}
+	filters := []string{}
+	if modifiers["sorted"] {
+		filters = append(filters, "| sort")
+	}
+	if modifiers["reversed"] {
+		filters = append(filters, "| reverse")
+	}
+	token := stream[first]
+	return []pongo2Finding{{
+		diagnostic: Diagnostic{
+			Rule:     "pongo2-for-modifier",
+			Severity: SeverityWarning,
+			Message:  "modifiers of for-loops must be given as filters",
+			Line:     token.Line,
+			Col:      token.Col,
+		},
+		edits: []pongo2Edit{{start: token.Pos, end: stream[end-1].End, text: strings.Join(filters, " ")}},
+	}}
+}
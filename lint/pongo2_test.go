@@ -0,0 +1,71 @@
+package lint_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/builtins"
+	"github.com/nikolalohinski/gonja/v2/lint"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("pongo2", func() {
+	var (
+		source = new(string)
+
+		returnedDiagnostics = new([]lint.Diagnostic)
+		returnedRewrite     = new(string)
+	)
+	JustBeforeEach(func() {
+		*returnedDiagnostics = lint.Pongo2Report("/test", *source, builtins.Pongo2(gonja.DefaultEnvironment), gonja.DefaultConfig)
+		*returnedRewrite = lint.Pongo2Rewrite(*source, gonja.DefaultConfig)
+	})
+	Context("when the template only uses supported constructs", func() {
+		BeforeEach(func() {
+			*source = "{% load humanize %}{% ifequal a 1 %}{{ b | capfirst }}{% else %}{% firstof c d %}{% endifequal %}"
+		})
+		It("should not report anything", func() {
+			Expect(*returnedDiagnostics).To(BeEmpty())
+		})
+		It("should not rewrite anything", func() {
+			Expect(*returnedRewrite).To(Equal(*source))
+		})
+	})
+	Context("when the template uses constructs which can be rewritten", func() {
+		BeforeEach(func() {
+			*source = "{% for x in items sorted reversed %}{{ forloop.Counter0 }}{{ x|floatformat:2|add:-1 }}{{ x|default:user.name }}{% endfor %}\n{% if a && b || c %}{% endif %}"
+		})
+		It("should report them as warnings", func() {
+			Expect(*returnedDiagnostics).To(Equal([]lint.Diagnostic{
+				{Rule: "pongo2-for-modifier", Severity: lint.SeverityWarning, Message: "modifiers of for-loops must be given as filters", Identifier: "/test", Line: 1, Col: 19},
+				{Rule: "pongo2-forloop", Severity: lint.SeverityWarning, Message: "forloop.Counter0 is loop.index0", Identifier: "/test", Line: 1, Col: 40},
+				{Rule: "pongo2-filter-arguments", Severity: lint.SeverityWarning, Message: "arguments of filter 'floatformat' must be given between parentheses", Identifier: "/test", Line: 1, Col: 75},
+				{Rule: "pongo2-filter-arguments", Severity: lint.SeverityWarning, Message: "arguments of filter 'add' must be given between parentheses", Identifier: "/test", Line: 1, Col: 81},
+				{Rule: "pongo2-filter-arguments", Severity: lint.SeverityWarning, Message: "arguments of filter 'default' must be given between parentheses", Identifier: "/test", Line: 1, Col: 99},
+				{Rule: "pongo2-operator", Severity: lint.SeverityWarning, Message: "'&&' is not an operator, use and instead", Identifier: "/test", Line: 2, Col: 9},
+				{Rule: "pongo2-operator", Severity: lint.SeverityWarning, Message: "'||' is not an operator, use or instead", Identifier: "/test", Line: 2, Col: 14},
+			}))
+		})
+		It("should rewrite them", func() {
+			Expect(*returnedRewrite).To(Equal("{% for x in items | sort | reverse %}{{ loop.index0 }}{{ x|floatformat(2)|add(-1) }}{{ x|default(user.name) }}{% endfor %}\n{% if a and b or c %}{% endif %}"))
+		})
+	})
+	Context("when the template uses constructs which must be migrated by hand", func() {
+		BeforeEach(func() {
+			*source = "{% for x in items %}{% cycle 'odd' 'even' %}{{ x|get_digit(1) }}{{ forloop.Parentloop }}{% empty %}{% lorem %}{% endfor %}\n{% if !a %}{% endif %}"
+		})
+		It("should report them as errors", func() {
+			Expect(*returnedDiagnostics).To(Equal([]lint.Diagnostic{
+				{Rule: "pongo2-statement", Severity: lint.SeverityError, Message: "statement 'cycle' is not supported, use loop.cycle instead", Identifier: "/test", Line: 1, Col: 24},
+				{Rule: "pongo2-filter", Severity: lint.SeverityError, Message: "filter 'get_digit' is not supported", Identifier: "/test", Line: 1, Col: 50},
+				{Rule: "pongo2-forloop", Severity: lint.SeverityError, Message: "forloop.Parentloop has no counterpart in loop", Identifier: "/test", Line: 1, Col: 68},
+				{Rule: "pongo2-statement", Severity: lint.SeverityError, Message: "statement 'empty' is not supported, use else instead", Identifier: "/test", Line: 1, Col: 92},
+				{Rule: "pongo2-statement", Severity: lint.SeverityError, Message: "statement 'lorem' is not supported", Identifier: "/test", Line: 1, Col: 103},
+				{Rule: "pongo2-syntax", Severity: lint.SeverityError, Message: "'!' is not an operator, use not instead", Identifier: "/test", Line: 2, Col: 7},
+			}))
+		})
+		It("should leave them untouched", func() {
+			Expect(*returnedRewrite).To(Equal(*source))
+		})
+	})
+})
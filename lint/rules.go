@@ -0,0 +1,219 @@
+package lint
+
+import (
+	controlStructures "github.com/nikolalohinski/gonja/v2/builtins/control_structures"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+)
+
+// DefaultMaxNesting is the depth of nested statements the default rules allow
+const DefaultMaxNesting = 5
+
+// DefaultRules returns the rules run by a linter created without rules. DeprecatedFilters is not part
+// of them as it needs to be given the filters deprecated by the application.
+func DefaultRules() []Rule {
+	return []Rule{
+		UnusedImports(),
+		UnescapedOutput("request"),
+		MaxNesting(DefaultMaxNesting),
+	}
+}
+
+// importer is implemented by the control structures binding imported templates or macros to names
+type importer interface {
+	ImportedNames() []string
+}
+
+type unusedImports struct{}
+
+// UnusedImports reports the names bound by import and from statements which are never used
+func UnusedImports() Rule {
+	return unusedImports{}
+}
+
+func (unusedImports) Name() string { return "unused-import" }
+
+func (unusedImports) Check(pass *Pass) {
+	names := []string{}
+	imported := map[string]nodes.Node{}
+	used := map[string]bool{}
+	nodes.Traverse(pass.Template, func(node nodes.Node, _ []nodes.Node) bool {
+		switch n := node.(type) {
+		case *nodes.ControlStructureBlock:
+			if statement, ok := n.ControlStructure.(importer); ok {
+				for _, name := range statement.ImportedNames() {
+					if _, exists := imported[name]; !exists {
+						names = append(names, name)
+						imported[name] = n
+					}
+				}
+			}
+		case *nodes.Name:
+			used[n.Name.Val] = true
+		}
+		return true
+	})
+	for _, name := range names {
+		if !used[name] {
+			pass.Report(imported[name], SeverityWarning, "'%s' is imported but never used", name)
+		}
+	}
+}
+
+type deprecatedFilters map[string]string
+
+// DeprecatedFilters reports the usages of the given filters, mapped to the filter to use instead if any
+func DeprecatedFilters(filters map[string]string) Rule {
+	return deprecatedFilters(filters)
+}
+
+func (deprecatedFilters) Name() string { return "deprecated-filter" }
+
+func (r deprecatedFilters) Check(pass *Pass) {
+	nodes.Traverse(pass.Template, func(node nodes.Node, _ []nodes.Node) bool {
+		if call, ok := node.(*nodes.FilterCall); ok {
+			if replacement, deprecated := r[call.Name]; deprecated {
+				if replacement == "" {
+					pass.Report(call, SeverityWarning, "filter '%s' is deprecated", call.Name)
+				} else {
+					pass.Report(call, SeverityWarning, "filter '%s' is deprecated, use '%s' instead", call.Name, replacement)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// escapingFilters are the filters making a value safe to output in HTML
+var escapingFilters = map[string]bool{
+	"e":           true,
+	"escape":      true,
+	"forceescape": true,
+	"urlencode":   true,
+	"tojson":      true,
+}
+
+type unescapedOutput struct {
+	names map[string]bool
+}
+
+// UnescapedOutput reports the outputs of the given variables, typically holding data of the incoming
+// request, which are rendered without being escaped, either because autoescaping is disabled or
+// because they are marked as safe. Variables are looked for in the whole output expression, such as
+// within concatenations or filter arguments.
+func UnescapedOutput(names ...string) Rule {
+	rule := unescapedOutput{names: map[string]bool{}}
+	for _, name := range names {
+		rule.names[name] = true
+	}
+	return rule
+}
+
+func (unescapedOutput) Name() string { return "unescaped-output" }
+
+func (r unescapedOutput) Check(pass *Pass) {
+	nodes.Traverse(pass.Template, func(node nodes.Node, ancestors []nodes.Node) bool {
+		output, ok := node.(*nodes.Output)
+		if !ok {
+			return true
+		}
+		name, safe := r.rendered(output.Expression)
+		switch {
+		case name == "":
+		case safe:
+			pass.Report(output, SeverityWarning, "'%s' is marked as safe and rendered without being escaped", name)
+		case !autoescaped(pass, ancestors):
+			pass.Report(output, SeverityWarning, "'%s' is rendered without being escaped", name)
+		}
+		return false
+	})
+}
+
+// rendered returns the first of the checked variables an expression renders without escaping it, such as
+// 'request' for 'request.args.id' or for 'prefix ~ request.path', and whether it is marked as safe. Parts
+// of the expression going through an escaping filter are skipped.
+func (r unescapedOutput) rendered(expression nodes.Node) (name string, safe bool) {
+	nodes.Traverse(expression, func(node nodes.Node, _ []nodes.Node) bool {
+		if name != "" {
+			return false
+		}
+		switch n := node.(type) {
+		case *nodes.Name:
+			if r.names[n.Name.Val] {
+				name = n.Name.Val
+			}
+		case *nodes.FilteredExpression:
+			marked, escaped := false, false
+			operands := []nodes.Node{n.Expression}
+			for _, filter := range n.Filters {
+				marked = marked || filter.Name == "safe"
+				escaped = escaped || escapingFilters[filter.Name]
+				operands = append(operands, nodes.Children(filter)...)
+			}
+			if escaped && !marked {
+				return false
+			}
+			for _, operand := range operands {
+				if name, safe = r.rendered(operand); name != "" {
+					safe = safe || marked
+					break
+				}
+			}
+			return false
+		}
+		return true
+	})
+	return name, safe
+}
+
+// autoescaped tells whether autoescaping applies to a node, given its ancestors
+func autoescaped(pass *Pass, ancestors []nodes.Node) bool {
+	for index := len(ancestors) - 1; index >= 0; index-- {
+		if block, ok := ancestors[index].(*nodes.ControlStructureBlock); ok {
+			if autoescape, ok := block.ControlStructure.(*controlStructures.AutoescapeControlStructure); ok {
+				return autoescape.Autoescape
+			}
+		}
+	}
+	return pass.Config != nil && pass.Config.AutoEscape
+}
+
+type maxNesting int
+
+// MaxNesting reports the statements nested deeper than the given depth, such as a for loop within
+// an if statement within another for loop for a depth of 2
+func MaxNesting(depth int) Rule {
+	return maxNesting(depth)
+}
+
+func (maxNesting) Name() string { return "max-nesting" }
+
+func (r maxNesting) Check(pass *Pass) {
+	nodes.Traverse(pass.Template, func(node nodes.Node, ancestors []nodes.Node) bool {
+		block, ok := node.(*nodes.ControlStructureBlock)
+		if !ok || !hasBody(block) {
+			return true
+		}
+		depth := 1
+		for _, ancestor := range ancestors {
+			if parent, ok := ancestor.(*nodes.ControlStructureBlock); ok && hasBody(parent) {
+				depth++
+			}
+		}
+		if depth > int(r) {
+			pass.Report(block, SeverityWarning, "'%s' statement is nested %d levels deep, more than the %d allowed", block.Name, depth, int(r))
+			// nested statements would be reported as well otherwise
+			return false
+		}
+		return true
+	})
+}
+
+// hasBody tells whether a statement holds other statements, such as if and for statements
+func hasBody(block *nodes.ControlStructureBlock) bool {
+	for _, child := range nodes.Children(block.ControlStructure) {
+		if _, ok := child.(*nodes.Wrapper); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package loaders
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// UpToDateFunc returns the current version of the template with the given resolved identifier, such as its
+// modification time, its ETag or a version string, which changes whenever the template changes
+type UpToDateFunc func(identifier string) (string, error)
+
+// CachingLoader memoizes the content of the templates read through another loader, keyed by resolved
+// identifier, so that templates imported or included over and over are not read again on every render.
+// The cached content is reused as long as the version of the template stays the same, and can be dropped
+// with Invalidate and Purge, when a deployment updates the templates for instance.
+//
+// Its Stat method reports a version changing whenever the cached content is read again, so that the parsed
+// templates cached by the templates referencing them are reused exactly as long as the content is.
+type CachingLoader struct {
+	loader   Loader
+	uptodate UpToDateFunc
+	// cache is shared by the loader and the loaders inherited from it
+	cache *contentCache
+}
+
+// contentCache holds the content of the templates read through a caching loader
+type contentCache struct {
+	lock    sync.Mutex
+	entries map[string]*cachedContent
+	// reads counts the reads of the wrapped loader, and stamps the cached content
+	reads uint64
+}
+
+type cachedContent struct {
+	version string
+	stamp   uint64
+	content []byte
+}
+
+// MustNewCachingLoader creates a new caching loader instance
+// and panics if there's any error during instantiation
+func MustNewCachingLoader(loader Loader, uptodate UpToDateFunc) *CachingLoader {
+	caching, err := NewCachingLoader(loader, uptodate)
+	if err != nil {
+		log.Panic(err)
+	}
+	return caching
+}
+
+// NewCachingLoader creates a loader caching the content of the templates read through the given loader. The
+// uptodate function gives the version of the templates, which is checked before reusing their cached content.
+// When it is nil, the version is given by the loader if it implements StatLoader, and the content is cached
+// until invalidated otherwise.
+func NewCachingLoader(loader Loader, uptodate UpToDateFunc) (*CachingLoader, error) {
+	if loader == nil {
+		return nil, fmt.Errorf("a loader to cache is required")
+	}
+	if uptodate == nil {
+		if statLoader, ok := loader.(StatLoader); ok {
+			uptodate = statLoader.Stat
+		}
+	}
+	return &CachingLoader{
+		loader:   loader,
+		uptodate: uptodate,
+		cache:    &contentCache{entries: map[string]*cachedContent{}},
+	}, nil
+}
+
+// Inherit creates a new loader from the current one, relatively to the given identifier, sharing its cache
+func (c *CachingLoader) Inherit(from string) (Loader, error) {
+	loader, err := c.loader.Inherit(from)
+	if err != nil {
+		return nil, err
+	}
+	return &CachingLoader{
+		loader:   loader,
+		uptodate: c.uptodate,
+		cache:    c.cache,
+	}, nil
+}
+
+// Read returns the cached content of the template, reading it through the wrapped loader if it is not
+// cached yet or if its version changed
+func (c *CachingLoader) Read(identifier string) (io.Reader, error) {
+	entry, err := c.load(identifier)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(entry.content), nil
+}
+
+// Resolve the given identifier with the wrapped loader
+func (c *CachingLoader) Resolve(identifier string) (string, error) {
+	return c.loader.Resolve(identifier)
+}
+
+// Stat returns the version of the template along with a stamp of its cached content, which changes whenever
+// the content is read again through the wrapped loader
+func (c *CachingLoader) Stat(identifier string) (string, error) {
+	entry, err := c.load(identifier)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s#%d", entry.version, entry.stamp), nil
+}
+
+// Glob returns the identifiers matching the pattern given by the wrapped loader, if it supports it
+func (c *CachingLoader) Glob(pattern string) ([]string, error) {
+	globLoader, ok := c.loader.(GlobLoader)
+	if !ok {
+		return nil, ErrGlobNotSupported
+	}
+	return globLoader.Glob(pattern)
+}
+
+// List returns the identifiers of the templates given by the wrapped loader, if it supports it
+func (c *CachingLoader) List() ([]string, error) {
+	listableLoader, ok := c.loader.(ListableLoader)
+	if !ok {
+		return nil, ErrListNotSupported
+	}
+	return listableLoader.List()
+}
+
+// Exists tells whether the wrapped loader holds the template, without caching its content
+func (c *CachingLoader) Exists(identifier string) (bool, error) {
+	return Exists(c.loader, identifier)
+}
+
+// Invalidate drops the cached content of the template, which is read again the next time it is loaded
+func (c *CachingLoader) Invalidate(identifier string) {
+	if resolved, err := c.loader.Resolve(identifier); err == nil {
+		identifier = resolved
+	}
+	c.cache.lock.Lock()
+	defer c.cache.lock.Unlock()
+	delete(c.cache.entries, identifier)
+}
+
+// Purge drops the cached content of all the templates
+func (c *CachingLoader) Purge() {
+	c.cache.lock.Lock()
+	defer c.cache.lock.Unlock()
+	c.cache.entries = map[string]*cachedContent{}
+}
+
+// load returns the cached content of the template, reading it again if its version changed. Templates whose
+// version cannot be determined are read again and not cached.
+func (c *CachingLoader) load(identifier string) (*cachedContent, error) {
+	resolved, err := c.loader.Resolve(identifier)
+	if err != nil {
+		return nil, err
+	}
+	version, current := "", true
+	if c.uptodate != nil {
+		if version, err = c.uptodate(resolved); err != nil {
+			current = false
+		}
+	}
+
+	c.cache.lock.Lock()
+	entry, cached := c.cache.entries[resolved]
+	c.cache.lock.Unlock()
+	if cached && current && entry.version == version {
+		return entry, nil
+	}
+
+	reader, err := c.loader.Read(resolved)
+	if err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", resolved, err)
+	}
+
+	c.cache.lock.Lock()
+	defer c.cache.lock.Unlock()
+	c.cache.reads++
+	entry = &cachedContent{version: version, stamp: c.cache.reads, content: content}
+	if current {
+		c.cache.entries[resolved] = entry
+	} else {
+		delete(c.cache.entries, resolved)
+	}
+	return entry, nil
+}
@@ -0,0 +1,117 @@
+package loaders_test
+
+import (
+	"io"
+	"testing/fstest"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// readCounter counts the reads of the loader it wraps
+type readCounter struct {
+	loaders.Loader
+	reads map[string]int
+}
+
+func (r *readCounter) Read(identifier string) (io.Reader, error) {
+	r.reads[identifier]++
+	return r.Loader.Read(identifier)
+}
+
+func (r *readCounter) Stat(identifier string) (string, error) {
+	return r.Loader.(loaders.StatLoader).Stat(identifier)
+}
+
+func (r *readCounter) Inherit(from string) (loaders.Loader, error) {
+	inherited, err := r.Loader.Inherit(from)
+	if err != nil {
+		return nil, err
+	}
+	return &readCounter{Loader: inherited, reads: r.reads}, nil
+}
+
+var _ = Context("caching", func() {
+	var (
+		loader *loaders.CachingLoader
+
+		fsys     = new(fstest.MapFS)
+		reads    = new(map[string]int)
+		version  = new(string)
+		uptodate = new(loaders.UpToDateFunc)
+	)
+	BeforeEach(func() {
+		*fsys = fstest.MapFS{
+			"page.html":    {Data: []byte(`{% for i in range(3) %}{% include "partial.html" %}{% endfor %}`)},
+			"partial.html": {Data: []byte(`partial`)},
+		}
+		*reads = map[string]int{}
+		*version = "v1"
+		*uptodate = func(identifier string) (string, error) {
+			return *version, nil
+		}
+	})
+	JustBeforeEach(func() {
+		loader = loaders.MustNewCachingLoader(&readCounter{Loader: loaders.MustNewFSLoader(*fsys, ""), reads: *reads}, *uptodate)
+	})
+	read := func(identifier string) string {
+		reader, err := loader.Read(identifier)
+		Expect(err).To(BeNil())
+		content, err := io.ReadAll(reader)
+		Expect(err).To(BeNil())
+		return string(content)
+	}
+	It("should read each template once", func() {
+		Expect(read("partial.html")).To(Equal("partial"))
+		Expect(read("/partial.html")).To(Equal("partial"))
+		Expect(*reads).To(Equal(map[string]int{"/partial.html": 1}))
+	})
+	It("should read the templates again once their version changed", func() {
+		Expect(read("partial.html")).To(Equal("partial"))
+		before, err := loader.Stat("partial.html")
+		Expect(err).To(BeNil())
+
+		(*fsys)["partial.html"] = &fstest.MapFile{Data: []byte(`updated`)}
+		Expect(read("partial.html")).To(Equal("partial"))
+		*version = "v2"
+		Expect(read("partial.html")).To(Equal("updated"))
+		Expect((*reads)["/partial.html"]).To(Equal(2))
+		Expect(loader.Stat("partial.html")).NotTo(Equal(before))
+	})
+	It("should read the templates again once invalidated", func() {
+		Expect(read("partial.html")).To(Equal("partial"))
+		loader.Invalidate("partial.html")
+		Expect(read("partial.html")).To(Equal("partial"))
+		loader.Purge()
+		Expect(read("partial.html")).To(Equal("partial"))
+		Expect((*reads)["/partial.html"]).To(Equal(3))
+	})
+	Context("when no uptodate function is given", func() {
+		BeforeEach(func() {
+			*uptodate = nil
+		})
+		It("should rely on the versions given by the wrapped loader", func() {
+			Expect(read("partial.html")).To(Equal("partial"))
+			Expect(read("partial.html")).To(Equal("partial"))
+			(*fsys)["partial.html"] = &fstest.MapFile{Data: []byte(`updated partial`)}
+			Expect(read("partial.html")).To(Equal("updated partial"))
+			Expect((*reads)["/partial.html"]).To(Equal(2))
+		})
+	})
+	Context("when rendering templates", func() {
+		It("should neither read nor parse the included templates on every render", func() {
+			for i := 0; i < 2; i++ {
+				template, err := exec.NewTemplate("page.html", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+				Expect(err).To(BeNil())
+				for j := 0; j < 2; j++ {
+					Expect(template.ExecuteToString(nil)).To(Equal("partialpartialpartial"))
+				}
+			}
+			Expect(*reads).To(Equal(map[string]int{"/page.html": 1, "/partial.html": 1}))
+		})
+	})
+})
@@ -0,0 +1,25 @@
+package loaders
+
+import (
+	"log"
+)
+
+// MustNewChoiceLoader creates a new choice loader instance
+// and panics if there's any error during instantiation
+func MustNewChoiceLoader(choices ...Loader) Loader {
+	loader, err := NewChoiceLoader(choices...)
+	if err != nil {
+		log.Panic(err)
+	}
+	return loader
+}
+
+// NewChoiceLoader creates a loader trying the given loaders in order until one of them holds the template,
+// like the ChoiceLoader of Jinja. This lets on-disk templates override the defaults embedded in the binary
+// by passing the file system loader first.
+//
+// It is a search path loader, see NewSearchPathLoader, so an overriding template can still extend the
+// default it overrides with {% extends "!layout.html" %}.
+func NewChoiceLoader(choices ...Loader) (Loader, error) {
+	return NewSearchPathLoader(choices...)
+}
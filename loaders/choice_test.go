@@ -0,0 +1,45 @@
+package loaders_test
+
+import (
+	"testing/fstest"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("choice", func() {
+	var (
+		loader loaders.Loader
+
+		overrides = new(loaders.Loader)
+		defaults  = new(loaders.Loader)
+	)
+	BeforeEach(func() {
+		*overrides = loaders.MustNewFSLoader(fstest.MapFS{
+			"footer.html": {Data: []byte(`{% extends "!footer.html" %}{% block text %}custom{% endblock %}`)},
+		}, "")
+		*defaults = loaders.MustNewFSLoader(fstest.MapFS{
+			"page.html":   {Data: []byte(`page {% include "footer.html" %}`)},
+			"footer.html": {Data: []byte(`<footer>{% block text %}default{% endblock %}</footer>`)},
+		}, "")
+	})
+	JustBeforeEach(func() {
+		loader = loaders.MustNewChoiceLoader(*overrides, *defaults)
+	})
+	It("should load the templates from the first loader holding them", func() {
+		Expect(loader.Resolve("/page.html")).To(Equal("1!/page.html"))
+		Expect(loader.Resolve("/footer.html")).To(Equal("/footer.html"))
+
+		template, err := exec.NewTemplate("/page.html", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+		Expect(err).To(BeNil())
+		Expect(template.ExecuteToString(exec.EmptyContext())).To(Equal("page <footer>custom</footer>"))
+	})
+	It("should fail when no loader holds the template", func() {
+		_, err := loader.Resolve("/missing.html")
+		Expect(err).To(MatchError("template '/missing.html' not found in the search path"))
+	})
+})
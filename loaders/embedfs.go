@@ -4,7 +4,9 @@ import (
 	"embed"
 	"fmt"
 	"io"
+	"io/fs"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -32,7 +34,11 @@ func (e *EmbedFSLoader) Read(path string) (io.Reader, error) {
 		return nil, fmt.Errorf("failed to resolve name '%s': %w", path, err)
 	}
 
-	return e.fs.Open(strings.TrimLeft(resolved, "/"))
+	file, err := e.fs.Open(strings.TrimLeft(resolved, "/"))
+	if err != nil {
+		return nil, fileError(err)
+	}
+	return file, nil
 }
 
 func (e *EmbedFSLoader) Resolve(path string) (string, error) {
@@ -41,7 +47,7 @@ func (e *EmbedFSLoader) Resolve(path string) (string, error) {
 	}
 	resolved := filepath.Clean(strings.Join([]string{e.root, path}, "/"))
 	if _, err := e.fs.Open(strings.TrimLeft(resolved, "/")); err != nil {
-		return "", fmt.Errorf("unknown resolved path '%s': %w", resolved, err)
+		return "", fileError(fmt.Errorf("unknown resolved path '%s': %w", resolved, err))
 	}
 	return resolved, nil
 }
@@ -53,7 +59,7 @@ func (e *EmbedFSLoader) Inherit(from string) (Loader, error) {
 	}
 	resolvedFrom, err := e.Resolve(from)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve '%s': %s", from, err)
+		return nil, fmt.Errorf("failed to resolve '%s': %w", from, err)
 	}
 	components := strings.Split(resolvedFrom, "/")
 	if len(components) < 2 {
@@ -63,3 +69,54 @@ func (e *EmbedFSLoader) Inherit(from string) (Loader, error) {
 	}
 	return NewEmbedFSLoader(root, e.fs)
 }
+
+// Glob returns the paths of the files matching the pattern, resolved as paths are
+func (e *EmbedFSLoader) Glob(pattern string) ([]string, error) {
+	resolved := pattern
+	if !strings.HasPrefix(pattern, "/") {
+		resolved = filepath.Clean(strings.Join([]string{e.root, pattern}, "/"))
+	}
+	matches, err := fs.Glob(e.fs, strings.TrimLeft(resolved, "/"))
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if info, err := fs.Stat(e.fs, match); err == nil && !info.IsDir() {
+			files = append(files, resolved[:len(resolved)-len(strings.TrimLeft(resolved, "/"))]+match)
+		}
+	}
+	return files, nil
+}
+
+// List returns the paths of the files under the root of the loader
+func (e *EmbedFSLoader) List() ([]string, error) {
+	files := []string{}
+	err := fs.WalkDir(e.fs, strings.TrimLeft(e.root, "/"), func(match string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			files = append(files, match)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Exists tells whether the path leads to a file
+func (e *EmbedFSLoader) Exists(path string) (bool, error) {
+	resolved, err := e.Resolve(path)
+	if err != nil {
+		return lookupError(err)
+	}
+	info, err := fs.Stat(e.fs, strings.TrimLeft(resolved, "/"))
+	if err != nil {
+		return lookupError(fileError(err))
+	}
+	return !info.IsDir(), nil
+}
@@ -2,18 +2,30 @@ package loaders
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
-
-	"github.com/pkg/errors"
+	"sort"
+	"strings"
 )
 
 // fileSystemLoader represents a local filesystem loader with basic
-// BaseDirectory capabilities. The access to the local filesystem is unrestricted.
+// BaseDirectory capabilities. The access to the local filesystem is unrestricted,
+// unless the loader is created in strict mode with NewStrictFileSystemLoader.
 type fileSystemLoader struct {
 	root string
+	// sandbox confines the templates to a directory in strict mode, and is nil otherwise
+	sandbox *fileSystemSandbox
+}
+
+// fileSystemSandbox is the directory the templates of a strict file system loader are confined to
+type fileSystemSandbox struct {
+	// root is the absolute path of the directory, and real the same path with its symbolic links evaluated
+	root string
+	real string
 }
 
 // MustNewFileSystemLoader creates a new FilesystemLoader instance
@@ -50,7 +62,7 @@ func NewFileSystemLoader(root string) (Loader, error) {
 			return nil, err
 		}
 		if !fi.IsDir() {
-			return nil, errors.Errorf("The given root '%s' is not a directory.", root)
+			return nil, fmt.Errorf("The given root '%s' is not a directory.", root)
 		}
 
 		loader.root = root
@@ -58,15 +70,51 @@ func NewFileSystemLoader(root string) (Loader, error) {
 	return loader, nil
 }
 
+// MustNewStrictFileSystemLoader creates a new strict file system loader
+// and panics if there's any error during instantiation, see NewStrictFileSystemLoader
+func MustNewStrictFileSystemLoader(root string) Loader {
+	fs, err := NewStrictFileSystemLoader(root)
+	if err != nil {
+		log.Panic(err)
+	}
+	return fs
+}
+
+// NewStrictFileSystemLoader creates a file system loader confining templates to the given root directory.
+// Names are cleaned and their symbolic links evaluated, and the ones leading outside of the root, such as
+// "../../etc/passwd" given to an include statement with a dynamic name, are refused with a SecurityError
+// instead of being read. Absolute names are allowed as long as they lead inside of the root.
+func NewStrictFileSystemLoader(root string) (Loader, error) {
+	if root == "" {
+		return nil, fmt.Errorf("a root directory is required in strict mode")
+	}
+	loader, err := NewFileSystemLoader(root)
+	if err != nil {
+		return nil, err
+	}
+	fs := loader.(*fileSystemLoader)
+	real, err := filepath.EvalSymlinks(fs.root)
+	if err != nil {
+		return nil, err
+	}
+	fs.sandbox = &fileSystemSandbox{root: fs.root, real: real}
+	return fs, nil
+}
+
 func (f *fileSystemLoader) Inherit(from string) (Loader, error) {
 	if from == "" {
-		return NewFileSystemLoader(f.root)
+		return &fileSystemLoader{root: f.root, sandbox: f.sandbox}, nil
 	}
 	resolvedFrom, err := f.Resolve(from)
 	if err != nil {
-		return nil, errors.Errorf("failed to resolve '%s': %s", from, err)
+		return nil, fmt.Errorf("failed to resolve '%s': %w", from, err)
 	}
-	return NewFileSystemLoader(filepath.Dir(resolvedFrom))
+	loader, err := NewFileSystemLoader(filepath.Dir(resolvedFrom))
+	if err != nil {
+		return nil, err
+	}
+	loader.(*fileSystemLoader).sandbox = f.sandbox
+	return loader, nil
 }
 
 // Get reads the path's content from your local filesystem.
@@ -77,7 +125,7 @@ func (f *fileSystemLoader) Read(path string) (io.Reader, error) {
 	}
 	buf, err := os.ReadFile(realPath)
 	if err != nil {
-		return nil, err
+		return nil, fileError(err)
 	}
 	return bytes.NewReader(buf), nil
 }
@@ -88,6 +136,9 @@ func (f *fileSystemLoader) Read(path string) (io.Reader, error) {
 // might be a path of a template which includes another template) or
 // the current working directory.
 func (f *fileSystemLoader) Resolve(name string) (string, error) {
+	if f.sandbox != nil {
+		return f.sandbox.confine(name, f.root)
+	}
 	if filepath.IsAbs(name) {
 		return name, nil
 	}
@@ -102,3 +153,108 @@ func (f *fileSystemLoader) Resolve(name string) (string, error) {
 		return filepath.Join(f.root, name), nil
 	}
 }
+
+// confine resolves the name from the given directory, and refuses it if it leads outside of the sandbox once
+// cleaned, or once its symbolic links are evaluated when it exists
+func (s *fileSystemSandbox) confine(name, dir string) (string, error) {
+	resolved := name
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(dir, name)
+	}
+	resolved = filepath.Clean(resolved)
+	if !within(s.root, resolved) && !within(s.real, resolved) {
+		return "", &SecurityError{Name: name, Reason: "it leads outside of the root directory"}
+	}
+	if real, err := filepath.EvalSymlinks(resolved); err == nil && !within(s.real, real) {
+		return "", &SecurityError{Name: name, Reason: "it links outside of the root directory"}
+	}
+	return resolved, nil
+}
+
+// within tells whether the cleaned path is the given directory or one of its descendants
+func within(dir, path string) bool {
+	relative, err := filepath.Rel(dir, path)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
+
+// Stat returns the modification time and the size of the file as version
+func (f *fileSystemLoader) Stat(path string) (string, error) {
+	realPath, err := f.Resolve(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(realPath)
+	if err != nil {
+		return "", fileError(err)
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), nil
+}
+
+// Glob returns the paths of the files matching the pattern, resolved as paths are
+func (f *fileSystemLoader) Glob(pattern string) ([]string, error) {
+	resolved, err := f.Resolve(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(resolved)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if f.sandbox != nil {
+			if _, err := f.sandbox.confine(match, f.root); err != nil {
+				continue
+			}
+		}
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			files = append(files, match)
+		}
+	}
+	return files, nil
+}
+
+// List returns the paths of the files under the base directory, or under the current working directory
+// when there is none
+func (f *fileSystemLoader) List() ([]string, error) {
+	root, err := f.Resolve(".")
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if f.sandbox != nil {
+			if _, err := f.sandbox.confine(path, f.root); err != nil {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Exists tells whether the path leads to a file
+func (f *fileSystemLoader) Exists(path string) (bool, error) {
+	realPath, err := f.Resolve(path)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(realPath)
+	if err != nil {
+		return lookupError(fileError(err))
+	}
+	return !info.IsDir(), nil
+}
@@ -1,10 +1,13 @@
 package loaders_test
 
 import (
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
 
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/loaders"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -236,4 +239,119 @@ var _ = Context("filesystem", func() {
 			})
 		})
 	})
+	Context("Stat", func() {
+		var (
+			file = new(os.File)
+
+			returnedVersion = new(string)
+		)
+		BeforeEach(func() {
+			file = MustReturn(os.CreateTemp("", "*.filesystem"))
+			MustReturn(file.WriteString("content"))
+		})
+		AfterEach(func() {
+			os.Remove(file.Name())
+		})
+		JustBeforeEach(func() {
+			*returnedVersion, *returnedErr = loader.(loaders.StatLoader).Stat(file.Name())
+		})
+		It("should change the version when the file changes", func() {
+			By("not returning an error")
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedVersion).ToNot(BeEmpty())
+			By("returning another version once the file changed")
+			MustReturn(file.WriteString(" changed"))
+			Expect(loader.(loaders.StatLoader).Stat(file.Name())).ToNot(Equal(*returnedVersion))
+		})
+		Context("when the file does not exist", func() {
+			BeforeEach(func() {
+				os.Remove(file.Name())
+			})
+			It("should return an error", func() {
+				Expect(*returnedErr).ToNot(BeNil())
+			})
+		})
+	})
+})
+
+var _ = Context("strict filesystem", func() {
+	var (
+		loader loaders.Loader
+		dir    = new(string)
+		root   = new(string)
+
+		returnedErr = new(error)
+	)
+
+	BeforeEach(func() {
+		*dir = MustReturn(os.MkdirTemp("", "*.filesystem"))
+		*root = filepath.Join(*dir, "templates")
+		Expect(os.MkdirAll(filepath.Join(*root, "partials"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(*dir, "secret.txt"), []byte("secret"), 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(*root, "page.html"), []byte(`{% include name %}`), 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(*root, "partials", "header.html"), []byte(`header`), 0o644)).To(Succeed())
+		Expect(os.Symlink(filepath.Join(*dir, "secret.txt"), filepath.Join(*root, "partials", "link.html"))).To(Succeed())
+	})
+	AfterEach(func() {
+		os.RemoveAll(*dir)
+	})
+
+	JustBeforeEach(func() {
+		loader, *returnedErr = loaders.NewStrictFileSystemLoader(*root)
+	})
+
+	It("should not return any error", func() {
+		Expect(*returnedErr).To(BeNil())
+	})
+	Context("when no root is given", func() {
+		BeforeEach(func() {
+			*root = ""
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError("a root directory is required in strict mode"))
+		})
+	})
+	Context("Resolve", func() {
+		It("should resolve names leading inside of the root", func() {
+			Expect(loader.Resolve("partials/../page.html")).To(Equal(filepath.Join(*root, "page.html")))
+			Expect(loader.Resolve(filepath.Join(*root, "page.html"))).To(Equal(filepath.Join(*root, "page.html")))
+		})
+		It("should refuse names leading outside of the root", func() {
+			for _, name := range []string{"../secret.txt", "partials/../../secret.txt", filepath.Join(*dir, "secret.txt")} {
+				_, err := loader.Resolve(name)
+				var security *loaders.SecurityError
+				Expect(errors.As(err, &security)).To(BeTrue(), name)
+				Expect(security.Name).To(Equal(name))
+				Expect(err).To(MatchError("refusing to load '" + name + "': it leads outside of the root directory"))
+			}
+		})
+		It("should refuse symbolic links leading outside of the root", func() {
+			_, err := loader.Resolve("partials/link.html")
+			Expect(err).To(MatchError("refusing to load 'partials/link.html': it links outside of the root directory"))
+		})
+	})
+	Context("Inherit", func() {
+		It("should keep confining templates to the root", func() {
+			inherited, err := loader.Inherit("partials/header.html")
+			Expect(err).To(BeNil())
+			Expect(inherited.Resolve("header.html")).To(Equal(filepath.Join(*root, "partials", "header.html")))
+			_, err = inherited.Read("../../secret.txt")
+			Expect(err).To(MatchError(ContainSubstring("it leads outside of the root directory")))
+		})
+	})
+	Context("Glob", func() {
+		It("should leave out the matches linking outside of the root", func() {
+			Expect(loader.(loaders.GlobLoader).Glob("partials/*.html")).To(Equal([]string{filepath.Join(*root, "partials", "header.html")}))
+		})
+	})
+	Context("when a template includes a dynamic name", func() {
+		It("should refuse to render names leading outside of the root", func() {
+			template, err := exec.NewTemplate("page.html", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+			Expect(err).To(BeNil())
+			Expect(template.ExecuteToString(exec.NewContext(map[string]interface{}{"name": "partials/header.html"}))).To(Equal("header"))
+			_, err = template.ExecuteToString(exec.NewContext(map[string]interface{}{"name": "../secret.txt"}))
+			var security *loaders.SecurityError
+			Expect(errors.As(err, &security)).To(BeTrue())
+		})
+	})
 })
@@ -0,0 +1,160 @@
+package loaders
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strings"
+)
+
+// fsLoader loads templates from an io/fs.FS, such as an embed.FS, the result of os.DirFS or a zip archive.
+// Identifiers are slash separated: the ones starting with '/' are rooted at the root of the file system,
+// and the other ones are relative to the directory of the loader, which is the directory of the template
+// referencing them once the loader is inherited. Identifiers leading outside of the file system are rejected.
+type fsLoader struct {
+	fsys fs.FS
+	dir  string
+}
+
+// MustNewFSLoader creates a new FSLoader and panics if there's any error during instantiation, see NewFSLoader
+func MustNewFSLoader(fsys fs.FS, dir string) Loader {
+	loader, err := NewFSLoader(fsys, dir)
+	if err != nil {
+		log.Panic(err)
+	}
+	return loader
+}
+
+// NewFSLoader creates a loader reading templates from the file system, resolving relative identifiers from
+// the given directory of the file system, or from its root when the directory is empty
+func NewFSLoader(fsys fs.FS, dir string) (Loader, error) {
+	dir = strings.TrimPrefix(path.Clean("/"+dir), "/")
+	if dir == "" {
+		dir = "."
+	}
+	info, err := fs.Stat(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("the given directory '%s' is not a directory", dir)
+	}
+	return &fsLoader{
+		fsys: fsys,
+		dir:  dir,
+	}, nil
+}
+
+func (f *fsLoader) Inherit(from string) (Loader, error) {
+	if from == "" {
+		return &fsLoader{fsys: f.fsys, dir: f.dir}, nil
+	}
+	resolvedFrom, err := f.Resolve(from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve '%s': %w", from, err)
+	}
+	return &fsLoader{fsys: f.fsys, dir: path.Dir(fsPath(resolvedFrom))}, nil
+}
+
+func (f *fsLoader) Read(name string) (io.Reader, error) {
+	resolved, err := f.Resolve(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve name '%s': %w", name, err)
+	}
+	data, err := fs.ReadFile(f.fsys, fsPath(resolved))
+	if err != nil {
+		return nil, fileError(err)
+	}
+	return bytes.NewReader(data), nil
+}
+
+// Resolve returns the identifier rooted at the root of the file system, such as '/partials/header.html'
+func (f *fsLoader) Resolve(name string) (string, error) {
+	if strings.HasPrefix(name, "/") {
+		return path.Clean(name), nil
+	}
+	resolved := path.Join(f.dir, name)
+	if !fs.ValidPath(resolved) {
+		return "", fmt.Errorf("'%s' leads outside of the file system", name)
+	}
+	if resolved == "." {
+		return "/", nil
+	}
+	return "/" + resolved, nil
+}
+
+// Stat returns the modification time and the size of the file as version
+func (f *fsLoader) Stat(name string) (string, error) {
+	resolved, err := f.Resolve(name)
+	if err != nil {
+		return "", err
+	}
+	info, err := fs.Stat(f.fsys, fsPath(resolved))
+	if err != nil {
+		return "", fileError(err)
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), nil
+}
+
+// Glob returns the paths of the files matching the pattern, resolved as paths are
+func (f *fsLoader) Glob(pattern string) ([]string, error) {
+	resolved, err := f.Resolve(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := fs.Glob(f.fsys, fsPath(resolved))
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if info, err := fs.Stat(f.fsys, match); err == nil && !info.IsDir() {
+			files = append(files, "/"+match)
+		}
+	}
+	return files, nil
+}
+
+// List returns the paths of the files under the directory of the loader, resolved as paths are
+func (f *fsLoader) List() ([]string, error) {
+	files := []string{}
+	err := fs.WalkDir(f.fsys, f.dir, func(match string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			files = append(files, "/"+match)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Exists tells whether the path leads to a file
+func (f *fsLoader) Exists(name string) (bool, error) {
+	resolved, err := f.Resolve(name)
+	if err != nil {
+		return false, err
+	}
+	info, err := fs.Stat(f.fsys, fsPath(resolved))
+	if err != nil {
+		return lookupError(fileError(err))
+	}
+	return !info.IsDir(), nil
+}
+
+// fsPath returns the path of the file system a resolved identifier stands for
+func fsPath(resolved string) string {
+	if resolved == "/" {
+		return "."
+	}
+	return strings.TrimPrefix(resolved, "/")
+}
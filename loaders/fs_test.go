@@ -0,0 +1,117 @@
+package loaders_test
+
+import (
+	"io"
+	"testing/fstest"
+	"time"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("fs", func() {
+	var (
+		loader loaders.Loader
+
+		fsys = new(fstest.MapFS)
+		dir  = new(string)
+
+		returnedErr = new(error)
+	)
+
+	BeforeEach(func() {
+		*fsys = fstest.MapFS{
+			"templates/page.html":           {Data: []byte(`{% extends "layouts/base.html" %}{% block body %}{% include "partials/header.html" %}{% endblock %}`)},
+			"templates/layouts/base.html":   {Data: []byte(`{% import "macros.html" as macros %}<{{ macros.title() }}>{% block body %}{% endblock %}`)},
+			"templates/layouts/macros.html": {Data: []byte(`{% macro title() %}layout{% endmacro %}`)},
+			"templates/partials/header.html": {
+				Data:    []byte(`header`),
+				ModTime: time.Unix(1700000000, 0),
+			},
+			"templates/partials/footer.html": {Data: []byte(`footer`)},
+		}
+		*dir = "templates"
+	})
+
+	JustBeforeEach(func() {
+		loader, *returnedErr = loaders.NewFSLoader(*fsys, *dir)
+	})
+
+	It("should not return any error", func() {
+		Expect(*returnedErr).To(BeNil())
+	})
+	Context("when the directory does not exist", func() {
+		BeforeEach(func() {
+			*dir = "missing"
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("missing")))
+		})
+	})
+	Context("when the directory is a file", func() {
+		BeforeEach(func() {
+			*dir = "templates/page.html"
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError("the given directory 'templates/page.html' is not a directory"))
+		})
+	})
+	Context("Read", func() {
+		It("should read paths relatively to the directory", func() {
+			reader, err := loader.Read("partials/header.html")
+			Expect(err).To(BeNil())
+			Expect(io.ReadAll(reader)).To(BeEquivalentTo("header"))
+		})
+		It("should read absolute paths from the root of the file system", func() {
+			reader, err := loader.Read("/templates/partials/footer.html")
+			Expect(err).To(BeNil())
+			Expect(io.ReadAll(reader)).To(BeEquivalentTo("footer"))
+		})
+		It("should return an error for unknown paths", func() {
+			_, err := loader.Read("partials/missing.html")
+			Expect(err).To(MatchError(ContainSubstring("file does not exist")))
+		})
+	})
+	Context("Resolve", func() {
+		It("should root the resolved paths", func() {
+			Expect(loader.Resolve("partials/../partials/header.html")).To(Equal("/templates/partials/header.html"))
+			Expect(loader.Resolve("/templates//page.html")).To(Equal("/templates/page.html"))
+		})
+		It("should reject paths leading outside of the file system", func() {
+			_, err := loader.Resolve("../../etc/passwd")
+			Expect(err).To(MatchError("'../../etc/passwd' leads outside of the file system"))
+		})
+	})
+	Context("Inherit", func() {
+		It("should resolve paths relatively to the given template", func() {
+			inherited, err := loader.Inherit("partials/header.html")
+			Expect(err).To(BeNil())
+			Expect(inherited.Resolve("footer.html")).To(Equal("/templates/partials/footer.html"))
+			Expect(inherited.Resolve("../page.html")).To(Equal("/templates/page.html"))
+		})
+	})
+	Context("Stat", func() {
+		It("should return the modification time and size of the file", func() {
+			Expect(loader.(loaders.StatLoader).Stat("partials/header.html")).To(Equal("1700000000000000000-6"))
+		})
+	})
+	Context("Glob", func() {
+		It("should return the files matching the pattern", func() {
+			Expect(loader.(loaders.GlobLoader).Glob("partials/*.html")).To(ConsistOf(
+				"/templates/partials/header.html",
+				"/templates/partials/footer.html",
+			))
+		})
+	})
+	Context("when rendering templates referencing other templates", func() {
+		It("should resolve them relatively to the templates referencing them", func() {
+			template, err := exec.NewTemplate("page.html", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+			Expect(err).To(BeNil())
+			Expect(template.ExecuteToString(nil)).To(Equal("<layout>header"))
+		})
+	})
+})
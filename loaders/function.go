@@ -0,0 +1,83 @@
+package loaders
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+)
+
+// LoadFunc returns the content of the template with the given slash separated path, such as
+// "emails/welcome.html", or a nil reader when there is no such template
+type LoadFunc func(name string) (io.Reader, error)
+
+// functionLoader loads templates through a function, resolving identifiers like the file system loader does
+type functionLoader struct {
+	load LoadFunc
+	dir  string
+}
+
+// MustNewFunctionLoader creates a new function loader instance
+// and panics if there's any error during instantiation
+func MustNewFunctionLoader(load LoadFunc) Loader {
+	loader, err := NewFunctionLoader(load)
+	if err != nil {
+		log.Panic(err)
+	}
+	return loader
+}
+
+// NewFunctionLoader creates a loader reading templates through the given function, which makes it easy to
+// back templates with a database, a key-value store or generated content, like the FunctionLoader of Jinja.
+//
+// Identifiers are resolved as the ones of the loader returned by NewFSLoader: the ones starting with '/' are
+// rooted, and the other ones are relative to the template referencing them. The function is given the
+// resolved paths without their leading slash, and never paths leading outside of the root.
+func NewFunctionLoader(load LoadFunc) (Loader, error) {
+	if load == nil {
+		return nil, fmt.Errorf("a load function is required")
+	}
+	return &functionLoader{load: load, dir: "."}, nil
+}
+
+func (f *functionLoader) Inherit(from string) (Loader, error) {
+	if from == "" {
+		return &functionLoader{load: f.load, dir: f.dir}, nil
+	}
+	resolvedFrom, err := f.Resolve(from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve '%s': %w", from, err)
+	}
+	return &functionLoader{load: f.load, dir: path.Dir(fsPath(resolvedFrom))}, nil
+}
+
+func (f *functionLoader) Read(name string) (io.Reader, error) {
+	resolved, err := f.Resolve(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve name '%s': %w", name, err)
+	}
+	reader, err := f.load(fsPath(resolved))
+	if err != nil {
+		return nil, err
+	}
+	if reader == nil {
+		return nil, notFound(fmt.Errorf("template '%s' not found", resolved))
+	}
+	return reader, nil
+}
+
+// Resolve returns the rooted path of the template, such as '/emails/welcome.html'
+func (f *functionLoader) Resolve(name string) (string, error) {
+	if strings.HasPrefix(name, "/") {
+		return path.Clean(name), nil
+	}
+	resolved := path.Join(f.dir, name)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return "", fmt.Errorf("'%s' leads outside of the root", name)
+	}
+	if resolved == "." {
+		return "/", nil
+	}
+	return "/" + resolved, nil
+}
@@ -0,0 +1,82 @@
+package loaders_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("function", func() {
+	var (
+		loader loaders.Loader
+
+		rows  = new(map[string]string)
+		names = new([]string)
+	)
+	BeforeEach(func() {
+		*rows = map[string]string{
+			"emails/welcome.html": `{% extends "emails/layout.html" %}{% block body %}Welcome {{ name }}{% endblock %}`,
+			"emails/layout.html":  `<mail>{% block body %}{% endblock %}{% include "footer.html" %}</mail>`,
+			"emails/footer.html":  `{% include "/signature.html" %}`,
+			"signature.html":      `, the team`,
+		}
+		*names = []string{}
+	})
+	JustBeforeEach(func() {
+		loader = loaders.MustNewFunctionLoader(func(name string) (io.Reader, error) {
+			*names = append(*names, name)
+			if name == "broken.html" {
+				return nil, errors.New("connection refused")
+			}
+			row, ok := (*rows)[name]
+			if !ok {
+				return nil, nil
+			}
+			return strings.NewReader(row), nil
+		})
+	})
+	Context("Read", func() {
+		It("should give the path of the template to the function", func() {
+			reader, err := loader.Read("/emails/../signature.html")
+			Expect(err).To(BeNil())
+			Expect(io.ReadAll(reader)).To(BeEquivalentTo(", the team"))
+			Expect(*names).To(Equal([]string{"signature.html"}))
+		})
+		It("should return an error when the function does not find the template", func() {
+			_, err := loader.Read("missing.html")
+			Expect(err).To(MatchError("template '/missing.html' not found"))
+		})
+		It("should return the errors of the function", func() {
+			_, err := loader.Read("broken.html")
+			Expect(err).To(MatchError("connection refused"))
+		})
+	})
+	Context("Resolve", func() {
+		It("should reject paths leading outside of the root", func() {
+			_, err := loader.Resolve("../secret.html")
+			Expect(err).To(MatchError("'../secret.html' leads outside of the root"))
+		})
+	})
+	Context("Inherit", func() {
+		It("should resolve paths relatively to the given template", func() {
+			inherited, err := loader.Inherit("emails/welcome.html")
+			Expect(err).To(BeNil())
+			Expect(inherited.Resolve("layout.html")).To(Equal("/emails/layout.html"))
+			Expect(inherited.Resolve("/signature.html")).To(Equal("/signature.html"))
+		})
+	})
+	Context("when rendering templates referencing other templates", func() {
+		It("should load them through the function", func() {
+			template, err := exec.NewTemplate("emails/welcome.html", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+			Expect(err).To(BeNil())
+			Expect(template.ExecuteToString(exec.NewContext(map[string]interface{}{"name": "Ada"}))).To(Equal("<mail>Welcome Ada, the team</mail>"))
+		})
+	})
+})
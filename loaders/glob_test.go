@@ -0,0 +1,69 @@
+package loaders_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("glob includes", func() {
+	var (
+		source = new(string)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*source = `{% include "conf.d/*.conf.j2" %}`
+	})
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(map[string]string{
+			"/root.j2":                 *source,
+			"/conf.d/20-b.conf.j2":     `b={{ value }};`,
+			"/conf.d/10-a.conf.j2":     `a={{ value }};`,
+			"/conf.d/30-c.conf.j2":     `{% include "../footer.j2" %}`,
+			"/conf.d/ignored.conf.txt": `ignored`,
+			"/footer.j2":               `end`,
+			"/pages/[id].j2":           `id={{ value }}`,
+		})
+		template, err := exec.NewTemplate("/root.j2", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		*returnedResult, *returnedErr = template.ExecuteToString(exec.NewContext(map[string]interface{}{"value": 1}))
+	})
+	It("should render every matching template in sorted order", func() {
+		Expect(*returnedErr).To(BeNil())
+		Expect(*returnedResult).To(Equal("a=1;b=1;end"))
+	})
+	Context("when nothing matches the pattern", func() {
+		BeforeEach(func() {
+			*source = `[{% include "conf.d/*.yaml" %}]`
+		})
+		It("should render nothing", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("[]"))
+		})
+	})
+	Context("when the name holds special characters but nothing matches it as a pattern", func() {
+		BeforeEach(func() {
+			*source = `{% include "pages/[id].j2" %}`
+		})
+		It("should include the template with that name", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("id=1"))
+		})
+	})
+	Context("when the pattern is computed", func() {
+		BeforeEach(func() {
+			*source = `{% include "conf.d/" ~ "1*" %}`
+		})
+		It("should expand it as well", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("a=1;"))
+		})
+	})
+})
@@ -0,0 +1,245 @@
+package loaders
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPLoaderOptions configures how an HTTP loader fetches templates
+type HTTPLoaderOptions struct {
+	// Client sends the requests, http.DefaultClient when nil
+	Client *http.Client
+	// Timeout bounds each request, on top of the timeout of the client. Zero means no additional timeout.
+	Timeout time.Duration
+	// Header is added to every request, to authenticate against the server for instance
+	Header http.Header
+	// MaxSize is the number of bytes a template may have, DefaultHTTPMaxSize when zero
+	MaxSize int64
+}
+
+// DefaultHTTPMaxSize is the number of bytes a template fetched by an HTTP loader may have by default
+const DefaultHTTPMaxSize = 10 << 20
+
+// httpLoader loads templates from an HTTP(S) server, such as a CDN or a configuration service.
+// Identifiers are URL references resolved against the base URL of the loader, which is the URL of the
+// template referencing them once the loader is inherited. Identifiers leading to another origin than the
+// one of the base URL are rejected, so that templates cannot make the application fetch arbitrary URLs, and
+// so are the redirects of the server to another origin.
+//
+// The fetched templates are cached along with their ETag and Last-Modified headers, which are sent back
+// as If-None-Match and If-Modified-Since so that unchanged templates are revalidated instead of downloaded.
+type httpLoader struct {
+	base    *url.URL
+	options HTTPLoaderOptions
+	// cache is shared by the loader and the loaders inherited from it
+	cache *httpCache
+}
+
+// httpCache holds the last response received for each URL
+type httpCache struct {
+	lock      sync.Mutex
+	responses map[string]*httpResponse
+}
+
+// httpResponse is a fetched template along with its validators
+type httpResponse struct {
+	body         []byte
+	etag         string
+	lastModified string
+}
+
+// MustNewHTTPLoader creates a new HTTP loader and panics if there's any error during instantiation, see NewHTTPLoader
+func MustNewHTTPLoader(baseURL string, options *HTTPLoaderOptions) Loader {
+	loader, err := NewHTTPLoader(baseURL, options)
+	if err != nil {
+		log.Panic(err)
+	}
+	return loader
+}
+
+// NewHTTPLoader creates a loader fetching templates from the given http or https base URL. The base URL is
+// considered a directory, so "https://cdn.example.com/templates" resolves "page.html" as
+// "https://cdn.example.com/templates/page.html". The options may be nil.
+func NewHTTPLoader(baseURL string, options *HTTPLoaderOptions) (Loader, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL '%s': %w", baseURL, err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("the base URL '%s' is not an http or https URL", baseURL)
+	}
+	if base.Host == "" {
+		return nil, fmt.Errorf("the base URL '%s' has no host", baseURL)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+		base.RawPath = ""
+	}
+	base.RawQuery = ""
+	base.Fragment = ""
+
+	loader := &httpLoader{
+		base:  base,
+		cache: &httpCache{responses: map[string]*httpResponse{}},
+	}
+	if options != nil {
+		loader.options = *options
+	}
+	if loader.options.Client == nil {
+		loader.options.Client = http.DefaultClient
+	}
+	if loader.options.MaxSize <= 0 {
+		loader.options.MaxSize = DefaultHTTPMaxSize
+	}
+	// the client is copied so that redirects can be confined to the origin of the base URL without
+	// altering the client given in the options
+	client := *loader.options.Client
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(request *http.Request, via []*http.Request) error {
+		if request.URL.Scheme != base.Scheme || request.URL.Host != base.Host {
+			return fmt.Errorf("redirect to '%s' is not served by %s://%s", request.URL, base.Scheme, base.Host)
+		}
+		if checkRedirect != nil {
+			return checkRedirect(request, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	loader.options.Client = &client
+	return loader, nil
+}
+
+func (h *httpLoader) Inherit(from string) (Loader, error) {
+	if from == "" {
+		return &httpLoader{base: h.base, options: h.options, cache: h.cache}, nil
+	}
+	resolvedFrom, err := h.Resolve(from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve '%s': %w", from, err)
+	}
+	base, _ := url.Parse(resolvedFrom)
+	return &httpLoader{base: base, options: h.options, cache: h.cache}, nil
+}
+
+func (h *httpLoader) Read(name string) (io.Reader, error) {
+	resolved, err := h.Resolve(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve name '%s': %w", name, err)
+	}
+	response, err := h.fetch(resolved)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(response.body), nil
+}
+
+// Resolve returns the absolute URL of the template, such as 'https://cdn.example.com/templates/page.html'
+func (h *httpLoader) Resolve(name string) (string, error) {
+	reference, err := url.Parse(name)
+	if err != nil {
+		return "", fmt.Errorf("'%s' is not a valid URL: %w", name, err)
+	}
+	resolved := h.base.ResolveReference(reference)
+	if resolved.Scheme != h.base.Scheme || resolved.Host != h.base.Host {
+		return "", fmt.Errorf("'%s' is not served by %s://%s", name, h.base.Scheme, h.base.Host)
+	}
+	resolved.Fragment = ""
+	return resolved.String(), nil
+}
+
+// Stat revalidates the template against the server and returns its ETag, or its Last-Modified date when
+// the server does not send any ETag, or else a checksum of its content as version
+func (h *httpLoader) Stat(name string) (string, error) {
+	resolved, err := h.Resolve(name)
+	if err != nil {
+		return "", err
+	}
+	response, err := h.fetch(resolved)
+	if err != nil {
+		return "", err
+	}
+	if response.etag != "" {
+		return response.etag, nil
+	}
+	if response.lastModified != "" {
+		return response.lastModified, nil
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(response.body)), nil
+}
+
+// fetch returns the template served at the URL, reusing the cached one when the server reports it unchanged
+func (h *httpLoader) fetch(resolved string) (*httpResponse, error) {
+	h.cache.lock.Lock()
+	cached := h.cache.responses[resolved]
+	h.cache.lock.Unlock()
+
+	ctx := context.Background()
+	if h.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.options.Timeout)
+		defer cancel()
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, resolved, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range h.options.Header {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+	if cached != nil {
+		if cached.etag != "" {
+			request.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			request.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	response, err := h.options.Client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch '%s': %w", resolved, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified && cached != nil {
+		return cached, nil
+	}
+	if response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusGone {
+		return nil, notFound(fmt.Errorf("failed to fetch '%s': %s", resolved, response.Status))
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch '%s': %s", resolved, response.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, h.options.MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch '%s': %w", resolved, err)
+	}
+	if int64(len(body)) > h.options.MaxSize {
+		return nil, fmt.Errorf("failed to fetch '%s': larger than %d bytes", resolved, h.options.MaxSize)
+	}
+	fetched := &httpResponse{
+		body:         body,
+		etag:         response.Header.Get("ETag"),
+		lastModified: response.Header.Get("Last-Modified"),
+	}
+	if fetched.etag != "" || fetched.lastModified != "" {
+		h.cache.lock.Lock()
+		h.cache.responses[resolved] = fetched
+		h.cache.lock.Unlock()
+	}
+	return fetched, nil
+}
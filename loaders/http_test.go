@@ -0,0 +1,200 @@
+package loaders_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("http", func() {
+	var (
+		loader loaders.Loader
+		server *httptest.Server
+
+		files       = new(map[string]string)
+		fetched     = new([]string)
+		revalidated = new([]string)
+		redirects   = new(map[string]string)
+		options     = new(*loaders.HTTPLoaderOptions)
+		baseURL     = new(string)
+
+		returnedErr = new(error)
+	)
+
+	BeforeEach(func() {
+		*files = map[string]string{
+			"/templates/page.html":            `{% extends "layouts/base.html" %}{% block body %}{% include "partials/header.html" %}{% endblock %}`,
+			"/templates/layouts/base.html":    `{% import "macros.html" as macros %}<{{ macros.title() }}>{% block body %}{% endblock %}`,
+			"/templates/layouts/macros.html":  `{% macro title() %}layout{% endmacro %}`,
+			"/templates/partials/header.html": `header`,
+		}
+		*fetched = []string{}
+		*revalidated = []string{}
+		*redirects = map[string]string{}
+		*options = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "none" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Path == "/slow" {
+				time.Sleep(200 * time.Millisecond)
+			}
+			if target, ok := (*redirects)[r.URL.Path]; ok {
+				http.Redirect(w, r, target, http.StatusFound)
+				return
+			}
+			content, ok := (*files)[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			etag := `"` + r.URL.Path + "-" + string(rune('a'+len(content)%26)) + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				*revalidated = append(*revalidated, r.URL.Path)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			*fetched = append(*fetched, r.URL.Path)
+			w.Header().Set("ETag", etag)
+			_, _ = io.WriteString(w, content)
+		}))
+		*baseURL = server.URL + "/templates"
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	JustBeforeEach(func() {
+		loader, *returnedErr = loaders.NewHTTPLoader(*baseURL, *options)
+	})
+
+	It("should not return any error", func() {
+		Expect(*returnedErr).To(BeNil())
+	})
+	Context("when the base URL is not an http URL", func() {
+		BeforeEach(func() {
+			*baseURL = "ftp://example.com/templates"
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError("the base URL 'ftp://example.com/templates' is not an http or https URL"))
+		})
+	})
+	Context("Resolve", func() {
+		It("should resolve identifiers against the base URL", func() {
+			Expect(loader.Resolve("partials/header.html")).To(Equal(server.URL + "/templates/partials/header.html"))
+			Expect(loader.Resolve("/templates/page.html")).To(Equal(server.URL + "/templates/page.html"))
+			Expect(loader.Resolve(server.URL + "/templates/page.html")).To(Equal(server.URL + "/templates/page.html"))
+		})
+		It("should reject identifiers served by another origin", func() {
+			_, err := loader.Resolve("https://evil.example.com/page.html")
+			Expect(err).To(MatchError("'https://evil.example.com/page.html' is not served by " + server.URL))
+		})
+	})
+	Context("Inherit", func() {
+		It("should resolve identifiers relatively to the given template", func() {
+			inherited, err := loader.Inherit("partials/header.html")
+			Expect(err).To(BeNil())
+			Expect(inherited.Resolve("footer.html")).To(Equal(server.URL + "/templates/partials/footer.html"))
+			Expect(inherited.Resolve("../page.html")).To(Equal(server.URL + "/templates/page.html"))
+		})
+	})
+	Context("Read", func() {
+		It("should fetch the template", func() {
+			reader, err := loader.Read("partials/header.html")
+			Expect(err).To(BeNil())
+			Expect(io.ReadAll(reader)).To(BeEquivalentTo("header"))
+		})
+		It("should return an error for unknown templates", func() {
+			_, err := loader.Read("partials/missing.html")
+			Expect(err).To(MatchError(ContainSubstring("failed to fetch '" + server.URL + "/templates/partials/missing.html': 404 Not Found")))
+		})
+		It("should revalidate the templates already fetched", func() {
+			for i := 0; i < 3; i++ {
+				reader, err := loader.Read("partials/header.html")
+				Expect(err).To(BeNil())
+				Expect(io.ReadAll(reader)).To(BeEquivalentTo("header"))
+			}
+			Expect(*fetched).To(Equal([]string{"/templates/partials/header.html"}))
+			Expect(*revalidated).To(HaveLen(2))
+		})
+		It("should fetch the templates again once they changed", func() {
+			_, err := loader.Read("partials/header.html")
+			Expect(err).To(BeNil())
+			(*files)["/templates/partials/header.html"] = "new header"
+			reader, err := loader.Read("partials/header.html")
+			Expect(err).To(BeNil())
+			Expect(io.ReadAll(reader)).To(BeEquivalentTo("new header"))
+			Expect(*fetched).To(HaveLen(2))
+		})
+		Context("when headers are configured", func() {
+			BeforeEach(func() {
+				*options = &loaders.HTTPLoaderOptions{Header: http.Header{"Authorization": {"none"}}}
+			})
+			It("should send them", func() {
+				_, err := loader.Read("page.html")
+				Expect(err).To(MatchError(ContainSubstring("401 Unauthorized")))
+			})
+		})
+		Context("when the server redirects to the same origin", func() {
+			BeforeEach(func() {
+				(*redirects)["/templates/old.html"] = "/templates/partials/header.html"
+			})
+			It("should follow the redirect", func() {
+				reader, err := loader.Read("old.html")
+				Expect(err).To(BeNil())
+				Expect(io.ReadAll(reader)).To(BeEquivalentTo("header"))
+			})
+		})
+		Context("when the server redirects to another origin", func() {
+			BeforeEach(func() {
+				(*redirects)["/templates/old.html"] = "http://example.com/page.html"
+			})
+			It("should return an error", func() {
+				_, err := loader.Read("old.html")
+				Expect(err).To(MatchError(ContainSubstring("redirect to 'http://example.com/page.html' is not served by " + server.URL)))
+			})
+		})
+		Context("when a template is too large", func() {
+			BeforeEach(func() {
+				*options = &loaders.HTTPLoaderOptions{MaxSize: 4}
+			})
+			It("should return an error", func() {
+				_, err := loader.Read("partials/header.html")
+				Expect(err).To(MatchError(ContainSubstring("larger than 4 bytes")))
+			})
+		})
+		Context("when a timeout is configured", func() {
+			BeforeEach(func() {
+				(*files)["/slow"] = "slow"
+				*options = &loaders.HTTPLoaderOptions{Timeout: 20 * time.Millisecond}
+			})
+			It("should give up on slow responses", func() {
+				_, err := loader.Read("/slow")
+				Expect(err).To(MatchError(ContainSubstring("context deadline exceeded")))
+			})
+		})
+	})
+	Context("Stat", func() {
+		It("should return the ETag of the template", func() {
+			Expect(loader.(loaders.StatLoader).Stat("partials/header.html")).To(Equal(`"/templates/partials/header.html-g"`))
+		})
+	})
+	Context("when rendering templates referencing other templates", func() {
+		It("should fetch them relatively to the templates referencing them", func() {
+			template, err := exec.NewTemplate("page.html", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+			Expect(err).To(BeNil())
+			Expect(template.ExecuteToString(nil)).To(Equal("<layout>header"))
+			Expect(strings.Join(*fetched, ",")).To(ContainSubstring("/templates/layouts/macros.html"))
+		})
+	})
+})
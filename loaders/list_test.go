@@ -0,0 +1,184 @@
+package loaders_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("listing templates", func() {
+	var (
+		loader = new(loaders.Loader)
+	)
+	Context("with the memory loader", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				"/page.html":          "page",
+				"/partials/nav.html":  "nav",
+				"/partials/foot.html": "foot",
+			})
+		})
+		It("should list every template", func() {
+			Expect((*loader).(loaders.ListableLoader).List()).To(Equal([]string{"/page.html", "/partials/foot.html", "/partials/nav.html"}))
+		})
+		It("should only list the templates under the root of inherited loaders", func() {
+			loader := loaders.MustNewMemoryLoader(map[string]string{
+				"/a/page.html":  "page",
+				"/a/b/nav.html": "nav",
+				"/ab/page.html": "other",
+			})
+			inherited, err := loader.Inherit("/a/page.html")
+			Expect(err).To(BeNil())
+			Expect(inherited.(loaders.ListableLoader).List()).To(Equal([]string{"/a/b/nav.html", "/a/page.html"}))
+		})
+		It("should tell which templates exist", func() {
+			Expect(loaders.Exists(*loader, "/partials/nav.html")).To(BeTrue())
+			Expect(loaders.Exists(*loader, "/partials/missing.html")).To(BeFalse())
+		})
+		It("should return the first existing template", func() {
+			Expect(loaders.FirstExisting(*loader, "/missing.html", "/page.html")).To(Equal("/page.html"))
+			_, err := loaders.FirstExisting(*loader, "/missing.html", "/other.html")
+			Expect(errors.Is(err, loaders.ErrTemplateNotFound)).To(BeTrue())
+			Expect(err).To(MatchError("none of the templates '/missing.html', '/other.html' exists"))
+		})
+	})
+	Context("with the io/fs loader", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewFSLoader(fstest.MapFS{
+				"emails/welcome.html": &fstest.MapFile{Data: []byte("welcome")},
+				"emails/base.html":    &fstest.MapFile{Data: []byte("base")},
+				"page.html":           &fstest.MapFile{Data: []byte("page")},
+			}, "emails")
+		})
+		It("should list the templates of its directory", func() {
+			Expect((*loader).(loaders.ListableLoader).List()).To(Equal([]string{"/emails/base.html", "/emails/welcome.html"}))
+		})
+		It("should tell which templates exist", func() {
+			Expect(loaders.Exists(*loader, "welcome.html")).To(BeTrue())
+			Expect(loaders.Exists(*loader, "/page.html")).To(BeTrue())
+			Expect(loaders.Exists(*loader, "missing.html")).To(BeFalse())
+			Expect(loaders.Exists(*loader, "/emails")).To(BeFalse())
+		})
+	})
+	Context("with the strict file system loader", func() {
+		var (
+			root    = new(string)
+			outside = new(string)
+		)
+		BeforeEach(func() {
+			*root = MustReturn(os.MkdirTemp("", "*.list"))
+			*outside = MustReturn(os.MkdirTemp("", "*.list"))
+			Expect(os.MkdirAll(filepath.Join(*root, "partials"), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(*root, "page.html"), []byte("page"), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(*root, "partials", "nav.html"), []byte("nav"), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(*outside, "secret"), []byte("secret"), 0o644)).To(Succeed())
+			Expect(os.Symlink(filepath.Join(*outside, "secret"), filepath.Join(*root, "link.html"))).To(Succeed())
+			*loader = loaders.MustNewStrictFileSystemLoader(*root)
+			*root = MustReturn(filepath.EvalSymlinks(*root))
+		})
+		AfterEach(func() {
+			os.RemoveAll(*root)
+			os.RemoveAll(*outside)
+		})
+		It("should list the files under its root only", func() {
+			files := MustReturn((*loader).(loaders.ListableLoader).List())
+			for index, file := range files {
+				files[index] = strings.TrimPrefix(MustReturn(filepath.EvalSymlinks(file)), *root)
+			}
+			Expect(files).To(Equal([]string{"/page.html", "/partials/nav.html"}))
+		})
+		It("should refuse to tell whether files outside of its root exist", func() {
+			Expect(loaders.Exists(*loader, "partials/nav.html")).To(BeTrue())
+			Expect(loaders.Exists(*loader, "partials/missing.html")).To(BeFalse())
+			var security *loaders.SecurityError
+			_, err := loaders.Exists(*loader, "../"+filepath.Base(*outside)+"/secret")
+			Expect(errors.As(err, &security)).To(BeTrue())
+		})
+	})
+	Context("with the namespaced loader", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewNamespacedLoader(
+				loaders.MustNewMemoryLoader(map[string]string{"/page.html": "page"}),
+				map[string]loaders.Loader{"base": loaders.MustNewMemoryLoader(map[string]string{"/layout.html": "layout"})},
+			)
+		})
+		It("should list the templates of every namespace", func() {
+			Expect((*loader).(loaders.ListableLoader).List()).To(Equal([]string{"/page.html", "base::/layout.html"}))
+		})
+		It("should tell which templates exist", func() {
+			Expect(loaders.Exists(*loader, "base::/layout.html")).To(BeTrue())
+			Expect(loaders.Exists(*loader, "base::/page.html")).To(BeFalse())
+			Expect(loaders.Exists(*loader, "other::/page.html")).To(BeFalse())
+		})
+	})
+	Context("with a loader which cannot list templates", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewFunctionLoader(func(name string) (io.Reader, error) {
+				if name == "page.html" {
+					return strings.NewReader("page"), nil
+				}
+				return nil, nil
+			})
+		})
+		It("should tell which templates exist by reading them", func() {
+			Expect(loaders.Exists(*loader, "page.html")).To(BeTrue())
+			Expect(loaders.Exists(*loader, "missing.html")).To(BeFalse())
+		})
+	})
+})
+
+var _ = Context("including the first existing template", func() {
+	var (
+		source = new(string)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(map[string]string{
+			"/root.j2":    *source,
+			"/default.j2": "default",
+		})
+		template, err := exec.NewTemplate("/root.j2", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		*returnedResult, *returnedErr = template.ExecuteToString(exec.EmptyContext())
+	})
+	Context("when one of the templates exists", func() {
+		BeforeEach(func() {
+			*source = `{% include ["/custom.j2", "/default.j2"] %}`
+		})
+		It("should include it", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedResult).To(Equal("default"))
+		})
+	})
+	Context("when none of the templates exists", func() {
+		BeforeEach(func() {
+			*source = `{% include ["/custom.j2", "/other.j2"] %}`
+		})
+		It("should fail", func() {
+			Expect(errors.Is(*returnedErr, loaders.ErrTemplateNotFound)).To(BeTrue())
+		})
+		Context("when missing templates are ignored", func() {
+			BeforeEach(func() {
+				*source = `[{% include ["/custom.j2", "/other.j2"] ignore missing %}]`
+			})
+			It("should render nothing", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect(*returnedResult).To(Equal("[]"))
+			})
+		})
+	})
+})
@@ -1,7 +1,11 @@
 package loaders
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
+	"strings"
 )
 
 // Loader is a wrapper interface to interact with a storage system for templates
@@ -15,3 +19,139 @@ type Loader interface {
 	// Create a new loader from the current one, relatively to the given path
 	Inherit(from string) (Loader, error)
 }
+
+// StatLoader is implemented by loaders able to tell whether a template changed,
+// which allows cached templates to be parsed again when their content changes
+type StatLoader interface {
+	Loader
+
+	// Stat returns a version of the template, such as its modification time, an ETag or a hash of its
+	// content, which changes whenever the content changes. An empty version means that the template
+	// is never revalidated.
+	Stat(path string) (string, error)
+}
+
+// GlobLoader is implemented by loaders able to list the templates matching a pattern, which allows include
+// statements to render every template matching a pattern such as "conf.d/*.conf.j2"
+type GlobLoader interface {
+	Loader
+
+	// Glob returns the resolved identifiers of the templates matching a pattern written with the syntax of
+	// path.Match, in no particular order. Patterns are resolved in the current context, like identifiers are.
+	Glob(pattern string) ([]string, error)
+}
+
+// ErrGlobNotSupported is returned by loaders wrapping loaders which cannot list templates
+var ErrGlobNotSupported = errors.New("the loader cannot list templates matching a pattern")
+
+// ListableLoader is implemented by loaders able to enumerate their templates, which allows applications to
+// list the available templates or validate the references of their templates at startup
+type ListableLoader interface {
+	Loader
+
+	// List returns the sorted resolved identifiers of the templates available from the current context,
+	// including the ones of its subdirectories
+	List() ([]string, error)
+
+	// Exists tells whether the template with the given identifier exists, resolving it in the current context.
+	// Missing templates are not errors.
+	Exists(identifier string) (bool, error)
+}
+
+// ErrListNotSupported is returned by loaders wrapping loaders which cannot enumerate templates
+var ErrListNotSupported = errors.New("the loader cannot enumerate templates")
+
+// Exists tells whether the loader holds the template with the given identifier. It asks loaders implementing
+// ListableLoader, and reads the template with the other ones, reporting the errors other than
+// ErrTemplateNotFound.
+func Exists(loader Loader, identifier string) (bool, error) {
+	if listableLoader, ok := loader.(ListableLoader); ok {
+		return listableLoader.Exists(identifier)
+	}
+	reader, err := loader.Read(identifier)
+	if err != nil {
+		return lookupError(err)
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		closer.Close()
+	}
+	return true, nil
+}
+
+// FirstExisting returns the first of the identifiers whose template exists, like Jinja does when given a
+// list of templates to include or extend. It fails with an error matching ErrTemplateNotFound when none of
+// them exists.
+func FirstExisting(loader Loader, identifiers ...string) (string, error) {
+	for _, identifier := range identifiers {
+		found, err := Exists(loader, identifier)
+		if err != nil {
+			return "", fmt.Errorf("failed to look '%s' up: %w", identifier, err)
+		}
+		if found {
+			return identifier, nil
+		}
+	}
+	return "", notFound(fmt.Errorf("none of the templates %s exists", strings.Join(quote(identifiers), ", ")))
+}
+
+// quote wraps each identifier into single quotes
+func quote(identifiers []string) []string {
+	quoted := make([]string, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		quoted = append(quoted, "'"+identifier+"'")
+	}
+	return quoted
+}
+
+// lookupError turns the error of a template lookup into the result of Exists: missing templates do not exist,
+// and the other errors are returned
+func lookupError(err error) (bool, error) {
+	if errors.Is(err, ErrTemplateNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+// ErrTemplateNotFound is matched with errors.Is by the errors of the loaders failing to find a template,
+// which tells missing templates apart from loaders failing to read existing ones
+var ErrTemplateNotFound = errors.New("template not found")
+
+// notFoundError reports a missing template with the message of the loader, and matches ErrTemplateNotFound
+type notFoundError struct {
+	cause error
+}
+
+func (e *notFoundError) Error() string        { return e.cause.Error() }
+func (e *notFoundError) Unwrap() error        { return e.cause }
+func (e *notFoundError) Is(target error) bool { return target == ErrTemplateNotFound }
+
+// notFound marks the error as reporting a missing template
+func notFound(err error) error {
+	return &notFoundError{cause: err}
+}
+
+// fileError marks the errors of file systems reporting missing files as reporting missing templates
+func fileError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return notFound(err)
+	}
+	return err
+}
+
+// SecurityError is returned by the loaders refusing to load a template, such as the strict file system loader
+// given a name leading outside of its root, and can be retrieved with errors.As
+type SecurityError struct {
+	// Name is the name of the template as given to the loader
+	Name string
+	// Reason tells why the template was refused
+	Reason string
+}
+
+func (e *SecurityError) Error() string {
+	return fmt.Sprintf("refusing to load '%s': %s", e.Name, e.Reason)
+}
+
+// IsPattern tells whether an identifier holds any of the special characters of glob patterns
+func IsPattern(identifier string) bool {
+	return strings.ContainsAny(identifier, "*?[")
+}
@@ -2,9 +2,12 @@ package loaders
 
 import (
 	"fmt"
+	"hash/fnv"
 	"io"
 	"log"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -49,7 +52,7 @@ func (m *memoryLoader) Inherit(from string) (Loader, error) {
 	if from != "" {
 		resolvedFrom, err := m.Resolve(from)
 		if err != nil {
-			return nil, fmt.Errorf("failed to resolve '%s': %s", from, err)
+			return nil, fmt.Errorf("failed to resolve '%s': %w", from, err)
 		}
 		components := strings.Split(resolvedFrom, "/")
 		if len(components) < 2 {
@@ -67,12 +70,12 @@ func (m *memoryLoader) Inherit(from string) (Loader, error) {
 func (m *memoryLoader) Read(path string) (io.Reader, error) {
 	resolved, err := m.Resolve(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve name '%s': %s", path, err)
+		return nil, fmt.Errorf("failed to resolve name '%s': %w", path, err)
 	}
 
 	data, ok := m.content[resolved]
 	if !ok {
-		return nil, fmt.Errorf("unknown path: '%s'", resolved)
+		return nil, notFound(fmt.Errorf("unknown path: '%s'", resolved))
 	}
 	return strings.NewReader(data), nil
 }
@@ -83,8 +86,81 @@ func (m *memoryLoader) Resolve(path string) (string, error) {
 	}
 	resolved := filepath.Clean(strings.Join([]string{m.root, path}, "/"))
 	if _, ok := m.content[resolved]; !ok {
-		return "", fmt.Errorf("unknown resolved path: '%s'", resolved)
+		return "", notFound(fmt.Errorf("unknown resolved path: '%s'", resolved))
 	}
 
 	return resolved, nil
 }
+
+// Stat returns a hash of the content of the template as version
+func (m *memoryLoader) Stat(path string) (string, error) {
+	resolved, err := m.Resolve(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve name '%s': %w", path, err)
+	}
+	data, ok := m.content[resolved]
+	if !ok {
+		return "", notFound(fmt.Errorf("unknown path: '%s'", resolved))
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(data))
+	return fmt.Sprintf("%x", hash.Sum64()), nil
+}
+
+// Glob returns the paths of the templates matching the pattern, resolved as paths are
+func (m *memoryLoader) Glob(pattern string) ([]string, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		pattern = filepath.Clean(strings.Join([]string{m.root, pattern}, "/"))
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	matches := []string{}
+	for key := range m.content {
+		if matched, _ := path.Match(pattern, key); matched {
+			matches = append(matches, key)
+		}
+	}
+	return matches, nil
+}
+
+// List returns the paths of the templates under the root of the loader
+func (m *memoryLoader) List() ([]string, error) {
+	directory := m.directory()
+	paths := []string{}
+	for key := range m.content {
+		if strings.HasPrefix(key, directory) {
+			paths = append(paths, key)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// directory returns the root of the loader as a directory ending with a slash, so that the root /a does not
+// hold /ab/page.html. The root of a loader created by NewMemoryLoader is the longest common prefix of its
+// paths, which may stop in the middle of a name, in which case the directory holding it is returned.
+func (m *memoryLoader) directory() string {
+	if strings.HasSuffix(m.root, "/") {
+		return m.root
+	}
+	for key := range m.content {
+		if strings.HasPrefix(key, m.root+"/") {
+			return m.root + "/"
+		}
+	}
+	if directory := path.Dir(m.root); directory != "/" {
+		return directory + "/"
+	}
+	return "/"
+}
+
+// Exists tells whether a template is registered at the resolved path
+func (m *memoryLoader) Exists(path string) (bool, error) {
+	resolved, err := m.Resolve(path)
+	if err != nil {
+		return lookupError(err)
+	}
+	_, ok := m.content[resolved]
+	return ok, nil
+}
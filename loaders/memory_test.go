@@ -131,4 +131,64 @@ var _ = Context("memory", func() {
 			})
 		})
 	})
+	Context("Stat", func() {
+		var (
+			returnedVersion = new(string)
+		)
+		JustBeforeEach(func() {
+			*returnedVersion, *returnedErr = loader.(loaders.StatLoader).Stat("/home/of")
+		})
+		It("should change the version when the content changes", func() {
+			By("not returning an error")
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedVersion).ToNot(BeEmpty())
+			By("returning the same version for the same content")
+			Expect(loader.(loaders.StatLoader).Stat("/home/of")).To(Equal(*returnedVersion))
+			By("returning another version once the content changed")
+			(*content)["/home/of"] = "changed"
+			Expect(loader.(loaders.StatLoader).Stat("/home/of")).ToNot(Equal(*returnedVersion))
+		})
+		Context("when the path is unknown", func() {
+			JustBeforeEach(func() {
+				*returnedVersion, *returnedErr = loader.(loaders.StatLoader).Stat("/unknown")
+			})
+			It("should return an error", func() {
+				Expect(*returnedErr).To(MatchError("unknown path: '/unknown'"))
+			})
+		})
+	})
+	Context("Glob", func() {
+		var (
+			pattern = new(string)
+
+			returnedMatches = new([]string)
+		)
+		BeforeEach(func() {
+			*pattern = "/home/*"
+		})
+		JustBeforeEach(func() {
+			*returnedMatches, *returnedErr = loader.(loaders.GlobLoader).Glob(*pattern)
+		})
+		It("should return the paths matching the pattern", func() {
+			Expect(*returnedErr).To(BeNil())
+			Expect(*returnedMatches).To(ConsistOf("/home/sweet", "/home/of"))
+		})
+		Context("when the pattern is relative", func() {
+			BeforeEach(func() {
+				*pattern = "s*"
+			})
+			It("should resolve it from the root of the loader", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect(*returnedMatches).To(ConsistOf("/home/sweet"))
+			})
+		})
+		Context("when the pattern is malformed", func() {
+			BeforeEach(func() {
+				*pattern = "/home/["
+			})
+			It("should return an error", func() {
+				Expect(*returnedErr).ToNot(BeNil())
+			})
+		})
+	})
 })
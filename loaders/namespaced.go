@@ -0,0 +1,197 @@
+package loaders
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+)
+
+// NamespaceSeparator separates the namespace from the path in identifiers such as "base::layout.html"
+const NamespaceSeparator = "::"
+
+// namespacedLoader dispatches identifiers prefixed with a namespace to the loader registered for it,
+// and every other identifier to the loader of the template being loaded
+type namespacedLoader struct {
+	// loader resolves the identifiers without namespace, relatively to the current template. It is nil
+	// when there is no default loader and the current template does not belong to any namespace yet.
+	loader Loader
+	// namespace is the namespace of the current template, empty for the default loader
+	namespace  string
+	namespaces map[string]Loader
+	// separator separates the namespace from the path, NamespaceSeparator or PrefixSeparator
+	separator string
+}
+
+// MustNewNamespacedLoader creates a new namespaced loader instance
+// and panics if there's any error during instantiation
+func MustNewNamespacedLoader(loader Loader, namespaces map[string]Loader) Loader {
+	namespaced, err := NewNamespacedLoader(loader, namespaces)
+	if err != nil {
+		log.Panic(err)
+	}
+	return namespaced
+}
+
+// NewNamespacedLoader creates a loader where identifiers such as "base::layout.html" are loaded by the
+// loader registered for their namespace, here "base", while the other identifiers are loaded by the given
+// default loader. This lets templates coming from different sources extend, include or import one another,
+// like the templates of a plugin extending the base templates of the host application.
+//
+// Identifiers without namespace are resolved by the loader of the template they appear in, so the templates
+// of a namespace keep loading their own dependencies from it. The identifiers resolved by a namespace keep
+// their prefix so that they never collide with the ones of other loaders.
+func NewNamespacedLoader(loader Loader, namespaces map[string]Loader) (Loader, error) {
+	for namespace := range namespaces {
+		if namespace == "" || strings.Contains(namespace, NamespaceSeparator) {
+			return nil, fmt.Errorf("invalid namespace '%s'", namespace)
+		}
+	}
+	return &namespacedLoader{
+		loader:     loader,
+		namespaces: namespaces,
+		separator:  NamespaceSeparator,
+	}, nil
+}
+
+// target returns the loader in charge of an identifier, along with its namespace and the identifier
+// without namespace
+func (n *namespacedLoader) target(identifier string) (Loader, string, string, error) {
+	namespace, path, found := strings.Cut(identifier, n.separator)
+	if !found {
+		if n.loader == nil {
+			return nil, "", "", notFound(fmt.Errorf("missing namespace"))
+		}
+		return n.loader, n.namespace, identifier, nil
+	}
+	loader, ok := n.namespaces[namespace]
+	if !ok {
+		return nil, "", "", notFound(fmt.Errorf("unknown namespace '%s'", namespace))
+	}
+	return loader, namespace, path, nil
+}
+
+// Inherit creates a new loader from the current one, relatively to the given identifier
+func (n *namespacedLoader) Inherit(from string) (Loader, error) {
+	loader, namespace, path, err := n.target(from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inherit from '%s': %w", from, err)
+	}
+	inherited, err := loader.Inherit(path)
+	if err != nil {
+		return nil, err
+	}
+	return &namespacedLoader{
+		loader:     inherited,
+		namespace:  namespace,
+		namespaces: n.namespaces,
+		separator:  n.separator,
+	}, nil
+}
+
+// Read returns an io.Reader where the template's content can be read from
+func (n *namespacedLoader) Read(identifier string) (io.Reader, error) {
+	loader, _, path, err := n.target(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", identifier, err)
+	}
+	return loader.Read(path)
+}
+
+// Resolve the given identifier in the current context
+func (n *namespacedLoader) Resolve(identifier string) (string, error) {
+	loader, namespace, path, err := n.target(identifier)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve '%s': %w", identifier, err)
+	}
+	resolved, err := loader.Resolve(path)
+	if err != nil {
+		return "", err
+	}
+	if namespace != "" {
+		return namespace + n.separator + resolved, nil
+	}
+	return resolved, nil
+}
+
+// Stat returns the version given by the loader in charge of the identifier, if it supports it
+func (n *namespacedLoader) Stat(identifier string) (string, error) {
+	loader, _, path, err := n.target(identifier)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat '%s': %w", identifier, err)
+	}
+	if loader, ok := loader.(StatLoader); ok {
+		return loader.Stat(path)
+	}
+	return "", nil
+}
+
+// Glob returns the identifiers matching the pattern given by the loader in charge of it, if it supports it
+func (n *namespacedLoader) Glob(pattern string) ([]string, error) {
+	loader, namespace, path, err := n.target(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to glob '%s': %w", pattern, err)
+	}
+	globLoader, ok := loader.(GlobLoader)
+	if !ok {
+		return nil, ErrGlobNotSupported
+	}
+	matches, err := globLoader.Glob(path)
+	if err != nil || namespace == "" {
+		return matches, err
+	}
+	for index, match := range matches {
+		matches[index] = namespace + n.separator + match
+	}
+	return matches, nil
+}
+
+// List returns the identifiers of the templates of the current loader and of every namespace, if they all
+// support it
+func (n *namespacedLoader) List() ([]string, error) {
+	identifiers := []string{}
+	if n.loader != nil {
+		listableLoader, ok := n.loader.(ListableLoader)
+		if !ok {
+			return nil, ErrListNotSupported
+		}
+		list, err := listableLoader.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, identifier := range list {
+			if n.namespace != "" {
+				identifier = n.namespace + n.separator + identifier
+			}
+			identifiers = append(identifiers, identifier)
+		}
+	}
+	for namespace, loader := range n.namespaces {
+		if namespace == n.namespace {
+			continue
+		}
+		listableLoader, ok := loader.(ListableLoader)
+		if !ok {
+			return nil, ErrListNotSupported
+		}
+		list, err := listableLoader.List()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list namespace '%s': %w", namespace, err)
+		}
+		for _, identifier := range list {
+			identifiers = append(identifiers, namespace+n.separator+identifier)
+		}
+	}
+	sort.Strings(identifiers)
+	return identifiers, nil
+}
+
+// Exists tells whether the loader in charge of the identifier holds the template
+func (n *namespacedLoader) Exists(identifier string) (bool, error) {
+	loader, _, path, err := n.target(identifier)
+	if err != nil {
+		return lookupError(err)
+	}
+	return Exists(loader, path)
+}
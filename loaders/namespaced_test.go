@@ -0,0 +1,111 @@
+package loaders_test
+
+import (
+	"io"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("namespaced", func() {
+	var (
+		loader loaders.Loader
+
+		host   = new(loaders.Loader)
+		plugin = new(loaders.Loader)
+	)
+	BeforeEach(func() {
+		*host = loaders.MustNewMemoryLoader(map[string]string{
+			"/layouts/base.html":    `<title>{% block title %}Host{% endblock %}</title>{% include "/partials/footer.html" %}`,
+			"/partials/footer.html": `<footer>host</footer>`,
+			"/macros.j2":            `{% macro badge(text) %}[{{ text }}]{% endmacro %}`,
+		})
+		*plugin = loaders.MustNewMemoryLoader(map[string]string{
+			"/pages/index.html":     `{% extends "base::/layouts/base.html" %}{% block title %}Plugin {% include "/partials/footer.html" %}{% endblock %}`,
+			"/pages/badge.html":     `{% import "base::/macros.j2" as macros %}{{ macros.badge("new") }}`,
+			"/partials/footer.html": `plugin`,
+		})
+	})
+	JustBeforeEach(func() {
+		loader = loaders.MustNewNamespacedLoader(*plugin, map[string]loaders.Loader{"base": *host})
+	})
+	Context("Resolve", func() {
+		It("should keep the namespace of resolved identifiers", func() {
+			Expect(loader.Resolve("base::/layouts/base.html")).To(Equal("base::/layouts/base.html"))
+			Expect(loader.Resolve("/pages/index.html")).To(Equal("/pages/index.html"))
+		})
+		It("should resolve identifiers without namespace with the loader of the current template", func() {
+			inherited, err := loader.Inherit("base::/layouts/base.html")
+			Expect(err).To(BeNil())
+			Expect(inherited.Resolve("/partials/footer.html")).To(Equal("base::/partials/footer.html"))
+			Expect(inherited.Resolve("base::/macros.j2")).To(Equal("base::/macros.j2"))
+		})
+		It("should fail for unknown namespaces", func() {
+			_, err := loader.Resolve("other::/layouts/base.html")
+			Expect(err).To(MatchError("failed to resolve 'other::/layouts/base.html': unknown namespace 'other'"))
+		})
+	})
+	Context("Read", func() {
+		It("should read from the loader of the namespace", func() {
+			reader, err := loader.Read("base::/partials/footer.html")
+			Expect(err).To(BeNil())
+			Expect(io.ReadAll(reader)).To(Equal([]byte("<footer>host</footer>")))
+		})
+	})
+	Context("when rendering templates", func() {
+		var (
+			identifier = new(string)
+
+			returnedResult = new(string)
+			returnedErr    = new(error)
+		)
+		JustBeforeEach(func() {
+			template, err := exec.NewTemplate(*identifier, gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+			if *returnedErr = err; err != nil {
+				return
+			}
+			*returnedResult, *returnedErr = template.ExecuteToString(exec.EmptyContext())
+		})
+		Context("when extending a template of another loader", func() {
+			BeforeEach(func() {
+				*identifier = "/pages/index.html"
+			})
+			It("should load each template dependency from the loader it belongs to", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect(*returnedResult).To(Equal("<title>Plugin plugin</title><footer>host</footer>"))
+			})
+			It("should report the dependencies of each template", func() {
+				template, err := exec.NewTemplate(*identifier, gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+				Expect(err).To(BeNil())
+				graph, err := template.Dependencies()
+				Expect(err).To(BeNil())
+				Expect(graph.Dependencies).To(Equal(map[string][]exec.Dependency{
+					"/pages/index.html": {
+						{Kind: "extends", Identifier: "base::/layouts/base.html"},
+						{Kind: "include", Identifier: "/partials/footer.html"},
+					},
+					"base::/layouts/base.html":    {{Kind: "include", Identifier: "base::/partials/footer.html"}},
+					"/partials/footer.html":       {},
+					"base::/partials/footer.html": {},
+				}))
+			})
+		})
+		Context("when importing macros from another loader", func() {
+			BeforeEach(func() {
+				*identifier = "/pages/badge.html"
+			})
+			It("should render them", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect(*returnedResult).To(Equal("[new]"))
+			})
+		})
+	})
+	It("should reject invalid namespaces", func() {
+		_, err := loaders.NewNamespacedLoader(*plugin, map[string]loaders.Loader{"a::b": *host})
+		Expect(err).To(MatchError("invalid namespace 'a::b'"))
+	})
+})
@@ -0,0 +1,42 @@
+package loaders
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// PrefixSeparator separates the prefix from the path in identifiers such as "emails/welcome.html"
+const PrefixSeparator = "/"
+
+// MustNewPrefixLoader creates a new prefix loader instance
+// and panics if there's any error during instantiation
+func MustNewPrefixLoader(prefixes map[string]Loader) Loader {
+	loader, err := NewPrefixLoader(prefixes)
+	if err != nil {
+		log.Panic(err)
+	}
+	return loader
+}
+
+// NewPrefixLoader creates a loader routing identifiers such as "emails/welcome.html" to the loader registered
+// for their first path segment, here "emails", which loads "welcome.html", like the PrefixLoader of Jinja.
+//
+// As with Jinja, templates reference one another with their prefix. The prefixes work as the namespaces of a
+// namespaced loader without default loader though, so the templates loaded by another template can also
+// reference the templates of their own prefix with identifiers without slash. The resolved identifiers keep
+// their prefix, as in "emails//welcome.html" when the loader of the prefix resolves "welcome.html" as "/welcome.html".
+func NewPrefixLoader(prefixes map[string]Loader) (Loader, error) {
+	if len(prefixes) == 0 {
+		return nil, fmt.Errorf("at least one prefix is required")
+	}
+	for prefix := range prefixes {
+		if prefix == "" || strings.Contains(prefix, PrefixSeparator) {
+			return nil, fmt.Errorf("invalid prefix '%s'", prefix)
+		}
+	}
+	return &namespacedLoader{
+		namespaces: prefixes,
+		separator:  PrefixSeparator,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package loaders_test
+
+import (
+	"io"
+	"testing/fstest"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("prefix", func() {
+	var (
+		loader loaders.Loader
+
+		prefixes = new(map[string]loaders.Loader)
+
+		returnedErr = new(error)
+	)
+	BeforeEach(func() {
+		*prefixes = map[string]loaders.Loader{
+			"emails": loaders.MustNewFSLoader(fstest.MapFS{
+				"welcome.html": {Data: []byte(`{% extends "emails/layout.html" %}{% block body %}Welcome {% include "pages/signature.html" %}{% endblock %}`)},
+				"layout.html":  {Data: []byte(`<mail>{% block body %}{% endblock %}{% include "footer.html" %}</mail>`)},
+				"footer.html":  {Data: []byte(`!`)},
+			}, ""),
+			"pages": loaders.MustNewFSLoader(fstest.MapFS{
+				"signature.html": {Data: []byte(`the team`)},
+			}, ""),
+		}
+	})
+	JustBeforeEach(func() {
+		loader, *returnedErr = loaders.NewPrefixLoader(*prefixes)
+	})
+	It("should not return any error", func() {
+		Expect(*returnedErr).To(BeNil())
+	})
+	Context("when a prefix contains a slash", func() {
+		BeforeEach(func() {
+			(*prefixes)["emails/marketing"] = loaders.MustNewMemoryLoader(nil)
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError("invalid prefix 'emails/marketing'"))
+		})
+	})
+	Context("Resolve", func() {
+		It("should keep the prefix of resolved identifiers", func() {
+			Expect(loader.Resolve("emails/welcome.html")).To(Equal("emails//welcome.html"))
+		})
+		It("should resolve identifiers without prefix with the loader of the current template", func() {
+			inherited, err := loader.Inherit("emails/welcome.html")
+			Expect(err).To(BeNil())
+			Expect(inherited.Resolve("layout.html")).To(Equal("emails//layout.html"))
+		})
+		It("should fail for identifiers without prefix outside of any prefix", func() {
+			_, err := loader.Resolve("welcome.html")
+			Expect(err).To(MatchError("failed to resolve 'welcome.html': missing namespace"))
+		})
+		It("should fail for unknown prefixes", func() {
+			_, err := loader.Resolve("other/welcome.html")
+			Expect(err).To(MatchError("failed to resolve 'other/welcome.html': unknown namespace 'other'"))
+		})
+	})
+	Context("Read", func() {
+		It("should read from the loader of the prefix", func() {
+			reader, err := loader.Read("pages/signature.html")
+			Expect(err).To(BeNil())
+			Expect(io.ReadAll(reader)).To(BeEquivalentTo("the team"))
+		})
+	})
+	Context("when rendering templates", func() {
+		It("should load each template from the loader of its prefix", func() {
+			template, err := exec.NewTemplate("emails/welcome.html", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+			Expect(err).To(BeNil())
+			Expect(template.ExecuteToString(exec.EmptyContext())).To(Equal("<mail>Welcome the team!</mail>"))
+		})
+	})
+})
@@ -0,0 +1,108 @@
+package loaders
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// rootedLoader resolves identifiers starting with "./" or "../" relatively to the current template, and
+// every other identifier from the root of the loader it wraps
+type rootedLoader struct {
+	root    Loader
+	current Loader
+}
+
+// IsRelative tells whether an identifier is explicitly relative to the template referencing it, that is
+// whether it starts with "./" or "../"
+func IsRelative(identifier string) bool {
+	return strings.HasPrefix(identifier, "./") || strings.HasPrefix(identifier, "../")
+}
+
+// NewRootedLoader wraps a loader so that the templates it loads resolve the identifiers starting with "./"
+// or "../" relatively to their own path, and every other identifier from the root of the loader, like the
+// python implementation does. The returned loader is the one of the template with the given identifier.
+// Loaders which are already rooted are returned as they are.
+func NewRootedLoader(loader Loader, identifier string) (Loader, error) {
+	if _, ok := loader.(*rootedLoader); ok {
+		return loader, nil
+	}
+	current, err := loader.Inherit(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inherit loader from '%s': %w", identifier, err)
+	}
+	return &rootedLoader{root: loader, current: current}, nil
+}
+
+// Inherit creates a new loader from the current one, relatively to the given identifier
+func (r *rootedLoader) Inherit(from string) (Loader, error) {
+	resolved, err := r.Resolve(from)
+	if err != nil {
+		return nil, err
+	}
+	current, err := r.current.Inherit(resolved)
+	if err != nil {
+		return nil, err
+	}
+	return &rootedLoader{root: r.root, current: current}, nil
+}
+
+// Read returns an io.Reader where the template's content can be read from
+func (r *rootedLoader) Read(identifier string) (io.Reader, error) {
+	resolved, err := r.Resolve(identifier)
+	if err != nil {
+		return nil, err
+	}
+	return r.root.Read(resolved)
+}
+
+// Resolve the given identifier relatively to the current template if it starts with "./" or "../",
+// and from the root otherwise
+func (r *rootedLoader) Resolve(identifier string) (string, error) {
+	if IsRelative(identifier) {
+		return r.current.Resolve(identifier)
+	}
+	return r.root.Resolve(identifier)
+}
+
+// Stat returns the version given by the wrapped loader, if it supports it
+func (r *rootedLoader) Stat(identifier string) (string, error) {
+	resolved, err := r.Resolve(identifier)
+	if err != nil {
+		return "", err
+	}
+	if loader, ok := r.root.(StatLoader); ok {
+		return loader.Stat(resolved)
+	}
+	return "", nil
+}
+
+// Glob returns the identifiers matching the pattern, resolved as identifiers are, if the wrapped loader
+// supports it
+func (r *rootedLoader) Glob(pattern string) ([]string, error) {
+	loader := r.root
+	if IsRelative(pattern) {
+		loader = r.current
+	}
+	if globLoader, ok := loader.(GlobLoader); ok {
+		return globLoader.Glob(pattern)
+	}
+	return nil, ErrGlobNotSupported
+}
+
+// List returns the identifiers of the templates given by the wrapped loader from its root, if it supports it
+func (r *rootedLoader) List() ([]string, error) {
+	if listableLoader, ok := r.root.(ListableLoader); ok {
+		return listableLoader.List()
+	}
+	return nil, ErrListNotSupported
+}
+
+// Exists tells whether the template exists, resolving the identifier as identifiers are
+func (r *rootedLoader) Exists(identifier string) (bool, error) {
+	resolved, err := r.Resolve(identifier)
+	if err != nil {
+		return lookupError(err)
+	}
+	return Exists(r.root, resolved)
+}
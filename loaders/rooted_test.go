@@ -0,0 +1,57 @@
+package loaders_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("rooted", func() {
+	var (
+		relativePaths = new(bool)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*relativePaths = true
+	})
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(map[string]string{
+			"/layouts/base.html":          `[{% block body %}{% endblock %}]`,
+			"/partials/footer.html":       `footer`,
+			"/pages/macros.j2":            `{% macro badge() %}badge{% endmacro %}`,
+			"/pages/blog/post.html":       `{% extends "layouts/base.html" %}{% block body %}{% include "./parts/meta.html" %}{% endblock %}`,
+			"/pages/blog/parts/meta.html": `{% from "../../macros.j2" import badge %}{{ badge() }} {% include "partials/footer.html" %}`,
+		})
+		configuration := config.New()
+		configuration.RelativePaths = *relativePaths
+		template, err := exec.NewTemplate("/pages/blog/post.html", configuration, loader, gonja.DefaultEnvironment)
+		if *returnedErr = err; err != nil {
+			return
+		}
+		*returnedResult, *returnedErr = template.ExecuteToString(exec.EmptyContext())
+	})
+	It("should resolve paths starting with a dot relatively to the template and other paths from the root", func() {
+		Expect(*returnedErr).To(BeNil())
+		Expect(*returnedResult).To(Equal("[badge footer]"))
+	})
+	Context("when relative paths are disabled", func() {
+		BeforeEach(func() {
+			*relativePaths = false
+		})
+		It("should resolve the paths of the root template from the root of the loader", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("unknown resolved path: '/parts/meta.html'")))
+		})
+	})
+	It("should tell explicitly relative identifiers apart", func() {
+		Expect(loaders.IsRelative("./header.html")).To(BeTrue())
+		Expect(loaders.IsRelative("../shared/macros.j2")).To(BeTrue())
+		Expect(loaders.IsRelative("partials/header.html")).To(BeFalse())
+		Expect(loaders.IsRelative(".hidden.html")).To(BeFalse())
+	})
+})
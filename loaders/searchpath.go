@@ -0,0 +1,140 @@
+package loaders
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// OriginalPrefix marks the identifiers referencing the template shadowed by the current one in a search path,
+// as in {% extends "!layout.html" %}
+const OriginalPrefix = "!"
+
+// searchPathLoader looks templates up in a list of loaders by decreasing priority
+type searchPathLoader struct {
+	layers []Loader
+	// layer is the index of the layer of the current template
+	layer int
+}
+
+// MustNewSearchPathLoader creates a new search path loader instance
+// and panics if there's any error during instantiation
+func MustNewSearchPathLoader(layers ...Loader) Loader {
+	loader, err := NewSearchPathLoader(layers...)
+	if err != nil {
+		log.Panic(err)
+	}
+	return loader
+}
+
+// NewSearchPathLoader creates a loader looking templates up in the given loaders by decreasing priority, so
+// that the templates of the first ones shadow the templates with the same identifier in the next ones. This
+// lets a theme override some templates of a base theme, like Sphinx or Hugo do.
+//
+// An overriding template can reference the template it shadows by prefixing its identifier with an
+// exclamation mark, as in {% extends "!layout.html" %}, which looks it up in the loaders following the one of
+// the overriding template only. Identifiers are resolved from the root of each loader.
+func NewSearchPathLoader(layers ...Loader) (Loader, error) {
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("at least one loader is required")
+	}
+	return &searchPathLoader{layers: layers}, nil
+}
+
+// Inherit creates a new loader from the current one, in the layer of the template with the given identifier
+func (s *searchPathLoader) Inherit(from string) (Loader, error) {
+	if from == "" {
+		return &searchPathLoader{layers: s.layers, layer: s.layer}, nil
+	}
+	resolved, err := s.Resolve(from)
+	if err != nil {
+		return nil, err
+	}
+	layer, _ := s.split(resolved)
+	return &searchPathLoader{layers: s.layers, layer: layer}, nil
+}
+
+// Read returns an io.Reader where the template's content can be read from
+func (s *searchPathLoader) Read(identifier string) (io.Reader, error) {
+	resolved, err := s.Resolve(identifier)
+	if err != nil {
+		return nil, err
+	}
+	layer, path := s.split(resolved)
+	return s.layers[layer].Read(path)
+}
+
+// Resolve returns the identifier of the template in the first layer holding it. Identifiers resolved in
+// other layers than the first one are prefixed with the index of their layer, as in "1!/layout.html".
+func (s *searchPathLoader) Resolve(identifier string) (string, error) {
+	if layer, path := s.split(identifier); layer > 0 {
+		return identifier, nil
+	} else if original, ok := strings.CutPrefix(identifier, OriginalPrefix); ok {
+		resolved, err := s.search(s.layer+1, original)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve the original of '%s': %w", original, err)
+		}
+		return resolved, nil
+	} else {
+		return s.search(0, path)
+	}
+}
+
+// Stat returns the version given by the layer of the template, if it supports it
+func (s *searchPathLoader) Stat(identifier string) (string, error) {
+	resolved, err := s.Resolve(identifier)
+	if err != nil {
+		return "", err
+	}
+	layer, path := s.split(resolved)
+	if loader, ok := s.layers[layer].(StatLoader); ok {
+		return loader.Stat(path)
+	}
+	return "", nil
+}
+
+// search returns the resolved identifier of the template in the first layer holding it, from the given one
+func (s *searchPathLoader) search(from int, identifier string) (string, error) {
+	for layer := from; layer < len(s.layers); layer++ {
+		path, err := s.layers[layer].Resolve(identifier)
+		if err != nil {
+			continue
+		}
+		if !exists(s.layers[layer], path) {
+			continue
+		}
+		if layer == 0 {
+			return path, nil
+		}
+		return strconv.Itoa(layer) + OriginalPrefix + path, nil
+	}
+	return "", notFound(fmt.Errorf("template '%s' not found in the search path", identifier))
+}
+
+// split returns the layer of a resolved identifier along with its path in that layer
+func (s *searchPathLoader) split(identifier string) (int, string) {
+	prefix, path, found := strings.Cut(identifier, OriginalPrefix)
+	if !found {
+		return 0, identifier
+	}
+	layer, err := strconv.Atoi(prefix)
+	if err != nil || layer <= 0 || layer >= len(s.layers) {
+		return 0, identifier
+	}
+	return layer, path
+}
+
+// exists tells whether a loader holds a template with the given resolved identifier
+func exists(loader Loader, identifier string) bool {
+	if statLoader, ok := loader.(StatLoader); ok {
+		_, err := statLoader.Stat(identifier)
+		return err == nil
+	}
+	reader, err := loader.Read(identifier)
+	if closer, ok := reader.(io.Closer); ok && err == nil {
+		closer.Close()
+	}
+	return err == nil
+}
@@ -0,0 +1,73 @@
+package loaders_test
+
+import (
+	"io"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("search path", func() {
+	var (
+		loader loaders.Loader
+
+		theme = new(loaders.Loader)
+		base  = new(loaders.Loader)
+	)
+	BeforeEach(func() {
+		*theme = loaders.MustNewMemoryLoader(map[string]string{
+			"/layout.html": `{% extends "!layout.html" %}{% block title %}Dark {{ super() }}{% endblock %}`,
+			"/footer.html": `dark footer`,
+		})
+		*base = loaders.MustNewMemoryLoader(map[string]string{
+			"/layout.html": `<title>{% block title %}Base{% endblock %}</title>{% include "footer.html" %}`,
+			"/footer.html": `base footer`,
+			"/index.html":  `{% extends "layout.html" %}{% block title %}Index, {{ super() }}{% endblock %}`,
+		})
+	})
+	JustBeforeEach(func() {
+		loader = loaders.MustNewSearchPathLoader(*theme, *base)
+	})
+	Context("Resolve", func() {
+		It("should resolve identifiers from the first loader holding them", func() {
+			Expect(loader.Resolve("layout.html")).To(Equal("/layout.html"))
+			Expect(loader.Resolve("index.html")).To(Equal("1!/index.html"))
+			Expect(loader.Resolve("1!/index.html")).To(Equal("1!/index.html"))
+		})
+		It("should resolve the original of a template from the next loaders", func() {
+			inherited, err := loader.Inherit("layout.html")
+			Expect(err).To(BeNil())
+			Expect(inherited.Resolve("!layout.html")).To(Equal("1!/layout.html"))
+		})
+		It("should fail when no loader holds the template", func() {
+			_, err := loader.Resolve("unknown.html")
+			Expect(err).To(MatchError("template 'unknown.html' not found in the search path"))
+			inherited, err := loader.Inherit("index.html")
+			Expect(err).To(BeNil())
+			_, err = inherited.Resolve("!layout.html")
+			Expect(err).To(MatchError("failed to resolve the original of 'layout.html': template 'layout.html' not found in the search path"))
+		})
+	})
+	Context("Read", func() {
+		It("should read from the loader holding the template", func() {
+			reader, err := loader.Read("1!/footer.html")
+			Expect(err).To(BeNil())
+			Expect(io.ReadAll(reader)).To(Equal([]byte("base footer")))
+		})
+	})
+	Context("when rendering templates", func() {
+		It("should let the overriding templates extend the ones they shadow", func() {
+			template, err := exec.NewTemplate("index.html", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+			Expect(err).To(BeNil())
+			Expect(template.ExecuteToString(exec.EmptyContext())).To(Equal("<title>Index, Dark Base</title>dark footer"))
+		})
+	})
+	It("should require at least one loader", func() {
+		_, err := loaders.NewSearchPathLoader()
+		Expect(err).To(MatchError("at least one loader is required"))
+	})
+})
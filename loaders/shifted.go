@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"sort"
 )
 
 // shiftedLoader represents a wrapping loader on top of an existing one
@@ -34,7 +35,7 @@ func MustNewShiftedLoader(rootID string, rootContent io.Reader, subLoader Loader
 func NewShiftedLoader(rootID string, rootContent io.Reader, loader Loader) (Loader, error) {
 	content, err := io.ReadAll(rootContent)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read root content: %s", err)
+		return nil, fmt.Errorf("failed to read root content: %w", err)
 	}
 
 	return &shiftedLoader{
@@ -48,7 +49,7 @@ func NewShiftedLoader(rootID string, rootContent io.Reader, loader Loader) (Load
 func (f *shiftedLoader) Inherit(from string) (Loader, error) {
 	loader, err := f.loader.Inherit(from)
 	if err != nil {
-		return nil, fmt.Errorf("failed to inherit file system loader: %s", err)
+		return nil, fmt.Errorf("failed to inherit file system loader: %w", err)
 	}
 	return &shiftedLoader{
 		rootID:      f.rootID,
@@ -61,7 +62,7 @@ func (f *shiftedLoader) Inherit(from string) (Loader, error) {
 func (f *shiftedLoader) Read(identifier string) (io.Reader, error) {
 	resolvedID, err := f.Resolve(identifier)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve '%s': %s", identifier, err)
+		return nil, fmt.Errorf("failed to resolve '%s': %w", identifier, err)
 	}
 	if resolvedID == f.rootID {
 		buffer := bytes.NewBuffer(f.rootContent)
@@ -77,3 +78,50 @@ func (f *shiftedLoader) Resolve(identifier string) (string, error) {
 	}
 	return f.loader.Resolve(identifier)
 }
+
+// Stat returns an empty version for the root template, which never changes, and
+// the version given by the sub-loader otherwise, if it supports it
+func (f *shiftedLoader) Stat(identifier string) (string, error) {
+	resolvedID, err := f.Resolve(identifier)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve '%s': %w", identifier, err)
+	}
+	if resolvedID == f.rootID {
+		return "", nil
+	}
+	if loader, ok := f.loader.(StatLoader); ok {
+		return loader.Stat(identifier)
+	}
+	return "", nil
+}
+
+// Glob returns the paths matching the pattern given by the sub-loader, if it supports it
+func (f *shiftedLoader) Glob(pattern string) ([]string, error) {
+	if loader, ok := f.loader.(GlobLoader); ok {
+		return loader.Glob(pattern)
+	}
+	return nil, ErrGlobNotSupported
+}
+
+// List returns the root identifier along with the identifiers given by the sub-loader, if it supports it
+func (f *shiftedLoader) List() ([]string, error) {
+	loader, ok := f.loader.(ListableLoader)
+	if !ok {
+		return nil, ErrListNotSupported
+	}
+	identifiers, err := loader.List()
+	if err != nil {
+		return nil, err
+	}
+	identifiers = append(identifiers, f.rootID)
+	sort.Strings(identifiers)
+	return identifiers, nil
+}
+
+// Exists tells whether the identifier is the root one or a template of the sub-loader
+func (f *shiftedLoader) Exists(identifier string) (bool, error) {
+	if identifier == f.rootID {
+		return true, nil
+	}
+	return Exists(f.loader, identifier)
+}
@@ -2,6 +2,7 @@ package nodes
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -45,10 +46,55 @@ type ControlStructure interface {
 // Template is the root node of any template
 type Template struct {
 	Identifier string
+	Source     string
 	Nodes      []Node
 	Blocks     BlockSet
 	Macros     map[string]*Macro
 	Parent     *Template
+	// References holds the templates statically referenced by the template,
+	// such as the ones included or imported with a string literal
+	References []*Reference
+	// Spans locates the nodes of the template within its source, including expressions,
+	// control structures and the end tags of the statements wrapping other nodes
+	Spans map[Node]Span
+	// Header is the front-matter of the template as written, which is replaced by a comment of HeaderSize
+	// bytes spanning as many lines at the beginning of Source, see Original
+	Header     string
+	HeaderSize int
+}
+
+// Span locates the source text a node was parsed from
+type Span struct {
+	Start *tokens.Token // first token of the node
+	End   *tokens.Token // last token of the node, such as the closing delimiter of a statement
+}
+
+// Offsets returns the byte offsets of the text of the span within the template source
+func (s Span) Offsets() (int, int) {
+	return s.Start.Pos, s.End.End
+}
+
+// Span returns the span of a node of the template, if known
+func (t *Template) Span(node Node) (Span, bool) {
+	if node == nil || !reflect.TypeOf(node).Comparable() {
+		return Span{}, false
+	}
+	span, ok := t.Spans[node]
+	return span, ok
+}
+
+// Raw returns the source text a node of the template was parsed from, including its delimiters
+// and the whitespace and comments it holds, or an empty string if the node is not located
+func (t *Template) Raw(node Node) string {
+	span, ok := t.Span(node)
+	if !ok {
+		return ""
+	}
+	start, end := span.Offsets()
+	if start < 0 || end > len(t.Source) || start > end {
+		return ""
+	}
+	return t.Source[start:end]
 }
 
 func (t *Template) Position() *tokens.Token { return t.Nodes[0].Position() }
@@ -145,6 +191,11 @@ type FilterCall struct {
 	// filterFunc FilterFunction
 }
 
+func (fc *FilterCall) Position() *tokens.Token { return fc.Token }
+func (fc *FilterCall) String() string {
+	return fmt.Sprintf("filter(%s)", fc.Name)
+}
+
 type TestExpression struct {
 	Expression Expression
 	Test       *TestCall
@@ -167,6 +218,7 @@ type TestCall struct {
 	// testFunc TestFunction
 }
 
+func (tc *TestCall) Position() *tokens.Token { return tc.Token }
 func (tc *TestCall) String() string {
 	return fmt.Sprintf("test(%s)", tc.Name)
 }
@@ -416,6 +468,19 @@ func (w Wrapper) String() string {
 	return fmt.Sprintf("wrapper(%s,%s)", w.Nodes, w.EndTag)
 }
 
+// Reference is a template referenced by name from another one
+type Reference struct {
+	Location *tokens.Token
+	Kind     string // name of the statement referencing the template, such as "include"
+	Name     string
+	Optional bool // whether the statement ignores the template when it is missing, see include ... ignore missing
+}
+
+func (r *Reference) Position() *tokens.Token { return r.Location }
+func (r *Reference) String() string {
+	return fmt.Sprintf("%s(%s)", r.Kind, r.Name)
+}
+
 type Macro struct {
 	Location *tokens.Token
 	Name     string
@@ -0,0 +1,133 @@
+package nodes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Original returns the source of the template as written, including its front-matter
+func (t *Template) Original() string {
+	if t.Header == "" || t.HeaderSize > len(t.Source) {
+		return t.Source
+	}
+	return t.Header + t.Source[t.HeaderSize:]
+}
+
+// Rewriter edits the source of a template node by node, for refactoring tools rewriting parts of
+// templates programmatically. The text which is not edited is re-emitted byte for byte, including
+// comments, whitespace and the front-matter, so that a template without edits is rewritten as written.
+type Rewriter struct {
+	template *Template
+	edits    []rewrite
+}
+
+// rewrite replaces the source between two offsets, and inserts text when both are equal
+type rewrite struct {
+	start int
+	end   int
+	text  string
+}
+
+// NewRewriter creates a rewriter of the given parsed template, whose Source must be set
+func NewRewriter(template *Template) *Rewriter {
+	return &Rewriter{template: template}
+}
+
+// Replace replaces the source text of a node, delimiters included for statements, outputs and comments
+func (r *Rewriter) Replace(node Node, text string) error {
+	start, end, err := r.offsets(node)
+	if err != nil {
+		return err
+	}
+	return r.add(rewrite{start: start, end: end, text: text})
+}
+
+// Remove removes the source text of a node
+func (r *Rewriter) Remove(node Node) error {
+	return r.Replace(node, "")
+}
+
+// InsertBefore inserts text right before the source text of a node
+func (r *Rewriter) InsertBefore(node Node, text string) error {
+	start, _, err := r.offsets(node)
+	if err != nil {
+		return err
+	}
+	return r.add(rewrite{start: start, end: start, text: text})
+}
+
+// InsertAfter inserts text right after the source text of a node
+func (r *Rewriter) InsertAfter(node Node, text string) error {
+	_, end, err := r.offsets(node)
+	if err != nil {
+		return err
+	}
+	return r.add(rewrite{start: end, end: end, text: text})
+}
+
+// String returns the source of the template with the edits applied
+func (r *Rewriter) String() string {
+	source := r.template.Source
+	edits := append([]rewrite{}, r.edits...)
+	if r.template.Header != "" && r.template.HeaderSize <= len(source) {
+		header := rewrite{start: 0, end: r.template.HeaderSize, text: r.template.Header}
+		if r.overlap(header) == nil {
+			edits = append(edits, header)
+		}
+	}
+	// insertions come before the replacement starting at the same offset, in the order they were made
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start < edits[j].start
+		}
+		return edits[i].start == edits[i].end && edits[j].start != edits[j].end
+	})
+	var out strings.Builder
+	offset := 0
+	for _, edit := range edits {
+		out.WriteString(source[offset:edit.start])
+		out.WriteString(edit.text)
+		offset = edit.end
+	}
+	out.WriteString(source[offset:])
+	return out.String()
+}
+
+func (r *Rewriter) offsets(node Node) (int, int, error) {
+	span, ok := r.template.Span(node)
+	if !ok {
+		return 0, 0, fmt.Errorf("unable to locate %s in template '%s'", node, r.template.Identifier)
+	}
+	start, end := span.Offsets()
+	if start < 0 || end > len(r.template.Source) || start > end {
+		return 0, 0, fmt.Errorf("unable to locate %s in template '%s'", node, r.template.Identifier)
+	}
+	return start, end, nil
+}
+
+func (r *Rewriter) add(edit rewrite) error {
+	if other := r.overlap(edit); other != nil {
+		return fmt.Errorf("the edit of bytes %d to %d overlaps the edit of bytes %d to %d of template '%s'",
+			edit.start, edit.end, other.start, other.end, r.template.Identifier)
+	}
+	r.edits = append(r.edits, edit)
+	return nil
+}
+
+// overlap returns the edit overlapping the given one, if any. Insertions only overlap the replacements
+// strictly containing them.
+func (r *Rewriter) overlap(edit rewrite) *rewrite {
+	for index, other := range r.edits {
+		if edit.start < other.end && other.start < edit.end {
+			return &r.edits[index]
+		}
+		if edit.start == edit.end && other.start < edit.start && edit.start < other.end {
+			return &r.edits[index]
+		}
+		if other.start == other.end && edit.start < other.start && other.start < edit.end {
+			return &r.edits[index]
+		}
+	}
+	return nil
+}
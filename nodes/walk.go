@@ -1,7 +1,9 @@
 package nodes
 
 import (
-	"github.com/pkg/errors"
+	"fmt"
+	"reflect"
+	"sort"
 )
 
 type Visitor interface {
@@ -35,7 +37,7 @@ func Walk(v Visitor, node Node) error {
 	// case *Output:
 	// 	return visitor.Output(t)
 	default:
-		return errors.Errorf("Unkown type %T", n)
+		return fmt.Errorf("Unkown type %T", n)
 	}
 	return nil
 }
@@ -57,6 +59,124 @@ func Inspect(node Node, f func(Node) bool) {
 	Walk(Inspector(f), node)
 }
 
+// Parent is implemented by nodes holding other nodes which are not reachable through the node
+// types of this package, such as control structures, so that Children and Traverse can reach them
+type Parent interface {
+	Node
+	Children() []Node
+}
+
+// Children returns the nodes directly held by a node, in the order they appear in the template
+// except for keyword arguments which are sorted by name. Unlike Walk which follows the rendering,
+// it returns the expressions held by outputs and control structures as well.
+func Children(node Node) []Node {
+	children := []Node{}
+	add := func(nodes ...Node) {
+		for _, node := range nodes {
+			if !isNil(node) {
+				children = append(children, node)
+			}
+		}
+	}
+	addExpressions := func(expressions []Expression, kwargs map[string]Expression) {
+		for _, expression := range expressions {
+			add(expression)
+		}
+		names := make([]string, 0, len(kwargs))
+		for name := range kwargs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			add(kwargs[name])
+		}
+	}
+
+	switch n := node.(type) {
+	case *Template:
+		add(n.Nodes...)
+	case *Wrapper:
+		add(n.Nodes...)
+	case *Output:
+		add(n.Expression, n.Condition, n.Alternative)
+	case *FilteredExpression:
+		add(n.Expression)
+		for _, filter := range n.Filters {
+			add(filter)
+		}
+	case *FilterCall:
+		addExpressions(n.Args, n.Kwargs)
+	case *TestExpression:
+		add(n.Expression, n.Test)
+	case *TestCall:
+		addExpressions(n.Args, n.Kwargs)
+	case *List:
+		addExpressions(n.Val, nil)
+	case *Tuple:
+		addExpressions(n.Val, nil)
+	case *Dict:
+		for _, pair := range n.Pairs {
+			add(pair)
+		}
+	case *Pair:
+		add(n.Key, n.Value)
+	case *Variable:
+		for _, part := range n.Parts {
+			addExpressions(part.Args, part.Kwargs)
+		}
+	case *Call:
+		add(n.Func)
+		addExpressions(n.Args, n.Kwargs)
+	case *GetItem:
+		add(n.Node, n.Arg)
+	case *GetSlice:
+		add(n.Node, n.Start, n.End)
+	case *GetAttribute:
+		add(n.Node)
+	case *Negation:
+		add(n.Term)
+	case *UnaryExpression:
+		add(n.Term)
+	case *BinaryExpression:
+		add(n.Left, n.Right)
+	case *ControlStructureBlock:
+		add(n.ControlStructure)
+	case *Macro:
+		for _, pair := range n.Kwargs {
+			add(pair)
+		}
+		add(n.Wrapper)
+	case Parent:
+		add(n.Children()...)
+	}
+	return children
+}
+
+// Traverse calls f for a node and each of its descendants in depth-first order, along with the
+// ancestors of the visited node starting from the given one. The descendants of a node are
+// skipped when f returns false.
+func Traverse(node Node, f func(node Node, ancestors []Node) bool) {
+	var traverse func(node Node, ancestors []Node)
+	traverse = func(node Node, ancestors []Node) {
+		if !f(node, ancestors) {
+			return
+		}
+		ancestors = append(ancestors, node)
+		for _, child := range Children(node) {
+			traverse(child, ancestors[:len(ancestors):len(ancestors)])
+		}
+	}
+	traverse(node, nil)
+}
+
+func isNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+	value := reflect.ValueOf(node)
+	return value.Kind() == reflect.Ptr && value.IsNil()
+}
+
 // type NoOpVisitor struct {}
 
 // func (v *NoOpVisitor) Template(node *Template) error {
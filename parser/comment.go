@@ -9,9 +9,11 @@ import (
 )
 
 func (p *Parser) ParseComment() (*nodes.Comment, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("ParseComment")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("ParseComment")
+	}
 
 	tok := p.Match(tokens.CommentBegin)
 	if tok == nil {
@@ -36,12 +38,15 @@ func (p *Parser) ParseComment() (*nodes.Comment, error) {
 		return nil, p.Error(msg, p.Current())
 	}
 	comment.End = tok
+	p.locate(comment, comment.Start)
 	if data := p.Current(tokens.Data); data != nil {
-		data.Trim = data.Trim || len(comment.End.Val) > 0 && comment.End.Val[0] == '-'
+		data.Trim = data.Trim || p.trimsAfter(comment.End)
 	}
 
-	log.WithFields(log.Fields{
-		"node": comment,
-	}).Trace("ParseComment return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"node": comment,
+		}).Trace("ParseComment return")
+	}
 	return comment, nil
 }
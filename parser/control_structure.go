@@ -5,8 +5,6 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
-	"github.com/pkg/errors"
-
 	"github.com/nikolalohinski/gonja/v2/nodes"
 	"github.com/nikolalohinski/gonja/v2/tokens"
 )
@@ -14,15 +12,18 @@ import (
 type ControlStructureParser func(parser *Parser, args *Parser) (nodes.ControlStructure, error)
 
 func (p *Parser) ParseControlStructureBlock() (*nodes.ControlStructureBlock, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("ParseControlStructureBlock")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("ParseControlStructureBlock")
+	}
 
 	begin := p.Match(tokens.BlockBegin)
 	if begin == nil {
-		return nil, errors.Errorf(`Expected "%s" got "%s"`, p.Config.BlockStartString, p.Current())
+		return nil, p.Error(fmt.Sprintf(`Expected "%s" got "%s"`, p.Config.BlockStartString, p.Current()), nil)
 	}
 
+	p.renameTag()
 	name := p.Match(tokens.Name)
 	if name == nil {
 		return nil, p.Error("Expected a controlStructure name here", p.Current())
@@ -33,42 +34,94 @@ func (p *Parser) ParseControlStructureBlock() (*nodes.ControlStructureBlock, err
 		return nil, p.Error(fmt.Sprintf("ControlStructure '%s' not found (or beginning not provided)", name.Val), name)
 	}
 
-	log.Trace("args")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.Trace("args")
+	}
 	var args []*tokens.Token
 	for p.Current(tokens.BlockEnd) == nil && !p.Stream().End() {
-		log.Trace("for args")
+		if log.IsLevelEnabled(log.TraceLevel) {
+			log.Trace("for args")
+		}
 		args = append(args, p.Next())
 	}
-	log.Trace("loop ended")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.Trace("loop ended")
+	}
 
 	end := p.Match(tokens.BlockEnd)
 	if end == nil {
 		return nil, p.Error(fmt.Sprintf(`Expected end of block "%s"`, p.Config.BlockEndString), p.Current())
 	}
 	if data := p.Current(tokens.Data); data != nil {
-		data.Trim = data.Trim || len(end.Val) > 0 && end.Val[0] == '-'
+		data.Trim = data.Trim || p.trimsAfter(end)
 		data.RemoveFirstLineReturn = p.Config.TrimBlocks && len(end.Val) > 0 && end.Val[0] != '+'
 	}
 
-	log.WithFields(log.Fields{
-		"args": args,
-	}).Trace("Matched end block")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"args": args,
+		}).Trace("Matched end block")
+	}
 
 	stream := tokens.NewStream(args)
-	log.WithFields(log.Fields{
-		"stream": stream,
-	}).Trace("Got stream")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"stream": stream,
+		}).Trace("Got stream")
+	}
 	argParser := NewParser(p.identifier, stream, p.Config, p.Loader, p.controlStructures)
-	log.Trace("argparser")
+	argParser.spans = p.spans
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.Trace("argparser")
+	}
 
 	controlStructure, err := controlStructureParser(p, argParser)
 	if err != nil {
-		return nil, errors.Wrapf(err, `Unable to parse controlStructure "%s"`, name.Val)
+		return nil, fmt.Errorf(`Unable to parse controlStructure "%s": %w`, name.Val, err)
+	}
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.Trace("got controlStructure and return")
 	}
-	log.Trace("got controlStructure and return")
-	return &nodes.ControlStructureBlock{
+	block := &nodes.ControlStructureBlock{
 		Location:         begin,
 		Name:             name.Val,
 		ControlStructure: controlStructure,
-	}, nil
+	}
+	// statements wrapping other nodes span until their end tag, which their parser consumed
+	p.locate(block, begin)
+	p.locate(controlStructure, begin)
+	return block, nil
+}
+
+// aliases returns the rename map of the control structures when they support legacy tag names, so that
+// the aliases of the statements whose body is not lexed are lexed like them
+func (p *Parser) aliases() map[string]string {
+	if aliases, ok := p.controlStructures.(interface{ Aliases() map[string]string }); ok {
+		return aliases.Aliases()
+	}
+	return nil
+}
+
+// renameTag renames the tag name at the current position when it is an alias, see ControlStructureRenamer
+func (p *Parser) renameTag() {
+	name := p.Current(tokens.Name)
+	if name == nil {
+		return
+	}
+	renamer, ok := p.controlStructures.(ControlStructureRenamer)
+	if !ok {
+		return
+	}
+	renamed, deprecated, ok := renamer.Rename(name.Val)
+	if !ok {
+		return
+	}
+	if deprecated {
+		log.WithFields(log.Fields{
+			"template": p.identifier,
+			"line":     name.Line,
+			"col":      name.Col,
+		}).Warnf("'%s' is deprecated, use '%s' instead", name.Val, renamed)
+	}
+	name.Val = renamed
 }
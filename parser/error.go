@@ -1,14 +1,40 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/nikolalohinski/gonja/v2/tokens"
-	"github.com/pkg/errors"
 )
 
+// ErrSyntax is matched with errors.Is by the errors reporting templates with an invalid syntax
+var ErrSyntax = errors.New("syntax error")
+
+// SyntaxError reports an invalid syntax, along with the faulty token when it is known
+type SyntaxError struct {
+	Message string
+	// Identifier is the template holding the invalid syntax
+	Identifier string
+	// Line, Col and Near locate the faulty token, and are zero when it is unknown
+	Line int
+	Col  int
+	Near string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf(`%s (Line: %d Col: %d, near "%s")`, e.Message, e.Line, e.Col, e.Near)
+}
+
+// Is makes syntax errors match ErrSyntax
+func (e *SyntaxError) Is(target error) bool { return target == ErrSyntax }
+
 func (p *Parser) Error(message string, token *tokens.Token) error {
 	if token == nil {
-		return errors.New(message)
+		return &SyntaxError{Message: message, Identifier: p.identifier}
 	}
 
-	return errors.Errorf(`%s (Line: %d Col: %d, near "%s")`, message, token.Line, token.Col, token.Val)
+	return &SyntaxError{Message: message, Identifier: p.identifier, Line: token.Line, Col: token.Col, Near: token.Val}
 }
@@ -10,10 +10,12 @@ import (
 
 // ParseFilterExpression parses an optionnal filter chain for a node
 func (p *Parser) ParseFilterExpression(expr nodes.Expression) (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current":    p.Current(),
-		"expression": expr,
-	}).Trace("ParseFilterExpression")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current":    p.Current(),
+			"expression": expr,
+		}).Trace("ParseFilterExpression")
+	}
 
 	if p.Current(tokens.Pipe) != nil {
 
@@ -29,23 +31,29 @@ func (p *Parser) ParseFilterExpression(expr nodes.Expression) (nodes.Expression,
 
 			filtered.Filters = append(filtered.Filters, filter)
 		}
+		p.locate(filtered, p.startOf(expr))
 		expr = filtered
 	}
 
-	log.WithFields(log.Fields{
-		"expr": expr,
-	}).Trace("ParseFilterExpression return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"expr": expr,
+		}).Trace("ParseFilterExpression return")
+	}
 	return expr, nil
 }
 
 // ParseExpression parses an expression with optional filters
 // Nested expression should call this method
 func (p *Parser) ParseExpression() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("ParseExpression")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("ParseExpression")
+	}
 	var expr nodes.Expression
 
+	start := p.Current()
 	expr, err := p.ParseLogicalExpression()
 	if err != nil {
 		return nil, err
@@ -55,10 +63,13 @@ func (p *Parser) ParseExpression() (nodes.Expression, error) {
 	if err != nil {
 		return nil, err
 	}
+	p.locate(expr, start)
 
-	log.WithFields(log.Fields{
-		"expr": expr,
-	}).Trace("ParseExpression return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"expr": expr,
+		}).Trace("ParseExpression return")
+	}
 	return expr, nil
 }
 
@@ -90,9 +101,11 @@ func (p *Parser) ParseCondition() (nodes.Expression, nodes.Expression, error) {
 }
 
 func (p *Parser) ParseExpressionNode() (nodes.Node, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("ParseExpressionNode")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("ParseExpressionNode")
+	}
 
 	tok := p.Match(tokens.VariableBegin)
 	if tok == nil {
@@ -127,12 +140,15 @@ func (p *Parser) ParseExpressionNode() (nodes.Node, error) {
 		return nil, p.Error(fmt.Sprintf("'%s' expected here", p.Config.VariableEndString), p.Current())
 	}
 	node.End = tok
+	p.locate(node, node.Start)
 	if data := p.Current(tokens.Data); data != nil {
-		data.Trim = data.Trim || len(node.End.Val) > 0 && node.End.Val[0] == '-'
+		data.Trim = data.Trim || p.trimsAfter(node.End)
 	}
 
-	log.WithFields(log.Fields{
-		"node": node,
-	}).Trace("parseExpressionNode return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"node": node,
+		}).Trace("parseExpressionNode return")
+	}
 	return node, nil
 }
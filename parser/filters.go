@@ -44,5 +44,6 @@ func (p *Parser) ParseFilter() (*nodes.FilterCall, error) {
 		}
 	}
 
+	p.locate(filter, identToken)
 	return filter, nil
 }
@@ -20,16 +20,20 @@ func BinOp(token *tokens.Token) *nodes.BinOperator {
 }
 
 func (p *Parser) ParseLogicalExpression() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("ParseLogicalExpression")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("ParseLogicalExpression")
+	}
 	return p.parseOr()
 }
 
 func (p *Parser) parseOr() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("parseOr")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("parseOr")
+	}
 
 	var expr nodes.Expression
 
@@ -44,23 +48,29 @@ func (p *Parser) parseOr() (nodes.Expression, error) {
 		if err != nil {
 			return nil, err
 		}
+		start := p.startOf(expr)
 		expr = &nodes.BinaryExpression{
 			Left:     expr,
 			Right:    right,
 			Operator: op,
 		}
+		p.locate(expr, start)
 	}
 
-	log.WithFields(log.Fields{
-		"expr": expr,
-	}).Trace("parseOr return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"expr": expr,
+		}).Trace("parseOr return")
+	}
 	return expr, nil
 }
 
 func (p *Parser) parseAnd() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("parseAnd")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("parseAnd")
+	}
 
 	var expr nodes.Expression
 
@@ -77,23 +87,29 @@ func (p *Parser) parseAnd() (nodes.Expression, error) {
 			return nil, err
 		}
 
+		start := p.startOf(expr)
 		expr = &nodes.BinaryExpression{
 			Left:     expr,
 			Right:    right,
 			Operator: op,
 		}
+		p.locate(expr, start)
 	}
 
-	log.WithFields(log.Fields{
-		"expr": expr,
-	}).Trace("parseAnd return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"expr": expr,
+		}).Trace("parseAnd return")
+	}
 	return expr, nil
 }
 
 func (p *Parser) parseNot() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("parseNot")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("parseNot")
+	}
 
 	op := p.Match(tokens.Not)
 	expr, err := p.parseCompare()
@@ -106,18 +122,23 @@ func (p *Parser) parseNot() (nodes.Expression, error) {
 			Operator: op,
 			Term:     expr,
 		}
+		p.locate(expr, op)
 	}
 
-	log.WithFields(log.Fields{
-		"expr": expr,
-	}).Trace("parseNot return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"expr": expr,
+		}).Trace("parseNot return")
+	}
 	return expr, nil
 }
 
 func (p *Parser) parseCompare() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("parseCompare")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("parseCompare")
+	}
 
 	var expr nodes.Expression
 
@@ -135,11 +156,13 @@ func (p *Parser) parseCompare() (nodes.Expression, error) {
 		}
 
 		if right != nil {
+			start := p.startOf(expr)
 			expr = &nodes.BinaryExpression{
 				Left:     expr,
 				Operator: BinOp(op),
 				Right:    right,
 			}
+			p.locate(expr, start)
 		}
 	}
 
@@ -148,8 +171,10 @@ func (p *Parser) parseCompare() (nodes.Expression, error) {
 		return nil, err
 	}
 
-	log.WithFields(log.Fields{
-		"expr": expr,
-	}).Trace("parseCompare return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"expr": expr,
+		}).Trace("parseCompare return")
+	}
 	return expr, nil
 }
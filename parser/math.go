@@ -9,9 +9,11 @@ import (
 )
 
 func (p *Parser) ParseMath() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("ParseMath")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("ParseMath")
+	}
 
 	expr, err := p.parseConcat()
 	if err != nil {
@@ -24,23 +26,29 @@ func (p *Parser) ParseMath() (nodes.Expression, error) {
 		if err != nil {
 			return nil, err
 		}
+		start := p.startOf(expr)
 		expr = &nodes.BinaryExpression{
 			Left:     expr,
 			Right:    right,
 			Operator: op,
 		}
+		p.locate(expr, start)
 	}
 
-	log.WithFields(log.Fields{
-		"expr": expr,
-	}).Trace("ParseMath return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"expr": expr,
+		}).Trace("ParseMath return")
+	}
 	return expr, nil
 }
 
 func (p *Parser) parseConcat() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("parseConcat")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("parseConcat")
+	}
 
 	expr, err := p.ParseMathPrioritary()
 	if err != nil {
@@ -53,23 +61,29 @@ func (p *Parser) parseConcat() (nodes.Expression, error) {
 		if err != nil {
 			return nil, err
 		}
+		start := p.startOf(expr)
 		expr = &nodes.BinaryExpression{
 			Left:     expr,
 			Right:    right,
 			Operator: op,
 		}
+		p.locate(expr, start)
 	}
 
-	log.WithFields(log.Fields{
-		"expr": expr,
-	}).Trace("parseConcat return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"expr": expr,
+		}).Trace("parseConcat return")
+	}
 	return expr, nil
 }
 
 func (p *Parser) ParseMathPrioritary() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("ParseMathPrioritary")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("ParseMathPrioritary")
+	}
 
 	expr, err := p.ParsePower()
 	if err != nil {
@@ -82,23 +96,29 @@ func (p *Parser) ParseMathPrioritary() (nodes.Expression, error) {
 		if err != nil {
 			return nil, err
 		}
+		start := p.startOf(expr)
 		expr = &nodes.BinaryExpression{
 			Left:     expr,
 			Right:    right,
 			Operator: op,
 		}
+		p.locate(expr, start)
 	}
 
-	log.WithFields(log.Fields{
-		"expr": expr,
-	}).Trace("ParseMathPrioritary return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"expr": expr,
+		}).Trace("ParseMathPrioritary return")
+	}
 	return expr, nil
 }
 
 func (p *Parser) parseUnary() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("parseUnary")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("parseUnary")
+	}
 
 	sign := p.Match(tokens.Addition, tokens.Subtraction)
 
@@ -113,6 +133,7 @@ func (p *Parser) parseUnary() (nodes.Expression, error) {
 			Negative: sign.Val == "-",
 			Term:     expr,
 		}
+		p.locate(expr, sign)
 	}
 
 	expr, err = p.ParseFilterExpression(expr)
@@ -120,16 +141,20 @@ func (p *Parser) parseUnary() (nodes.Expression, error) {
 		return nil, err
 	}
 
-	log.WithFields(log.Fields{
-		"expr": expr,
-	}).Trace("parseUnary return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"expr": expr,
+		}).Trace("parseUnary return")
+	}
 	return expr, nil
 }
 
 func (p *Parser) ParsePower() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("ParsePower")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("ParsePower")
+	}
 
 	if p.Current(tokens.In) != nil {
 		return nil, nil
@@ -146,16 +171,20 @@ func (p *Parser) ParsePower() (nodes.Expression, error) {
 		if err != nil {
 			return nil, err
 		}
+		start := p.startOf(expr)
 		expr = &nodes.BinaryExpression{
 			Left:     expr,
 			Right:    right,
 			Operator: op,
 		}
+		p.locate(expr, start)
 	}
 
-	log.WithFields(log.Fields{
-		"type": fmt.Sprintf("%T", expr),
-		"expr": expr,
-	}).Trace("ParsePower return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"type": fmt.Sprintf("%T", expr),
+			"expr": expr,
+		}).Trace("ParsePower return")
+	}
 	return expr, nil
 }
@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"io"
+	"reflect"
 	"regexp"
 	"strings"
 
@@ -20,6 +21,13 @@ type ControlStructureGetter interface {
 	Get(name string) (ControlStructureParser, bool)
 }
 
+// ControlStructureRenamer is implemented by the control structure getters supporting legacy tag names,
+// which are renamed to the name they stand for before being parsed
+type ControlStructureRenamer interface {
+	// Rename returns the name an alias stands for, whether the alias is deprecated and whether it exists
+	Rename(alias string) (string, bool, bool)
+}
+
 // The parser provides you a comprehensive and easy tool to
 // work with the template document and arguments provided by
 // the user for your custom tag.
@@ -37,6 +45,16 @@ type Parser struct {
 	Config   *config.Config
 	Template *nodes.Template
 	Loader   loaders.Loader
+
+	// spans is shared with the parsers of control structure arguments and bodies, see nodes.Template.Spans
+	spans map[nodes.Node]nodes.Span
+
+	// extended holds the resolved identifiers of the templates extended by the template being parsed,
+	// from the first child to the direct child of the template being parsed, to detect extends cycles
+	extended []string
+
+	// loops is the number of loops holding the statements being parsed, see EnterLoop
+	loops int
 }
 
 func (p *Parser) Stream() *tokens.Stream {
@@ -56,6 +74,26 @@ func NewParser(identifier string, stream *tokens.Stream, cfg *config.Config, loa
 	}
 }
 
+// EnterLoop marks the statements parsed until the returned function is called as held by a loop, which
+// statements such as break and continue require, see InLoop
+func (p *Parser) EnterLoop() func() {
+	p.loops++
+	return func() { p.loops-- }
+}
+
+// LeaveLoops marks the statements parsed until the returned function is called as held by no loop, for
+// bodies rendered apart from the statements holding them such as the ones of macros and blocks
+func (p *Parser) LeaveLoops() func() {
+	loops := p.loops
+	p.loops = 0
+	return func() { p.loops = loops }
+}
+
+// InLoop tells whether the statement being parsed is held by a loop, see EnterLoop
+func (p *Parser) InLoop() bool {
+	return p.loops > 0
+}
+
 // Consume one token. It will be gone forever.
 func (p *Parser) Consume() {
 	p.stream.Next()
@@ -142,6 +180,35 @@ func (p *Parser) CurrentName(names ...string) *tokens.Token {
 	return nil
 }
 
+// locate records the span of a node, from the given token to the last consumed one. Nodes being located
+// again, such as an expression when parsing the parentheses grouping it, get their widest span.
+func (p *Parser) locate(node nodes.Node, start *tokens.Token) {
+	end := p.stream.Previous()
+	if p.spans == nil || isNilNode(node) || start == nil || end == nil || !reflect.TypeOf(node).Comparable() {
+		return
+	}
+	p.spans[node] = nodes.Span{Start: start, End: end}
+}
+
+// startOf returns the first token of a node already parsed
+func (p *Parser) startOf(node nodes.Node) *tokens.Token {
+	if isNilNode(node) {
+		return nil
+	}
+	if span, ok := p.spans[node]; ok {
+		return span.Start
+	}
+	return node.Position()
+}
+
+func isNilNode(node nodes.Node) bool {
+	if node == nil {
+		return true
+	}
+	value := reflect.ValueOf(node)
+	return value.Kind() == reflect.Ptr && value.IsNil()
+}
+
 // WrapUntil wraps all nodes between starting tag and "{% endtag %}" and provides
 // one simple interface to execute the wrapped nodes.
 // It returns a parser to process provided arguments to the tag.
@@ -156,6 +223,7 @@ func (p *Parser) WrapUntil(names ...string) (*nodes.Wrapper, *Parser, error) {
 	for !p.stream.End() {
 		// New tag, check whether we have to stop wrapping here
 		if begin := p.Match(tokens.BlockBegin); begin != nil {
+			p.renameTag()
 			endTag := p.CurrentName(names...)
 
 			if endTag != nil {
@@ -164,10 +232,13 @@ func (p *Parser) WrapUntil(names ...string) (*nodes.Wrapper, *Parser, error) {
 					if end := p.Match(tokens.BlockEnd); end != nil {
 						wrapper.EndTag = endTag.Val
 						if data := p.Current(tokens.Data); data != nil {
-							data.Trim = data.Trim || len(end.Val) > 0 && end.Val[0] == '-'
+							data.Trim = data.Trim || p.trimsAfter(end)
 						}
+						p.locate(wrapper, wrapper.Location)
 						stream := tokens.NewStream(args)
-						return wrapper, NewParser(p.identifier, stream, p.Config, p.Loader, p.controlStructures), nil
+						endArgs := NewParser(p.identifier, stream, p.Config, p.Loader, p.controlStructures)
+						endArgs.spans = p.spans
+						return wrapper, endArgs, nil
 					}
 					if p.End() || p.Current(tokens.EOF) != nil {
 						return nil, nil, p.Error("Unexpected EOF.", p.Current())
@@ -190,6 +261,48 @@ func (p *Parser) WrapUntil(names ...string) (*nodes.Wrapper, *Parser, error) {
 		p.Current())
 }
 
+// trimsBefore tells whether the whitespace before a tag is removed given its opening token, which is the case
+// with a '-' marker, or without marker when the configuration trims this kind of tags by default
+func (p *Parser) trimsBefore(begin *tokens.Token) bool {
+	if len(begin.Val) == 0 {
+		return p.trimsByDefault(begin)
+	}
+	return p.trims(begin, begin.Val[len(begin.Val)-1])
+}
+
+// trimsAfter tells whether the whitespace after a tag is removed given its closing token, see trimsBefore
+func (p *Parser) trimsAfter(end *tokens.Token) bool {
+	if len(end.Val) == 0 {
+		return p.trimsByDefault(end)
+	}
+	return p.trims(end, end.Val[0])
+}
+
+func (p *Parser) trims(tag *tokens.Token, marker byte) bool {
+	switch marker {
+	case '-':
+		return true
+	case '+':
+		return false
+	default:
+		return p.trimsByDefault(tag)
+	}
+}
+
+// trimsByDefault returns the trimming policy of the configuration for the kind of the tag
+func (p *Parser) trimsByDefault(tag *tokens.Token) bool {
+	switch tag.Type {
+	case tokens.BlockBegin, tokens.BlockEnd:
+		return p.Config.TrimStatements
+	case tokens.VariableBegin, tokens.VariableEnd:
+		return p.Config.TrimOutputs
+	case tokens.CommentBegin, tokens.CommentEnd:
+		return p.Config.TrimComments
+	default:
+		return false
+	}
+}
+
 func (p *Parser) parseDocElement() (nodes.Node, error) {
 	t := p.Current()
 	switch t.Type {
@@ -202,9 +315,7 @@ func (p *Parser) parseDocElement() (nodes.Node, error) {
 			},
 		}
 		if next := p.Peek(tokens.VariableBegin, tokens.CommentBegin, tokens.BlockBegin); next != nil {
-			if len(next.Val) > 0 && next.Val[len(next.Val)-1] == '-' {
-				n.Trim.Right = true
-			}
+			n.Trim.Right = p.trimsBefore(next)
 		}
 		if p.Config.LeftStripBlocks {
 			if next := p.Peek(tokens.BlockBegin); next != nil {
@@ -214,6 +325,7 @@ func (p *Parser) parseDocElement() (nodes.Node, error) {
 			}
 		}
 		p.Consume()
+		p.locate(n, t)
 		return n, nil
 	case tokens.EOF:
 		p.Consume()
@@ -229,7 +341,8 @@ func (p *Parser) parseDocElement() (nodes.Node, error) {
 		}
 		if p.Config.TrimBlocks && !p.End() && p.Peek(tokens.BlockBegin) != nil {
 			if data := p.Current(tokens.Data); data != nil && lineReturnWithOnlyWhiteSpace.MatchString(data.Val) {
-				p.Consume() // Consume whitespace
+				// the whitespace is kept in the tree but never rendered
+				data.Trim = true
 			}
 		}
 		return node, err
@@ -242,8 +355,10 @@ func (p *Parser) Parse() (*nodes.Template, error) {
 		Identifier: p.identifier,
 		Blocks:     nodes.BlockSet{},
 		Macros:     map[string]*nodes.Macro{},
+		Spans:      map[nodes.Node]nodes.Span{},
 	}
 	p.Template = tpl
+	p.spans = tpl.Spans
 
 	for !p.Stream().End() {
 		node, err := p.parseDocElement()
@@ -260,32 +375,56 @@ func (p *Parser) Parse() (*nodes.Template, error) {
 func (p *Parser) Extend(identifier string) (*nodes.Template, error) {
 	input, err := p.Loader.Read(identifier)
 	if err != nil {
-		return nil, fmt.Errorf("failed to reader template '%s': %s", identifier, err)
+		return nil, fmt.Errorf("failed to reader template '%s': %w", identifier, err)
 	}
 
 	identifier, err = p.Loader.Resolve(identifier)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve identifier '%s': %s", identifier, err)
+		return nil, fmt.Errorf("failed to resolve identifier '%s': %w", identifier, err)
+	}
+
+	current := p.identifier
+	if len(p.extended) == 0 {
+		if resolved, err := p.Loader.Resolve(current); err == nil {
+			current = resolved
+		}
+	}
+	chain := append(append([]string{}, p.extended...), current)
+	for index, extended := range chain {
+		if extended == identifier {
+			return nil, fmt.Errorf("extends cycle detected: %s -> %s", strings.Join(chain[index:], " -> "), identifier)
+		}
 	}
 
 	source := new(strings.Builder)
 	if _, err := io.Copy(source, input); err != nil {
-		return nil, fmt.Errorf("failed to copy '%s' to string buffer: %s", source, err)
+		return nil, fmt.Errorf("failed to copy '%s' to string buffer: %w", source, err)
 	}
 
 	loader, err := p.Loader.Inherit(identifier)
 	if err != nil {
-		return nil, fmt.Errorf("failed to inherit loader: %s", err)
+		return nil, fmt.Errorf("failed to inherit loader: %w", err)
 	}
 
-	config := p.Config.Inherit()
+	frontMatter, err := p.Config.ParseFrontMatter(source.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template '%s': %w", identifier, err)
+	}
+	config := frontMatter.Config
 
 	parser := &Parser{
 		identifier:        identifier,
-		stream:            tokens.Lex(source.String(), config),
+		stream:            tokens.LexWithAliases(frontMatter.Source, config, p.aliases()),
 		controlStructures: p.controlStructures,
 		Config:            config,
 		Loader:            loader,
+		extended:          chain,
+	}
+	template, err := parser.Parse()
+	if err != nil {
+		return nil, err
 	}
-	return parser.Parse()
+	template.Source = frontMatter.Source
+	template.Header, template.HeaderSize = frontMatter.Header, frontMatter.HeaderSize
+	return template, nil
 }
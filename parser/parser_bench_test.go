@@ -0,0 +1,25 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nikolalohinski/gonja/v2/builtins"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/parser"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+)
+
+func BenchmarkParse(b *testing.B) {
+	input := strings.Repeat("<li class=\"item\">{{ user.name | upper }} - {{ 'label' ~ user.id }}</li>\n{% if user.age >= 18 and user.active %}adult{% endif %}\n", 200)
+	cfg := config.New()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stream := tokens.Lex(input, cfg)
+		p := parser.NewParser("bench", stream, cfg, nil, builtins.ControlStructures)
+		if _, err := p.Parse(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
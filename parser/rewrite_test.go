@@ -0,0 +1,74 @@
+package parser_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("rewriter", func() {
+	var (
+		input         = new(string)
+		configuration = new(*config.Config)
+
+		template = new(*nodes.Template)
+		rewriter = new(*nodes.Rewriter)
+	)
+	// find returns the first node matching in depth-first order
+	find := func(match func(nodes.Node) bool) nodes.Node {
+		var found nodes.Node
+		nodes.Traverse(*template, func(node nodes.Node, _ []nodes.Node) bool {
+			if found == nil && match(node) {
+				found = node
+			}
+			return found == nil
+		})
+		return found
+	}
+	BeforeEach(func() {
+		*configuration = config.New()
+		*input = "---\ntitle: Home\n---\n{# greeting #}\nHello {{ name | upper }} !\r\n\t{%- if admin %}  admin {% endif -%}\n\n"
+		(*configuration).FrontMatter = true
+		(*configuration).TrimTrailingNewline = true
+	})
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/template": *input})
+		parsed, err := exec.NewTemplate("/template", *configuration, loader, gonja.DefaultEnvironment)
+		Expect(err).To(BeNil())
+		*template = parsed.Root()
+		*rewriter = nodes.NewRewriter(*template)
+	})
+	It("should re-emit the source byte for byte when nothing is edited", func() {
+		Expect((*template).Original()).To(Equal(*input))
+		Expect((*rewriter).String()).To(Equal(*input))
+	})
+	It("should rewrite the edited nodes only", func() {
+		name := find(func(node nodes.Node) bool { n, ok := node.(*nodes.Name); return ok && n.Name.Val == "name" })
+		Expect((*rewriter).Replace(name, "user.name")).To(Succeed())
+		comment := find(func(node nodes.Node) bool { c, ok := node.(*nodes.Comment); return ok && c.Text == " greeting " })
+		Expect((*rewriter).Remove(comment)).To(Succeed())
+		statement := find(func(node nodes.Node) bool { _, ok := node.(*nodes.ControlStructureBlock); return ok })
+		Expect((*rewriter).InsertBefore(statement, "{# admins only #}")).To(Succeed())
+		Expect((*rewriter).InsertAfter(statement, "!")).To(Succeed())
+		Expect((*rewriter).String()).To(Equal("---\ntitle: Home\n---\n\nHello {{ user.name | upper }} !\r\n\t{# admins only #}{%- if admin %}  admin {% endif -%}!\n\n"))
+	})
+	It("should reject overlapping edits", func() {
+		output := find(func(node nodes.Node) bool { _, ok := node.(*nodes.Output); return ok })
+		name := find(func(node nodes.Node) bool { _, ok := node.(*nodes.Name); return ok })
+		Expect((*rewriter).Replace(output, "{{ name }}")).To(Succeed())
+		Expect((*rewriter).Replace(name, "other")).To(MatchError(ContainSubstring("overlaps the edit of bytes")))
+		Expect((*rewriter).InsertBefore(name, "other")).To(MatchError(ContainSubstring("overlaps the edit of bytes")))
+	})
+	Context("when the front-matter is edited", func() {
+		It("should replace it", func() {
+			header := (*template).Nodes[0]
+			Expect((*rewriter).Replace(header, "{# gonja: autoescape=true #}\n")).To(Succeed())
+			Expect((*rewriter).String()).To(HavePrefix("{# gonja: autoescape=true #}\n{# greeting #}"))
+		})
+	})
+})
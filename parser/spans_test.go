@@ -0,0 +1,114 @@
+package parser_test
+
+import (
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2/builtins"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/parser"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("spans", func() {
+	var (
+		input         = new(string)
+		configuration = new(*config.Config)
+
+		returnedTemplate = new(*nodes.Template)
+	)
+	// raws returns the source text of the nodes of the given type, in depth-first order
+	raws := func(match func(nodes.Node) bool) []string {
+		texts := []string{}
+		nodes.Traverse(*returnedTemplate, func(node nodes.Node, _ []nodes.Node) bool {
+			if match(node) {
+				texts = append(texts, (*returnedTemplate).Raw(node))
+			}
+			return true
+		})
+		return texts
+	}
+	BeforeEach(func() {
+		*configuration = config.New()
+	})
+	JustBeforeEach(func() {
+		stream := tokens.Lex(*input, *configuration)
+		template, err := parser.NewParser("tests", stream, *configuration, loaders.MustNewFileSystemLoader(""), builtins.ControlStructures).Parse()
+		Expect(err).To(BeNil())
+		template.Source = *input
+		*returnedTemplate = template
+	})
+	Context("when parsing a template", func() {
+		BeforeEach(func() {
+			*input = "Hello {# greeting #}\n{{- ( name ~ '!' ) | upper if name else 'nobody' }}\n{% if user.is_admin() and not banned %}\n  admin {{ 'a\\'b' }}\n{% else %}\n  user\n{%- endif %}"
+		})
+		It("should locate the nodes of the template exactly", func() {
+			By("reconstructing the source from the top level nodes")
+			parts := []string{}
+			for _, node := range (*returnedTemplate).Nodes {
+				parts = append(parts, (*returnedTemplate).Raw(node))
+			}
+			Expect(strings.Join(parts, "")).To(Equal(*input))
+
+			By("locating comments and outputs with their delimiters")
+			Expect(raws(func(node nodes.Node) bool { _, ok := node.(*nodes.Comment); return ok })).To(Equal([]string{"{# greeting #}"}))
+			Expect(raws(func(node nodes.Node) bool { _, ok := node.(*nodes.Output); return ok })).To(Equal([]string{
+				"{{- ( name ~ '!' ) | upper if name else 'nobody' }}",
+				"{{ 'a\\'b' }}",
+			}))
+
+			By("locating expressions with the parentheses grouping them")
+			Expect(raws(func(node nodes.Node) bool { _, ok := node.(*nodes.FilteredExpression); return ok })).To(Equal([]string{"( name ~ '!' ) | upper"}))
+			Expect(raws(func(node nodes.Node) bool { _, ok := node.(*nodes.BinaryExpression); return ok })).To(Equal([]string{
+				"( name ~ '!' )",
+				"user.is_admin() and not banned",
+			}))
+			Expect(raws(func(node nodes.Node) bool { _, ok := node.(*nodes.Call); return ok })).To(Equal([]string{"user.is_admin()"}))
+			Expect(raws(func(node nodes.Node) bool { _, ok := node.(*nodes.Negation); return ok })).To(Equal([]string{"not banned"}))
+			Expect(raws(func(node nodes.Node) bool { _, ok := node.(*nodes.String); return ok })).To(Equal([]string{"'!'", "'nobody'", "'a\\'b'"}))
+
+			By("locating statements until their end tag")
+			Expect(raws(func(node nodes.Node) bool { _, ok := node.(*nodes.ControlStructureBlock); return ok })).To(Equal([]string{
+				"{% if user.is_admin() and not banned %}\n  admin {{ 'a\\'b' }}\n{% else %}\n  user\n{%- endif %}",
+			}))
+			Expect(raws(func(node nodes.Node) bool { _, ok := node.(*nodes.Wrapper); return ok })).To(Equal([]string{
+				"\n  admin {{ 'a\\'b' }}\n{% else %}",
+				"\n  user\n{%- endif %}",
+			}))
+		})
+		It("should expose end positions", func() {
+			span, ok := (*returnedTemplate).Span((*returnedTemplate).Nodes[len((*returnedTemplate).Nodes)-1])
+			Expect(ok).To(BeTrue())
+			Expect(span.Start.Line).To(Equal(3))
+			Expect(span.End.Line).To(Equal(7))
+			Expect(span.End.Val).To(Equal("%}"))
+			start, end := span.Offsets()
+			Expect(end).To(Equal(len(*input)))
+			Expect((*input)[start:end]).To(HavePrefix("{% if"))
+		})
+	})
+	Context("when blocks are trimmed", func() {
+		BeforeEach(func() {
+			(*configuration).TrimBlocks = true
+			*input = "{% if true %}\n{% endif %}\n{% if false %}{% endif %}"
+		})
+		It("should keep the whitespace between blocks in the tree", func() {
+			parts := []string{}
+			for _, node := range (*returnedTemplate).Nodes {
+				parts = append(parts, (*returnedTemplate).Raw(node))
+			}
+			Expect(strings.Join(parts, "")).To(Equal(*input))
+		})
+	})
+	Context("when a node is not part of the template", func() {
+		It("should not locate it", func() {
+			_, ok := (*returnedTemplate).Span(&nodes.Name{})
+			Expect(ok).To(BeFalse())
+			Expect((*returnedTemplate).Raw(&nodes.Name{})).To(BeEmpty())
+		})
+	})
+})
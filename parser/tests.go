@@ -8,9 +8,11 @@ import (
 )
 
 func (p *Parser) ParseTest(expr nodes.Expression) (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("parseTest")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("parseTest")
+	}
 
 	expr, err := p.ParseFilterExpression(expr)
 	if err != nil {
@@ -36,28 +38,55 @@ func (p *Parser) ParseTest(expr nodes.Expression) (nodes.Expression, error) {
 			Args:   []nodes.Expression{},
 			Kwargs: map[string]nodes.Expression{},
 		}
-		// avoid trying to parse "else" as test arguments
-		if p.CurrentName("else") == nil {
+		// operators such as `in` take a single argument, which may be a tuple literal as in `is in (1, 2)`
+		if ident.Type == tokens.Name && p.Match(tokens.LeftParenthesis) != nil {
+			for p.Match(tokens.RightParenthesis) == nil {
+				v, err := p.ParseExpression()
+				if err != nil {
+					return nil, err
+				}
+				if p.Match(tokens.Assign) != nil {
+					key := v.Position().Val
+					value, errValue := p.ParseExpression()
+					if errValue != nil {
+						return nil, errValue
+					}
+					test.Kwargs[key] = value
+				} else {
+					test.Args = append(test.Args, v)
+				}
+				if p.Current(tokens.RightParenthesis) == nil && p.Match(tokens.Comma) == nil {
+					return nil, p.Error("',' or ')' expected in test arguments", p.Current())
+				}
+			}
+		} else if p.CurrentName("else") == nil {
+			// avoid trying to parse "else" as test arguments
 			arg, err := p.ParseVariableOrLiteral()
 			if err == nil && arg != nil {
 				test.Args = append(test.Args, arg)
 			}
 		}
+		p.locate(test, ident)
+		start := p.startOf(expr)
 		expr = &nodes.TestExpression{
 			Expression: expr,
 			Test:       test,
 		}
+		p.locate(expr, start)
 
 		if not != nil {
 			expr = &nodes.Negation{
 				Term:     expr,
 				Operator: not,
 			}
+			p.locate(expr, start)
 		}
 	}
 
-	log.WithFields(log.Fields{
-		"expr": expr,
-	}).Trace("parseTest return")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"expr": expr,
+		}).Trace("parseTest return")
+	}
 	return expr, nil
 }
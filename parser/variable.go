@@ -12,9 +12,11 @@ import (
 )
 
 func (p *Parser) parseNumber() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("parseNumber")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("parseNumber")
+	}
 	t := p.Match(tokens.Integer, tokens.Float)
 	if t == nil {
 		return nil, p.Error("Expected a number", t)
@@ -44,9 +46,11 @@ func (p *Parser) parseNumber() (nodes.Expression, error) {
 }
 
 func (p *Parser) parseString() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("parseString")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("parseString")
+	}
 	t := p.Match(tokens.String)
 	if t == nil {
 		return nil, p.Error("Expected a string", t)
@@ -78,9 +82,11 @@ func (p *Parser) parseCollectionOrExpression() (nodes.Expression, error) {
 }
 
 func (p *Parser) parseList() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("parseList")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("parseList")
+	}
 	t := p.Match(tokens.LeftBracket)
 	if t == nil {
 		return nil, p.Error("Expected [", t)
@@ -120,9 +126,11 @@ func (p *Parser) parseList() (nodes.Expression, error) {
 }
 
 func (p *Parser) parseTupleOrExpression() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("parseTuple")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("parseTuple")
+	}
 	t := p.Match(tokens.LeftParenthesis)
 	if t == nil {
 		return nil, p.Error("Expected (", t)
@@ -169,9 +177,11 @@ func (p *Parser) parseTupleOrExpression() (nodes.Expression, error) {
 }
 
 func (p *Parser) parsePair() (*nodes.Pair, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("parsePair")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("parsePair")
+	}
 	key, err := p.ParseExpression()
 	if err != nil {
 		return nil, err
@@ -184,16 +194,20 @@ func (p *Parser) parsePair() (*nodes.Pair, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &nodes.Pair{
+	pair := &nodes.Pair{
 		Key:   key,
 		Value: value,
-	}, nil
+	}
+	p.locate(pair, p.startOf(key))
+	return pair, nil
 }
 
 func (p *Parser) parseDict() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("parseDict")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("parseDict")
+	}
 	t := p.Match(tokens.LeftBrace)
 	if t == nil {
 		return nil, p.Error("Expected {", t)
@@ -228,9 +242,11 @@ func (p *Parser) parseDict() (nodes.Expression, error) {
 }
 
 func (p *Parser) ParseVariable() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("ParseVariable")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("ParseVariable")
+	}
 
 	t := p.Match(tokens.Name)
 	if t == nil {
@@ -249,6 +265,12 @@ func (p *Parser) ParseVariable() (nodes.Expression, error) {
 			Location: t,
 		}
 		return br, nil
+	case "none":
+		// the lowercase literal of python is only reserved by the Jinja2 compatibility configuration, so that
+		// variables named none keep working otherwise
+		if p.Config.PythonNone {
+			return &nodes.None{Location: t}, nil
+		}
 	case "false", "False":
 		br := &nodes.Bool{
 			Location: t,
@@ -326,9 +348,11 @@ func (p *Parser) ParseGetter(accessor *tokens.Token, from nodes.Expression) (nod
 
 // IDENT | IDENT.(IDENT|NUMBER)...
 func (p *Parser) ParseVariableOrLiteral() (nodes.Expression, error) {
-	log.WithFields(log.Fields{
-		"current": p.Current(),
-	}).Trace("ParseVariableOrLiteral")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"current": p.Current(),
+		}).Trace("ParseVariableOrLiteral")
+	}
 	t := p.Current()
 
 	if t == nil {
@@ -351,6 +375,7 @@ func (p *Parser) ParseVariableOrLiteral() (nodes.Expression, error) {
 	if err != nil {
 		return nil, err
 	}
+	p.locate(ident, t)
 
 	var parent nodes.Node
 	for !p.Stream().EOF() {
@@ -361,6 +386,7 @@ func (p *Parser) ParseVariableOrLiteral() (nodes.Expression, error) {
 			}
 			parent = ident
 			ident = getter
+			p.locate(ident, t)
 			continue
 		} else if leftParenthesis := p.Match(tokens.LeftParenthesis); leftParenthesis != nil {
 			call := &nodes.Call{
@@ -389,6 +415,7 @@ func (p *Parser) ParseVariableOrLiteral() (nodes.Expression, error) {
 				}
 			}
 			ident = call
+			p.locate(ident, t)
 			continue
 		}
 		break
@@ -0,0 +1,129 @@
+package schema
+
+import (
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2/lint"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+)
+
+// filterInputs lists the kinds of values supported by built-in filters which do not accept anything
+var filterInputs = map[string][]Kind{
+	"capitalize":     {String},
+	"center":         {String},
+	"indent":         {String},
+	"lower":          {String},
+	"replace":        {String},
+	"striptags":      {String},
+	"title":          {String},
+	"trim":           {String},
+	"truncate":       {String},
+	"upper":          {String},
+	"urlize":         {String},
+	"wordcount":      {String},
+	"wordwrap":       {String},
+	"format":         {String},
+	"abs":            {Number},
+	"round":          {Number},
+	"filesizeformat": {Number},
+	"batch":          {Array},
+	"groupby":        {Array},
+	"join":           {Array},
+	"map":            {Array},
+	"reject":         {Array},
+	"rejectattr":     {Array},
+	"select":         {Array},
+	"selectattr":     {Array},
+	"slice":          {Array},
+	"sum":            {Array},
+	"unique":         {Array},
+	"first":          {Array, String},
+	"last":           {Array, String},
+	"max":            {Array, String},
+	"min":            {Array, String},
+	"random":         {Array, String},
+	"reverse":        {Array, String},
+	"sort":           {Array, Object},
+	"length":         {Array, String, Object},
+	"list":           {Array, String, Object},
+	"dictsort":       {Object},
+	"xmlattr":        {Object},
+}
+
+// filterOutputs gives the kind of the values returned by built-in filters whose result does not depend
+// on their input
+var filterOutputs = map[string]Kind{
+	"capitalize":     String,
+	"center":         String,
+	"e":              String,
+	"escape":         String,
+	"filesizeformat": String,
+	"format":         String,
+	"indent":         String,
+	"join":           String,
+	"lower":          String,
+	"nl2br":          String,
+	"replace":        String,
+	"string":         String,
+	"striptags":      String,
+	"title":          String,
+	"tojson":         String,
+	"trim":           String,
+	"truncate":       String,
+	"upper":          String,
+	"urlencode":      String,
+	"urlize":         String,
+	"wordwrap":       String,
+	"int":            Integer,
+	"length":         Integer,
+	"wordcount":      Integer,
+	"float":          Number,
+	"round":          Number,
+	"batch":          Array,
+	"dictsort":       Array,
+	"groupby":        Array,
+	"list":           Array,
+	"map":            Array,
+}
+
+// filter reports the filters given values they do not support, and returns the schema of their result
+func (v *validator) filter(filter *nodes.FilterCall, input *Schema) *Schema {
+	if input != nil && input.Kind != Any {
+		if accepted, ok := filterInputs[filter.Name]; ok && !supports(accepted, input.Kind) {
+			names := make([]string, 0, len(accepted))
+			for _, kind := range accepted {
+				names = append(names, kind.String())
+			}
+			v.pass.Report(filter, lint.SeverityError, "filter '%s' expects %s but is given %s", filter.Name, article(strings.Join(names, " or ")), article(input.Kind.String()))
+		}
+	}
+	if kind, ok := filterOutputs[filter.Name]; ok {
+		return &Schema{Kind: kind}
+	}
+	switch filter.Name {
+	case "first", "last", "random":
+		if input != nil && input.Kind == String {
+			return input
+		}
+		return itemsOf(input)
+	case "abs", "reverse", "sort", "unique", "reject", "rejectattr", "select", "selectattr":
+		return input
+	}
+	return nil
+}
+
+func supports(accepted []Kind, kind Kind) bool {
+	for _, candidate := range accepted {
+		if candidate == kind || candidate == Number && kind == Integer {
+			return true
+		}
+	}
+	return false
+}
+
+func article(noun string) string {
+	if strings.ContainsAny(noun[:1], "aeiou") {
+		return "an " + noun
+	}
+	return "a " + noun
+}
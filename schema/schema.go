@@ -0,0 +1,344 @@
+// Package schema validates templates against the shape of the data they are rendered with, described by a
+// JSON Schema or a Go struct type, so that changes of API payloads breaking templates are caught before rendering.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Kind is the type of the values described by a schema
+type Kind int
+
+const (
+	Any Kind = iota
+	Null
+	Boolean
+	Integer
+	Number
+	String
+	Array
+	Object
+)
+
+var kindNames = map[Kind]string{
+	Any:     "any",
+	Null:    "null",
+	Boolean: "boolean",
+	Integer: "integer",
+	Number:  "number",
+	String:  "string",
+	Array:   "array",
+	Object:  "object",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("kind(%d)", int(k))
+}
+
+// Schema describes the values a template is rendered with
+type Schema struct {
+	Kind Kind
+	// Properties describes the attributes of objects
+	Properties map[string]*Schema
+	// Additional describes the attributes of objects which are not listed in Properties,
+	// which are not allowed when nil
+	Additional *Schema
+	// Items describes the items of arrays, which can be anything when nil
+	Items *Schema
+}
+
+// property returns the schema of an attribute of an object, and whether the attribute is allowed
+func (s *Schema) property(name string) (*Schema, bool) {
+	if property, ok := s.Properties[name]; ok {
+		return property, true
+	}
+	return s.Additional, s.Additional != nil
+}
+
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 json.RawMessage        `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Items                json.RawMessage        `json:"items"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+	Definitions          map[string]*jsonSchema `json:"definitions"`
+	Defs                 map[string]*jsonSchema `json:"$defs"`
+}
+
+var jsonKinds = map[string]Kind{
+	"null":    Null,
+	"boolean": Boolean,
+	"integer": Integer,
+	"number":  Number,
+	"string":  String,
+	"array":   Array,
+	"object":  Object,
+}
+
+// FromJSONSchema builds a schema out of a JSON Schema document.
+//
+// Only the keywords describing the shape of the data are used: type, properties, additionalProperties,
+// items and references to definitions of the same document. Unlike JSON Schema, objects listing their
+// properties do not allow additional ones unless additionalProperties says otherwise, so that references
+// to attributes missing from the schema can be reported. Types combining several kinds other than null,
+// or described by other keywords such as anyOf, are not checked.
+func FromJSONSchema(data []byte) (*Schema, error) {
+	root := &jsonSchema{}
+	if err := json.Unmarshal(data, root); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON schema: %w", err)
+	}
+	converter := &jsonConverter{root: root, refs: map[string]*Schema{}}
+	return converter.convert(root)
+}
+
+type jsonConverter struct {
+	root *jsonSchema
+	refs map[string]*Schema
+}
+
+func (c *jsonConverter) convert(s *jsonSchema) (*Schema, error) {
+	if s.Ref != "" {
+		return c.resolve(s.Ref)
+	}
+	result := &Schema{}
+	if err := c.convertInto(s, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *jsonConverter) convertInto(s *jsonSchema, result *Schema) error {
+	kind, err := jsonKind(s)
+	if err != nil {
+		return err
+	}
+	result.Kind = kind
+	switch kind {
+	case Object:
+		if len(s.Properties) == 0 && len(s.AdditionalProperties) == 0 {
+			result.Additional = &Schema{}
+		}
+		result.Properties = make(map[string]*Schema, len(s.Properties))
+		for name, property := range s.Properties {
+			converted, err := c.convert(property)
+			if err != nil {
+				return fmt.Errorf("invalid property '%s': %w", name, err)
+			}
+			result.Properties[name] = converted
+		}
+		if len(s.AdditionalProperties) > 0 {
+			var allowed bool
+			if err := json.Unmarshal(s.AdditionalProperties, &allowed); err == nil {
+				if allowed {
+					result.Additional = &Schema{}
+				}
+			} else if result.Additional, err = c.convertRaw(s.AdditionalProperties); err != nil {
+				return fmt.Errorf("invalid additional properties: %w", err)
+			}
+		}
+	case Array:
+		// items given as an array describe tuples, which are not checked
+		if len(s.Items) > 0 && s.Items[0] == '{' {
+			items, err := c.convertRaw(s.Items)
+			if err != nil {
+				return fmt.Errorf("invalid items: %w", err)
+			}
+			result.Items = items
+		}
+	}
+	return nil
+}
+
+func (c *jsonConverter) convertRaw(data json.RawMessage) (*Schema, error) {
+	s := &jsonSchema{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return c.convert(s)
+}
+
+// resolve converts the definition a reference points to, once for all the references to it
+// so that recursive definitions are supported
+func (c *jsonConverter) resolve(ref string) (*Schema, error) {
+	if resolved, ok := c.refs[ref]; ok {
+		return resolved, nil
+	}
+	var definition *jsonSchema
+	switch {
+	case ref == "#":
+		definition = c.root
+	case strings.HasPrefix(ref, "#/definitions/"):
+		definition = c.root.Definitions[strings.TrimPrefix(ref, "#/definitions/")]
+	case strings.HasPrefix(ref, "#/$defs/"):
+		definition = c.root.Defs[strings.TrimPrefix(ref, "#/$defs/")]
+	default:
+		return nil, fmt.Errorf("unsupported reference '%s'", ref)
+	}
+	if definition == nil {
+		return nil, fmt.Errorf("undefined reference '%s'", ref)
+	}
+	resolved := &Schema{}
+	c.refs[ref] = resolved
+	if definition.Ref != "" {
+		target, err := c.resolve(definition.Ref)
+		if err != nil {
+			return nil, err
+		}
+		*resolved = *target
+		return resolved, nil
+	}
+	if err := c.convertInto(definition, resolved); err != nil {
+		return nil, fmt.Errorf("invalid definition '%s': %w", ref, err)
+	}
+	return resolved, nil
+}
+
+func jsonKind(s *jsonSchema) (Kind, error) {
+	if len(s.Type) == 0 {
+		switch {
+		case len(s.Properties) > 0:
+			return Object, nil
+		case len(s.Items) > 0:
+			return Array, nil
+		}
+		return Any, nil
+	}
+	var names []string
+	if err := json.Unmarshal(s.Type, &names); err != nil {
+		var name string
+		if err := json.Unmarshal(s.Type, &name); err != nil {
+			return Any, fmt.Errorf("invalid type %s", s.Type)
+		}
+		names = []string{name}
+	}
+	kind := Any
+	for _, name := range names {
+		current, ok := jsonKinds[name]
+		if !ok {
+			return Any, fmt.Errorf("unknown type '%s'", name)
+		}
+		switch {
+		case current == Null && len(names) > 1:
+			continue
+		case kind == Integer && current == Number, kind == Number && current == Integer:
+			kind = Number
+		case kind != Any:
+			return Any, nil
+		default:
+			kind = current
+		}
+	}
+	return kind, nil
+}
+
+var typeOfTime = reflect.TypeOf(time.Time{})
+
+// FromStruct builds a schema out of the type of a struct or of a pointer to a struct, which may be nil.
+//
+// Fields are named and promoted following the rules of exec.ContextFromStruct, and structs do not allow
+// attributes other than their fields, while maps with string keys allow any attribute.
+func FromStruct(v interface{}) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unable to build a schema from %v: not a struct", t)
+	}
+	return fromType(t, map[reflect.Type]*Schema{}), nil
+}
+
+func fromType(t reflect.Type, seen map[reflect.Type]*Schema) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Kind: Boolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Kind: Integer}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Kind: Number}
+	case reflect.String:
+		return &Schema{Kind: String}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Kind: Array, Items: fromType(t.Elem(), seen)}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return &Schema{Kind: Object, Additional: &Schema{}}
+		}
+		return &Schema{Kind: Object, Additional: fromType(t.Elem(), seen)}
+	case reflect.Struct:
+		if t == typeOfTime {
+			return &Schema{}
+		}
+		if known, ok := seen[t]; ok {
+			return known
+		}
+		result := &Schema{Kind: Object, Properties: map[string]*Schema{}}
+		seen[t] = result
+		addFields(result, t, seen)
+		return result
+	}
+	return &Schema{}
+}
+
+func addFields(result *Schema, t reflect.Type, seen map[reflect.Type]*Schema) {
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		name, skip := fieldName(field)
+		if skip {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				promoted := &Schema{Properties: map[string]*Schema{}}
+				addFields(promoted, embedded, seen)
+				for key, property := range promoted.Properties {
+					if _, shadowed := result.Properties[key]; !shadowed {
+						result.Properties[key] = property
+					}
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		// fields declared by the struct itself take precedence over promoted ones
+		result.Properties[name] = fromType(field.Type, seen)
+	}
+}
+
+// fieldName returns the name given to a field through its tags, and whether it should be skipped
+func fieldName(field reflect.StructField) (string, bool) {
+	for _, tag := range []string{"gonja", "json"} {
+		value, ok := field.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(value, ",")
+		if name == "-" {
+			return "", true
+		}
+		if name != "" {
+			return name, false
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,106 @@
+package schema_test
+
+import (
+	"time"
+
+	"github.com/nikolalohinski/gonja/v2/schema"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("building schemas", func() {
+	Context("from a JSON schema", func() {
+		var (
+			document = new(string)
+
+			returnedSchema = new(*schema.Schema)
+			returnedErr    = new(error)
+		)
+		JustBeforeEach(func() {
+			*returnedSchema, *returnedErr = schema.FromJSONSchema([]byte(*document))
+		})
+		Context("when the document describes nested objects and arrays", func() {
+			BeforeEach(func() {
+				*document = `{
+					"type": "object",
+					"properties": {
+						"name": {"type": ["string", "null"]},
+						"tags": {"type": "array", "items": {"type": "string"}},
+						"labels": {"type": "object", "additionalProperties": {"type": "integer"}},
+						"extra": {"type": "object"},
+						"mixed": {"type": ["string", "integer"]}
+					}
+				}`
+			})
+			It("should describe them", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect(*returnedSchema).To(Equal(&schema.Schema{
+					Kind: schema.Object,
+					Properties: map[string]*schema.Schema{
+						"name":   {Kind: schema.String},
+						"tags":   {Kind: schema.Array, Items: &schema.Schema{Kind: schema.String}},
+						"labels": {Kind: schema.Object, Properties: map[string]*schema.Schema{}, Additional: &schema.Schema{Kind: schema.Integer}},
+						"extra":  {Kind: schema.Object, Properties: map[string]*schema.Schema{}, Additional: &schema.Schema{}},
+						"mixed":  {Kind: schema.Any},
+					},
+				}))
+			})
+		})
+		Context("when the document holds recursive references", func() {
+			BeforeEach(func() {
+				*document = `{
+					"$ref": "#/$defs/node",
+					"$defs": {"node": {"properties": {"children": {"items": {"$ref": "#/$defs/node"}}}}}
+				}`
+			})
+			It("should resolve them", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect((*returnedSchema).Kind).To(Equal(schema.Object))
+				Expect((*returnedSchema).Properties["children"].Items).To(BeIdenticalTo(*returnedSchema))
+			})
+		})
+		Context("when the document references another one", func() {
+			BeforeEach(func() {
+				*document = `{"properties": {"user": {"$ref": "user.json"}}}`
+			})
+			It("should return an error", func() {
+				Expect(*returnedErr).To(MatchError("invalid property 'user': unsupported reference 'user.json'"))
+			})
+		})
+	})
+	Context("from a struct", func() {
+		type Address struct {
+			City string `json:"city"`
+		}
+		type Audit struct {
+			Created time.Time
+		}
+		type User struct {
+			Audit
+			Name     string            `gonja:"name"`
+			Age      int               `json:"age,omitempty"`
+			Password string            `json:"-"`
+			Address  *Address          `json:"address"`
+			Friends  []*User           `json:"friends"`
+			Labels   map[string]string `json:"labels"`
+			private  bool
+		}
+		It("should describe its fields", func() {
+			returned, err := schema.FromStruct((*User)(nil))
+			Expect(err).To(BeNil())
+			Expect(returned.Kind).To(Equal(schema.Object))
+			Expect(returned.Properties).To(HaveLen(6))
+			Expect(returned.Properties["Created"]).To(Equal(&schema.Schema{Kind: schema.Any}))
+			Expect(returned.Properties["name"]).To(Equal(&schema.Schema{Kind: schema.String}))
+			Expect(returned.Properties["age"]).To(Equal(&schema.Schema{Kind: schema.Integer}))
+			Expect(returned.Properties["address"]).To(Equal(&schema.Schema{Kind: schema.Object, Properties: map[string]*schema.Schema{"city": {Kind: schema.String}}}))
+			Expect(returned.Properties["friends"].Items).To(BeIdenticalTo(returned))
+			Expect(returned.Properties["labels"]).To(Equal(&schema.Schema{Kind: schema.Object, Additional: &schema.Schema{Kind: schema.String}}))
+		})
+		It("should refuse other values", func() {
+			_, err := schema.FromStruct(42)
+			Expect(err).To(MatchError("unable to build a schema from int: not a struct"))
+		})
+	})
+})
@@ -0,0 +1,281 @@
+package schema
+
+import (
+	controlStructures "github.com/nikolalohinski/gonja/v2/builtins/control_structures"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/lint"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+)
+
+// Validate reports, without rendering the template, the variables and attributes it references which are
+// not described by the schema, and the filters given values of types they do not support. Names defined by
+// the environment of the template, such as global functions, are not reported.
+func Validate(template *exec.Template, schema *Schema) []lint.Diagnostic {
+	return lint.New(Rule(schema, template.Environment())).Lint(template.Root(), template.Config())
+}
+
+// Rule returns a lint rule validating templates against a schema, as Validate does. The environment
+// may be nil, in which case only the variables defined by the templates themselves are known.
+func Rule(schema *Schema, environment *exec.Environment) lint.Rule {
+	return schemaRule{schema: schema, environment: environment}
+}
+
+type schemaRule struct {
+	schema      *Schema
+	environment *exec.Environment
+}
+
+func (schemaRule) Name() string { return "schema" }
+
+func (r schemaRule) Check(pass *lint.Pass) {
+	v := &validator{pass: pass, schema: r.schema, environment: r.environment}
+	root := &scope{names: map[string]*Schema{}}
+	for name := range pass.Template.Macros {
+		root.declare(name, nil)
+	}
+	v.check(pass.Template, root)
+}
+
+// implicitNames are defined by the engine while rendering templates
+var implicitNames = map[string]bool{"self": true, "super": true}
+
+type scope struct {
+	parent *scope
+	names  map[string]*Schema
+}
+
+func (s *scope) inherit() *scope {
+	return &scope{parent: s, names: map[string]*Schema{}}
+}
+
+func (s *scope) declare(name string, schema *Schema) {
+	s.names[name] = schema
+}
+
+func (s *scope) lookup(name string) (*Schema, bool) {
+	for current := s; current != nil; current = current.parent {
+		if schema, ok := current.names[name]; ok {
+			return schema, true
+		}
+	}
+	return nil, false
+}
+
+type validator struct {
+	pass        *lint.Pass
+	schema      *Schema
+	environment *exec.Environment
+}
+
+// check reports the problems found in a node and its descendants, and returns the schema of the values
+// the node evaluates to, which is nil when unknown
+func (v *validator) check(node nodes.Node, s *scope) *Schema {
+	switch n := node.(type) {
+	case *nodes.Name:
+		return v.variable(n, s)
+	case *nodes.GetAttribute:
+		from := v.check(n.Node, s)
+		if n.Attribute == "" {
+			return itemsOf(from)
+		}
+		return v.attribute(n, from, n.Attribute)
+	case *nodes.GetItem:
+		from := v.check(n.Node, s)
+		v.check(n.Arg, s)
+		if key, ok := n.Arg.(*nodes.String); ok {
+			return v.attribute(n, from, key.Val)
+		}
+		return itemsOf(from)
+	case *nodes.GetSlice:
+		from := v.check(n.Node, s)
+		v.checkAll(s, n.Start, n.End)
+		return from
+	case *nodes.String:
+		return &Schema{Kind: String}
+	case *nodes.Integer:
+		return &Schema{Kind: Integer}
+	case *nodes.Float:
+		return &Schema{Kind: Number}
+	case *nodes.Bool:
+		return &Schema{Kind: Boolean}
+	case *nodes.None:
+		return &Schema{Kind: Null}
+	case *nodes.List, *nodes.Tuple:
+		v.checkAll(s, nodes.Children(n)...)
+		return &Schema{Kind: Array}
+	case *nodes.Dict:
+		v.checkAll(s, nodes.Children(n)...)
+		return &Schema{Kind: Object, Additional: &Schema{}}
+	case *nodes.FilteredExpression:
+		result := v.check(n.Expression, s)
+		for _, filter := range n.Filters {
+			v.checkAll(s, nodes.Children(filter)...)
+			result = v.filter(filter, result)
+		}
+		return result
+	case *nodes.TestExpression:
+		v.checkAll(s, n.Expression, n.Test)
+		return &Schema{Kind: Boolean}
+	case *nodes.Negation:
+		v.check(n.Term, s)
+		return &Schema{Kind: Boolean}
+	case *nodes.UnaryExpression:
+		return v.check(n.Term, s)
+	case *nodes.ControlStructureBlock:
+		if loop, ok := n.ControlStructure.(*controlStructures.ForControlStructure); ok {
+			v.loop(loop, s)
+			return nil
+		}
+	case *controlStructures.MacroControlStructure:
+		return v.check(n.Macro, s)
+	case *nodes.Macro:
+		s.declare(n.Name, nil)
+		body := s.inherit()
+		for _, pair := range n.Kwargs {
+			v.check(pair.Value, s)
+			if argument, ok := pair.Key.(*nodes.String); ok {
+				body.declare(argument.Val, nil)
+			}
+		}
+		v.check(n.Wrapper, body)
+		return nil
+	}
+	if declarer, ok := node.(interface{ DeclaredNames() []string }); ok {
+		for _, name := range declarer.DeclaredNames() {
+			s.declare(name, nil)
+		}
+	}
+	if importer, ok := node.(interface{ ImportedNames() []string }); ok {
+		for _, name := range importer.ImportedNames() {
+			s.declare(name, nil)
+		}
+	}
+	v.checkAll(s, nodes.Children(node)...)
+	return nil
+}
+
+func (v *validator) checkAll(s *scope, children ...nodes.Node) {
+	for _, child := range children {
+		if child != nil {
+			v.check(child, s)
+		}
+	}
+}
+
+func (v *validator) variable(name *nodes.Name, s *scope) *Schema {
+	if schema, ok := s.lookup(name.Name.Val); ok {
+		return schema
+	}
+	if v.schema != nil {
+		if property, ok := v.schema.property(name.Name.Val); ok {
+			return property
+		}
+	}
+	if implicitNames[name.Name.Val] || v.environment != nil && v.environment.Context != nil && v.environment.Context.Has(name.Name.Val) {
+		return nil
+	}
+	v.pass.Report(name, lint.SeverityError, "'%s' is not defined by the schema", name.Name.Val)
+	return nil
+}
+
+func (v *validator) attribute(node nodes.Node, from *Schema, name string) *Schema {
+	if from == nil || from.Kind == Any {
+		return nil
+	}
+	if from.Kind == Object {
+		if property, ok := from.property(name); ok {
+			return property
+		}
+	}
+	if v.hasMethod(from.Kind, name) {
+		return nil
+	}
+	if path := pathOf(node); path != "" {
+		v.pass.Report(node, lint.SeverityError, "'%s' is not defined by the schema", path)
+	} else {
+		v.pass.Report(node, lint.SeverityError, "attribute '%s' is not defined by the schema", name)
+	}
+	return nil
+}
+
+// hasMethod tells whether values of a kind have a method with the given name
+func (v *validator) hasMethod(kind Kind, name string) bool {
+	if v.environment == nil {
+		return false
+	}
+	methods := v.environment.Methods
+	switch {
+	case kind == Object && methods.Dict != nil:
+		return methods.Dict.Exists(name)
+	case kind == Array && methods.List != nil:
+		return methods.List.Exists(name)
+	case kind == String && methods.Str != nil:
+		return methods.Str.Exists(name)
+	case kind == Integer && methods.Int != nil:
+		return methods.Int.Exists(name)
+	case kind == Number && methods.Float != nil:
+		return methods.Float.Exists(name)
+	case kind == Boolean && methods.Bool != nil:
+		return methods.Bool.Exists(name)
+	}
+	return false
+}
+
+// loop checks a for loop, whose variables are described by the items of the iterated value
+func (v *validator) loop(loop *controlStructures.ForControlStructure, s *scope) {
+	iterated := v.check(loop.ObjectEvaluator, s)
+	body := s.inherit()
+	body.declare("loop", nil)
+	var key, value *Schema
+	if iterated != nil {
+		switch {
+		case iterated.Kind == Array && loop.Value == "":
+			key = iterated.Items
+		case iterated.Kind == Object:
+			key = &Schema{Kind: String}
+			if len(iterated.Properties) == 0 {
+				value = iterated.Additional
+			}
+		case iterated.Kind == String:
+			key = &Schema{Kind: String}
+		}
+	}
+	body.declare(loop.Key, key)
+	if loop.Value != "" {
+		body.declare(loop.Value, value)
+	}
+	if loop.IfCondition != nil {
+		v.check(loop.IfCondition, body)
+	}
+	v.check(loop.BodyWrapper, body)
+	if loop.EmptyWrapper != nil {
+		v.check(loop.EmptyWrapper, s)
+	}
+}
+
+func itemsOf(from *Schema) *Schema {
+	if from != nil && from.Kind == Array {
+		return from.Items
+	}
+	return nil
+}
+
+// pathOf returns the dotted path of a variable such as 'user.address.city', or an empty string
+// when the expression is not made of names and attributes only
+func pathOf(node nodes.Node) string {
+	switch n := node.(type) {
+	case *nodes.Name:
+		return n.Name.Val
+	case *nodes.GetAttribute:
+		if parent := pathOf(n.Node); parent != "" && n.Attribute != "" {
+			return parent + "." + n.Attribute
+		}
+	case *nodes.GetItem:
+		if key, ok := n.Arg.(*nodes.String); ok {
+			if parent := pathOf(n.Node); parent != "" {
+				return parent + "." + key.Val
+			}
+		}
+	}
+	return ""
+}
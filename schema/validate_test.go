@@ -0,0 +1,78 @@
+package schema_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/lint"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/schema"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("validating templates", func() {
+	type Item struct {
+		Title string  `json:"title"`
+		Price float64 `json:"price"`
+	}
+	type Payload struct {
+		User struct {
+			Name string `json:"name"`
+			Age  int    `json:"age"`
+		} `json:"user"`
+		Items []Item          `json:"items"`
+		Meta  map[string]bool `json:"meta"`
+	}
+	var (
+		source = new(string)
+
+		returnedDiagnostics = new([]lint.Diagnostic)
+	)
+	JustBeforeEach(func() {
+		described, err := schema.FromStruct(Payload{})
+		Expect(err).To(BeNil())
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/test": *source})
+		template, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+		Expect(err).To(BeNil())
+		*returnedDiagnostics = schema.Validate(template, described)
+	})
+	Context("when the template matches the schema", func() {
+		BeforeEach(func() {
+			*source = `{{ user.name | upper }} is {{ user.age + 1 }}
+{% for item in items if item.price > 0 %}{{ loop.index }} {{ item.title | title }} {{ item.price | round }}{% endfor %}
+{% for key, value in meta.items() %}{{ key }}={{ value }}{% endfor %}{{ meta.anything }}
+{% set total = items | map(attribute='price') | sum %}{{ total }}{{ range(3) | join(',') }}
+{% macro card(label, size=2) %}{{ label }}{{ size }}{% endmacro %}{{ card(user.name) }}
+{% with first = items | first %}{{ first.title }}{% endwith %}{{ user.name.upper() }}`
+		})
+		It("should not report anything", func() {
+			Expect(*returnedDiagnostics).To(BeEmpty())
+		})
+	})
+	Context("when the template references undefined variables and attributes", func() {
+		BeforeEach(func() {
+			*source = "{{ usr.name }}\n{{ user.nmae }}{% for item in items %}{{ item.cost }}{% endfor %}{{ user['email'] }}"
+		})
+		It("should report them", func() {
+			Expect(*returnedDiagnostics).To(Equal([]lint.Diagnostic{
+				{Rule: "schema", Severity: lint.SeverityError, Message: "'usr' is not defined by the schema", Identifier: "/test", Line: 1, Col: 4},
+				{Rule: "schema", Severity: lint.SeverityError, Message: "'user.nmae' is not defined by the schema", Identifier: "/test", Line: 2, Col: 8},
+				{Rule: "schema", Severity: lint.SeverityError, Message: "'item.cost' is not defined by the schema", Identifier: "/test", Line: 2, Col: 46},
+				{Rule: "schema", Severity: lint.SeverityError, Message: "'user.email' is not defined by the schema", Identifier: "/test", Line: 2, Col: 73},
+			}))
+		})
+	})
+	Context("when filters are given values of incompatible types", func() {
+		BeforeEach(func() {
+			*source = "{{ user.age | upper }}{{ items | dictsort }}{{ user.name | length | capitalize }}{{ user.age | abs }}"
+		})
+		It("should report them", func() {
+			Expect(*returnedDiagnostics).To(HaveLen(3))
+			Expect((*returnedDiagnostics)[0].Message).To(Equal("filter 'upper' expects a string but is given an integer"))
+			Expect((*returnedDiagnostics)[0].Col).To(Equal(15))
+			Expect((*returnedDiagnostics)[1].Message).To(Equal("filter 'dictsort' expects an object but is given an array"))
+			Expect((*returnedDiagnostics)[2].Message).To(Equal("filter 'capitalize' expects a string but is given an integer"))
+		})
+	})
+})
@@ -0,0 +1,289 @@
+// Package semantic classifies the tokens of templates into semantic categories, such as variables, filter
+// names or statement keywords, so that language servers can provide rich highlighting and hover information.
+package semantic
+
+import (
+	"fmt"
+
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+)
+
+// Category is the semantic category of a token
+type Category int
+
+const (
+	Keyword Category = iota
+	Variable
+	Property
+	Parameter
+	Filter
+	Test
+	String
+	Number
+	Constant
+	Operator
+	Delimiter
+	Comment
+)
+
+// categoryNames follow the names of the standard semantic token types of the language server protocol
+// where there is one
+var categoryNames = map[Category]string{
+	Keyword:   "keyword",
+	Variable:  "variable",
+	Property:  "property",
+	Parameter: "parameter",
+	Filter:    "filter",
+	Test:      "test",
+	String:    "string",
+	Number:    "number",
+	Constant:  "constant",
+	Operator:  "operator",
+	Delimiter: "delimiter",
+	Comment:   "comment",
+}
+
+func (c Category) String() string {
+	if name, ok := categoryNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("category(%d)", int(c))
+}
+
+// MarshalText encodes the category by name, so that tokens can be exported as JSON
+func (c Category) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes a category from its name
+func (c *Category) UnmarshalText(text []byte) error {
+	for category, name := range categoryNames {
+		if name == string(text) {
+			*c = category
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown category '%s'", text)
+}
+
+// Token is a classified span of the template source
+type Token struct {
+	Category Category `json:"category"`
+	Text     string   `json:"text"`
+	Start    int      `json:"start"` // offset of the token within the source
+	End      int      `json:"end"`   // offset right after the token
+	Line     int      `json:"line"`
+	Col      int      `json:"col"`
+	// Node is the syntax tree node the token stands for, such as a *nodes.FilterCall for a filter name,
+	// which can be used to provide hover information. It is nil when the token was classified without a tree.
+	Node nodes.Node `json:"-"`
+}
+
+// keywords are the names which are part of the syntax of control structures and expressions
+var keywords = map[string]bool{
+	"if":        true,
+	"elif":      true,
+	"else":      true,
+	"recursive": true,
+	"import":    true,
+	"from":      true,
+	"as":        true,
+	"with":      true,
+	"without":   true,
+	"context":   true,
+	"ignore":    true,
+	"missing":   true,
+	"scoped":    true,
+	"required":  true,
+}
+
+var constants = map[string]bool{
+	"true": true, "True": true, "false": true, "False": true, "none": true, "None": true,
+}
+
+var operators = map[tokens.Type]bool{
+	tokens.Addition:           true,
+	tokens.Subtraction:        true,
+	tokens.Multiply:           true,
+	tokens.Division:           true,
+	tokens.FloorDivision:      true,
+	tokens.Modulo:             true,
+	tokens.Power:              true,
+	tokens.Tilde:              true,
+	tokens.Pipe:               true,
+	tokens.Assign:             true,
+	tokens.Equals:             true,
+	tokens.Ne:                 true,
+	tokens.GreaterThan:        true,
+	tokens.GreaterThanOrEqual: true,
+	tokens.LowerThan:          true,
+	tokens.LowerThanOrEqual:   true,
+	tokens.Operator:           true,
+}
+
+// Classify returns the semantic tokens of a parsed template in source order, using its syntax tree to
+// tell variables, attributes, filters and tests apart and to attach the nodes to the tokens.
+// Text outside of tags, whitespace and punctuation are left out.
+func Classify(template *nodes.Template, cfg *config.Config) []Token {
+	return classify(template.Source, template, cfg)
+}
+
+// ClassifySource returns the semantic tokens of a template which may not parse, classifying names
+// from the tokens surrounding them. The nodes of the returned tokens are nil.
+func ClassifySource(source string, cfg *config.Config) []Token {
+	return classify(source, nil, cfg)
+}
+
+type classified struct {
+	category Category
+	node     nodes.Node
+}
+
+func classify(source string, template *nodes.Template, cfg *config.Config) []Token {
+	known := map[int]classified{}
+	if template != nil {
+		known = classifyNodes(template)
+	}
+
+	var (
+		result    []Token
+		stream    = tokens.Tokenize(source, cfg)
+		inTag     bool
+		inComment bool
+		statement bool // whether the next name is the one of a statement
+		test      bool // whether the next name is the one of a test
+		depth     int
+		previous  *tokens.Token
+	)
+	add := func(token *tokens.Token, category Category, node nodes.Node) {
+		result = append(result, Token{
+			Category: category,
+			Text:     source[token.Pos:token.End],
+			Start:    token.Pos,
+			End:      token.End,
+			Line:     token.Line,
+			Col:      token.Col,
+			Node:     node,
+		})
+	}
+	for index, token := range stream {
+		switch token.Type {
+		case tokens.Whitespace, tokens.EOF, tokens.Error:
+			continue
+		case tokens.CommentBegin, tokens.LinecommentBegin:
+			inComment = true
+			add(token, Comment, nil)
+		case tokens.CommentEnd, tokens.LinecommentEnd:
+			inComment = false
+			add(token, Comment, nil)
+		case tokens.Data, tokens.Comment, tokens.Linecomment:
+			if inComment || token.Type != tokens.Data {
+				add(token, Comment, nil)
+			}
+		case tokens.BlockBegin, tokens.LinecontrolStructureBegin:
+			inTag, statement, depth = true, true, 0
+			add(token, Delimiter, nil)
+		case tokens.VariableBegin:
+			inTag, statement, depth = true, false, 0
+			add(token, Delimiter, nil)
+		case tokens.BlockEnd, tokens.VariableEnd, tokens.LinecontrolStructureEnd:
+			inTag = false
+			add(token, Delimiter, nil)
+		case tokens.LeftParenthesis, tokens.LeftBracket, tokens.LeftBrace:
+			depth++
+		case tokens.RightParenthesis, tokens.RightBracket, tokens.RightBrace:
+			depth--
+		case tokens.String:
+			add(token, String, known[token.Pos].node)
+		case tokens.Integer, tokens.Float:
+			add(token, Number, known[token.Pos].node)
+		case tokens.Is:
+			test = true
+			add(token, Keyword, nil)
+		case tokens.Not, tokens.In, tokens.And, tokens.Or:
+			add(token, Keyword, nil)
+		case tokens.Name:
+			if !inTag {
+				break
+			}
+			if entry, ok := known[token.Pos]; ok {
+				add(token, entry.category, entry.node)
+			} else {
+				add(token, classifyName(token, previous, next(stream, index), statement, test, depth), nil)
+			}
+			statement, test = false, false
+		default:
+			if operators[token.Type] {
+				add(token, Operator, nil)
+			}
+		}
+		previous = token
+	}
+	return result
+}
+
+// classifyNodes maps the offsets of the tokens standing for nodes of the syntax tree to their category
+func classifyNodes(template *nodes.Template) map[int]classified {
+	known := map[int]classified{}
+	nodes.Traverse(template, func(node nodes.Node, _ []nodes.Node) bool {
+		switch n := node.(type) {
+		case *nodes.Name:
+			known[n.Name.Pos] = classified{Variable, n}
+		case *nodes.FilterCall:
+			known[n.Token.Pos] = classified{Filter, n}
+		case *nodes.TestCall:
+			known[n.Token.Pos] = classified{Test, n}
+		case *nodes.Bool:
+			known[n.Location.Pos] = classified{Constant, n}
+		case *nodes.None:
+			known[n.Location.Pos] = classified{Constant, n}
+		case *nodes.String:
+			known[n.Location.Pos] = classified{String, n}
+		case *nodes.Integer:
+			known[n.Location.Pos] = classified{Number, n}
+		case *nodes.Float:
+			known[n.Location.Pos] = classified{Number, n}
+		case *nodes.GetAttribute:
+			// the attribute is the last token of the node, right after the dot
+			if span, ok := template.Span(n); ok && n.Attribute != "" {
+				known[span.End.Pos] = classified{Property, n}
+			}
+		}
+		return true
+	})
+	return known
+}
+
+// classifyName classifies a name which does not stand for a node of the syntax tree from its surrounding
+// tokens, such as loop variables, keyword arguments or the names used in templates which do not parse
+func classifyName(token, previous, following *tokens.Token, statement, test bool, depth int) Category {
+	switch {
+	case statement:
+		return Keyword
+	case test:
+		return Test
+	case previous != nil && previous.Type == tokens.Pipe:
+		return Filter
+	case previous != nil && previous.Type == tokens.Dot:
+		return Property
+	case constants[token.Val]:
+		return Constant
+	case keywords[token.Val]:
+		return Keyword
+	case depth > 0 && following != nil && following.Type == tokens.Assign:
+		return Parameter
+	}
+	return Variable
+}
+
+// next returns the first token after the given index which is not whitespace
+func next(stream []*tokens.Token, index int) *tokens.Token {
+	for _, token := range stream[index+1:] {
+		if token.Type != tokens.Whitespace {
+			return token
+		}
+	}
+	return nil
+}
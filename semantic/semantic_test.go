@@ -0,0 +1,137 @@
+package semantic_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/semantic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("semantic", func() {
+	var (
+		source = new(string)
+
+		returnedTokens = new([]semantic.Token)
+	)
+	summarize := func() []string {
+		summary := make([]string, 0, len(*returnedTokens))
+		for _, token := range *returnedTokens {
+			summary = append(summary, fmt.Sprintf("%s %s", token.Category, token.Text))
+		}
+		return summary
+	}
+	Context("when classifying a parsed template", func() {
+		JustBeforeEach(func() {
+			loader := loaders.MustNewMemoryLoader(map[string]string{"/test": *source})
+			template, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+			Expect(err).To(BeNil())
+			*returnedTokens = semantic.Classify(template.Root(), gonja.DefaultConfig)
+		})
+		Context("when the template holds expressions", func() {
+			BeforeEach(func() {
+				*source = "Hi {{ user.name | default('x', boolean=true) ~ 42 }}{# note #}"
+			})
+			It("should classify their tokens", func() {
+				Expect(summarize()).To(Equal([]string{
+					"delimiter {{",
+					"variable user",
+					"property name",
+					"operator |",
+					"filter default",
+					"string 'x'",
+					"parameter boolean",
+					"operator =",
+					"constant true",
+					"operator ~",
+					"number 42",
+					"delimiter }}",
+					"comment {#",
+					"comment  note ",
+					"comment #}",
+				}))
+			})
+			It("should attach the nodes and locate the tokens", func() {
+				filter := (*returnedTokens)[4]
+				Expect(filter.Node).To(BeAssignableToTypeOf(&nodes.FilterCall{}))
+				Expect(filter.Node.(*nodes.FilterCall).Args).To(HaveLen(1))
+				Expect(filter.Start).To(Equal(18))
+				Expect(filter.End).To(Equal(25))
+				Expect(filter.Line).To(Equal(1))
+				Expect(filter.Col).To(Equal(19))
+				Expect((*returnedTokens)[1].Node).To(BeAssignableToTypeOf(&nodes.Name{}))
+			})
+		})
+		Context("when the template holds control structures", func() {
+			BeforeEach(func() {
+				*source = "{% for item in items if item is not none %}{{ loop.index }}{% endfor %}"
+			})
+			It("should classify statement keywords and tests", func() {
+				Expect(summarize()).To(Equal([]string{
+					"delimiter {%",
+					"keyword for",
+					"variable item",
+					"keyword in",
+					"variable items",
+					"keyword if",
+					"variable item",
+					"keyword is",
+					"keyword not",
+					"test none",
+					"delimiter %}",
+					"delimiter {{",
+					"variable loop",
+					"property index",
+					"delimiter }}",
+					"delimiter {%",
+					"keyword endfor",
+					"delimiter %}",
+				}))
+			})
+		})
+	})
+	Context("when classifying a template which does not parse", func() {
+		JustBeforeEach(func() {
+			*returnedTokens = semantic.ClassifySource(*source, gonja.DefaultConfig)
+		})
+		BeforeEach(func() {
+			*source = "{% if x is defined %}{{ a.b | upper }}{{ broken( %}"
+		})
+		It("should classify names from their surrounding tokens", func() {
+			Expect(summarize()).To(Equal([]string{
+				"delimiter {%",
+				"keyword if",
+				"variable x",
+				"keyword is",
+				"test defined",
+				"delimiter %}",
+				"delimiter {{",
+				"variable a",
+				"property b",
+				"operator |",
+				"filter upper",
+				"delimiter }}",
+				"delimiter {{",
+				"variable broken",
+				"delimiter %}",
+			}))
+			Expect((*returnedTokens)[2].Node).To(BeNil())
+		})
+	})
+	Context("when exporting tokens", func() {
+		It("should name categories", func() {
+			encoded, err := json.Marshal(semantic.ClassifySource("{{ x }}", gonja.DefaultConfig)[1])
+			Expect(err).To(BeNil())
+			Expect(string(encoded)).To(Equal(`{"category":"variable","text":"x","start":3,"end":4,"line":1,"col":4}`))
+			decoded := semantic.Token{}
+			Expect(json.Unmarshal(encoded, &decoded)).To(Succeed())
+			Expect(decoded.Category).To(Equal(semantic.Variable))
+		})
+	})
+})
@@ -0,0 +1,115 @@
+package integration_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/builtins"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("ansible", func() {
+	var (
+		identifier = new(string)
+
+		environment = new(*exec.Environment)
+		loader      = new(loaders.Loader)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+		shouldRender   = func(template, result string) {
+			Context(template, func() {
+				BeforeEach(func() {
+					*loader = loaders.MustNewMemoryLoader(map[string]string{
+						*identifier: template,
+					})
+				})
+				It("should return the expected rendered content", func() {
+					By("not returning any error")
+					Expect(*returnedErr).To(BeNil())
+					By("returning the expected result")
+					AssertPrettyDiff(result, *returnedResult)
+				})
+			})
+		}
+		shouldFail = func(template, err string) {
+			Context(template, func() {
+				BeforeEach(func() {
+					*loader = loaders.MustNewMemoryLoader(map[string]string{
+						*identifier: template,
+					})
+				})
+				It("should return the expected error", func() {
+					Expect(*returnedErr).ToNot(BeNil())
+					Expect((*returnedErr).Error()).To(MatchRegexp(err))
+				})
+			})
+		}
+	)
+	BeforeEach(func() {
+		*identifier = "/test"
+		*environment = builtins.AnsibleEnvironment()
+		*loader = loaders.MustNewMemoryLoader(nil)
+	})
+	JustBeforeEach(func() {
+		var t *exec.Template
+		t, *returnedErr = exec.NewTemplate(*identifier, gonja.DefaultConfig, *loader, *environment)
+		if *returnedErr != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(nil)
+	})
+	It("should not alter the default environment", func() {
+		Expect(gonja.DefaultEnvironment.Filters.Exists("regex_replace")).To(BeFalse())
+		Expect(gonja.DefaultEnvironment.Tests.Exists("version")).To(BeFalse())
+	})
+	Context("bool", func() {
+		shouldRender(`{{ "Yes" | bool }} {{ "on" | bool }} {{ "1" | bool }} {{ 1 | bool }} {{ "off" | bool }} {{ "maybe" | bool }}`, "True True True True False False")
+	})
+	Context("mandatory", func() {
+		shouldRender(`{{ "set" | mandatory }}`, "set")
+		shouldFail(`{{ missing | mandatory }}`, "Mandatory variable not defined")
+		shouldFail(`{{ missing | mandatory(msg="missing is required") }}`, "missing is required")
+	})
+	Context("ternary", func() {
+		shouldRender(`{{ true | ternary("yes", "no") }} {{ [] | ternary("yes", "no") }} {{ none | ternary("yes", "no", none_val="null") }}`, "yes no null")
+	})
+	Context("type_debug", func() {
+		shouldRender(`{{ 1 | type_debug }} {{ 1.5 | type_debug }} {{ "s" | type_debug }} {{ [] | type_debug }} {{ {} | type_debug }} {{ none | type_debug }}`, "int float str list dict NoneType")
+	})
+	Context("regex", func() {
+		shouldRender(`{{ "foo bar foo" | regex_replace("f(o+)", "\\1x") }}`, "oox bar oox")
+		shouldRender(`{{ "db1.example.com" | regex_replace("^(?P<host>\\w+)\\..*$", "\\g<host>") }}`, "db1")
+		shouldRender(`{{ "aaa" | regex_replace("A", "b", count=2, ignorecase=true) }}`, "bba")
+		shouldRender(`{{ "server-12-east" | regex_search("\\d+") }}`, "12")
+		shouldRender(`{{ "server-12-east" | regex_search("(\\d+)-(?P<zone>\\w+)", "\\1", "\\g<zone>") }}`, "['12', 'east']")
+		shouldRender(`[{{ "server" | regex_search("\\d+") }}]`, "[]")
+		shouldRender(`{{ "k=v,x=y" | regex_findall("(\\w)=(\\w)") }} {{ "a1b22" | regex_findall("\\d+") }}`, "[['k', 'v'], ['x', 'y']] ['1', '22']")
+		shouldRender(`{{ "1.2.3+build" | regex_escape }}`, "1\\.2\\.3\\+build")
+		shouldFail(`{{ "a" | regex_search("a", "\\3") }}`, "unknown group reference")
+	})
+	Context("ipaddr", func() {
+		shouldRender(`{{ "192.168.1.10/24" | ipaddr }} {{ "nope" | ipaddr }}`, "192.168.1.10/24 False")
+		shouldRender(`{{ "192.168.1.10/24" | ipaddr("address") }} {{ "192.168.1.10/24" | ipaddr("network") }} {{ "192.168.1.10/24" | ipaddr("netmask") }}`, "192.168.1.10 192.168.1.0 255.255.255.0")
+		shouldRender(`{{ "192.168.1.10/24" | ipaddr("broadcast") }} {{ "192.168.1.10/24" | ipaddr("prefix") }} {{ "10.0.0.0/8" | ipaddr("size") }}`, "192.168.1.255 24 16777216")
+		shouldRender(`{{ ["10.0.0.1", "8.8.8.8", "fe80::1", "bogus"] | ipaddr("private") }} {{ ["10.0.0.1", "::1"] | ipv6 }} {{ "::1" | ipv4 }}`, "['10.0.0.1'] ['::1'] False")
+		shouldFail(`{{ "10.0.0.1" | ipaddr("bogus") }}`, "unknown query 'bogus' for filter 'ipaddr'")
+	})
+	Context("b64", func() {
+		shouldRender(`{{ "hello" | b64encode }} {{ "aGVsbG8=" | b64decode }}`, "aGVsbG8= hello")
+		shouldFail(`{{ "%%%" | b64decode }}`, "is not base64 encoded")
+	})
+	Context("json", func() {
+		shouldRender(`{{ {"b": [1, 2.0, "é"], "a": none} | to_json }}`, `{"a": null, "b": [1, 2.0, "\u00e9"]}`)
+		shouldRender(`{{ {"b": [1, {}], "a": true} | to_nice_json }}`, "{\n    \"a\": true,\n    \"b\": [\n        1,\n        {}\n    ]\n}")
+		shouldRender(`{{ ('{"a": [1, 2.5]}' | from_json).a }}`, "[1, 2.5]")
+	})
+	Context("tests", func() {
+		shouldRender(`{{ [1] is subset([1, 2]) }} {{ [1, 2] is superset([3]) }}`, "True False")
+		shouldRender(`{{ "1.10" is version("1.9", ">") }} {{ "1.2.3" is version("1.2.3") }} {{ "2.0" is version("2.1", operator="lt") }}`, "True True True")
+		shouldFail(`{{ "1.0" is version("1.0", "~") }}`, "unknown operator '~'")
+		shouldFail(`{{ "1.0" is version(["2.0", "lt"]) }}`, "failed to validate argument 'version': .* is not a string")
+	})
+})
@@ -268,6 +268,201 @@ var _ = Context("config", func() {
 			})
 		})
 	})
+	Context("when toggling the default trimming of tags", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: heredoc.Doc(`
+					[
+					  {% for item in ["a", "b"] %}
+					    {{ item }}
+					    {# separator #}
+					  {% endfor %}
+					]
+				`),
+			})
+		})
+		Context("when no policy is set", func() {
+			It("should keep the whitespace around tags", func() {
+				Expect(*returnedErr).To(BeNil())
+				AssertPrettyDiff("[\n  \n    a\n    \n  \n    b\n    \n  \n]\n", *returnedResult)
+			})
+		})
+		Context("when Config.TrimStatements = true", func() {
+			BeforeEach(func() {
+				(*configuration).TrimStatements = true
+			})
+			It("should trim the whitespace around statements only", func() {
+				Expect(*returnedErr).To(BeNil())
+				AssertPrettyDiff("[a\n    b\n    ]\n", *returnedResult)
+			})
+		})
+		Context("when every policy is set", func() {
+			BeforeEach(func() {
+				(*configuration).TrimStatements = true
+				(*configuration).TrimOutputs = true
+				(*configuration).TrimComments = true
+			})
+			It("should trim the whitespace around every tag", func() {
+				Expect(*returnedErr).To(BeNil())
+				AssertPrettyDiff("[ab]\n", *returnedResult)
+			})
+			Context("when trimming is disabled locally", func() {
+				BeforeEach(func() {
+					*loader = loaders.MustNewMemoryLoader(map[string]string{
+						*identifier: "[ {%+ if true +%} {{ 1 +}} {#+ comment +#} {% endif %} ]",
+					})
+				})
+				It("should keep the whitespace next to the '+' markers", func() {
+					Expect(*returnedErr).To(BeNil())
+					AssertPrettyDiff("[ 1 ]", *returnedResult)
+				})
+			})
+		})
+	})
+	Context("when toggling Config.ZeroValueTruthiness behavior", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: heredoc.Doc(`
+					{% if empty_list %}if{% else %}else{% endif %}
+					{{ "yes" if empty_map else "no" }}
+					{{ not empty_list }}
+					{{ empty_list and "and" }}
+					{{ [empty_list, nil_list, "", 0, zero] | select | list | length }}
+					{{ empty_map is truthy }} {{ nil_list is falsy }} {{ zero is falsy }}`),
+			})
+			(*environment).Context.Set("empty_list", []int{})
+			(*environment).Context.Set("nil_list", []int(nil))
+			(*environment).Context.Set("empty_map", map[string]int{})
+			(*environment).Context.Set("zero", struct{ Name string }{})
+		})
+		Context("when Config.ZeroValueTruthiness = false", func() {
+			BeforeEach(func() {
+				(*configuration).ZeroValueTruthiness = false
+			})
+			It("should return the expected rendered content", func() {
+				By("not returning any error")
+				Expect(*returnedErr).To(BeNil())
+				By("returning the expected result")
+				AssertPrettyDiff(heredoc.Doc(`
+					else
+					no
+					True
+					False
+					1
+					False True False`), *returnedResult)
+			})
+		})
+		Context("when Config.ZeroValueTruthiness = true", func() {
+			BeforeEach(func() {
+				(*configuration).ZeroValueTruthiness = true
+			})
+			It("should return the expected rendered content", func() {
+				By("not returning any error")
+				Expect(*returnedErr).To(BeNil())
+				By("returning the expected result")
+				AssertPrettyDiff(heredoc.Doc(`
+					if
+					yes
+					False
+					True
+					1
+					True True True`), *returnedResult)
+			})
+		})
+	})
+	Context("when toggling Config.TrimTrailingNewline behavior", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: "line\n\n",
+			})
+		})
+		Context("when Config.TrimTrailingNewline = false", func() {
+			BeforeEach(func() {
+				(*configuration).TrimTrailingNewline = false
+			})
+			It("should keep every trailing newline", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect(*returnedResult).To(Equal("line\n\n"))
+			})
+		})
+		Context("when Config.TrimTrailingNewline = true", func() {
+			BeforeEach(func() {
+				(*configuration).TrimTrailingNewline = true
+			})
+			It("should remove a single trailing newline", func() {
+				Expect(*returnedErr).To(BeNil())
+				Expect(*returnedResult).To(Equal("line\n"))
+			})
+		})
+	})
+	Context("when toggling Config.PythonNone behavior", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: heredoc.Doc(`
+					{{ none }}|{{ nothing }}|{{ "a" ~ none }}|{{ none | default("d") }}
+					{{ missing }}|{{ data.missing }}|{{ "a" ~ missing }}|{{ missing | default("d") }}`),
+			})
+			(*environment).Context.Set("nothing", nil)
+			(*environment).Context.Set("data", map[string]interface{}{})
+		})
+		Context("when Config.PythonNone = false", func() {
+			BeforeEach(func() {
+				(*configuration).PythonNone = false
+			})
+			It("should return the expected rendered content", func() {
+				By("not returning any error")
+				Expect(*returnedErr).To(BeNil())
+				By("returning the expected result")
+				AssertPrettyDiff(heredoc.Doc(`
+					||a|d
+					||a|d`), *returnedResult)
+			})
+			Context("and a variable is named none", func() {
+				BeforeEach(func() {
+					*environment = (*environment).Overlay()
+					(*environment).Context.Set("none", "variable")
+				})
+				It("should render the variable", func() {
+					By("not returning any error")
+					Expect(*returnedErr).To(BeNil())
+					By("returning the expected result")
+					AssertPrettyDiff(heredoc.Doc(`
+						variable||avariable|variable
+						||a|d`), *returnedResult)
+				})
+			})
+		})
+		Context("when Config.PythonNone = true", func() {
+			BeforeEach(func() {
+				(*configuration).PythonNone = true
+			})
+			It("should return the expected rendered content", func() {
+				By("not returning any error")
+				Expect(*returnedErr).To(BeNil())
+				By("returning the expected result")
+				AssertPrettyDiff(heredoc.Doc(`
+					None|None|aNone|None
+					||a|d`), *returnedResult)
+			})
+		})
+	})
+	Context("when using the config.Jinja2 preset", func() {
+		BeforeEach(func() {
+			*configuration = config.Jinja2()
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: heredoc.Doc(`
+					{% for key, value in {"b": none, "a": ""} %}{{ key }}={{ value }},{% endfor %}
+					{{ "yes" if [] else "no" }} {{ missing }}{{ "ab" | center | length }}
+				`),
+			})
+		})
+		It("should render as the python implementation does", func() {
+			By("not returning any error")
+			Expect(*returnedErr).To(BeNil())
+			By("returning the expected result")
+			Expect(*returnedResult).To(Equal("b=None,a=,\nno 80"))
+		})
+	})
 	Context("https://github.com/NikolaLohinski/gonja/issues/18", func() {
 		BeforeEach(func() {
 			(*configuration).TrimBlocks = true
@@ -0,0 +1,117 @@
+package integration_test
+
+import (
+	"errors"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("control structure 'abort'", func() {
+	var (
+		identifier = new(string)
+
+		environment = new(*exec.Environment)
+		loader      = new(loaders.Loader)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*identifier = "/test"
+		*environment = gonja.DefaultEnvironment
+		*loader = loaders.MustNewMemoryLoader(nil)
+	})
+	JustBeforeEach(func() {
+		var t *exec.Template
+		t, *returnedErr = exec.NewTemplate(*identifier, gonja.DefaultConfig, *loader, *environment)
+		if *returnedErr != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(exec.NewContext(map[string]interface{}{
+			"user": map[string]interface{}{"name": "bob"},
+		}))
+	})
+	Context("when the abort statement is not reached", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% if user is not defined %}{% abort 404, "no such user" %}{% endif %}{{ user.name }}`,
+			})
+		})
+		It("should return the expected rendered content", func() {
+			By("not returning any error")
+			Expect(*returnedErr).To(BeNil())
+			By("returning the expected result")
+			AssertPrettyDiff("bob", *returnedResult)
+		})
+	})
+	Context("when the abort statement is reached", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: "{{ user.name }}\n{% if user.admin is not defined %}{% abort 400 + 3, 'user ' ~ user.name ~ ' is not an admin' %}{% endif %}",
+			})
+		})
+		It("should return an error carrying the code", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("aborted with code 403: user bob is not an admin (/test, line 2, col 35)")))
+			var abort *exec.AbortError
+			Expect(errors.As(*returnedErr, &abort)).To(BeTrue())
+			Expect(*abort).To(Equal(exec.AbortError{
+				Code:       403,
+				Message:    "user bob is not an admin",
+				Identifier: "/test",
+				Line:       2,
+				Col:        35,
+			}))
+		})
+	})
+	Context("when no message is given", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% abort 404 %}`,
+			})
+		})
+		It("should default to the text of the HTTP status", func() {
+			var abort *exec.AbortError
+			Expect(errors.As(*returnedErr, &abort)).To(BeTrue())
+			Expect(abort.Code).To(Equal(404))
+			Expect(abort.Message).To(Equal("Not Found"))
+		})
+	})
+	Context("when the code is not an integer", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% abort "404" %}`,
+			})
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("abort code must be an integer, got 404")))
+		})
+	})
+	Context("when no code is given", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% abort %}`,
+			})
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("Tag 'abort' requires a code.")))
+		})
+	})
+	Context("when the environment does not allow the statement", func() {
+		BeforeEach(func() {
+			var err error
+			*environment, err = exec.Restrict(gonja.DefaultEnvironment, exec.Allowlist{ControlStructures: []string{"if"}})
+			Expect(err).To(BeNil())
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% abort 404 %}`,
+			})
+		})
+		It("should reject the template", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("control structure 'abort' is not allowed")))
+		})
+	})
+})
@@ -0,0 +1,96 @@
+package integration_test
+
+import (
+	"errors"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("control structure 'assert'", func() {
+	var (
+		identifier = new(string)
+
+		environment = new(*exec.Environment)
+		loader      = new(loaders.Loader)
+
+		context = new(*exec.Context)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*identifier = "/test"
+		*environment = gonja.DefaultEnvironment
+		*loader = loaders.MustNewMemoryLoader(nil)
+		*context = exec.NewContext(map[string]interface{}{
+			"user": map[string]interface{}{"name": "bob"},
+		})
+	})
+	JustBeforeEach(func() {
+		var t *exec.Template
+		t, *returnedErr = exec.NewTemplate(*identifier, gonja.DefaultConfig, *loader, *environment)
+		if *returnedErr != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(*context)
+	})
+	Context("when the condition holds", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% assert user.name is defined, "user.name is required" %}{{ user.name }}`,
+			})
+		})
+		It("should return the expected rendered content", func() {
+			By("not returning any error")
+			Expect(*returnedErr).To(BeNil())
+			By("returning the expected result")
+			AssertPrettyDiff("bob", *returnedResult)
+		})
+	})
+	Context("when the condition does not hold", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: "{{ user.name }}\n{% assert user.id is defined, \"user.id is required\" %}",
+			})
+		})
+		It("should return an error carrying the message and position of the statement", func() {
+			Expect(*returnedErr).ToNot(BeNil())
+			Expect((*returnedErr).Error()).To(ContainSubstring("user.id is required (/test, line 2, col 1)"))
+			failure := new(exec.FailError)
+			Expect(errors.As(*returnedErr, &failure)).To(BeTrue())
+			Expect(*failure).To(Equal(exec.FailError{Message: "user.id is required", Identifier: "/test", Line: 2, Col: 1}))
+		})
+	})
+	Context("when the statement is in an included template", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% include "/partial" %}`,
+				"/partial":  `{% assert user.age > 18 %}`,
+			})
+			(*context).Set("user", map[string]interface{}{"age": 12})
+		})
+		It("should return an error locating the statement in that template with a default message", func() {
+			Expect(*returnedErr).ToNot(BeNil())
+			failure := new(exec.FailError)
+			Expect(errors.As(*returnedErr, &failure)).To(BeTrue())
+			Expect(failure.Identifier).To(Equal("/partial"))
+			Expect(failure.Message).To(HavePrefix("assertion failed: "))
+		})
+	})
+	Context("when the statement is malformed", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% assert true, "a" "b" %}`,
+			})
+		})
+		It("should return a parsing error", func() {
+			Expect(*returnedErr).ToNot(BeNil())
+			Expect((*returnedErr).Error()).To(ContainSubstring("Malformed 'assert' tag args."))
+		})
+	})
+})
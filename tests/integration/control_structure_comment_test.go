@@ -0,0 +1,92 @@
+package integration_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("control structure 'comment'", func() {
+	var (
+		identifier = new(string)
+
+		environment = new(*exec.Environment)
+		loader      = new(loaders.Loader)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*identifier = "/test"
+		*environment = gonja.DefaultEnvironment
+		*loader = loaders.MustNewMemoryLoader(nil)
+	})
+	JustBeforeEach(func() {
+		var t *exec.Template
+		t, *returnedErr = exec.NewTemplate(*identifier, gonja.DefaultConfig, *loader, *environment)
+		if *returnedErr != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(exec.NewContext(map[string]interface{}{"name": "bob"}))
+	})
+	Context("when the body is not valid template syntax", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `Hello {% comment "disabled until the new layout ships" %}{% if %}{{ name | }}{% for %}{% endcomment %}{{ name }}`,
+			})
+		})
+		It("should skip the body entirely", func() {
+			By("not returning any error")
+			Expect(*returnedErr).To(BeNil())
+			By("returning the expected result")
+			AssertPrettyDiff("Hello bob", *returnedResult)
+		})
+	})
+	Context("when no note is given", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: "{%- comment -%}\n  {% include 'missing.html' %}\n{%- endcomment %}{{ name }}",
+			})
+		})
+		It("should skip the body as well", func() {
+			By("not returning any error")
+			Expect(*returnedErr).To(BeNil())
+			By("returning the expected result")
+			AssertPrettyDiff("bob", *returnedResult)
+		})
+	})
+	Context("when the body is empty", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `[{% comment %}{% endcomment %}]`,
+			})
+		})
+		It("should render nothing", func() {
+			Expect(*returnedErr).To(BeNil())
+			AssertPrettyDiff("[]", *returnedResult)
+		})
+	})
+	Context("when the note is not a string", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% comment name %}{% endcomment %}`,
+			})
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("Tag 'comment' only accepts an optional note string.")))
+		})
+	})
+	Context("when the comment is not closed", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% comment %}{{ name }}`,
+			})
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("Unexpected EOF, expected tag endcomment.")))
+		})
+	})
+})
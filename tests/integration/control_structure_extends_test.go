@@ -0,0 +1,74 @@
+package integration_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("control structure 'extends'", func() {
+	var (
+		identifier = new(string)
+
+		environment = new(*exec.Environment)
+		loader      = new(loaders.Loader)
+
+		context = new(*exec.Context)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*identifier = "/test"
+		*environment = gonja.DefaultEnvironment
+		*loader = loaders.MustNewMemoryLoader(nil)
+	})
+	JustBeforeEach(func() {
+		var t *exec.Template
+		t, *returnedErr = exec.NewTemplate(*identifier, gonja.DefaultConfig, *loader, *environment)
+		if *returnedErr != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(*context)
+	})
+	Context("when extending a chain of templates", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier:     "{% extends '/layouts/page' %}{% block content %}child{% endblock %}",
+				"/layouts/page": "{% extends '/layouts/base' %}{% block title %}page{% endblock %}",
+				"/layouts/base": "{% block title %}{% endblock %}: {% block content %}{% endblock %}",
+			})
+		})
+		It("should return the expected rendered content", func() {
+			By("not returning any error")
+			Expect(*returnedErr).To(BeNil())
+			By("returning the expected result")
+			AssertPrettyDiff("page: child", *returnedResult)
+		})
+	})
+	Context("when templates extend each other", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: "{% extends '/a' %}",
+				"/a":        "{% extends '/b' %}",
+				"/b":        "{% extends '/a' %}",
+			})
+		})
+		It("should return an error listing the inheritance chain", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("extends cycle detected: /a -> /b -> /a")))
+		})
+	})
+	Context("when a template extends itself", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: "{% extends '/test' %}",
+			})
+		})
+		It("should return an error listing the inheritance chain", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("extends cycle detected: /test -> /test")))
+		})
+	})
+})
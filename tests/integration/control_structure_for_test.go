@@ -0,0 +1,155 @@
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// cursor is an iterator over rows, like the cursors of database drivers
+type cursor struct {
+	rows   []map[string]interface{}
+	pulled int
+}
+
+func (c *cursor) Next() (map[string]interface{}, bool) {
+	if c.pulled == len(c.rows) {
+		return nil, false
+	}
+	c.pulled++
+	return c.rows[c.pulled-1], true
+}
+
+var _ = Context("control structure 'for' over streams", func() {
+	var (
+		source = new(string)
+		data   = new(map[string]interface{})
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*data = map[string]interface{}{}
+	})
+	JustBeforeEach(func() {
+		loader := loaders.MustNewMemoryLoader(map[string]string{"/test": *source})
+		var t *exec.Template
+		t, *returnedErr = exec.NewTemplate("/test", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+		if *returnedErr != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(exec.NewContext(*data))
+	})
+	Context("when iterating over a channel", func() {
+		BeforeEach(func() {
+			items := make(chan string, 3)
+			items <- "a"
+			items <- "b"
+			items <- "c"
+			close(items)
+			(*data)["items"] = items
+			*source = `{% for item in items %}{{ loop.index }}:{{ item }}{% if loop.first %}(first){% endif %}{% if loop.last %}(last){% endif %},{% endfor %}`
+		})
+		It("should render every item it receives once the channel is closed", func() {
+			Expect(*returnedErr).To(BeNil())
+			AssertPrettyDiff("1:a(first),2:b,3:c(last),", *returnedResult)
+		})
+		Context("when separating its items", func() {
+			BeforeEach(func() {
+				*source = `{% for x in items %}{{ x }}/{{ loop.length }}{% if not loop.last %},{% endif %}{% endfor %}`
+			})
+			It("should know its length and its last item", func() {
+				Expect(*returnedErr).To(BeNil())
+				AssertPrettyDiff("a/3,b/3,c/3", *returnedResult)
+			})
+		})
+	})
+	Context("when iterating over an iterator", func() {
+		var rows = new(*cursor)
+		BeforeEach(func() {
+			*rows = &cursor{rows: []map[string]interface{}{{"name": "ada"}, {"name": "bob"}, {"name": "eve"}}}
+			(*data)["rows"] = *rows
+			*source = `{% for row in rows if row.name != "bob" %}{% if loop.PrevItem %}{{ loop.PrevItem.name }}{% endif %}>{{ row.name }}>{% if loop.NextItem %}{{ loop.NextItem.name }}{% endif %};{% endfor %}`
+		})
+		It("should render the items it pulls, looking one item ahead", func() {
+			Expect(*returnedErr).To(BeNil())
+			AssertPrettyDiff(">ada>eve;ada>eve>;", *returnedResult)
+		})
+		Context("when separating its items", func() {
+			BeforeEach(func() {
+				*source = `{% for row in rows %}{{ row.name }}/{{ loop.length }}{% if not loop.last %},{% endif %}{% endfor %}`
+			})
+			It("should know its last item but not its length", func() {
+				Expect(*returnedErr).To(BeNil())
+				AssertPrettyDiff("ada/-1,bob/-1,eve/-1", *returnedResult)
+			})
+		})
+		Context("when breaking out of the loop", func() {
+			BeforeEach(func() {
+				*source = `{% for row in rows %}{{ row.name }}{% break %}{% endfor %}`
+			})
+			It("should stop pulling items", func() {
+				Expect(*returnedErr).To(BeNil())
+				AssertPrettyDiff("ada", *returnedResult)
+				Expect((*rows).pulled).To(Equal(2))
+			})
+		})
+	})
+	Context("when iterating over a range", func() {
+		BeforeEach(func() {
+			*source = `{% for i in range(3) %}{{ i }}/{{ loop.length }}/{{ loop.revindex }}{% if not loop.last %},{% endif %}{% endfor %}`
+		})
+		It("should know its length and its last item", func() {
+			Expect(*returnedErr).To(BeNil())
+			AssertPrettyDiff("0/3/3,1/3/2,2/3/1", *returnedResult)
+		})
+	})
+	Context("when the stream is empty", func() {
+		BeforeEach(func() {
+			items := make(chan int)
+			close(items)
+			(*data)["items"] = items
+			*source = `{% for item in items %}{{ item }}{% else %}empty{% endfor %}`
+		})
+		It("should render the else block", func() {
+			Expect(*returnedErr).To(BeNil())
+			AssertPrettyDiff("empty", *returnedResult)
+		})
+	})
+	Context("when filtering a stream", func() {
+		BeforeEach(func() {
+			items := make(chan int, 3)
+			items <- 3
+			items <- 1
+			items <- 2
+			close(items)
+			(*data)["items"] = items
+			*source = `{{ items | sort | join(",") }}`
+		})
+		It("should go through its items like the ones of a list", func() {
+			Expect(*returnedErr).To(BeNil())
+			AssertPrettyDiff("1,2,3", *returnedResult)
+		})
+	})
+	Context("when the render is canceled while waiting for an item", func() {
+		It("should stop waiting and return an error", func() {
+			items := make(chan int)
+			defer close(items)
+			loader := loaders.MustNewMemoryLoader(map[string]string{"/test": `{% for item in items %}{{ item }}{% endfor %}`})
+			t, err := exec.NewTemplate("/test", gonja.DefaultConfig, loader, gonja.DefaultEnvironment)
+			Expect(err).To(BeNil())
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err = t.ExecuteWithContext(ctx, new(strings.Builder), exec.NewContext(map[string]interface{}{"items": items}))
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,150 @@
+package integration_test
+
+import (
+	"errors"
+	"io"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// countingLoader counts the reads of the loader it wraps, and tells the versions of its files as it does
+type countingLoader struct {
+	loaders.Loader
+	reads   map[string]int
+	failing map[string]bool
+}
+
+func (c *countingLoader) Read(identifier string) (io.Reader, error) {
+	c.reads[identifier]++
+	if c.failing[identifier] {
+		return nil, errors.New("permission denied")
+	}
+	return c.Loader.Read(identifier)
+}
+
+func (c *countingLoader) Stat(identifier string) (string, error) {
+	return c.Loader.(loaders.StatLoader).Stat(identifier)
+}
+
+func (c *countingLoader) Inherit(from string) (loaders.Loader, error) {
+	inherited, err := c.Loader.Inherit(from)
+	if err != nil {
+		return nil, err
+	}
+	return &countingLoader{Loader: inherited, reads: c.reads, failing: c.failing}, nil
+}
+
+var _ = Context("control structure 'include_static'", func() {
+	var (
+		identifier = new(string)
+		files      = new(map[string]string)
+		renders    = new(int)
+		edits      = new(map[string]string)
+
+		reads   = new(map[string]int)
+		failing = new(map[string]bool)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*identifier = "/test"
+		*files = map[string]string{
+			"/icons/logo.svg": `<svg>{{ not a template }}{% raw %}</svg>`,
+			"/style.css":      `a > b { color: red; }`,
+		}
+		*renders = 1
+		*edits = map[string]string{}
+		*failing = map[string]bool{}
+	})
+	JustBeforeEach(func() {
+		*reads = map[string]int{}
+		loader := &countingLoader{Loader: loaders.MustNewMemoryLoader(*files), reads: *reads, failing: *failing}
+		var t *exec.Template
+		cfg := gonja.DefaultConfig.Inherit()
+		cfg.AutoEscape = true
+		t, *returnedErr = exec.NewTemplate(*identifier, cfg, loader, gonja.DefaultEnvironment)
+		if *returnedErr != nil {
+			return
+		}
+		for i := 0; i < *renders; i++ {
+			*returnedResult, *returnedErr = t.ExecuteToString(exec.NewContext(map[string]interface{}{"icon": "logo"}))
+			for identifier, content := range *edits {
+				(*files)[identifier] = content
+			}
+		}
+	})
+	Context("when the file is not valid template syntax", func() {
+		BeforeEach(func() {
+			(*files)[*identifier] = `<style>{% include_static "/style.css" %}</style>{% include_static "/icons/" ~ icon ~ ".svg" %}`
+		})
+		It("should include its content as is", func() {
+			By("not returning any error")
+			Expect(*returnedErr).To(BeNil())
+			By("returning the expected result")
+			AssertPrettyDiff(`<style>a > b { color: red; }</style><svg>{{ not a template }}{% raw %}</svg>`, *returnedResult)
+		})
+	})
+	Context("when the file is included several times over several renders", func() {
+		BeforeEach(func() {
+			*renders = 3
+			(*files)[*identifier] = `{% for i in range(3) %}{% include_static "style.css" %}{% endfor %}`
+		})
+		It("should read it once", func() {
+			Expect(*returnedErr).To(BeNil())
+			AssertPrettyDiff(`a > b { color: red; }a > b { color: red; }a > b { color: red; }`, *returnedResult)
+			Expect((*reads)["/style.css"]).To(Equal(1))
+		})
+	})
+	Context("when the file changes between renders", func() {
+		BeforeEach(func() {
+			*renders = 2
+			(*files)[*identifier] = `{% include_static "style.css" %}`
+			(*edits)["/style.css"] = `a > b { color: blue; }`
+		})
+		It("should read it again", func() {
+			Expect(*returnedErr).To(BeNil())
+			AssertPrettyDiff(`a > b { color: blue; }`, *returnedResult)
+			Expect((*reads)["/style.css"]).To(Equal(2))
+		})
+	})
+	Context("when the file does not exist", func() {
+		BeforeEach(func() {
+			(*files)[*identifier] = `{% include_static "missing.svg" %}`
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("failed to resolve filename: unknown resolved path: '/missing.svg'")))
+		})
+		Context("and missing files are ignored", func() {
+			BeforeEach(func() {
+				(*files)[*identifier] = `[{% include_static "missing.svg" ignore missing %}]`
+			})
+			It("should render nothing", func() {
+				Expect(*returnedErr).To(BeNil())
+				AssertPrettyDiff("[]", *returnedResult)
+			})
+		})
+	})
+	Context("when the file exists but cannot be read", func() {
+		BeforeEach(func() {
+			(*files)[*identifier] = `[{% include_static "style.css" ignore missing %}]`
+			(*failing)["/style.css"] = true
+		})
+		It("should return an error even though missing files are ignored", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("unable to load file '/style.css': permission denied")))
+		})
+	})
+	Context("when the arguments are malformed", func() {
+		BeforeEach(func() {
+			(*files)[*identifier] = `{% include_static "style.css" with context %}`
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("Malformed 'include_static'-tag args.")))
+		})
+	})
+})
@@ -0,0 +1,124 @@
+package integration_test
+
+import (
+	"errors"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("control structures 'break' and 'continue'", func() {
+	var (
+		identifier = new(string)
+		loader     = new(loaders.Loader)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*identifier = "/test"
+		*loader = loaders.MustNewMemoryLoader(nil)
+	})
+	JustBeforeEach(func() {
+		var t *exec.Template
+		t, *returnedErr = exec.NewTemplate(*identifier, gonja.DefaultConfig, *loader, gonja.DefaultEnvironment)
+		if *returnedErr != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(exec.NewContext(map[string]interface{}{
+			"items": []int{1, 2, 3, 4, 5},
+		}))
+	})
+	Context("when breaking out of a loop", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% for item in items %}{% if item > 3 %}{% break %}{% endif %}{{ item }}{% endfor %}`,
+			})
+		})
+		It("should stop the loop", func() {
+			Expect(*returnedErr).To(BeNil())
+			AssertPrettyDiff("123", *returnedResult)
+		})
+	})
+	Context("when continuing a loop", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% for item in items %}{% if item is even %}{% continue %}{% endif %}{{ item }}{% if not loop.last %},{% endif %}{% endfor %}`,
+			})
+		})
+		It("should skip the rest of the iteration", func() {
+			Expect(*returnedErr).To(BeNil())
+			AssertPrettyDiff("1,3,5", *returnedResult)
+		})
+	})
+	Context("when breaking out of a nested loop", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% for i in items[:2] %}{% for j in items %}{% if j > i %}{% break %}{% endif %}{{ i }}{{ j }} {% endfor %}{% endfor %}`,
+			})
+		})
+		It("should only stop the innermost loop", func() {
+			Expect(*returnedErr).To(BeNil())
+			AssertPrettyDiff("11 21 22 ", *returnedResult)
+		})
+	})
+	Context("when breaking out of a loop with an else block", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% for item in items %}{% break %}{% else %}empty{% endfor %}done`,
+			})
+		})
+		It("should not render the else block", func() {
+			Expect(*returnedErr).To(BeNil())
+			AssertPrettyDiff("done", *returnedResult)
+		})
+	})
+	Context("when used outside of any loop", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% continue %}`,
+			})
+		})
+		It("should fail to parse", func() {
+			Expect(errors.Is(*returnedErr, gonja.ErrSyntax)).To(BeTrue())
+			Expect(*returnedErr).To(MatchError(ContainSubstring("'continue' used outside of a loop")))
+		})
+	})
+	Context("when used in a macro called from a loop", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% for item in items %}{% macro stop() %}{% break %}{% endmacro %}{{ stop() }}{{ item }}{% endfor %}`,
+			})
+		})
+		It("should fail to parse", func() {
+			Expect(errors.Is(*returnedErr, gonja.ErrSyntax)).To(BeTrue())
+			Expect(*returnedErr).To(MatchError(ContainSubstring("'break' used outside of a loop")))
+		})
+	})
+	Context("when used in a template included from a loop", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% for item in items %}{% include "/partial" %}{{ item }}{% endfor %}`,
+				"/partial":  `{% break %}`,
+			})
+		})
+		It("should fail instead of stopping the loop", func() {
+			Expect(*returnedResult).To(BeEmpty())
+			Expect(*returnedErr).To(MatchError(ContainSubstring("'break' used outside of a loop")))
+		})
+	})
+	Context("when given arguments", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% for item in items %}{% break 2 %}{% endfor %}`,
+			})
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("Tag 'break' takes no argument.")))
+		})
+	})
+})
@@ -0,0 +1,98 @@
+package integration_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("control structure 'set'", func() {
+	var (
+		identifier = new(string)
+
+		environment = new(*exec.Environment)
+		loader      = new(loaders.Loader)
+
+		context = new(*exec.Context)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*identifier = "/test"
+		*environment = gonja.DefaultEnvironment
+		*loader = loaders.MustNewMemoryLoader(nil)
+		*context = exec.NewContext(map[string]interface{}{
+			"csrf_token": "secret",
+			"settings":   map[string]interface{}{"debug": false},
+		})
+		(*context).SetReadOnly("csrf_token", "settings")
+	})
+	JustBeforeEach(func() {
+		var t *exec.Template
+		t, *returnedErr = exec.NewTemplate(*identifier, gonja.DefaultConfig, *loader, *environment)
+		if *returnedErr != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(*context)
+	})
+	Context("when reading read-only variables", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% set other = csrf_token %}{{ other }} {{ settings.debug }}`,
+			})
+		})
+		It("should return the expected rendered content", func() {
+			By("not returning any error")
+			Expect(*returnedErr).To(BeNil())
+			By("returning the expected result")
+			AssertPrettyDiff("secret False", *returnedResult)
+		})
+	})
+	Context("when overwriting a read-only variable", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% set csrf_token = "forged" %}{{ csrf_token }}`,
+			})
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("unable to set 'csrf_token': variable is read-only")))
+		})
+	})
+	Context("when shadowing a read-only variable in a nested scope", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% for i in [1] %}{% set csrf_token = "forged" %}{% endfor %}`,
+			})
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("unable to set 'csrf_token': variable is read-only")))
+		})
+	})
+	Context("when modifying an item of a read-only variable", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% set settings["debug"] = true %}`,
+			})
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("unable to set 'settings': variable is read-only")))
+		})
+	})
+	Context("when the read-only variable is an environment global", func() {
+		BeforeEach(func() {
+			*environment = gonja.DefaultEnvironment.Overlay()
+			(*environment).Context.Set("site", "example.com")
+			(*environment).Context.SetReadOnly("site")
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% set site = "evil.com" %}`,
+			})
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("unable to set 'site': variable is read-only")))
+		})
+	})
+})
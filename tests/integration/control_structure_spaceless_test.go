@@ -0,0 +1,89 @@
+package integration_test
+
+import (
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("control structure 'spaceless'", func() {
+	var (
+		identifier = new(string)
+
+		environment = new(*exec.Environment)
+		loader      = new(loaders.Loader)
+
+		context = new(*exec.Context)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+	)
+	BeforeEach(func() {
+		*identifier = "/test"
+		*environment = gonja.DefaultEnvironment
+		*loader = loaders.MustNewMemoryLoader(nil)
+		*context = exec.NewContext(map[string]interface{}{
+			"items": []string{"a", "b"},
+		})
+	})
+	JustBeforeEach(func() {
+		var t *exec.Template
+		t, *returnedErr = exec.NewTemplate(*identifier, gonja.DefaultConfig, *loader, *environment)
+		if *returnedErr != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(*context)
+	})
+	Context("when wrapping HTML", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: "<div>\n{% spaceless %}\n  <table>\n    {% for item in items %}\n    <tr>\n      <td> {{ item }} </td>\n    </tr>\n    {% endfor %}\n  </table>\n{% endspaceless %}\n</div>",
+			})
+		})
+		It("should remove the whitespace between tags only", func() {
+			By("not returning any error")
+			Expect(*returnedErr).To(BeNil())
+			By("returning the expected result")
+			AssertPrettyDiff("<div>\n<table><tr><td> a </td></tr><tr><td> b </td></tr></table>\n</div>", *returnedResult)
+		})
+	})
+	Context("when wrapping text", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: "{% spaceless %}  <p>Hello  world</p>\n<p>!</p>  {% endspaceless %}",
+			})
+		})
+		It("should keep the whitespace of the text", func() {
+			By("not returning any error")
+			Expect(*returnedErr).To(BeNil())
+			By("returning the expected result")
+			AssertPrettyDiff("<p>Hello  world</p><p>!</p>", *returnedResult)
+		})
+	})
+	Context("when a loop iteration is skipped or stopped within the statement", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: `{% for item in ["a", "b", "c"] %}{% spaceless %} <b>{{ item }}</b> {% if item == "a" %}{% continue %}{% elif item == "c" %}{% break %}{% endif %}<i>next</i> {% endspaceless %}{% endfor %}`,
+			})
+		})
+		It("should keep what was rendered before", func() {
+			By("not returning any error")
+			Expect(*returnedErr).To(BeNil())
+			By("returning the expected result")
+			AssertPrettyDiff("<b>a</b><b>b</b><i>next</i><b>c</b>", *returnedResult)
+		})
+	})
+	Context("when given arguments", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				*identifier: "{% spaceless true %}<p></p>{% endspaceless %}",
+			})
+		})
+		It("should return an error", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("Tag 'spaceless' does not take any argument.")))
+		})
+	})
+})
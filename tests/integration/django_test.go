@@ -0,0 +1,123 @@
+package integration_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/builtins"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type djangoResolver struct{}
+
+func (djangoResolver) ResolveURL(name string, args []interface{}, kwargs map[string]interface{}) (string, error) {
+	if name == "unknown" {
+		return "", fmt.Errorf("no view named '%s'", name)
+	}
+	return fmt.Sprintf("/%s/%v?page=%v", name, args, kwargs["page"]), nil
+}
+
+func (djangoResolver) ResolveStatic(path string) (string, error) {
+	return "/static/" + path, nil
+}
+
+var _ = Context("django", func() {
+	var (
+		identifier = new(string)
+
+		environment = new(*exec.Environment)
+		loader      = new(loaders.Loader)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+		shouldRender   = func(template, result string) {
+			Context(template, func() {
+				BeforeEach(func() {
+					*loader = loaders.MustNewMemoryLoader(map[string]string{
+						*identifier: template,
+					})
+				})
+				It("should return the expected rendered content", func() {
+					By("not returning any error")
+					Expect(*returnedErr).To(BeNil())
+					By("returning the expected result")
+					AssertPrettyDiff(result, *returnedResult)
+				})
+			})
+		}
+		shouldFail = func(template, err string) {
+			Context(template, func() {
+				BeforeEach(func() {
+					*loader = loaders.MustNewMemoryLoader(map[string]string{
+						*identifier: template,
+					})
+				})
+				It("should return the expected error", func() {
+					Expect(*returnedErr).ToNot(BeNil())
+					Expect((*returnedErr).Error()).To(MatchRegexp(err))
+				})
+			})
+		}
+	)
+	BeforeEach(func() {
+		*identifier = "/test"
+		*environment = builtins.Django(gonja.DefaultEnvironment, djangoResolver{}, djangoResolver{})
+		(*environment).Context.Set("posted", time.Date(2024, time.March, 1, 13, 5, 9, 0, time.UTC))
+		(*environment).Context.Set("midnight", time.Date(2024, time.September, 22, 0, 0, 0, 0, time.UTC))
+		*loader = loaders.MustNewMemoryLoader(nil)
+	})
+	JustBeforeEach(func() {
+		var t *exec.Template
+		t, *returnedErr = exec.NewTemplate(*identifier, gonja.DefaultConfig, *loader, *environment)
+		if *returnedErr != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(nil)
+	})
+	It("should not alter the default environment", func() {
+		Expect(gonja.DefaultEnvironment.Filters.Exists("yesno")).To(BeFalse())
+		Expect(gonja.DefaultEnvironment.ControlStructures.Exists("url")).To(BeFalse())
+	})
+	Context("load", func() {
+		shouldRender("{% load static humanize %}loaded", "loaded")
+		shouldFail("{% load 'static' %}", "Expected the name of a library")
+	})
+	Context("url", func() {
+		shouldRender("{% url 'article' 3 'draft' page=2 %}", "/article/[3 draft]?page=2")
+		shouldRender("{% url 'article' 3 as link %}[{{ link }}]", "[/article/[3]?page=<nil>]")
+		shouldFail("{% url 'article' page=2 3 %}", "Positional arguments cannot follow keyword arguments")
+		shouldFail("{% url 'unknown' %}", "unable to resolve URL of view 'unknown': no view named 'unknown'")
+	})
+	Context("static", func() {
+		shouldRender("{% static 'css/main.css' %}", "/static/css/main.css")
+		shouldRender("{% static 'css/' ~ 'main.css' as stylesheet %}{{ stylesheet }}", "/static/css/main.css")
+		shouldFail("{% static 'main.css' 'extra' %}", "Malformed 'static' tag args")
+	})
+	Context("date", func() {
+		shouldRender("{{ posted | date }}", "March 1, 2024")
+		shouldRender(`{{ posted | date("D, d M Y H:i:s") }}`, "Fri, 01 Mar 2024 13:05:09")
+		shouldRender(`{{ posted | date("l jS \\o\\f F y, g:i A") }}`, "Friday 1st of March 24, 1:05 PM")
+		shouldRender(`{{ posted | date("Y-m-d") }}|{{ none | date }}|{{ "" | date }}`, "2024-03-01||")
+		shouldFail(`{{ "yesterday" | date }}`, "invalid call to filter 'date': yesterday is not a RFC3339 date")
+	})
+	Context("time", func() {
+		shouldRender("{{ posted | time }}", "1:05 p.m.")
+		shouldRender("{{ midnight | time }}", "midnight")
+		shouldRender(`{{ posted | time("H:i") }}`, "13:05")
+	})
+	Context("yesno", func() {
+		shouldRender("{{ true | yesno }} {{ false | yesno }} {{ none | yesno }}", "yes no maybe")
+		shouldRender(`{{ true | yesno("on,off") }} {{ none | yesno("on,off") }}`, "on off")
+		shouldRender(`{{ 1 | yesno("single") }}`, "1")
+	})
+	Context("pluralize", func() {
+		shouldRender("vote{{ 1 | pluralize }} vote{{ 2 | pluralize }} vote{{ 0 | pluralize }}", "vote votes votes")
+		shouldRender(`class{{ [1, 2] | pluralize("es") }} cherr{{ ["a"] | pluralize("y,ies") }}`, "classes cherry")
+		shouldRender(`{{ "1" | pluralize("y,ies") }}{{ "many" | pluralize }}{{ 2 | pluralize("a,b,c") }}`, "y")
+	})
+})
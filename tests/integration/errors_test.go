@@ -0,0 +1,122 @@
+package integration_test
+
+import (
+	"errors"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("errors", func() {
+	var (
+		identifier = new(string)
+
+		configuration = new(*config.Config)
+		loader        = new(loaders.Loader)
+
+		returnedErr = new(error)
+	)
+	BeforeEach(func() {
+		*identifier = "/page"
+		*configuration = gonja.DefaultConfig
+		*loader = loaders.MustNewMemoryLoader(map[string]string{
+			"/page":    "{{ user.name }}\n{% include '/partial' %}",
+			"/partial": "partial",
+		})
+	})
+	JustBeforeEach(func() {
+		var t *exec.Template
+		t, *returnedErr = exec.NewTemplate(*identifier, *configuration, *loader, gonja.DefaultEnvironment)
+		if *returnedErr != nil {
+			return
+		}
+		_, *returnedErr = t.ExecuteToString(exec.NewContext(map[string]interface{}{
+			"user": map[string]interface{}{"name": "bob"},
+		}))
+	})
+	It("should not return any error", func() {
+		Expect(*returnedErr).To(BeNil())
+	})
+	Context("when the template does not exist", func() {
+		BeforeEach(func() {
+			*identifier = "/missing"
+		})
+		It("should return an error matching ErrTemplateNotFound", func() {
+			Expect(errors.Is(*returnedErr, gonja.ErrTemplateNotFound)).To(BeTrue())
+			var located *gonja.Error
+			Expect(errors.As(*returnedErr, &located)).To(BeTrue())
+			Expect(located.Identifier).To(Equal("/missing"))
+			Expect([]int{located.Line, located.Col}).To(Equal([]int{0, 0}))
+		})
+	})
+	Context("when an extended template does not exist", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				"/page": "{# layout #}\n{% extends '/missing' %}",
+			})
+		})
+		It("should return an error matching ErrTemplateNotFound located at the extends statement", func() {
+			Expect(errors.Is(*returnedErr, gonja.ErrTemplateNotFound)).To(BeTrue())
+			Expect(errors.Is(*returnedErr, gonja.ErrSyntax)).To(BeFalse())
+			var located *gonja.Error
+			Expect(errors.As(*returnedErr, &located)).To(BeTrue())
+			Expect(located.Identifier).To(Equal("/page"))
+			Expect([]int{located.Line, located.Col}).To(Equal([]int{2, 12}))
+		})
+	})
+	Context("when an included template does not exist", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				"/page":  "{{ user.name }}\n{% include '/missing' %}",
+				"/other": "other",
+			})
+		})
+		It("should return an error matching ErrTemplateNotFound", func() {
+			Expect(errors.Is(*returnedErr, gonja.ErrTemplateNotFound)).To(BeTrue())
+			Expect(errors.Is(*returnedErr, gonja.ErrSyntax)).To(BeFalse())
+			var located *gonja.Error
+			Expect(errors.As(*returnedErr, &located)).To(BeTrue())
+			Expect(located.Identifier).To(Equal("/page"))
+			Expect(located.Line).To(Equal(2))
+			Expect(located.Col).To(Equal(1))
+		})
+	})
+	Context("when an extended template has an invalid syntax", func() {
+		BeforeEach(func() {
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				"/page":   "{% extends '/layout' %}",
+				"/layout": "<html>\n{{ title }}{% endif %}",
+			})
+		})
+		It("should return an error matching ErrSyntax located in the extended template", func() {
+			Expect(errors.Is(*returnedErr, gonja.ErrSyntax)).To(BeTrue())
+			var located *gonja.Error
+			Expect(errors.As(*returnedErr, &located)).To(BeTrue())
+			Expect(located.Identifier).To(Equal("/layout"))
+			Expect([]int{located.Line, located.Col}).To(Equal([]int{2, 15}))
+		})
+	})
+	Context("when an undefined variable is looked up strictly", func() {
+		BeforeEach(func() {
+			*configuration = gonja.DefaultConfig.Inherit()
+			(*configuration).StrictUndefined = true
+			*loader = loaders.MustNewMemoryLoader(map[string]string{
+				"/page":    "{{ user.name }}\n{% include '/partial' %}",
+				"/partial": "{{ user.email }}",
+			})
+		})
+		It("should return an error matching ErrUndefined located in the included template", func() {
+			Expect(*returnedErr).To(MatchError(ContainSubstring("attribute 'email' not found")))
+			Expect(errors.Is(*returnedErr, gonja.ErrUndefined)).To(BeTrue())
+			var located *gonja.Error
+			Expect(errors.As(*returnedErr, &located)).To(BeTrue())
+			Expect(located.Identifier).To(Equal("/partial"))
+			Expect([]int{located.Line, located.Col}).To(Equal([]int{1, 8}))
+		})
+	})
+})
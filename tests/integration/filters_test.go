@@ -2,6 +2,7 @@ package integration_test
 
 import (
 	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/builtins"
 	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/loaders"
 
@@ -81,4 +82,221 @@ var _ = Context("filters", func() {
 		shouldRender(`{{ "" | default("default_value", true) }}`, "default_value")
 		shouldRender(`{{ "is_true" | default("default_value", true) }}`, "is_true")
 	})
+	Context("basename", func() {
+		shouldRender("{{ '/etc/foo.txt' | basename }}", "foo.txt")
+		shouldRender("{{ '/etc/' | basename }}", "")
+		shouldRender("{{ 'foo' | basename }}", "foo")
+		shouldFail("{{ 42 | basename }}", "invalid call to filter 'basename': 42 is not a string")
+	})
+	Context("dirname", func() {
+		shouldRender("{{ '/etc/foo.txt' | dirname }}", "/etc")
+		shouldRender("{{ '/foo.txt' | dirname }}", "/")
+		shouldRender("{{ 'foo.txt' | dirname }}", "")
+		shouldFail("{{ 42 | dirname }}", "invalid call to filter 'dirname': 42 is not a string")
+	})
+	Context("splitext", func() {
+		shouldRender("{{ '/srv/archive.tar.gz' | splitext }}", "['/srv/archive.tar', '.gz']")
+		shouldRender("{{ '.bashrc' | splitext }}", "['.bashrc', '']")
+		shouldRender("{{ 'dir.d/file' | splitext }}", "['dir.d/file', '']")
+		shouldFail("{{ True | splitext }}", "invalid call to filter 'splitext': True is not a string")
+	})
+	Context("expanduser", func() {
+		shouldRender("{{ '/no/tilde' | expanduser }}", "/no/tilde")
+		shouldFail("{{ 42 | expanduser }}", "invalid call to filter 'expanduser': 42 is not a string")
+	})
+	Context("realpath", func() {
+		shouldRender("{{ '/../tmp/..' | realpath }}", "/")
+		shouldFail("{{ 42 | realpath }}", "invalid call to filter 'realpath': 42 is not a string")
+	})
+	Context("relpath", func() {
+		shouldRender("{{ '/etc/ssh/sshd_config' | relpath('/etc') }}", "ssh/sshd_config")
+		shouldRender("{{ '/etc' | relpath(start='/etc/ssh') }}", "..")
+		shouldFail("{{ '/etc' | relpath(start=42) }}", "invalid call to filter 'relpath': failed to validate argument 'start': 42 is not a string")
+	})
+	Context("win_basename", func() {
+		shouldRender(`{{ "C:\\Users\\me\\notes.txt" | win_basename }}`, "notes.txt")
+		shouldRender(`{{ "C:/Users/me" | win_basename }}`, "me")
+		shouldFail("{{ 42 | win_basename }}", "invalid call to filter 'win_basename': 42 is not a string")
+	})
+	Context("win_dirname", func() {
+		shouldRender(`{{ "C:\\Users\\me\\notes.txt" | win_dirname }}`, `C:\Users\me`)
+		shouldRender(`{{ "C:\\notes.txt" | win_dirname }}`, `C:\`)
+		shouldFail("{{ 42 | win_dirname }}", "invalid call to filter 'win_dirname': 42 is not a string")
+	})
+	Context("strftime", func() {
+		shouldRender(`{{ 1700000000 | strftime("%Y-%m-%d %H:%M:%S") }}`, "2023-11-14 22:13:20")
+		shouldRender(`{{ 1700000000.5 | strftime("%S.%f") }}`, "20.500000")
+		shouldRender(`{{ "2023-11-14T22:13:20Z" | strftime("%a %A %b %B %d %e %j %y %p %I") }}`, "Tue Tuesday Nov November 14 14 318 23 PM 10")
+		shouldRender(`{{ "2024-01-07T00:00:00Z" | strftime("%u %w %U %W %G-W%V %s %%") }}`, "7 0 01 01 2024-W01 1704585600 %")
+		shouldRender(`[{{ "2023-11-14T09:05:00Z" | strftime("%l|%k") }}] [{{ "2023-11-14T22:13:20Z" | strftime("%l|%k") }}] [{{ "2023-11-14T00:00:00Z" | strftime("%l|%k") }}]`, "[ 9| 9] [10|22] [12| 0]")
+		shouldRender(`{{ 1700000000 | strftime("%H:%M %Z", tz="Europe/Paris") }}`, "23:13 CET")
+		shouldFail(`{{ 1700000000 | strftime }}`, "invalid call to filter 'strftime': missing required 1st positional argument 'format'")
+		shouldFail(`{{ 1700000000 | strftime("%Q") }}`, "invalid call to filter 'strftime': unsupported directive '%Q'")
+		shouldFail(`{{ 1700000000 | strftime("%Y", tz="Nowhere/Land") }}`, "invalid call to filter 'strftime': unknown timezone 'Nowhere/Land'")
+		shouldFail(`{{ "yesterday" | strftime("%Y") }}`, "invalid call to filter 'strftime': yesterday is not a RFC3339 date")
+		shouldFail(`{{ [] | strftime("%Y") }}`, `invalid call to filter 'strftime': \[\] is not a date`)
+	})
+	Context("to_datetime", func() {
+		shouldRender(`{{ "2024-03-01" | to_datetime | strftime("%d/%m/%Y") }}`, "01/03/2024")
+		shouldRender(`{{ "01/03/24 13:37" | to_datetime(format="%d/%m/%y %H:%M") | strftime("%Y-%m-%dT%H:%M") }}`, "2024-03-01T13:37")
+		shouldRender(`{{ "2024-03-01" | to_datetime < "2024-03-02" | to_datetime }}`, "True")
+		shouldRender(`{{ "2024-03-01" | to_datetime >= "2024-03-02" | to_datetime }}`, "False")
+		shouldRender(`{{ "2024-03-01" | to_datetime == "2024-03-01T00:00:00Z" | to_datetime("%Y-%m-%dT%H:%M:%SZ") }}`, "True")
+		shouldRender(`{{ ("2024-03-01" | to_datetime - "2024-02-01" | to_datetime).Hours() }}`, "696.0")
+		shouldFail(`{{ "yesterday" | to_datetime }}`, "invalid call to filter 'to_datetime': 'yesterday' does not match format '%Y-%m-%d'")
+		shouldFail(`{{ "2024" | to_datetime("%Y %Q") }}`, "invalid call to filter 'to_datetime': unsupported directive '%Q'")
+		shouldFail(`{{ 42 | to_datetime }}`, "invalid call to filter 'to_datetime': 42 is not a string")
+	})
+	Context("to_duration", func() {
+		shouldRender(`{{ "1h30m" | to_duration }}`, "1h30m0s")
+		shouldRender(`{{ 90 | to_duration }}`, "1m30s")
+		shouldRender(`{{ "1h" | to_duration > "59m" | to_duration }}`, "True")
+		shouldRender(`{{ ("2024-03-01" | to_datetime + "36h" | to_duration) | strftime("%Y-%m-%d %H:%M") }}`, "2024-03-02 12:00")
+		shouldRender(`{{ ("2024-03-01" | to_datetime - "1h" | to_duration) | strftime("%Y-%m-%d %H:%M") }}`, "2024-02-29 23:00")
+		shouldFail(`{{ "tomorrow" | to_duration }}`, "invalid call to filter 'to_duration': 'tomorrow' is not a valid duration")
+		shouldFail(`{{ [] | to_duration }}`, `invalid call to filter 'to_duration': \[\] is not a string nor a number`)
+	})
+	Context("humanize", func() {
+		shouldRender(`{{ 1234567 | intcomma }}`, "1,234,567")
+		shouldRender(`{{ -1234.5 | intcomma }}`, "-1,234.5")
+		shouldRender(`{{ 123 | intcomma }}`, "123")
+		shouldRender(`{{ 1 | ordinal }} {{ 2 | ordinal }} {{ 3 | ordinal }} {{ 11 | ordinal }} {{ 22 | ordinal }}`, "1st 2nd 3rd 11th 22nd")
+		shouldRender(`{{ 3000 | naturalsize }}`, "3.0 kB")
+		shouldRender(`{{ 3000 | naturalsize(binary=True) }}`, "2.9 KiB")
+		shouldRender(`{{ (now() - timedelta(hours=3)) | naturaltime }}`, "3 hours ago")
+		shouldRender(`{{ (now() + timedelta(days=2, minutes=1)) | naturaltime }}`, "2 days from now")
+		shouldRender(`{{ now() | naturaldate }}`, "today")
+		shouldRender(`{{ (now() - timedelta(days=1)) | naturaldate }}`, "yesterday")
+		shouldRender(`{{ (now() + timedelta(days=1)) | naturaldate }}`, "tomorrow")
+		shouldRender(`{{ "2001-02-03T04:05:06Z" | naturaldate }}`, "Feb 03 2001")
+		shouldFail(`{{ "foo" | intcomma }}`, "invalid call to filter 'intcomma': foo is not a number")
+		shouldFail(`{{ 1.5 | ordinal }}`, "invalid call to filter 'ordinal': 1.5 is not an integer")
+		shouldFail(`{{ -1 | naturalsize }}`, "invalid call to filter 'naturalsize': -1 is not a positive number")
+		shouldFail(`{{ 42 | intcomma(locale="xx") }}`, "invalid call to filter 'intcomma': unknown locale 'xx'")
+	})
+	Context("humanize with a custom locale", func() {
+		BeforeEach(func() {
+			french := *builtins.HumanizeLocales["en"]
+			french.ThousandsSeparator = " "
+			french.DecimalSeparator = ","
+			french.Today = "aujourd'hui"
+			builtins.HumanizeLocales["fr"] = &french
+			DeferCleanup(func() { delete(builtins.HumanizeLocales, "fr") })
+		})
+		shouldRender(`{{ 1234567.25 | intcomma(locale="fr") }}`, "1 234 567,25")
+		shouldRender(`{{ 3000 | naturalsize(locale="fr") }}`, "3,0 kB")
+		shouldRender(`{{ now() | naturaldate(locale="fr") }}`, "aujourd'hui")
+	})
+	Context("forceescape", func() {
+		shouldRender(`{{ "<b>" | forceescape }}`, "&lt;b&gt;")
+		shouldRender(`{{ "&lt;b&gt;" | safe | forceescape }}`, "&amp;lt;b&amp;gt;")
+		shouldRender(`{{ "<b>" | forceescape is escaped }}`, "True")
+	})
+	Context("type_debug", func() {
+		BeforeEach(func() {
+			*context = exec.NewContext(map[string]interface{}{
+				"ports":    []int{80, 443},
+				"mixed":    []interface{}{1, "a", nil},
+				"settings": map[string]interface{}{"debug": true, "hosts": []string{"a"}},
+				"any":      map[interface{}]string{1: "a", "b": "c"},
+				"empty":    []interface{}{},
+			})
+		})
+		AfterEach(func() {
+			*context = nil
+		})
+		shouldRender(`{{ 1 | type_debug }} {{ 1.5 | type_debug }} {{ "s" | type_debug }} {{ none | type_debug }}`, "int float64 string nil")
+		shouldRender(`{{ ports | type_debug }}`, "[]int")
+		shouldRender(`{{ mixed | type_debug }}`, "[]interface {} (elements: int, nil, string)")
+		shouldRender(`{{ settings | type_debug }}`, "map[string]interface {} (values: []string, bool)")
+		shouldRender(`{{ any | type_debug }}`, "map[interface {}]string (keys: int, string)")
+		shouldRender(`{{ empty | type_debug }}`, "[]interface {}")
+		shouldRender(`{{ {"a": 1, "b": [1]} | type_debug }}`, "*exec.Dict (values: exec.ValuesList, int)")
+		shouldFail(`{{ 1 | type_debug(2) }}`, "Wrong signature for 'type_debug'")
+	})
+	Context("bool", func() {
+		shouldRender(`{{ "Yes" | bool }} {{ "on" | bool }} {{ "TRUE" | bool }} {{ "y" | bool }} {{ "1" | bool }} {{ " true " | bool }}`, "True True True True True True")
+		shouldRender(`{{ "No" | bool }} {{ "off" | bool }} {{ "False" | bool }} {{ "n" | bool }} {{ "0" | bool }}`, "False False False False False")
+		shouldRender(`{{ 1 | bool }} {{ 2 | bool }} {{ 0 | bool }} {{ 0.5 | bool }} {{ "2" | bool }} {{ "0.0" | bool }}`, "True True False True True False")
+		shouldRender(`{{ true | bool }} {{ "maybe" | bool }} {{ "" | bool }} {{ none | bool }} {{ missing | bool }}`, "True False False False False")
+		shouldRender(`{{ "yes" | bool(strict=true) }} {{ 0 | bool(strict=true) }}`, "True False")
+		shouldFail(`{{ "maybe" | bool(strict=true) }}`, "invalid call to filter 'bool': maybe is not a boolean")
+		shouldFail(`{{ missing | bool(strict=true) }}`, "invalid call to filter 'bool': None is not a boolean")
+	})
+	Context("extract", func() {
+		BeforeEach(func() {
+			*context = exec.NewContext(map[string]interface{}{
+				"hostvars": map[string]interface{}{
+					"web1": map[string]interface{}{"ip": "10.0.0.1", "interfaces": []interface{}{map[string]interface{}{"name": "eth0"}}},
+					"web2": map[string]interface{}{"ip": "10.0.0.2", "interfaces": []interface{}{map[string]interface{}{"name": "ens3"}}},
+				},
+				"hosts": []string{"web1", "web2"},
+			})
+		})
+		AfterEach(func() {
+			*context = nil
+		})
+		shouldRender(`{{ "web1" | extract(hostvars) | length }}`, "2")
+		shouldRender(`{{ hosts | map("extract", hostvars, "ip") | join(",") }}`, "10.0.0.1,10.0.0.2")
+		shouldRender(`{{ hosts | map("extract", hostvars, ["interfaces", 0, "name"]) | join(",") }}`, "eth0,ens3")
+		shouldRender(`{{ [0, 2] | map("extract", ["a", "b", "c"]) | join(",") }}`, "a,c")
+		shouldRender(`{{ "web3" | extract(hostvars) is none }} {{ "web1" | extract(hostvars, morekeys="missing") is none }}`, "True True")
+		shouldFail(`{{ "web1" | extract }}`, "invalid call to filter 'extract': missing required 1st positional argument 'container'")
+	})
+	Context("urlize", func() {
+		shouldRender(`{{ "see https://example.com now" | urlize }}`, `see <a href="https://example.com" rel="noopener">https://example.com</a> now`)
+		shouldRender(`{{ "https://example.com" | urlize(nofollow=true, target="_blank") }}`, `<a href="https://example.com" rel="nofollow noopener" target="_blank">https://example.com</a>`)
+		shouldRender(`{{ "https://example.com" | urlize(rel="external", nofollow=true) }}`, `<a href="https://example.com" rel="external nofollow noopener">https://example.com</a>`)
+		shouldRender(`{{ "get ftp://files.example.com/a.txt or write to mailto:me@example.com" | urlize(extra_schemes=["ftp://", "mailto:"]) }}`, `get <a href="ftp://files.example.com/a.txt" rel="noopener">ftp://files.example.com/a.txt</a> or write to <a href="mailto:me@example.com" rel="noopener">mailto:me@example.com</a>`)
+		shouldRender(`{{ "ftp:" | urlize(extra_schemes=["ftp:"]) }}`, `ftp:`)
+		shouldRender(`{% autoescape true %}{{ "1 < 2, see www.example.com & me@example.com" | urlize }}{% endautoescape %}`, `1 &lt; 2, see <a href="http://www.example.com" rel="noopener">www.example.com</a> &amp; <a href="mailto:me@example.com">me@example.com</a>`)
+		shouldRender(`{% autoescape true %}{{ "<b>bold</b> www.example.com" | safe | urlize }}{% endautoescape %}`, `<b>bold</b> <a href="http://www.example.com" rel="noopener">www.example.com</a>`)
+		shouldRender(`{{ "a < b" | urlize is escaped }}`, "True")
+		shouldFail(`{{ "text" | urlize(extra_schemes=["not a scheme"]) }}`, "invalid call to filter 'urlize': 'not a scheme' is not a valid URI scheme prefix")
+		shouldFail(`{{ "text" | urlize(extra_schemes="ftp:") }}`, "invalid call to filter 'urlize': extra_schemes must be a list of URI scheme prefixes, got ftp:")
+	})
+	Context("nl2br", func() {
+		shouldRender(`{{ "first line\nsecond line\n\n\nnew paragraph" | nl2br }}`, "<p>first line<br>\nsecond line</p>\n\n<p>new paragraph</p>")
+		shouldRender(`{{ "a\r\nb\r\n\r\nc" | nl2br }}`, "<p>a<br>\nb</p>\n\n<p>c</p>")
+		shouldRender(`{{ "\n\n" | nl2br }}`, "")
+		shouldRender(`{% autoescape true %}{{ "<b>Tom & Jerry</b>\nsays hi" | nl2br }}{% endautoescape %}`, "<p>&lt;b&gt;Tom &amp; Jerry&lt;/b&gt;<br>\nsays hi</p>")
+		shouldRender(`{% autoescape true %}{{ "<b>bold</b>\nline" | safe | nl2br }}{% endautoescape %}`, "<p><b>bold</b><br>\nline</p>")
+		shouldRender(`{{ "a\nb" | nl2br is escaped }}`, "True")
+		shouldFail(`{{ "a" | nl2br(1) }}`, "invalid call to filter 'nl2br'")
+	})
+	Context("ternary", func() {
+		shouldRender(`{{ true | ternary("yes", "no") }} {{ 0 | ternary("yes", "no") }} {{ none | ternary("yes", "no") }}`, "yes no no")
+		shouldRender(`{{ none | ternary("yes", "no", "null") }} {{ false | ternary("yes", "no", "null") }} {{ none | ternary("yes", "no", none_val="null") }}`, "null no null")
+		shouldRender(`{{ missing | ternary("yes", "no", "undefined") }}`, "undefined")
+		shouldRender(`{{ none | ternary("yes", "no", none) is none }}`, "True")
+		shouldRender(`{{ (replicas > 1) | ternary(["a", "b"], "a") | join(",") }}`, "a")
+		shouldFail(`{{ true | ternary("yes") }}`, "missing required 2nd positional argument 'false_val'")
+	})
+	Context("mandatory", func() {
+		shouldRender(`{{ "value" | mandatory }} {{ None | mandatory is none }}`, "value True")
+		shouldFail(`{{ missing | mandatory }}`, "Mandatory variable not defined.")
+		shouldFail(`{{ missing | mandatory(msg="missing is required") }}`, "missing is required")
+	})
+	Context("default(omit)", func() {
+		shouldRender(`{{ {"host": "localhost", "port": port | default(omit)} | tojson }}`, `{"host":"localhost"}`)
+		shouldRender(`{{ {"host": "localhost", "port": 8080 | default(omit)} | tojson }}`, `{"host":"localhost","port":8080}`)
+		shouldRender(`{{ ["a", missing | default(omit), "b"] | join(",") }}`, "a,b")
+		shouldRender(`{{ dict(a=1, b=missing | default(omit)) | length }}`, "1")
+		shouldRender(`{% macro port(value=80) %}{{ value }}{% endmacro %}{{ port(value=missing | default(omit)) }}`, "80")
+		shouldRender(`{{ "a,b" | replace(",", ";", count=missing | default(omit)) }}`, "a;b")
+		shouldRender(`[{{ omit }}]`, "[]")
+	})
+	Context("collation", func() {
+		shouldRender(`{{ ["zèbre", "Öl", "Émile", "avion", "Zoé"] | sort | join(",") }}`, "avion,Zoé,zèbre,Émile,Öl")
+		shouldRender(`{{ ["zèbre", "Öl", "Émile", "avion", "Zoé"] | sort(locale="fr") | join(",") }}`, "avion,Émile,Öl,zèbre,Zoé")
+		shouldRender(`{{ ["zèbre", "Öl", "Émile", "avion", "Zoé"] | sort(locale="sv") | join(",") }}`, "avion,Émile,zèbre,Zoé,Öl")
+		shouldRender(`{{ ["zèbre", "Öl", "Émile", "avion", "Zoé"] | sort(locale="fr", reverse=true) | join(",") }}`, "Zoé,zèbre,Öl,Émile,avion")
+		shouldRender(`{{ ["b", "B", "a"] | sort(locale="en", case_sensitive=true) | join(",") }}`, "a,b,B")
+		shouldRender(`{{ [3, 10, 1] | sort(locale="fr") | join(",") }}`, "1,3,10")
+		shouldRender(`{% for key, value in {"Öl": 1, "zèbre": 2, "Émile": 3} | dictsort(locale="de") %}{{ key }}={{ value }} {% endfor %}`, "Émile=3 Öl=1 zèbre=2 ")
+		shouldRender(`{% for key, value in {"a": "Öl", "b": "zèbre", "c": "Émile"} | dictsort(by="value", locale="de") %}{{ key }} {% endfor %}`, "c a b ")
+		shouldRender(`{% for group in [{"c": "Öl"}, {"c": "zèbre"}, {"c": "Émile"}, {"c": "Öl"}] | groupby("c", locale="fr") %}{{ group.grouper }}:{{ group.list | length }} {% endfor %}`, "Émile:1 Öl:2 zèbre:1 ")
+		shouldRender(`{# gonja: collation="sv" #}{{ ["Öl", "zèbre"] | sort | join(",") }} {{ ["Öl", "zèbre"] | sort(locale="de") | join(",") }}`, "zèbre,Öl Öl,zèbre")
+		shouldFail(`{{ ["a"] | sort(locale="not a locale!") }}`, "unknown locale 'not a locale!'")
+	})
 })
@@ -4,6 +4,8 @@ import (
 	"github.com/nikolalohinski/gonja/v2"
 	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -53,6 +55,7 @@ var _ = Context("functions", func() {
 		*identifier = "/test"
 		*environment = gonja.DefaultEnvironment
 		*loader = loaders.MustNewMemoryLoader(nil)
+		*context = nil
 	})
 	JustBeforeEach(func() {
 		var t *exec.Template
@@ -73,4 +76,75 @@ var _ = Context("functions", func() {
 		shouldRender(`{% for i in range(10, 1, -2) %}{{ i }}{% endfor %}`, "108642")
 		shouldFail("{% set invalid = range(True) -%}", "invalid call to function 'range': expected signature is \\[start, ]stop\\[, step] where all arguments are integers")
 	})
+	Context("now", func() {
+		shouldRender(`{{ now() > "2020-01-01" | to_datetime }}`, "True")
+		shouldRender(`{{ now(utc=True) | strftime("%Z") }}`, "UTC")
+		shouldRender(`{{ now() + "24h" | to_duration > now() }}`, "True")
+		shouldFail("{{ now(utc=42) }}", "invalid call to function 'now': failed to validate argument 'utc': 42 is not a bool")
+	})
+	Context("timedelta", func() {
+		shouldRender(`{{ timedelta(days=1, hours=2, minutes=3, seconds=4) }}`, "26h3m4s")
+		shouldRender(`{{ timedelta(weeks=1) == timedelta(days=7) }}`, "True")
+		shouldRender(`{{ timedelta(milliseconds=1500) }}`, "1.5s")
+		shouldRender(`{{ timedelta(hours=1) * 3 }}`, "3h0m0s")
+		shouldRender(`{{ timedelta(hours=1) + timedelta(minutes=30) - timedelta(minutes=10) }}`, "1h20m0s")
+		shouldRender(`{{ ("2024-03-01" | to_datetime + timedelta(days=1)) | strftime("%Y-%m-%d") }}`, "2024-03-02")
+		shouldRender(`{{ ("2024-03-01" | to_datetime - timedelta(days=1)) | strftime("%Y-%m-%d") }}`, "2024-02-29")
+		shouldRender(`{{ ("2024-03-02" | to_datetime - "2024-03-01" | to_datetime) == timedelta(days=1) }}`, "True")
+		shouldFail("{{ timedelta(days='one') }}", "invalid call to function 'timedelta': failed to validate argument 'days': one is not a number")
+	})
+	Context("fail", func() {
+		shouldRender(`{% if false %}{{ fail("unreachable") }}{% endif %}ok`, "ok")
+		shouldFail(`{% if not port %}{{ fail("port is required") }}{% endif %}`, `port is required \(/test, line 1, col 21\)`)
+		shouldFail(`{{ fail() }}`, `failed \(/test, line 1, col 4\)`)
+	})
+	Context("warn and log", func() {
+		var hook = new(*test.Hook)
+		BeforeEach(func() {
+			logger, h := test.NewNullLogger()
+			logger.SetLevel(logrus.DebugLevel)
+			*hook = h
+			built, err := exec.NewEnvironmentBuilder(gonja.DefaultEnvironment).WithLogger(logger).Build()
+			Expect(err).To(BeNil())
+			*environment = built
+		})
+		Context("when logging through the environment logger", func() {
+			BeforeEach(func() {
+				*loader = loaders.MustNewMemoryLoader(map[string]string{
+					*identifier: `a{{ warn("deprecated field used", field="X") }}b{{ log("rendering", level="debug") }}c{{ log("done") }}`,
+				})
+			})
+			It("should log the messages without affecting the output", func() {
+				Expect(*returnedErr).To(BeNil())
+				AssertPrettyDiff("abc", *returnedResult)
+				entries := (*hook).AllEntries()
+				Expect(entries).To(HaveLen(3))
+				Expect(entries[0].Level).To(Equal(logrus.WarnLevel))
+				Expect(entries[0].Message).To(Equal("deprecated field used"))
+				Expect(entries[0].Data).To(Equal(logrus.Fields{"field": "X"}))
+				Expect(entries[1].Level).To(Equal(logrus.DebugLevel))
+				Expect(entries[1].Message).To(Equal("rendering"))
+				Expect(entries[2].Level).To(Equal(logrus.InfoLevel))
+				Expect(entries[2].Message).To(Equal("done"))
+			})
+		})
+		Context("when logging sensitive variables", func() {
+			BeforeEach(func() {
+				*context = exec.NewContext(map[string]interface{}{"token": "s3cr3t-value"})
+				(*context).SetSensitive("token")
+				*loader = loaders.MustNewMemoryLoader(map[string]string{
+					*identifier: `{{ warn("token is " ~ token, auth=token, attempt=1) }}`,
+				})
+			})
+			It("should redact them from the messages and fields", func() {
+				Expect(*returnedErr).To(BeNil())
+				entries := (*hook).AllEntries()
+				Expect(entries).To(HaveLen(1))
+				Expect(entries[0].Message).To(Equal("token is [REDACTED]"))
+				Expect(entries[0].Data).To(Equal(logrus.Fields{"auth": "[REDACTED]", "attempt": 1}))
+			})
+		})
+		shouldFail(`{{ log("message", level="fatal") }}`, "invalid call to function 'log': unknown level 'fatal', expected one of debug, info, warning or error")
+		shouldFail(`{{ warn() }}`, `invalid call to function 'warn': expected signature is message\[, \*\*fields\]`)
+	})
 })
@@ -1,7 +1,9 @@
 package integration_test
 
 import (
+	"errors"
 	"os"
+	"path"
 
 	"github.com/nikolalohinski/gonja/v2"
 	"github.com/nikolalohinski/gonja/v2/exec"
@@ -69,5 +71,26 @@ var _ = Context("helpers", func() {
 			Expect(*returnedErr).To(BeNil())
 			Expect(executeResult).To(Equal("Hello Bob!"))
 		})
+		Context("when the file does not exist", func() {
+			BeforeEach(func() {
+				*filepath += ".missing"
+			})
+			It("should return a located error matching ErrTemplateNotFound", func() {
+				Expect(errors.Is(*returnedErr, gonja.ErrTemplateNotFound)).To(BeTrue())
+				var located *gonja.Error
+				Expect(errors.As(*returnedErr, &located)).To(BeTrue())
+			})
+		})
+		Context("when the directory of the file does not exist", func() {
+			BeforeEach(func() {
+				*filepath = path.Join(*filepath+".missing", "page.tpl")
+			})
+			It("should return a located error matching ErrTemplateNotFound", func() {
+				Expect(errors.Is(*returnedErr, gonja.ErrTemplateNotFound)).To(BeTrue())
+				var located *gonja.Error
+				Expect(errors.As(*returnedErr, &located)).To(BeTrue())
+				Expect(located.Identifier).To(Equal(*filepath))
+			})
+		})
 	})
 })
@@ -0,0 +1,109 @@
+package integration_test
+
+import (
+	"time"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/builtins"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Context("pongo2", func() {
+	var (
+		identifier = new(string)
+
+		environment = new(*exec.Environment)
+		loader      = new(loaders.Loader)
+
+		returnedResult = new(string)
+		returnedErr    = new(error)
+		shouldRender   = func(template, result string) {
+			Context(template, func() {
+				BeforeEach(func() {
+					*loader = loaders.MustNewMemoryLoader(map[string]string{
+						*identifier: template,
+					})
+				})
+				It("should return the expected rendered content", func() {
+					By("not returning any error")
+					Expect(*returnedErr).To(BeNil())
+					By("returning the expected result")
+					AssertPrettyDiff(result, *returnedResult)
+				})
+			})
+		}
+		shouldFail = func(template, err string) {
+			Context(template, func() {
+				BeforeEach(func() {
+					*loader = loaders.MustNewMemoryLoader(map[string]string{
+						*identifier: template,
+					})
+				})
+				It("should return the expected error", func() {
+					Expect(*returnedErr).ToNot(BeNil())
+					Expect((*returnedErr).Error()).To(MatchRegexp(err))
+				})
+			})
+		}
+	)
+	BeforeEach(func() {
+		*identifier = "/test"
+		*environment = builtins.Pongo2(gonja.DefaultEnvironment)
+		(*environment).Context.Set("posted", time.Date(2024, time.March, 1, 13, 5, 9, 0, time.UTC))
+		*loader = loaders.MustNewMemoryLoader(nil)
+	})
+	JustBeforeEach(func() {
+		var t *exec.Template
+		t, *returnedErr = exec.NewTemplate(*identifier, gonja.DefaultConfig, *loader, *environment)
+		if *returnedErr != nil {
+			return
+		}
+		*returnedResult, *returnedErr = t.ExecuteToString(nil)
+	})
+	It("should not alter the default environment", func() {
+		Expect(gonja.DefaultEnvironment.Filters.Exists("capfirst")).To(BeFalse())
+		Expect(gonja.DefaultEnvironment.ControlStructures.Exists("ifequal")).To(BeFalse())
+	})
+	Context("ifequal", func() {
+		shouldRender("{% ifequal 1 1 %}equal{% endifequal %}|{% ifequal 'a' 'b' %}equal{% else %}different{% endifequal %}", "equal|different")
+		shouldRender("{% ifnotequal 1 2 %}different{% else %}equal{% endifnotequal %}", "different")
+		shouldFail("{% ifequal 1 %}{% endifequal %}", "expects exactly two arguments")
+	})
+	Context("firstof", func() {
+		shouldRender(`{% firstof none "" 0 "first" "second" %}|{% firstof none false %}`, "first|")
+		shouldFail("{% firstof %}", "expects at least one argument")
+	})
+	Context("now", func() {
+		shouldRender(`{% now "2006" %}`, time.Now().Format("2006"))
+	})
+	Context("templatetag", func() {
+		shouldRender("{% templatetag openblock %} {% templatetag closevariable %} {% templatetag openbrace %}", "{% }} {")
+		shouldFail("{% templatetag openparenthesis %}", "Expected the name of a delimiter")
+	})
+	Context("widthratio", func() {
+		shouldRender("{% widthratio 175 200 100 %}|{% widthratio 1 3 100 as width %}{{ width }}|{% widthratio 1 0 100 %}", "88|33|0")
+		shouldFail("{% widthratio 1 2 %}", "expects a value, a maximum and a width")
+		shouldFail("{% widthratio 'a' 2 100 %}", "a is not a number")
+	})
+	Context("filters", func() {
+		shouldRender(`{{ 1 | add(2) }} {{ 1 | add(0.5) }} {{ "a" | add("b") }}`, "3 1.5 ab")
+		shouldRender(`{{ "it's \"ok\"" | addslashes }}`, `it\'s \"ok\"`)
+		shouldRender(`{{ "école" | capfirst }} {{ "a-b-c" | cut("-") }}`, "École abc")
+		shouldRender(`{{ posted | date("2006-01-02") }} {{ posted | time("15:04") }}`, "2024-03-01 13:05")
+		shouldRender(`{{ none | default_if_none("none") }} {{ "" | default_if_none("none") }}`, "none ")
+		shouldRender(`{{ "<a href='x'>" | escapejs }}`, `\u003Ca href\u003D\u0027x\u0027\u003E`)
+		shouldRender(`{{ 3.0 | floatformat }} {{ 3.14159 | floatformat }} {{ 3.14159 | floatformat(3) }} {{ 3 | floatformat(-2) }}`, "3 3.1 3.142 3")
+		shouldRender(`{{ "12" | integer }} {{ [1, 2] | length_is(2) }} {{ "abc" | make_list }}`, "12 True ['a', 'b', 'c']")
+		shouldRender(`{{ "a\nb\n\nc" | linebreaks }}|{{ "a\nb" | linebreaksbr }}`, "<p>a<br />b</p>\n\n<p>c</p>|a<br />b")
+		shouldRender(`[{{ "ab" | ljust(4) }}][{{ "ab" | rjust(4) }}][{{ "abcde" | rjust(4) }}]`, "[ab  ][  ab][abcde]")
+		shouldRender(`{{ "<b>bold</b> <i class='x'>italic</i>" | removetags("b, i") }}`, "bold italic")
+		shouldRender(`{{ " Hello, World! " | slugify }} {{ "a,b" | split(",") }} {{ 7 | stringformat("%03d") }}`, "hello-world ['a', 'b'] 007")
+		shouldRender(`{{ "abcdefgh" | truncatechars(4) }} {{ "one two three" | truncatewords(2) }}`, "abc… one two …")
+		shouldRender(`{{ true | yesno }} vote{{ 2 | pluralize }}`, "yes votes")
+		shouldFail(`{{ "a" | cut }}`, "missing required 1st positional argument 'value'")
+	})
+})
@@ -193,4 +193,187 @@ var _ = Context("tests", func() {
 		shouldRender("{{ 42 is eq 42.0 }}", "True")
 		shouldRender("{{ 42.5 is eq 42 }}", "False")
 	})
+	Context("comparisons", func() {
+		shouldRender("{{ [5, 10, 15, 20] | select('ge', 10) | list }}", "[10, 15, 20]")
+		shouldRender("{{ [5, 10, 15, 20] | select('gt', 10) | list }}", "[15, 20]")
+		shouldRender("{{ [5, 10, 15, 20] | select('le', 10) | list }}", "[5, 10]")
+		shouldRender("{{ [5, 10, 15, 20] | select('lt', 10) | list }}", "[5]")
+		shouldRender("{{ [5, 10, 15, 20] | reject('eq', 10) | list }}", "[5, 15, 20]")
+		shouldRender("{{ [5, 10, 15, 20] | select('ne', 10) | list }}", "[5, 15, 20]")
+		shouldRender("{{ [5, 10, 15, 20] | select('==', 10.0) | list }}", "[10]")
+		shouldRender("{{ ['a', 'b', 'c'] | select('>=', 'b') | list }}", "['b', 'c']")
+		shouldRender("{{ 42 is equalto 42 }}", "True")
+		shouldRender("{{ 42 is ne 42.0 }}", "False")
+		shouldRender("{{ 42 is != 'foo' }}", "True")
+		shouldRender("{{ 42 is ge 42 }}", "True")
+		shouldRender("{{ 42 is >= 43 }}", "False")
+		shouldRender("{{ 42 is le 42 }}", "True")
+		shouldRender("{{ 42 is <= 41.5 }}", "False")
+		shouldRender("{{ 42 is lessthan 43 }}", "True")
+		shouldRender("{{ 42 is < 42 }}", "False")
+		shouldRender("{{ 'a' is lt 'b' }}", "True")
+		shouldRender(`{{ ("2024-03-01" | to_datetime) is gt ("2024-02-01" | to_datetime) }}`, "True")
+		shouldFail("{{ 42 is ge }}", "invalid call to test 'ge': missing required 1st positional argument 'other'")
+		shouldFail("{{ 42 is eq }}", "invalid call to test 'eq': missing required 1st positional argument 'other'")
+		shouldRender("{{ 42 is lt 'foo' }}", "False")
+		shouldRender("{{ 'foo' is ge 42 }}", "False")
+		shouldRender("{{ none is gt 42 }}", "False")
+		shouldFail("{{ 42 is lt [] }}", `invalid call to test 'lt': \[\] is not a number`)
+		shouldFail("{{ 'foo' is le {} }}", "invalid call to test 'le': {} is not a string")
+	})
+	Context("in and contains", func() {
+		BeforeEach(func() {
+			*context = exec.NewContext(map[string]interface{}{
+				"allowed": []string{"admin", "editor"},
+				"ids":     []uint{1, 2, 3},
+				"ports":   map[int]string{22: "ssh", 80: "http"},
+			})
+		})
+		shouldRender("{{ 'admin' is in(allowed) }}", "True")
+		shouldRender("{{ 'guest' is in allowed }}", "False")
+		shouldRender("{{ 2 is in ids }}", "True")
+		shouldRender("{{ 2.0 is in [1, 2, 3] }}", "True")
+		shouldRender("{{ [1] is in [[1], [2]] }}", "True")
+		shouldRender("{{ 22 is in ports }}", "True")
+		shouldRender("{{ 'ssh' is in ports }}", "False")
+		shouldRender("{{ 'oo' is in 'foo' }}", "True")
+		shouldRender("{{ ['admin', 'guest', 'editor'] | select('in', allowed) | list }}", "['admin', 'editor']")
+		shouldRender("{{ allowed is contains 'admin' }}", "True")
+		shouldRender("{{ 'foobar' is contains('bar') }}", "True")
+		shouldRender("{{ {'key': 'value'} is contains 'key' }}", "True")
+		shouldRender("{{ {'key': 'value'} is contains 'value' }}", "False")
+		shouldRender("{{ [[1, 2], [3], [4, 1]] | select('contains', 1) | list }}", "[[1, 2], [4, 1]]")
+		shouldRender("{{ ['foo', 'bar', 'baz'] | reject('contains', 'a') | list }}", "['foo']")
+		shouldFail("{{ 1 is in 42 }}", "invalid call to test 'in': 42 is not iterable")
+		shouldFail("{{ 1 is in }}", "invalid call to test 'in': missing required 1st positional argument 'seq'")
+		shouldFail("{{ 42 is contains 1 }}", "invalid call to test 'contains': 42 is not iterable")
+	})
+	Context("sameas", func() {
+		BeforeEach(func() {
+			sentinel := &struct{ Name string }{Name: "missing"}
+			other := &struct{ Name string }{Name: "missing"}
+			config := map[string]interface{}{"key": "value"}
+			*context = exec.NewContext(map[string]interface{}{
+				"sentinel": sentinel,
+				"value":    sentinel,
+				"other":    other,
+				"config":   config,
+				"alias":    config,
+				"copy":     map[string]interface{}{"key": "value"},
+			})
+		})
+		shouldRender("{{ value is sameas sentinel }}", "True")
+		shouldRender("{{ other is sameas sentinel }}", "False")
+		shouldRender("{{ other == sentinel }}", "False")
+		shouldRender("{{ alias is sameas config }}", "True")
+		shouldRender("{{ copy is sameas config }}", "False")
+		shouldRender("{{ none is sameas none }}", "True")
+		shouldRender("{{ false is sameas none }}", "False")
+		shouldRender("{{ true is sameas true }}", "True")
+		shouldRender("{{ 1 is sameas 1.0 }}", "False")
+		shouldFail("{{ 1 is sameas }}", "invalid call to test 'sameas': missing required 1st positional argument 'other'")
+	})
+	Context("divisibleby", func() {
+		shouldRender("{% for i in range(1, 8) %}{{ i }}{% if loop.index is divisibleby 3 %}|{% endif %}{% endfor %}", "123|456|7")
+		shouldRender("{{ 21 is divisibleby(7) }}", "True")
+		shouldRender("{{ 22 is divisibleby 7 }}", "False")
+		shouldRender("{{ 7.5 is divisibleby 2.5 }}", "True")
+		shouldRender("{{ 7.5 is divisibleby 2 }}", "False")
+		shouldRender("{{ 'abc' is divisibleby 3 }}", "False")
+		shouldRender("{{ [3, 4, 6, 7, 9] | select('divisibleby', 3) | list }}", "[3, 6, 9]")
+		shouldFail("{{ 21 is divisibleby 0 }}", "invalid call to test 'divisibleby': 0 is not a non-zero number")
+		shouldFail("{{ 21 is divisibleby }}", "invalid call to test 'divisibleby': missing required 1st positional argument 'num'")
+	})
+	Context("types", func() {
+		BeforeEach(func() {
+			*context = exec.NewContext(map[string]interface{}{
+				"count":   uint8(3),
+				"ratio":   float32(0.5),
+				"nothing": nil,
+			})
+		})
+		shouldRender("{{ count is integer }}", "True")
+		shouldRender("{{ count is number }}", "True")
+		shouldRender("{{ ratio is float }}", "True")
+		shouldRender("{{ ratio is number }}", "True")
+		shouldRender("{{ '42' is number }}", "False")
+		shouldRender("{{ true is integer }}", "False")
+		shouldRender("{{ timedelta(hours=1) is integer }}", "False")
+		shouldRender("{{ timedelta(hours=1) is number }}", "False")
+		shouldRender("{{ 'foo' is string }}", "True")
+		shouldRender("{{ 42 is string }}", "False")
+		shouldRender("{{ nothing is none }}", "True")
+		shouldRender("{{ undefined_variable is none }}", "True")
+		shouldRender("{{ 0 is none }}", "False")
+		shouldRender("{{ 0 is false }}", "False")
+		shouldRender("{{ '' is false }}", "False")
+		shouldRender("{{ 1 is true }}", "False")
+		shouldRender("{{ false is false }}", "True")
+		shouldRender("{{ false is boolean }}", "True")
+		shouldRender("{{ 'False' is boolean }}", "False")
+		shouldRender("{{ [1, 'a', 2.5, none, true] | select('number') | list }}", "[1, 2.5]")
+	})
+	Context("structures", func() {
+		BeforeEach(func() {
+			channel := make(chan int, 2)
+			channel <- 1
+			channel <- 2
+			close(channel)
+			*context = exec.NewContext(map[string]interface{}{
+				"hash":    map[string]int{"one": 1},
+				"slice":   []int{1, 2},
+				"array":   [2]string{"a", "b"},
+				"pointer": &[]int{1, 2},
+				"channel": channel,
+				"struct":  struct{ Name string }{Name: "john"},
+				"func":    func() string { return "called" },
+			})
+		})
+		shouldRender("{{ hash is mapping }}", "True")
+		shouldRender("{{ {'a': 1} is mapping }}", "True")
+		shouldRender("{{ slice is mapping }}", "False")
+		shouldRender("{{ struct is mapping }}", "False")
+		shouldRender("{{ slice is sequence }}", "True")
+		shouldRender("{{ array is sequence }}", "True")
+		shouldRender("{{ pointer is sequence }}", "True")
+		shouldRender("{{ channel is sequence }}", "False")
+		shouldRender("{{ hash is sequence }}", "False")
+		shouldRender("{{ channel is iterable }}", "True")
+		shouldRender("{{ range(3) is iterable }}", "True")
+		shouldRender("{{ array is iterable }}", "True")
+		shouldRender("{{ hash is iterable }}", "True")
+		shouldRender("{{ struct is iterable }}", "False")
+		shouldRender("{{ func is callable }}", "True")
+		shouldRender("{{ range is callable }}", "True")
+		shouldRender("{% macro hello() %}hello{% endmacro %}{{ hello is callable }}", "True")
+		shouldRender("{{ hash is callable }}", "False")
+	})
+	Context("regular expressions", func() {
+		shouldRender(`{{ "my-service-01" is match("^[a-z0-9-]+$") }}`, "True")
+		shouldRender(`{{ "My_Service" is match("^[a-z0-9-]+$") }}`, "False")
+		shouldRender(`{{ "My-Service" is match("[a-z-]+$", ignorecase=True) }}`, "True")
+		shouldRender(`{{ "foobar" is match("bar") }}`, "False")
+		shouldRender(`{{ "foobar" is search("bar") }}`, "True")
+		shouldRender(`{{ "foo\nbar" is search("^bar") }}`, "False")
+		shouldRender(`{{ "foo\nbar" is search("^bar", multiline=True) }}`, "True")
+		shouldRender(`{{ "foo\nbar" is match("^bar", multiline=True) }}`, "False")
+		shouldRender(`{{ "FOOBAR" is match("foo", true) }}`, "True")
+		shouldRender(`{{ "FOOBAR" is search("bar", false) }}`, "False")
+		shouldRender(`{{ "foobar" is regex("bar") }}`, "True")
+		shouldRender(`{{ "foobar" is regex("foo", match_type="fullmatch") }}`, "False")
+		shouldRender(`{{ "foo" is regex("fo+", match_type="fullmatch") }}`, "True")
+		shouldRender(`{{ 42 is search("4") }}`, "False")
+		shouldRender(`{{ ["web-01", "db-01", "web-02"] | select("match", "web-") | list }}`, "['web-01', 'web-02']")
+		shouldFail(`{{ "foo" is match("(") }}`, "invalid call to test 'match': error parsing regexp: missing closing \\)")
+		shouldFail(`{{ "foo" is search }}`, "invalid call to test 'search': missing required 1st positional argument 'pattern'")
+		shouldFail(`{{ "foo" is regex("foo", match_type="yolo") }}`, "invalid call to test 'regex': failed to validate argument 'match_type': unexpected value 'yolo'")
+	})
+	Context("escaping", func() {
+		shouldRender(`{{ "<b>" is escaped }}`, "False")
+		shouldRender(`{{ "plain" is escaped }}`, "False")
+		shouldRender(`{{ "<b>" | safe is escaped }}`, "True")
+		shouldRender(`{{ "<b>" | escape is escaped }}`, "True")
+		shouldRender(`{% macro bold(text) %}<b>{{ text if text is escaped else text | forceescape }}</b>{% endmacro %}{{ bold("<i>") }} {{ bold("<i>" | safe) }}`, "<b>&lt;i&gt;</b> <b><i></b>")
+		shouldFail(`{{ "foo" is escaped(1) }}`, "invalid call to test 'escaped': received 1 unexpected positional argument")
+	})
 })
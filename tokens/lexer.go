@@ -18,6 +18,9 @@ import (
 const rEOF = -1
 const re_ENDRAW = `%s\s*%s`
 
+// tokenBatchSize is the number of tokens allocated at once by the lexer
+const tokenBatchSize = 128
+
 var escapedStrings = map[string]string{
 	`\"`: `"`,
 	`\'`: `'`,
@@ -27,7 +30,8 @@ var escapedStrings = map[string]string{
 
 // lexFn represents the state of the scanner
 // as a function that returns the next state.
-type lexFn func() lexFn
+// States are method expressions rather than method values, which would be allocated on every transition.
+type lexFn func(*Lexer) lexFn
 
 // Lexer holds the state of the scanner.
 type Lexer struct {
@@ -43,9 +47,20 @@ type Lexer struct {
 	delimiters           []rune
 	RawControlStructures rawControlStructure
 	rawEnd               *regexp.Regexp
-}
-
-// TODO: set from env
+	// line, lineStart and scanned cache how far the input has been scanned for newlines,
+	// so that positions of emitted tokens are computed without rescanning the input
+	line      int
+	lineStart int
+	scanned   int
+	// sink receives the tokens instead of the channel when set, so that the lexer can run synchronously
+	sink func(*Token)
+	// batch holds tokens allocated ahead of time, so that tokens are not allocated one by one.
+	// A batch is garbage collected once none of its tokens is referenced anymore.
+	batch []Token
+}
+
+// rawControlStructure maps the names of the statements whose body is not lexed to the expression matching
+// the beginning of their end tag
 type rawControlStructure map[string]*regexp.Regexp
 
 func escape_chars_clashing_regexp(s string) string {
@@ -54,6 +69,11 @@ func escape_chars_clashing_regexp(s string) string {
 	return s
 }
 
+// rawEnd returns the expression matching the beginning of the end tag of a raw statement under any of its names
+func rawEnd(config *config.Config, names []string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`%s-?\s*(?:%s)`, escape_chars_clashing_regexp(config.BlockStartString), strings.Join(names, "|")))
+}
+
 // NewLexer creates a new scanner for the input string.
 func NewLexer(input string, config *config.Config) *Lexer {
 	return &Lexer{
@@ -61,14 +81,63 @@ func NewLexer(input string, config *config.Config) *Lexer {
 		Tokens: make(chan *Token),
 		Config: config,
 		RawControlStructures: rawControlStructure{
-			"raw":     regexp.MustCompile(fmt.Sprintf(`%s-?\s*endraw`, escape_chars_clashing_regexp(config.BlockStartString))),
-			"comment": regexp.MustCompile(fmt.Sprintf(`%s-?\s*endcomment`, escape_chars_clashing_regexp(config.BlockStartString))),
+			"raw":     rawEnd(config, []string{"endraw"}),
+			"comment": rawEnd(config, []string{"endcomment"}),
 		},
 	}
 }
 
+// AliasRawControlStructures lexes the aliases of the statements whose body is not lexed, such as verbatim
+// for raw, like the statements they stand for. The aliases map each alias to the name it stands for, as
+// returned by exec.ControlStructureSet.Aliases, and the aliases of end tags, such as endverbatim for endraw,
+// end the bodies as well.
+func (l *Lexer) AliasRawControlStructures(aliases map[string]string) {
+	names := make([]string, 0, len(l.RawControlStructures))
+	for name := range l.RawControlStructures {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		starts := []string{}
+		ends := []string{"end" + name}
+		for alias, target := range aliases {
+			switch target {
+			case name:
+				starts = append(starts, alias)
+			case "end" + name:
+				ends = append(ends, regexp.QuoteMeta(alias))
+			}
+		}
+		if len(starts) == 0 && len(ends) == 1 {
+			continue
+		}
+		end := rawEnd(l.Config, ends)
+		l.RawControlStructures[name] = end
+		for _, alias := range starts {
+			l.RawControlStructures[alias] = end
+		}
+	}
+}
+
+// trimTrailingNewline removes a single trailing newline, whatever its line ending
+func trimTrailingNewline(input string) string {
+	if strings.HasSuffix(input, "\r\n") {
+		return input[:len(input)-2]
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(input, "\n"), "\r")
+}
+
 func Lex(input string, config *config.Config) *Stream {
+	return LexWithAliases(input, config, nil)
+}
+
+// LexWithAliases lexes the input like Lex, lexing the aliases of the statements whose body is not lexed
+// like the statements they stand for, see Lexer.AliasRawControlStructures
+func LexWithAliases(input string, config *config.Config, aliases map[string]string) *Stream {
+	if config.TrimTrailingNewline {
+		input = trimTrailingNewline(input)
+	}
 	l := NewLexer(input, config)
+	l.AliasRawControlStructures(aliases)
 	go l.Run()
 	return NewStream(l.Tokens)
 }
@@ -77,14 +146,27 @@ func Lex(input string, config *config.Config) *Stream {
 // by passing back a nil pointer that will be the next
 // state, terminating Lexer.Run.
 func (l *Lexer) errorf(format string, args ...interface{}) lexFn {
-	l.Tokens <- &Token{
+	line, col := l.readablePosition(l.Start)
+	l.send(&Token{
 		Type: Error,
 		Val:  fmt.Sprintf(format, args...),
-		Pos:  l.Pos,
-	}
+		Pos:  l.Start,
+		End:  l.Pos,
+		Line: line,
+		Col:  col,
+	})
 	return nil
 }
 
+// send delivers a token to the sink of the lexer if any, or to its channel otherwise
+func (l *Lexer) send(token *Token) {
+	if l.sink != nil {
+		l.sink(token)
+		return
+	}
+	l.Tokens <- token
+}
+
 // Position return the current position in the input
 func (l *Lexer) Position() *Position {
 	return &Position{
@@ -101,8 +183,8 @@ func (l *Lexer) Current() string {
 // Run lexes the input by executing state functions until
 // the state is nil.
 func (l *Lexer) Run() {
-	for state := l.lexData; state != nil; {
-		state = state()
+	for state := lexFn((*Lexer).lexData); state != nil; {
+		state = state(l)
 	}
 	close(l.Tokens) // No more tokens will be delivered.
 }
@@ -128,21 +210,47 @@ func (l *Lexer) emit(t Type) {
 }
 
 func (l *Lexer) processAndEmit(t Type, fn func(string) string) {
-	line, col := ReadablePosition(l.Start, l.Input)
+	line, col := l.readablePosition(l.Start)
+	// values are slices of the input and do not copy it
 	val := l.Input[l.Start:l.Pos]
 	if fn != nil {
 		val = fn(val)
 	}
-	l.Tokens <- &Token{
-		Type: t,
-		Val:  val,
-		Pos:  l.Start,
-		Line: line,
-		Col:  col,
-	}
+	token := l.newToken()
+	token.Type = t
+	token.Val = val
+	token.Pos = l.Start
+	token.End = l.Pos
+	token.Line = line
+	token.Col = col
+	l.send(token)
 	l.Start = l.Pos
 }
 
+// newToken takes a token from the current batch, allocating a new batch when it is exhausted
+func (l *Lexer) newToken() *Token {
+	if len(l.batch) == 0 {
+		l.batch = make([]Token, tokenBatchSize)
+	}
+	token := &l.batch[0]
+	l.batch = l.batch[1:]
+	return token
+}
+
+// readablePosition works like ReadablePosition for offsets that never decrease between calls,
+// only scanning the part of the input that has not been scanned yet
+func (l *Lexer) readablePosition(offset int) (int, int) {
+	if offset > l.scanned {
+		chunk := l.Input[l.scanned:offset]
+		if count := strings.Count(chunk, "\n"); count > 0 {
+			l.line += count
+			l.lineStart = l.scanned + strings.LastIndexByte(chunk, '\n') + 1
+		}
+		l.scanned = offset
+	}
+	return l.line + 1, offset - l.lineStart + 1
+}
+
 // ignore skips over the pending input before this point.
 func (l *Lexer) ignore() {
 	l.Start = l.Pos
@@ -218,21 +326,21 @@ func (l *Lexer) lexData() lexFn {
 			if l.Pos > l.Start {
 				l.emit(Data)
 			}
-			return l.lexComment
+			return (*Lexer).lexComment
 		}
 
 		if l.hasPrefix(l.Config.VariableStartString) {
 			if l.Pos > l.Start {
 				l.emit(Data)
 			}
-			return l.lexVariable
+			return (*Lexer).lexVariable
 		}
 
 		if l.hasPrefix(l.Config.BlockStartString) {
 			if l.Pos > l.Start {
 				l.emit(Data)
 			}
-			return l.lexBlock
+			return (*Lexer).lexBlock
 		}
 
 		if l.next() == rEOF {
@@ -259,7 +367,7 @@ func (l *Lexer) lexRaw() lexFn {
 	l.Pos += loc[0]
 	l.emit(Data)
 	l.rawEnd = nil
-	return l.lexBlock
+	return (*Lexer).lexBlock
 	// regexp.MustCompile(`(?m)(?P<key>\w+):\s+(?P<value>\w+)$`)
 	// idx := pattern
 }
@@ -267,39 +375,44 @@ func (l *Lexer) lexRaw() lexFn {
 func (l *Lexer) lexComment() lexFn {
 	l.Pos += len(l.Config.CommentStartString)
 	l.accept("-")
+	l.accept("+")
 	l.emit(CommentBegin)
 	i := strings.Index(l.Input[l.Pos:], l.Config.CommentEndString)
 	if i < 0 {
 		return l.errorf("unclosed comment")
 	}
 	l.Pos += i
-	if l.Input[l.Pos-1] == '-' {
+	if l.Input[l.Pos-1] == '-' || l.Input[l.Pos-1] == '+' {
 		l.Pos -= 1
 	}
 	l.emit(Data)
 	l.accept("-")
+	l.accept("+")
 	l.Pos += len(l.Config.CommentEndString)
 	l.emit(CommentEnd)
-	return l.lexData
+	return (*Lexer).lexData
 }
 
 func (l *Lexer) lexVariable() lexFn {
-	log.WithFields(log.Fields{
-		"pos":       l.Pos,
-		"input":     l.Input,
-		"remaining": l.remaining(),
-	}).Trace("Lexer.lexVariable")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"pos":       l.Pos,
+			"input":     l.Input,
+			"remaining": l.remaining(),
+		}).Trace("Lexer.lexVariable")
+	}
 	l.Pos += len(l.Config.VariableStartString)
 	l.accept("-")
 	l.emit(VariableBegin)
-	return l.lexExpression
+	return (*Lexer).lexExpression
 }
 
 func (l *Lexer) lexVariableEnd() lexFn {
 	l.accept("-")
+	l.accept("+")
 	l.Pos += len(l.Config.VariableEndString)
 	l.emit(VariableEnd)
-	return l.lexData
+	return (*Lexer).lexData
 }
 
 func (l *Lexer) lexBlock() lexFn {
@@ -319,7 +432,7 @@ func (l *Lexer) lexBlock() lexFn {
 	if exists {
 		l.rawEnd = re
 	}
-	return l.lexExpression
+	return (*Lexer).lexExpression
 }
 
 func (l *Lexer) lexBlockEnd() lexFn {
@@ -328,41 +441,45 @@ func (l *Lexer) lexBlockEnd() lexFn {
 	l.Pos += len(l.Config.BlockEndString)
 	l.emit(BlockEnd)
 	if l.rawEnd != nil {
-		return l.lexRaw
+		return (*Lexer).lexRaw
 	} else {
-		return l.lexData
+		return (*Lexer).lexData
 	}
 }
 
 func (l *Lexer) lexExpression() lexFn {
-	log.WithFields(log.Fields{
-		"pos":       l.Pos,
-		"input":     l.Input,
-		"remaining": l.remaining(),
-	}).Trace("lexExpression")
+	if log.IsLevelEnabled(log.TraceLevel) {
+		log.WithFields(log.Fields{
+			"pos":       l.Pos,
+			"input":     l.Input,
+			"remaining": l.remaining(),
+		}).Trace("lexExpression")
+	}
 	for {
 		if !l.expectDelimiter(l.peek()) {
 			if l.hasPrefix(l.Config.VariableEndString) {
-				return l.lexVariableEnd
+				return (*Lexer).lexVariableEnd
 			}
 
 			if l.hasPrefix(l.Config.BlockEndString) {
-				return l.lexBlockEnd
+				return (*Lexer).lexBlockEnd
 			}
 		}
 
 		r := l.next()
-		log.WithFields(log.Fields{"rune": r}).Trace("lexExpression")
+		if log.IsLevelEnabled(log.TraceLevel) {
+			log.WithFields(log.Fields{"rune": r}).Trace("lexExpression")
+		}
 		switch {
 		case isEOF(r):
-			return l.lexEOF
+			return (*Lexer).lexEOF
 		case isSpace(r):
-			return l.lexSpace
+			return (*Lexer).lexSpace
 		case isNumeric(r):
-			return l.lexNumber
+			return (*Lexer).lexNumber
 		case r == '"' || r == '\'':
 			l.backup()
-			return l.lexString
+			return (*Lexer).lexString
 		case r == ',':
 			l.emit(Comma)
 		case r == '|':
@@ -370,17 +487,20 @@ func (l *Lexer) lexExpression() lexFn {
 		case r == '+':
 			if l.hasPrefix(l.Config.BlockEndString) {
 				l.backup()
-				return l.lexBlockEnd
+				return (*Lexer).lexBlockEnd
+			} else if l.hasPrefix(l.Config.VariableEndString) {
+				l.backup()
+				return (*Lexer).lexVariableEnd
 			} else {
 				l.emit(Addition)
 			}
 		case r == '-':
 			if l.hasPrefix(l.Config.BlockEndString) {
 				l.backup()
-				return l.lexBlockEnd
+				return (*Lexer).lexBlockEnd
 			} else if l.hasPrefix(l.Config.VariableEndString) {
 				l.backup()
-				return l.lexVariableEnd
+				return (*Lexer).lexVariableEnd
 			} else {
 				l.emit(Subtraction)
 			}
@@ -456,35 +576,35 @@ func (l *Lexer) lexExpression() lexFn {
 		// in
 		case r == 'i' && l.accept("n"):
 			if !isSpace(l.peek()) {
-				return l.lexIdentifier
+				return (*Lexer).lexIdentifier
 			}
 			l.emit(In)
 		// is
 		case r == 'i' && l.accept("s"):
 			if !isSpace(l.peek()) {
-				return l.lexIdentifier
+				return (*Lexer).lexIdentifier
 			}
 			l.emit(Is)
 		// and
 		case r == 'a' && l.accept("n"):
 			if !(l.accept("d") && isSpace(l.peek())) {
-				return l.lexIdentifier
+				return (*Lexer).lexIdentifier
 			}
 			l.emit(And)
 		// or
 		case r == 'o' && l.accept("r"):
 			if !isSpace(l.peek()) {
-				return l.lexIdentifier
+				return (*Lexer).lexIdentifier
 			}
 			l.emit(Or)
 		// not
 		case r == 'n' && l.accept("o"):
 			if !(l.accept("t") && (isSpace(l.peek()) || l.peek() == '(')) {
-				return l.lexIdentifier
+				return (*Lexer).lexIdentifier
 			}
 			l.emit(Not)
 		case isAlphaNumeric(r):
-			return l.lexIdentifier
+			return (*Lexer).lexIdentifier
 		}
 	}
 }
@@ -494,7 +614,7 @@ func (l *Lexer) lexSpace() lexFn {
 		l.next()
 	}
 	l.emit(Whitespace)
-	return l.lexExpression
+	return (*Lexer).lexExpression
 }
 
 func (l *Lexer) lexEOF() lexFn {
@@ -523,7 +643,7 @@ func (l *Lexer) nextIdentifier() string {
 func (l *Lexer) lexIdentifier() lexFn {
 	l.nextIdentifier()
 	l.emit(Name)
-	return l.lexExpression
+	return (*Lexer).lexExpression
 }
 
 func (l *Lexer) lexNumber() lexFn {
@@ -541,11 +661,11 @@ func (l *Lexer) lexNumber() lexFn {
 				return l.errorf("two dots in numeric token")
 			}
 		case isAlphaNumeric(r) && tokType == Integer:
-			return l.lexIdentifier
+			return (*Lexer).lexIdentifier
 		}
 		l.backup()
 		l.emit(tokType)
-		return l.lexExpression
+		return (*Lexer).lexExpression
 	}
 }
 
@@ -560,18 +680,18 @@ func unescape(str string) string {
 func (l *Lexer) lexString() lexFn {
 	quote := l.next() // should be either ' or "
 	var prev rune
-	near := make([]rune, 0)
 	for r := l.next(); r != quote || prev == '\\'; r, prev = l.next(), r {
-		// only keep near context of the current line in case of error
-		if (len(near) == 0 || near[len(near)-1] != '\n') && r != rEOF {
-			near = append(near, r)
-		}
 		if r == rEOF {
-			return l.errorf(`%s`, string(near))
+			// only report the near context of the current line
+			near := l.Input[l.Start+1:]
+			if i := strings.IndexByte(near, '\n'); i >= 0 {
+				near = near[:i+1]
+			}
+			return l.errorf(`%s`, near)
 		}
 	}
 	l.processAndEmit(String, unescape)
-	return l.lexExpression
+	return (*Lexer).lexExpression
 }
 
 // isSpace reports whether r is a space character.
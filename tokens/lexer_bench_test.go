@@ -0,0 +1,23 @@
+package tokens_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+)
+
+func BenchmarkLex(b *testing.B) {
+	input := strings.Repeat("<li class=\"item\">{{ user.name | upper }} - {{ 'label' ~ user.id }}</li>\n{% if user.age >= 18 and user.active %}adult{% endif %}\n", 200)
+	cfg := config.New()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lexer := tokens.NewLexer(input, cfg)
+		go lexer.Run()
+		for range lexer.Tokens {
+		}
+	}
+}
@@ -438,6 +438,33 @@ var _ = Context("lexer", func() {
 					{"Type": Equal(tokens.EOF), "Val": Equal(""), "Pos": Equal(40), "Line": Equal(6), "Col": Equal(1)},
 				},
 			},
+			{
+				"has expressions on several lines",
+				"{{ a }}\n  {{ b }}",
+				[]Fields{
+					{"Type": Equal(tokens.VariableBegin), "Pos": Equal(0), "Line": Equal(1), "Col": Equal(1)},
+					{"Type": Equal(tokens.Whitespace), "Pos": Equal(2), "Line": Equal(1), "Col": Equal(3)},
+					{"Type": Equal(tokens.Name), "Val": Equal("a"), "Pos": Equal(3), "Line": Equal(1), "Col": Equal(4)},
+					{"Type": Equal(tokens.Whitespace), "Pos": Equal(4), "Line": Equal(1), "Col": Equal(5)},
+					{"Type": Equal(tokens.VariableEnd), "Pos": Equal(5), "Line": Equal(1), "Col": Equal(6)},
+					{"Type": Equal(tokens.Data), "Val": Equal("\n  "), "Pos": Equal(7), "Line": Equal(1), "Col": Equal(8)},
+					{"Type": Equal(tokens.VariableBegin), "Pos": Equal(10), "Line": Equal(2), "Col": Equal(3)},
+					{"Type": Equal(tokens.Whitespace), "Pos": Equal(12), "Line": Equal(2), "Col": Equal(5)},
+					{"Type": Equal(tokens.Name), "Val": Equal("b"), "Pos": Equal(13), "Line": Equal(2), "Col": Equal(6)},
+					{"Type": Equal(tokens.Whitespace), "Pos": Equal(14), "Line": Equal(2), "Col": Equal(7)},
+					{"Type": Equal(tokens.VariableEnd), "Pos": Equal(15), "Line": Equal(2), "Col": Equal(8)},
+					{"Type": Equal(tokens.EOF), "Pos": Equal(17), "Line": Equal(2), "Col": Equal(10)},
+				},
+			},
+			{
+				"has an unterminated string",
+				"{{ 'abc\ndef }}",
+				[]Fields{
+					{"Type": Equal(tokens.VariableBegin)},
+					{"Type": Equal(tokens.Whitespace)},
+					{"Type": Equal(tokens.Error), "Val": Equal("abc\n")},
+				},
+			},
 		} {
 			t := testCase
 			Context(fmt.Sprintf("when the input %s", t.description), func() {
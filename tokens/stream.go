@@ -104,6 +104,11 @@ func (s *Stream) consume() *Token {
 	return s.previous
 }
 
+// Previous returns the last consumed token, or nil right after a backup
+func (s *Stream) Previous() *Token {
+	return s.previous
+}
+
 func (s *Stream) Current() *Token {
 	return s.current
 }
@@ -0,0 +1,94 @@
+package tokens
+
+import (
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2/config"
+)
+
+// Tokenizer splits a template into tokens on demand, for tooling such as syntax highlighters and language
+// servers. It runs on the calling goroutine, so that it can be abandoned at any point.
+//
+// Unlike the stream returned by Lex, whitespace tokens are returned, and tokenizing goes on after errors:
+// the text from the faulty token to the next tag is returned as a single Error token, whose value holds the
+// error message, and tokenizing resumes from that tag. Every token locates its raw text within the input
+// from Pos to End, so that concatenating the raw text of all tokens gives back the input, except for the
+// text of the tags of raw control structures and comments which is tokenized as a whole.
+type Tokenizer struct {
+	lexer   *Lexer
+	state   lexFn
+	pending []*Token
+	done    bool
+}
+
+// NewTokenizer creates a tokenizer for a template using the delimiters of the given configuration
+func NewTokenizer(input string, config *config.Config) *Tokenizer {
+	t := &Tokenizer{
+		lexer: NewLexer(input, config),
+		state: (*Lexer).lexData,
+	}
+	t.lexer.sink = func(token *Token) {
+		t.pending = append(t.pending, token)
+	}
+	return t
+}
+
+// Next returns the next token, the last one being of type EOF, or nil once every token was returned
+func (t *Tokenizer) Next() *Token {
+	for len(t.pending) == 0 {
+		if t.done {
+			return nil
+		}
+		if t.state = t.state(t.lexer); t.state == nil {
+			t.stop()
+		}
+	}
+	token := t.pending[0]
+	t.pending = t.pending[1:]
+	return token
+}
+
+// stop is called when the lexer stops, either after the EOF token or after an error, in which case
+// tokenizing resumes at the next tag
+func (t *Tokenizer) stop() {
+	var last *Token
+	if len(t.pending) > 0 {
+		last = t.pending[len(t.pending)-1]
+	}
+	l := t.lexer
+	if last == nil || last.Type != Error || l.Start >= len(l.Input) {
+		if last == nil || last.Type != EOF {
+			line, col := l.readablePosition(len(l.Input))
+			l.send(&Token{Type: EOF, Pos: len(l.Input), End: len(l.Input), Line: line, Col: col})
+		}
+		t.done = true
+		return
+	}
+
+	resume := len(l.Input)
+	from := l.Start + 1
+	if l.Pos > from {
+		from = l.Pos
+	}
+	for _, delimiter := range []string{l.Config.BlockStartString, l.Config.VariableStartString, l.Config.CommentStartString} {
+		if index := strings.Index(l.Input[from:], delimiter); index >= 0 && from+index < resume {
+			resume = from + index
+		}
+	}
+	// the error token covers the text skipped until the resuming point
+	last.End = resume
+	l.Start, l.Pos = resume, resume
+	l.delimiters = nil
+	l.rawEnd = nil
+	t.state = (*Lexer).lexData
+}
+
+// Tokenize returns every token of a template, see Tokenizer
+func Tokenize(input string, config *config.Config) []*Token {
+	tokenizer := NewTokenizer(input, config)
+	tokens := []*Token{}
+	for token := tokenizer.Next(); token != nil; token = tokenizer.Next() {
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
@@ -0,0 +1,111 @@
+package tokens_test
+
+import (
+	"strings"
+
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/tokens"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+var _ = Context("tokenizer", func() {
+	var (
+		input         = new(string)
+		configuration = new(*config.Config)
+
+		returnedTokens = new([]*tokens.Token)
+	)
+	BeforeEach(func() {
+		*configuration = config.New()
+	})
+	JustBeforeEach(func() {
+		*returnedTokens = tokens.Tokenize(*input, *configuration)
+	})
+	raw := func() []string {
+		texts := make([]string, 0, len(*returnedTokens))
+		for _, token := range *returnedTokens {
+			texts = append(texts, (*input)[token.Pos:token.End])
+		}
+		return texts
+	}
+	Context("when the template is valid", func() {
+		BeforeEach(func() {
+			*input = "Hello {{ name | upper }}{% if x %}!{% endif %}"
+		})
+		It("should return whitespace tokens and end with EOF", func() {
+			Expect(*returnedTokens).To(HaveLen(24))
+			Expect((*returnedTokens)[2]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type": Equal(tokens.Whitespace),
+				"Pos":  Equal(8),
+				"End":  Equal(9),
+			})))
+			Expect((*returnedTokens)[23]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type": Equal(tokens.EOF),
+				"Pos":  Equal(len(*input)),
+			})))
+		})
+		It("should locate tokens so that their raw text gives back the input", func() {
+			Expect(strings.Join(raw(), "")).To(Equal(*input))
+		})
+	})
+	Context("when the template contains errors", func() {
+		BeforeEach(func() {
+			*input = "a {{ x ] }} b {{ y }}"
+		})
+		It("should return the skipped text as an error token and resume at the next tag", func() {
+			Expect(raw()).To(Equal([]string{"a ", "{{", " ", "x", " ", "] }} b ", "{{", " ", "y", " ", "}}", ""}))
+			Expect((*returnedTokens)[5]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type": Equal(tokens.Error),
+				"Val":  Equal(`Unexpected delimiter "]"`),
+				"Line": Equal(1),
+				"Col":  Equal(8),
+			})))
+			Expect((*returnedTokens)[8].Val).To(Equal("y"))
+		})
+	})
+	Context("when an error reaches the end of the input", func() {
+		BeforeEach(func() {
+			*input = "{# open"
+		})
+		It("should end with an EOF token", func() {
+			Expect(*returnedTokens).To(HaveLen(3))
+			Expect((*returnedTokens)[1]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type": Equal(tokens.Error),
+				"End":  Equal(len(*input)),
+			})))
+			Expect((*returnedTokens)[2].Type).To(Equal(tokens.EOF))
+		})
+	})
+	Context("when using custom delimiters", func() {
+		BeforeEach(func() {
+			*configuration = config.New()
+			(*configuration).VariableStartString = "<<"
+			(*configuration).VariableEndString = ">>"
+			*input = "<< ) >> {{ << ok >>"
+		})
+		It("should resume at the custom delimiters", func() {
+			Expect(raw()).To(Equal([]string{"<<", " ", ") >> {{ ", "<<", " ", "ok", " ", ">>", ""}))
+		})
+	})
+	Context("when trailing newlines are trimmed from templates", func() {
+		BeforeEach(func() {
+			*configuration = config.Jinja2()
+			*input = "{{ x }}\n"
+		})
+		It("should still locate tokens so that their raw text gives back the input", func() {
+			Expect(strings.Join(raw(), "")).To(Equal(*input))
+		})
+	})
+	Context("when reading tokens one by one", func() {
+		It("should return nil once EOF was returned", func() {
+			tokenizer := tokens.NewTokenizer("text", config.New())
+			Expect(tokenizer.Next().Type).To(Equal(tokens.Data))
+			Expect(tokenizer.Next().Type).To(Equal(tokens.EOF))
+			Expect(tokenizer.Next()).To(BeNil())
+			Expect(tokenizer.Next()).To(BeNil())
+		})
+	})
+})
@@ -128,6 +128,7 @@ type Token struct {
 	Type                  Type
 	Val                   string
 	Pos                   int
+	End                   int // offset right after the raw text of the token, which may differ from Val
 	Line                  int
 	Col                   int
 	Trim                  bool
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateFormatLayouts maps the format characters of Django's date filter to their go layout equivalent
+var dateFormatLayouts = map[byte]string{
+	'A': "PM",
+	'd': "02",
+	'D': "Mon",
+	'F': "January",
+	'E': "January",
+	'h': "03",
+	'H': "15",
+	'i': "04",
+	'l': "Monday",
+	'm': "01",
+	'M': "Jan",
+	'O': "-0700",
+	'r': "Mon, 02 Jan 2006 15:04:05 -0700",
+	's': "05",
+	'T': "MST",
+	'y': "06",
+	'Y': "2006",
+}
+
+// associatedPressMonths are the month abbreviations of the Associated Press style
+var associatedPressMonths = [...]string{"Jan.", "Feb.", "March", "April", "May", "June", "July", "Aug.", "Sept.", "Oct.", "Nov.", "Dec."}
+
+// DateFormat formats a time using the format characters of Django's date filter, such as "N j, Y".
+// Characters can be escaped with a backslash, and characters which are not format characters are kept as is.
+func DateFormat(t time.Time, format string) string {
+	var out strings.Builder
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c == '\\' && i+1 < len(format) {
+			i++
+			out.WriteByte(format[i])
+			continue
+		}
+		if layout, ok := dateFormatLayouts[c]; ok {
+			out.WriteString(t.Format(layout))
+			continue
+		}
+		switch c {
+		case 'a':
+			if t.Hour() < 12 {
+				out.WriteString("a.m.")
+			} else {
+				out.WriteString("p.m.")
+			}
+		case 'b':
+			out.WriteString(strings.ToLower(t.Format("Jan")))
+		case 'c':
+			out.WriteString(t.Format("2006-01-02T15:04:05.999999-07:00"))
+		case 'e':
+			name, _ := t.Zone()
+			out.WriteString(name)
+		case 'f':
+			out.WriteString(shortTime(t))
+		case 'g':
+			out.WriteString(t.Format("3"))
+		case 'G':
+			out.WriteString(strconv.Itoa(t.Hour()))
+		case 'I':
+			if t.IsDST() {
+				out.WriteByte('1')
+			} else {
+				out.WriteByte('0')
+			}
+		case 'j':
+			out.WriteString(strconv.Itoa(t.Day()))
+		case 'L':
+			if isLeap(t.Year()) {
+				out.WriteString("True")
+			} else {
+				out.WriteString("False")
+			}
+		case 'n':
+			out.WriteString(strconv.Itoa(int(t.Month())))
+		case 'N':
+			out.WriteString(associatedPressMonths[t.Month()-1])
+		case 'o':
+			year, _ := t.ISOWeek()
+			out.WriteString(strconv.Itoa(year))
+		case 'P':
+			switch {
+			case t.Hour() == 0 && t.Minute() == 0:
+				out.WriteString("midnight")
+			case t.Hour() == 12 && t.Minute() == 0:
+				out.WriteString("noon")
+			default:
+				out.WriteString(shortTime(t))
+				if t.Hour() < 12 {
+					out.WriteString(" a.m.")
+				} else {
+					out.WriteString(" p.m.")
+				}
+			}
+		case 'S':
+			out.WriteString(ordinalSuffix(t.Day()))
+		case 't':
+			out.WriteString(strconv.Itoa(time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()))
+		case 'u':
+			out.WriteString(padMicroseconds(t))
+		case 'U':
+			out.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'w':
+			out.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'W':
+			_, week := t.ISOWeek()
+			out.WriteString(strconv.Itoa(week))
+		case 'z':
+			out.WriteString(strconv.Itoa(t.YearDay()))
+		case 'Z':
+			_, offset := t.Zone()
+			out.WriteString(strconv.Itoa(offset))
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// shortTime formats the hour of a time on 12 hours, with its minutes only when they are not zero
+func shortTime(t time.Time) string {
+	if t.Minute() == 0 {
+		return t.Format("3")
+	}
+	return t.Format("3:04")
+}
+
+func padMicroseconds(t time.Time) string {
+	return fmt.Sprintf("%06d", t.Nanosecond()/int(time.Microsecond))
+}
+
+func ordinalSuffix(day int) string {
+	switch {
+	case day >= 11 && day <= 13:
+		return "th"
+	case day%10 == 1:
+		return "st"
+	case day%10 == 2:
+		return "nd"
+	case day%10 == 3:
+		return "rd"
+	}
+	return "th"
+}
+
+func isLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// PosixBasename returns the final component of a '/' separated path the way python's
+// posixpath.basename does, meaning a trailing slash yields an empty string
+func PosixBasename(p string) string {
+	return p[strings.LastIndex(p, "/")+1:]
+}
+
+// PosixDirname returns the directory component of a '/' separated path the way python's
+// posixpath.dirname does
+func PosixDirname(p string) string {
+	head := p[:strings.LastIndex(p, "/")+1]
+	if head != "" && head != strings.Repeat("/", len(head)) {
+		head = strings.TrimRight(head, "/")
+	}
+	return head
+}
+
+// SplitExt splits a path into a root and an extension the way python's
+// os.path.splitext does, so leading dots of the final component are not
+// considered as the start of an extension
+func SplitExt(p string) (string, string) {
+	separator := strings.LastIndex(p, "/")
+	dot := strings.LastIndex(p, ".")
+	if dot <= separator {
+		return p, ""
+	}
+	for index := separator + 1; index < dot; index++ {
+		if p[index] != '.' {
+			return p[:dot], p[dot:]
+		}
+	}
+	return p, ""
+}
+
+// WindowsBasename returns the final component of a windows path the way python's
+// ntpath.basename does
+func WindowsBasename(p string) string {
+	_, tail := splitWindowsPath(p)
+	return tail
+}
+
+// WindowsDirname returns the directory component of a windows path the way python's
+// ntpath.dirname does
+func WindowsDirname(p string) string {
+	head, _ := splitWindowsPath(p)
+	return head
+}
+
+func splitWindowsPath(p string) (string, string) {
+	drive := ""
+	if len(p) >= 2 && p[1] == ':' {
+		drive, p = p[:2], p[2:]
+	}
+	index := strings.LastIndexAny(p, `\/`) + 1
+	head, tail := p[:index], p[index:]
+	if trimmed := strings.TrimRight(head, `\/`); trimmed != "" {
+		head = trimmed
+	}
+	return drive + head, tail
+}
+
+// ExpandUser replaces a leading '~' or '~user' component by the matching home directory.
+// The path is returned untouched if the home directory can not be determined.
+func ExpandUser(p string) string {
+	if !strings.HasPrefix(p, "~") {
+		return p
+	}
+	index := strings.Index(p, "/")
+	if index < 0 {
+		index = len(p)
+	}
+	var home string
+	if name := p[1:index]; name == "" {
+		dir, err := os.UserHomeDir()
+		if err != nil {
+			return p
+		}
+		home = dir
+	} else {
+		account, err := user.Lookup(name)
+		if err != nil {
+			return p
+		}
+		home = account.HomeDir
+	}
+	expanded := strings.TrimRight(home, "/") + p[index:]
+	if expanded == "" {
+		return "/"
+	}
+	return expanded
+}
+
+// RealPath returns the canonical absolute path, resolving symbolic links when the path exists
+func RealPath(p string) (string, error) {
+	absolute, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(absolute); err == nil {
+		return resolved, nil
+	}
+	return absolute, nil
+}
+
+// RelPath returns a relative version of the path from the start directory
+func RelPath(p, start string) (string, error) {
+	absolute, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	absoluteStart, err := filepath.Abs(start)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(absoluteStart, absolute)
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// strftimeLayouts maps strftime directives to their go layout equivalent
+var strftimeLayouts = map[byte]string{
+	'a': "Mon",
+	'A': "Monday",
+	'b': "Jan",
+	'B': "January",
+	'd': "02",
+	'e': "_2",
+	'f': ".000000",
+	'H': "15",
+	'I': "03",
+	'j': "002",
+	'm': "01",
+	'M': "04",
+	'p': "PM",
+	'S': "05",
+	'y': "06",
+	'Y': "2006",
+	'z': "-0700",
+	'Z': "MST",
+	'c': "Mon Jan _2 15:04:05 2006",
+	'x': "01/02/06",
+	'X': "15:04:05",
+	'D': "01/02/06",
+	'F': "2006-01-02",
+	'R': "15:04",
+	'T': "15:04:05",
+	'h': "Jan",
+}
+
+// Strftime formats a time using python's strftime directives
+func Strftime(t time.Time, format string) (string, error) {
+	var out strings.Builder
+	for index := 0; index < len(format); index++ {
+		if format[index] != '%' {
+			out.WriteByte(format[index])
+			continue
+		}
+		index++
+		if index >= len(format) {
+			return "", fmt.Errorf("incomplete directive at the end of '%s'", format)
+		}
+		directive := format[index]
+		if layout, ok := strftimeLayouts[directive]; ok {
+			formatted := t.Format(layout)
+			if directive == 'f' {
+				formatted = formatted[1:]
+			}
+			out.WriteString(formatted)
+			continue
+		}
+		switch directive {
+		case '%':
+			out.WriteByte('%')
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'C':
+			out.WriteString(fmt.Sprintf("%02d", t.Year()/100))
+		case 'k':
+			out.WriteString(fmt.Sprintf("%2d", t.Hour()))
+		case 'l':
+			hour := t.Hour() % 12
+			if hour == 0 {
+				hour = 12
+			}
+			out.WriteString(fmt.Sprintf("%2d", hour))
+		case 'P':
+			out.WriteString(t.Format("pm"))
+		case 's':
+			out.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'u':
+			weekday := int(t.Weekday())
+			if weekday == 0 {
+				weekday = 7
+			}
+			out.WriteString(strconv.Itoa(weekday))
+		case 'w':
+			out.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'U':
+			out.WriteString(fmt.Sprintf("%02d", (t.YearDay()+6-int(t.Weekday()))/7))
+		case 'W':
+			out.WriteString(fmt.Sprintf("%02d", (t.YearDay()+6-(int(t.Weekday())+6)%7)/7))
+		case 'G':
+			year, _ := t.ISOWeek()
+			out.WriteString(strconv.Itoa(year))
+		case 'V':
+			_, week := t.ISOWeek()
+			out.WriteString(fmt.Sprintf("%02d", week))
+		default:
+			return "", fmt.Errorf("unsupported directive '%%%c'", directive)
+		}
+	}
+	return out.String(), nil
+}
+
+// StrftimeLayout translates a format made of python's strftime directives into a go layout
+// that can be used to parse times. Only directives that have a go layout equivalent are supported.
+func StrftimeLayout(format string) (string, error) {
+	var out strings.Builder
+	for index := 0; index < len(format); index++ {
+		if format[index] != '%' {
+			out.WriteByte(format[index])
+			continue
+		}
+		index++
+		if index >= len(format) {
+			return "", fmt.Errorf("incomplete directive at the end of '%s'", format)
+		}
+		directive := format[index]
+		if directive == '%' {
+			out.WriteByte('%')
+			continue
+		}
+		layout, ok := strftimeLayouts[directive]
+		if !ok {
+			return "", fmt.Errorf("unsupported directive '%%%c'", directive)
+		}
+		if directive == 'f' {
+			layout = layout[1:]
+		}
+		out.WriteString(layout)
+	}
+	return out.String(), nil
+}